@@ -68,6 +68,8 @@ install -m 664 "${COPY_SOURCE}/install/lib/systemd/system/samba_statusd.service"
 install -d -m 775 "${PACKAGE_ROOT}/etc/default"
 install -m 664 "${COPY_SOURCE}/install/etc/default/samba_exporter" "${PACKAGE_ROOT}/etc/default/samba_exporter"
 install -m 664 "${COPY_SOURCE}/install/etc/default/samba_statusd" "${PACKAGE_ROOT}/etc/default/samba_statusd"
+install -d -m 775 "${PACKAGE_ROOT}/usr/share/dbus-1/system.d"
+install -m 664 "${COPY_SOURCE}/install/usr/share/dbus-1/system.d/de.backfrak.tobi.SambaStatusd.conf" "${PACKAGE_ROOT}/usr/share/dbus-1/system.d/de.backfrak.tobi.SambaStatusd.conf"
 install -d -m 775 "${PACKAGE_ROOT}/usr/share/doc/samba-exporter/grafana"
 install -m 664 "${COPY_SOURCE}/README.md" "${PACKAGE_ROOT}/usr/share/doc/samba-exporter/README.md"
 install -m 664 "${COPY_SOURCE}/src/example/grafana/SambaService.json" "${PACKAGE_ROOT}/usr/share/doc/samba-exporter/grafana/SambaService.json"
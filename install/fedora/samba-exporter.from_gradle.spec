@@ -86,6 +86,7 @@ fi
 "/usr/bin/samba_exporter"
 "/usr/bin/samba_statusd"
 "/usr/bin/start_samba_statusd"
+"/usr/share/dbus-1/system.d/de.backfrak.tobi.SambaStatusd.conf"
 %dir "/usr/share/"
 %docdir "/usr/share/doc/"
 %docdir "/usr/share/doc/samba-exporter/"
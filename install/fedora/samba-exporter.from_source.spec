@@ -26,6 +26,7 @@ BuildRequires:  golang(github.com/prometheus/client_golang/prometheus)
 BuildRequires:  golang(github.com/prometheus/client_golang/prometheus/collectors)
 BuildRequires:  golang(github.com/prometheus/client_golang/prometheus/promhttp)
 BuildRequires:  golang(github.com/prometheus/client_model/go)
+BuildRequires:  golang(github.com/prometheus/common/expfmt)
 BuildRequires:  golang(golang.org/x/sys/unix)
 BuildRequires:  golang(gopkg.in/alecthomas/kingpin.v2)
 BuildRequires:  golang(github.com/shirou/gopsutil)
@@ -35,6 +36,12 @@ BuildRequires:  golang(github.com/tklauser/numcpus)
 BuildRequires:  golang(golang.org/x/xerrors)
 BuildRequires:  golang(gopkg.in/check.v1)
 BuildRequires:  golang(gopkg.in/yaml.v3) 
+BuildRequires:  golang(google.golang.org/grpc)
+BuildRequires:  golang(github.com/godbus/dbus/v5)
+BuildRequires:  golang(github.com/oschwald/maxminddb-golang)
+BuildRequires:  golang(github.com/golang/snappy)
+BuildRequires:  golang(google.golang.org/protobuf/proto)
+BuildRequires:  golang(golang.org/x/sync/singleflight)
 BuildRequires:  rubygem-ronn-ng
 BuildRequires:  procps-ng
 
@@ -77,7 +84,10 @@ export GOPATH="$GOPATH:%{gobuilddir}/src/:/usr/share/gocode/"
 %gotest tobi.backfrak.de/internal/smbexporterbl/smbstatusreader
 %gotest tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator
 %gotest tobi.backfrak.de/internal/commonbl
+%gotest tobi.backfrak.de/internal/configfile
 %gotest tobi.backfrak.de/internal/smbstatusdbl 
+%gotest tobi.backfrak.de/internal/statusdrpc
+%gotest tobi.backfrak.de/pkg/sambacollector
 
 %pre
 if [ $1 == 2 ];then
@@ -151,6 +161,7 @@ fi
 "/usr/bin/samba_exporter"
 "/usr/bin/samba_statusd"
 "/usr/bin/start_samba_statusd"
+"/usr/share/dbus-1/system.d/de.backfrak.tobi.SambaStatusd.conf"
 %dir "/usr/share/"
 %docdir "/usr/share/doc/"
 %docdir "/usr/share/doc/samba-exporter/"
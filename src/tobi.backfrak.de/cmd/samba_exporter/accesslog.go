@@ -0,0 +1,65 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// statusRecorder - A http.ResponseWriter keeping the status code the handler answered with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader - Keep the status code and send the header
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// Write - Write the body, the status code is 200 when no header was sent before
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(data)
+}
+
+// Unwrap - Get the wrapped http.ResponseWriter, so http.ResponseController can use it
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+// logAccess - Wrap the handler, so each request is logged with the method, path, client IP, status code and duration once it is handled
+func logAccess(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := statusRecorder{ResponseWriter: w}
+		handler.ServeHTTP(&recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		logger.WriteInformation(fmt.Sprintf("Access: %s %s from %s answered with %d in %s",
+			r.Method, r.URL.Path, getClientIP(r), recorder.status, time.Since(start).Round(time.Microsecond)))
+	})
+}
+
+// getClientIP - Get the IP address of the client of the request. Requests on a unix socket have no address
+func getClientIP(r *http.Request) string {
+	host, _, errSplit := net.SplitHostPort(r.RemoteAddr)
+	if errSplit != nil || host == "" {
+		return "unix socket"
+	}
+
+	return host
+}
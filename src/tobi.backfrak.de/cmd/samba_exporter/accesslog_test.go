@@ -0,0 +1,68 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestLogAccess(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	testLogger := testhelper.NewTestLogger(false)
+	logger = testLogger
+	handler := logAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("samba_server_up 1\n"))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics?collect[]=samba", nil)
+	request.RemoteAddr = "10.1.0.42:51234"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "samba_server_up 1\n" {
+		t.Errorf("Got status code '%d' and body '%s', but expected the ones of the handler", recorder.Code, recorder.Body.String())
+	}
+
+	request = httptest.NewRequest(http.MethodHead, "/missing", nil)
+	request.RemoteAddr = "[fd00::5]:51234"
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if testLogger.GetMessageCount() != 2 {
+		t.Fatalf("Got '%d' messages, but expected one for each request", testLogger.GetMessageCount())
+	}
+	expected := []string{"Access: GET /metrics from 10.1.0.42 answered with 200 in ", "Access: HEAD /missing from fd00::5 answered with 404 in "}
+	for i, message := range testLogger.WrittenMessages {
+		if !strings.Contains(message, expected[i]) {
+			t.Errorf("Got the message '%s', but expected it to contain '%s'", message, expected[i])
+		}
+	}
+}
+
+func TestGetClientIP(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	cases := map[string]string{
+		"10.1.0.42:51234": "10.1.0.42",
+		"[fd00::5]:51234": "fd00::5",
+		"@":               "unix socket",
+		"":                "unix socket",
+	}
+	for remoteAddr, expected := range cases {
+		request.RemoteAddr = remoteAddr
+		if getClientIP(request) != expected {
+			t.Errorf("Got the client IP '%s' for '%s', but expected '%s'", getClientIP(request), remoteAddr, expected)
+		}
+	}
+}
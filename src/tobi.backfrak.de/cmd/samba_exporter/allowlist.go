@@ -0,0 +1,74 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// getAllowedCIDRs - Get the networks of the -web.allowed-cidrs, nil when no networks are given. Single addresses are
+// taken as networks with only this address
+func getAllowedCIDRs() ([]netip.Prefix, error) {
+	if strings.TrimSpace(params.AllowedCIDRs) == "" {
+		return nil, nil
+	}
+	if params.ListenSocket != "" {
+		return nil, fmt.Errorf("The parameter -web.allowed-cidrs can not be combined with -web.listen-socket, the clients of a unix socket have no address")
+	}
+
+	var ret []netip.Prefix
+	for _, value := range strings.Split(params.AllowedCIDRs, ",") {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			addr, errAddr := netip.ParseAddr(value)
+			if errAddr != nil {
+				return nil, fmt.Errorf("The network \"%s\" is neither a CIDR like '192.168.1.0/24' nor an IP address", value)
+			}
+			ret = append(ret, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, errPrefix := netip.ParsePrefix(value)
+		if errPrefix != nil {
+			return nil, fmt.Errorf("The network \"%s\" is neither a CIDR like '192.168.1.0/24' nor an IP address", value)
+		}
+		ret = append(ret, prefix.Masked())
+	}
+
+	return ret, nil
+}
+
+// allowCIDRs - Wrap the handler, so only requests from clients in one of the networks are handled. Other requests are answered with 403
+func allowCIDRs(networks []netip.Prefix, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAddressAllowed(r.RemoteAddr, networks) {
+			logger.WriteVerbose(fmt.Sprintf("Reject the request of '%s' to '%s', the client is not in the -web.allowed-cidrs", r.RemoteAddr, r.URL.Path))
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// isAddressAllowed - Tell if the remote address of a request, in the format 'host:port', is in one of the networks
+func isAddressAllowed(remoteAddr string, networks []netip.Prefix) bool {
+	addrPort, errParse := netip.ParseAddrPort(remoteAddr)
+	if errParse != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+
+	for _, network := range networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
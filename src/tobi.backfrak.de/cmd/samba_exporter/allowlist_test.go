@@ -0,0 +1,111 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestGetAllowedCIDRs(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.AllowedCIDRs = ""
+	networks, err := getAllowedCIDRs()
+	if err != nil || networks != nil {
+		t.Errorf("Got the networks '%v' and error '%v', but expected none", networks, err)
+	}
+
+	params.AllowedCIDRs = "10.1.0.17/24, 192.168.1.5,fd00::/8"
+	networks, err = getAllowedCIDRs()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	expected := []string{"10.1.0.0/24", "192.168.1.5/32", "fd00::/8"}
+	if len(networks) != len(expected) {
+		t.Fatalf("Got '%d' networks but expected '%d'", len(networks), len(expected))
+	}
+	for i, network := range networks {
+		if network.String() != expected[i] {
+			t.Errorf("Got the network '%s' but expected '%s'", network.String(), expected[i])
+		}
+	}
+
+	for _, value := range []string{"10.1.0.0/33", "fileserver", "10.1.0.0/24,"} {
+		params.AllowedCIDRs = value
+		_, err = getAllowedCIDRs()
+		if err == nil {
+			t.Errorf("Got no error for '%s', but expected one", value)
+		}
+	}
+}
+
+func TestIsAddressAllowed(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.AllowedCIDRs = "10.1.0.0/24,::1"
+	networks, err := getAllowedCIDRs()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	cases := map[string]bool{
+		"10.1.0.42:51234":          true,
+		"[::ffff:10.1.0.42]:51234": true,
+		"[::1]:51234":              true,
+		"10.1.1.42:51234":          false,
+		"127.0.0.1:51234":          false,
+		"broken":                   false,
+	}
+	for remoteAddr, expected := range cases {
+		if isAddressAllowed(remoteAddr, networks) != expected {
+			t.Errorf("The address '%s' is allowed: %t, but expected %t", remoteAddr, !expected, expected)
+		}
+	}
+}
+
+func TestAllowCIDRs(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	logger = testhelper.NewTestLogger(true)
+	params.AllowedCIDRs = "10.1.0.0/24"
+	networks, err := getAllowedCIDRs()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	called := 0
+	handler := allowCIDRs(networks, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.RemoteAddr = "10.1.0.42:51234"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || called != 1 {
+		t.Errorf("Got status code '%d' and '%d' calls, but expected '%d' and '1'", recorder.Code, called, http.StatusOK)
+	}
+
+	request.RemoteAddr = "192.168.1.5:51234"
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusForbidden || called != 1 {
+		t.Errorf("Got status code '%d' and '%d' calls, but expected '%d' and no further call", recorder.Code, called, http.StatusForbidden)
+	}
+}
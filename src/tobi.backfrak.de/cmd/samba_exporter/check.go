@@ -0,0 +1,140 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/statusdrpc"
+)
+
+// SUBCOMMAND_CHECK - The subcommand checking the samba server once, as plugin of Nagios or Icinga
+const SUBCOMMAND_CHECK = "check"
+
+// The exit codes of a Nagios plugin
+const (
+	NAGIOS_OK       = 0
+	NAGIOS_WARNING  = 1
+	NAGIOS_CRITICAL = 2
+	NAGIOS_UNKNOWN  = 3
+)
+
+// The values the check subcommand compares with the thresholds
+const (
+	CHECK_VALUE_SESSIONS = "sessions"
+	CHECK_VALUE_LOCKS    = "locks"
+)
+
+// The values of the check subcommand, in the order they are printed
+var checkValues = []string{CHECK_VALUE_SESSIONS, CHECK_VALUE_LOCKS}
+
+// The names of the states of a Nagios plugin, by exit code
+var nagiosStates = map[int]string{NAGIOS_OK: "OK", NAGIOS_WARNING: "WARNING", NAGIOS_CRITICAL: "CRITICAL", NAGIOS_UNKNOWN: "UNKNOWN"}
+
+// checkThresholds - The highest accepted number of each value. Values without a threshold are not compared
+type checkThresholds map[string]int
+
+// parseCheckThresholds - Parse the thresholds given as 'key=value' pairs separated by ',', like 'sessions=100,locks=1000'
+func parseCheckThresholds(value string) (checkThresholds, error) {
+	ret := make(checkThresholds)
+	if strings.TrimSpace(value) == "" {
+		return ret, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, threshold, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return nil, fmt.Errorf("the threshold '%s' is not given as 'key=value'", pair)
+		}
+		if name != CHECK_VALUE_SESSIONS && name != CHECK_VALUE_LOCKS {
+			return nil, fmt.Errorf("the threshold '%s' is not known, use '%s'", name, strings.Join(checkValues, "' or '"))
+		}
+		number, errNumber := strconv.Atoi(threshold)
+		if errNumber != nil || number < 0 {
+			return nil, fmt.Errorf("the threshold '%s' of '%s' is not a positive number", threshold, name)
+		}
+		ret[name] = number
+	}
+
+	return ret, nil
+}
+
+// runCheck - Request the samba status once, compare the number of sessions and locks with the -warn and -crit thresholds given in args
+// and print the result with performance data to out. Returns the exit code of the Nagios plugin
+func runCheck(args []string, requestHandler commonbl.MessageHandler, responseHandler commonbl.MessageHandler, grpcClient statusdrpc.SambaStatusClient, out io.Writer) int {
+	flags := flag.NewFlagSet(SUBCOMMAND_CHECK, flag.ContinueOnError)
+	warn := flags.String("warn", "", "The thresholds of the warning state, like 'sessions=100,locks=1000'")
+	crit := flags.String("crit", "", "The thresholds of the critical state, like 'sessions=200,locks=5000'")
+	errParse := flags.Parse(args)
+	if errParse != nil {
+		fmt.Fprintln(out, fmt.Sprintf("SAMBA UNKNOWN - %s", errParse.Error()))
+		return NAGIOS_UNKNOWN
+	}
+	warnThresholds, errWarn := parseCheckThresholds(*warn)
+	if errWarn != nil {
+		fmt.Fprintln(out, fmt.Sprintf("SAMBA UNKNOWN - -warn: %s", errWarn.Error()))
+		return NAGIOS_UNKNOWN
+	}
+	critThresholds, errCrit := parseCheckThresholds(*crit)
+	if errCrit != nil {
+		fmt.Fprintln(out, fmt.Sprintf("SAMBA UNKNOWN - -crit: %s", errCrit.Error()))
+		return NAGIOS_UNKNOWN
+	}
+
+	locks, processes, _, _, errGet := getSambaStatus(requestHandler, responseHandler, grpcClient)
+	if errGet != nil {
+		fmt.Fprintln(out, fmt.Sprintf("SAMBA CRITICAL - can not get the status from samba_statusd: %s", strings.TrimSpace(errGet.Error())))
+		return NAGIOS_CRITICAL
+	}
+
+	values := map[string]int{CHECK_VALUE_SESSIONS: len(processes), CHECK_VALUE_LOCKS: len(params.ShareFilter.FilterLockData(locks))}
+	state, output := getCheckResult(values, warnThresholds, critThresholds)
+	fmt.Fprintln(out, output)
+
+	return state
+}
+
+// getCheckResult - Get the state of the values compared with the thresholds, and the plugin output like
+// 'SAMBA OK - 3 sessions, 12 locks | sessions=3;100;200;0 locks=12;;;0'
+func getCheckResult(values map[string]int, warn checkThresholds, crit checkThresholds) (int, string) {
+	state := NAGIOS_OK
+	var messages []string
+	var perfData []string
+	for _, name := range checkValues {
+		value := values[name]
+		message := fmt.Sprintf("%d %s", value, name)
+		critThreshold, foundCrit := crit[name]
+		warnThreshold, foundWarn := warn[name]
+		if foundCrit && value > critThreshold {
+			state = NAGIOS_CRITICAL
+			message = fmt.Sprintf("%s (critical at %d)", message, critThreshold)
+		} else if foundWarn && value > warnThreshold {
+			if state == NAGIOS_OK {
+				state = NAGIOS_WARNING
+			}
+			message = fmt.Sprintf("%s (warning at %d)", message, warnThreshold)
+		}
+		messages = append(messages, message)
+		perfData = append(perfData, fmt.Sprintf("%s=%d;%s;%s;0", name, value, getPerfDataThreshold(warn, name), getPerfDataThreshold(crit, name)))
+	}
+
+	return state, fmt.Sprintf("SAMBA %s - %s | %s", nagiosStates[state], strings.Join(messages, ", "), strings.Join(perfData, " "))
+}
+
+// getPerfDataThreshold - Get the threshold of the value for the performance data, empty when there is none
+func getPerfDataThreshold(thresholds checkThresholds, name string) string {
+	threshold, found := thresholds[name]
+	if !found {
+		return ""
+	}
+
+	return strconv.Itoa(threshold)
+}
@@ -0,0 +1,91 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestParseCheckThresholds(t *testing.T) {
+	thresholds, err := parseCheckThresholds("sessions=100, locks=1000")
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(thresholds) != 2 || thresholds[CHECK_VALUE_SESSIONS] != 100 || thresholds[CHECK_VALUE_LOCKS] != 1000 {
+		t.Errorf("The thresholds %v are not expected", thresholds)
+	}
+
+	thresholds, err = parseCheckThresholds("")
+	if err != nil || len(thresholds) != 0 {
+		t.Errorf("Got the thresholds %v and error '%v', but expected none", thresholds, err)
+	}
+
+	for _, invalid := range []string{"sessions", "shares=1", "locks=many", "locks=-1"} {
+		_, err = parseCheckThresholds(invalid)
+		if err == nil {
+			t.Errorf("Got no error for the thresholds '%s', but expected one", invalid)
+		}
+	}
+}
+
+func TestGetCheckResult(t *testing.T) {
+	warn := checkThresholds{CHECK_VALUE_SESSIONS: 100, CHECK_VALUE_LOCKS: 1000}
+	crit := checkThresholds{CHECK_VALUE_SESSIONS: 200}
+
+	state, output := getCheckResult(map[string]int{CHECK_VALUE_SESSIONS: 3, CHECK_VALUE_LOCKS: 12}, warn, crit)
+	if state != NAGIOS_OK || output != "SAMBA OK - 3 sessions, 12 locks | sessions=3;100;200;0 locks=12;1000;;0" {
+		t.Errorf("Got the state %d and output '%s', which are not expected", state, output)
+	}
+
+	state, output = getCheckResult(map[string]int{CHECK_VALUE_SESSIONS: 3, CHECK_VALUE_LOCKS: 1001}, warn, crit)
+	if state != NAGIOS_WARNING || !strings.HasPrefix(output, "SAMBA WARNING - 3 sessions, 1001 locks (warning at 1000) |") {
+		t.Errorf("Got the state %d and output '%s', which are not expected", state, output)
+	}
+
+	state, output = getCheckResult(map[string]int{CHECK_VALUE_SESSIONS: 201, CHECK_VALUE_LOCKS: 1001}, warn, crit)
+	if state != NAGIOS_CRITICAL || !strings.HasPrefix(output, "SAMBA CRITICAL - 201 sessions (critical at 200), 1001 locks (warning at 1000) |") {
+		t.Errorf("Got the state %d and output '%s', which are not expected", state, output)
+	}
+}
+
+func TestRunCheckStatusdNotReachable(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.RequestTimeOut = 1
+	logger = testhelper.NewTestLogger(true)
+	// Nothing listens on the port, so the requests fail
+	conn, client, errClient := pipecomunication.NewGrpcClient("127.0.0.1:1", nil)
+	if errClient != nil {
+		t.Fatalf("Got error '%s' but expected none", errClient.Error())
+	}
+	defer conn.Close()
+
+	var out bytes.Buffer
+	state := runCheck([]string{"-warn", "sessions=100"}, nil, nil, client, &out)
+	if state != NAGIOS_CRITICAL || !strings.HasPrefix(out.String(), "SAMBA CRITICAL - can not get the status from samba_statusd") {
+		t.Errorf("Got the state %d and output '%s', which are not expected", state, out.String())
+	}
+}
+
+func TestRunCheckInvalidThresholds(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	state := runCheck([]string{"-crit", "users=1"}, nil, nil, nil, &out)
+	if state != NAGIOS_UNKNOWN || !strings.HasPrefix(out.String(), "SAMBA UNKNOWN - -crit:") {
+		t.Errorf("Got the state %d and output '%s', which are not expected", state, out.String())
+	}
+}
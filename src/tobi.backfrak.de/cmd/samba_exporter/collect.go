@@ -0,0 +1,63 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+)
+
+// SUBCOMMAND_COLLECT - The subcommand printing the metrics once, e. g. for the exec input of Telegraf
+const SUBCOMMAND_COLLECT = "collect"
+
+// The output formats of the collect subcommand
+const (
+	COLLECT_FORMAT_JSON   = "json"
+	COLLECT_FORMAT_INFLUX = "influx"
+)
+
+// runCollect - Gather the metrics once and print them to out, in the format given by the -format in args. Returns the exit code of the program
+func runCollect(args []string, gatherer prometheus.Gatherer, out io.Writer) int {
+	flags := flag.NewFlagSet(SUBCOMMAND_COLLECT, flag.ContinueOnError)
+	format := flags.String("format", COLLECT_FORMAT_JSON,
+		fmt.Sprintf("The format the metrics are printed in, '%s' or '%s' for the InfluxDB line protocol", COLLECT_FORMAT_JSON, COLLECT_FORMAT_INFLUX))
+	errParse := flags.Parse(args)
+	if errParse != nil {
+		// The flag set already printed the error and its usage
+		return -21
+	}
+	if *format != COLLECT_FORMAT_JSON && *format != COLLECT_FORMAT_INFLUX {
+		logger.WriteError(fmt.Errorf("The parameter -format '%s' of the %s subcommand is not known, use '%s' or '%s'", *format, SUBCOMMAND_COLLECT, COLLECT_FORMAT_JSON, COLLECT_FORMAT_INFLUX))
+		return -21
+	}
+
+	families, errGather := gatherer.Gather()
+	if errGather != nil {
+		logger.WriteErrorWithAddition(errGather, "while collecting the metrics")
+		return -21
+	}
+
+	if *format == COLLECT_FORMAT_INFLUX {
+		fmt.Fprint(out, strings.Join(smbexporter.GetInfluxLines(families, time.Now()), ""))
+		return 0
+	}
+
+	data, errMarshal := json.Marshal(smbexporter.GetJSONMetrics(families))
+	if errMarshal != nil {
+		logger.WriteErrorWithAddition(errMarshal, "while encoding the metrics")
+		return -21
+	}
+	fmt.Fprintln(out, string(data))
+
+	return 0
+}
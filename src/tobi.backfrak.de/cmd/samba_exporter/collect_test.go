@@ -0,0 +1,88 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// getCollectTestRegistry - Get a registry with the gauge samba_server_up{cluster="a"} 1
+func getCollectTestRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "samba_server_up", Help: "Test gauge"}, []string{"cluster"})
+	gauge.WithLabelValues("a").Set(1)
+	registry.MustRegister(gauge)
+
+	return registry
+}
+
+func TestRunCollectJSON(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	exitCode := runCollect([]string{}, getCollectTestRegistry(), &out)
+	if exitCode != 0 {
+		t.Fatalf("Got exit code %d but expected 0", exitCode)
+	}
+
+	var metrics []map[string]interface{}
+	errDecode := json.Unmarshal(out.Bytes(), &metrics)
+	if errDecode != nil {
+		t.Fatalf("Got error '%s' but expected none", errDecode.Error())
+	}
+	if len(metrics) != 1 || metrics[0]["name"] != "samba_server_up" || metrics[0]["cluster"] != "a" || metrics[0]["value"] != float64(1) {
+		t.Errorf("The metrics %v are not expected", metrics)
+	}
+}
+
+func TestRunCollectInflux(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	exitCode := runCollect([]string{"-format", "influx"}, getCollectTestRegistry(), &out)
+	if exitCode != 0 {
+		t.Fatalf("Got exit code %d but expected 0", exitCode)
+	}
+	if !strings.HasPrefix(out.String(), "samba_server_up,cluster=a value=1 ") {
+		t.Errorf("The output '%s' is not expected", out.String())
+	}
+}
+
+func TestRunCollectInvalidFormat(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	exitCode := runCollect([]string{"-format", "xml"}, getCollectTestRegistry(), &out)
+	if exitCode != -21 {
+		t.Errorf("Got exit code %d but expected -21", exitCode)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Got the output '%s' but expected none", out.String())
+	}
+}
+
+func TestRunSubcommandUnknown(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	exitCode := runSubcommand([]string{"serve"}, getListMetricsTestExporter())
+	if exitCode != -21 {
+		t.Errorf("Got exit code %d but expected -21", exitCode)
+	}
+}
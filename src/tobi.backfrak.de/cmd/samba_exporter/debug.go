@@ -0,0 +1,79 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+)
+
+// The path the last raw responses of samba_statusd are served on, when a -web.debug-token-file is given
+const DEBUG_RAW_PATH = "/debug/raw"
+
+// The smbstatus calls samba_statusd answers the requests with, used as description of the raw responses
+var debugRawCommands = map[commonbl.RequestType]string{
+	commonbl.PROCESS_REQUEST: "smbstatus -p -n",
+	commonbl.SHARE_REQUEST:   "smbstatus -S -n",
+	commonbl.LOCK_REQUEST:    "smbstatus -L -n",
+	commonbl.PS_REQUEST:      "ps data of the smbd processes as JSON",
+}
+
+// debugRawHandler - Get the handler answering with the last raw responses of samba_statusd as text.
+// Only requests with the header 'Authorization: Bearer <token>' are answered
+func debugRawHandler(token []byte) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !isDebugRequestAuthorized(request, token) {
+			writer.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if request.Method != http.MethodGet && request.Method != http.MethodHead {
+			writer.Header().Set("Allow", "GET, HEAD")
+			http.Error(writer, fmt.Sprintf("The method %s is not allowed", request.Method), http.StatusMethodNotAllowed)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writer.Write([]byte(getDebugRawText(pipecomunication.GetLastRawResponses())))
+	})
+}
+
+// isDebugRequestAuthorized - Tells if the request has the bearer token in its Authorization header
+func isDebugRequestAuthorized(request *http.Request, token []byte) bool {
+	requestToken, found := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if !found || len(token) == 0 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(requestToken), token) == 1
+}
+
+// getDebugRawText - Get the text the raw responses are served with. Each response starts with a header line naming
+// the request and the time it was received, followed by the data exactly as samba_statusd sent it
+func getDebugRawText(responses []pipecomunication.RawResponse) string {
+	if len(responses) == 0 {
+		return "# No response received from samba_statusd yet\n"
+	}
+
+	var builder strings.Builder
+	for _, response := range responses {
+		builder.WriteString(fmt.Sprintf("# %s (%s) received %s\n", strings.TrimSuffix(string(response.Request), ":"),
+			debugRawCommands[response.Request], response.Received.UTC().Format(time.RFC3339)))
+		builder.WriteString(response.Data)
+		if !strings.HasSuffix(response.Data, "\n") {
+			builder.WriteString("\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
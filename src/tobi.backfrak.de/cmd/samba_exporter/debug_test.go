@@ -0,0 +1,86 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+)
+
+func TestDebugRawHandlerAuthorization(t *testing.T) {
+	handler := debugRawHandler([]byte("debug-token"))
+	for _, authorization := range []string{"", "Bearer wrong-token", "Basic debug-token", "debug-token"} {
+		request := httptest.NewRequest(http.MethodGet, DEBUG_RAW_PATH, nil)
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusUnauthorized || recorder.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("Got the status %d for the Authorization '%s', but expected %d", recorder.Code, authorization, http.StatusUnauthorized)
+		}
+	}
+
+	request := httptest.NewRequest(http.MethodGet, DEBUG_RAW_PATH, nil)
+	request.Header.Set("Authorization", "Bearer debug-token")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Got the status %d but expected %d", recorder.Code, http.StatusOK)
+	}
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Got the content type '%s' but expected 'text/plain'", recorder.Header().Get("Content-Type"))
+	}
+
+	request = httptest.NewRequest(http.MethodPost, DEBUG_RAW_PATH, nil)
+	request.Header.Set("Authorization", "Bearer debug-token")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Got the status %d but expected %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestGetDebugRawText(t *testing.T) {
+	if text := getDebugRawText(nil); !strings.Contains(text, "No response") {
+		t.Errorf("Got '%s' but expected a note that no response was received", text)
+	}
+
+	received := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	text := getDebugRawText([]pipecomunication.RawResponse{
+		{Request: commonbl.LOCK_REQUEST, Received: received, Data: commonbl.TestLockResponse},
+	})
+	expectedHeader := "# LOCK_REQUEST (smbstatus -L -n) received 2024-03-01T10:30:00Z\n"
+	if !strings.HasPrefix(text, expectedHeader) {
+		t.Errorf("The text '%s' does not start with '%s'", text, expectedHeader)
+	}
+	if !strings.Contains(text, commonbl.TestLockResponse) {
+		t.Errorf("The text '%s' does not contain the raw lock response", text)
+	}
+}
+
+func TestMainWithMissingDebugTokenFile(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.Test = true
+	params.TestPipeMode = true
+	params.DebugTokenFile = "/not/existing/debug-token"
+
+	res := realMain()
+	if res != -23 {
+		t.Errorf("Got %d from main, but expected -23", res)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+)
+
+// handlerMetrics - The metrics about the requests to the metrics endpoint, so the scrapes of the exporter itself can be monitored
+type handlerMetrics struct {
+	inFlight     prometheus.Gauge
+	duration     *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+}
+
+// newHandlerMetrics - Get the handlerMetrics registered with the registerer. The constLabels are added to every metric
+func newHandlerMetrics(registerer prometheus.Registerer, constLabels map[string]string) (*handlerMetrics, error) {
+	metrics := handlerMetrics{
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   smbexporter.EXPORTER_LABEL_PREFIX,
+			Name:        "exporter_http_requests_in_flight",
+			Help:        "Number of requests to the metrics endpoint currently handled",
+			ConstLabels: constLabels,
+		}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   smbexporter.EXPORTER_LABEL_PREFIX,
+			Name:        "exporter_http_request_duration_seconds",
+			Help:        "Time it took to handle a request to the metrics endpoint in seconds",
+			Buckets:     prometheus.DefBuckets,
+			ConstLabels: constLabels,
+		}, []string{"code"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   smbexporter.EXPORTER_LABEL_PREFIX,
+			Name:        "exporter_http_response_size_bytes",
+			Help:        "Size of the responses of the metrics endpoint in bytes",
+			Buckets:     prometheus.ExponentialBuckets(256, 4, 8),
+			ConstLabels: constLabels,
+		}, []string{"code"}),
+	}
+
+	for _, collector := range []prometheus.Collector{metrics.inFlight, metrics.duration, metrics.responseSize} {
+		errRegister := registerer.Register(collector)
+		if errRegister != nil {
+			return nil, errRegister
+		}
+	}
+
+	return &metrics, nil
+}
+
+// instrument - Wrap the handler, so the requests it handles are counted in the handlerMetrics
+func (metrics *handlerMetrics) instrument(handler http.Handler) http.Handler {
+	return promhttp.InstrumentHandlerInFlight(metrics.inFlight,
+		promhttp.InstrumentHandlerDuration(metrics.duration,
+			promhttp.InstrumentHandlerResponseSize(metrics.responseSize, handler)))
+}
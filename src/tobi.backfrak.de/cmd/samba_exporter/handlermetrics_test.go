@@ -0,0 +1,61 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHandlerMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics, errNew := newHandlerMetrics(registry, map[string]string{"datacenter": "fra1"})
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+
+	handler := metrics.instrument(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("samba_server_up 1\n"))
+	}))
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	}
+
+	families, errGather := registry.Gather()
+	if errGather != nil {
+		t.Fatalf("Got error '%s' but expected none", errGather.Error())
+	}
+
+	found := map[string]bool{}
+	for _, family := range families {
+		found[family.GetName()] = true
+		for _, metric := range family.GetMetric() {
+			if metric.GetHistogram() != nil && metric.GetHistogram().GetSampleCount() != 3 {
+				t.Errorf("Got '%d' samples for '%s', but expected '3'", metric.GetHistogram().GetSampleCount(), family.GetName())
+			}
+			if metric.GetGauge() != nil && metric.GetGauge().GetValue() != 0 {
+				t.Errorf("Got '%f' requests in flight, but expected '0'", metric.GetGauge().GetValue())
+			}
+			if len(metric.GetLabel()) == 0 || metric.GetLabel()[0].GetName() != "code" && metric.GetLabel()[0].GetName() != "datacenter" {
+				t.Errorf("The metric '%s' has not the expected labels", family.GetName())
+			}
+		}
+	}
+
+	for _, name := range []string{"samba_exporter_http_requests_in_flight", "samba_exporter_http_request_duration_seconds", "samba_exporter_http_response_size_bytes"} {
+		if !found[name] {
+			t.Errorf("The metric '%s' was not found", name)
+		}
+	}
+
+	_, errTwice := newHandlerMetrics(registry, nil)
+	if errTwice == nil {
+		t.Errorf("Got no error when registering the metrics twice, but expected one")
+	}
+}
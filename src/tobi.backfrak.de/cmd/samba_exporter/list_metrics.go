@@ -0,0 +1,77 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+)
+
+// SUBCOMMAND_LIST_METRICS - The subcommand listing the metrics the exporter exports, e. g. for the authors of dashboards
+const SUBCOMMAND_LIST_METRICS = "list-metrics"
+
+// The output formats of the list-metrics subcommand
+const (
+	LIST_METRICS_FORMAT_TEXT = "text"
+	LIST_METRICS_FORMAT_JSON = "json"
+)
+
+// runListMetrics - Print the name, type, help text and labels of the metrics the exporter exports with the given options to out,
+// in the format given by the -format in args. Does not need a running samba_statusd. Returns the exit code of the program
+func runListMetrics(args []string, exporter *smbexporter.SambaExporter, out io.Writer) int {
+	flags := flag.NewFlagSet(SUBCOMMAND_LIST_METRICS, flag.ContinueOnError)
+	format := flags.String("format", LIST_METRICS_FORMAT_TEXT,
+		fmt.Sprintf("The format the metrics are listed in, '%s' as table or '%s'", LIST_METRICS_FORMAT_TEXT, LIST_METRICS_FORMAT_JSON))
+	errParse := flags.Parse(args)
+	if errParse != nil {
+		// The flag set already printed the error and its usage
+		return -21
+	}
+	if *format != LIST_METRICS_FORMAT_TEXT && *format != LIST_METRICS_FORMAT_JSON {
+		logger.WriteError(fmt.Errorf("The parameter -format '%s' of the %s subcommand is not known, use '%s' or '%s'", *format, SUBCOMMAND_LIST_METRICS, LIST_METRICS_FORMAT_TEXT, LIST_METRICS_FORMAT_JSON))
+		return -21
+	}
+
+	infos, errInfos := exporter.GetMetricInfos()
+	if errInfos != nil {
+		logger.WriteErrorWithAddition(errInfos, "while listing the metrics")
+		return -21
+	}
+
+	if *format == LIST_METRICS_FORMAT_JSON {
+		data, errMarshal := json.Marshal(infos)
+		if errMarshal != nil {
+			logger.WriteErrorWithAddition(errMarshal, "while encoding the metrics")
+			return -21
+		}
+		fmt.Fprintln(out, string(data))
+		return 0
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tTYPE\tCOLLECTOR\tLABELS\tHELP")
+	for _, info := range infos {
+		fmt.Fprintln(writer, fmt.Sprintf("%s\t%s\t%s\t%s\t%s", info.Name, info.Type, getListMetricsColumn(info.Collector), getListMetricsColumn(strings.Join(info.Labels, ",")), info.Help))
+	}
+	writer.Flush()
+
+	return 0
+}
+
+// getListMetricsColumn - Get the value of a column of the table, '-' when it is empty
+func getListMetricsColumn(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
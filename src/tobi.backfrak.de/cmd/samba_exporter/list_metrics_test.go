@@ -0,0 +1,91 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// getListMetricsTestExporter - Get an exporter without connection to samba_statusd
+func getListMetricsTestExporter() *smbexporter.SambaExporter {
+	return smbexporter.NewSambaExporter(nil, nil, logger, "0.0.0", 1, statisticsGenerator.StatisticsGeneratorSettings{})
+}
+
+func TestRunListMetricsText(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	exitCode := runListMetrics([]string{}, getListMetricsTestExporter(), &out)
+	if exitCode != 0 {
+		t.Fatalf("Got exit code %d but expected 0", exitCode)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !strings.HasPrefix(lines[0], "NAME") || !strings.HasSuffix(lines[0], "HELP") {
+		t.Errorf("The header '%s' is not expected", lines[0])
+	}
+	found := false
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if fields[0] == "samba_server_up" {
+			found = true
+			if fields[1] != "gauge" || fields[3] != "-" {
+				t.Errorf("The line '%s' is not expected", line)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("The output '%s' does not list 'samba_server_up'", out.String())
+	}
+}
+
+func TestRunListMetricsJSON(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	exitCode := runListMetrics([]string{"-format", "json"}, getListMetricsTestExporter(), &out)
+	if exitCode != 0 {
+		t.Fatalf("Got exit code %d but expected 0", exitCode)
+	}
+
+	var infos []smbexporter.MetricInfo
+	errUnmarshal := json.Unmarshal(out.Bytes(), &infos)
+	if errUnmarshal != nil {
+		t.Fatalf("Got error '%s' but expected none", errUnmarshal.Error())
+	}
+	for _, info := range infos {
+		if info.Name == "samba_lock_created_at" {
+			if info.Type != "gauge" || strings.Join(info.Labels, ",") != "share,user" || info.Collector != "locks" {
+				t.Errorf("The metric %v is not expected", info)
+			}
+			return
+		}
+	}
+	t.Errorf("The output '%s' does not list 'samba_lock_created_at'", out.String())
+}
+
+func TestRunListMetricsInvalidFormat(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	exitCode := runListMetrics([]string{"-format", "yaml"}, getListMetricsTestExporter(), &out)
+	if exitCode != -21 || out.Len() != 0 {
+		t.Errorf("Got exit code %d and output '%s', but expected -21 and no output", exitCode, out.String())
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// getWebListeners - Listen for the clients of the web interface on the sockets passed by systemd socket activation when
+// given, otherwise on the -web.listen-socket when set or on all -web.listen-address. When tlsConfig is nil, no TLS is used
+func getWebListeners(tlsConfig *tls.Config) ([]net.Listener, error) {
+	activated, errActivated := commonbl.SdListeners()
+	if errActivated != nil {
+		return nil, errActivated
+	}
+	if len(activated) > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Use the %d sockets passed by systemd, the -web.listen-address and -web.listen-socket are ignored", len(activated)))
+		if tlsConfig == nil {
+			return activated, nil
+		}
+		listeners := make([]net.Listener, 0, len(activated))
+		for _, listener := range activated {
+			listeners = append(listeners, tls.NewListener(listener, tlsConfig))
+		}
+
+		return listeners, nil
+	}
+
+	if params.ListenSocket != "" {
+		listener, errListen := listenUnixSocket(params.ListenSocket)
+		if errListen != nil {
+			return nil, errListen
+		}
+		if tlsConfig != nil {
+			return []net.Listener{tls.NewListener(listener, tlsConfig)}, nil
+		}
+
+		return []net.Listener{listener}, nil
+	}
+
+	var listeners []net.Listener
+	for _, address := range getListenAddresses() {
+		listener, errListen := commonbl.ListenTcp(address, tlsConfig)
+		if errListen != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, errListen
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// getWebURLs - Get the comma separated URLs of the path on the listeners of the web interface, for the log messages.
+// A unix socket is given in the format of reverse proxies like nginx
+func getWebURLs(listeners []net.Listener, scheme string, path string) string {
+	var urls []string
+	for _, listener := range listeners {
+		if listener.Addr().Network() == "unix" {
+			urls = append(urls, fmt.Sprintf("%s://unix:%s:%s", scheme, listener.Addr().String(), path))
+			continue
+		}
+		urls = append(urls, fmt.Sprintf("%s://%s%s", scheme, listener.Addr().String(), path))
+	}
+
+	return strings.Join(urls, ", ")
+}
+
+// listenUnixSocket - Listen on the unix socket at the path. A socket left over by an exporter that did not exit clean is
+// removed before, other files at the path are not touched
+func listenUnixSocket(path string) (net.Listener, error) {
+	info, errStat := os.Lstat(path)
+	if errStat == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("The -web.listen-socket \"%s\" exists and is not a unix socket", path)
+		}
+		errRemove := os.Remove(path)
+		if errRemove != nil {
+			return nil, errRemove
+		}
+	} else if !os.IsNotExist(errStat) {
+		return nil, errStat
+	}
+
+	listener, errListen := net.Listen("unix", path)
+	if errListen != nil {
+		return nil, errListen
+	}
+	// Only the user and group of the exporter, e. g. a reverse proxy added to the group, can connect
+	errChmod := os.Chmod(path, 0660)
+	if errChmod != nil {
+		listener.Close()
+		return nil, errChmod
+	}
+
+	return listener, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestGetWebListenersSocket(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.ListenSocket = filepath.Join(t.TempDir(), "samba_exporter.sock")
+
+	listeners, errListen := getWebListeners(nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	if len(listeners) != 1 {
+		t.Fatalf("Got '%d' listeners but expected only the one of the socket", len(listeners))
+	}
+	listener := listeners[0]
+	server := http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("samba_server_up 1\n"))
+	})}
+	go server.Serve(listener)
+
+	info, errStat := os.Stat(params.ListenSocket)
+	if errStat != nil {
+		t.Fatalf("Got error '%s' but expected none", errStat.Error())
+	}
+	if info.Mode().Perm() != 0660 {
+		t.Errorf("Got the permissions '%s' for the socket, but expected '-rw-rw----'", info.Mode().Perm())
+	}
+
+	client := http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return net.Dial("unix", params.ListenSocket)
+	}}}
+	response, errGet := client.Get("http://localhost/metrics")
+	if errGet != nil {
+		t.Fatalf("Got error '%s' but expected none", errGet.Error())
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "samba_server_up 1\n" {
+		t.Errorf("Got the body '%s' but expected 'samba_server_up 1'", string(body))
+	}
+	server.Close()
+}
+
+func TestGetWebListenersAddresses(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.ListenSocket = ""
+	params.ListenAddresses = nil
+	errSet := params.ListenAddresses.Set("127.0.0.1:0, [::1]:0")
+	if errSet != nil {
+		t.Fatalf("Got error '%s' but expected none", errSet.Error())
+	}
+
+	listeners, errListen := getWebListeners(nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	if len(listeners) != 2 {
+		t.Fatalf("Got '%d' listeners but expected '2'", len(listeners))
+	}
+	for _, listener := range listeners {
+		listener.Close()
+	}
+	if listeners[0].Addr().(*net.TCPAddr).IP.To4() == nil || listeners[1].Addr().(*net.TCPAddr).IP.To4() != nil {
+		t.Errorf("Got the listeners on '%s' and '%s', but expected one on IPv4 and one on IPv6", listeners[0].Addr(), listeners[1].Addr())
+	}
+
+	// The second address is in use, so the listener of the first one must be closed again
+	blocking, errBlock := net.Listen("tcp", "127.0.0.1:0")
+	if errBlock != nil {
+		t.Fatalf("Got error '%s' but expected none", errBlock.Error())
+	}
+	defer blocking.Close()
+	params.ListenAddresses = listenAddressFlag{"127.0.0.1:0", blocking.Addr().String()}
+	_, errListen = getWebListeners(nil)
+	if errListen == nil {
+		t.Errorf("Got no error but expected one, since the address '%s' is in use", blocking.Addr())
+	}
+}
+
+func TestGetWebURLs(t *testing.T) {
+	ipv4, errIPv4 := net.Listen("tcp", "127.0.0.1:0")
+	if errIPv4 != nil {
+		t.Fatalf("Got error '%s' but expected none", errIPv4.Error())
+	}
+	defer ipv4.Close()
+	ipv6, errIPv6 := net.Listen("tcp", "[::1]:0")
+	if errIPv6 != nil {
+		t.Fatalf("Got error '%s' but expected none", errIPv6.Error())
+	}
+	defer ipv6.Close()
+	socketPath := filepath.Join(t.TempDir(), "samba_exporter.sock")
+	socket, errSocket := net.Listen("unix", socketPath)
+	if errSocket != nil {
+		t.Fatalf("Got error '%s' but expected none", errSocket.Error())
+	}
+	defer socket.Close()
+
+	expected := fmt.Sprintf("https://%s/metrics, https://%s/metrics", ipv4.Addr(), ipv6.Addr())
+	if urls := getWebURLs([]net.Listener{ipv4, ipv6}, "https", "/metrics"); urls != expected {
+		t.Errorf("Got the URLs '%s' but expected '%s'", urls, expected)
+	}
+
+	expected = fmt.Sprintf("http://unix:%s:/metrics", socketPath)
+	if urls := getWebURLs([]net.Listener{socket}, "http", "/metrics"); urls != expected {
+		t.Errorf("Got the URLs '%s' but expected '%s'", urls, expected)
+	}
+}
+
+func TestGetWebListenersActivated(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	logger = testhelper.NewTestLogger(true)
+	params.ListenSocket = ""
+	params.ListenAddresses = listenAddressFlag{"127.0.0.1:0"}
+
+	// Sockets meant for an other process are not used
+	t.Setenv("LISTEN_PID", fmt.Sprintf("%d", os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, errListen := getWebListeners(nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listeners[0].Close()
+	if len(listeners) != 1 || listeners[0].Addr().(*net.TCPAddr).IP.String() != "127.0.0.1" {
+		t.Errorf("Got the listeners '%v', but expected the one of the -web.listen-address", listeners)
+	}
+}
+
+func TestListenUnixSocketStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samba_exporter.sock")
+
+	// A socket file left over, like the one of an exporter that got killed
+	stale, errStale := net.Listen("unix", path)
+	if errStale != nil {
+		t.Fatalf("Got error '%s' but expected none", errStale.Error())
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, errListen := listenUnixSocket(path)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none, since the left over socket should be removed", errListen.Error())
+	}
+	listener.Close()
+
+	filePath := filepath.Join(t.TempDir(), "samba_exporter.conf")
+	os.WriteFile(filePath, []byte("ARGS=''"), 0644)
+	_, errFile := listenUnixSocket(filePath)
+	if errFile == nil {
+		t.Errorf("Got no error but expected one, since the path is a regular file")
+	}
+	if _, errStat := os.Stat(filePath); errStat != nil {
+		t.Errorf("The regular file at the -web.listen-socket got removed")
+	}
+}
+
+func TestGetAllowedCIDRsWithSocket(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.ListenSocket = "/run/samba_exporter.sock"
+	params.AllowedCIDRs = "10.1.0.0/24"
+
+	_, err := getAllowedCIDRs()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since -web.allowed-cidrs can not be combined with -web.listen-socket")
+	}
+}
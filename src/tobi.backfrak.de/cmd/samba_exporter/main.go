@@ -6,21 +6,27 @@ package main
 // LICENSE file.
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"tobi.backfrak.de/internal/commonbl"
 	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
 	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
 	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
 	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/statusdrpc"
 )
 
 // Authors - Information about the authors of the program. You might want to add your name here when contributing to this software
@@ -32,20 +38,44 @@ var version = "undefined"
 // The logger used in the program
 var logger commonbl.Logger
 
+// Tracks the running scrapes, so the systemd watchdog can detect a hanging exporter
+var scrapeTracker = commonbl.NewOperationTracker()
+
 func main() {
 	handleComandlineOptions()
 	os.Exit(realMain())
 }
 
 func realMain() int {
-	var newLoggerErrror error
-	requestHandler := *commonbl.NewPipeHandler(params.Test, commonbl.RequestPipe)
-	responseHandler := *commonbl.NewPipeHandler(params.Test, commonbl.ResposePipe)
-	logger, newLoggerErrror = commonbl.GetLogger(params.LogFilePath, params.Verbose)
+	errConfig := applyConfigFile()
+	if errConfig != nil {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf("Error when reading the configuration file: %s", errConfig.Error()))
+		return -8
+	}
+	// The verbose messages are switched by the SwitchableLogger, so they can be switched on and off with SIGUSR2
+	baseLogger, newLoggerErrror := commonbl.GetLogger(params.LogFilePath, true)
 	if newLoggerErrror != nil {
 		fmt.Fprintln(os.Stderr, fmt.Sprintf("Error when creating the logger: %s", newLoggerErrror.Error()))
 		return -9
 	}
+	switchableLogger := commonbl.NewSwitchableLogger(baseLogger, params.Verbose)
+	logger = switchableLogger
+	if params.LogDedupInterval > 0 {
+		logger = commonbl.NewDedupLogger(logger, params.LogDedupInterval)
+	}
+	requestHandler, responseHandler, errHandler := getMessageHandlers()
+	if errHandler != nil {
+		logger.WriteErrorWithAddition(errHandler, "while setting up the connection to samba_statusd")
+		return -10
+	}
+	grpcConn, grpcClient, errGrpc := getGrpcClient()
+	if errGrpc != nil {
+		logger.WriteErrorWithAddition(errGrpc, "while setting up the gRPC connection to samba_statusd")
+		return -10
+	}
+	if grpcConn != nil {
+		defer grpcConn.Close()
+	}
 
 	if !strings.HasPrefix(params.MetricsPath, "/") {
 		params.MetricsPath = fmt.Sprintf("/%s", params.MetricsPath)
@@ -62,8 +92,14 @@ func realMain() int {
 		}
 	}
 
-	logger.WriteVerbose(fmt.Sprintf("Named pipe for requests: %s", requestHandler.GetPipeFilePath()))
-	logger.WriteVerbose(fmt.Sprintf("Named pipe for response: %s", responseHandler.GetPipeFilePath()))
+	if grpcClient != nil {
+		logger.WriteVerbose(fmt.Sprintf("gRPC connection to samba_statusd: %s", params.StatusdAddress))
+	} else if params.StatusdAddress != "" {
+		logger.WriteVerbose(fmt.Sprintf("Connection to samba_statusd: %s", requestHandler.GetPipeFilePath()))
+	} else {
+		logger.WriteVerbose(fmt.Sprintf("Named pipe for requests: %s", requestHandler.GetPipeFilePath()))
+		logger.WriteVerbose(fmt.Sprintf("Named pipe for response: %s", responseHandler.GetPipeFilePath()))
+	}
 
 	if params.PrintVersion {
 		printVersion()
@@ -91,8 +127,111 @@ func realMain() int {
 		logger.WriteVerbose("-not-expose-share-details set, will not export share details")
 	}
 
+	errPrivacy := statisticsGenerator.CheckPrivacyMode(params.PrivacyMode)
+	if errPrivacy != nil {
+		logger.WriteErrorWithAddition(errPrivacy, "while reading the -privacy.mode")
+		return -14
+	}
+	hashKey, errHashKey := getPrivacyHashKey()
+	if errHashKey != nil {
+		logger.WriteErrorWithAddition(errHashKey, "while reading the -privacy.hash-key-file")
+		return -14
+	}
+	params.PrivacyHashKey = hashKey
+	if params.PrivacyMode != statisticsGenerator.PRIVACY_MODE_NONE {
+		logger.WriteVerbose(fmt.Sprintf("-privacy.mode set to '%s', the labels identifying persons will not be exported as they are", params.PrivacyMode))
+	}
+	if params.EnableStatusApi && params.PrivacyMode != statisticsGenerator.PRIVACY_MODE_NONE {
+		logger.WriteErrorMessage("The -web.enable-status-api can not be combined with -privacy.mode, the status API returns the users and clients as they are")
+		return -14
+	}
+
+	debugToken, errDebugToken := getDebugToken()
+	if errDebugToken != nil {
+		logger.WriteErrorWithAddition(errDebugToken, "while reading the -web.debug-token-file")
+		return -23
+	}
+
+	allowedCIDRs, errCIDRs := getAllowedCIDRs()
+	if errCIDRs != nil {
+		logger.WriteErrorWithAddition(errCIDRs, "while reading the -web.allowed-cidrs")
+		return -24
+	}
+
+	webTLSConfig, errWebTLS := getWebTLSConfig()
+	if errWebTLS != nil {
+		logger.WriteErrorWithAddition(errWebTLS, "while reading the -web.tls.* parameters")
+		return -25
+	}
+	webScheme := "http"
+	if webTLSConfig != nil {
+		webScheme = "https"
+		if webTLSConfig.ClientCAs != nil {
+			logger.WriteVerbose("Only clients with a certificate signed by the -web.tls.client-ca-file can connect")
+		}
+	}
+
+	if params.ReverseDNS {
+		logger.WriteVerbose(fmt.Sprintf("-clients.reverse-dns set, will resolve the addresses of the clients and cache them for %s", params.ReverseDNSTTL))
+		params.ClientResolver = statisticsGenerator.NewClientResolver(params.ReverseDNSTTL)
+	}
+
+	if params.GeoIPDatabases != "" {
+		geoIP, errGeoIP := statisticsGenerator.OpenGeoIPDatabases(strings.Split(params.GeoIPDatabases, ","))
+		if errGeoIP != nil {
+			logger.WriteErrorWithAddition(errGeoIP, "while opening the -clients.geoip-databases")
+			return -16
+		}
+		defer geoIP.Close()
+		logger.WriteVerbose(fmt.Sprintf("Add the country and asn of the clients found in '%s'", params.GeoIPDatabases))
+		params.GeoIP = geoIP
+	}
+
+	shareFilter, errFilter := statisticsGenerator.NewShareFilter(params.SharesInclude, params.SharesExclude)
+	if errFilter != nil {
+		logger.WriteErrorWithAddition(errFilter, "while reading the -shares.include or -shares.exclude")
+		return -15
+	}
+	params.ShareFilter = shareFilter
+	if shareFilter != nil {
+		logger.WriteVerbose(fmt.Sprintf("Export only the shares matching -shares.include '%s' and not matching -shares.exclude '%s'", params.SharesInclude, params.SharesExclude))
+	}
+
+	if params.LockedFilesTopN > statisticsGenerator.MAX_LOCKED_FILES_TOP_N {
+		logger.WriteInformation(fmt.Sprintf("-locked-files.top-n %d is more than %d, will export only the %d most locked files",
+			params.LockedFilesTopN, statisticsGenerator.MAX_LOCKED_FILES_TOP_N, statisticsGenerator.MAX_LOCKED_FILES_TOP_N))
+		params.LockedFilesTopN = statisticsGenerator.MAX_LOCKED_FILES_TOP_N
+	} else if params.LockedFilesTopN > 0 {
+		logger.WriteVerbose(fmt.Sprintf("-locked-files.top-n set, will export the %d most locked files", params.LockedFilesTopN))
+	}
+
+	if params.LogRawLines {
+		logger.WriteVerbose("-log.raw-lines set, will log the smbstatus lines when parsing them")
+		smbstatusreader.SetLogRawLines(true)
+	}
+
+	if len(params.TimeLayouts) > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Use the additional time layouts '%s'", params.TimeLayouts.String()))
+		smbstatusreader.SetAdditionalTimeLayouts(params.TimeLayouts)
+	}
+
+	if params.SambaTimezone != "" {
+		location, errLocation := time.LoadLocation(params.SambaTimezone)
+		if errLocation != nil {
+			logger.WriteErrorWithAddition(errLocation, "while loading the -samba-timezone")
+			return -11
+		}
+		logger.WriteVerbose(fmt.Sprintf("Read the time stamps of the samba server in the timezone '%s'", location.String()))
+		smbstatusreader.SetSambaTimezone(location)
+	}
+
+	params.DisabledCollectors = getDisabledCollectors()
+	if len(params.DisabledCollectors) > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Disabled collectors: '%s', will not export their metrics", strings.Join(params.DisabledCollectors, ", ")))
+	}
+
 	if params.TestPipeMode {
-		errTest := testPipeMode(&requestHandler, &responseHandler)
+		errTest := testPipeMode(requestHandler, responseHandler, grpcClient)
 		if errTest != nil {
 			logger.WriteError(errTest)
 			return -2
@@ -103,15 +242,165 @@ func realMain() int {
 	// Ensure we exit clean on term and kill signals
 	go waitforKillSignalAndExit()
 	go waitforTermSignalAndExit()
+	commonbl.StartVerboseToggle(switchableLogger)
 
 	logger.WriteVerbose("Setup prometheus exporter")
 
-	exporter := smbexporter.NewSambaExporter(&requestHandler, &responseHandler, logger, version, params.RequestTimeOut, params.StatisticsGeneratorSettings)
-	prometheus.MustRegister(exporter)
+	exporter := smbexporter.NewSambaExporter(requestHandler, responseHandler, logger, version, params.RequestTimeOut, params.StatisticsGeneratorSettings)
+	exporter.GrpcClient = grpcClient
+	exporter.RequestRetries = params.RequestRetries
+	exporter.RequestRetryBackoff = params.RequestRetryBackoff
+	if params.RequestBreakerThreshold > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Send no requests to samba_statusd for %s, after %d requests timed out in a row", params.RequestBreakerCooldown, params.RequestBreakerThreshold))
+		exporter.RequestBreakerThreshold = params.RequestBreakerThreshold
+		exporter.RequestBreakerCooldown = params.RequestBreakerCooldown
+	}
+	if params.StateFile != "" {
+		counterState, errState := smbexporter.NewCounterState(params.StateFile)
+		if errState != nil {
+			logger.WriteErrorWithAddition(errState, "while reading the -state.file")
+			return -13
+		}
+		logger.WriteVerbose(fmt.Sprintf("Keep the values of the counters in '%s'", params.StateFile))
+		exporter.CounterState = counterState
+	}
+	if params.StaleDataMaxAge > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Export the samba status of the last successful request for at most %s, when samba_statusd can not be reached", params.StaleDataMaxAge))
+		exporter.StaleDataMaxAge = params.StaleDataMaxAge
+	}
+	if params.MemoryLimit > 0 {
+		debug.SetMemoryLimit(int64(params.MemoryLimit))
+	}
+	if memoryLimit := getMemoryLimit(); memoryLimit > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Abort the scrapes, when parsing the smbstatus output would exceed the memory limit of %d bytes", memoryLimit))
+		exporter.MemoryLimit = memoryLimit
+	}
+	if params.StatusdMaxResponseSize > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Discard the responses of samba_statusd larger than %s", params.StatusdMaxResponseSize.String()))
+		exporter.MaxResponseSize = int(params.StatusdMaxResponseSize)
+	}
+	if params.StatusdDropDeadEntries {
+		logger.WriteVerbose("Drop the entries of smbd processes that are not running anymore")
+		exporter.DropDeadEntries = true
+	}
+	if params.ClusterNodeNames {
+		logger.WriteVerbose("Export the cluster nodes by their address")
+		exporter.ResolveClusterNodes = true
+	}
+	if params.CardinalityLimit > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Export at most %d series of a metric", params.CardinalityLimit))
+		exporter.CardinalityLimit = params.CardinalityLimit
+	}
+	if len(params.Labels) > 0 {
+		logger.WriteVerbose(fmt.Sprintf("Add the constant labels '%s' to every metric", params.Labels.String()))
+		exporter.ConstLabels = params.Labels
+	}
+	if flag.NArg() > 0 {
+		return runSubcommand(flag.Args(), exporter)
+	}
+	registry, errRegistry := getRegistry(nil)
+	if errRegistry != nil {
+		logger.WriteErrorWithAddition(errRegistry, "while setting up the prometheus registry")
+		return -12
+	}
+	// The metrics endpoint collects the exporter with the context of each scrape, so the exporter gets a registry of its own
+	exporterRegistry := prometheus.NewRegistry()
+	errRegister := exporterRegistry.Register(exporter)
+	if errRegister != nil {
+		logger.WriteErrorWithAddition(errRegister, "while setting up the prometheus registry")
+		return -12
+	}
+	gatherer := prometheus.Gatherers{registry, exporterRegistry}
 
-	logger.WriteInformation(fmt.Sprintf("Started %s, get metrics on http://%s%s", os.Args[0], params.ListenAddress, params.MetricsPath))
+	remoteWriter, errRemoteWrite := getRemoteWriter(gatherer)
+	if errRemoteWrite != nil {
+		logger.WriteErrorWithAddition(errRemoteWrite, "while setting up the -remote-write.url")
+		return -17
+	}
+	if remoteWriter != nil {
+		logger.WriteVerbose(fmt.Sprintf("Push the metrics to '%s' every %s", params.RemoteWrite.URL, params.RemoteWrite.Interval))
+		go remoteWriter.Run()
+	}
 
-	http.Handle(params.MetricsPath, promhttp.Handler())
+	otlpExporter, errOTLP := getOTLPExporter(gatherer)
+	if errOTLP != nil {
+		logger.WriteErrorWithAddition(errOTLP, "while setting up the -otlp.endpoint")
+		return -18
+	}
+	if otlpExporter != nil {
+		logger.WriteVerbose(fmt.Sprintf("Send the metrics to the OpenTelemetry collector '%s' every %s", params.OTLP.Endpoint, params.OTLP.Interval))
+		go otlpExporter.Run()
+	}
+
+	influxWriter, errInflux := getInfluxWriter(gatherer)
+	if errInflux != nil {
+		logger.WriteErrorWithAddition(errInflux, "while setting up the -influx.target")
+		return -19
+	}
+	if influxWriter != nil {
+		logger.WriteVerbose(fmt.Sprintf("Write the metrics in the InfluxDB line protocol to '%s' every %s", params.Influx.Target, params.Influx.Interval))
+		go influxWriter.Run()
+	}
+
+	statsdEmitter, errStatsd := getStatsdEmitter(gatherer)
+	if errStatsd != nil {
+		logger.WriteErrorWithAddition(errStatsd, "while setting up the -statsd.address")
+		return -20
+	}
+	if statsdEmitter != nil {
+		defer statsdEmitter.Close()
+		logger.WriteVerbose(fmt.Sprintf("Send the metrics as statsd to '%s' every %s", params.Statsd.Address, params.Statsd.Interval))
+		go statsdEmitter.Run()
+	}
+
+	zabbixSender, errZabbix := getZabbixSender(gatherer)
+	if errZabbix != nil {
+		logger.WriteErrorWithAddition(errZabbix, "while setting up the -zabbix.server")
+		return -22
+	}
+	if zabbixSender != nil {
+		logger.WriteVerbose(fmt.Sprintf("Send the metrics to the Zabbix server '%s' every %s", params.Zabbix.Server, params.Zabbix.Interval))
+		go zabbixSender.Run()
+	}
+
+	listeners, errListen := getWebListeners(webTLSConfig)
+	if errListen != nil {
+		logger.WriteError(errListen)
+		return -1
+	}
+	logger.WriteInformation(fmt.Sprintf("Started %s, get metrics on %s", os.Args[0], getWebURLs(listeners, webScheme, params.MetricsPath)))
+
+	handlerMetrics, errMetrics := newHandlerMetrics(registry, params.Labels)
+	if errMetrics != nil {
+		logger.WriteErrorWithAddition(errMetrics, "while setting up the metrics of the metrics endpoint")
+		return -12
+	}
+
+	scrapeHandler := metricsHandler(exporter, registry)
+	if params.MaxScrapeRate > 0 || params.MaxConcurrentScrapes > 0 {
+		limiter, errLimiter := newScrapeLimiter(params.MaxScrapeRate, params.MaxConcurrentScrapes, registry, params.Labels)
+		if errLimiter != nil {
+			logger.WriteErrorWithAddition(errLimiter, "while setting up the limits of the metrics endpoint")
+			return -12
+		}
+		logger.WriteVerbose(fmt.Sprintf("Limit the scrapes of the metrics endpoint to %g per second and %d at the same time, 0 for no limit", params.MaxScrapeRate, params.MaxConcurrentScrapes))
+		scrapeHandler = limiter.limit(scrapeHandler)
+	}
+	if allowedCIDRs != nil {
+		logger.WriteVerbose(fmt.Sprintf("Only clients in the networks '%s' can scrape the metrics endpoint", params.AllowedCIDRs))
+		scrapeHandler = allowCIDRs(allowedCIDRs, scrapeHandler)
+	}
+	http.Handle(params.MetricsPath, trackScrapes(handlerMetrics.instrument(scrapeHandler)))
+	statusLink := ""
+	if params.EnableStatusApi {
+		logger.WriteVerbose(fmt.Sprintf("Serve the samba status as JSON on %s", getWebURLs(listeners, webScheme, STATUS_API_PATH)))
+		http.Handle(STATUS_API_PATH, statusHandler(exporter))
+		statusLink = `<p><a href='` + STATUS_API_PATH + `'>Status</a></p>`
+	}
+	if debugToken != nil {
+		logger.WriteVerbose(fmt.Sprintf("Serve the last raw responses of samba_statusd on %s", getWebURLs(listeners, webScheme, DEBUG_RAW_PATH)))
+		http.Handle(DEBUG_RAW_PATH, debugRawHandler(debugToken))
+	}
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`
 			<html>
@@ -119,20 +408,150 @@ func realMain() int {
 			<body>
 			<h1>Samba Exporter</h1>
 			<p><a href='` + params.MetricsPath + `'>Metrics</a></p>
+			` + statusLink + `
 			</body>
 			</html>`))
 	})
 
-	errListen := http.ListenAndServe(params.ListenAddress, nil)
-	if errListen != nil {
-		logger.WriteError(errListen)
+	var webHandler http.Handler = http.DefaultServeMux
+	if params.AccessLog {
+		logger.WriteVerbose("Log each request to the web interface")
+		webHandler = logAccess(webHandler)
+	}
+
+	commonbl.StartSdNotifications(scrapeTracker.IsHealthy, logger)
+
+	// Serve on all listeners, the first one failing ends the exporter
+	serveErrors := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func(listener net.Listener) { serveErrors <- http.Serve(listener, webHandler) }(listener)
+	}
+	errServe := <-serveErrors
+	if errServe != nil {
+		logger.WriteError(errServe)
 		return -1
 	}
 
 	return 0
 }
 
-func testPipeMode(requestHandler *commonbl.PipeHandler, responseHandler *commonbl.PipeHandler) error {
+// runSubcommand - Run the subcommand given after the options, instead of serving the metrics. Returns the exit code of the program
+func runSubcommand(args []string, exporter *smbexporter.SambaExporter) int {
+	switch args[0] {
+	case SUBCOMMAND_CHECK:
+		// The check requests samba_statusd directly, so an unreachable samba_statusd is reported and does not fail the setup of the registry
+		return runCheck(args[1:], exporter.RequestHandler, exporter.ResponseHander, exporter.GrpcClient, os.Stdout)
+	case SUBCOMMAND_LIST_METRICS:
+		return runListMetrics(args[1:], exporter, os.Stdout)
+	case SUBCOMMAND_COLLECT, SUBCOMMAND_PRINT:
+		registry, errRegistry := getRegistry(exporter)
+		if errRegistry != nil {
+			logger.WriteErrorWithAddition(errRegistry, "while setting up the prometheus registry")
+			return -12
+		}
+		if args[0] == SUBCOMMAND_COLLECT {
+			return runCollect(args[1:], registry, os.Stdout)
+		}
+		return runPrint(args[1:], registry, os.Stdout)
+	default:
+		logger.WriteError(fmt.Errorf("The subcommand '%s' is not known, use '%s', '%s', '%s' or '%s'", args[0],
+			SUBCOMMAND_CHECK, SUBCOMMAND_COLLECT, SUBCOMMAND_LIST_METRICS, SUBCOMMAND_PRINT))
+		return -21
+	}
+}
+
+// trackScrapes - Wrap the handler, so every scrape is tracked by the scrapeTracker
+func trackScrapes(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trackingId := scrapeTracker.Start()
+		defer scrapeTracker.Done(trackingId)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// getRegistry - Get the registry of the metrics endpoint with the exporter registered, when one is given. The go runtime and process
+// metrics are registered, as long as they are not disabled
+func getRegistry(exporter prometheus.Collector) (*prometheus.Registry, error) {
+	registry := prometheus.NewRegistry()
+	var toRegister []prometheus.Collector
+	if !params.DisableGoMetrics {
+		toRegister = append(toRegister, collectors.NewGoCollector())
+	} else {
+		logger.WriteVerbose("-web.disable-go-metrics set, will not export the go runtime metrics")
+	}
+	if !params.DisableProcessMetrics {
+		toRegister = append(toRegister, collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	} else {
+		logger.WriteVerbose("-web.disable-process-metrics set, will not export the process metrics")
+	}
+	if exporter != nil {
+		toRegister = append(toRegister, exporter)
+	}
+
+	for _, collector := range toRegister {
+		errRegister := registry.Register(collector)
+		if errRegister != nil {
+			return nil, errRegister
+		}
+	}
+
+	return registry, nil
+}
+
+// metricsHandler - Get the handler for the metrics endpoint, exporting the metrics of the registry and the exporter. When the 'collect[]' query
+// parameter is given, only the metrics of the named collectors are exported. The requests to samba_statusd are cancelled, when the scrape is
+func metricsHandler(exporter *smbexporter.SambaExporter, registry *prometheus.Registry) http.Handler {
+	defaultHandler := promhttp.InstrumentMetricHandler(registry, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gatherers := prometheus.Gatherers{registry}
+		if exporter != nil {
+			filtered, errFilter := exporter.NewFilteredCollector(r.Context(), nil)
+			if errFilter != nil {
+				http.Error(w, errFilter.Error(), http.StatusInternalServerError)
+				return
+			}
+			exporterRegistry := prometheus.NewRegistry()
+			exporterRegistry.MustRegister(filtered)
+			gatherers = append(gatherers, exporterRegistry)
+		}
+		promhttp.HandlerFor(gatherers, getHandlerOpts()).ServeHTTP(w, r)
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		collectors := r.URL.Query()["collect[]"]
+		if len(collectors) == 0 {
+			defaultHandler.ServeHTTP(w, r)
+			return
+		}
+
+		filtered, errFilter := exporter.NewFilteredCollector(r.Context(), collectors)
+		if errFilter != nil {
+			logger.WriteErrorWithAddition(errFilter, "while handling the 'collect[]' query parameter")
+			http.Error(w, errFilter.Error(), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(filtered)
+		promhttp.HandlerFor(registry, getHandlerOpts()).ServeHTTP(w, r)
+	})
+}
+
+// getHandlerOpts - Get the options of the handlers for the metrics endpoint. The OpenMetrics format is sent,
+// when the client asks for it, e. g. prometheus with the 'OpenMetricsText1.0.0' scrape protocol
+func getHandlerOpts() promhttp.HandlerOpts {
+	return promhttp.HandlerOpts{EnableOpenMetrics: true}
+}
+
+// getSambaStatus - Request the data tables from samba_statusd, using the gRPC service when a grpcClient is given
+func getSambaStatus(requestHandler commonbl.MessageHandler, responseHandler commonbl.MessageHandler, grpcClient statusdrpc.SambaStatusClient) ([]smbstatusreader.LockData, []smbstatusreader.ProcessData, []smbstatusreader.ShareData, []commonbl.PsUtilPidData, error) {
+	if grpcClient != nil {
+		return pipecomunication.GetSambaStatusGrpc(context.Background(), grpcClient, logger, getRequestSettings())
+	}
+
+	return pipecomunication.GetSambaStatus(context.Background(), requestHandler, responseHandler, logger, getRequestSettings())
+}
+
+func testPipeMode(requestHandler commonbl.MessageHandler, responseHandler commonbl.MessageHandler, grpcClient statusdrpc.SambaStatusClient) error {
 	var processes []smbstatusreader.ProcessData
 	var shares []smbstatusreader.ShareData
 	var locks []smbstatusreader.LockData
@@ -140,7 +559,7 @@ func testPipeMode(requestHandler *commonbl.PipeHandler, responseHandler *commonb
 	var errGet error
 
 	logger.WriteVerbose("Request samba_statusd to get metrics for test-pipe mode")
-	locks, processes, shares, psData, errGet = pipecomunication.GetSambaStatus(requestHandler, responseHandler, logger, params.RequestTimeOut)
+	locks, processes, shares, psData, errGet = getSambaStatus(requestHandler, responseHandler, grpcClient)
 	if errGet != nil {
 		return errGet
 	}
@@ -168,7 +587,9 @@ func handleTestResponse(processes []smbstatusreader.ProcessData, shares []smbsta
 	}
 
 	stats := statisticsGenerator.GetSmbStatistics(locks, processes, shares, params.StatisticsGeneratorSettings)
-	stats = append(stats, statisticsGenerator.GetSmbdMetrics(psData, params.DoNotExportPid)...)
+	if params.IsCollectorEnabled(statisticsGenerator.COLLECTOR_PSDATA) {
+		stats = append(stats, statisticsGenerator.GetSmbdMetrics(psData, params.DoNotExportPid)...)
+	}
 	for _, stat := range stats {
 		fmt.Fprintln(os.Stdout, fmt.Sprintf("%s_%s: %f", smbexporter.EXPORTER_LABEL_PREFIX, stat.Name, stat.Value))
 	}
@@ -180,6 +601,7 @@ func waitforKillSignalAndExit() {
 	<-killSignal
 
 	logger.WriteInformation(fmt.Sprintf("End %s due to kill signal", os.Args[0]))
+	commonbl.SdNotify(commonbl.SD_NOTIFY_STOPPING)
 
 	os.Exit(0)
 }
@@ -190,6 +612,7 @@ func waitforTermSignalAndExit() {
 	<-termSignal
 
 	logger.WriteInformation(fmt.Sprintf("End %s due to terminate signal", os.Args[0]))
+	commonbl.SdNotify(commonbl.SD_NOTIFY_STOPPING)
 
 	os.Exit(0)
 }
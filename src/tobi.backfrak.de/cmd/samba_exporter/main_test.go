@@ -6,14 +6,22 @@ package main
 // LICENSE file.
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"tobi.backfrak.de/internal/commonbl"
 	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
 	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
 	"tobi.backfrak.de/internal/testhelper"
 )
 
@@ -42,7 +50,7 @@ func TestTestPipeMode(t *testing.T) {
 	testLogger := testhelper.NewTestLogger(true)
 	logger = testLogger
 
-	err := testPipeMode(requestHandler, responseHandler)
+	err := testPipeMode(requestHandler, responseHandler, nil)
 	if err == nil {
 		t.Errorf("Got no error, but expected one")
 	}
@@ -54,8 +62,8 @@ func TestTestPipeMode(t *testing.T) {
 		t.Errorf("Got error of type '%s', but expected type '*pipecomunication.SmbStatusTimeOutError'", err)
 	}
 
-	if testLogger.GetOutputCount() != 4 {
-		t.Errorf("Got '%d' output messages but expected '4'", testLogger.GetOutputCount())
+	if testLogger.GetOutputCount() != 5 {
+		t.Errorf("Got '%d' output messages but expected '5'", testLogger.GetOutputCount())
 	}
 }
 
@@ -148,3 +156,223 @@ func TestMainWithTestPipeMode(t *testing.T) {
 	}
 
 }
+
+func TestMainWithInvalidSambaTimezone(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.Test = true
+	params.TestPipeMode = true
+	params.SambaTimezone = "Not/A_Timezone"
+
+	res := realMain()
+	if res != -11 {
+		t.Errorf("Got %d from main, but expected -11", res)
+	}
+
+}
+
+func TestMainWithInvalidPrivacyMode(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.Test = true
+	params.TestPipeMode = true
+	params.PrivacyMode = "anonymize"
+
+	res := realMain()
+	if res != -14 {
+		t.Errorf("Got %d from main, but expected -14", res)
+	}
+
+	params.PrivacyMode = ""
+	params.PrivacyHashKeyFile = "/not/existing/key"
+	res = realMain()
+	if res != -14 {
+		t.Errorf("Got %d from main for a hash key without hash mode, but expected -14", res)
+	}
+}
+
+func TestMainWithInvalidShareFilter(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.Test = true
+	params.TestPipeMode = true
+	params.SharesExclude = "IPC$("
+
+	res := realMain()
+	if res != -15 {
+		t.Errorf("Got %d from main, but expected -15", res)
+	}
+}
+
+func TestMainWithInvalidGeoIPDatabase(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.Test = true
+	params.TestPipeMode = true
+	params.GeoIPDatabases = "/not/existing/GeoLite2-Country.mmdb"
+
+	res := realMain()
+	if res != -16 {
+		t.Errorf("Got %d from main, but expected -16", res)
+	}
+}
+
+func TestTrackScrapes(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	handler := trackScrapes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		if scrapeTracker.LongestRunning() == 0 {
+			t.Errorf("The scrape is not tracked while running")
+		}
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if scrapeTracker.LongestRunning() != 0 {
+		t.Errorf("The scrape is still tracked after it is done")
+	}
+}
+
+func TestMetricsHandlerUnknownCollector(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	exporter := smbexporter.NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 1, statisticsGenerator.StatisticsGeneratorSettings{})
+	handler := metricsHandler(exporter, prometheus.NewRegistry())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics?collect[]=locks&collect[]=unknown", nil))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Got status code '%d' but expected '%d'", recorder.Code, http.StatusBadRequest)
+	}
+
+	if !strings.Contains(recorder.Body.String(), "unknown") {
+		t.Errorf("The response '%s' does not name the unknown collector", recorder.Body.String())
+	}
+}
+
+func TestMetricsHandlerCancelledScrape(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	exporter := smbexporter.NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 10, statisticsGenerator.StatisticsGeneratorSettings{})
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "samba_scrape_errors_total", Help: "Test counter"})
+	registry.MustRegister(counter)
+	handler := metricsHandler(exporter, registry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil).WithContext(ctx))
+
+	if time.Since(start) > time.Second {
+		t.Errorf("The cancelled scrape took '%s', but expected it not to wait for samba_statusd", time.Since(start))
+	}
+
+	if !strings.Contains(recorder.Body.String(), "samba_scrape_errors_total") {
+		t.Errorf("The response '%s' does not contain the metrics of the registry", recorder.Body.String())
+	}
+
+	if strings.Contains(recorder.Body.String(), "samba_statusd_up") {
+		t.Errorf("The response '%s' contains metrics of the exporter, but expected none for a cancelled scrape", recorder.Body.String())
+	}
+}
+
+func TestGetRegistry(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	logger = testhelper.NewTestLogger(true)
+	exporter := prometheus.NewGauge(prometheus.GaugeOpts{Name: "samba_server_up", Help: "Test gauge"})
+
+	for _, disable := range []bool{false, true} {
+		params.DisableGoMetrics = disable
+		params.DisableProcessMetrics = disable
+		registry, errRegistry := getRegistry(exporter)
+		if errRegistry != nil {
+			t.Fatalf("Got error '%s' but expected none", errRegistry.Error())
+		}
+
+		families, errGather := registry.Gather()
+		if errGather != nil {
+			t.Fatalf("Got error '%s' but expected none", errGather.Error())
+		}
+
+		foundExporter, foundGo, foundProcess := false, false, false
+		for _, family := range families {
+			foundExporter = foundExporter || family.GetName() == "samba_server_up"
+			foundGo = foundGo || strings.HasPrefix(family.GetName(), "go_")
+			foundProcess = foundProcess || strings.HasPrefix(family.GetName(), "process_")
+		}
+
+		if !foundExporter {
+			t.Errorf("The metric of the exporter was not found")
+		}
+		if foundGo == disable {
+			t.Errorf("Found go runtime metrics: %t, but the go metrics are disabled: %t", foundGo, disable)
+		}
+		if foundProcess == disable && runtime.GOOS == "linux" {
+			t.Errorf("Found process metrics: %t, but the process metrics are disabled: %t", foundProcess, disable)
+		}
+	}
+}
+
+func TestMetricsHandlerOpenMetrics(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "samba_scrape_errors_total", Help: "Test counter"})
+	registry.MustRegister(counter)
+	handler := metricsHandler(nil, registry)
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "application/openmetrics-text") {
+		t.Errorf("Got the content type '%s' but expected OpenMetrics", recorder.Header().Get("Content-Type"))
+	}
+
+	if !strings.Contains(recorder.Body.String(), "# TYPE samba_scrape_errors counter") || !strings.HasSuffix(recorder.Body.String(), "# EOF\n") {
+		t.Errorf("The response '%s' is not in the OpenMetrics format", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Got the content type '%s' but expected text", recorder.Header().Get("Content-Type"))
+	}
+}
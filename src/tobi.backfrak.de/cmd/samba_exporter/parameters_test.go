@@ -6,7 +6,27 @@ package main
 // LICENSE file.
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+	"tobi.backfrak.de/internal/testhelper"
 )
 
 func TestHandleComandlineOptions(t *testing.T) {
@@ -29,3 +49,584 @@ func TestCustomHelpMessage(t *testing.T) {
 
 	customHelpMessage()
 }
+
+func TestApplyConfigFile(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.ConfigFile = ""
+	err := applyConfigFile()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none, since no configuration file is given", err.Error())
+	}
+
+	params.ConfigFile = filepath.Join(t.TempDir(), "samba_exporter.yml")
+	err = applyConfigFile()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the configuration file does not exist")
+	}
+
+	os.WriteFile(params.ConfigFile, []byte("web:\n  telemetry-path: /samba/metrics\nrequest-timeout: 8\nrequest-retry-backoff: 2s\n"), 0644)
+	err = applyConfigFile()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if params.MetricsPath != "/samba/metrics" {
+		t.Errorf("The metrics path is '%s' but expected '/samba/metrics'", params.MetricsPath)
+	}
+
+	if params.RequestTimeOut != 8 {
+		t.Errorf("The request timeout is '%d' but expected '8'", params.RequestTimeOut)
+	}
+
+	if params.RequestRetryBackoff != 2*time.Second {
+		t.Errorf("The request retry backoff is '%s' but expected '2s'", params.RequestRetryBackoff)
+	}
+}
+
+func TestGetRequestSettings(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.RequestTimeOut = 3
+	params.RequestRetries = 2
+	params.RequestRetryBackoff = 500 * time.Millisecond
+	settings := getRequestSettings()
+
+	if settings.TimeOut != 3 || settings.Retries != 2 || settings.RetryBackoff != 500*time.Millisecond {
+		t.Errorf("Got the request settings '%v', but expected the values of the parameters", settings)
+	}
+}
+
+func TestGetDisabledCollectors(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	enabled, disabled := true, false
+	notEnabled, isDisabled := false, true
+	params.collectorEnabled = map[string]*bool{"locks": &enabled, "shares": &notEnabled, "psdata": &enabled}
+	params.collectorDisabled = map[string]*bool{"locks": &disabled, "shares": &disabled, "psdata": &isDisabled}
+
+	collectors := getDisabledCollectors()
+	if len(collectors) != 2 {
+		t.Errorf("Got '%d' disabled collectors, but expected '2'", len(collectors))
+	}
+
+	if len(collectors) == 2 && (collectors[0] != "shares" || collectors[1] != "psdata") {
+		t.Errorf("Got the disabled collectors '%v', but expected 'shares' and 'psdata'", collectors)
+	}
+}
+
+func TestLabelFlag(t *testing.T) {
+	labels := make(labelFlag)
+
+	err := labels.Set("datacenter=fra1")
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	err = labels.Set("cluster=samba-01,rack=")
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if labels.String() != "cluster=samba-01,datacenter=fra1,rack=" {
+		t.Errorf("The labels '%s' are not the expected", labels.String())
+	}
+
+	for _, invalid := range []string{"datacenter", "1datacenter=fra1", "data-center=fra1", "__name__=fra1"} {
+		err = labels.Set(invalid)
+		if err == nil {
+			t.Errorf("Got no error for the label '%s', but expected one", invalid)
+		}
+	}
+
+	var zero labelFlag
+	if zero.String() != "" {
+		t.Errorf("The zero value of the labelFlag is not an empty string")
+	}
+}
+
+func TestTimeLayoutFlag(t *testing.T) {
+	var layouts timeLayoutFlag
+
+	if layouts.String() != "" {
+		t.Errorf("The zero value of the timeLayoutFlag is not an empty string")
+	}
+
+	for _, layout := range []string{"Mon 2 January 2006 15:04:05", "Mon, 02 Jan 2006 15:04:05 MST"} {
+		err := layouts.Set(layout)
+		if err != nil {
+			t.Errorf("Got error '%s' but expected none", err.Error())
+		}
+	}
+
+	if len(layouts) != 2 || layouts[1] != "Mon, 02 Jan 2006 15:04:05 MST" {
+		t.Errorf("The layouts '%s' are not the expected", layouts.String())
+	}
+
+	err := layouts.Set(" ")
+	if err == nil {
+		t.Errorf("Got no error for an empty layout, but expected one")
+	}
+}
+
+func TestListenAddressFlag(t *testing.T) {
+	var addresses listenAddressFlag
+
+	if addresses.String() != "" {
+		t.Errorf("The zero value of the listenAddressFlag is not an empty string")
+	}
+
+	err := addresses.Set("0.0.0.0:9922")
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+	err = addresses.Set("[::]:9922, 10.1.0.5:9123")
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if addresses.String() != "0.0.0.0:9922,[::]:9922,10.1.0.5:9123" {
+		t.Errorf("The addresses '%s' are not the expected", addresses.String())
+	}
+
+	for _, invalid := range []string{"", "fileserver", "::1:9922", "10.1.0.5:9922,"} {
+		err = addresses.Set(invalid)
+		if err == nil {
+			t.Errorf("Got no error for the address '%s', but expected one", invalid)
+		}
+	}
+}
+
+func TestByteSizeFlag(t *testing.T) {
+	var limit byteSizeFlag
+
+	if limit.String() != "" {
+		t.Errorf("The zero value of the byteSizeFlag is not an empty string")
+	}
+
+	for _, size := range []struct {
+		value    string
+		expected int64
+		str      string
+	}{{"512MiB", 512 << 20, "512MiB"}, {"2GiB", 2 << 30, "2GiB"}, {"1536KiB", 1536 << 10, "1536KiB"}, {"1000B", 1000, "1000B"}, {" 1TiB ", 1 << 40, "1TiB"}} {
+		err := limit.Set(size.value)
+		if err != nil {
+			t.Errorf("Got error '%s' but expected none", err.Error())
+		}
+		if int64(limit) != size.expected {
+			t.Errorf("Got the limit '%d' for '%s', but expected '%d'", limit, size.value, size.expected)
+		}
+		if limit.String() != size.str {
+			t.Errorf("The limit '%s' is not the expected '%s'", limit.String(), size.str)
+		}
+	}
+
+	for _, invalid := range []string{"", "512", "512MB", "-1GiB", "0B", "1.5GiB", "9999999999TiB"} {
+		err := limit.Set(invalid)
+		if err == nil {
+			t.Errorf("Got no error for the size '%s', but expected one", invalid)
+		}
+	}
+}
+
+func TestGetMessageHandlers(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.Test = true
+
+	requestHandler, responseHandler, err := getMessageHandlers()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+	if requestHandler.GetPipeFilePath() == responseHandler.GetPipeFilePath() {
+		t.Errorf("The same pipe '%s' is used for requests and responses", requestHandler.GetPipeFilePath())
+	}
+
+	params.PipeDirectory = "/run/samba_exporter"
+	requestHandler, _, err = getMessageHandlers()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+	if requestHandler.GetPipeFilePath() != "/run/samba_exporter/samba_exporter.request.pipe" {
+		t.Errorf("The pipe is '%s' but expected '/run/samba_exporter/samba_exporter.request.pipe'", requestHandler.GetPipeFilePath())
+	}
+
+	params.StatusdAddress = "fileserver:9923"
+	params.StatusdMaxResponseSize = 64 << 20
+	requestHandler, responseHandler, err = getMessageHandlers()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+	if requestHandler != responseHandler {
+		t.Errorf("Different connections are used for requests and responses")
+	}
+	if requestHandler.(*commonbl.TcpHandler).MaxMessageSize != 64<<20 {
+		t.Errorf("The connection has the maximum message size '%d', but expected the -statusd.max-response-size", requestHandler.(*commonbl.TcpHandler).MaxMessageSize)
+	}
+	if requestHandler.GetPipeFilePath() != "tcp://fileserver:9923" {
+		t.Errorf("The connection is '%s' but expected 'tcp://fileserver:9923'", requestHandler.GetPipeFilePath())
+	}
+
+	params.StatusdTLS.Enabled = true
+	requestHandler, _, err = getMessageHandlers()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+	if requestHandler.GetPipeFilePath() != "tls://fileserver:9923" {
+		t.Errorf("The connection is '%s' but expected 'tls://fileserver:9923'", requestHandler.GetPipeFilePath())
+	}
+
+	params.StatusdTLS.CAFile = filepath.Join(t.TempDir(), "not_existing.pem")
+	_, _, err = getMessageHandlers()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the CA file does not exist")
+	}
+}
+
+func TestGetMessageHandlersWithAuthSecret(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.Test = true
+	logger = testhelper.NewTestLogger(true)
+
+	params.AuthSecretFile = filepath.Join(t.TempDir(), "secret")
+	_, _, err := getMessageHandlers()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the secret file does not exist")
+	}
+
+	os.WriteFile(params.AuthSecretFile, []byte("my secret\n"), 0600)
+	requestHandler, responseHandler, err := getMessageHandlers()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	switch requestHandler.(type) {
+	case *commonbl.SigningHandler:
+		fmt.Println("OK")
+	default:
+		t.Errorf("The request handler does not sign the requests")
+	}
+	if requestHandler.GetPipeFilePath() == responseHandler.GetPipeFilePath() {
+		t.Errorf("The same pipe '%s' is used for requests and responses", requestHandler.GetPipeFilePath())
+	}
+
+	params.StatusdAddress = "fileserver:9923"
+	requestHandler, responseHandler, err = getMessageHandlers()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if requestHandler != responseHandler {
+		t.Errorf("Different connections are used for requests and responses")
+	}
+}
+
+func TestGetGrpcClient(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	conn, client, err := getGrpcClient()
+	if err != nil || conn != nil || client != nil {
+		t.Errorf("Got a gRPC client, but -statusd.grpc is not set")
+	}
+
+	params.StatusdGrpc = true
+	_, _, err = getGrpcClient()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since no -statusd.address is set")
+	}
+
+	params.StatusdAddress = "fileserver:9924"
+	conn, client, err = getGrpcClient()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	defer conn.Close()
+	if client == nil {
+		t.Errorf("Got no gRPC client, but -statusd.grpc is set")
+	}
+}
+
+func TestGetRemoteWriter(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	logger = testhelper.NewTestLogger(true)
+	registry := prometheus.NewRegistry()
+
+	writer, err := getRemoteWriter(registry)
+	if err != nil || writer != nil {
+		t.Errorf("Got a remote writer, but no -remote-write.url is set")
+	}
+
+	params.RemoteWrite.URL = "http://mimir:9009/api/v1/push"
+	params.RemoteWrite.Interval = time.Minute
+	params.RemoteWrite.PasswordFile = filepath.Join(t.TempDir(), "password")
+	_, err = getRemoteWriter(registry)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since no -remote-write.username is set")
+	}
+
+	params.RemoteWrite.Username = "exporter"
+	_, err = getRemoteWriter(registry)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the password file does not exist")
+	}
+
+	os.WriteFile(params.RemoteWrite.PasswordFile, []byte("my password\n"), 0600)
+	writer, err = getRemoteWriter(registry)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if writer.Username != "exporter" || writer.Password != "my password" {
+		t.Errorf("The remote writer has not the expected basic authentication")
+	}
+
+	params.RemoteWrite.BearerTokenFile = params.RemoteWrite.PasswordFile
+	_, err = getRemoteWriter(registry)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the basic authentication and a bearer token are set")
+	}
+}
+
+func TestGetOTLPExporter(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	logger = testhelper.NewTestLogger(true)
+	registry := prometheus.NewRegistry()
+
+	exporter, err := getOTLPExporter(registry)
+	if err != nil || exporter != nil {
+		t.Errorf("Got an OTLP exporter, but no -otlp.endpoint is set")
+	}
+
+	params.OTLP.Endpoint = "http://collector:4318/v1/metrics"
+	params.OTLP.Interval = time.Minute
+	params.OTLP.Headers = "Authorization=Basic c2FtYmE6c2VjcmV0, X-Scope-OrgID = fileserver"
+	exporter, err = getOTLPExporter(registry)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if exporter.Headers["Authorization"] != "Basic c2FtYmE6c2VjcmV0" || exporter.Headers["X-Scope-OrgID"] != "fileserver" {
+		t.Errorf("The headers %v are not expected", exporter.Headers)
+	}
+
+	params.OTLP.Headers = "Authorization"
+	_, err = getOTLPExporter(registry)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the header has no value")
+	}
+}
+
+func TestGetInfluxWriter(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	logger = testhelper.NewTestLogger(true)
+	registry := prometheus.NewRegistry()
+
+	writer, err := getInfluxWriter(registry)
+	if err != nil || writer != nil {
+		t.Errorf("Got an Influx writer, but no -influx.target is set")
+	}
+
+	params.Influx.Target = "http://influxdb:8086/api/v2/write?org=it&bucket=samba"
+	params.Influx.Interval = time.Minute
+	params.Influx.TokenFile = filepath.Join(t.TempDir(), "token")
+	_, err = getInfluxWriter(registry)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the token file does not exist")
+	}
+
+	os.WriteFile(params.Influx.TokenFile, []byte("my token\n"), 0600)
+	writer, err = getInfluxWriter(registry)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if writer.Token != "my token" {
+		t.Errorf("The token '%s' is not expected", writer.Token)
+	}
+}
+
+func TestGetStatsdEmitter(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	logger = testhelper.NewTestLogger(true)
+	registry := prometheus.NewRegistry()
+
+	emitter, err := getStatsdEmitter(registry)
+	if err != nil || emitter != nil {
+		t.Errorf("Got a statsd emitter, but no -statsd.address is set")
+	}
+
+	params.Statsd.Address = "127.0.0.1:8125"
+	params.Statsd.Interval = time.Minute
+	params.Statsd.TagFormat = "graphite"
+	_, err = getStatsdEmitter(registry)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the tag format is not known")
+	}
+
+	params.Statsd.TagFormat = smbexporter.STATSD_TAGS_NONE
+	emitter, err = getStatsdEmitter(registry)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	emitter.Close()
+}
+
+func TestGetZabbixSender(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	logger = testhelper.NewTestLogger(true)
+	registry := prometheus.NewRegistry()
+
+	sender, err := getZabbixSender(registry)
+	if err != nil || sender != nil {
+		t.Errorf("Got a Zabbix sender, but no -zabbix.server is set")
+	}
+
+	params.Zabbix.Server = "zabbix"
+	params.Zabbix.Interval = 0
+	_, err = getZabbixSender(registry)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the interval is 0")
+	}
+
+	params.Zabbix.Interval = time.Minute
+	sender, err = getZabbixSender(registry)
+	if err != nil || sender == nil {
+		t.Errorf("Got no Zabbix sender for the host name of the system, error: '%v'", err)
+	}
+}
+
+func TestGetWebTLSConfig(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	certFile, keyFile := writeTestCertificate(t)
+
+	config, err := getWebTLSConfig()
+	if err != nil || config != nil {
+		t.Errorf("Got a TLS configuration, but no -web.tls.cert-file is set")
+	}
+
+	params.WebTLS.ClientCAFile = certFile
+	_, err = getWebTLSConfig()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the -web.tls.client-ca-file is set without -web.tls.cert-file")
+	}
+
+	params.WebTLS.CertFile = certFile
+	params.WebTLS.KeyFile = keyFile
+	config, err = getWebTLSConfig()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if config.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("Got the client authentication '%s', but expected '%s'", config.ClientAuth, tls.RequireAndVerifyClientCert)
+	}
+
+	listener, errListen := commonbl.ListenTcp("127.0.0.1:0", config)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+	// The failed handshake of the client without certificate is logged by the server, so discard its log
+	server := http.Server{ErrorLog: log.New(io.Discard, "", 0), Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("samba_server_up 1\n"))
+	})}
+	go server.Serve(listener)
+
+	clientConfig, errClient := commonbl.GetClientTLSConfig(certFile, certFile, keyFile, "localhost")
+	if errClient != nil {
+		t.Fatalf("Got error '%s' but expected none", errClient.Error())
+	}
+	client := http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+	response, errGet := client.Get("https://" + listener.Addr().String() + "/metrics")
+	if errGet != nil {
+		t.Fatalf("Got error '%s' but expected none", errGet.Error())
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Got status code '%d' but expected '%d'", response.StatusCode, http.StatusOK)
+	}
+
+	clientConfig, _ = commonbl.GetClientTLSConfig(certFile, "", "", "localhost")
+	noCertClient := http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+	response, errGet = noCertClient.Get("https://" + listener.Addr().String() + "/metrics")
+	if errGet == nil {
+		response.Body.Close()
+		t.Errorf("Got no error but expected one, since the client has no certificate")
+	}
+}
+
+// writeTestCertificate - Write a self signed certificate for 'localhost' usable by servers and clients to the temp dir
+func writeTestCertificate(t *testing.T) (string, string) {
+	key, errKey := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if errKey != nil {
+		t.Fatalf("Got error '%s' but expected none", errKey.Error())
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, errCreate := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if errCreate != nil {
+		t.Fatalf("Got error '%s' but expected none", errCreate.Error())
+	}
+	keyDer, errMarshal := x509.MarshalECPrivateKey(key)
+	if errMarshal != nil {
+		t.Fatalf("Got error '%s' but expected none", errMarshal.Error())
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	return certFile, keyFile
+}
@@ -6,46 +6,436 @@ package main
 // LICENSE file.
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"math"
+	"net"
 	"os"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
 	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/configfile"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
 	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/statusdrpc"
 )
 
 // The paramters for this executable
 type parmeters struct {
 	commonbl.Parmeters
 	statisticsGenerator.StatisticsGeneratorSettings
-	TestPipeMode   bool
-	ListenAddress  string
-	MetricsPath    string
-	RequestTimeOut int
+	TestPipeMode        bool
+	ListenAddresses     listenAddressFlag
+	MetricsPath         string
+	RequestTimeOut      int
+	RequestRetries      int
+	RequestRetryBackoff time.Duration
+	StaleDataMaxAge     time.Duration
+	ConfigFile          string
+	Labels              labelFlag
+	StatusdAddress      string
+	StatusdGrpc         bool
+	StatusdTLS          statusdTLSParameters
+	WebTLS              webTLSParameters
+	RemoteWrite         remoteWriteParameters
+	OTLP                otlpParameters
+	Influx              influxParameters
+	Statsd              statsdParameters
+	Zabbix              zabbixParameters
+	AuthSecretFile      string
+	PipeDirectory       string
+	LogRawLines         bool
+	TimeLayouts         timeLayoutFlag
+	SambaTimezone       string
+	StateFile           string
+	CardinalityLimit    int
+	ReverseDNS          bool
+	ReverseDNSTTL       time.Duration
+	GeoIPDatabases      string
+	PrivacyHashKeyFile  string
+	SharesInclude       string
+	SharesExclude       string
+	// When set, the go runtime or process metrics of the exporter itself are not exported
+	DisableGoMetrics      bool
+	DisableProcessMetrics bool
+	// When set, the current samba status is served as JSON on STATUS_API_PATH
+	EnableStatusApi bool
+	// When set, the last raw responses of samba_statusd are served on DEBUG_RAW_PATH to requests with the token of the file
+	DebugTokenFile string
+	// When greater 0, no requests are sent to samba_statusd for the RequestBreakerCooldown, after this number of requests timed out in a row
+	RequestBreakerThreshold int
+	RequestBreakerCooldown  time.Duration
+	// When greater 0, the scrapes of the metrics endpoint are limited to this rate per second, or this number running at the same time
+	MaxScrapeRate        float64
+	MaxConcurrentScrapes int
+	// When set, only clients in these comma separated networks can scrape the metrics endpoint
+	AllowedCIDRs string
+	// When set, the web interface is served on this unix socket instead of the ListenAddress
+	ListenSocket string
+	// When set, each request to the web interface is logged
+	AccessLog bool
+	// When greater 0, each error message is logged at most once in this time
+	LogDedupInterval time.Duration
+	// When greater 0, the memory limit of the go runtime, and the collections are aborted when parsing a response would exceed it
+	MemoryLimit byteSizeFlag
+	// When greater 0, the responses of samba_statusd larger than this are discarded
+	StatusdMaxResponseSize byteSizeFlag
+	// When set, the entries of smbd processes that are not in the process table of samba_statusd are dropped
+	StatusdDropDeadEntries bool
+	// When set, the cluster nodes are exported by their address, as listed by samba_statusd
+	ClusterNodeNames bool
+
+	// The values of the -collector.<name> and -no-collector.<name> flags
+	collectorEnabled  map[string]*bool
+	collectorDisabled map[string]*bool
+}
+
+// The paramters for the TLS connection to a samba_statusd listening on TCP
+type statusdTLSParameters struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// The paramters for TLS on the -web.listen-address
+type webTLSParameters struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+// The paramters for pushing the metrics to a Prometheus remote write endpoint
+type remoteWriteParameters struct {
+	URL             string
+	Interval        time.Duration
+	Timeout         time.Duration
+	Username        string
+	PasswordFile    string
+	BearerTokenFile string
+}
+
+// The paramters for sending the metrics to an OpenTelemetry collector
+type otlpParameters struct {
+	Endpoint string
+	Interval time.Duration
+	Timeout  time.Duration
+	Headers  string
+}
+
+// The paramters for writing the metrics in the InfluxDB line protocol
+type influxParameters struct {
+	Target    string
+	Interval  time.Duration
+	Timeout   time.Duration
+	TokenFile string
+}
+
+// The paramters for sending the metrics to a statsd server
+type statsdParameters struct {
+	Address   string
+	Interval  time.Duration
+	TagFormat string
+}
+
+// The paramters for sending the metrics to a Zabbix server
+type zabbixParameters struct {
+	Server   string
+	Host     string
+	Interval time.Duration
+	Timeout  time.Duration
 }
 
 var params parmeters
 
+// labelFlag - The value of the repeatable -label parameter, a map of label names and values
+type labelFlag map[string]string
+
+var labelNameRegex = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+func (labels labelFlag) String() string { // Implement the flag.Value Interface for the labelFlag type
+	var pairs []string
+	for key, value := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// Set - Add the comma separated 'key=value' pairs to the labels
+func (labels labelFlag) Set(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		key, labelValue, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || !labelNameRegex.MatchString(key) || strings.HasPrefix(key, "__") {
+			return fmt.Errorf("The label \"%s\" is not in the format 'key=value' with a valid label name", pair)
+		}
+		labels[key] = labelValue
+	}
+
+	return nil
+}
+
+// timeLayoutFlag - The value of the repeatable -time-layout parameter, a list of layouts in the format of the time package
+type timeLayoutFlag []string
+
+func (layouts *timeLayoutFlag) String() string { // Implement the flag.Value Interface for the timeLayoutFlag type
+	if layouts == nil {
+		return ""
+	}
+
+	return strings.Join(*layouts, "; ")
+}
+
+// Set - Add the layout to the list. The layout is not split, since layouts may contain ','
+func (layouts *timeLayoutFlag) Set(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("The time layout is empty")
+	}
+	*layouts = append(*layouts, value)
+
+	return nil
+}
+
+// The units of the byte size parameters, the same as of the GOMEMLIMIT environment variable
+var byteSizeUnits = []struct {
+	suffix string
+	factor int64
+}{{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}, {"B", 1}}
+
+// byteSizeFlag - The value of a parameter in bytes, like -memory.limit, given like the GOMEMLIMIT environment variable
+type byteSizeFlag int64
+
+func (size *byteSizeFlag) String() string { // Implement the flag.Value Interface for the byteSizeFlag type
+	if size == nil || *size == 0 {
+		return ""
+	}
+	for _, unit := range byteSizeUnits {
+		if int64(*size)%unit.factor == 0 {
+			return fmt.Sprintf("%d%s", int64(*size)/unit.factor, unit.suffix)
+		}
+	}
+
+	return fmt.Sprintf("%dB", int64(*size))
+}
+
+// Set - Parse the size in bytes, or with one of the units 'KiB', 'MiB', 'GiB' or 'TiB'
+func (size *byteSizeFlag) Set(value string) error {
+	value = strings.TrimSpace(value)
+	for _, unit := range byteSizeUnits {
+		number, found := strings.CutSuffix(value, unit.suffix)
+		if !found {
+			continue
+		}
+		bytes, errParse := strconv.ParseInt(number, 10, 64)
+		if errParse != nil || bytes <= 0 || bytes > math.MaxInt64/unit.factor {
+			break
+		}
+		*size = byteSizeFlag(bytes * unit.factor)
+
+		return nil
+	}
+
+	return fmt.Errorf("The size \"%s\" is not a positive number with one of the units 'B', 'KiB', 'MiB', 'GiB' or 'TiB', like '512MiB'", value)
+}
+
+// The address the web interface listens on, when no -web.listen-address is given
+const DEFAULT_LISTEN_ADDRESS = ":9922"
+
+// listenAddressFlag - The value of the repeatable -web.listen-address parameter, a list of addresses in the format 'host:port'
+type listenAddressFlag []string
+
+func (addresses *listenAddressFlag) String() string { // Implement the flag.Value Interface for the listenAddressFlag type
+	if addresses == nil {
+		return ""
+	}
+
+	return strings.Join(*addresses, ",")
+}
+
+// Set - Add the comma separated addresses to the list
+func (addresses *listenAddressFlag) Set(value string) error {
+	for _, address := range strings.Split(value, ",") {
+		address = strings.TrimSpace(address)
+		if _, _, errSplit := net.SplitHostPort(address); errSplit != nil {
+			return fmt.Errorf("The listen address \"%s\" is not in the format 'host:port'", address)
+		}
+		*addresses = append(*addresses, address)
+	}
+
+	return nil
+}
+
+// getListenAddresses - Get the addresses given with -web.listen-address, or the DEFAULT_LISTEN_ADDRESS when none is given
+func getListenAddresses() []string {
+	if len(params.ListenAddresses) == 0 {
+		return []string{DEFAULT_LISTEN_ADDRESS}
+	}
+
+	return params.ListenAddresses
+}
+
 // Setup commandline parameters  and parse them
 func handleComandlineOptions() {
 
 	// Setup the usabel parametes
 	flag.BoolVar(&params.PrintVersion, "print-version", false, "With this flag the program will only print it's version and exit")
-	flag.BoolVar(&params.Verbose, "verbose", false, "With this flag the program will print verbose output")
+	flag.BoolVar(&params.Verbose, "verbose", false, "With this flag the program will print verbose output. Send SIGUSR2 to switch the verbose output on or off while running")
 	flag.BoolVar(&params.Test, "test-mode", false,
 		"Run the program in test mode. In this mode the program will always return the same test data. To work with samba_statusd both programs needs to run in test mode or not.")
 	flag.BoolVar(&params.Help, "help", false, "Print this help message")
 	flag.BoolVar(&params.TestPipeMode, "test-pipe", false, "Requests status from samba_statusd and exits. May be combined with -test-mode.")
-	flag.StringVar(&params.ListenAddress, "web.listen-address", ":9922", "Address to listen on for web interface and telemetry.")
+	flag.Var(&params.ListenAddresses, "web.listen-address",
+		"Address to listen on for web interface and telemetry. Repeat the parameter or separate the addresses with ',' to listen on multiple addresses, "+
+			"e. g. on IPv4 and IPv6 or on multiple interfaces (default \""+DEFAULT_LISTEN_ADDRESS+"\")")
+	flag.StringVar(&params.ListenSocket, "web.listen-socket", "",
+		"Path to a unix socket the web interface is served on instead of the -web.listen-address, e. g. for a local reverse proxy. When not set, the -web.listen-address is used")
 	flag.StringVar(&params.MetricsPath, "web.telemetry-path", "/metrics", "Path under which to expose metrics.")
 	flag.IntVar(&params.RequestTimeOut, "request-timeout", 5, "The timeout for a request to samba_statusd in seconds")
+	flag.IntVar(&params.RequestRetries, "request-retries", 0, "How often a request to samba_statusd that timed out is sent again")
+	flag.DurationVar(&params.RequestRetryBackoff, "request-retry-backoff", time.Second,
+		"The time to wait before the first retry of a request to samba_statusd, it doubles with each further retry")
+	flag.IntVar(&params.RequestBreakerThreshold, "request-breaker-threshold", 0,
+		"The number of requests to samba_statusd that timed out in a row, after which no requests are sent for the -request-breaker-cooldown. The circuit breaker is not used when 0")
+	flag.DurationVar(&params.RequestBreakerCooldown, "request-breaker-cooldown", time.Minute,
+		"The time no requests are sent to samba_statusd, once the -request-breaker-threshold is reached")
 	flag.BoolVar(&params.DoNotExportEncryption, "not-expose-encryption-data", false, "Set to 'true', no details about the used encryption or signing will be exported")
 	flag.BoolVar(&params.DoNotExportClient, "not-expose-client-data", false, "Set to 'true', no details about the connected clients will be exported")
 	flag.BoolVar(&params.DoNotExportUser, "not-expose-user-data", false, "Set to 'true', no details about the connected users will be exported")
 	flag.BoolVar(&params.DoNotExportPid, "not-expose-pid-data", false, "Set to 'true', no process IDs will be exported")
 	flag.BoolVar(&params.DoNotExportShareDetails, "not-expose-share-details", false, "Set to 'true', no details about the shares will be exported")
+	flag.IntVar(&params.LockedFilesTopN, "locked-files.top-n", 0,
+		fmt.Sprintf("Export the path and number of locks of the given number of most locked files, at most %d. Use only for debugging, each file is an own time series", statisticsGenerator.MAX_LOCKED_FILES_TOP_N))
+	flag.StringVar(&params.PrivacyMode, "privacy.mode", "",
+		fmt.Sprintf("Set to '%s', the values of the labels identifying persons (user, client_ip, client_host, share and path) are hashed. Set to '%s', the metrics with these labels are not exported",
+			statisticsGenerator.PRIVACY_MODE_HASH, statisticsGenerator.PRIVACY_MODE_OMIT))
+	flag.StringVar(&params.PrivacyHashKeyFile, "privacy.hash-key-file", "",
+		"Path to a file containing the key used to hash the labels with '-privacy.mode=hash', so the hashed values can not be guessed")
+	flag.StringVar(&params.SharesInclude, "shares.include", "",
+		"Regular expression of the shares whose locks and connections are exported. Matched against the name of a share, or its path for the locks")
+	flag.StringVar(&params.SharesExclude, "shares.exclude", "",
+		"Regular expression of the shares whose locks and connections are not exported, e. g. 'IPC\\$|print\\$'. Matched against the name of a share, or its path for the locks")
 	flag.StringVar(&params.LogFilePath, "log-file-path", " ",
 		"Give the full file path for a log file. When parameter is not set (as by default), logs will be written to stdout and stderr")
+	params.collectorEnabled = make(map[string]*bool)
+	params.collectorDisabled = make(map[string]*bool)
+	for _, collector := range statisticsGenerator.GetCollectorNames() {
+		help := statisticsGenerator.GetCollectorHelp(collector)
+		params.collectorEnabled[collector] = flag.Bool(fmt.Sprintf("collector.%s", collector), true,
+			fmt.Sprintf("Export the metrics about %s. Set to 'false' to disable the collector", help))
+		params.collectorDisabled[collector] = flag.Bool(fmt.Sprintf("no-collector.%s", collector), false,
+			fmt.Sprintf("Do not export the metrics about %s", help))
+	}
+	params.Labels = make(labelFlag)
+	flag.Var(params.Labels, "label",
+		"Add a label with a constant value to every exported metric. Given as 'key=value', repeat the parameter or separate the pairs with ',' to add multiple labels")
+	flag.DurationVar(&params.StaleDataMaxAge, "statusd.stale-data-max-age", 0,
+		"When samba_statusd can not be reached, export the samba status of the last successful request for at most this time, e. g. '5m'. "+
+			"The age is exported as samba_data_stale_seconds. When 0, no samba status is exported while samba_statusd can not be reached")
+	flag.Var(&params.StatusdMaxResponseSize, "statusd.max-response-size",
+		"The maximum size of a response of samba_statusd, like '64MiB'. Larger responses are discarded without keeping them in memory, "+
+			"and the scrape fails with samba_server_up 0. When not set, the responses are not limited")
+	flag.BoolVar(&params.StatusdDropDeadEntries, "statusd.drop-dead-entries", false,
+		"Set to 'true', the locks, shares and processes of smbd processes that are not running anymore are dropped. "+
+			"The PIDs are checked against the smbd processes samba_statusd found, the number of dropped entries is exported as samba_dead_entries_dropped_total")
+	flag.BoolVar(&params.ClusterNodeNames, "cluster.node-names", false,
+		"Set to 'true', the node label of the cluster metrics is the address of the node instead of its number. "+
+			"samba_statusd lists the nodes with 'ctdb listnodes'")
+	flag.StringVar(&params.StatusdAddress, "statusd.address", "",
+		"Address of a samba_statusd listening on TCP, e. g. 'fileserver:9923'. When set, the named pipes are not used")
+	flag.BoolVar(&params.StatusdGrpc, "statusd.grpc", false, "Use the gRPC service of the samba_statusd on the -statusd.address")
+	flag.BoolVar(&params.StatusdTLS.Enabled, "statusd.tls.enabled", false, "Use TLS for the connection to the -statusd.address")
+	flag.StringVar(&params.StatusdTLS.CAFile, "statusd.tls.ca-file", "",
+		"Path to the PEM encoded CA certificates used to verify samba_statusd. When not set, the CAs of the system are used")
+	flag.StringVar(&params.StatusdTLS.CertFile, "statusd.tls.cert-file", "", "Path to the PEM encoded client certificate used to connect to samba_statusd")
+	flag.StringVar(&params.StatusdTLS.KeyFile, "statusd.tls.key-file", "", "Path to the PEM encoded private key of the -statusd.tls.cert-file")
+	flag.StringVar(&params.StatusdTLS.ServerName, "statusd.tls.server-name", "",
+		"The name expected in the certificate of samba_statusd. When not set, the host of the -statusd.address is used")
+	flag.StringVar(&params.RemoteWrite.URL, "remote-write.url", "",
+		"URL of a Prometheus remote write endpoint, e. g. of Mimir, Thanos or VictoriaMetrics. When set, the metrics are pushed to the endpoint in the -remote-write.interval")
+	flag.DurationVar(&params.RemoteWrite.Interval, "remote-write.interval", 30*time.Second, "The interval the metrics are pushed to the -remote-write.url")
+	flag.DurationVar(&params.RemoteWrite.Timeout, "remote-write.timeout", 10*time.Second, "The time to wait for the answer of the -remote-write.url")
+	flag.StringVar(&params.RemoteWrite.Username, "remote-write.username", "", "The user for the basic authentication at the -remote-write.url")
+	flag.StringVar(&params.RemoteWrite.PasswordFile, "remote-write.password-file", "",
+		"Path to a file containing the password for the basic authentication of the -remote-write.username")
+	flag.StringVar(&params.RemoteWrite.BearerTokenFile, "remote-write.bearer-token-file", "",
+		"Path to a file containing the bearer token sent to the -remote-write.url, instead of the basic authentication")
+	flag.StringVar(&params.OTLP.Endpoint, "otlp.endpoint", "",
+		"URL the metrics are sent to with OTLP over HTTP, e. g. 'http://collector:4318/v1/metrics'. When set, the metrics are sent to the OpenTelemetry collector in the -otlp.interval")
+	flag.DurationVar(&params.OTLP.Interval, "otlp.interval", 30*time.Second, "The interval the metrics are sent to the -otlp.endpoint")
+	flag.DurationVar(&params.OTLP.Timeout, "otlp.timeout", 10*time.Second, "The time to wait for the answer of the -otlp.endpoint")
+	flag.StringVar(&params.OTLP.Headers, "otlp.headers", "",
+		"Headers added to the requests to the -otlp.endpoint, e. g. for the authentication. Given as 'key=value', separate the pairs with ','")
+	flag.StringVar(&params.Influx.Target, "influx.target", "",
+		"URL of the write API of an InfluxDB or Telegraf, e. g. 'http://influxdb:8086/api/v2/write?org=it&bucket=samba', or the path of a file. When set, the metrics are written in the InfluxDB line protocol in the -influx.interval")
+	flag.DurationVar(&params.Influx.Interval, "influx.interval", 30*time.Second, "The interval the metrics are written to the -influx.target")
+	flag.DurationVar(&params.Influx.Timeout, "influx.timeout", 10*time.Second, "The time to wait for the answer of the -influx.target, when it is a URL")
+	flag.StringVar(&params.Influx.TokenFile, "influx.token-file", "", "Path to a file containing the API token of the InfluxDB the metrics are written to")
+	flag.StringVar(&params.Statsd.Address, "statsd.address", "",
+		"UDP address of a statsd server or Datadog agent, e. g. 'localhost:8125'. When set, the metrics are sent as statsd gauges and counters in the -statsd.interval")
+	flag.DurationVar(&params.Statsd.Interval, "statsd.interval", 30*time.Second, "The interval the metrics are sent to the -statsd.address")
+	flag.StringVar(&params.Statsd.TagFormat, "statsd.tag-format", smbexporter.STATSD_TAGS_DOGSTATSD,
+		fmt.Sprintf("How the labels are sent to the -statsd.address, '%s' as DogStatsD tags or '%s' for statsd servers without tags", smbexporter.STATSD_TAGS_DOGSTATSD, smbexporter.STATSD_TAGS_NONE))
+	flag.StringVar(&params.Zabbix.Server, "zabbix.server", "",
+		"Address of a Zabbix server or proxy, e. g. 'zabbix:10051'. When set, the metrics are sent to the trapper items of the -zabbix.host in the -zabbix.interval")
+	flag.StringVar(&params.Zabbix.Host, "zabbix.host", "", "The name of the host in Zabbix the metrics are sent for. When not set, the host name of the system is used")
+	flag.DurationVar(&params.Zabbix.Interval, "zabbix.interval", 30*time.Second, "The interval the metrics are sent to the -zabbix.server")
+	flag.DurationVar(&params.Zabbix.Timeout, "zabbix.timeout", 10*time.Second, "The time to wait for the answer of the -zabbix.server")
+	flag.StringVar(&params.PipeDirectory, "pipe.directory", "",
+		"The directory of the named pipes. When not set, '/run' is used, or '/dev/shm' in test mode. samba_statusd needs the same directory")
+	flag.StringVar(&params.AuthSecretFile, "auth.secret-file", "",
+		"Path to a file with a shared secret. When set, the requests to samba_statusd are signed and only signed responses are accepted. samba_statusd needs the same secret")
+	flag.DurationVar(&params.LogDedupInterval, "log.dedup-interval", 0,
+		"The minimum time between two equal error messages, e. g. '5m'. Repetitions within the time are counted and logged with the message, once the time passed. When 0, each error is logged")
+	flag.Var(&params.MemoryLimit, "memory.limit",
+		"The memory the exporter should not exceed, like '512MiB'. The go runtime collects garbage more often when getting close, and a scrape is aborted "+
+			"when parsing the smbstatus output would exceed the limit. When not set, the GOMEMLIMIT environment variable is used")
+	flag.BoolVar(&params.LogRawLines, "log.raw-lines", false,
+		"Add the smbstatus line to the errors about lines that can not be parsed, and log each parsed entry with its line as verbose message")
+	flag.Var(&params.TimeLayouts, "time-layout",
+		"An additional layout of the time stamps in the smbstatus output, like 'Mon 2 January 2006 15:04:05'. The german and french day and month names are translated before. Repeat the parameter to add multiple layouts")
+	flag.StringVar(&params.SambaTimezone, "samba-timezone", "",
+		"The timezone of the samba server, like 'Europe/Berlin'. Time stamps without zone are read in this timezone. When not set, the local timezone is used")
+	flag.BoolVar(&params.DisableGoMetrics, "web.disable-go-metrics", false, "Set to 'true', the go runtime metrics of the exporter (go_*) will not be exported")
+	flag.BoolVar(&params.DisableProcessMetrics, "web.disable-process-metrics", false, "Set to 'true', the process metrics of the exporter (process_*) will not be exported")
+	flag.BoolVar(&params.EnableStatusApi, "web.enable-status-api", false,
+		"Set to 'true', the current locks, shares and processes are served as JSON on '"+STATUS_API_PATH+"'. Can not be combined with -privacy.mode")
+	flag.StringVar(&params.DebugTokenFile, "web.debug-token-file", "",
+		"Path to a file with a bearer token. When set, the last raw responses of samba_statusd are served on '"+DEBUG_RAW_PATH+"' to requests with the header 'Authorization: Bearer <token>'")
+	flag.Float64Var(&params.MaxScrapeRate, "web.max-scrape-rate", 0,
+		"The maximum number of scrapes of the metrics endpoint per second, like '0.2' for one scrape every 5 seconds. Further scrapes are answered with 429. 0 for no limit")
+	flag.IntVar(&params.MaxConcurrentScrapes, "web.max-concurrent-scrapes", 0,
+		"The maximum number of scrapes of the metrics endpoint handled at the same time. Further scrapes are answered with 503. 0 for no limit")
+	flag.StringVar(&params.WebTLS.CertFile, "web.tls.cert-file", "",
+		"Path to the PEM encoded certificate used for TLS on the -web.listen-address. When not set, the metrics are served without TLS. Reloaded once the file changes")
+	flag.StringVar(&params.WebTLS.KeyFile, "web.tls.key-file", "", "Path to the PEM encoded private key of the -web.tls.cert-file")
+	flag.StringVar(&params.WebTLS.ClientCAFile, "web.tls.client-ca-file", "",
+		"Path to the PEM encoded CA certificates. When set, only clients with a certificate signed by one of this CAs can connect to the -web.listen-address")
+	flag.StringVar(&params.AllowedCIDRs, "web.allowed-cidrs", "",
+		"Comma separated networks like '10.1.0.0/24,192.168.1.5', only clients in these networks can scrape the metrics endpoint, others are answered with 403. When not set, all clients can scrape")
+	flag.BoolVar(&params.AccessLog, "web.access-log", false,
+		"Set to 'true', each request to the web interface is logged with the method, path, client IP, status code and duration")
+	flag.StringVar(&params.StateFile, "state.file", "",
+		"Path to a file the values of the counters of the exporter are kept in, so they are not reset when the exporter restarts. When not set, the counters start with 0")
+	flag.IntVar(&params.CardinalityLimit, "cardinality.limit", 0,
+		"The maximum number of series exported for a metric, the others are collapsed into one series with the labels 'other'. 0 for no limit")
+	flag.BoolVar(&params.ReverseDNS, "clients.reverse-dns", false, "Set to 'true', the addresses of the clients are resolved to their host names with reverse DNS lookups")
+	flag.DurationVar(&params.ReverseDNSTTL, "clients.reverse-dns-ttl", 5*time.Minute, "The time the host names of the -clients.reverse-dns lookups are cached")
+	flag.StringVar(&params.GeoIPDatabases, "clients.geoip-databases", "",
+		"Comma separated paths of MaxMind DB files, e. g. a GeoLite2-Country and a GeoLite2-ASN database. When given, the client connection metrics get the labels country and asn")
+	flag.StringVar(&params.ConfigFile, "config.file", "",
+		"Path to a YAML configuration file. The keys of the file are the names of the parameters. Parameters given on the command line override the values of the file")
 
 	// Overwrite the std Usage function with some custom stuff
 	flag.Usage = customHelpMessage
@@ -54,14 +444,291 @@ func handleComandlineOptions() {
 	flag.Parse()
 }
 
+// applyConfigFile - Use the settings of the configuration file for all parameters not given on the command line
+func applyConfigFile() error {
+	if params.ConfigFile == "" {
+		return nil
+	}
+
+	return configfile.ApplyConfigFile(params.ConfigFile, flag.CommandLine)
+}
+
+// getRequestSettings - Get the timeout and retry settings for requests to samba_statusd
+func getRequestSettings() pipecomunication.RequestSettings {
+	return pipecomunication.RequestSettings{TimeOut: params.RequestTimeOut, Retries: params.RequestRetries, RetryBackoff: params.RequestRetryBackoff,
+		BreakerThreshold: params.RequestBreakerThreshold, BreakerCooldown: params.RequestBreakerCooldown, MemoryLimit: getMemoryLimit(),
+		MaxResponseSize: int(params.StatusdMaxResponseSize)}
+}
+
+// getMemoryLimit - Get the -memory.limit in bytes, or the limit set with the GOMEMLIMIT environment variable. 0 when no limit is set
+func getMemoryLimit() int64 {
+	if params.MemoryLimit > 0 {
+		return int64(params.MemoryLimit)
+	}
+	// A negative value does not change the limit, but tells the current one
+	limit := debug.SetMemoryLimit(-1)
+	if limit == math.MaxInt64 {
+		return 0
+	}
+
+	return limit
+}
+
+// getMessageHandlers - Get the handlers used to send requests to and receive responses from samba_statusd.
+// When a -statusd.address is given, a single TCP connection is used for both. When a -auth.secret-file is given, the messages are signed
+func getMessageHandlers() (commonbl.MessageHandler, commonbl.MessageHandler, error) {
+	var requestHandler, responseHandler commonbl.MessageHandler
+	if params.StatusdAddress == "" {
+		pipeSettings := commonbl.NewDefaultPipeSettings()
+		pipeSettings.Directory = params.PipeDirectory
+		requestHandler = commonbl.NewPipeHandlerWithSettings(params.Test, commonbl.RequestPipe, pipeSettings)
+		pipeHandler := commonbl.NewPipeHandlerWithSettings(params.Test, commonbl.ResposePipe, pipeSettings)
+		pipeHandler.MaxMessageSize = int(params.StatusdMaxResponseSize)
+		responseHandler = pipeHandler
+	} else {
+		tlsConfig, errConfig := getStatusdTLSConfig()
+		if errConfig != nil {
+			return nil, nil, errConfig
+		}
+		handler := commonbl.NewTcpClientHandler(params.StatusdAddress, tlsConfig)
+		handler.MaxMessageSize = int(params.StatusdMaxResponseSize)
+		handler.DialTimeout = time.Duration(params.RequestTimeOut) * time.Second
+		requestHandler = handler
+		responseHandler = handler
+	}
+
+	if params.AuthSecretFile == "" {
+		return requestHandler, responseHandler, nil
+	}
+
+	secret, errSecret := commonbl.ReadSecretFile(params.AuthSecretFile)
+	if errSecret != nil {
+		return nil, nil, errSecret
+	}
+	if requestHandler == responseHandler {
+		signedHandler := commonbl.NewSigningHandler(requestHandler, secret, logger)
+		return signedHandler, signedHandler, nil
+	}
+
+	return commonbl.NewSigningHandler(requestHandler, secret, logger), commonbl.NewSigningHandler(responseHandler, secret, logger), nil
+}
+
+// getPrivacyHashKey - Get the key read from the -privacy.hash-key-file, nil when no file is given
+func getPrivacyHashKey() ([]byte, error) {
+	if params.PrivacyHashKeyFile == "" {
+		return nil, nil
+	}
+	if params.PrivacyMode != statisticsGenerator.PRIVACY_MODE_HASH {
+		return nil, fmt.Errorf("The parameter -privacy.hash-key-file needs the -privacy.mode '%s'", statisticsGenerator.PRIVACY_MODE_HASH)
+	}
+
+	return commonbl.ReadSecretFile(params.PrivacyHashKeyFile)
+}
+
+// getDebugToken - Get the token read from the -web.debug-token-file, nil when no file is given
+func getDebugToken() ([]byte, error) {
+	if params.DebugTokenFile == "" {
+		return nil, nil
+	}
+
+	return commonbl.ReadSecretFile(params.DebugTokenFile)
+}
+
+// getRemoteWriter - Get the RemoteWriter pushing the metrics of the gatherer as defined by the -remote-write.* parameters,
+// nil when no -remote-write.url is given
+func getRemoteWriter(gatherer prometheus.Gatherer) (*smbexporter.RemoteWriter, error) {
+	if params.RemoteWrite.URL == "" {
+		return nil, nil
+	}
+	if params.RemoteWrite.Username != "" && params.RemoteWrite.BearerTokenFile != "" {
+		return nil, fmt.Errorf("The parameters -remote-write.username and -remote-write.bearer-token-file can not be used together")
+	}
+	if params.RemoteWrite.PasswordFile != "" && params.RemoteWrite.Username == "" {
+		return nil, fmt.Errorf("The parameter -remote-write.password-file needs the -remote-write.username")
+	}
+
+	writer, errWriter := smbexporter.NewRemoteWriter(params.RemoteWrite.URL, params.RemoteWrite.Interval, params.RemoteWrite.Timeout, gatherer, logger)
+	if errWriter != nil {
+		return nil, errWriter
+	}
+	writer.Username = params.RemoteWrite.Username
+	if params.RemoteWrite.PasswordFile != "" {
+		password, errPassword := commonbl.ReadSecretFile(params.RemoteWrite.PasswordFile)
+		if errPassword != nil {
+			return nil, errPassword
+		}
+		writer.Password = string(password)
+	}
+	if params.RemoteWrite.BearerTokenFile != "" {
+		token, errToken := commonbl.ReadSecretFile(params.RemoteWrite.BearerTokenFile)
+		if errToken != nil {
+			return nil, errToken
+		}
+		writer.BearerToken = string(token)
+	}
+
+	return writer, nil
+}
+
+// getOTLPExporter - Get the OTLPExporter sending the metrics of the gatherer as defined by the -otlp.* parameters,
+// nil when no -otlp.endpoint is given
+func getOTLPExporter(gatherer prometheus.Gatherer) (*smbexporter.OTLPExporter, error) {
+	if params.OTLP.Endpoint == "" {
+		return nil, nil
+	}
+
+	exporter, errExporter := smbexporter.NewOTLPExporter(params.OTLP.Endpoint, params.OTLP.Interval, params.OTLP.Timeout, gatherer, version, logger)
+	if errExporter != nil {
+		return nil, errExporter
+	}
+	if params.OTLP.Headers == "" {
+		return exporter, nil
+	}
+	for _, pair := range strings.Split(params.OTLP.Headers, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("The header '%s' of the -otlp.headers is not given as 'key=value'", pair)
+		}
+		exporter.Headers[key] = strings.TrimSpace(value)
+	}
+
+	return exporter, nil
+}
+
+// getInfluxWriter - Get the InfluxWriter writing the metrics of the gatherer as defined by the -influx.* parameters,
+// nil when no -influx.target is given
+func getInfluxWriter(gatherer prometheus.Gatherer) (*smbexporter.InfluxWriter, error) {
+	if params.Influx.Target == "" {
+		return nil, nil
+	}
+
+	writer, errWriter := smbexporter.NewInfluxWriter(params.Influx.Target, params.Influx.Interval, params.Influx.Timeout, gatherer, logger)
+	if errWriter != nil {
+		return nil, errWriter
+	}
+	if params.Influx.TokenFile != "" {
+		token, errToken := commonbl.ReadSecretFile(params.Influx.TokenFile)
+		if errToken != nil {
+			return nil, errToken
+		}
+		writer.Token = string(token)
+	}
+
+	return writer, nil
+}
+
+// getStatsdEmitter - Get the StatsdEmitter sending the metrics of the gatherer as defined by the -statsd.* parameters,
+// nil when no -statsd.address is given
+func getStatsdEmitter(gatherer prometheus.Gatherer) (*smbexporter.StatsdEmitter, error) {
+	if params.Statsd.Address == "" {
+		return nil, nil
+	}
+
+	return smbexporter.NewStatsdEmitter(params.Statsd.Address, params.Statsd.Interval, params.Statsd.TagFormat, gatherer, logger)
+}
+
+// getZabbixSender - Get the ZabbixSender sending the metrics of the gatherer as defined by the -zabbix.* parameters,
+// nil when no -zabbix.server is given
+func getZabbixSender(gatherer prometheus.Gatherer) (*smbexporter.ZabbixSender, error) {
+	if params.Zabbix.Server == "" {
+		return nil, nil
+	}
+
+	host := params.Zabbix.Host
+	if host == "" {
+		hostName, errHost := os.Hostname()
+		if errHost != nil {
+			return nil, errHost
+		}
+		host = hostName
+	}
+
+	return smbexporter.NewZabbixSender(params.Zabbix.Server, host, params.Zabbix.Interval, params.Zabbix.Timeout, gatherer, logger)
+}
+
+// getGrpcClient - Get the client for the gRPC service of samba_statusd, when -statusd.grpc is set. Otherwise nil is returned
+func getGrpcClient() (*grpc.ClientConn, statusdrpc.SambaStatusClient, error) {
+	if !params.StatusdGrpc {
+		return nil, nil, nil
+	}
+	if params.StatusdAddress == "" {
+		return nil, nil, fmt.Errorf("The parameter -statusd.grpc needs the -statusd.address of samba_statusd")
+	}
+
+	tlsConfig, errConfig := getStatusdTLSConfig()
+	if errConfig != nil {
+		return nil, nil, errConfig
+	}
+
+	return pipecomunication.NewGrpcClient(params.StatusdAddress, tlsConfig)
+}
+
+// getStatusdTLSConfig - Get the TLS configuration defined by the -statusd.tls.* parameters, nil when TLS is not enabled
+func getStatusdTLSConfig() (*tls.Config, error) {
+	if !params.StatusdTLS.Enabled {
+		return nil, nil
+	}
+
+	return commonbl.GetClientTLSConfig(params.StatusdTLS.CAFile, params.StatusdTLS.CertFile, params.StatusdTLS.KeyFile, params.StatusdTLS.ServerName)
+}
+
+// getWebTLSConfig - Get the TLS configuration defined by the -web.tls.* parameters, nil when no certificate is given. The certificate is
+// reloaded once its files change
+func getWebTLSConfig() (*tls.Config, error) {
+	if params.WebTLS.CertFile == "" {
+		if params.WebTLS.ClientCAFile != "" {
+			return nil, fmt.Errorf("The parameter -web.tls.client-ca-file needs the -web.tls.cert-file")
+		}
+		return nil, nil
+	}
+
+	config, errConfig := commonbl.GetServerTLSConfig(params.WebTLS.CertFile, params.WebTLS.KeyFile, params.WebTLS.ClientCAFile)
+	if errConfig != nil {
+		return nil, errConfig
+	}
+	reloader, errReloader := newCertificateReloader(params.WebTLS.CertFile, params.WebTLS.KeyFile, WEB_TLS_RELOAD_INTERVAL)
+	if errReloader != nil {
+		return nil, errReloader
+	}
+	config.Certificates = nil
+	config.GetCertificate = reloader.getCertificate
+
+	return config, nil
+}
+
+// getDisabledCollectors - Get the names of the collectors disabled by the -collector.<name> and -no-collector.<name> flags
+func getDisabledCollectors() []string {
+	var ret []string
+	for _, collector := range statisticsGenerator.GetCollectorNames() {
+		enabled, foundEnabled := params.collectorEnabled[collector]
+		disabled, foundDisabled := params.collectorDisabled[collector]
+		if (foundEnabled && !*enabled) || (foundDisabled && *disabled) {
+			ret = append(ret, collector)
+		}
+	}
+
+	return ret
+}
+
 // customHelpMessage - Print he customized help message
 func customHelpMessage() {
 	fmt.Fprintln(os.Stdout, fmt.Sprintf("%s: prometheus exporter for the samba file server. Collects data using the samba_statusd service.", os.Args[0]))
 	fmt.Fprintln(os.Stdout, fmt.Sprintf("Program %s", getVersion()))
 	fmt.Fprintln(os.Stdout)
-	fmt.Fprintln(os.Stdout, fmt.Sprintf("Usage: %s [options]", os.Args[0]))
+	fmt.Fprintln(os.Stdout, fmt.Sprintf("Usage: %s [options] [subcommand]", os.Args[0]))
 	fmt.Fprintln(os.Stdout, "Options:")
 	flag.PrintDefaults()
 	fmt.Fprintln(os.Stdout)
+	fmt.Fprintln(os.Stdout, "Subcommands:")
+	fmt.Fprintln(os.Stdout, fmt.Sprintf("  %s [-warn sessions=N,locks=N] [-crit sessions=N,locks=N]", SUBCOMMAND_CHECK))
+	fmt.Fprintln(os.Stdout, "    \tCheck the number of sessions and locks once, as plugin of Nagios or Icinga")
+	fmt.Fprintln(os.Stdout, fmt.Sprintf("  %s [-format json|influx]", SUBCOMMAND_COLLECT))
+	fmt.Fprintln(os.Stdout, "    \tCollect the metrics once and print them, e. g. for the exec input of Telegraf")
+	fmt.Fprintln(os.Stdout, fmt.Sprintf("  %s [-format text|json]", SUBCOMMAND_LIST_METRICS))
+	fmt.Fprintln(os.Stdout, "    \tList the name, type, help and labels of the metrics exported with the given options")
+	fmt.Fprintln(os.Stdout, fmt.Sprintf("  %s", SUBCOMMAND_PRINT))
+	fmt.Fprintln(os.Stdout, "    \tCollect the metrics once and print them in the format of the metrics endpoint, e. g. for cron jobs or to debug")
+	fmt.Fprintln(os.Stdout)
 	fmt.Fprintln(os.Stdout, "This program is used to run as a service. To change the service behavior edit '/etc/default/samba_exporter' according to your needs.")
 }
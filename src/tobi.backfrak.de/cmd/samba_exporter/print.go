@@ -0,0 +1,42 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"flag"
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// SUBCOMMAND_PRINT - The subcommand printing the metrics once in the text format of the metrics endpoint, e. g. for cron jobs
+const SUBCOMMAND_PRINT = "print"
+
+// runPrint - Gather the metrics once and print them to out in the Prometheus text format. Returns the exit code of the program
+func runPrint(args []string, gatherer prometheus.Gatherer, out io.Writer) int {
+	flags := flag.NewFlagSet(SUBCOMMAND_PRINT, flag.ContinueOnError)
+	errParse := flags.Parse(args)
+	if errParse != nil {
+		// The flag set already printed the error and its usage
+		return -21
+	}
+
+	families, errGather := gatherer.Gather()
+	if errGather != nil {
+		logger.WriteErrorWithAddition(errGather, "while collecting the metrics")
+		return -21
+	}
+	for _, family := range families {
+		_, errWrite := expfmt.MetricFamilyToText(out, family)
+		if errWrite != nil {
+			logger.WriteErrorWithAddition(errWrite, "while printing the metrics")
+			return -21
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestRunPrint(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	exitCode := runPrint([]string{}, getCollectTestRegistry(), &out)
+	if exitCode != 0 {
+		t.Fatalf("Got exit code %d but expected 0", exitCode)
+	}
+
+	expected := "# HELP samba_server_up Test gauge\n# TYPE samba_server_up gauge\nsamba_server_up{cluster=\"a\"} 1\n"
+	if out.String() != expected {
+		t.Errorf("The output '%s' is not the expected '%s'", out.String(), expected)
+	}
+}
+
+func TestRunPrintUnknownArgument(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	var out bytes.Buffer
+	exitCode := runPrint([]string{"-format", "json"}, getCollectTestRegistry(), &out)
+	if exitCode != -21 || strings.TrimSpace(out.String()) != "" {
+		t.Errorf("Got exit code %d and output '%s', but expected -21 and no output", exitCode, out.String())
+	}
+}
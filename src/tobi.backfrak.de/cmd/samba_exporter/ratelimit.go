@@ -0,0 +1,104 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+)
+
+// scrapeLimiter - Limits the rate and the number of concurrent scrapes of the metrics endpoint, so a misconfigured
+// or abusive client can not make the exporter call smbstatus all the time
+type scrapeLimiter struct {
+	// The scrapes allowed per second, as a token bucket holding at least one scrape. No limit when 0
+	maxRate float64
+	mutex   sync.Mutex
+	tokens  float64
+	last    time.Time
+	// Holds a value for each scrape running. No limit when nil
+	running chan struct{}
+	limited *prometheus.CounterVec
+}
+
+// newScrapeLimiter - Get a scrapeLimiter with its metric registered with the registerer. The constLabels are added to the metric
+func newScrapeLimiter(maxRate float64, maxConcurrent int, registerer prometheus.Registerer, constLabels map[string]string) (*scrapeLimiter, error) {
+	limiter := scrapeLimiter{
+		maxRate: maxRate,
+		limited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   smbexporter.EXPORTER_LABEL_PREFIX,
+			Name:        "exporter_http_requests_limited_total",
+			Help:        "Number of requests to the metrics endpoint rejected, since the maximum scrape rate or the maximum of concurrent scrapes was reached",
+			ConstLabels: constLabels,
+		}, []string{"reason"}),
+	}
+	if maxConcurrent > 0 {
+		limiter.running = make(chan struct{}, maxConcurrent)
+	}
+
+	errRegister := registerer.Register(limiter.limited)
+	if errRegister != nil {
+		return nil, errRegister
+	}
+
+	return &limiter, nil
+}
+
+// limit - Wrap the handler, so requests exceeding the maximum scrape rate are answered with 429 and requests exceeding the
+// maximum of concurrent scrapes with 503, without calling the handler
+func (limiter *scrapeLimiter) limit(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, wait := limiter.take(time.Now())
+		if !allowed {
+			limiter.limited.WithLabelValues("rate").Inc()
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(wait.Seconds()))))
+			http.Error(w, fmt.Sprintf("The maximum scrape rate of %g per second is exceeded", limiter.maxRate), http.StatusTooManyRequests)
+			return
+		}
+
+		if limiter.running != nil {
+			select {
+			case limiter.running <- struct{}{}:
+				defer func() { <-limiter.running }()
+			default:
+				limiter.limited.WithLabelValues("concurrency").Inc()
+				http.Error(w, fmt.Sprintf("The maximum of %d concurrent scrapes is reached", cap(limiter.running)), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// take - Take a scrape from the token bucket. When the bucket is empty, get false and the time until the next scrape is allowed
+func (limiter *scrapeLimiter) take(now time.Time) (bool, time.Duration) {
+	if limiter.maxRate <= 0 {
+		return true, 0
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	burst := math.Max(1, limiter.maxRate)
+	if limiter.last.IsZero() {
+		limiter.tokens = burst
+	} else {
+		limiter.tokens = math.Min(burst, limiter.tokens+now.Sub(limiter.last).Seconds()*limiter.maxRate)
+	}
+	limiter.last = now
+
+	if limiter.tokens < 1 {
+		return false, time.Duration((1 - limiter.tokens) / limiter.maxRate * float64(time.Second))
+	}
+	limiter.tokens--
+
+	return true, 0
+}
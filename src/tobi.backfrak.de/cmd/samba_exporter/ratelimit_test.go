@@ -0,0 +1,150 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestScrapeLimiterTake(t *testing.T) {
+	limiter, errNew := newScrapeLimiter(0.5, 0, prometheus.NewRegistry(), nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	start := time.Now()
+
+	if allowed, _ := limiter.take(start); !allowed {
+		t.Errorf("The first scrape is not allowed")
+	}
+
+	allowed, wait := limiter.take(start.Add(time.Second))
+	if allowed {
+		t.Errorf("The second scrape after one second is allowed, but expected one scrape every two seconds")
+	}
+	if wait != time.Second {
+		t.Errorf("Got the wait time '%s', but expected '1s'", wait)
+	}
+
+	if allowed, _ := limiter.take(start.Add(2 * time.Second)); !allowed {
+		t.Errorf("The scrape after two seconds is not allowed")
+	}
+}
+
+func TestScrapeLimiterNoRateLimit(t *testing.T) {
+	limiter, errNew := newScrapeLimiter(0, 1, prometheus.NewRegistry(), nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		if allowed, _ := limiter.take(now); !allowed {
+			t.Fatalf("The scrape '%d' is not allowed, but expected no rate limit", i)
+		}
+	}
+}
+
+func TestScrapeLimiterRate(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	limiter, errNew := newScrapeLimiter(1, 0, registry, map[string]string{"datacenter": "fra1"})
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	handler := limiter.limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("samba_server_up 1\n"))
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Got status code '%d' but expected '%d'", recorder.Code, http.StatusOK)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("Got status code '%d' but expected '%d'", recorder.Code, http.StatusTooManyRequests)
+	}
+	if recorder.Header().Get("Retry-After") != "1" {
+		t.Errorf("Got the Retry-After header '%s' but expected '1'", recorder.Header().Get("Retry-After"))
+	}
+
+	if value := getLimitedCount(t, registry, "rate"); value != 1 {
+		t.Errorf("Got '%f' requests limited by the rate, but expected '1'", value)
+	}
+}
+
+func TestScrapeLimiterConcurrency(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	limiter, errNew := newScrapeLimiter(0, 1, registry, nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	started := make(chan bool)
+	release := make(chan bool)
+	handler := limiter.limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			started <- true
+			<-release
+		}
+	}))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics?block=true", nil))
+	<-started
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Got status code '%d' but expected '%d'", recorder.Code, http.StatusServiceUnavailable)
+	}
+
+	release <- true
+	for i := 0; i < 100 && len(limiter.running) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Got status code '%d' but expected '%d' once the running scrape is done", recorder.Code, http.StatusOK)
+	}
+
+	if value := getLimitedCount(t, registry, "concurrency"); value != 1 {
+		t.Errorf("Got '%f' requests limited by the concurrency, but expected '1'", value)
+	}
+
+	_, errTwice := newScrapeLimiter(0, 1, registry, nil)
+	if errTwice == nil {
+		t.Errorf("Got no error when registering the metric twice, but expected one")
+	}
+}
+
+// getLimitedCount - Get the value of samba_exporter_http_requests_limited_total for the reason
+func getLimitedCount(t *testing.T, registry *prometheus.Registry, reason string) float64 {
+	families, errGather := registry.Gather()
+	if errGather != nil {
+		t.Fatalf("Got error '%s' but expected none", errGather.Error())
+	}
+
+	for _, family := range families {
+		if family.GetName() != "samba_exporter_http_requests_limited_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "reason" && label.GetValue() == reason {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,56 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+)
+
+// The path the status API is served on, when enabled with -web.enable-status-api
+const STATUS_API_PATH = "/api/v1/status"
+
+// The body of the answers of the status API in case of an error
+type statusApiError struct {
+	Error string
+}
+
+// statusHandler - Get the handler of the status API, answering with the current smbexporter.SambaStatus as JSON
+func statusHandler(exporter *smbexporter.SambaExporter) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet && request.Method != http.MethodHead {
+			writer.Header().Set("Allow", "GET, HEAD")
+			writeStatusApiResponse(writer, http.StatusMethodNotAllowed, statusApiError{fmt.Sprintf("The method %s is not allowed", request.Method)})
+			return
+		}
+
+		status, errGet := exporter.GetSambaStatus(request.Context())
+		if errGet != nil {
+			logger.WriteErrorWithAddition(errGet, "while answering a request to the status API")
+			writeStatusApiResponse(writer, http.StatusServiceUnavailable, statusApiError{errGet.Error()})
+			return
+		}
+
+		writeStatusApiResponse(writer, http.StatusOK, status)
+	})
+}
+
+// writeStatusApiResponse - Write the body as JSON with the status code
+func writeStatusApiResponse(writer http.ResponseWriter, statusCode int, body interface{}) {
+	jsonData, errConv := json.MarshalIndent(body, "", " ")
+	if errConv != nil {
+		logger.WriteErrorWithAddition(errConv, "while converting the answer of the status API")
+		http.Error(writer, errConv.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	writer.Write(jsonData)
+}
@@ -0,0 +1,75 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestStatusHandlerStatusdNotReachable(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	// Nothing listens on the port, so the requests fail
+	conn, client, errClient := pipecomunication.NewGrpcClient("127.0.0.1:1", nil)
+	if errClient != nil {
+		t.Fatalf("Got error '%s' but expected none", errClient.Error())
+	}
+	defer conn.Close()
+	exporter := smbexporter.NewSambaExporter(nil, nil, logger, "0.0.0", 1, statisticsGenerator.StatisticsGeneratorSettings{})
+	exporter.GrpcClient = client
+
+	recorder := httptest.NewRecorder()
+	statusHandler(exporter).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, STATUS_API_PATH, nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Got the status %d but expected %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+	var apiError statusApiError
+	errDecode := json.Unmarshal(recorder.Body.Bytes(), &apiError)
+	if errDecode != nil || apiError.Error == "" {
+		t.Errorf("The body '%s' does not contain the error", recorder.Body.String())
+	}
+}
+
+func TestStatusHandlerMethodNotAllowed(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+	logger = testhelper.NewTestLogger(true)
+
+	exporter := smbexporter.NewSambaExporter(nil, nil, logger, "0.0.0", 1, statisticsGenerator.StatisticsGeneratorSettings{})
+	recorder := httptest.NewRecorder()
+	statusHandler(exporter).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, STATUS_API_PATH, nil))
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("Got the status %d and allowed methods '%s', but expected %d and 'GET, HEAD'", recorder.Code, recorder.Header().Get("Allow"), http.StatusMethodNotAllowed)
+	}
+}
+
+func TestMainWithStatusApiAndPrivacyMode(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.Test = true
+	params.TestPipeMode = true
+	params.EnableStatusApi = true
+	params.PrivacyMode = statisticsGenerator.PRIVACY_MODE_HASH
+
+	res := realMain()
+	if res != -14 {
+		t.Errorf("Got %d from main, but expected -14", res)
+	}
+}
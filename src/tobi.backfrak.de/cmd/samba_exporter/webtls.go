@@ -0,0 +1,113 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// The minimum time between two checks if the -web.tls.cert-file or -web.tls.key-file changed
+const WEB_TLS_RELOAD_INTERVAL = 10 * time.Second
+
+// certificateReloader - Serves the certificate of the -web.tls.cert-file and -web.tls.key-file for the TLS handshakes and
+// loads it again once one of the files changed, so renewed certificates are used without restarting the exporter
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	// The minimum time between two checks of the files
+	interval  time.Duration
+	mutex     sync.Mutex
+	cert      *tls.Certificate
+	lastCheck time.Time
+	certState fileState
+	keyState  fileState
+}
+
+// fileState - The modification time and size of a file, used to find out if the file changed
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// newCertificateReloader - Get a certificateReloader with the certificate of the files loaded
+func newCertificateReloader(certFile, keyFile string, interval time.Duration) (*certificateReloader, error) {
+	reloader := certificateReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	errLoad := reloader.load(time.Now())
+	if errLoad != nil {
+		return nil, errLoad
+	}
+
+	return &reloader, nil
+}
+
+// getCertificate - Get the certificate for a TLS handshake, to be used as tls.Config.GetCertificate. When the files changed
+// since the last check, the certificate is loaded again. When loading fails, the last certificate is kept
+func (reloader *certificateReloader) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(reloader.lastCheck) < reloader.interval {
+		return reloader.cert, nil
+	}
+	reloader.lastCheck = now
+
+	certState, errCert := getFileState(reloader.certFile)
+	keyState, errKey := getFileState(reloader.keyFile)
+	if errCert != nil || errKey != nil {
+		logger.WriteVerbose(fmt.Sprintf("Can not check if the -web.tls.cert-file or -web.tls.key-file changed, keep the current certificate: %v %v", errCert, errKey))
+		return reloader.cert, nil
+	}
+	if certState == reloader.certState && keyState == reloader.keyState {
+		return reloader.cert, nil
+	}
+
+	errLoad := reloader.load(now)
+	if errLoad != nil {
+		logger.WriteErrorWithAddition(errLoad, "while reloading the -web.tls.cert-file, keep the current certificate")
+		return reloader.cert, nil
+	}
+	logger.WriteInformation(fmt.Sprintf("Reloaded the TLS certificate from '%s'", reloader.certFile))
+
+	return reloader.cert, nil
+}
+
+// load - Load the certificate from the files and remember their state. The mutex must be held, or the reloader not yet in use
+func (reloader *certificateReloader) load(now time.Time) error {
+	certState, errCert := getFileState(reloader.certFile)
+	if errCert != nil {
+		return errCert
+	}
+	keyState, errKey := getFileState(reloader.keyFile)
+	if errKey != nil {
+		return errKey
+	}
+	cert, errLoad := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if errLoad != nil {
+		return errLoad
+	}
+
+	reloader.cert = &cert
+	reloader.certState = certState
+	reloader.keyState = keyState
+	reloader.lastCheck = now
+
+	return nil
+}
+
+// getFileState - Get the state of the file, following symbolic links like the ones of mounted kubernetes secrets
+func getFileState(path string) (fileState, error) {
+	info, errStat := os.Stat(path)
+	if errStat != nil {
+		return fileState{}, errStat
+	}
+
+	return fileState{modTime: info.ModTime(), size: info.Size()}, nil
+}
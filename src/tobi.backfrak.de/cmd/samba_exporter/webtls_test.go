@@ -0,0 +1,95 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	logger = testhelper.NewTestLogger(true)
+	certFile, keyFile := writeTestCertificate(t)
+	reloader, errNew := newCertificateReloader(certFile, keyFile, 0)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	first, _ := reloader.getCertificate(nil)
+
+	unchanged, _ := reloader.getCertificate(nil)
+	if unchanged != first {
+		t.Errorf("Got a new certificate, but the files did not change")
+	}
+
+	// Replace the files, like a renewal of the certificate does
+	newCertFile, newKeyFile := writeTestCertificate(t)
+	copyTestFile(t, newCertFile, certFile)
+	copyTestFile(t, newKeyFile, keyFile)
+	renewed, _ := reloader.getCertificate(nil)
+	if renewed == first || bytes.Equal(renewed.Certificate[0], first.Certificate[0]) {
+		t.Errorf("Got the old certificate, but expected the renewed one")
+	}
+
+	// A broken file keeps the last certificate
+	os.WriteFile(certFile, []byte("broken"), 0644)
+	os.Chtimes(certFile, time.Now(), time.Now().Add(time.Hour))
+	kept, _ := reloader.getCertificate(nil)
+	if kept != renewed {
+		t.Errorf("Got an other certificate, but expected the last one is kept when the file is broken")
+	}
+
+	os.Remove(keyFile)
+	kept, _ = reloader.getCertificate(nil)
+	if kept != renewed {
+		t.Errorf("Got an other certificate, but expected the last one is kept when the file is missing")
+	}
+}
+
+func TestCertificateReloaderInterval(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	logger = testhelper.NewTestLogger(true)
+	certFile, keyFile := writeTestCertificate(t)
+	reloader, errNew := newCertificateReloader(certFile, keyFile, time.Hour)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	first, _ := reloader.getCertificate(nil)
+
+	newCertFile, newKeyFile := writeTestCertificate(t)
+	copyTestFile(t, newCertFile, certFile)
+	copyTestFile(t, newKeyFile, keyFile)
+	cert, _ := reloader.getCertificate(nil)
+	if cert != first {
+		t.Errorf("Got a new certificate, but expected the files are not checked again within the interval")
+	}
+
+	_, errMissing := newCertificateReloader(certFile, certFile+".missing", time.Hour)
+	if errMissing == nil {
+		t.Errorf("Got no error but expected one, since the key file does not exist")
+	}
+}
+
+// copyTestFile - Copy the file and move its modification time into the future, so the change is seen on file systems with coarse time stamps
+func copyTestFile(t *testing.T, source, target string) {
+	data, errRead := os.ReadFile(source)
+	if errRead != nil {
+		t.Fatalf("Got error '%s' but expected none", errRead.Error())
+	}
+	errWrite := os.WriteFile(target, data, 0600)
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+	os.Chtimes(target, time.Now(), time.Now().Add(time.Minute))
+}
@@ -0,0 +1,98 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// cachedOutput - The output of a smbstatus call and the time smbstatus was called
+type cachedOutput struct {
+	data     []byte
+	received time.Time
+}
+
+// outputCache - Caches the output of smbstatus per argument list
+type outputCache struct {
+	mutex   sync.Mutex
+	entries map[string]cachedOutput
+}
+
+var smbstatusCache = newOutputCache()
+
+// newOutputCache - Get a new, empty outputCache
+func newOutputCache() *outputCache {
+	return &outputCache{entries: map[string]cachedOutput{}}
+}
+
+// getOrRun - Get the cached output for the arguments when it is younger than minInterval, otherwise call run and cache its output.
+// Errors of run are not cached. The bool tells if the cached output was used
+func (cache *outputCache) getOrRun(arguments []string, minInterval time.Duration, run func() ([]byte, error)) ([]byte, bool, error) {
+	key := strings.Join(arguments, " ")
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, found := cache.entries[key]
+	if found && minInterval > 0 && time.Since(entry.received) < minInterval {
+		return entry.data, true, nil
+	}
+
+	data, err := run()
+	if err != nil {
+		return nil, false, err
+	}
+	if minInterval > 0 {
+		cache.entries[key] = cachedOutput{data: data, received: time.Now()}
+	}
+
+	return data, false, nil
+}
+
+// clear - Remove all cached outputs
+func (cache *outputCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries = map[string]cachedOutput{}
+}
+
+// runSmbstatus - Run smbstatus with the given arguments and the current runtime settings.
+// When smbstatus was called with the same arguments less than -smbstatus.min-interval ago, the output of this call is returned
+func runSmbstatus(arguments ...string) ([]byte, error) {
+	settings := getRuntimeSettings()
+	data, cached, err := smbstatusCache.getOrRun(arguments, settings.SmbstatusMinInterval, func() ([]byte, error) {
+		return settings.smbstatusCommand(arguments...).Output()
+	})
+	if cached {
+		logger.WriteVerbose(fmt.Sprintf("Use the cached output of \"%s %s\"", settings.SmbstatusPath, strings.Join(arguments, " ")))
+	}
+
+	return data, err
+}
+
+// clearSmbstatusCaches - Remove the cached smbstatus outputs and the tables of the current collection cycle,
+// so the next request runs smbstatus again
+func clearSmbstatusCaches() {
+	smbstatusCache.clear()
+	smbstatusCycle.clear()
+}
+
+// waitforUserSignalAndClearCache - Clear the smbstatus caches each time SIGUSR1 is received
+func waitforUserSignalAndClearCache() {
+	userSignal := make(chan os.Signal, 1)
+	signal.Notify(userSignal, syscall.SIGUSR1)
+
+	for range userSignal {
+		logger.WriteInformation("Clear the cached smbstatus output due to user signal 1, the next request runs smbstatus again")
+		clearSmbstatusCaches()
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestOutputCacheGetOrRun(t *testing.T) {
+	cache := newOutputCache()
+	calls := 0
+	run := func() ([]byte, error) {
+		calls++
+		return []byte(fmt.Sprintf("call %d", calls)), nil
+	}
+
+	data, cached, err := cache.getOrRun([]string{"-L", "-n"}, time.Minute, run)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if cached || string(data) != "call 1" {
+		t.Errorf("Got '%s' (cached: %t) but expected 'call 1' not cached", string(data), cached)
+	}
+
+	data, cached, _ = cache.getOrRun([]string{"-L", "-n"}, time.Minute, run)
+	if !cached || string(data) != "call 1" {
+		t.Errorf("Got '%s' (cached: %t) but expected 'call 1' cached", string(data), cached)
+	}
+
+	data, cached, _ = cache.getOrRun([]string{"-S", "-n"}, time.Minute, run)
+	if cached || string(data) != "call 2" {
+		t.Errorf("Got '%s' (cached: %t) but expected 'call 2' not cached", string(data), cached)
+	}
+
+	cache.clear()
+	data, cached, _ = cache.getOrRun([]string{"-L", "-n"}, time.Minute, run)
+	if cached || string(data) != "call 3" {
+		t.Errorf("Got '%s' (cached: %t) but expected 'call 3' not cached", string(data), cached)
+	}
+
+	if calls != 3 {
+		t.Errorf("Got '%d' calls but expected '3'", calls)
+	}
+}
+
+func TestOutputCacheGetOrRunExpired(t *testing.T) {
+	cache := newOutputCache()
+	calls := 0
+	run := func() ([]byte, error) {
+		calls++
+		return []byte("data"), nil
+	}
+
+	cache.getOrRun([]string{"-p", "-n"}, 10*time.Millisecond, run)
+	time.Sleep(20 * time.Millisecond)
+	_, cached, _ := cache.getOrRun([]string{"-p", "-n"}, 10*time.Millisecond, run)
+	if cached {
+		t.Errorf("Got the cached output, but the output is expired")
+	}
+
+	cache.getOrRun([]string{"-p", "-n"}, 0, run)
+	cache.getOrRun([]string{"-p", "-n"}, 0, run)
+	if calls != 4 {
+		t.Errorf("Got '%d' calls but expected '4', since the cache is not used without a minimum interval", calls)
+	}
+}
+
+func TestOutputCacheGetOrRunError(t *testing.T) {
+	cache := newOutputCache()
+	calls := 0
+	run := func() ([]byte, error) {
+		calls++
+		return nil, fmt.Errorf("smbstatus failed")
+	}
+
+	for i := 0; i < 2; i++ {
+		_, cached, err := cache.getOrRun([]string{"-L", "-n"}, time.Minute, run)
+		if err == nil {
+			t.Errorf("Got no error but expected one")
+		}
+		if cached {
+			t.Errorf("Got the cached output, but errors should not be cached")
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Got '%d' calls but expected '2'", calls)
+	}
+}
+
+func TestClearSmbstatusCaches(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	calls := 0
+	run := func() ([]byte, error) {
+		calls++
+		return []byte("output"), nil
+	}
+	collect := func() (map[commonbl.RequestType]string, error) {
+		calls++
+		return splitSmbstatusOutput(testSingleCallOutput)
+	}
+
+	smbstatusCache.getOrRun([]string{"-L", "-n"}, time.Minute, run)
+	smbstatusCycle.getTable(commonbl.LOCK_REQUEST, collect)
+	clearSmbstatusCaches()
+
+	_, cached, _ := smbstatusCache.getOrRun([]string{"-L", "-n"}, time.Minute, run)
+	if cached {
+		t.Errorf("Got the cached output but expected a new call after the caches were cleared")
+	}
+	smbstatusCycle.getTable(commonbl.SHARE_REQUEST, collect)
+	if calls != 4 {
+		t.Errorf("Got '%d' calls but expected '4', since the tables of the cycle were cleared", calls)
+	}
+	clearSmbstatusCaches()
+}
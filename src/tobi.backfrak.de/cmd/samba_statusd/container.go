@@ -0,0 +1,96 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// The container runtimes smbstatus can be run in a container with
+const (
+	CONTAINER_RUNTIME_DOCKER  = "docker"
+	CONTAINER_RUNTIME_PODMAN  = "podman"
+	CONTAINER_RUNTIME_NSENTER = "nsenter"
+)
+
+// smbstatusContainer - The container smbstatus runs in, so a samba server in a container can be monitored from the host
+type smbstatusContainer struct {
+	// One of the CONTAINER_RUNTIME_* values
+	runtime string
+	// The path of the executable of the runtime
+	runtimePath string
+	// The name or ID of the container, or the PID of a process in the container for nsenter
+	target string
+}
+
+// getContainerRuntimes - Get the names of the container runtimes smbstatus can be run with
+func getContainerRuntimes() []string {
+	return []string{CONTAINER_RUNTIME_DOCKER, CONTAINER_RUNTIME_PODMAN, CONTAINER_RUNTIME_NSENTER}
+}
+
+// newSmbstatusContainer - Validate the -smbstatus.container and -smbstatus.container-runtime. Returns nil when smbstatus runs on the host
+func newSmbstatusContainer(target string, runtime string) (*smbstatusContainer, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return nil, nil
+	}
+
+	container := smbstatusContainer{runtime: strings.TrimSpace(runtime), target: target}
+	switch container.runtime {
+	case CONTAINER_RUNTIME_DOCKER, CONTAINER_RUNTIME_PODMAN:
+	case CONTAINER_RUNTIME_NSENTER:
+		pid, errPid := strconv.Atoi(target)
+		if errPid != nil || pid <= 0 {
+			return nil, fmt.Errorf("The -smbstatus.container '%s' is not the PID of a process, as needed by nsenter", target)
+		}
+	default:
+		return nil, fmt.Errorf("The container runtime '%s' is unknown. Possible values: %s", container.runtime, strings.Join(getContainerRuntimes(), ", "))
+	}
+
+	var errLookPath error
+	container.runtimePath, errLookPath = exec.LookPath(container.runtime)
+	if errLookPath != nil {
+		return nil, fmt.Errorf("Can not find \"%s\" executable of the -smbstatus.container-runtime", container.runtime)
+	}
+
+	return &container, nil
+}
+
+// commandLine - Get the runtime and its arguments, to run a command in the container. docker and podman do not pass the environment
+// to the container, so the variables are given as arguments. nsenter keeps the environment of samba_statusd
+func (container *smbstatusContainer) commandLine(variables []string, workDir string) []string {
+	commandLine := []string{container.runtimePath}
+	if container.runtime == CONTAINER_RUNTIME_NSENTER {
+		commandLine = append(commandLine, "--target", container.target, "--mount", "--uts", "--ipc", "--net", "--pid")
+		if workDir != "" {
+			commandLine = append(commandLine, "--wd="+workDir)
+		}
+
+		return append(commandLine, "--")
+	}
+
+	commandLine = append(commandLine, "exec")
+	for _, variable := range variables {
+		commandLine = append(commandLine, "--env", variable)
+	}
+	if workDir != "" {
+		commandLine = append(commandLine, "--workdir", workDir)
+	}
+
+	return append(commandLine, container.target)
+}
+
+// String - Get a description of the container for the log
+func (container *smbstatusContainer) String() string {
+	if container.runtime == CONTAINER_RUNTIME_NSENTER {
+		return fmt.Sprintf("the namespaces of the process %s", container.target)
+	}
+
+	return fmt.Sprintf("the %s container '%s'", container.runtime, container.target)
+}
@@ -0,0 +1,77 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNewSmbstatusContainer(t *testing.T) {
+	container, err := newSmbstatusContainer(" ", CONTAINER_RUNTIME_DOCKER)
+	if err != nil || container != nil {
+		t.Errorf("Got the container '%v' and error '%v', but expected none", container, err)
+	}
+
+	_, err = newSmbstatusContainer("samba", "lxc")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the runtime is unknown")
+	}
+
+	_, err = newSmbstatusContainer("samba", CONTAINER_RUNTIME_NSENTER)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since nsenter needs a PID")
+	}
+
+	if _, errLookPath := exec.LookPath(CONTAINER_RUNTIME_NSENTER); errLookPath != nil {
+		t.Skip("nsenter is not installed")
+	}
+	container, err = newSmbstatusContainer(fmt.Sprintf("%d", os.Getpid()), CONTAINER_RUNTIME_NSENTER)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if container.runtimePath == "" || container.target != fmt.Sprintf("%d", os.Getpid()) {
+		t.Errorf("Got the runtime '%s' and target '%s', but expected the path of nsenter and the PID", container.runtimePath, container.target)
+	}
+}
+
+func TestSmbstatusContainerCommandLine(t *testing.T) {
+	docker := smbstatusContainer{runtime: CONTAINER_RUNTIME_DOCKER, runtimePath: "/usr/bin/docker", target: "samba"}
+	commandLine := strings.Join(docker.commandLine([]string{"LC_ALL=C", "TZ=UTC"}, "/tmp"), " ")
+	expected := "/usr/bin/docker exec --env LC_ALL=C --env TZ=UTC --workdir /tmp samba"
+	if commandLine != expected {
+		t.Errorf("Got the command line '%s', but expected '%s'", commandLine, expected)
+	}
+
+	nsenter := smbstatusContainer{runtime: CONTAINER_RUNTIME_NSENTER, runtimePath: "/usr/bin/nsenter", target: "42"}
+	commandLine = strings.Join(nsenter.commandLine([]string{"LC_ALL=C"}, ""), " ")
+	expected = "/usr/bin/nsenter --target 42 --mount --uts --ipc --net --pid --"
+	if commandLine != expected {
+		t.Errorf("Got the command line '%s', but expected '%s'", commandLine, expected)
+	}
+}
+
+func TestSmbstatusCommandInContainer(t *testing.T) {
+	settings := runtimeSettings{
+		SmbstatusPath:      "smbstatus",
+		SmbstatusLocale:    "C",
+		SmbstatusWorkDir:   "/var/lib/samba",
+		SmbstatusPrefix:    []string{"/usr/bin/sudo", "-n"},
+		SmbstatusContainer: &smbstatusContainer{runtime: CONTAINER_RUNTIME_PODMAN, runtimePath: "/usr/bin/podman", target: "samba"},
+	}
+
+	command := settings.smbstatusCommand("-L", "-n")
+	expected := "/usr/bin/sudo -n /usr/bin/podman exec --env LC_ALL=C --env LANG=C --workdir /var/lib/samba samba smbstatus -L -n"
+	if strings.Join(command.Args, " ") != expected {
+		t.Errorf("Got the command '%s', but expected '%s'", strings.Join(command.Args, " "), expected)
+	}
+	if command.Dir != "" {
+		t.Errorf("Got the working directory '%s', but expected the one of samba_statusd, since it is given to the container", command.Dir)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The well known name samba_statusd owns on the D-Bus
+const DBUS_NAME = "de.backfrak.tobi.SambaStatusd"
+
+// The path of the object samba_statusd exports on the D-Bus
+const DBUS_PATH = dbus.ObjectPath("/de/backfrak/tobi/SambaStatusd")
+
+// The interface of the object samba_statusd exports on the D-Bus
+const DBUS_INTERFACE = "de.backfrak.tobi.SambaStatusd1"
+
+// The error returned for the methods of disabled collectors
+const DBUS_ERROR_DISABLED = DBUS_INTERFACE + ".Error.Disabled"
+
+// The error returned when the data could not be collected
+const DBUS_ERROR_FAILED = DBUS_INTERFACE + ".Error.Failed"
+
+// The buses samba_statusd can export its object on
+var dbusBuses = map[string]func(...dbus.ConnOption) (*dbus.Conn, error){
+	"system":  dbus.ConnectSystemBus,
+	"session": dbus.ConnectSessionBus,
+}
+
+// sambaStatusObject - Implements the methods of the D-Bus interface samba_statusd offers to local tools
+type sambaStatusObject struct{}
+
+// GetLocks - Get the output of 'smbstatus -L -n'
+func (object *sambaStatusObject) GetLocks() (string, *dbus.Error) {
+	return getDbusSmbstatusOutput(commonbl.LOCK_REQUEST)
+}
+
+// GetShares - Get the output of 'smbstatus -S -n'
+func (object *sambaStatusObject) GetShares() (string, *dbus.Error) {
+	return getDbusSmbstatusOutput(commonbl.SHARE_REQUEST)
+}
+
+// GetProcesses - Get the output of 'smbstatus -p -n', the current sessions
+func (object *sambaStatusObject) GetProcesses() (string, *dbus.Error) {
+	return getDbusSmbstatusOutput(commonbl.PROCESS_REQUEST)
+}
+
+// GetPsData - Get the resource usage of the smbd processes as JSON array
+func (object *sambaStatusObject) GetPsData() (string, *dbus.Error) {
+	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" D-Bus request", commonbl.PS_REQUEST))
+	trackingId := requestTracker.Start()
+	defer requestTracker.Done(trackingId)
+
+	if getRuntimeSettings().isRequestDisabled(commonbl.PS_REQUEST) {
+		return "", getDbusDisabledError(commonbl.PS_REQUEST)
+	}
+
+	pidData, errGet := getPsData()
+	if errGet != nil {
+		logger.WriteError(errGet)
+		return "", dbus.NewError(DBUS_ERROR_FAILED, []interface{}{errGet.Error()})
+	}
+	jsonData, errConv := json.MarshalIndent(pidData, "", " ")
+	if errConv != nil {
+		return "", dbus.NewError(DBUS_ERROR_FAILED, []interface{}{errConv.Error()})
+	}
+
+	return string(jsonData), nil
+}
+
+// GetVersion - Get the version of samba_statusd
+func (object *sambaStatusObject) GetVersion() (string, *dbus.Error) {
+	return version, nil
+}
+
+// getDbusSmbstatusOutput - Get the output of smbstatus for the request type as answer of a D-Bus method
+func getDbusSmbstatusOutput(requestType commonbl.RequestType) (string, *dbus.Error) {
+	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" D-Bus request", requestType))
+	trackingId := requestTracker.Start()
+	defer requestTracker.Done(trackingId)
+
+	if getRuntimeSettings().isRequestDisabled(requestType) {
+		return "", getDbusDisabledError(requestType)
+	}
+
+	output, errGet := getSmbstatusOutput(requestType)
+	if errGet != nil {
+		logger.WriteError(errGet)
+		return "", dbus.NewError(DBUS_ERROR_FAILED, []interface{}{errGet.Error()})
+	}
+
+	return output, nil
+}
+
+// getDbusDisabledError - Get the D-Bus error for a request of a disabled collector
+func getDbusDisabledError(requestType commonbl.RequestType) *dbus.Error {
+	message := fmt.Sprintf("The collector for \"%s\" is disabled in samba_statusd", strings.TrimSuffix(string(requestType), ":"))
+
+	return dbus.NewError(DBUS_ERROR_DISABLED, []interface{}{message})
+}
+
+// getDbusIntrospection - Get the introspection data of the exported object
+func getDbusIntrospection() string {
+	stringOut := []introspect.Arg{{Name: "data", Type: "s", Direction: "out"}}
+	node := introspect.Node{
+		Name: string(DBUS_PATH),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			{
+				Name: DBUS_INTERFACE,
+				Methods: []introspect.Method{
+					{Name: "GetLocks", Args: stringOut},
+					{Name: "GetShares", Args: stringOut},
+					{Name: "GetProcesses", Args: stringOut},
+					{Name: "GetPsData", Args: stringOut},
+					{Name: "GetVersion", Args: stringOut},
+				},
+			},
+		},
+	}
+
+	return string(introspect.NewIntrospectable(&node))
+}
+
+// exportDbus - Export the samba status on the -dbus.bus and take the name DBUS_NAME. The requests are answered until the connection is closed
+func exportDbus(bus string) (*dbus.Conn, error) {
+	connect, found := dbusBuses[bus]
+	if !found {
+		return nil, fmt.Errorf("The -dbus.bus '%s' is not known, use 'system' or 'session'", bus)
+	}
+
+	conn, errConnect := connect()
+	if errConnect != nil {
+		return nil, errConnect
+	}
+
+	errExport := conn.Export(&sambaStatusObject{}, DBUS_PATH, DBUS_INTERFACE)
+	if errExport != nil {
+		conn.Close()
+		return nil, errExport
+	}
+	errExport = conn.Export(introspect.Introspectable(getDbusIntrospection()), DBUS_PATH, "org.freedesktop.DBus.Introspectable")
+	if errExport != nil {
+		conn.Close()
+		return nil, errExport
+	}
+
+	reply, errRequest := conn.RequestName(DBUS_NAME, dbus.NameFlagDoNotQueue)
+	if errRequest != nil {
+		conn.Close()
+		return nil, errRequest
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("The name '%s' is already taken on the %s bus", DBUS_NAME, bus)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestSambaStatusObject(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	oldSettings := getRuntimeSettings()
+	defer setRuntimeSettings(oldSettings)
+	params.Test = true
+	logger = testhelper.NewTestLogger(true)
+	setRuntimeSettings(runtimeSettings{})
+
+	object := &sambaStatusObject{}
+	locks, errLocks := object.GetLocks()
+	if errLocks != nil {
+		t.Fatalf("Got error '%s' but expected none", errLocks.Error())
+	}
+	if locks != commonbl.TestLockResponse {
+		t.Errorf("Got '%s' but expected the test lock response", locks)
+	}
+
+	processes, errProcesses := object.GetProcesses()
+	if errProcesses != nil {
+		t.Fatalf("Got error '%s' but expected none", errProcesses.Error())
+	}
+	if processes != commonbl.TestProcessResponse {
+		t.Errorf("Got '%s' but expected the test process response", processes)
+	}
+
+	psData, errPs := object.GetPsData()
+	if errPs != nil {
+		t.Fatalf("Got error '%s' but expected none", errPs.Error())
+	}
+	var pidData []commonbl.PsUtilPidData
+	errConv := json.Unmarshal([]byte(psData), &pidData)
+	if errConv != nil {
+		t.Fatalf("Got error '%s' but expected none", errConv.Error())
+	}
+	if len(pidData) != 2 {
+		t.Errorf("Got '%d' ps data entries but expected '2'", len(pidData))
+	}
+
+	setRuntimeSettings(runtimeSettings{DisabledCollectors: []string{"shares"}})
+	_, errShares := object.GetShares()
+	if errShares == nil {
+		t.Fatalf("Got no error but expected one, since the collector is disabled")
+	}
+	if errShares.Name != DBUS_ERROR_DISABLED {
+		t.Errorf("Got the error '%s' but expected '%s'", errShares.Name, DBUS_ERROR_DISABLED)
+	}
+}
+
+func TestGetDbusIntrospection(t *testing.T) {
+	introspection := getDbusIntrospection()
+	for _, expected := range []string{DBUS_INTERFACE, "GetLocks", "GetShares", "GetProcesses", "GetPsData", "GetVersion"} {
+		if !strings.Contains(introspection, expected) {
+			t.Errorf("The introspection data does not contain '%s'", expected)
+		}
+	}
+}
+
+func TestExportDbusUnknownBus(t *testing.T) {
+	_, err := exportDbus("not-a-bus")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the bus is not known")
+	}
+}
@@ -6,6 +6,10 @@ require tobi.backfrak.de/internal/commonbl v0.0.0
 
 replace tobi.backfrak.de/internal/commonbl v0.0.0 => ../../internal/commonbl
 
+require tobi.backfrak.de/internal/configfile v0.0.0
+
+replace tobi.backfrak.de/internal/configfile v0.0.0 => ../../internal/configfile
+
 require tobi.backfrak.de/internal/testhelper v0.0.0
 
 replace tobi.backfrak.de/internal/testhelper v0.0.0 => ../../internal/testhelper
@@ -16,11 +20,34 @@ replace tobi.backfrak.de/internal/smbstatusdbl v0.0.0 => ../../internal/smbstatu
 
 require (
 	github.com/go-ole/go-ole v1.2.6 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
 	github.com/power-devops/perfstat v0.0.0-20210106213030-5aafc221ea8c // indirect
 	github.com/shirou/gopsutil/v3 v3.23.2 // indirect
 	github.com/tklauser/go-sysconf v0.3.11 // indirect
 	github.com/tklauser/numcpus v0.6.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.2 // indirect
-	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+require tobi.backfrak.de/internal/statusdrpc v0.0.0
+
+replace tobi.backfrak.de/internal/statusdrpc v0.0.0 => ../../internal/statusdrpc
+
+require google.golang.org/grpc v1.62.1
+
+require github.com/godbus/dbus/v5 v5.1.0
+
+require golang.org/x/sys v0.16.0
+
+require tobi.backfrak.de/internal/smbexporterbl/smbstatusreader v0.0.0
+
+replace tobi.backfrak.de/internal/smbexporterbl/smbstatusreader v0.0.0 => ../../internal/smbexporterbl/smbstatusreader
+
+require tobi.backfrak.de/internal/smbstatusout v0.0.0 // indirect
+
+replace tobi.backfrak.de/internal/smbstatusout v0.0.0 => ../../internal/smbstatusout
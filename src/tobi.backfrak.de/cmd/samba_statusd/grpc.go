@@ -0,0 +1,207 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/statusdrpc"
+)
+
+// The arguments smbstatus is called with to answer the requests
+var smbstatusArguments = map[commonbl.RequestType][]string{
+	commonbl.LOCK_REQUEST:    {"-L", "-n"},
+	commonbl.SHARE_REQUEST:   {"-S", "-n"},
+	commonbl.PROCESS_REQUEST: {"-p", "-n"},
+}
+
+// The output of smbstatus used in test mode
+var testSmbstatusOutput = map[commonbl.RequestType]string{
+	commonbl.LOCK_REQUEST:    commonbl.TestLockResponse,
+	commonbl.SHARE_REQUEST:   commonbl.TestShareResponse,
+	commonbl.PROCESS_REQUEST: commonbl.TestProcessResponse,
+}
+
+// sambaStatusServer - Implements the gRPC service samba_statusd offers to samba_exporter
+type sambaStatusServer struct {
+	statusdrpc.UnimplementedSambaStatusServer
+}
+
+// Stream the smbstatus output is sent with
+type smbstatusOutputStream interface {
+	Send(*statusdrpc.SmbstatusOutput) error
+}
+
+// GetLocks - Send the output of 'smbstatus -L -n'
+func (server *sambaStatusServer) GetLocks(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetLocksServer) error {
+	return sendSmbstatusOutput(commonbl.LOCK_REQUEST, stream)
+}
+
+// GetShares - Send the output of 'smbstatus -S -n'
+func (server *sambaStatusServer) GetShares(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetSharesServer) error {
+	return sendSmbstatusOutput(commonbl.SHARE_REQUEST, stream)
+}
+
+// GetProcesses - Send the output of 'smbstatus -p -n'
+func (server *sambaStatusServer) GetProcesses(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetProcessesServer) error {
+	return sendSmbstatusOutput(commonbl.PROCESS_REQUEST, stream)
+}
+
+// GetPsData - Send the resource usage of the smbd processes
+func (server *sambaStatusServer) GetPsData(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetPsDataServer) error {
+	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" gRPC request", commonbl.PS_REQUEST))
+	trackingId := requestTracker.Start()
+	defer requestTracker.Done(trackingId)
+
+	if getRuntimeSettings().isRequestDisabled(commonbl.PS_REQUEST) {
+		return nil
+	}
+
+	pidData, errGet := getPsData()
+	if errGet != nil {
+		logger.WriteError(errGet)
+		return status.Error(codes.Internal, errGet.Error())
+	}
+
+	for _, data := range pidData {
+		errSend := stream.Send(statusdrpc.NewPsData(data))
+		if errSend != nil {
+			return errSend
+		}
+	}
+
+	return nil
+}
+
+// GetCgroups - Send the resource usage of the cgroups samba runs in
+func (server *sambaStatusServer) GetCgroups(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetCgroupsServer) error {
+	return sendList(commonbl.CGROUP_REQUEST, getCgroupData, statusdrpc.NewCgroup, stream.Send)
+}
+
+// GetCtdbNodes - Send the nodes of the CTDB cluster samba runs in
+func (server *sambaStatusServer) GetCtdbNodes(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetCtdbNodesServer) error {
+	return sendList(commonbl.CTDB_NODES_REQUEST, getCtdbNodes, statusdrpc.NewCtdbNode, stream.Send)
+}
+
+// GetShareConfig - Send the shares configured in the smb.conf
+func (server *sambaStatusServer) GetShareConfig(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetShareConfigServer) error {
+	return sendList(commonbl.SHARE_CONFIG_REQUEST, getShareConfig, statusdrpc.NewShareConfig, stream.Send)
+}
+
+// GetShareHandles - Send the open, durable and persistent file handles of each share
+func (server *sambaStatusServer) GetShareHandles(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetShareHandlesServer) error {
+	return sendList(commonbl.SHARE_HANDLES_REQUEST, getShareHandles, statusdrpc.NewShareHandles, stream.Send)
+}
+
+// sendList - Send each entry of the list getData returns as message. Like the responses on the pipes and TCP connections,
+// getData logs errors and returns an empty list then
+func sendList[T any, M any](requestType commonbl.RequestType, getData func() []T, newMessage func(T) *M, send func(*M) error) error {
+	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" gRPC request", requestType))
+	trackingId := requestTracker.Start()
+	defer requestTracker.Done(trackingId)
+
+	if getRuntimeSettings().isRequestDisabled(requestType) {
+		return nil
+	}
+
+	for _, entry := range getData() {
+		errSend := send(newMessage(entry))
+		if errSend != nil {
+			return errSend
+		}
+	}
+
+	return nil
+}
+
+// sendSmbstatusOutput - Send the output of smbstatus for the request type in chunks
+func sendSmbstatusOutput(requestType commonbl.RequestType, stream smbstatusOutputStream) error {
+	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" gRPC request", requestType))
+	trackingId := requestTracker.Start()
+	defer requestTracker.Done(trackingId)
+
+	if getRuntimeSettings().isRequestDisabled(requestType) {
+		return nil
+	}
+
+	output, errGet := getSmbstatusOutput(requestType)
+	if errGet != nil {
+		logger.WriteError(errGet)
+		return status.Error(codes.Internal, errGet.Error())
+	}
+
+	for _, message := range statusdrpc.SplitSmbstatusOutput(output) {
+		errSend := stream.Send(message)
+		if errSend != nil {
+			return errSend
+		}
+	}
+
+	return nil
+}
+
+// getPsData - Get the resource usage of the smbd processes, or the test data when running in test mode
+func getPsData() ([]commonbl.PsUtilPidData, error) {
+	var pidData []commonbl.PsUtilPidData
+	if params.Test {
+		errConv := json.Unmarshal([]byte(commonbl.TestPsResponse()), &pidData)
+		return pidData, errConv
+	}
+
+	pidData, errGet := psDataGenerator.GetPsUtilPidData()
+	if errGet != nil {
+		return nil, fmt.Errorf("Getting the ps data of \"%s\" returned the following error: %s", PROCESS_TO_MONITOR, errGet)
+	}
+
+	return pidData, nil
+}
+
+// getSmbstatusOutput - Get the output of smbstatus for the request type, or the test data when running in test mode
+func getSmbstatusOutput(requestType commonbl.RequestType) (string, error) {
+	if params.Test {
+		return testSmbstatusOutput[requestType], nil
+	}
+
+	settings := getRuntimeSettings()
+	arguments := getSmbstatusArguments(settings, requestType)
+	data, err := runSmbstatusFor(requestType)
+	if err != nil {
+		return "", fmt.Errorf("\"%s %s\"  returned the following error: %s", settings.SmbstatusPath, strings.Join(arguments, " "), err)
+	}
+
+	return string(data), nil
+}
+
+// listenGrpc - Listen for gRPC requests of samba_exporter on the -grpc.listen-address. TLS is used when a certificate is given
+func listenGrpc() (*grpc.Server, net.Listener, error) {
+	tlsConfig, errConfig := getServerTLSConfig()
+	if errConfig != nil {
+		return nil, nil, errConfig
+	}
+
+	var options []grpc.ServerOption
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	listener, errListen := net.Listen("tcp", params.GrpcListenAddress)
+	if errListen != nil {
+		return nil, nil, errListen
+	}
+
+	server := grpc.NewServer(options...)
+	statusdrpc.RegisterSambaStatusServer(server, &sambaStatusServer{})
+
+	return server, listener, nil
+}
@@ -0,0 +1,209 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/statusdrpc"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestListenGrpc(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	oldSettings := getRuntimeSettings()
+	defer setRuntimeSettings(oldSettings)
+	params.Test = true
+	params.GrpcListenAddress = "127.0.0.1:0"
+	logger = testhelper.NewTestLogger(true)
+	setRuntimeSettings(runtimeSettings{})
+
+	server, listener, errListen := listenGrpc()
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, errDial := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if errDial != nil {
+		t.Fatalf("Got error '%s' but expected none", errDial.Error())
+	}
+	defer conn.Close()
+	client := statusdrpc.NewSambaStatusClient(conn)
+
+	locks := receiveLocks(t, client)
+	if locks != commonbl.TestLockResponse {
+		t.Errorf("Received '%s' but expected the test lock response", locks)
+	}
+
+	psStream, errPs := client.GetPsData(context.Background(), &statusdrpc.StatusRequest{})
+	if errPs != nil {
+		t.Fatalf("Got error '%s' but expected none", errPs.Error())
+	}
+	psCount := 0
+	for {
+		_, errRecv := psStream.Recv()
+		if errRecv == io.EOF {
+			break
+		}
+		if errRecv != nil {
+			t.Fatalf("Got error '%s' but expected none", errRecv.Error())
+		}
+		psCount++
+	}
+	if psCount != 2 {
+		t.Errorf("Received '%d' ps data messages but expected '2'", psCount)
+	}
+
+	setRuntimeSettings(runtimeSettings{DisabledCollectors: []string{"locks"}})
+	locks = receiveLocks(t, client)
+	if locks != "" {
+		t.Errorf("Received '%s' but expected nothing, since the collector is disabled", locks)
+	}
+}
+
+func TestGrpcLists(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	oldSettings := getRuntimeSettings()
+	defer setRuntimeSettings(oldSettings)
+	params.Test = true
+	params.GrpcListenAddress = "127.0.0.1:0"
+	logger = testhelper.NewTestLogger(true)
+	setRuntimeSettings(runtimeSettings{})
+
+	server, listener, errListen := listenGrpc()
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, errDial := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if errDial != nil {
+		t.Fatalf("Got error '%s' but expected none", errDial.Error())
+	}
+	defer conn.Close()
+	client := statusdrpc.NewSambaStatusClient(conn)
+	ctx := context.Background()
+
+	cgroupStream, errCgroup := client.GetCgroups(ctx, &statusdrpc.StatusRequest{})
+	cgroups := receiveAll[statusdrpc.Cgroup](t, cgroupStream, errCgroup)
+	if len(cgroups) != 1 || cgroups[0].ToCgroupData() != commonbl.GetTestCgroupData()[0] {
+		t.Errorf("Received the cgroups '%v' but expected the test data", cgroups)
+	}
+
+	nodeStream, errNodes := client.GetCtdbNodes(ctx, &statusdrpc.StatusRequest{})
+	nodes := receiveAll[statusdrpc.CtdbNode](t, nodeStream, errNodes)
+	if len(nodes) != 2 || nodes[1].ToCtdbNode() != commonbl.GetTestCtdbNodes()[1] {
+		t.Errorf("Received the nodes '%v' but expected the test data", nodes)
+	}
+
+	configStream, errConfig := client.GetShareConfig(ctx, &statusdrpc.StatusRequest{})
+	configs := receiveAll[statusdrpc.ShareConfig](t, configStream, errConfig)
+	if len(configs) != 2 || configs[1].GetName() != "data" || configs[1].GetParameters()["path"] != "/srv/data" {
+		t.Errorf("Received the shares '%v' but expected the test data", configs)
+	}
+
+	handlesStream, errHandles := client.GetShareHandles(ctx, &statusdrpc.StatusRequest{})
+	handles := receiveAll[statusdrpc.ShareHandles](t, handlesStream, errHandles)
+	if len(handles) != 1 || handles[0].ToShareHandles() != commonbl.GetTestShareHandles()[0] {
+		t.Errorf("Received the handles '%v' but expected the test data", handles)
+	}
+
+	setRuntimeSettings(runtimeSettings{DisabledCollectors: []string{"cgroup"}})
+	cgroupStream, errCgroup = client.GetCgroups(ctx, &statusdrpc.StatusRequest{})
+	cgroups = receiveAll[statusdrpc.Cgroup](t, cgroupStream, errCgroup)
+	if len(cgroups) != 0 {
+		t.Errorf("Received the cgroups '%v' but expected none, since the collector is disabled", cgroups)
+	}
+}
+
+func TestListenGrpcWithInvalidCertificate(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.GrpcListenAddress = "127.0.0.1:0"
+	params.TcpTLSCertFile = "/not/existing/cert.pem"
+	params.TcpTLSKeyFile = "/not/existing/key.pem"
+	logger = testhelper.NewTestLogger(true)
+
+	_, _, errListen := listenGrpc()
+	if errListen == nil {
+		t.Errorf("Got no error but expected one, since the certificate does not exist")
+	}
+}
+
+func TestMainWithTcpAndGrpc(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.Test = true
+	params.TcpListenAddress = "127.0.0.1:0"
+	params.GrpcListenAddress = "127.0.0.1:0"
+
+	res := realMain()
+	if res != -10 {
+		t.Errorf("Got %d from main, but expected -10", res)
+	}
+}
+
+func receiveLocks(t *testing.T, client statusdrpc.SambaStatusClient) string {
+	stream, errCall := client.GetLocks(context.Background(), &statusdrpc.StatusRequest{})
+	if errCall != nil {
+		t.Fatalf("Got error '%s' but expected none", errCall.Error())
+	}
+
+	var messages []*statusdrpc.SmbstatusOutput
+	for {
+		message, errRecv := stream.Recv()
+		if errRecv == io.EOF {
+			break
+		}
+		if errRecv != nil {
+			t.Fatalf("Got error '%s' but expected none", errRecv.Error())
+		}
+		messages = append(messages, message)
+	}
+
+	return statusdrpc.JoinSmbstatusOutput(messages)
+}
+
+// receiveAll - Receive all messages of the stream
+func receiveAll[M any](t *testing.T, stream interface{ Recv() (*M, error) }, errCall error) []*M {
+	if errCall != nil {
+		t.Fatalf("Got error '%s' but expected none", errCall.Error())
+	}
+
+	var messages []*M
+	for {
+		message, errRecv := stream.Recv()
+		if errRecv == io.EOF {
+			return messages
+		}
+		if errRecv != nil {
+			t.Fatalf("Got error '%s' but expected none", errRecv.Error())
+		}
+		messages = append(messages, message)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// The prefix of the paths of the HTTP JSON API
+const HTTP_API_PREFIX = "/api/v1/"
+
+// The time a client gets to send the request header to the HTTP JSON API
+const httpReadHeaderTimeout = 10 * time.Second
+
+// Parses the output of smbstatus into the entries the HTTP JSON API answers with
+type smbstatusParser func(data string) interface{}
+
+// The smbstatus tables of the HTTP JSON API by path
+var httpApiTables = map[string]commonbl.RequestType{
+	HTTP_API_PREFIX + "locks":     commonbl.LOCK_REQUEST,
+	HTTP_API_PREFIX + "shares":    commonbl.SHARE_REQUEST,
+	HTTP_API_PREFIX + "processes": commonbl.PROCESS_REQUEST,
+}
+
+// The parsers of the smbstatus tables by request type. Tables without entries are answered with an empty array
+var smbstatusParsers = map[commonbl.RequestType]smbstatusParser{
+	commonbl.LOCK_REQUEST: func(data string) interface{} {
+		if entries := smbstatusreader.GetLockData(data, logger); entries != nil {
+			return entries
+		}
+		return []smbstatusreader.LockData{}
+	},
+	commonbl.SHARE_REQUEST: func(data string) interface{} {
+		if entries := smbstatusreader.GetShareData(data, logger); entries != nil {
+			return entries
+		}
+		return []smbstatusreader.ShareData{}
+	},
+	commonbl.PROCESS_REQUEST: func(data string) interface{} {
+		if entries := smbstatusreader.GetProcessData(data, logger); entries != nil {
+			return entries
+		}
+		return []smbstatusreader.ProcessData{}
+	},
+}
+
+// The body of the answers of the HTTP JSON API in case of an error
+type httpApiError struct {
+	Error string
+}
+
+// listenHttpApi - Listen for requests to the HTTP JSON API on the -http.listen-address. TLS is used when a certificate is given
+func listenHttpApi() (*http.Server, net.Listener, error) {
+	tlsConfig, errConfig := getServerTLSConfig()
+	if errConfig != nil {
+		return nil, nil, errConfig
+	}
+
+	listener, errListen := commonbl.ListenTcp(params.HttpListenAddress, tlsConfig)
+	if errListen != nil {
+		return nil, nil, errListen
+	}
+
+	server := &http.Server{Handler: getHttpApiHandler(), ReadHeaderTimeout: httpReadHeaderTimeout}
+
+	return server, listener, nil
+}
+
+// goServeHttpApi, is called as go routine and answers the requests to the HTTP JSON API until the listener is closed.
+// Exits the program when the server stops unexpected
+func goServeHttpApi(server *http.Server, listener net.Listener) {
+	errServe := server.Serve(listener)
+	if errServe != nil && errServe != http.ErrServerClosed {
+		logger.WriteErrorWithAddition(errServe, "while serving the HTTP JSON API")
+		os.Exit(-1)
+	}
+}
+
+// getHttpApiHandler - Get the handler of the HTTP JSON API
+func getHttpApiHandler() http.Handler {
+	mux := http.NewServeMux()
+	for path, requestType := range httpApiTables {
+		requestType := requestType
+		mux.HandleFunc(path, func(writer http.ResponseWriter, request *http.Request) {
+			serveSmbstatusTable(writer, request, requestType)
+		})
+	}
+	mux.HandleFunc(HTTP_API_PREFIX+"psdata", servePsData)
+	mux.HandleFunc(HTTP_API_PREFIX+"version", func(writer http.ResponseWriter, request *http.Request) {
+		if checkHttpApiMethod(writer, request) {
+			writeHttpApiResponse(writer, http.StatusOK, map[string]string{"Version": version})
+		}
+	})
+
+	return mux
+}
+
+// serveSmbstatusTable - Answer with the parsed entries of the smbstatus table of the request type
+func serveSmbstatusTable(writer http.ResponseWriter, request *http.Request, requestType commonbl.RequestType) {
+	if !checkHttpApiMethod(writer, request) {
+		return
+	}
+	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" HTTP request from %s", requestType, request.RemoteAddr))
+	trackingId := requestTracker.Start()
+	defer requestTracker.Done(trackingId)
+
+	settings := getRuntimeSettings()
+	if settings.isRequestDisabled(requestType) {
+		writeHttpApiDisabled(writer, requestType)
+		return
+	}
+
+	output, errGet := getSmbstatusOutput(requestType)
+	if errGet != nil {
+		logger.WriteError(errGet)
+		writeHttpApiResponse(writer, http.StatusInternalServerError, httpApiError{errGet.Error()})
+		return
+	}
+
+	smbstatusreader.SetSambaTimezone(getSmbstatusTimezone(settings))
+	writeHttpApiResponse(writer, http.StatusOK, smbstatusParsers[requestType](output))
+}
+
+// servePsData - Answer with the resource usage of the smbd processes
+func servePsData(writer http.ResponseWriter, request *http.Request) {
+	if !checkHttpApiMethod(writer, request) {
+		return
+	}
+	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" HTTP request from %s", commonbl.PS_REQUEST, request.RemoteAddr))
+	trackingId := requestTracker.Start()
+	defer requestTracker.Done(trackingId)
+
+	if getRuntimeSettings().isRequestDisabled(commonbl.PS_REQUEST) {
+		writeHttpApiDisabled(writer, commonbl.PS_REQUEST)
+		return
+	}
+
+	pidData, errGet := getPsData()
+	if errGet != nil {
+		logger.WriteError(errGet)
+		writeHttpApiResponse(writer, http.StatusInternalServerError, httpApiError{errGet.Error()})
+		return
+	}
+	if pidData == nil {
+		pidData = []commonbl.PsUtilPidData{}
+	}
+
+	writeHttpApiResponse(writer, http.StatusOK, pidData)
+}
+
+// getSmbstatusTimezone - Get the timezone smbstatus runs with, so the time stamps of its output are read correctly
+func getSmbstatusTimezone(settings runtimeSettings) *time.Location {
+	if settings.SmbstatusTimezone == "" {
+		return nil
+	}
+	location, errLoad := time.LoadLocation(settings.SmbstatusTimezone)
+	if errLoad != nil {
+		return nil
+	}
+
+	return location
+}
+
+// checkHttpApiMethod - Tells if the request uses a method the HTTP JSON API answers, otherwise the error is written
+func checkHttpApiMethod(writer http.ResponseWriter, request *http.Request) bool {
+	if request.Method == http.MethodGet || request.Method == http.MethodHead {
+		return true
+	}
+	writer.Header().Set("Allow", "GET, HEAD")
+	writeHttpApiResponse(writer, http.StatusMethodNotAllowed, httpApiError{fmt.Sprintf("The method %s is not allowed", request.Method)})
+
+	return false
+}
+
+// writeHttpApiDisabled - Answer a request of a disabled collector
+func writeHttpApiDisabled(writer http.ResponseWriter, requestType commonbl.RequestType) {
+	message := fmt.Sprintf("The collector for \"%s\" is disabled in samba_statusd", strings.TrimSuffix(string(requestType), ":"))
+	writeHttpApiResponse(writer, http.StatusNotFound, httpApiError{message})
+}
+
+// writeHttpApiResponse - Write the body as JSON with the status code
+func writeHttpApiResponse(writer http.ResponseWriter, statusCode int, body interface{}) {
+	jsonData, errConv := json.MarshalIndent(body, "", " ")
+	if errConv != nil {
+		logger.WriteErrorWithAddition(errConv, "while converting the answer of the HTTP JSON API")
+		http.Error(writer, errConv.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	writer.Write(jsonData)
+}
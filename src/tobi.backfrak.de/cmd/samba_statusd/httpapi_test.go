@@ -0,0 +1,110 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// getHttpApi - Request the path of the HTTP JSON API and read the JSON body into the value
+func getHttpApi(t *testing.T, server *httptest.Server, method string, path string, value interface{}) int {
+	request, errRequest := http.NewRequest(method, server.URL+path, nil)
+	if errRequest != nil {
+		t.Fatalf("Got error '%s' but expected none", errRequest.Error())
+	}
+	response, errDo := server.Client().Do(request)
+	if errDo != nil {
+		t.Fatalf("Got error '%s' but expected none", errDo.Error())
+	}
+	defer response.Body.Close()
+
+	if response.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Got the content type '%s' but expected 'application/json'", response.Header.Get("Content-Type"))
+	}
+	errDecode := json.NewDecoder(response.Body).Decode(value)
+	if errDecode != nil {
+		t.Fatalf("Got error '%s' but expected none", errDecode.Error())
+	}
+
+	return response.StatusCode
+}
+
+func TestHttpApi(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	oldSettings := getRuntimeSettings()
+	defer setRuntimeSettings(oldSettings)
+	params.Test = true
+	logger = testhelper.NewTestLogger(true)
+	setRuntimeSettings(runtimeSettings{})
+
+	server := httptest.NewServer(getHttpApiHandler())
+	defer server.Close()
+
+	var locks []smbstatusreader.LockData
+	status := getHttpApi(t, server, http.MethodGet, "/api/v1/locks", &locks)
+	if status != http.StatusOK {
+		t.Errorf("Got the status %d but expected %d", status, http.StatusOK)
+	}
+	if len(locks) != 1 || locks[0].PID != 1120 || locks[0].SharePath != "/usr/share/data" {
+		t.Errorf("The locks '%v' are not expected", locks)
+	}
+
+	var processes []smbstatusreader.ProcessData
+	status = getHttpApi(t, server, http.MethodGet, "/api/v1/processes", &processes)
+	if status != http.StatusOK || len(processes) != 1 {
+		t.Errorf("Got the status %d and %d processes, but expected %d and 1", status, len(processes), http.StatusOK)
+	}
+
+	var shares []smbstatusreader.ShareData
+	status = getHttpApi(t, server, http.MethodGet, "/api/v1/shares", &shares)
+	if status != http.StatusOK || len(shares) == 0 {
+		t.Errorf("Got the status %d and %d shares, but expected %d and some shares", status, len(shares), http.StatusOK)
+	}
+
+	var psData []commonbl.PsUtilPidData
+	status = getHttpApi(t, server, http.MethodGet, "/api/v1/psdata", &psData)
+	if status != http.StatusOK || len(psData) != 2 {
+		t.Errorf("Got the status %d and %d ps data entries, but expected %d and 2", status, len(psData), http.StatusOK)
+	}
+
+	var apiError httpApiError
+	status = getHttpApi(t, server, http.MethodPost, "/api/v1/locks", &apiError)
+	if status != http.StatusMethodNotAllowed || apiError.Error == "" {
+		t.Errorf("Got the status %d and the error '%s', but expected %d and an error", status, apiError.Error, http.StatusMethodNotAllowed)
+	}
+
+	setRuntimeSettings(runtimeSettings{DisabledCollectors: []string{"locks"}})
+	apiError = httpApiError{}
+	status = getHttpApi(t, server, http.MethodGet, "/api/v1/locks", &apiError)
+	if status != http.StatusNotFound || apiError.Error == "" {
+		t.Errorf("Got the status %d and the error '%s', but expected %d and an error, since the collector is disabled", status, apiError.Error, http.StatusNotFound)
+	}
+}
+
+func TestHttpApiEmptyTable(t *testing.T) {
+	logger = testhelper.NewTestLogger(true)
+
+	for requestType, parse := range smbstatusParsers {
+		data, errConv := json.Marshal(parse(""))
+		if errConv != nil {
+			t.Fatalf("Got error '%s' but expected none", errConv.Error())
+		}
+		if string(data) != "[]" {
+			t.Errorf("Got '%s' for an empty \"%s\" table but expected '[]'", string(data), requestType)
+		}
+	}
+}
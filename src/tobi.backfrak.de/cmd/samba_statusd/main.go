@@ -10,11 +10,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
 	"os/user"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"tobi.backfrak.de/internal/commonbl"
 	"tobi.backfrak.de/internal/smbstatusdbl"
@@ -28,17 +29,54 @@ const PROCESS_TO_MONITOR = "smbd"
 var version = "undefined"
 
 // Type for functions that can create a response string
-type response func(*commonbl.PipeHandler, int) error
+type response func(commonbl.MessageHandler, int) error
+
+// requestResponse - The responses samba_statusd sends to a request type
+type requestResponse struct {
+	productive response
+	test       response
+	// jsonList - The data of the response is a JSON list, a disabled collector answers with an empty list
+	jsonList bool
+}
+
+// The responses to the requests samba_statusd can handle
+var requestResponses = map[commonbl.RequestType]requestResponse{
+	commonbl.PROCESS_REQUEST:       {productive: processResponse, test: testProcessResponse},
+	commonbl.SHARE_REQUEST:         {productive: shareResponse, test: testShareResponse},
+	commonbl.LOCK_REQUEST:          {productive: lockResponse, test: testLockResponse},
+	commonbl.PS_REQUEST:            {productive: psResponse, test: testPsResponse, jsonList: true},
+	commonbl.VERSION_REQUEST:       {productive: versionResponse, test: versionResponse},
+	commonbl.PLUGIN_REQUEST:        jsonListResponse(commonbl.PLUGIN_REQUEST, getPluginResults),
+	commonbl.CGROUP_REQUEST:        jsonListResponse(commonbl.CGROUP_REQUEST, getCgroupData),
+	commonbl.CTDB_NODES_REQUEST:    jsonListResponse(commonbl.CTDB_NODES_REQUEST, getCtdbNodes),
+	commonbl.SHARE_CONFIG_REQUEST:  jsonListResponse(commonbl.SHARE_CONFIG_REQUEST, getShareConfig),
+	commonbl.SHARE_HANDLES_REQUEST: jsonListResponse(commonbl.SHARE_HANDLES_REQUEST, getShareHandles),
+}
 
 // The logger for this programm
 var logger commonbl.Logger
 
-// Path to the smbstatus executable
-var smbstatusPath string
-
 var requestQueue commonbl.StringQueue
 
-var psDataGenerator *smbstatusdbl.PsDataGenerator
+// Ensures only one request is handled at a time, so smbstatus runs one after the other and
+// the responses are written in the order the requests were received
+var handleMux sync.Mutex
+
+// psDataSource - Gets the resource usage of the smbd processes
+type psDataSource interface {
+	GetPsUtilPidData() ([]commonbl.PsUtilPidData, error)
+}
+
+var psDataGenerator psDataSource
+
+// The generator of the smbstatus output in demo mode, nil when smbstatus is used
+var demoDataGenerator *smbstatusdbl.DemoDataGenerator
+
+// The shared secret of the -auth.secret-file, nil when the messages are not signed
+var authSecret []byte
+
+// Tracks the running requests, so the systemd watchdog can detect a hanging smbstatus
+var requestTracker = commonbl.NewOperationTracker()
 
 func main() {
 	handleComandlineOptions()
@@ -46,14 +84,36 @@ func main() {
 }
 
 func realMain() int {
-	var newLoggerErrror error
-	requestHandler := *commonbl.NewPipeHandler(params.Test, commonbl.RequestPipe)
-	responseHandler := *commonbl.NewPipeHandler(params.Test, commonbl.ResposePipe)
-	logger, newLoggerErrror = commonbl.GetLogger(params.LogFilePath, params.Verbose)
+	errConfig := applyConfigFile(flag.CommandLine, &params)
+	if errConfig != nil {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf("Error when reading the configuration file: %s", errConfig.Error()))
+		return -8
+	}
+	// The verbose messages are switched by the SwitchableLogger, so they can be switched on and off with SIGUSR2
+	baseLogger, newLoggerErrror := commonbl.GetLogger(params.LogFilePath, true)
 	if newLoggerErrror != nil {
 		fmt.Fprintln(os.Stderr, fmt.Sprintf("Error when creating the logger: %s", newLoggerErrror.Error()))
 		return -9
 	}
+	switchableLogger := commonbl.NewSwitchableLogger(baseLogger, params.Verbose)
+	logger = switchableLogger
+	runAs, errRunAs := lookupRunAs(params.RunAsUser, params.RunAsGroup)
+	if errRunAs != nil {
+		logger.WriteErrorWithAddition(errRunAs, "in the -run-as.* parameters")
+		return -17
+	}
+	// The pipes need to belong to the -run-as.user, so they can still be used once the privileges are dropped
+	pipeOwner := params.PipeOwner
+	if pipeOwner == "" {
+		pipeOwner = params.RunAsUser
+	}
+	pipeSettings, errPipeSettings := commonbl.NewPipeSettings(params.PipeDirectory, params.PipeMode, pipeOwner, params.PipeGroup)
+	if errPipeSettings != nil {
+		logger.WriteErrorWithAddition(errPipeSettings, "in the -pipe.* parameters")
+		return -12
+	}
+	requestHandler := commonbl.NewPipeHandlerWithSettings(params.Test, commonbl.RequestPipe, pipeSettings)
+	responseHandler := commonbl.NewPipeHandlerWithSettings(params.Test, commonbl.ResposePipe, pipeSettings)
 
 	if params.Verbose {
 		args := ""
@@ -65,8 +125,14 @@ func realMain() int {
 			printVersion()
 		}
 	}
-	logger.WriteVerbose(fmt.Sprintf("Named pipe for requests: %s", requestHandler.GetPipeFilePath()))
-	logger.WriteVerbose(fmt.Sprintf("Named pipe for response: %s", responseHandler.GetPipeFilePath()))
+	if params.TcpListenAddress != "" {
+		logger.WriteVerbose(fmt.Sprintf("Listen for requests on: %s", params.TcpListenAddress))
+	} else if params.GrpcListenAddress != "" {
+		logger.WriteVerbose(fmt.Sprintf("Listen for gRPC requests on: %s", params.GrpcListenAddress))
+	} else {
+		logger.WriteVerbose(fmt.Sprintf("Named pipe for requests: %s", requestHandler.GetPipeFilePath()))
+		logger.WriteVerbose(fmt.Sprintf("Named pipe for response: %s", responseHandler.GetPipeFilePath()))
+	}
 
 	if params.PrintVersion {
 		printVersion()
@@ -78,7 +144,46 @@ func realMain() int {
 		return 0
 	}
 
-	if !params.Test {
+	if params.TcpListenAddress != "" && params.GrpcListenAddress != "" {
+		logger.WriteErrorMessage("The parameters -tcp.listen-address and -grpc.listen-address can not be used together")
+		return -10
+	}
+
+	modes := 0
+	for _, mode := range []bool{params.Test, params.Demo, params.ReplayDirectory != ""} {
+		if mode {
+			modes++
+		}
+	}
+	if modes > 1 {
+		logger.WriteErrorMessage("The parameters -test-mode, -demo and -replay.directory can not be used together")
+		return -13
+	}
+
+	if params.Sandbox && strings.TrimSpace(params.SmbstatusCommandPrefix) != "" {
+		logger.WriteErrorMessage("The -sandbox can not be combined with the -smbstatus.command-prefix, since no program can gain the privileges of root in the sandbox")
+		return -18
+	}
+	if params.Sandbox && strings.TrimSpace(params.SmbstatusContainer) != "" && params.SmbstatusContainerRuntime == CONTAINER_RUNTIME_NSENTER {
+		logger.WriteErrorMessage("The -sandbox can not be combined with the nsenter -smbstatus.container-runtime, since the sandbox denies to enter namespaces")
+		return -18
+	}
+
+	if params.Demo {
+		demoDataGenerator = smbstatusdbl.NewDemoDataGenerator(time.Now().UnixNano())
+		psDataGenerator = demoDataGenerator
+	} else if params.ReplayDirectory != "" {
+		source, errSource := newReplaySource(params.ReplayDirectory)
+		if errSource != nil {
+			logger.WriteErrorWithAddition(errSource, "in the -replay.directory")
+			return -14
+		}
+		for _, missing := range source.getMissingFiles() {
+			logger.WriteInformation(fmt.Sprintf("The file '%s' is not in the -replay.directory, its requests are answered with empty data", missing))
+		}
+		replayData = source
+		psDataGenerator = source
+	} else if !params.Test {
 
 		currentUser, errUserGet := user.Current()
 		if errUserGet != nil {
@@ -86,40 +191,149 @@ func realMain() int {
 			return -5
 		}
 
-		if currentUser.Username != "root" {
-			logger.WriteErrorMessage(fmt.Sprintf("The current user %s is not root.", currentUser.Username))
+		// With a -smbstatus.command-prefix, only smbstatus needs to run as root
+		if currentUser.Username != "root" && strings.TrimSpace(params.SmbstatusCommandPrefix) == "" {
+			logger.WriteErrorMessage(fmt.Sprintf("The current user %s is not root. Use the -smbstatus.command-prefix to run smbstatus as root", currentUser.Username))
 			return -6
 		}
 
-		var errLookPath error
-		smbstatusPath, errLookPath = exec.LookPath("smbstatus")
-		if errLookPath != nil {
-			logger.WriteErrorMessage("Can not find \"smbstatus\" executable. Please install the needed package.")
-			return -3
-		} else {
-			logger.WriteVerbose(fmt.Sprintf("Use %s to get samba status.", smbstatusPath))
-		}
-
 		psDataGeneratorTmp, errNewGen := smbstatusdbl.NewPsDataGenerator(PROCESS_TO_MONITOR)
 		if errNewGen != nil {
 			logger.WriteError(errNewGen)
 			return -7
 		}
+		psDataGeneratorTmp.Logger = logger
 		psDataGenerator = psDataGeneratorTmp
 	}
 
+	settings, errSettings := newRuntimeSettings(params.runtimeParmeters, !usesSmbstatus(params))
+	if errSettings != nil {
+		logger.WriteError(errSettings)
+		return -3
+	}
+	setRuntimeSettings(settings)
+
+	if params.AuthSecretFile != "" {
+		secret, errSecret := commonbl.ReadSecretFile(params.AuthSecretFile)
+		if errSecret != nil {
+			logger.WriteErrorWithAddition(errSecret, "while reading the -auth.secret-file")
+			return -11
+		}
+		authSecret = secret
+		if params.GrpcListenAddress != "" {
+			logger.WriteInformation("The -auth.secret-file is not used for gRPC requests, use -tcp.tls.client-ca-file to authenticate samba_exporter")
+		}
+		if params.HttpListenAddress != "" {
+			logger.WriteInformation("The -auth.secret-file is not used for the HTTP JSON API, use -tcp.tls.client-ca-file to authenticate the clients")
+		}
+	}
+	if settings.PluginsDirectory != "" && params.GrpcListenAddress != "" {
+		logger.WriteInformation("The plugins of the -plugins.directory are not run for gRPC requests, use -tcp.listen-address or the named pipes to export their metrics")
+	}
+	if params.Demo {
+		logger.WriteInformation("Running in demo mode, the samba status is generated")
+	} else if params.ReplayDirectory != "" {
+		logger.WriteInformation(fmt.Sprintf("Answer the requests with the files in '%s'", params.ReplayDirectory))
+	} else if !params.Test {
+		logger.WriteVerbose(fmt.Sprintf("Use %s to get samba status.", settings.SmbstatusPath))
+		if len(settings.SmbstatusPrefix) > 0 {
+			logger.WriteVerbose(fmt.Sprintf("Run smbstatus with the command prefix '%s'", strings.Join(settings.SmbstatusPrefix, " ")))
+		}
+		if settings.SmbstatusContainer != nil {
+			logger.WriteInformation(fmt.Sprintf("Run smbstatus in %s", settings.SmbstatusContainer))
+		}
+	}
+
 	// Ensure we exit clean on term and kill signals
 	go waitforKillSignalAndExit()
 	go waitforTermSignalAndExit()
+	go waitforHangupSignalAndReload()
+	go waitforUserSignalAndClearCache()
+	commonbl.StartVerboseToggle(switchableLogger)
+
+	if params.DbusBus != "" {
+		conn, errDbus := exportDbus(params.DbusBus)
+		if errDbus != nil {
+			logger.WriteErrorWithAddition(errDbus, fmt.Sprintf("while exporting the samba status on the D-Bus %s bus", params.DbusBus))
+			return -15
+		}
+		defer conn.Close()
+		logger.WriteInformation(fmt.Sprintf("Exported the samba status on the D-Bus %s bus as '%s'", params.DbusBus, DBUS_NAME))
+	}
+
+	if params.HttpListenAddress != "" {
+		server, listener, errListen := listenHttpApi()
+		if errListen != nil {
+			logger.WriteErrorWithAddition(errListen, fmt.Sprintf("while listening on '%s'", params.HttpListenAddress))
+			return -16
+		}
+		defer server.Close()
+		logger.WriteInformation(fmt.Sprintf("Waiting for requests to the HTTP JSON API on '%s'", params.HttpListenAddress))
+		go goServeHttpApi(server, listener)
+	}
+
+	if params.TcpListenAddress != "" {
+		listener, errListen := listenTcp()
+		if errListen != nil {
+			logger.WriteErrorWithAddition(errListen, fmt.Sprintf("while listening on '%s'", params.TcpListenAddress))
+			return -10
+		}
+		defer listener.Close()
+		if errRestrict := restrictProcess(runAs); errRestrict != nil {
+			logger.WriteError(errRestrict)
+			return -17
+		}
+
+		logger.WriteInformation(fmt.Sprintf("Started %s, waiting for requests on '%s'", os.Args[0], params.TcpListenAddress))
+		commonbl.StartSdNotifications(requestTracker.IsHealthy, logger)
+		return serveTcp(listener)
+	}
+
+	if params.GrpcListenAddress != "" {
+		server, listener, errListen := listenGrpc()
+		if errListen != nil {
+			logger.WriteErrorWithAddition(errListen, fmt.Sprintf("while listening on '%s'", params.GrpcListenAddress))
+			return -10
+		}
+		if errRestrict := restrictProcess(runAs); errRestrict != nil {
+			logger.WriteError(errRestrict)
+			return -17
+		}
+
+		logger.WriteInformation(fmt.Sprintf("Started %s, waiting for gRPC requests on '%s'", os.Args[0], params.GrpcListenAddress))
+		commonbl.StartSdNotifications(requestTracker.IsHealthy, logger)
+		errServe := server.Serve(listener)
+		if errServe != nil {
+			logger.WriteError(errServe)
+			return -1
+		}
+		return 0
+	}
+
+	// Create the pipes with the configured permissions, before samba_exporter might create them
+	for _, handler := range []*commonbl.PipeHandler{requestHandler, responseHandler} {
+		errPrepare := handler.PreparePipe()
+		if errPrepare != nil {
+			logger.WriteErrorWithAddition(errPrepare, fmt.Sprintf("while preparing the pipe '%s'", handler.GetPipeFilePath()))
+			return -12
+		}
+	}
+	if errRestrict := restrictProcess(runAs); errRestrict != nil {
+		logger.WriteError(errRestrict)
+		return -17
+	}
 
 	// Init a queue, to store the requests
 	requestQueue = *commonbl.NewStringQueue()
+	signedRequestHandler := getSignedHandler(requestHandler)
+	signedResponseHandler := getSignedHandler(responseHandler)
 
 	// Wait for pipe input and process it in an infinite loop
 	logger.WriteInformation(fmt.Sprintf("Started %s, waiting for requests in pipe", os.Args[0]))
+	commonbl.StartSdNotifications(requestTracker.IsHealthy, logger)
 	for {
 		logger.WriteVerbose(fmt.Sprintf("Wait for requests in: %s", requestHandler.GetPipeFilePath()))
-		received, errRecv := requestHandler.WaitForPipeInputString()
+		received, errRecv := signedRequestHandler.WaitForPipeInputString()
 		if errRecv != nil {
 			logger.WriteErrorMessage(fmt.Sprintf("Receive this unexpected data from the pipe: %s", errRecv))
 			return -1
@@ -127,13 +341,15 @@ func realMain() int {
 
 		// Add request to the queue and process the request in own "thread"
 		requestQueue.Push(received)
-		go goHandleRequestQueue(&responseHandler)
+		go goHandleRequestQueue(signedResponseHandler)
 	}
 
 }
 
 // goHandleRequestQueue, is called as go routine and processes the "oldest" request in the request Queue
-func goHandleRequestQueue(responseHandler *commonbl.PipeHandler) {
+func goHandleRequestQueue(responseHandler commonbl.MessageHandler) {
+	handleMux.Lock()
+	defer handleMux.Unlock()
 	var err error = nil
 	var received string
 	received, err = requestQueue.Pull()
@@ -142,37 +358,42 @@ func goHandleRequestQueue(responseHandler *commonbl.PipeHandler) {
 		os.Exit(-8)
 	}
 
-	if received == "" {
-		return
+	err = handleReceived(responseHandler, received)
+	if err != nil {
+		logger.WriteErrorMessage(fmt.Sprintf("Handle request \"%s\"\n\n: %s", received, err))
+		os.Exit(-2)
 	}
+}
 
-	if strings.HasPrefix(received, string(commonbl.PROCESS_REQUEST)) {
-		err = handleRequest(responseHandler, received, commonbl.PROCESS_REQUEST, processResponse, testProcessResponse)
-	} else if strings.HasPrefix(received, string(commonbl.SHARE_REQUEST)) {
-		err = handleRequest(responseHandler, received, commonbl.SHARE_REQUEST, shareResponse, testShareResponse)
-	} else if strings.HasPrefix(received, string(commonbl.LOCK_REQUEST)) {
-		err = handleRequest(responseHandler, received, commonbl.LOCK_REQUEST, lockResponse, testLockResponse)
-	} else if strings.HasPrefix(received, string(commonbl.PS_REQUEST)) {
-		err = handleRequest(responseHandler, received, commonbl.PS_REQUEST, psResponse, testPsResponse)
-	} else {
-		logger.WriteErrorMessage(fmt.Sprintf("Can not handle the request: '%s'", received))
+// handleReceived - Handle the received request and write the response using the responseHandler
+func handleReceived(responseHandler commonbl.MessageHandler, received string) error {
+	if received == "" {
+		return nil
 	}
 
-	if err != nil {
-		logger.WriteErrorMessage(fmt.Sprintf("Handle request \"%s\"\n\n: %s", received, err))
-		os.Exit(-2)
+	for requestType, responses := range requestResponses {
+		if strings.HasPrefix(received, string(requestType)) {
+			return handleRequest(responseHandler, received, requestType, responses.productive, responses.test)
+		}
 	}
+	logger.WriteErrorMessage(fmt.Sprintf("Can not handle the request: '%s'", received))
+
+	return nil
 }
 
-func handleRequest(handler *commonbl.PipeHandler, request string, requestType commonbl.RequestType, productiveFunc response, testFunc response) error {
+func handleRequest(handler commonbl.MessageHandler, request string, requestType commonbl.RequestType, productiveFunc response, testFunc response) error {
 	id, errConv := commonbl.GetIdFromRequest(request)
 	if errConv != nil {
 		return nil // In case we cant find an ID, we simply ingnor the request as any other invalid input
 	}
 	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" with id %d", requestType, id))
+	trackingId := requestTracker.Start()
+	defer requestTracker.Done(trackingId)
 
 	var writeErr error
-	if !params.Test {
+	if getRuntimeSettings().isRequestDisabled(requestType) {
+		writeErr = disabledResponse(handler, requestType, id)
+	} else if !params.Test {
 		writeErr = productiveFunc(handler, id)
 	} else {
 		writeErr = testFunc(handler, id)
@@ -184,11 +405,13 @@ func handleRequest(handler *commonbl.PipeHandler, request string, requestType co
 	return nil
 }
 
-func lockResponse(handler *commonbl.PipeHandler, id int) error {
+func lockResponse(handler commonbl.MessageHandler, id int) error {
 	header := commonbl.GetResponseHeader(commonbl.LOCK_REQUEST, id)
-	data, err := exec.Command(smbstatusPath, "-L", "-n").Output()
+	settings := getRuntimeSettings()
+	data, err := runSmbstatusFor(commonbl.LOCK_REQUEST)
 	if err != nil {
-		logger.WriteErrorMessage(fmt.Sprintf("\"%s -L -n\"  returned the following error: %s", smbstatusPath, err))
+		logger.WriteErrorMessage(fmt.Sprintf("\"%s %s\"  returned the following error: %s", settings.SmbstatusPath,
+			strings.Join(getSmbstatusArguments(settings, commonbl.LOCK_REQUEST), " "), err))
 		os.Exit(-4)
 	}
 	response := commonbl.GetResponse(header, string(data))
@@ -196,11 +419,13 @@ func lockResponse(handler *commonbl.PipeHandler, id int) error {
 	return handler.WritePipeString(response)
 }
 
-func shareResponse(handler *commonbl.PipeHandler, id int) error {
+func shareResponse(handler commonbl.MessageHandler, id int) error {
 	header := commonbl.GetResponseHeader(commonbl.SHARE_REQUEST, id)
-	data, err := exec.Command(smbstatusPath, "-S", "-n").Output()
+	settings := getRuntimeSettings()
+	data, err := runSmbstatusFor(commonbl.SHARE_REQUEST)
 	if err != nil {
-		logger.WriteErrorMessage(fmt.Sprintf("\"%s -S -n\"  returned the following error: %s", smbstatusPath, err))
+		logger.WriteErrorMessage(fmt.Sprintf("\"%s %s\"  returned the following error: %s", settings.SmbstatusPath,
+			strings.Join(getSmbstatusArguments(settings, commonbl.SHARE_REQUEST), " "), err))
 		os.Exit(-4)
 	}
 	response := commonbl.GetResponse(header, string(data))
@@ -208,11 +433,13 @@ func shareResponse(handler *commonbl.PipeHandler, id int) error {
 	return handler.WritePipeString(response)
 }
 
-func processResponse(handler *commonbl.PipeHandler, id int) error {
+func processResponse(handler commonbl.MessageHandler, id int) error {
 	header := commonbl.GetResponseHeader(commonbl.PROCESS_REQUEST, id)
-	data, err := exec.Command(smbstatusPath, "-p", "-n").Output()
+	settings := getRuntimeSettings()
+	data, err := runSmbstatusFor(commonbl.PROCESS_REQUEST)
 	if err != nil {
-		logger.WriteErrorMessage(fmt.Sprintf("\"%s -p -n\"  returned the following error: %s", smbstatusPath, err))
+		logger.WriteErrorMessage(fmt.Sprintf("\"%s %s\"  returned the following error: %s", settings.SmbstatusPath,
+			strings.Join(getSmbstatusArguments(settings, commonbl.PROCESS_REQUEST), " "), err))
 		os.Exit(-4)
 	}
 	response := commonbl.GetResponse(header, string(data))
@@ -220,12 +447,16 @@ func processResponse(handler *commonbl.PipeHandler, id int) error {
 	return handler.WritePipeString(response)
 }
 
-func psResponse(handler *commonbl.PipeHandler, id int) error {
+func psResponse(handler commonbl.MessageHandler, id int) error {
 	header := commonbl.GetResponseHeader(commonbl.PS_REQUEST, id)
 	pidData, err := psDataGenerator.GetPsUtilPidData()
 	if err != nil {
-		logger.WriteErrorMessage(fmt.Sprintf("\"%s -p -n\"  returned the following error: %s", smbstatusPath, err))
-		os.Exit(-4)
+		// samba_exporter still gets the other data, when the ps data can not be read
+		message := fmt.Sprintf("Getting the ps data of \"%s\" returned the following error: %s", PROCESS_TO_MONITOR, err)
+		logger.WriteErrorMessage(message)
+		response := commonbl.GetResponse(header, commonbl.GetErrorResponseData(message))
+
+		return handler.WritePipeString(response)
 	}
 	jsonData, errConv := json.MarshalIndent(pidData, "", " ")
 	if errConv != nil {
@@ -236,28 +467,165 @@ func psResponse(handler *commonbl.PipeHandler, id int) error {
 	return handler.WritePipeString(response)
 }
 
-func testPsResponse(handler *commonbl.PipeHandler, id int) error {
+// disabledResponse - Answer a request of a disabled collector with the commonbl.DISABLED_RESPONSE_DATA, samba_exporter reads as empty table.
+// The requests answered with a JSON list get an empty list
+func disabledResponse(handler commonbl.MessageHandler, requestType commonbl.RequestType, id int) error {
+	header := commonbl.GetResponseHeader(requestType, id)
+	data := commonbl.DISABLED_RESPONSE_DATA
+	if requestResponses[requestType].jsonList {
+		data = "[]"
+	}
+	response := commonbl.GetResponse(header, data)
+
+	return handler.WritePipeString(response)
+}
+
+// jsonListResponse - Get the responses sending the list getData returns as JSON. getData returns the test data in test mode
+func jsonListResponse[T any](requestType commonbl.RequestType, getData func() []T) requestResponse {
+	send := func(handler commonbl.MessageHandler, id int) error {
+		header := commonbl.GetResponseHeader(requestType, id)
+		jsonData, errConv := json.MarshalIndent(getData(), "", " ")
+		if errConv != nil {
+			return errConv
+		}
+		response := commonbl.GetResponse(header, string(jsonData))
+
+		return handler.WritePipeString(response)
+	}
+
+	return requestResponse{productive: send, test: send, jsonList: true}
+}
+
+// getPluginResults - Run the plugins of the -plugins.directory and get their metrics, in test mode as well
+func getPluginResults() []commonbl.PluginResult {
+	results := []commonbl.PluginResult{}
+	settings := getRuntimeSettings()
+	if settings.PluginsDirectory != "" {
+		var errRun error
+		results, errRun = smbstatusdbl.NewPluginRunner(settings.PluginsDirectory, settings.PluginsTimeout).RunPlugins()
+		if errRun != nil {
+			logger.WriteErrorWithAddition(errRun, "while running the plugins")
+			results = []commonbl.PluginResult{}
+		}
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			logger.WriteErrorMessage(fmt.Sprintf("The plugin '%s' returned the following error: %s", result.Plugin, result.Error))
+		}
+	}
+
+	return results
+}
+
+// getCgroupData - Get the usage of the cgroups samba runs in. The generated or replayed samba status has no cgroups
+func getCgroupData() []commonbl.CgroupData {
+	if params.Test {
+		return commonbl.GetTestCgroupData()
+	}
+	if !usesSmbstatus(params) {
+		return []commonbl.CgroupData{}
+	}
+
+	data, errRead := smbstatusdbl.NewCgroupReader(getRuntimeSettings().CgroupPaths, PROCESS_TO_MONITOR).GetCgroupData()
+	if errRead != nil {
+		logger.WriteErrorWithAddition(errRead, "while reading the cgroups")
+		return []commonbl.CgroupData{}
+	}
+
+	return data
+}
+
+// getCtdbNodes - Get the nodes of the CTDB cluster. The generated or replayed samba status has no cluster
+func getCtdbNodes() []commonbl.CtdbNode {
+	if params.Test {
+		return commonbl.GetTestCtdbNodes()
+	}
+	if !usesSmbstatus(params) {
+		return []commonbl.CtdbNode{}
+	}
+
+	nodes, errList := smbstatusdbl.NewCtdbNodeLister(getRuntimeSettings().CtdbPath).GetCtdbNodes()
+	if errList != nil {
+		logger.WriteErrorWithAddition(errList, "while listing the cluster nodes")
+		return []commonbl.CtdbNode{}
+	}
+
+	return nodes
+}
+
+// getShareConfig - Get the shares configured in the smb.conf. The generated or replayed samba status has no smb.conf
+func getShareConfig() []commonbl.ShareConfig {
+	if params.Test {
+		return commonbl.GetTestShareConfig()
+	}
+	if !usesSmbstatus(params) {
+		return []commonbl.ShareConfig{}
+	}
+
+	shares, errRead := smbstatusdbl.NewTestparmReader(getRuntimeSettings().TestparmPath).GetShareConfig()
+	if errRead != nil {
+		logger.WriteErrorWithAddition(errRead, "while reading the configured shares")
+		return []commonbl.ShareConfig{}
+	}
+
+	return shares
+}
+
+// getShareHandles - Get the open, durable and persistent file handles of each share. Older smbstatus versions can not print
+// the handles as JSON, then the error is logged and an empty list is returned. The generated or replayed samba status has no handles
+func getShareHandles() []commonbl.ShareHandles {
+	if params.Test {
+		return commonbl.GetTestShareHandles()
+	}
+	if !usesSmbstatus(params) {
+		return []commonbl.ShareHandles{}
+	}
+
+	data, errRun := runSmbstatus(smbstatusdbl.SMBSTATUS_HANDLES_ARGUMENTS...)
+	if errRun != nil {
+		logger.WriteErrorWithAddition(errRun, fmt.Sprintf("while reading the file handles with \"%s %s\"", getRuntimeSettings().SmbstatusPath,
+			strings.Join(smbstatusdbl.SMBSTATUS_HANDLES_ARGUMENTS, " ")))
+		return []commonbl.ShareHandles{}
+	}
+	handles, errParse := smbstatusdbl.ParseSmbstatusHandles(data)
+	if errParse != nil {
+		logger.WriteErrorWithAddition(errParse, "while parsing the file handles")
+		return []commonbl.ShareHandles{}
+	}
+
+	return handles
+}
+
+// versionResponse - Tell samba_exporter the protocol version, so it can detect an incompatible samba_statusd
+func versionResponse(handler commonbl.MessageHandler, id int) error {
+	header := commonbl.GetResponseHeader(commonbl.VERSION_REQUEST, id)
+	response := commonbl.GetResponse(header, commonbl.GetVersionResponseData(version))
+
+	return handler.WritePipeString(response)
+}
+
+func testPsResponse(handler commonbl.MessageHandler, id int) error {
 	header := commonbl.GetResponseHeader(commonbl.PS_REQUEST, id)
 	response := commonbl.GetResponse(header, commonbl.TestPsResponse())
 
 	return handler.WritePipeString(response)
 }
 
-func testProcessResponse(handler *commonbl.PipeHandler, id int) error {
+func testProcessResponse(handler commonbl.MessageHandler, id int) error {
 	header := commonbl.GetTestResponseHeader(commonbl.PROCESS_REQUEST, id)
 	response := commonbl.GetResponse(header, commonbl.TestProcessResponse)
 
 	return handler.WritePipeString(response)
 }
 
-func testShareResponse(handler *commonbl.PipeHandler, id int) error {
+func testShareResponse(handler commonbl.MessageHandler, id int) error {
 	header := commonbl.GetTestResponseHeader(commonbl.SHARE_REQUEST, id)
 	response := commonbl.GetResponse(header, commonbl.TestShareResponse)
 
 	return handler.WritePipeString(response)
 }
 
-func testLockResponse(handler *commonbl.PipeHandler, id int) error {
+func testLockResponse(handler commonbl.MessageHandler, id int) error {
 	header := commonbl.GetTestResponseHeader(commonbl.LOCK_REQUEST, id)
 	response := commonbl.GetResponse(header, commonbl.TestLockResponse)
 
@@ -270,6 +638,7 @@ func waitforKillSignalAndExit() {
 	<-killSignal
 
 	logger.WriteInformation(fmt.Sprintf("End %s due to kill signal", os.Args[0]))
+	commonbl.SdNotify(commonbl.SD_NOTIFY_STOPPING)
 
 	os.Exit(0)
 }
@@ -280,6 +649,7 @@ func waitforTermSignalAndExit() {
 	<-termSignal
 
 	logger.WriteInformation(fmt.Sprintf("End %s due to terminate signal", os.Args[0]))
+	commonbl.SdNotify(commonbl.SD_NOTIFY_STOPPING)
 
 	os.Exit(0)
 }
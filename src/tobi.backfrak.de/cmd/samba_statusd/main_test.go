@@ -6,11 +6,17 @@ package main
 // LICENSE file.
 
 import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbstatusdbl"
 	"tobi.backfrak.de/internal/testhelper"
 )
 
@@ -95,8 +101,8 @@ func TestHandleRequest(t *testing.T) {
 	errNil := handleRequest(responseHandler,
 		commonbl.GetRequest(commonbl.LOCK_REQUEST, 12),
 		commonbl.LOCK_REQUEST,
-		func(ph *commonbl.PipeHandler, i int) error { return nil },
-		func(ph *commonbl.PipeHandler, i int) error { return nil },
+		func(ph commonbl.MessageHandler, i int) error { return nil },
+		func(ph commonbl.MessageHandler, i int) error { return nil },
 	)
 
 	if errNil != nil {
@@ -107,8 +113,8 @@ func TestHandleRequest(t *testing.T) {
 	errNil = handleRequest(responseHandler,
 		commonbl.GetRequest(commonbl.LOCK_REQUEST, 12),
 		commonbl.LOCK_REQUEST,
-		func(ph *commonbl.PipeHandler, i int) error { return nil },
-		func(ph *commonbl.PipeHandler, i int) error { return nil },
+		func(ph commonbl.MessageHandler, i int) error { return nil },
+		func(ph commonbl.MessageHandler, i int) error { return nil },
 	)
 
 	if errNil != nil {
@@ -119,8 +125,8 @@ func TestHandleRequest(t *testing.T) {
 	errHandle := handleRequest(responseHandler,
 		commonbl.GetRequest(commonbl.LOCK_REQUEST, 12),
 		commonbl.LOCK_REQUEST,
-		func(ph *commonbl.PipeHandler, i int) error { return nil },
-		func(ph *commonbl.PipeHandler, i int) error { return errRequest },
+		func(ph commonbl.MessageHandler, i int) error { return nil },
+		func(ph commonbl.MessageHandler, i int) error { return errRequest },
 	)
 
 	if errHandle != errRequest {
@@ -131,8 +137,8 @@ func TestHandleRequest(t *testing.T) {
 	errHandle = handleRequest(responseHandler,
 		commonbl.GetRequest(commonbl.LOCK_REQUEST, 12),
 		commonbl.LOCK_REQUEST,
-		func(ph *commonbl.PipeHandler, i int) error { return errRequest },
-		func(ph *commonbl.PipeHandler, i int) error { return nil },
+		func(ph commonbl.MessageHandler, i int) error { return errRequest },
+		func(ph commonbl.MessageHandler, i int) error { return nil },
 	)
 
 	if errHandle != errRequest {
@@ -168,14 +174,218 @@ func TestGoHandleRequestQueue(t *testing.T) {
 	requestQueue.Push(commonbl.GetRequest(commonbl.PS_REQUEST, 3))
 	goHandleRequestQueue(responseHandler)
 
+	requestQueue.Push(commonbl.GetRequest(commonbl.VERSION_REQUEST, 4))
+	goHandleRequestQueue(responseHandler)
+
 	requestQueue.Push(commonbl.GetRequest("NO_REQUEST", 3))
 	goHandleRequestQueue(responseHandler)
 
 	requestQueue.Push("")
 	goHandleRequestQueue(responseHandler)
 
-	if testLogger.GetOutputCount() != 5 {
-		t.Errorf("Got '%d' output messages but expected '5'", testLogger.GetOutputCount())
+	if testLogger.GetOutputCount() != 6 {
+		t.Errorf("Got '%d' output messages but expected '6'", testLogger.GetOutputCount())
+	}
+}
+
+// recordingHandler - A commonbl.MessageHandler that records the written responses
+type recordingHandler struct {
+	mutex     sync.Mutex
+	responses []string
+}
+
+func (handler *recordingHandler) WaitForPipeInputString() (string, error) {
+	return "", nil
+}
+
+func (handler *recordingHandler) WritePipeString(data string) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	handler.responses = append(handler.responses, data)
+
+	return nil
+}
+
+func (handler *recordingHandler) GetPipeFilePath() string {
+	return "recording"
+}
+
+func (handler *recordingHandler) getResponses() []string {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	return append([]string{}, handler.responses...)
+}
+
+func TestGoHandleRequestQueueConcurrent(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	responseHandler := &recordingHandler{}
+	requestQueue = *commonbl.NewStringQueue()
+	params.Test = true
+	logger = testhelper.NewTestLogger(true)
+
+	requestTypes := []commonbl.RequestType{commonbl.LOCK_REQUEST, commonbl.SHARE_REQUEST, commonbl.PROCESS_REQUEST, commonbl.PS_REQUEST}
+	requestCount := 20
+	for id := 0; id < requestCount; id++ {
+		requestQueue.Push(commonbl.GetRequest(requestTypes[id%len(requestTypes)], id))
+		go goHandleRequestQueue(responseHandler)
+	}
+
+	for i := 0; i < 100 && len(responseHandler.getResponses()) < requestCount; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	responses := responseHandler.getResponses()
+	if len(responses) != requestCount {
+		t.Fatalf("Got '%d' responses but expected '%d'", len(responses), requestCount)
+	}
+
+	for id, response := range responses {
+		header, _, errSplit := commonbl.SplitResponse(response)
+		if errSplit != nil {
+			t.Fatalf("Got error '%s' but expected none", errSplit.Error())
+		}
+		if !commonbl.CheckResponseHeader(header, requestTypes[id%len(requestTypes)], id) {
+			t.Errorf("The response '%s' is not the response to the request with ID %d", header, id)
+		}
+	}
+}
+
+func TestVersionResponse(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.Test = true
+	params.TcpListenAddress = "127.0.0.1:0"
+	logger = testhelper.NewTestLogger(true)
+
+	listener, errListen := listenTcp()
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer serveTestTcp(listener)()
+
+	client := commonbl.NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+	errWrite := client.WritePipeString(commonbl.GetRequest(commonbl.VERSION_REQUEST, 7))
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+	response, errRead := client.WaitForPipeInputString()
+	if errRead != nil {
+		t.Fatalf("Got error '%s' but expected none", errRead.Error())
+	}
+
+	header, data, errSplit := commonbl.SplitResponse(response)
+	if errSplit != nil {
+		t.Fatalf("Got error '%s' but expected none", errSplit.Error())
+	}
+	if !commonbl.CheckResponseHeader(header, commonbl.VERSION_REQUEST, 7) {
+		t.Errorf("The header '%s' is not the header of the expected response", header)
+	}
+
+	protocolVersion, programVersion, errParse := commonbl.ParseVersionResponseData(data)
+	if errParse != nil {
+		t.Fatalf("Got error '%s' but expected none", errParse.Error())
+	}
+	if protocolVersion != commonbl.PROTOCOL_VERSION {
+		t.Errorf("Got protocol version %d but expected %d", protocolVersion, commonbl.PROTOCOL_VERSION)
+	}
+	if programVersion != version {
+		t.Errorf("Got program version '%s' but expected '%s'", programVersion, version)
+	}
+}
+
+func TestJsonListResponses(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	oldSettings := getRuntimeSettings()
+	defer setRuntimeSettings(oldSettings)
+	params.Test = true
+	params.TcpListenAddress = "127.0.0.1:0"
+	testLogger := testhelper.NewTestLogger(true)
+	logger = testLogger
+
+	directory := t.TempDir()
+	os.WriteFile(filepath.Join(directory, "quota"), []byte("#!/bin/sh\necho '[{\"name\": \"quota_used_bytes\", \"help\": \"Bytes used\", \"value\": 42}]'\n"), 0755)
+	os.WriteFile(filepath.Join(directory, "failing"), []byte("#!/bin/sh\nexit 1\n"), 0755)
+	setRuntimeSettings(runtimeSettings{PluginsDirectory: directory, PluginsTimeout: 5 * time.Second})
+
+	listener, errListen := listenTcp()
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer serveTestTcp(listener)()
+
+	client := commonbl.NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+
+	tests := []struct {
+		requestType commonbl.RequestType
+		data        interface{}
+		check       func(data interface{}) bool
+	}{
+		{commonbl.PLUGIN_REQUEST, &[]commonbl.PluginResult{}, func(data interface{}) bool {
+			results := *data.(*[]commonbl.PluginResult)
+			return len(results) == 2 && results[0].Plugin == "failing" && results[0].Error != "" && results[1].Plugin == "quota" && len(results[1].Metrics) == 1
+		}},
+		{commonbl.CGROUP_REQUEST, &[]commonbl.CgroupData{}, func(data interface{}) bool {
+			cgroups := *data.(*[]commonbl.CgroupData)
+			return len(cgroups) == 1 && cgroups[0] == commonbl.GetTestCgroupData()[0]
+		}},
+		{commonbl.CTDB_NODES_REQUEST, &[]commonbl.CtdbNode{}, func(data interface{}) bool {
+			nodes := *data.(*[]commonbl.CtdbNode)
+			expected := commonbl.GetTestCtdbNodes()
+			return len(nodes) == len(expected) && nodes[0] == expected[0] && nodes[1] == expected[1]
+		}},
+		{commonbl.SHARE_CONFIG_REQUEST, &[]commonbl.ShareConfig{}, func(data interface{}) bool {
+			shares := *data.(*[]commonbl.ShareConfig)
+			return len(shares) == 2 && shares[1].Name == "data" && shares[1].Parameters["path"] == "/srv/data"
+		}},
+		{commonbl.SHARE_HANDLES_REQUEST, &[]commonbl.ShareHandles{}, func(data interface{}) bool {
+			handles := *data.(*[]commonbl.ShareHandles)
+			return len(handles) == 1 && handles[0].Share == "/usr/share/data" && handles[0].DurableCount == 2
+		}},
+	}
+
+	for id, test := range tests {
+		errWrite := client.WritePipeString(commonbl.GetRequest(test.requestType, id))
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		response, errRead := client.WaitForPipeInputString()
+		if errRead != nil {
+			t.Fatalf("Got error '%s' but expected none", errRead.Error())
+		}
+
+		header, data, errSplit := commonbl.SplitResponse(response)
+		if errSplit != nil {
+			t.Fatalf("Got error '%s' but expected none", errSplit.Error())
+		}
+		if !commonbl.CheckResponseHeader(header, test.requestType, id) {
+			t.Errorf("The header '%s' is not the header of the expected response", header)
+		}
+
+		errConv := json.Unmarshal([]byte(data), test.data)
+		if errConv != nil {
+			t.Fatalf("Got error '%s' but expected none", errConv.Error())
+		}
+		if !test.check(test.data) {
+			t.Errorf("The \"%s\" response data '%s' is not expected", test.requestType, data)
+		}
+	}
+
+	if testLogger.GetErrorCount() != 1 {
+		t.Errorf("Got '%d' errors but expected '1' for the failing plugin", testLogger.GetErrorCount())
 	}
 }
 
@@ -211,3 +421,116 @@ func TestMainWithPrintVersion(t *testing.T) {
 	}
 
 }
+
+func TestMainWithInvalidPipeMode(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.Test = true
+	params.PipeMode = "rw-rw----"
+
+	res := realMain()
+	if res != -12 {
+		t.Errorf("Got %d from main, but expected -12", res)
+	}
+}
+
+func TestMainWithTestAndDemoMode(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.Test = true
+	params.Demo = true
+
+	res := realMain()
+	if res != -13 {
+		t.Errorf("Got %d from main, but expected -13", res)
+	}
+}
+
+func TestDemoModeResponses(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() {
+		params = oldParmas
+		demoDataGenerator = nil
+		psDataGenerator = nil
+	}()
+	logger = testhelper.NewTestLogger(true)
+	demoDataGenerator = smbstatusdbl.NewDemoDataGenerator(1)
+	psDataGenerator = demoDataGenerator
+
+	for requestType, expected := range map[commonbl.RequestType]string{
+		commonbl.LOCK_REQUEST:    "Locked files:",
+		commonbl.SHARE_REQUEST:   "Service",
+		commonbl.PROCESS_REQUEST: "Samba version",
+	} {
+		output, errGet := getSmbstatusOutput(requestType)
+		if errGet != nil {
+			t.Fatalf("Got error '%s' but expected none", errGet.Error())
+		}
+		// The lock table is empty, when the demo server has no locked files
+		if !strings.HasPrefix(strings.TrimSpace(output), expected) && !strings.HasPrefix(strings.TrimSpace(output), commonbl.NO_LOCKED_FILES) {
+			t.Errorf("The output '%s' for '%s' does not start with '%s'", output, requestType, expected)
+		}
+	}
+
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	errPs := psResponse(responseHandler, 5)
+	if errPs != nil {
+		t.Errorf("Got error '%s' but expected none", errPs.Error())
+	}
+}
+
+// failingPsDataSource - A psDataSource that can not read the ps data
+type failingPsDataSource struct{}
+
+func (source *failingPsDataSource) GetPsUtilPidData() ([]commonbl.PsUtilPidData, error) {
+	return nil, errors.New("permission denied")
+}
+
+func TestPsResponseError(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	defer func() { psDataGenerator = nil }()
+	testLogger := testhelper.NewTestLogger(true)
+	logger = testLogger
+	psDataGenerator = &failingPsDataSource{}
+
+	responseHandler := &recordingHandler{}
+	errPs := psResponse(responseHandler, 5)
+	if errPs != nil {
+		t.Fatalf("Got error '%s' but expected none", errPs.Error())
+	}
+
+	responses := responseHandler.getResponses()
+	if len(responses) != 1 {
+		t.Fatalf("Got '%d' responses but expected '1'", len(responses))
+	}
+	header, data, errSplit := commonbl.SplitResponse(responses[0])
+	if errSplit != nil {
+		t.Fatalf("Got error '%s' but expected none", errSplit.Error())
+	}
+	if !commonbl.CheckResponseHeader(header, commonbl.PS_REQUEST, 5) {
+		t.Errorf("The header '%s' is not the header of the expected response", header)
+	}
+	message, isError := commonbl.ParseErrorResponseData(data)
+	if !isError {
+		t.Fatalf("The data '%s' is no error response", data)
+	}
+	if !strings.HasSuffix(message, "permission denied") {
+		t.Errorf("The error message '%s' does not end with 'permission denied'", message)
+	}
+	if testLogger.GetErrorCount() != 1 {
+		t.Errorf("Got '%d' errors but expected '1'", testLogger.GetErrorCount())
+	}
+}
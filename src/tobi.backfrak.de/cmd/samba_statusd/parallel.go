@@ -0,0 +1,68 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// runSmbstatusParallel - Get the tables for the request types by calling smbstatus with the arguments of each request type.
+// At most workers calls of run are running at the same time
+func runSmbstatusParallel(requestTypes []commonbl.RequestType, workers int, run func(arguments ...string) ([]byte, error)) (map[commonbl.RequestType]string, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	tables := map[commonbl.RequestType]string{}
+	var errRet error
+	var mutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	pool := make(chan struct{}, workers)
+
+	for _, requestType := range requestTypes {
+		waitGroup.Add(1)
+		go func(requestType commonbl.RequestType) {
+			defer waitGroup.Done()
+			pool <- struct{}{}
+			defer func() { <-pool }()
+
+			arguments := smbstatusArguments[requestType]
+			data, err := run(arguments...)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				if errRet == nil {
+					errRet = fmt.Errorf("\"smbstatus %s\" failed: %s", strings.Join(arguments, " "), err)
+				}
+				return
+			}
+			tables[requestType] = string(data)
+		}(requestType)
+	}
+	waitGroup.Wait()
+
+	if errRet != nil {
+		return nil, errRet
+	}
+
+	return tables, nil
+}
+
+// getEnabledSmbstatusRequests - Get the request types answered with the output of smbstatus, that are not disabled
+func (settings runtimeSettings) getEnabledSmbstatusRequests() []commonbl.RequestType {
+	var ret []commonbl.RequestType
+	for _, requestType := range []commonbl.RequestType{commonbl.LOCK_REQUEST, commonbl.SHARE_REQUEST, commonbl.PROCESS_REQUEST} {
+		if !settings.isRequestDisabled(requestType) {
+			ret = append(ret, requestType)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,80 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestRunSmbstatusParallel(t *testing.T) {
+	requestTypes := []commonbl.RequestType{commonbl.LOCK_REQUEST, commonbl.SHARE_REQUEST, commonbl.PROCESS_REQUEST}
+	for _, workers := range []int{0, 1, 2, 3} {
+		var mutex sync.Mutex
+		running := 0
+		maxRunning := 0
+		run := func(arguments ...string) ([]byte, error) {
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+
+			return []byte(strings.Join(arguments, " ")), nil
+		}
+
+		tables, err := runSmbstatusParallel(requestTypes, workers, run)
+		if err != nil {
+			t.Fatalf("Got error '%s' but expected none", err.Error())
+		}
+
+		for _, requestType := range requestTypes {
+			if tables[requestType] != strings.Join(smbstatusArguments[requestType], " ") {
+				t.Errorf("Got the table '%s' for '%s' but expected '%s'", tables[requestType], requestType, strings.Join(smbstatusArguments[requestType], " "))
+			}
+		}
+
+		expectedMax := workers
+		if expectedMax < 1 {
+			expectedMax = 1
+		}
+		if maxRunning > expectedMax {
+			t.Errorf("Got '%d' calls running at the same time with %d workers", maxRunning, workers)
+		}
+	}
+}
+
+func TestRunSmbstatusParallelError(t *testing.T) {
+	requestTypes := []commonbl.RequestType{commonbl.LOCK_REQUEST, commonbl.SHARE_REQUEST, commonbl.PROCESS_REQUEST}
+	run := func(arguments ...string) ([]byte, error) {
+		if arguments[0] == "-S" {
+			return nil, fmt.Errorf("smbstatus failed")
+		}
+		return []byte("data"), nil
+	}
+
+	_, err := runSmbstatusParallel(requestTypes, 3, run)
+	if err == nil {
+		t.Errorf("Got no error but expected one")
+	}
+}
+
+func TestGetEnabledSmbstatusRequests(t *testing.T) {
+	requestTypes := runtimeSettings{DisabledCollectors: []string{"shares", "psdata"}}.getEnabledSmbstatusRequests()
+	if len(requestTypes) != 2 || requestTypes[0] != commonbl.LOCK_REQUEST || requestTypes[1] != commonbl.PROCESS_REQUEST {
+		t.Errorf("Got the request types '%v' but expected the lock and process requests", requestTypes)
+	}
+}
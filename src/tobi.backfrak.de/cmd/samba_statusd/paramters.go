@@ -9,13 +9,60 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/configfile"
+	"tobi.backfrak.de/internal/smbstatusdbl"
 )
 
 // The paramters for this executable
 type parmeters struct {
 	commonbl.Parmeters
+	runtimeParmeters
+	Demo               bool
+	ReplayDirectory    string
+	ServiceConfigFile  string
+	ConfigFile         string
+	TcpListenAddress   string
+	GrpcListenAddress  string
+	HttpListenAddress  string
+	TcpTLSCertFile     string
+	TcpTLSKeyFile      string
+	TcpTLSClientCAFile string
+	AuthSecretFile     string
+	DbusBus            string
+	PipeDirectory      string
+	PipeMode           string
+	PipeOwner          string
+	PipeGroup          string
+	RunAsUser          string
+	RunAsGroup         string
+	Sandbox            bool
+}
+
+// The paramters that can be changed at runtime by sending SIGHUP to the process
+type runtimeParmeters struct {
+	SmbstatusPath        string
+	DisabledCollectors   string
+	SmbstatusLocale      string
+	SmbstatusTimezone    string
+	SmbstatusEnv         string
+	SmbstatusWorkDir     string
+	SmbstatusMinInterval time.Duration
+	SmbstatusSingleCall  bool
+	SmbstatusWorkers     int
+	PluginsDirectory     string
+	PluginsTimeout       time.Duration
+	CgroupPaths          string
+	CtdbPath             string
+	TestparmPath         string
+	// The command smbstatus is called with, so samba_statusd can run as regular user
+	SmbstatusCommandPrefix string
+	// The container smbstatus runs in, so samba in a container can be monitored from the host
+	SmbstatusContainer        string
+	SmbstatusContainerRuntime string
 }
 
 var params parmeters
@@ -24,13 +71,7 @@ var params parmeters
 func handleComandlineOptions() {
 
 	// Setup the usabel parametes
-	flag.BoolVar(&params.PrintVersion, "print-version", false, "With this flag the program will only print it's version and exit")
-	flag.BoolVar(&params.Verbose, "verbose", false, "With this flag the program will print verbose output")
-	flag.BoolVar(&params.Test, "test-mode", false,
-		"Run the program in test mode. In this mode the program will always return the same test data. To work with samba_exporter both programs needs to run in test mode or not.")
-	flag.BoolVar(&params.Help, "help", false, "Print this help message")
-	flag.StringVar(&params.LogFilePath, "log-file-path", " ",
-		"Give the full file path for a log file. When parameter is not set (as by default), logs will be written to stdout and stderr")
+	defineFlags(flag.CommandLine, &params)
 
 	// Overwrite the std Usage function with some custom stuff
 	flag.Usage = customHelpMessage
@@ -39,6 +80,107 @@ func handleComandlineOptions() {
 	flag.Parse()
 }
 
+// defineFlags - Setup the usable parameters of this executable on the given flag set
+func defineFlags(flagSet *flag.FlagSet, parameters *parmeters) {
+	flagSet.BoolVar(&parameters.PrintVersion, "print-version", false, "With this flag the program will only print it's version and exit")
+	flagSet.BoolVar(&parameters.Verbose, "verbose", false, "With this flag the program will print verbose output. Send SIGUSR2 to switch the verbose output on or off while running")
+	flagSet.BoolVar(&parameters.Test, "test-mode", false,
+		"Run the program in test mode. In this mode the program will always return the same test data. To work with samba_exporter both programs needs to run in test mode or not.")
+	flagSet.BoolVar(&parameters.Demo, "demo", false,
+		"Run the program in demo mode. In this mode the program answers with generated sessions, shares and locks of a samba server that does not exist, to develop dashboards and alerts. Does not need root or smbstatus")
+	flagSet.StringVar(&parameters.ReplayDirectory, "replay.directory", "",
+		"Directory with saved outputs of smbstatus the requests are answered with, in the files 'locks.txt', 'shares.txt', 'processes.txt' and 'psdata.json'. The files are read for each request. Does not need root or smbstatus")
+	flagSet.BoolVar(&parameters.Help, "help", false, "Print this help message")
+	flagSet.StringVar(&parameters.LogFilePath, "log-file-path", " ",
+		"Give the full file path for a log file. When parameter is not set (as by default), logs will be written to stdout and stderr")
+	flagSet.StringVar(&parameters.ConfigFile, "config.file", "",
+		"Path to a YAML configuration file. The keys of the file are the names of the parameters. Parameters given on the command line override the values of the file. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.ServiceConfigFile, "service-config-file", "/etc/default/samba_statusd",
+		"The service configuration file. When receiving SIGHUP, the runtime settings are reloaded from the 'ARGS' in this file")
+	flagSet.StringVar(&parameters.SmbstatusPath, "smbstatus-path", "",
+		"Path to the smbstatus executable. When not set, smbstatus is searched in the PATH. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.SmbstatusLocale, "smbstatus.locale", "C",
+		"The locale smbstatus runs with, so the time stamps have the format samba_exporter expects. When empty, the locale of samba_statusd is used. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.SmbstatusTimezone, "smbstatus.timezone", "",
+		"The timezone smbstatus runs with, e. g. 'UTC'. When not set, the timezone of samba_statusd is used. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.SmbstatusContainer, "smbstatus.container", "",
+		"Name or ID of the container smbstatus runs in, to monitor a samba server in a container. The PID of a process in the container for the nsenter runtime. When not set, smbstatus runs on the host. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.SmbstatusContainerRuntime, "smbstatus.container-runtime", CONTAINER_RUNTIME_DOCKER,
+		fmt.Sprintf("The command smbstatus is run in the -smbstatus.container with. Possible values: %s. Reloaded on SIGHUP", strings.Join(getContainerRuntimes(), ", ")))
+	flagSet.StringVar(&parameters.SmbstatusEnv, "smbstatus.env", "",
+		"Comma separated list of NAME=VALUE environment variables smbstatus runs with, e. g. 'SMB_CONF_PATH=/etc/samba/smb-2.conf'. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.SmbstatusCommandPrefix, "smbstatus.command-prefix", "",
+		"Comma separated command smbstatus is called with, like 'sudo,-n' or 'doas,-n', so samba_statusd can run as regular user and only smbstatus runs as root. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.SmbstatusWorkDir, "smbstatus.working-directory", "",
+		"The working directory smbstatus runs in. When not set, the working directory of samba_statusd is used. Reloaded on SIGHUP")
+	flagSet.DurationVar(&parameters.SmbstatusMinInterval, "smbstatus.min-interval", 0,
+		"The minimum time between two smbstatus calls, e. g. '5s'. Requests received faster are answered with the output of the last call. When 0, smbstatus is called for each request. Reloaded on SIGHUP")
+	flagSet.BoolVar(&parameters.SmbstatusSingleCall, "smbstatus.single-call", false,
+		"Call smbstatus only once per collection cycle and split its output into the lock, share and process tables, instead of calling it for each table. Reloaded on SIGHUP")
+	flagSet.IntVar(&parameters.SmbstatusWorkers, "smbstatus.workers", 1,
+		"The maximum number of smbstatus calls running at the same time. When greater 1, the lock, share and process tables of a collection cycle are collected in parallel. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.PluginsDirectory, "plugins.directory", "",
+		"Directory with executable plugins. Each plugin prints a JSON array of metrics samba_exporter exports in addition. When not set, no plugins run. Reloaded on SIGHUP")
+	flagSet.DurationVar(&parameters.PluginsTimeout, "plugins.timeout", 3*time.Second,
+		"The maximum time a plugin may run, before it is killed and reported as failed. Keep it below the -request-timeout of samba_exporter. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.CgroupPaths, "cgroup.paths", "",
+		"Comma separated list of cgroups below '"+smbstatusdbl.CGROUP_ROOT+"' the cpu, memory and io usage is reported for, e. g. 'system.slice/smbd.service,system.slice/nmbd.service' or the slice samba runs in. When not set, the cgroups of the smbd processes are used. Needs the cgroup v2 hierarchy. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.CtdbPath, "ctdb.path", "ctdb",
+		"Path of the ctdb executable, called as 'ctdb -X listnodes' when samba_exporter asks for the addresses of the cluster nodes. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.TestparmPath, "testparm.path", "testparm",
+		"Path of the testparm executable, called as 'testparm -s' when samba_exporter asks for the shares configured in the smb.conf. Reloaded on SIGHUP")
+	flagSet.StringVar(&parameters.DisabledCollectors, "disabled-collectors", "",
+		fmt.Sprintf("Comma separated list of collectors that should not run. Possible values: %s. Reloaded on SIGHUP", strings.Join(getCollectorNames(), ", ")))
+	flagSet.StringVar(&parameters.TcpListenAddress, "tcp.listen-address", "",
+		"Address to listen on for requests of samba_exporter, e. g. ':9923'. When set, the named pipes are not used")
+	flagSet.StringVar(&parameters.GrpcListenAddress, "grpc.listen-address", "",
+		"Address to listen on for gRPC requests of samba_exporter, e. g. ':9924'. When set, the named pipes are not used. Can not be combined with -tcp.listen-address")
+	flagSet.StringVar(&parameters.HttpListenAddress, "http.listen-address", "",
+		"Address to listen on for requests to the HTTP JSON API, e. g. ':9925'. The API answers with the parsed locks, shares and processes, in addition to the named pipes, -tcp.listen-address or -grpc.listen-address")
+	flagSet.StringVar(&parameters.TcpTLSCertFile, "tcp.tls.cert-file", "",
+		"Path to the PEM encoded certificate used for TLS on the -tcp.listen-address, -grpc.listen-address or -http.listen-address. When not set, no TLS is used")
+	flagSet.StringVar(&parameters.TcpTLSKeyFile, "tcp.tls.key-file", "", "Path to the PEM encoded private key of the -tcp.tls.cert-file")
+	flagSet.StringVar(&parameters.TcpTLSClientCAFile, "tcp.tls.client-ca-file", "",
+		"Path to the PEM encoded CA certificates. When set, only clients with a certificate signed by one of this CAs can connect")
+	flagSet.StringVar(&parameters.DbusBus, "dbus.bus", "",
+		"The D-Bus the samba status is exported on in addition, as '"+DBUS_NAME+"', so local tools can query it. Possible values: system, session. When not set, the D-Bus is not used")
+	flagSet.StringVar(&parameters.PipeDirectory, "pipe.directory", "",
+		"The directory of the named pipes. When not set, '/run' is used, or '/dev/shm' in test mode. samba_exporter needs the same directory")
+	flagSet.StringVar(&parameters.PipeMode, "pipe.mode", "0660", "The octal file mode of the named pipes")
+	flagSet.StringVar(&parameters.PipeOwner, "pipe.owner", "", "User name or ID the named pipes belong to. When not set, the owner is not changed")
+	flagSet.StringVar(&parameters.PipeGroup, "pipe.group", "", "Group name or ID the named pipes belong to. When not set, the group is not changed")
+	flagSet.StringVar(&parameters.RunAsUser, "run-as.user", "",
+		"Name of the user samba_statusd switches to, once the named pipes or sockets are opened. smbstatus and the plugins run as this user then. When not set, samba_statusd keeps running as root")
+	flagSet.StringVar(&parameters.RunAsGroup, "run-as.group", "", "Name of the group samba_statusd switches to with the -run-as.user. When not set, the primary group of the user is used")
+	flagSet.BoolVar(&parameters.Sandbox, "sandbox", false,
+		"Deny the syscalls administrating the system, like mount, ptrace or module loading, to samba_statusd, smbstatus and the plugins, once the named pipes or sockets are opened")
+	flagSet.StringVar(&parameters.AuthSecretFile, "auth.secret-file", "",
+		"Path to a file with a shared secret. When set, only requests signed with this secret are answered and the responses are signed. samba_exporter needs the same secret")
+}
+
+// usesSmbstatus - Tells if the requests are answered with the output of smbstatus, and not with test, demo or replayed data
+func usesSmbstatus(parameters parmeters) bool {
+	return !parameters.Test && !parameters.Demo && parameters.ReplayDirectory == ""
+}
+
+// getSignedHandler - Get the handler signing the messages with the secret of the -auth.secret-file, or the handler itself when no secret is given
+func getSignedHandler(handler commonbl.MessageHandler) commonbl.MessageHandler {
+	if authSecret == nil {
+		return handler
+	}
+
+	return commonbl.NewSigningHandler(handler, authSecret, logger)
+}
+
+// applyConfigFile - Use the settings of the configuration file given in the parameters for all parameters not set on the flagSet
+func applyConfigFile(flagSet *flag.FlagSet, parameters *parmeters) error {
+	if parameters.ConfigFile == "" {
+		return nil
+	}
+
+	return configfile.ApplyConfigFile(parameters.ConfigFile, flagSet)
+}
+
 // customHelpMessage - Print he customized help message
 func customHelpMessage() {
 	fmt.Fprintln(os.Stdout, fmt.Sprintf("%s: Wrapper for smbstatus. Collects data used by the samba_exporter service.", os.Args[0]))
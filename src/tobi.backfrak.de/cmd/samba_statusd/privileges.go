@@ -0,0 +1,101 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// runAsIds - The IDs of the -run-as.user and -run-as.group samba_statusd switches to after the initialization
+type runAsIds struct {
+	userName string
+	uid      int
+	gid      int
+	// The IDs of all groups the user is member of, so group permissions of the user are kept
+	groups []int
+}
+
+// lookupRunAs - Get the IDs of the user and group, nil when no user is given. When no group is given, the primary group of the user is used
+func lookupRunAs(userName string, groupName string) (*runAsIds, error) {
+	if userName == "" {
+		if groupName != "" {
+			return nil, fmt.Errorf("The parameter -run-as.group needs the -run-as.user")
+		}
+		return nil, nil
+	}
+
+	found, errUser := user.Lookup(userName)
+	if errUser != nil {
+		return nil, errUser
+	}
+	ids := runAsIds{userName: found.Username}
+	ids.uid, _ = strconv.Atoi(found.Uid)
+	ids.gid, _ = strconv.Atoi(found.Gid)
+
+	if groupName != "" {
+		group, errGroup := user.LookupGroup(groupName)
+		if errGroup != nil {
+			return nil, errGroup
+		}
+		ids.gid, _ = strconv.Atoi(group.Gid)
+	}
+
+	groupIds, errGroups := found.GroupIds()
+	if errGroups != nil {
+		return nil, errGroups
+	}
+	ids.groups = []int{ids.gid}
+	for _, groupId := range groupIds {
+		gid, errConv := strconv.Atoi(groupId)
+		if errConv == nil && gid != ids.gid {
+			ids.groups = append(ids.groups, gid)
+		}
+	}
+
+	return &ids, nil
+}
+
+// dropPrivileges - Switch the process to the user and groups of the ids, so a long running samba_statusd does not keep the privileges of root.
+// Called after the pipes and sockets are opened. Does nothing when ids is nil
+func dropPrivileges(ids *runAsIds) error {
+	if ids == nil {
+		return nil
+	}
+
+	// The groups need to be changed first, since the user is not allowed to change them later
+	errGroups := syscall.Setgroups(ids.groups)
+	if errGroups != nil {
+		return fmt.Errorf("Can not set the groups of the -run-as.user: %s", errGroups.Error())
+	}
+	errGid := syscall.Setgid(ids.gid)
+	if errGid != nil {
+		return fmt.Errorf("Can not switch to the group %d: %s", ids.gid, errGid.Error())
+	}
+	errUid := syscall.Setuid(ids.uid)
+	if errUid != nil {
+		return fmt.Errorf("Can not switch to the user %s: %s", ids.userName, errUid.Error())
+	}
+
+	logger.WriteInformation(fmt.Sprintf("Dropped the privileges, running as user '%s' (uid %d, gid %d)", ids.userName, ids.uid, ids.gid))
+
+	return nil
+}
+
+// restrictProcess - Drop the privileges to the -run-as.user and apply the -sandbox, when they are given
+func restrictProcess(runAs *runAsIds) error {
+	errDrop := dropPrivileges(runAs)
+	if errDrop != nil {
+		return errDrop
+	}
+	if !params.Sandbox {
+		return nil
+	}
+
+	return applySandbox()
+}
@@ -0,0 +1,58 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+)
+
+func TestLookupRunAs(t *testing.T) {
+	ids, err := lookupRunAs("", "")
+	if err != nil || ids != nil {
+		t.Errorf("Got the ids '%v' and error '%v', but expected none", ids, err)
+	}
+
+	ids, err = lookupRunAs("root", "")
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if ids.uid != 0 || ids.gid != 0 || ids.userName != "root" {
+		t.Errorf("Got the uid '%d' and gid '%d' of '%s', but expected the ones of root", ids.uid, ids.gid, ids.userName)
+	}
+	if len(ids.groups) == 0 || ids.groups[0] != ids.gid {
+		t.Errorf("Got the groups '%v', but expected the primary group first", ids.groups)
+	}
+
+	ids, err = lookupRunAs("root", "root")
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if ids.gid != 0 {
+		t.Errorf("Got the gid '%d', but expected the one of the group root", ids.gid)
+	}
+
+	_, err = lookupRunAs("", "root")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the -run-as.group is given without -run-as.user")
+	}
+
+	_, err = lookupRunAs("samba-statusd-not-existing", "")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the user does not exist")
+	}
+
+	_, err = lookupRunAs("root", "samba-statusd-not-existing")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the group does not exist")
+	}
+}
+
+func TestDropPrivilegesNoUser(t *testing.T) {
+	err := dropPrivileges(nil)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The collectors samba_statusd runs to answer the requests of samba_exporter
+var collectorRequests = map[string]commonbl.RequestType{
+	"locks":     commonbl.LOCK_REQUEST,
+	"shares":    commonbl.SHARE_REQUEST,
+	"processes": commonbl.PROCESS_REQUEST,
+	"psdata":    commonbl.PS_REQUEST,
+	"plugins":   commonbl.PLUGIN_REQUEST,
+	"cgroup":    commonbl.CGROUP_REQUEST,
+	"ctdb":      commonbl.CTDB_NODES_REQUEST,
+	"config":    commonbl.SHARE_CONFIG_REQUEST,
+	"handles":   commonbl.SHARE_HANDLES_REQUEST,
+}
+
+// The settings samba_statusd uses while running. They are reloaded when SIGHUP is received
+type runtimeSettings struct {
+	SmbstatusPath        string
+	DisabledCollectors   []string
+	SmbstatusLocale      string
+	SmbstatusTimezone    string
+	SmbstatusEnv         []string
+	SmbstatusWorkDir     string
+	SmbstatusMinInterval time.Duration
+	SmbstatusSingleCall  bool
+	SmbstatusWorkers     int
+	PluginsDirectory     string
+	PluginsTimeout       time.Duration
+	// The paths of the cgroups below /sys/fs/cgroup the usage is reported for. Empty when the cgroups of smbd are used
+	CgroupPaths []string
+	// The path of the ctdb executable the nodes of the cluster are listed with
+	CtdbPath string
+	// The path of the testparm executable the shares of the smb.conf are read with
+	TestparmPath string
+	// The command and its arguments smbstatus is called with, like 'sudo -n'. Empty when smbstatus is called directly
+	SmbstatusPrefix []string
+	// The container smbstatus runs in. nil when smbstatus runs on the host
+	SmbstatusContainer *smbstatusContainer
+}
+
+var currentSettings runtimeSettings
+var settingsMutex sync.RWMutex
+
+// getCollectorNames - Get the names of the collectors samba_statusd can run
+func getCollectorNames() []string {
+	return []string{"locks", "shares", "processes", "psdata", "plugins", "cgroup", "ctdb", "config", "handles"}
+}
+
+// getRuntimeSettings - Get the runtime settings currently used
+func getRuntimeSettings() runtimeSettings {
+	settingsMutex.RLock()
+	defer settingsMutex.RUnlock()
+
+	return currentSettings
+}
+
+// setRuntimeSettings - Set the runtime settings to use from now on
+func setRuntimeSettings(settings runtimeSettings) {
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+
+	currentSettings = settings
+}
+
+// newRuntimeSettings - Validate the given parameters and convert them to runtimeSettings.
+// The smbstatus executable is only searched when smbstatus is used
+func newRuntimeSettings(runtimeParams runtimeParmeters, withoutSmbstatus bool) (runtimeSettings, error) {
+	var ret runtimeSettings
+
+	for _, collector := range strings.Split(runtimeParams.DisabledCollectors, ",") {
+		collector = strings.TrimSpace(collector)
+		if collector == "" {
+			continue
+		}
+		if _, found := collectorRequests[collector]; !found {
+			return ret, fmt.Errorf("The collector '%s' is unknown. Possible values: %s", collector, strings.Join(getCollectorNames(), ", "))
+		}
+		ret.DisabledCollectors = append(ret.DisabledCollectors, collector)
+	}
+
+	ret.SmbstatusLocale = strings.TrimSpace(runtimeParams.SmbstatusLocale)
+	ret.SmbstatusTimezone = strings.TrimSpace(runtimeParams.SmbstatusTimezone)
+	if ret.SmbstatusTimezone != "" {
+		if _, errTimezone := time.LoadLocation(ret.SmbstatusTimezone); errTimezone != nil {
+			return ret, fmt.Errorf("The timezone '%s' is unknown: %s", ret.SmbstatusTimezone, errTimezone)
+		}
+	}
+
+	for _, variable := range strings.Split(runtimeParams.SmbstatusEnv, ",") {
+		variable = strings.TrimSpace(variable)
+		if variable == "" {
+			continue
+		}
+		name, _, found := strings.Cut(variable, "=")
+		if !found || strings.TrimSpace(name) == "" {
+			return ret, fmt.Errorf("The environment variable '%s' is not in the format NAME=VALUE", variable)
+		}
+		ret.SmbstatusEnv = append(ret.SmbstatusEnv, variable)
+	}
+
+	if runtimeParams.SmbstatusMinInterval < 0 {
+		return ret, fmt.Errorf("The -smbstatus.min-interval '%s' is negative", runtimeParams.SmbstatusMinInterval)
+	}
+	ret.SmbstatusMinInterval = runtimeParams.SmbstatusMinInterval
+	ret.SmbstatusSingleCall = runtimeParams.SmbstatusSingleCall
+	if runtimeParams.SmbstatusWorkers < 0 {
+		return ret, fmt.Errorf("The -smbstatus.workers '%d' is negative", runtimeParams.SmbstatusWorkers)
+	}
+	ret.SmbstatusWorkers = runtimeParams.SmbstatusWorkers
+	if ret.SmbstatusWorkers == 0 {
+		ret.SmbstatusWorkers = 1
+	}
+
+	// The working directory of smbstatus in a container does not need to exist on the host
+	inContainer := strings.TrimSpace(runtimeParams.SmbstatusContainer) != ""
+	ret.SmbstatusWorkDir = strings.TrimSpace(runtimeParams.SmbstatusWorkDir)
+	if ret.SmbstatusWorkDir != "" && !inContainer {
+		info, errStat := os.Stat(ret.SmbstatusWorkDir)
+		if errStat != nil {
+			return ret, fmt.Errorf("Can not use '%s' as working directory of smbstatus: %s", ret.SmbstatusWorkDir, errStat)
+		}
+		if !info.IsDir() {
+			return ret, fmt.Errorf("Can not use '%s' as working directory of smbstatus: It is not a directory", ret.SmbstatusWorkDir)
+		}
+	}
+
+	ret.PluginsDirectory = strings.TrimSpace(runtimeParams.PluginsDirectory)
+	if ret.PluginsDirectory != "" {
+		info, errStat := os.Stat(ret.PluginsDirectory)
+		if errStat != nil {
+			return ret, fmt.Errorf("Can not use '%s' as plugins directory: %s", ret.PluginsDirectory, errStat)
+		}
+		if !info.IsDir() {
+			return ret, fmt.Errorf("Can not use '%s' as plugins directory: It is not a directory", ret.PluginsDirectory)
+		}
+	}
+	if runtimeParams.PluginsTimeout <= 0 && ret.PluginsDirectory != "" {
+		return ret, fmt.Errorf("The -plugins.timeout '%s' is not positive", runtimeParams.PluginsTimeout)
+	}
+	ret.PluginsTimeout = runtimeParams.PluginsTimeout
+
+	for _, path := range strings.Split(runtimeParams.CgroupPaths, ",") {
+		path = strings.Trim(strings.TrimSpace(path), "/")
+		if path == "" {
+			continue
+		}
+		if cleaned := filepath.Clean(path); cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return ret, fmt.Errorf("The cgroup '%s' is not below the cgroup root", path)
+		}
+		ret.CgroupPaths = append(ret.CgroupPaths, path)
+	}
+
+	ret.CtdbPath = strings.TrimSpace(runtimeParams.CtdbPath)
+	if ret.CtdbPath == "" {
+		ret.CtdbPath = "ctdb"
+	}
+
+	ret.TestparmPath = strings.TrimSpace(runtimeParams.TestparmPath)
+	if ret.TestparmPath == "" {
+		ret.TestparmPath = "testparm"
+	}
+
+	if withoutSmbstatus {
+		return ret, nil
+	}
+
+	smbstatus := runtimeParams.SmbstatusPath
+	if smbstatus == "" {
+		smbstatus = "smbstatus"
+	}
+	var errContainer error
+	ret.SmbstatusContainer, errContainer = newSmbstatusContainer(runtimeParams.SmbstatusContainer, runtimeParams.SmbstatusContainerRuntime)
+	if errContainer != nil {
+		return ret, errContainer
+	}
+	if ret.SmbstatusContainer != nil {
+		// smbstatus is searched in the container, when it runs there
+		ret.SmbstatusPath = smbstatus
+	} else {
+		var errLookPath error
+		ret.SmbstatusPath, errLookPath = exec.LookPath(smbstatus)
+		if errLookPath != nil {
+			return ret, fmt.Errorf("Can not find \"%s\" executable. Please install the needed package.", smbstatus)
+		}
+	}
+
+	// The ARGS of the service configuration file are split at white spaces, so the words of the prefix can be separated by commas as well
+	ret.SmbstatusPrefix = strings.FieldsFunc(runtimeParams.SmbstatusCommandPrefix, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(ret.SmbstatusPrefix) > 0 {
+		prefixPath, errPrefix := exec.LookPath(ret.SmbstatusPrefix[0])
+		if errPrefix != nil {
+			return ret, fmt.Errorf("Can not find the \"%s\" executable of the -smbstatus.command-prefix", ret.SmbstatusPrefix[0])
+		}
+		ret.SmbstatusPrefix[0] = prefixPath
+	}
+
+	return ret, nil
+}
+
+// isRequestDisabled - Tell if the collector answering requests of the given type is disabled
+func (settings runtimeSettings) isRequestDisabled(requestType commonbl.RequestType) bool {
+	for _, collector := range settings.DisabledCollectors {
+		if collectorRequests[collector] == requestType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// smbstatusEnvironment - Get the environment smbstatus runs with. The locale and timezone of the settings replace the ones of samba_statusd,
+// so the output of smbstatus has the format samba_exporter expects. The variables of -smbstatus.env are added last and win over all others
+func (settings runtimeSettings) smbstatusEnvironment() []string {
+	return append(os.Environ(), settings.smbstatusVariables()...)
+}
+
+// smbstatusVariables - Get the environment variables the settings give to smbstatus, in addition to the environment of samba_statusd
+func (settings runtimeSettings) smbstatusVariables() []string {
+	var variables []string
+	if settings.SmbstatusLocale != "" {
+		variables = append(variables, "LC_ALL="+settings.SmbstatusLocale, "LANG="+settings.SmbstatusLocale)
+	}
+	if settings.SmbstatusTimezone != "" {
+		variables = append(variables, "TZ="+settings.SmbstatusTimezone)
+	}
+
+	return append(variables, settings.SmbstatusEnv...)
+}
+
+// smbstatusCommand - Get the command to run smbstatus with the given arguments. When a -smbstatus.container is given,
+// smbstatus is run in the container. When a -smbstatus.command-prefix is given, smbstatus or the container runtime is run by the command of the prefix
+func (settings runtimeSettings) smbstatusCommand(arguments ...string) *exec.Cmd {
+	commandLine := append([]string{}, settings.SmbstatusPrefix...)
+	if settings.SmbstatusContainer != nil {
+		commandLine = append(commandLine, settings.SmbstatusContainer.commandLine(settings.smbstatusVariables(), settings.SmbstatusWorkDir)...)
+	}
+	commandLine = append(commandLine, settings.SmbstatusPath)
+	commandLine = append(commandLine, arguments...)
+
+	command := exec.Command(commandLine[0], commandLine[1:]...)
+	command.Env = settings.smbstatusEnvironment()
+	if settings.SmbstatusContainer == nil {
+		command.Dir = settings.SmbstatusWorkDir
+	}
+
+	return command
+}
+
+// reloadRuntimeSettings - Read the 'ARGS' out of the service configuration file and the YAML configuration file given there
+// and use the runtime settings defined there
+func reloadRuntimeSettings() error {
+	args, errRead := commonbl.ReadArgsFromEnvironmentFile(params.ServiceConfigFile, "ARGS")
+	if errRead != nil {
+		return errRead
+	}
+
+	var newParams parmeters
+	flagSet := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	defineFlags(flagSet, &newParams)
+	if errParse := flagSet.Parse(args); errParse != nil {
+		return errParse
+	}
+	if errConfig := applyConfigFile(flagSet, &newParams); errConfig != nil {
+		return errConfig
+	}
+
+	newSettings, errNew := newRuntimeSettings(newParams.runtimeParmeters, !usesSmbstatus(params))
+	if errNew != nil {
+		return errNew
+	}
+	setRuntimeSettings(newSettings)
+	clearSmbstatusCaches()
+
+	return nil
+}
+
+// waitforHangupSignalAndReload - Reload the runtime settings each time SIGHUP is received
+func waitforHangupSignalAndReload() {
+	hangupSignal := make(chan os.Signal, 1)
+	signal.Notify(hangupSignal, syscall.SIGHUP)
+
+	for range hangupSignal {
+		logger.WriteInformation(fmt.Sprintf("Reload runtime settings from '%s' due to hangup signal", params.ServiceConfigFile))
+		errReload := reloadRuntimeSettings()
+		if errReload != nil {
+			logger.WriteErrorWithAddition(errReload, "while reloading the runtime settings, keep the current settings")
+			continue
+		}
+		settings := getRuntimeSettings()
+		logger.WriteVerbose(fmt.Sprintf("Use %s to get samba status.", settings.SmbstatusPath))
+		logger.WriteVerbose(fmt.Sprintf("Run smbstatus with the command prefix '%s'", strings.Join(settings.SmbstatusPrefix, " ")))
+		if settings.SmbstatusContainer != nil {
+			logger.WriteVerbose(fmt.Sprintf("Run smbstatus in %s", settings.SmbstatusContainer))
+		}
+		logger.WriteVerbose(fmt.Sprintf("Disabled collectors: '%s'", strings.Join(settings.DisabledCollectors, ", ")))
+		logger.WriteVerbose(fmt.Sprintf("Run smbstatus with locale '%s' and timezone '%s'", settings.SmbstatusLocale, settings.SmbstatusTimezone))
+		logger.WriteVerbose(fmt.Sprintf("Minimum interval between smbstatus calls: %s", settings.SmbstatusMinInterval))
+		logger.WriteVerbose(fmt.Sprintf("Call smbstatus once per collection cycle: %t", settings.SmbstatusSingleCall))
+		logger.WriteVerbose(fmt.Sprintf("Parallel smbstatus calls: %d", settings.SmbstatusWorkers))
+		logger.WriteVerbose(fmt.Sprintf("Run smbstatus with the additional environment '%s' in '%s'", strings.Join(settings.SmbstatusEnv, ", "), settings.SmbstatusWorkDir))
+		logger.WriteVerbose(fmt.Sprintf("Run the plugins in '%s' with the timeout %s", settings.PluginsDirectory, settings.PluginsTimeout))
+		logger.WriteVerbose(fmt.Sprintf("Report the usage of the cgroups '%s'", strings.Join(settings.CgroupPaths, ", ")))
+		logger.WriteVerbose(fmt.Sprintf("List the cluster nodes with '%s'", settings.CtdbPath))
+		logger.WriteVerbose(fmt.Sprintf("Read the configured shares with '%s'", settings.TestparmPath))
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestNewRuntimeSettings(t *testing.T) {
+	settings, err := newRuntimeSettings(runtimeParmeters{DisabledCollectors: "locks, psdata"}, true)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(settings.DisabledCollectors) != 2 {
+		t.Errorf("Got '%d' disabled collectors but expected '2'", len(settings.DisabledCollectors))
+	}
+
+	if !settings.isRequestDisabled(commonbl.LOCK_REQUEST) || !settings.isRequestDisabled(commonbl.PS_REQUEST) {
+		t.Errorf("The lock and ps requests are not disabled, but should")
+	}
+
+	if settings.isRequestDisabled(commonbl.SHARE_REQUEST) || settings.isRequestDisabled(commonbl.PROCESS_REQUEST) {
+		t.Errorf("The share or process requests are disabled, but should not")
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{DisabledCollectors: "locks,unknown"}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one")
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{SmbstatusPath: "/not/existing/smbstatus"}, false)
+	if err == nil {
+		t.Errorf("Got no error but expected one")
+	}
+}
+
+func TestNewRuntimeSettingsTimezone(t *testing.T) {
+	settings, err := newRuntimeSettings(runtimeParmeters{SmbstatusLocale: "C", SmbstatusTimezone: "UTC"}, true)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if settings.SmbstatusLocale != "C" || settings.SmbstatusTimezone != "UTC" {
+		t.Errorf("Got locale '%s' and timezone '%s' but expected 'C' and 'UTC'", settings.SmbstatusLocale, settings.SmbstatusTimezone)
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{SmbstatusTimezone: "Not/A_Timezone"}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the timezone is unknown")
+	}
+}
+
+func TestSmbstatusEnvironment(t *testing.T) {
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	t.Setenv("TZ", "Europe/Berlin")
+
+	environment := runtimeSettings{SmbstatusLocale: "C", SmbstatusTimezone: "UTC"}.smbstatusEnvironment()
+	if getLastEnvironmentValue(environment, "LC_ALL") != "C" {
+		t.Errorf("Got LC_ALL '%s' but expected 'C'", getLastEnvironmentValue(environment, "LC_ALL"))
+	}
+	if getLastEnvironmentValue(environment, "LANG") != "C" {
+		t.Errorf("Got LANG '%s' but expected 'C'", getLastEnvironmentValue(environment, "LANG"))
+	}
+	if getLastEnvironmentValue(environment, "TZ") != "UTC" {
+		t.Errorf("Got TZ '%s' but expected 'UTC'", getLastEnvironmentValue(environment, "TZ"))
+	}
+
+	environment = runtimeSettings{}.smbstatusEnvironment()
+	if getLastEnvironmentValue(environment, "LC_ALL") != "de_DE.UTF-8" {
+		t.Errorf("Got LC_ALL '%s' but expected 'de_DE.UTF-8'", getLastEnvironmentValue(environment, "LC_ALL"))
+	}
+	if getLastEnvironmentValue(environment, "TZ") != "Europe/Berlin" {
+		t.Errorf("Got TZ '%s' but expected 'Europe/Berlin'", getLastEnvironmentValue(environment, "TZ"))
+	}
+
+	command := runtimeSettings{SmbstatusPath: "/usr/bin/smbstatus", SmbstatusLocale: "C"}.smbstatusCommand("-L", "-n")
+	if getLastEnvironmentValue(command.Env, "LC_ALL") != "C" {
+		t.Errorf("The smbstatus command does not run with LC_ALL 'C'")
+	}
+}
+
+func TestNewRuntimeSettingsEnvironment(t *testing.T) {
+	workDir := t.TempDir()
+	settings, err := newRuntimeSettings(runtimeParmeters{SmbstatusEnv: "SMB_CONF_PATH=/etc/samba/smb-2.conf, KRB5_CONFIG=/etc/krb5-2.conf", SmbstatusWorkDir: workDir}, true)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(settings.SmbstatusEnv) != 2 {
+		t.Errorf("Got '%d' environment variables but expected '2'", len(settings.SmbstatusEnv))
+	}
+
+	command := settings.smbstatusCommand("-S", "-n")
+	if getLastEnvironmentValue(command.Env, "SMB_CONF_PATH") != "/etc/samba/smb-2.conf" {
+		t.Errorf("Got SMB_CONF_PATH '%s' but expected '/etc/samba/smb-2.conf'", getLastEnvironmentValue(command.Env, "SMB_CONF_PATH"))
+	}
+	if getLastEnvironmentValue(command.Env, "KRB5_CONFIG") != "/etc/krb5-2.conf" {
+		t.Errorf("Got KRB5_CONFIG '%s' but expected '/etc/krb5-2.conf'", getLastEnvironmentValue(command.Env, "KRB5_CONFIG"))
+	}
+	if command.Dir != workDir {
+		t.Errorf("Got working directory '%s' but expected '%s'", command.Dir, workDir)
+	}
+
+	for _, env := range []string{"SMB_CONF_PATH", "=value", " =value"} {
+		_, err = newRuntimeSettings(runtimeParmeters{SmbstatusEnv: env}, true)
+		if err == nil {
+			t.Errorf("Got no error for the environment '%s' but expected one", env)
+		}
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{SmbstatusWorkDir: filepath.Join(workDir, "not-existing")}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the working directory does not exist")
+	}
+
+	file := filepath.Join(workDir, "file")
+	os.WriteFile(file, []byte("file"), 0644)
+	_, err = newRuntimeSettings(runtimeParmeters{SmbstatusWorkDir: file}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the working directory is a file")
+	}
+}
+
+func TestNewRuntimeSettingsCommandPrefix(t *testing.T) {
+	settings, err := newRuntimeSettings(runtimeParmeters{SmbstatusPath: "true", SmbstatusCommandPrefix: " env,-u  HOME "}, false)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	command := settings.smbstatusCommand("-L", "-n")
+	expected := []string{settings.SmbstatusPrefix[0], "-u", "HOME", settings.SmbstatusPath, "-L", "-n"}
+	if strings.Join(command.Args, " ") != strings.Join(expected, " ") {
+		t.Errorf("Got the command '%s' but expected '%s'", strings.Join(command.Args, " "), strings.Join(expected, " "))
+	}
+	if !filepath.IsAbs(command.Path) || filepath.Base(command.Path) != "env" {
+		t.Errorf("Got the executable '%s', but expected the path of 'env'", command.Path)
+	}
+	if errRun := command.Run(); errRun != nil {
+		t.Errorf("Got error '%s' but expected none", errRun.Error())
+	}
+
+	// The prefix must not be changed by the commands
+	settings.smbstatusCommand("-S")
+	if len(settings.SmbstatusPrefix) != 3 {
+		t.Errorf("Got the prefix '%s', but expected 'env -u HOME'", strings.Join(settings.SmbstatusPrefix, " "))
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{SmbstatusPath: "true", SmbstatusCommandPrefix: "not-existing-sudo -n"}, false)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the command of the prefix does not exist")
+	}
+}
+
+func TestNewRuntimeSettingsPlugins(t *testing.T) {
+	directory := t.TempDir()
+	settings, err := newRuntimeSettings(runtimeParmeters{PluginsDirectory: directory, PluginsTimeout: 3 * time.Second}, true)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if settings.PluginsDirectory != directory || settings.PluginsTimeout != 3*time.Second {
+		t.Errorf("Got the plugins directory '%s' with timeout %s, but expected '%s' with 3s", settings.PluginsDirectory, settings.PluginsTimeout, directory)
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{PluginsDirectory: filepath.Join(directory, "not-existing"), PluginsTimeout: time.Second}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the plugins directory does not exist")
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{PluginsDirectory: directory}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the plugins timeout is 0")
+	}
+}
+
+func TestNewRuntimeSettingsCgroupPaths(t *testing.T) {
+	settings, err := newRuntimeSettings(runtimeParmeters{CgroupPaths: " /system.slice/smbd.service/, system.slice/nmbd.service,"}, true)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(settings.CgroupPaths) != 2 || settings.CgroupPaths[0] != "system.slice/smbd.service" || settings.CgroupPaths[1] != "system.slice/nmbd.service" {
+		t.Errorf("Got the cgroups '%v', but expected 'system.slice/smbd.service' and 'system.slice/nmbd.service'", settings.CgroupPaths)
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{CgroupPaths: "system.slice/../../etc"}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the cgroup is not below the cgroup root")
+	}
+}
+
+func TestNewRuntimeSettingsCtdbPath(t *testing.T) {
+	settings, err := newRuntimeSettings(runtimeParmeters{CtdbPath: " "}, true)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if settings.CtdbPath != "ctdb" {
+		t.Errorf("Got the ctdb path '%s', but expected 'ctdb'", settings.CtdbPath)
+	}
+
+	settings, _ = newRuntimeSettings(runtimeParmeters{CtdbPath: "/usr/bin/ctdb"}, true)
+	if settings.CtdbPath != "/usr/bin/ctdb" {
+		t.Errorf("Got the ctdb path '%s', but expected '/usr/bin/ctdb'", settings.CtdbPath)
+	}
+}
+
+func TestNewRuntimeSettingsTestparmPath(t *testing.T) {
+	settings, err := newRuntimeSettings(runtimeParmeters{TestparmPath: " "}, true)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if settings.TestparmPath != "testparm" {
+		t.Errorf("Got the testparm path '%s', but expected 'testparm'", settings.TestparmPath)
+	}
+
+	settings, _ = newRuntimeSettings(runtimeParmeters{TestparmPath: "/usr/bin/testparm"}, true)
+	if settings.TestparmPath != "/usr/bin/testparm" {
+		t.Errorf("Got the testparm path '%s', but expected '/usr/bin/testparm'", settings.TestparmPath)
+	}
+}
+
+func TestNewRuntimeSettingsMinInterval(t *testing.T) {
+	settings, err := newRuntimeSettings(runtimeParmeters{SmbstatusMinInterval: 5 * time.Second}, true)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+	if settings.SmbstatusMinInterval != 5*time.Second {
+		t.Errorf("Got minimum interval '%s' but expected '5s'", settings.SmbstatusMinInterval)
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{SmbstatusMinInterval: -time.Second}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the minimum interval is negative")
+	}
+}
+
+func TestNewRuntimeSettingsWorkers(t *testing.T) {
+	settings, err := newRuntimeSettings(runtimeParmeters{}, true)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+	if settings.SmbstatusWorkers != 1 {
+		t.Errorf("Got '%d' workers but expected '1'", settings.SmbstatusWorkers)
+	}
+
+	_, err = newRuntimeSettings(runtimeParmeters{SmbstatusWorkers: -1}, true)
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the number of workers is negative")
+	}
+}
+
+// getLastEnvironmentValue - Get the value of the variable in the environment, the last one wins like for exec.Cmd
+func getLastEnvironmentValue(environment []string, name string) string {
+	value := ""
+	for _, variable := range environment {
+		if strings.HasPrefix(variable, name+"=") {
+			value = strings.TrimPrefix(variable, name+"=")
+		}
+	}
+
+	return value
+}
+
+func TestReloadRuntimeSettings(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	oldSettings := getRuntimeSettings()
+	defer setRuntimeSettings(oldSettings)
+
+	params.Test = true
+	params.ServiceConfigFile = filepath.Join(t.TempDir(), "samba_statusd")
+	setRuntimeSettings(runtimeSettings{})
+
+	err := reloadRuntimeSettings()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the service configuration file does not exist")
+	}
+
+	os.WriteFile(params.ServiceConfigFile, []byte("ARGS='-verbose -disabled-collectors=shares'\n"), 0644)
+	err = reloadRuntimeSettings()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if !getRuntimeSettings().isRequestDisabled(commonbl.SHARE_REQUEST) {
+		t.Errorf("The share requests are not disabled after reload")
+	}
+
+	os.WriteFile(params.ServiceConfigFile, []byte("ARGS='-disabled-collectors=invalid'\n"), 0644)
+	err = reloadRuntimeSettings()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the collector is invalid")
+	}
+
+	if !getRuntimeSettings().isRequestDisabled(commonbl.SHARE_REQUEST) {
+		t.Errorf("The settings changed, after a reload with invalid settings")
+	}
+}
+
+func TestReloadRuntimeSettingsWithConfigFile(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	oldSettings := getRuntimeSettings()
+	defer setRuntimeSettings(oldSettings)
+
+	params.Test = true
+	configFile := filepath.Join(t.TempDir(), "samba_statusd.yml")
+	params.ServiceConfigFile = filepath.Join(t.TempDir(), "samba_statusd")
+	setRuntimeSettings(runtimeSettings{})
+
+	os.WriteFile(configFile, []byte("disabled-collectors:\n  - locks\n  - shares\n"), 0644)
+	os.WriteFile(params.ServiceConfigFile, []byte(fmt.Sprintf("ARGS='-config.file=%s'\n", configFile)), 0644)
+	err := reloadRuntimeSettings()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if !getRuntimeSettings().isRequestDisabled(commonbl.LOCK_REQUEST) || !getRuntimeSettings().isRequestDisabled(commonbl.SHARE_REQUEST) {
+		t.Errorf("The lock and share requests are not disabled after reload")
+	}
+
+	os.WriteFile(params.ServiceConfigFile, []byte(fmt.Sprintf("ARGS='-config.file=%s -disabled-collectors=psdata'\n", configFile)), 0644)
+	err = reloadRuntimeSettings()
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if getRuntimeSettings().isRequestDisabled(commonbl.LOCK_REQUEST) || !getRuntimeSettings().isRequestDisabled(commonbl.PS_REQUEST) {
+		t.Errorf("The command line does not override the configuration file")
+	}
+
+	os.WriteFile(configFile, []byte("unknown-setting: true\n"), 0644)
+	err = reloadRuntimeSettings()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the configuration file contains an unknown setting")
+	}
+}
+
+func TestDisabledResponse(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	responseHandler := &recordingHandler{}
+
+	err := disabledResponse(responseHandler, commonbl.PS_REQUEST, 40)
+	if err != nil {
+		t.Errorf("Get error '%s' but expected none", err.Error())
+	}
+
+	err = disabledResponse(responseHandler, commonbl.LOCK_REQUEST, 41)
+	if err != nil {
+		t.Errorf("Get error '%s' but expected none", err.Error())
+	}
+
+	responses := responseHandler.getResponses()
+	if len(responses) != 2 {
+		t.Fatalf("Got '%d' responses but expected '2'", len(responses))
+	}
+	for i, expected := range []string{"[]", commonbl.DISABLED_RESPONSE_DATA} {
+		_, data, errSplit := commonbl.SplitResponse(responses[i])
+		if errSplit != nil {
+			t.Fatalf("Got error '%s' but expected none", errSplit.Error())
+		}
+		if data != expected {
+			t.Errorf("Got the data '%s' but expected '%s'", data, expected)
+		}
+	}
+}
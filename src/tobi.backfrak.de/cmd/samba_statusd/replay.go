@@ -0,0 +1,95 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The files in the -replay.directory the requests are answered with
+var replayFileNames = map[commonbl.RequestType]string{
+	commonbl.LOCK_REQUEST:    "locks.txt",
+	commonbl.SHARE_REQUEST:   "shares.txt",
+	commonbl.PROCESS_REQUEST: "processes.txt",
+	commonbl.PS_REQUEST:      "psdata.json",
+}
+
+// replaySource - Answers the requests with saved outputs of smbstatus and ps data, one file per request type
+type replaySource struct {
+	directory string
+}
+
+// The source of the answers when replaying files, nil when smbstatus is used
+var replayData *replaySource
+
+// newReplaySource - Get a replaySource reading the files in the directory
+func newReplaySource(directory string) (*replaySource, error) {
+	info, errStat := os.Stat(directory)
+	if errStat != nil {
+		return nil, errStat
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", directory)
+	}
+
+	return &replaySource{directory: directory}, nil
+}
+
+// getMissingFiles - Get the names of the files that are not in the directory. Their requests are answered with empty data
+func (source *replaySource) getMissingFiles() []string {
+	var ret []string
+	for _, requestType := range []commonbl.RequestType{commonbl.LOCK_REQUEST, commonbl.SHARE_REQUEST, commonbl.PROCESS_REQUEST, commonbl.PS_REQUEST} {
+		_, errStat := os.Stat(filepath.Join(source.directory, replayFileNames[requestType]))
+		if errors.Is(errStat, fs.ErrNotExist) {
+			ret = append(ret, replayFileNames[requestType])
+		}
+	}
+
+	return ret
+}
+
+// readFile - Read the file of the request type. The file is read for each request, so it can be changed while samba_statusd runs.
+// A missing file gives empty data
+func (source *replaySource) readFile(requestType commonbl.RequestType) ([]byte, error) {
+	data, errRead := os.ReadFile(filepath.Join(source.directory, replayFileNames[requestType]))
+	if errors.Is(errRead, fs.ErrNotExist) {
+		return []byte{}, nil
+	}
+
+	return data, errRead
+}
+
+// getSmbstatusOutput - Get the saved output of smbstatus for the request type
+func (source *replaySource) getSmbstatusOutput(requestType commonbl.RequestType) ([]byte, error) {
+	return source.readFile(requestType)
+}
+
+// GetPsUtilPidData - Get the saved ps data of the smbd processes, in the JSON format samba_statusd sends
+func (source *replaySource) GetPsUtilPidData() ([]commonbl.PsUtilPidData, error) {
+	data, errRead := source.readFile(commonbl.PS_REQUEST)
+	if errRead != nil {
+		return nil, errRead
+	}
+	ret := []commonbl.PsUtilPidData{}
+	if strings.TrimSpace(string(data)) == "" {
+		return ret, nil
+	}
+
+	errConv := json.Unmarshal(data, &ret)
+	if errConv != nil {
+		return nil, fmt.Errorf("can not read '%s': %s", replayFileNames[commonbl.PS_REQUEST], errConv.Error())
+	}
+
+	return ret, nil
+}
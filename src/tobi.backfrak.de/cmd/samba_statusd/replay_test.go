@@ -0,0 +1,90 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestReplaySource(t *testing.T) {
+	directory := t.TempDir()
+	os.WriteFile(filepath.Join(directory, "locks.txt"), []byte(commonbl.TestLockResponse), 0644)
+	os.WriteFile(filepath.Join(directory, "psdata.json"), []byte(commonbl.TestPsResponse()), 0644)
+
+	source, errNew := newReplaySource(directory)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+
+	missing := strings.Join(source.getMissingFiles(), ",")
+	if missing != "shares.txt,processes.txt" {
+		t.Errorf("The missing files '%s' are not expected", missing)
+	}
+
+	locks, errLocks := source.getSmbstatusOutput(commonbl.LOCK_REQUEST)
+	if errLocks != nil || string(locks) != commonbl.TestLockResponse {
+		t.Errorf("Got '%s' and error '%v', but expected the test lock response", string(locks), errLocks)
+	}
+	shares, errShares := source.getSmbstatusOutput(commonbl.SHARE_REQUEST)
+	if errShares != nil || len(shares) != 0 {
+		t.Errorf("Got '%s' and error '%v' for a missing file, but expected empty data", string(shares), errShares)
+	}
+
+	psData, errPs := source.GetPsUtilPidData()
+	if errPs != nil || len(psData) != len(commonbl.GetTestPsUtilPidData()) {
+		t.Errorf("Got %d processes and error '%v', but expected %d", len(psData), errPs, len(commonbl.GetTestPsUtilPidData()))
+	}
+
+	// The files are read for each request
+	os.WriteFile(filepath.Join(directory, "locks.txt"), []byte(commonbl.NO_LOCKED_FILES), 0644)
+	locks, _ = source.getSmbstatusOutput(commonbl.LOCK_REQUEST)
+	if string(locks) != commonbl.NO_LOCKED_FILES {
+		t.Errorf("Got '%s' but expected the changed file", string(locks))
+	}
+}
+
+func TestReplaySourceInvalidPsData(t *testing.T) {
+	directory := t.TempDir()
+	os.WriteFile(filepath.Join(directory, "psdata.json"), []byte("PID CPU"), 0644)
+	source, _ := newReplaySource(directory)
+
+	_, errPs := source.GetPsUtilPidData()
+	if errPs == nil {
+		t.Errorf("Got no error for invalid ps data, but expected one")
+	}
+}
+
+func TestNewReplaySourceNoDirectory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "locks.txt")
+	os.WriteFile(file, []byte(commonbl.TestLockResponse), 0644)
+
+	for _, directory := range []string{file, filepath.Join(file, "not-existing")} {
+		_, errNew := newReplaySource(directory)
+		if errNew == nil {
+			t.Errorf("Got no error for '%s', but expected one", directory)
+		}
+	}
+}
+
+func TestMainWithInvalidReplayDirectory(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+
+	params.ReplayDirectory = filepath.Join(t.TempDir(), "not-existing")
+
+	res := realMain()
+	if res != -14 {
+		t.Errorf("Got %d from main, but expected -14", res)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// The values of linux/seccomp.h not defined in the unix package
+const (
+	seccompSetModeFilter   = 1
+	seccompFilterFlagTsync = 1
+	seccompRetKillProcess  = 0x80000000
+	seccompRetErrno        = 0x00050000
+	seccompRetAllow        = 0x7fff0000
+	// Syscalls of the x32 ABI on amd64 have this bit set, they are denied as a whole
+	x32SyscallBit = 0x40000000
+)
+
+// The architectures the seccomp filter can be applied on, with the architecture the kernel reports for their syscalls
+var seccompArchitectures = map[string]uint32{
+	"amd64":   unix.AUDIT_ARCH_X86_64,
+	"arm64":   unix.AUDIT_ARCH_AARCH64,
+	"arm":     unix.AUDIT_ARCH_ARM,
+	"386":     unix.AUDIT_ARCH_I386,
+	"ppc64le": unix.AUDIT_ARCH_PPC64LE,
+	"s390x":   unix.AUDIT_ARCH_S390X,
+	"riscv64": unix.AUDIT_ARCH_RISCV64,
+}
+
+// The syscalls neither samba_statusd nor smbstatus or the plugins need. They administrate the system and would help an attacker
+// taking over the daemon running as root
+var sandboxDeniedSyscalls = []uintptr{
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT,
+	unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_REBOOT, unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	unix.SYS_SETNS, unix.SYS_UNSHARE,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+	unix.SYS_SETTIMEOFDAY, unix.SYS_CLOCK_SETTIME, unix.SYS_ADJTIMEX,
+	unix.SYS_SETHOSTNAME, unix.SYS_SETDOMAINNAME,
+	unix.SYS_ACCT, unix.SYS_QUOTACTL, unix.SYS_SYSLOG,
+	unix.SYS_OPEN_BY_HANDLE_AT, unix.SYS_NAME_TO_HANDLE_AT,
+}
+
+// applySandbox - Apply a seccomp filter to all threads of samba_statusd and the processes it starts, so the sandboxDeniedSyscalls
+// fail with EPERM. The process can not gain privileges afterwards, e. g. by executing setuid programs
+func applySandbox() error {
+	arch, found := seccompArchitectures[runtime.GOARCH]
+	if !found {
+		return fmt.Errorf("The -sandbox is not supported on the architecture %s", runtime.GOARCH)
+	}
+	filter := getSeccompFilter(arch, sandboxDeniedSyscalls)
+
+	// The no_new_privs and the filter get set on the calling thread, the filter is then synchronized to the other threads
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	errNoNewPrivs := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0)
+	if errNoNewPrivs != nil {
+		return fmt.Errorf("Can not set no_new_privs for the -sandbox: %s", errNoNewPrivs.Error())
+	}
+	program := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	_, _, errNo := unix.Syscall(unix.SYS_SECCOMP, seccompSetModeFilter, seccompFilterFlagTsync, uintptr(unsafe.Pointer(&program)))
+	if errNo != 0 {
+		return fmt.Errorf("Can not apply the seccomp filter of the -sandbox: %s", errNo.Error())
+	}
+
+	logger.WriteInformation(fmt.Sprintf("Applied the sandbox, %d syscalls are denied", len(sandboxDeniedSyscalls)))
+
+	return nil
+}
+
+// getSeccompFilter - Get the BPF program killing the process for syscalls of other architectures than arch and
+// answering the denied syscalls with EPERM. All other syscalls are allowed
+func getSeccompFilter(arch uint32, denied []uintptr) []unix.SockFilter {
+	filter := []unix.SockFilter{
+		// Load the architecture from the seccomp_data, and kill the process when it is not the expected one
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: 4},
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: 1, Jf: 0, K: arch},
+		{Code: unix.BPF_RET | unix.BPF_K, K: seccompRetKillProcess},
+		// Load the syscall number
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: 0},
+	}
+
+	// Each check jumps to the EPERM at the end, when the syscall matches. The last check needs to skip the allow before
+	checks := len(denied) + 1
+	filter = append(filter, unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K, Jt: uint8(checks), Jf: 0, K: x32SyscallBit})
+	for i, syscall := range denied {
+		filter = append(filter, unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: uint8(checks - i - 1), Jf: 0, K: uint32(syscall)})
+	}
+
+	return append(filter,
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: seccompRetAllow},
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: seccompRetErrno | uint32(unix.EPERM)},
+	)
+}
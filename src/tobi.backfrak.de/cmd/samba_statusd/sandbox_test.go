@@ -0,0 +1,66 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestGetSeccompFilter(t *testing.T) {
+	denied := []uintptr{unix.SYS_MOUNT, unix.SYS_PTRACE}
+	filter := getSeccompFilter(unix.AUDIT_ARCH_X86_64, denied)
+
+	if len(filter) != 4+1+len(denied)+2 {
+		t.Fatalf("Got a filter with '%d' instructions, but expected '%d'", len(filter), 4+1+len(denied)+2)
+	}
+	if filter[1].K != unix.AUDIT_ARCH_X86_64 {
+		t.Errorf("The filter checks the architecture '%x', but expected '%x'", filter[1].K, unix.AUDIT_ARCH_X86_64)
+	}
+	errnoIndex := len(filter) - 1
+	if filter[errnoIndex].K != seccompRetErrno|uint32(unix.EPERM) || filter[errnoIndex-1].K != seccompRetAllow {
+		t.Errorf("The filter does not end with the allow and the EPERM")
+	}
+	// Each check of a syscall needs to jump to the EPERM
+	for i := 4; i < errnoIndex-1; i++ {
+		if i+1+int(filter[i].Jt) != errnoIndex {
+			t.Errorf("The check '%d' of the filter jumps to '%d', but expected '%d'", i, i+1+int(filter[i].Jt), errnoIndex)
+		}
+	}
+}
+
+func TestApplySandbox(t *testing.T) {
+	// The filter can not be removed again, so it is applied in a process of its own
+	if os.Getenv("SAMBA_STATUSD_SANDBOX_TEST") == "1" {
+		logger = testhelper.NewTestLogger(true)
+		errApply := applySandbox()
+		if errApply != nil {
+			t.Fatalf("Got error '%s' but expected none", errApply.Error())
+		}
+		// Without the filter setns fails with EBADF for the invalid file descriptor
+		errSetns := unix.Setns(-1, 0)
+		if !errors.Is(errSetns, unix.EPERM) {
+			t.Errorf("Got error '%v' from setns, but expected EPERM from the filter", errSetns)
+		}
+		errExec := exec.Command(os.Args[0], "-test.run=^$").Run()
+		if errExec != nil {
+			t.Errorf("Got error '%s' but expected none, since the sandbox allows to start processes", errExec.Error())
+		}
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestApplySandbox$")
+	cmd.Env = append(os.Environ(), "SAMBA_STATUSD_SANDBOX_TEST=1")
+	output, errRun := cmd.CombinedOutput()
+	if errRun != nil {
+		t.Errorf("Got error '%s' but expected none, the sandboxed process printed: %s", errRun.Error(), string(output))
+	}
+}
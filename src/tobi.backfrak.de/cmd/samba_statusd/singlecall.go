@@ -0,0 +1,142 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The arguments smbstatus is called with, when one call answers the lock, share and process requests
+var singleCallArguments = []string{"-n"}
+
+// The maximum age of the tables, that are used to answer the requests of one collection cycle
+const cycleMaxAge = 5 * time.Second
+
+// cycleOutput - The tables of one collection cycle and the request types they were already sent for
+type cycleOutput struct {
+	mutex    sync.Mutex
+	tables   map[commonbl.RequestType]string
+	sent     map[commonbl.RequestType]bool
+	received time.Time
+}
+
+var smbstatusCycle = &cycleOutput{}
+
+// getTable - Get the table for the request type. The tables are collected again, when the table was already sent
+// in this collection cycle or the tables are older than cycleMaxAge
+func (output *cycleOutput) getTable(requestType commonbl.RequestType, collect func() (map[commonbl.RequestType]string, error)) (string, error) {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	if output.tables == nil || output.sent[requestType] || time.Since(output.received) > cycleMaxAge {
+		tables, errCollect := collect()
+		if errCollect != nil {
+			return "", errCollect
+		}
+		output.tables = tables
+		output.sent = map[commonbl.RequestType]bool{}
+		output.received = time.Now()
+	}
+	output.sent[requestType] = true
+
+	return output.tables[requestType], nil
+}
+
+// clear - Remove the tables, so the next request collects them again
+func (output *cycleOutput) clear() {
+	output.mutex.Lock()
+	defer output.mutex.Unlock()
+
+	output.tables = nil
+	output.sent = nil
+}
+
+// splitSmbstatusOutput - Split the output of smbstatus called without -L, -S or -p into the process, share and lock tables
+func splitSmbstatusOutput(data string) (map[commonbl.RequestType]string, error) {
+	lines := strings.Split(data, "\n")
+	shareStart := -1
+	lockStart := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if shareStart < 0 && strings.HasPrefix(trimmed, "Service") {
+			shareStart = i
+		}
+		if lockStart < 0 && (strings.HasPrefix(trimmed, "Locked files:") || strings.HasPrefix(trimmed, "No locked files")) {
+			lockStart = i
+		}
+	}
+
+	if shareStart < 0 || lockStart < shareStart {
+		return nil, fmt.Errorf("Can not find the share and lock tables in the smbstatus output")
+	}
+
+	return map[commonbl.RequestType]string{
+		commonbl.PROCESS_REQUEST: strings.Join(lines[:shareStart], "\n"),
+		commonbl.SHARE_REQUEST:   strings.Join(lines[shareStart:lockStart], "\n"),
+		commonbl.LOCK_REQUEST:    strings.Join(lines[lockStart:], "\n"),
+	}, nil
+}
+
+// getSmbstatusArguments - Get the arguments smbstatus is called with to answer requests of the given type
+func getSmbstatusArguments(settings runtimeSettings, requestType commonbl.RequestType) []string {
+	if settings.SmbstatusSingleCall {
+		return singleCallArguments
+	}
+
+	return smbstatusArguments[requestType]
+}
+
+// runSmbstatusFor - Get the output of smbstatus for the request type, or the generated or replayed output. With -smbstatus.single-call, one smbstatus call
+// answers the lock, share and process requests of a collection cycle. With -smbstatus.workers greater 1, the tables
+// of a collection cycle are collected with parallel smbstatus calls
+func runSmbstatusFor(requestType commonbl.RequestType) ([]byte, error) {
+	if demoDataGenerator != nil {
+		return []byte(getDemoSmbstatusOutput(requestType)), nil
+	}
+	if replayData != nil {
+		return replayData.getSmbstatusOutput(requestType)
+	}
+
+	settings := getRuntimeSettings()
+	if settings.SmbstatusSingleCall {
+		table, err := smbstatusCycle.getTable(requestType, func() (map[commonbl.RequestType]string, error) {
+			data, errRun := runSmbstatus(singleCallArguments...)
+			if errRun != nil {
+				return nil, errRun
+			}
+			return splitSmbstatusOutput(string(data))
+		})
+
+		return []byte(table), err
+	}
+
+	if settings.SmbstatusWorkers > 1 {
+		table, err := smbstatusCycle.getTable(requestType, func() (map[commonbl.RequestType]string, error) {
+			return runSmbstatusParallel(settings.getEnabledSmbstatusRequests(), settings.SmbstatusWorkers, runSmbstatus)
+		})
+
+		return []byte(table), err
+	}
+
+	return runSmbstatus(smbstatusArguments[requestType]...)
+}
+
+// getDemoSmbstatusOutput - Get the output of smbstatus for the request type generated by the demoDataGenerator
+func getDemoSmbstatusOutput(requestType commonbl.RequestType) string {
+	switch requestType {
+	case commonbl.LOCK_REQUEST:
+		return demoDataGenerator.GetLockTable()
+	case commonbl.SHARE_REQUEST:
+		return demoDataGenerator.GetShareTable()
+	default:
+		return demoDataGenerator.GetProcessTable()
+	}
+}
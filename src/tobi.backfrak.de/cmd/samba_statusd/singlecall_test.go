@@ -0,0 +1,94 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The output of smbstatus called without -L, -S or -p for the test data
+var testSingleCallOutput = commonbl.TestProcessResponse + "\n" + commonbl.TestShareResponse + "\n" + commonbl.TestLockResponse
+
+func TestSplitSmbstatusOutput(t *testing.T) {
+	tables, err := splitSmbstatusOutput(testSingleCallOutput)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	expected := map[commonbl.RequestType]string{
+		commonbl.PROCESS_REQUEST: commonbl.TestProcessResponse,
+		commonbl.SHARE_REQUEST:   commonbl.TestShareResponse,
+		commonbl.LOCK_REQUEST:    commonbl.TestLockResponse,
+	}
+	for requestType, table := range expected {
+		if strings.TrimSpace(tables[requestType]) != strings.TrimSpace(table) {
+			t.Errorf("Got the table '%s' for '%s' but expected '%s'", tables[requestType], requestType, table)
+		}
+	}
+
+	tables, err = splitSmbstatusOutput(commonbl.TestProcessResponse + "\n" + commonbl.TestShareResponse + "\nNo locked files\n")
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if strings.TrimSpace(tables[commonbl.LOCK_REQUEST]) != "No locked files" {
+		t.Errorf("Got the lock table '%s' but expected 'No locked files'", tables[commonbl.LOCK_REQUEST])
+	}
+
+	for _, data := range []string{"", commonbl.TestProcessResponse, commonbl.TestLockResponse + "\n" + commonbl.TestShareResponse} {
+		_, err = splitSmbstatusOutput(data)
+		if err == nil {
+			t.Errorf("Got no error for '%s' but expected one", data)
+		}
+	}
+}
+
+func TestCycleOutputGetTable(t *testing.T) {
+	output := &cycleOutput{}
+	calls := 0
+	run := func() (map[commonbl.RequestType]string, error) {
+		calls++
+		return splitSmbstatusOutput(testSingleCallOutput)
+	}
+
+	for cycle := 1; cycle <= 2; cycle++ {
+		for _, requestType := range []commonbl.RequestType{commonbl.LOCK_REQUEST, commonbl.SHARE_REQUEST, commonbl.PROCESS_REQUEST} {
+			table, err := output.getTable(requestType, run)
+			if err != nil {
+				t.Fatalf("Got error '%s' but expected none", err.Error())
+			}
+			if strings.TrimSpace(table) != strings.TrimSpace(testSmbstatusOutput[requestType]) {
+				t.Errorf("Got the table '%s' for '%s' but expected '%s'", table, requestType, testSmbstatusOutput[requestType])
+			}
+		}
+
+		if calls != cycle {
+			t.Errorf("Got '%d' smbstatus calls after %d cycles but expected '%d'", calls, cycle, cycle)
+		}
+	}
+
+	_, err := (&cycleOutput{}).getTable(commonbl.LOCK_REQUEST, func() (map[commonbl.RequestType]string, error) {
+		return nil, fmt.Errorf("smbstatus failed")
+	})
+	if err == nil {
+		t.Errorf("Got no error but expected one")
+	}
+}
+
+func TestGetSmbstatusArguments(t *testing.T) {
+	arguments := getSmbstatusArguments(runtimeSettings{}, commonbl.SHARE_REQUEST)
+	if strings.Join(arguments, " ") != "-S -n" {
+		t.Errorf("Got the arguments '%s' but expected '-S -n'", strings.Join(arguments, " "))
+	}
+
+	arguments = getSmbstatusArguments(runtimeSettings{SmbstatusSingleCall: true}, commonbl.SHARE_REQUEST)
+	if strings.Join(arguments, " ") != "-n" {
+		t.Errorf("Got the arguments '%s' but expected '-n'", strings.Join(arguments, " "))
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// listenTcp - Listen for connections of samba_exporter on the -tcp.listen-address. TLS is used when a certificate is given
+func listenTcp() (net.Listener, error) {
+	tlsConfig, errConfig := getServerTLSConfig()
+	if errConfig != nil {
+		return nil, errConfig
+	}
+
+	return commonbl.ListenTcp(params.TcpListenAddress, tlsConfig)
+}
+
+// getServerTLSConfig - Get the TLS configuration defined by the -tcp.tls.* parameters, nil when no certificate is given
+func getServerTLSConfig() (*tls.Config, error) {
+	if params.TcpTLSCertFile == "" {
+		logger.WriteInformation("No -tcp.tls.cert-file given, the requests and responses are sent unencrypted")
+		return nil, nil
+	}
+
+	return commonbl.GetServerTLSConfig(params.TcpTLSCertFile, params.TcpTLSKeyFile, params.TcpTLSClientCAFile)
+}
+
+// serveTcp - Accept connections of samba_exporter in an infinite loop and handle each connection in own "thread".
+// When accepting fails, e. g. since the listener got closed, the open connections are closed and serveTcp returns after their handlers finished
+func serveTcp(listener net.Listener) int {
+	var handlers sync.WaitGroup
+	var connMux sync.Mutex
+	connections := map[net.Conn]bool{}
+	defer func() {
+		connMux.Lock()
+		for conn := range connections {
+			conn.Close()
+		}
+		connMux.Unlock()
+		handlers.Wait()
+	}()
+
+	for {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			logger.WriteErrorWithAddition(errAccept, "while accepting connections")
+			return -1
+		}
+
+		connMux.Lock()
+		connections[conn] = true
+		connMux.Unlock()
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+			goHandleConnection(commonbl.NewTcpConnectionHandler(conn))
+			connMux.Lock()
+			delete(connections, conn)
+			connMux.Unlock()
+		}()
+	}
+}
+
+// goHandleConnection, is called as go routine and processes the requests received on the connection, until it is closed.
+// The responses are sent on the same connection, in the order the requests were received
+func goHandleConnection(tcpHandler *commonbl.TcpHandler) {
+	defer tcpHandler.Close()
+	handler := getSignedHandler(tcpHandler)
+	logger.WriteVerbose(fmt.Sprintf("Accepted connection from: %s", handler.GetPipeFilePath()))
+
+	for {
+		received, errRecv := handler.WaitForPipeInputString()
+		if errRecv != nil {
+			if errRecv != io.EOF {
+				logger.WriteErrorWithAddition(errRecv, fmt.Sprintf("while reading from %s", handler.GetPipeFilePath()))
+			}
+			logger.WriteVerbose(fmt.Sprintf("Closed connection from: %s", handler.GetPipeFilePath()))
+			return
+		}
+
+		// Handle the requests of all connections one after the other, like the requests received on the pipe
+		handleMux.Lock()
+		errHandle := handleReceived(handler, received)
+		handleMux.Unlock()
+		if errHandle != nil {
+			logger.WriteErrorWithAddition(errHandle, fmt.Sprintf("while answering \"%s\" to %s", received, handler.GetPipeFilePath()))
+		}
+	}
+}
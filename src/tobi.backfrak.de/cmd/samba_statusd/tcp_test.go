@@ -0,0 +1,119 @@
+package main
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestServeTcp(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.Test = true
+	params.TcpListenAddress = "127.0.0.1:0"
+	logger = testhelper.NewTestLogger(true)
+
+	listener, errListen := listenTcp()
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer serveTestTcp(listener)()
+
+	client := commonbl.NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+	for id, request := range []commonbl.RequestType{commonbl.LOCK_REQUEST, commonbl.SHARE_REQUEST, commonbl.PS_REQUEST, commonbl.VERSION_REQUEST} {
+		errWrite := client.WritePipeString(commonbl.GetRequest(request, id))
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		response, errRead := client.WaitForPipeInputString()
+		if errRead != nil {
+			t.Fatalf("Got error '%s' but expected none", errRead.Error())
+		}
+		if !strings.Contains(response, string(request)) {
+			t.Errorf("The response '%s' is not the response to '%s'", response, request)
+		}
+	}
+}
+
+// serveTestTcp - Serve the listener in the background. The returned function closes the listener and waits until serveTcp returned,
+// so no handler uses the logger or the params of the test any longer
+func serveTestTcp(listener net.Listener) func() {
+	done := make(chan int)
+	go func() { done <- serveTcp(listener) }()
+
+	return func() {
+		listener.Close()
+		<-done
+	}
+}
+
+func TestListenTcpWithInvalidCertificate(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	params.TcpListenAddress = "127.0.0.1:0"
+	params.TcpTLSCertFile = "/not/existing/cert.pem"
+	params.TcpTLSKeyFile = "/not/existing/key.pem"
+	logger = testhelper.NewTestLogger(true)
+
+	_, errListen := listenTcp()
+	if errListen == nil {
+		t.Errorf("Got no error but expected one, since the certificate does not exist")
+	}
+}
+
+func TestServeTcpWithAuthSecret(t *testing.T) {
+	mMutext.Lock()
+	defer mMutext.Unlock()
+
+	oldParmas := params
+	defer func() { params = oldParmas }()
+	defer func() { authSecret = nil }()
+	params.Test = true
+	params.TcpListenAddress = "127.0.0.1:0"
+	logger = testhelper.NewTestLogger(true)
+	authSecret = []byte("my secret")
+
+	listener, errListen := listenTcp()
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer serveTestTcp(listener)()
+
+	client := commonbl.NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+	signedClient := commonbl.NewSigningHandler(client, []byte("my secret"), logger)
+
+	// The unsigned request is dropped, so the first response is the one for the signed request
+	errWrite := client.WritePipeString(commonbl.GetRequest(commonbl.LOCK_REQUEST, 1))
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+	errWrite = signedClient.WritePipeString(commonbl.GetRequest(commonbl.SHARE_REQUEST, 2))
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+
+	response, errRead := signedClient.WaitForPipeInputString()
+	if errRead != nil {
+		t.Fatalf("Got error '%s' but expected none", errRead.Error())
+	}
+	header, _, _ := commonbl.SplitResponse(response)
+	if !commonbl.CheckResponseHeader(header, commonbl.SHARE_REQUEST, 2) {
+		t.Errorf("The response '%s' is not the response to the signed request", header)
+	}
+}
@@ -0,0 +1,30 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import "fmt"
+
+// CgroupData - The resource usage of a cgroup samba runs in, as given in the files of the cgroup v2 hierarchy.
+// A controller not enabled for the cgroup gives 0 for its values
+type CgroupData struct {
+	// The path of the cgroup below the cgroup root, e. g. 'system.slice/smbd.service'
+	Path                string  `json:"path"`
+	CpuUsageSeconds     float64 `json:"cpu_usage_seconds"`
+	CpuUserSeconds      float64 `json:"cpu_user_seconds"`
+	CpuSystemSeconds    float64 `json:"cpu_system_seconds"`
+	CpuThrottledSeconds float64 `json:"cpu_throttled_seconds"`
+	MemoryUsageBytes    uint64  `json:"memory_usage_bytes"`
+	IoReadBytes         uint64  `json:"io_read_bytes"`
+	IoWriteBytes        uint64  `json:"io_write_bytes"`
+	TaskCount           uint64  `json:"task_count"`
+}
+
+// Implement Stringer Interface for CgroupData
+func (data CgroupData) String() string {
+	return fmt.Sprintf("Path: %s; CPU Usage Seconds: %f; CPU User Seconds: %f; CPU System Seconds: %f; CPU Throttled Seconds: %f; Memory Usage Bytes: %d; IO Read Bytes: %d; IO Write Bytes: %d; Task Count: %d",
+		data.Path, data.CpuUsageSeconds, data.CpuUserSeconds, data.CpuSystemSeconds, data.CpuThrottledSeconds,
+		data.MemoryUsageBytes, data.IoReadBytes, data.IoWriteBytes, data.TaskCount)
+}
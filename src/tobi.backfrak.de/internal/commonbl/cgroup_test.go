@@ -0,0 +1,35 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCgroupDataJson(t *testing.T) {
+	var data []CgroupData
+	errConv := json.Unmarshal([]byte(TestCgroupResponse()), &data)
+	if errConv != nil {
+		t.Fatalf("Got error '%s' but expected none", errConv.Error())
+	}
+
+	if len(data) != 1 || data[0] != GetTestCgroupData()[0] {
+		t.Errorf("The cgroups '%v' are not the test data", data)
+	}
+
+	if !strings.Contains(TestCgroupResponse(), `"memory_usage_bytes": 104857600`) {
+		t.Errorf("The response '%s' does not contain the memory usage", TestCgroupResponse())
+	}
+}
+
+func TestCgroupDataString(t *testing.T) {
+	str := GetTestCgroupData()[0].String()
+	if !strings.HasPrefix(str, "Path: system.slice/smbd.service;") || !strings.Contains(str, "Task Count: 12") {
+		t.Errorf("The string '%s' is not expected", str)
+	}
+}
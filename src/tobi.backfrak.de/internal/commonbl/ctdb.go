@@ -0,0 +1,21 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import "fmt"
+
+// CtdbNode - A node of the CTDB cluster, as given by 'ctdb listnodes'
+type CtdbNode struct {
+	// The number of the node, the cluster node ID smbstatus prints in front of the PIDs
+	Node int `json:"node"`
+	// The address the node is configured with in the CTDB nodes file
+	Address string `json:"address"`
+}
+
+// Implement Stringer Interface for CtdbNode
+func (node CtdbNode) String() string {
+	return fmt.Sprintf("Node: %d; Address: %s", node.Node, node.Address)
+}
@@ -0,0 +1,87 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DedupLogger - A Logger writing each error message at most once per interval, so an error repeating on every scrape does not
+// flood the log. The repetitions within the interval are counted and written together with the message, once the interval passed.
+// The other messages are written by the wrapped Logger as they are
+type DedupLogger struct {
+	Logger
+	interval time.Duration
+	mMutex   sync.Mutex
+	written  map[string]*dedupEntry
+	// Returns the current time, replaced in the tests
+	now func() time.Time
+}
+
+// dedupEntry - The time an error message was written last, and the number of repetitions suppressed since then
+type dedupEntry struct {
+	lastWritten time.Time
+	suppressed  int
+}
+
+// NewDedupLogger - Get a new instance of the DedupLogger, writing each error message of the logger at most once per interval
+func NewDedupLogger(logger Logger, interval time.Duration) *DedupLogger {
+	ret := DedupLogger{Logger: logger, interval: interval, now: time.Now}
+	ret.written = make(map[string]*dedupEntry)
+
+	return &ret
+}
+
+// WriteErrorMessage - Write the message to Stderr, when it was not written within the interval. The Message will be prefixed with "Error: "
+func (logger *DedupLogger) WriteErrorMessage(message string) {
+	logger.mMutex.Lock()
+	defer logger.mMutex.Unlock()
+
+	now := logger.now()
+	entry, found := logger.written[message]
+	if found && now.Sub(entry.lastWritten) < logger.interval {
+		entry.suppressed++
+		return
+	}
+	if found && entry.suppressed > 0 {
+		logger.Logger.WriteErrorMessage(withRepetitions(message, entry))
+	} else {
+		logger.Logger.WriteErrorMessage(message)
+	}
+	logger.written[message] = &dedupEntry{lastWritten: now}
+	logger.flushExpired(now)
+}
+
+// WriteError - Writes the err.Error() output to Stderr, when it was not written within the interval
+func (logger *DedupLogger) WriteError(err error) {
+	logger.WriteErrorMessage(err.Error())
+}
+
+// WriteErrorWithAddition - Writes the 'err.Error() - addition' output to Stderr, when it was not written within the interval
+func (logger *DedupLogger) WriteErrorWithAddition(err error, addition string) {
+	logger.WriteErrorMessage(fmt.Sprintf("%s - %s", err.Error(), addition))
+}
+
+// flushExpired - Forget the messages not written within the interval, so the map does not grow with messages not repeating.
+// The repetitions of a forgotten message are written before
+func (logger *DedupLogger) flushExpired(now time.Time) {
+	for message, entry := range logger.written {
+		if now.Sub(entry.lastWritten) < logger.interval {
+			continue
+		}
+		if entry.suppressed > 0 {
+			logger.Logger.WriteErrorMessage(withRepetitions(message, entry))
+		}
+		delete(logger.written, message)
+	}
+}
+
+// withRepetitions - Add the number of repetitions suppressed since the message was written last
+func withRepetitions(message string, entry *dedupEntry) string {
+	return fmt.Sprintf("%s (repeated %d times since %s)", message, entry.suppressed, entry.lastWritten.Format(time.RFC3339))
+}
@@ -0,0 +1,93 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingLogger - A Logger keeping the error and verbose messages written
+type recordingLogger struct {
+	ConsoleLogger
+	errors   []string
+	verboses []string
+}
+
+func (logger *recordingLogger) WriteErrorMessage(message string) {
+	logger.errors = append(logger.errors, message)
+}
+
+func (logger *recordingLogger) WriteVerbose(message string) {
+	logger.verboses = append(logger.verboses, message)
+}
+
+func TestDedupLogger(t *testing.T) {
+	recorder := recordingLogger{}
+	sut := NewDedupLogger(&recorder, 5*time.Minute)
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	sut.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		sut.WriteErrorWithAddition(fmt.Errorf("Not able to parse the time stamp"), "in the locks table")
+		now = now.Add(time.Minute)
+	}
+	sut.WriteErrorMessage("samba_statusd is not reachable")
+	if len(recorder.errors) != 2 {
+		t.Fatalf("Got '%d' errors, but expected each message once: %v", len(recorder.errors), recorder.errors)
+	}
+
+	now = start.Add(6 * time.Minute)
+	sut.WriteError(fmt.Errorf("Not able to parse the time stamp - in the locks table"))
+	if len(recorder.errors) != 3 {
+		t.Fatalf("Got '%d' errors, but expected the repeated message once the interval passed: %v", len(recorder.errors), recorder.errors)
+	}
+	expected := "Not able to parse the time stamp - in the locks table (repeated 3 times since 2024-03-01T10:00:00Z)"
+	if recorder.errors[2] != expected {
+		t.Errorf("Got the message '%s', but expected '%s'", recorder.errors[2], expected)
+	}
+
+	// The message written last gets no repetition count, when it did not repeat
+	now = start.Add(20 * time.Minute)
+	sut.WriteErrorMessage("samba_statusd is not reachable")
+	if len(recorder.errors) != 4 || recorder.errors[3] != "samba_statusd is not reachable" {
+		t.Errorf("Got the messages '%v', but expected the message without repetitions last", recorder.errors)
+	}
+}
+
+func TestDedupLoggerFlushExpired(t *testing.T) {
+	recorder := recordingLogger{}
+	sut := NewDedupLogger(&recorder, time.Minute)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	sut.now = func() time.Time { return now }
+
+	sut.WriteErrorMessage("first")
+	sut.WriteErrorMessage("first")
+	now = now.Add(2 * time.Minute)
+	sut.WriteErrorMessage("second")
+
+	if len(recorder.errors) != 3 || !strings.HasPrefix(recorder.errors[2], "first (repeated 1 times since ") {
+		t.Errorf("Got the messages '%v', but expected the repetitions of 'first' once it expired", recorder.errors)
+	}
+	if len(sut.written) != 1 {
+		t.Errorf("Got '%d' messages remembered, but expected only the one not expired", len(sut.written))
+	}
+}
+
+func TestDedupLoggerPassesOtherMessages(t *testing.T) {
+	recorder := recordingLogger{}
+	recorder.Verbose = true
+	sut := NewDedupLogger(&recorder, time.Minute)
+
+	if !sut.GetVerbose() {
+		t.Errorf("The DedupLogger is not verbose, but the wrapped logger is")
+	}
+	var logger Logger = sut
+	logger.WriteInformation("Information of the dedup logger test")
+}
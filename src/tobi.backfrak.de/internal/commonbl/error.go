@@ -99,3 +99,53 @@ func NewDirectoryNotExistError(directoryPath string) *DirectoryNotExistError {
 func (e *DirectoryNotExistError) Error() string { // Implement the Error Interface for the DirectoryNotExistError struct
 	return fmt.Sprintf("Error: %s", e.err)
 }
+
+// InvalidSignatureError - Error when a received message has no valid signature
+type InvalidSignatureError struct {
+	err string
+	// Message - The message with the invalid signature
+	Message string
+}
+
+func (e *InvalidSignatureError) Error() string { // Implement the Error Interface for the InvalidSignatureError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewInvalidSignatureError - Get a new InvalidSignatureError struct
+func NewInvalidSignatureError(message string) *InvalidSignatureError {
+	return &InvalidSignatureError{fmt.Sprintf("The message \"%s\" has no valid signature", message), message}
+}
+
+// ReplayedMessageError - Error when a received message has a valid signature, but was signed too long ago or was received before
+type ReplayedMessageError struct {
+	err string
+	// Message - The replayed message
+	Message string
+}
+
+func (e *ReplayedMessageError) Error() string { // Implement the Error Interface for the ReplayedMessageError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewReplayedMessageError - Get a new ReplayedMessageError struct
+func NewReplayedMessageError(message string) *ReplayedMessageError {
+	return &ReplayedMessageError{fmt.Sprintf("The message \"%s\" is outdated or was received before", message), message}
+}
+
+// MessageTooLargeError - Error when a received message is larger than the maximum message size. The message is discarded
+type MessageTooLargeError struct {
+	err string
+	// Size - The size of the message in bytes
+	Size int
+	// MaxSize - The maximum size of a message in bytes
+	MaxSize int
+}
+
+func (e *MessageTooLargeError) Error() string { // Implement the Error Interface for the MessageTooLargeError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewMessageTooLargeError - Get a new MessageTooLargeError struct
+func NewMessageTooLargeError(size int, maxSize int) *MessageTooLargeError {
+	return &MessageTooLargeError{fmt.Sprintf("The received message with %d bytes is larger than the maximum of %d bytes and was discarded", size, maxSize), size, maxSize}
+}
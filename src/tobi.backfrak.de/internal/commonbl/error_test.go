@@ -80,3 +80,41 @@ func TestDirectoryNotExistError(t *testing.T) {
 		t.Errorf("The error message of DirectoryNotExistError does not contain the expected data")
 	}
 }
+
+func TestInvalidSignatureError(t *testing.T) {
+	message := "LOCK_REQUEST: 12"
+	err := NewInvalidSignatureError(message)
+
+	if err.Message != message {
+		t.Errorf("The Message was %s, but %s was expected", err.Message, message)
+	}
+
+	if strings.Contains(err.Error(), message) == false {
+		t.Errorf("The error message of InvalidSignatureError does not contain the expected message")
+	}
+}
+
+func TestReplayedMessageError(t *testing.T) {
+	message := "LOCK_REQUEST: 12"
+	err := NewReplayedMessageError(message)
+
+	if err.Message != message {
+		t.Errorf("The Message was %s, but %s was expected", err.Message, message)
+	}
+
+	if strings.Contains(err.Error(), message) == false {
+		t.Errorf("The error message of ReplayedMessageError does not contain the expected message")
+	}
+}
+
+func TestMessageTooLargeError(t *testing.T) {
+	err := NewMessageTooLargeError(2048, 1024)
+
+	if err.Size != 2048 || err.MaxSize != 1024 {
+		t.Errorf("The MessageTooLargeError has the size '%d' and maximum '%d', but expected '2048' and '1024'", err.Size, err.MaxSize)
+	}
+
+	if !strings.Contains(err.Error(), "1024 bytes") {
+		t.Errorf("The error message of MessageTooLargeError does not contain the maximum")
+	}
+}
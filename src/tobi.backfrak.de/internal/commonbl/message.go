@@ -0,0 +1,58 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bufio"
+	"io"
+)
+
+// MESSAGE_CHUNK_SIZE - The number of bytes written at once, so a large message is not copied as a whole before it is sent
+const MESSAGE_CHUNK_SIZE = 64 * 1024
+
+// readMessage - Read the next message up to the end byte chunk by chunk. When maxSize is greater 0 and the message is larger,
+// the rest of the message is read without keeping it, so the next message can be read, and a MessageTooLargeError is returned.
+// The end byte is not part of the returned message
+func readMessage(reader *bufio.Reader, maxSize int) ([]byte, error) {
+	var message []byte
+	size := 0
+	for {
+		chunk, errRead := reader.ReadSlice(endByte)
+		if errRead == nil {
+			chunk = chunk[0 : len(chunk)-1]
+		}
+		size += len(chunk)
+		tooLarge := maxSize > 0 && size > maxSize
+		if tooLarge {
+			message = nil
+		} else {
+			message = append(message, chunk...)
+		}
+		if errRead == bufio.ErrBufferFull {
+			continue
+		}
+
+		if tooLarge && (errRead == nil || errRead == io.EOF) {
+			return []byte{}, NewMessageTooLargeError(size, maxSize)
+		}
+
+		return message, errRead
+	}
+}
+
+// writeMessage - Write the message followed by the end byte. The writer passes the message on in chunks of its buffer size
+func writeMessage(writer *bufio.Writer, data string) error {
+	_, errWrite := writer.WriteString(data)
+	if errWrite != nil {
+		return errWrite
+	}
+	errWrite = writer.WriteByte(endByte)
+	if errWrite != nil {
+		return errWrite
+	}
+
+	return writer.Flush()
+}
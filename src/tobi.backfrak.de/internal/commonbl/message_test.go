@@ -0,0 +1,88 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadMessage(t *testing.T) {
+	large := strings.Repeat("LOCK_REQUEST: 1\n", 1000)
+	input := bytes.NewBufferString(large + string(endByte) + "small" + string(endByte) + large + string(endByte) + "last" + string(endByte))
+	// The smallest buffer of a bufio.Reader, so the large messages are read in many chunks
+	reader := bufio.NewReaderSize(input, 16)
+
+	message, err := readMessage(reader, 0)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if string(message) != large {
+		t.Errorf("Got a message with %d bytes, but expected %d bytes", len(message), len(large))
+	}
+
+	message, err = readMessage(reader, 100)
+	if err != nil || string(message) != "small" {
+		t.Errorf("Got the message '%s' and error '%v', but expected 'small' and no error", message, err)
+	}
+
+	_, err = readMessage(reader, 100)
+	switch err.(type) {
+	case *MessageTooLargeError:
+		if err.(*MessageTooLargeError).Size != len(large) {
+			t.Errorf("Got the size '%d' in the error, but expected '%d'", err.(*MessageTooLargeError).Size, len(large))
+		}
+	default:
+		t.Errorf("Got error '%v' but expected a MessageTooLargeError", err)
+	}
+
+	// The message after the discarded one is read as it is
+	message, err = readMessage(reader, 100)
+	if err != nil || string(message) != "last" {
+		t.Errorf("Got the message '%s' and error '%v', but expected 'last' and no error", message, err)
+	}
+}
+
+func TestWriteMessage(t *testing.T) {
+	var output bytes.Buffer
+	data := strings.Repeat("x", MESSAGE_CHUNK_SIZE*2+10)
+
+	err := writeMessage(bufio.NewWriterSize(&output, MESSAGE_CHUNK_SIZE), data)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if output.Len() != len(data)+1 || output.Bytes()[output.Len()-1] != endByte {
+		t.Errorf("Got '%d' bytes written, but expected the data and the end byte", output.Len())
+	}
+}
+
+func TestTcpHandlerMaxMessageSize(t *testing.T) {
+	listener, errListen := ListenTcp("127.0.0.1:0", nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+	go echoServer(listener)
+
+	client := NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+	client.MaxMessageSize = 1024
+	errWrite := client.WritePipeString(strings.Repeat("LOCK_REQUEST: 1\n", 1000))
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+	_, errRead := client.WaitForPipeInputString()
+	switch errRead.(type) {
+	case *MessageTooLargeError:
+	default:
+		t.Errorf("Got error '%v' but expected a MessageTooLargeError", errRead)
+	}
+
+	// The connection is still used for the next messages
+	sendAndReceive(t, client, "SHARE_REQUEST: 2")
+}
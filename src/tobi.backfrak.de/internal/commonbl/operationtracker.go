@@ -0,0 +1,64 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"sync"
+	"time"
+)
+
+// OperationTracker - Tracks the start time of running operations, so operations that hang can be detected
+type OperationTracker struct {
+	mMutex  sync.Mutex
+	running map[int]time.Time
+	nextId  int
+}
+
+// NewOperationTracker - Get a new instance of the OperationTracker
+func NewOperationTracker() *OperationTracker {
+	ret := OperationTracker{}
+	ret.running = make(map[int]time.Time)
+
+	return &ret
+}
+
+// Start - Mark an operation as started. Returns the ID needed to mark the operation as done
+func (tracker *OperationTracker) Start() int {
+	tracker.mMutex.Lock()
+	defer tracker.mMutex.Unlock()
+	tracker.nextId++
+	tracker.running[tracker.nextId] = time.Now()
+
+	return tracker.nextId
+}
+
+// Done - Mark the operation with the given ID as done
+func (tracker *OperationTracker) Done(id int) {
+	tracker.mMutex.Lock()
+	defer tracker.mMutex.Unlock()
+	delete(tracker.running, id)
+}
+
+// LongestRunning - Get the duration the oldest still running operation is running. Zero if no operation is running
+func (tracker *OperationTracker) LongestRunning() time.Duration {
+	tracker.mMutex.Lock()
+	defer tracker.mMutex.Unlock()
+
+	var ret time.Duration
+	for _, started := range tracker.running {
+		running := time.Since(started)
+		if running > ret {
+			ret = running
+		}
+	}
+
+	return ret
+}
+
+// IsHealthy - Tell if no operation is running longer than the given timeout
+func (tracker *OperationTracker) IsHealthy(timeout time.Duration) bool {
+	return tracker.LongestRunning() < timeout
+}
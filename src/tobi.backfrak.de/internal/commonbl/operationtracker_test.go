@@ -0,0 +1,49 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOperationTracker(t *testing.T) {
+	tracker := NewOperationTracker()
+
+	if tracker.LongestRunning() != 0 {
+		t.Errorf("LongestRunning is '%s' but no operation was started", tracker.LongestRunning())
+	}
+
+	first := tracker.Start()
+	time.Sleep(20 * time.Millisecond)
+	second := tracker.Start()
+
+	if first == second {
+		t.Errorf("Two operations got the same ID '%d'", first)
+	}
+
+	if tracker.LongestRunning() < 20*time.Millisecond {
+		t.Errorf("LongestRunning is '%s' but expected at least 20ms", tracker.LongestRunning())
+	}
+
+	if tracker.IsHealthy(10 * time.Millisecond) {
+		t.Errorf("The tracker is healthy, but an operation runs longer than the timeout")
+	}
+
+	if !tracker.IsHealthy(time.Minute) {
+		t.Errorf("The tracker is not healthy, but no operation runs longer than the timeout")
+	}
+
+	tracker.Done(first)
+	if tracker.LongestRunning() >= 20*time.Millisecond {
+		t.Errorf("LongestRunning is '%s' but the long running operation is done", tracker.LongestRunning())
+	}
+
+	tracker.Done(second)
+	if tracker.LongestRunning() != 0 {
+		t.Errorf("LongestRunning is '%s' but all operations are done", tracker.LongestRunning())
+	}
+}
@@ -5,6 +5,11 @@ package commonbl
 // by a BSD-style license that can be found in the
 // LICENSE file.
 
+import (
+	"os"
+	"strings"
+)
+
 // Parmeters - Data structure that stores the common paramters for the executables in this appalication
 type Parmeters struct {
 	PrintVersion bool
@@ -13,3 +18,32 @@ type Parmeters struct {
 	Test         bool
 	LogFilePath  string
 }
+
+// ReadArgsFromEnvironmentFile - Read the arguments stored in the variable with the given name out of a
+// systemd environment file, like '/etc/default/samba_statusd'. In case the variable is set multiple times, the last value is used.
+// The value is split at white spaces, like systemd splits the $ARGS of the ExecStart. Quotes within the value are not interpreted,
+// so an argument can not contain white spaces. Returns an empty list in case the variable is not set in the file
+func ReadArgsFromEnvironmentFile(filePath string, variableName string) ([]string, error) {
+	content, errRead := os.ReadFile(filePath)
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	value := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, variableName+"=") {
+			value = strings.TrimPrefix(line, variableName+"=")
+			// Only the quotes around the whole value are removed
+			if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+				value = value[1 : len(value)-1]
+			}
+		}
+	}
+
+	return strings.Fields(value), nil
+}
@@ -0,0 +1,77 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testEnvironmentFile = `# Set the command-line arguments to pass to the samba_statusd daemon
+
+# The samba_statusd running without args by default
+ARGS=''
+
+# The samba_statusd running with verbose output
+# ARGS='-verbose'
+ARGS='-verbose   -smbstatus-path=/usr/bin/smbstatus'
+OTHER="-test-mode"
+QUOTED='-smbstatus.locale="C UTF-8"'
+`
+
+func TestReadArgsFromEnvironmentFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "samba_statusd")
+	if err := os.WriteFile(filePath, []byte(testEnvironmentFile), 0644); err != nil {
+		t.Fatalf("Got error '%s' while writing the test file", err.Error())
+	}
+
+	args, err := ReadArgsFromEnvironmentFile(filePath, "ARGS")
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(args) != 2 {
+		t.Fatalf("Got '%d' arguments but expected '2'", len(args))
+	}
+
+	if args[0] != "-verbose" || args[1] != "-smbstatus-path=/usr/bin/smbstatus" {
+		t.Errorf("The arguments '%v' are not the expected", args)
+	}
+
+	args, err = ReadArgsFromEnvironmentFile(filePath, "OTHER")
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(args) != 1 || args[0] != "-test-mode" {
+		t.Errorf("The arguments '%v' are not the expected", args)
+	}
+
+	// Like systemd, the quotes within the value are not interpreted
+	args, err = ReadArgsFromEnvironmentFile(filePath, "QUOTED")
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(args) != 2 || args[0] != "-smbstatus.locale=\"C" || args[1] != "UTF-8\"" {
+		t.Errorf("The arguments '%v' are not the expected", args)
+	}
+
+	args, err = ReadArgsFromEnvironmentFile(filePath, "NOT_SET")
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(args) != 0 {
+		t.Errorf("Got '%d' arguments but expected '0'", len(args))
+	}
+
+	_, err = ReadArgsFromEnvironmentFile(filepath.Join(t.TempDir(), "not-existing"), "ARGS")
+	if err == nil {
+		t.Errorf("Got no error but expected one")
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/user"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -29,26 +31,115 @@ const (
 	ResposePipe PipeTypeT = "RESPONSE_PIPE"
 )
 
+// PipeSettings - The location and the permissions of the named pipes
+type PipeSettings struct {
+	// Directory - The directory of the pipes. When empty, /run or /dev/shm in test mode is used
+	Directory string
+	// Mode - The file mode of the pipes
+	Mode os.FileMode
+	// Uid - The user ID of the pipes owner, -1 to keep the owner
+	Uid int
+	// Gid - The group ID of the pipes, -1 to keep the group
+	Gid int
+}
+
 // PipeHandler - Type to handle the pipe for comunication between samba_exporter and samba_statusd
 type PipeHandler struct {
 	TestMode bool
 	PipeType PipeTypeT
+	Settings PipeSettings
 	mMutext  sync.Mutex
+
+	// MaxMessageSize - The maximum size of a received message in bytes, larger messages are discarded. No limit when 0
+	MaxMessageSize int
 }
 
 // NewPipeHandler - Get a new instance of the PipeHandler type
 func NewPipeHandler(testMode bool, pipeType PipeTypeT) *PipeHandler {
+	return NewPipeHandlerWithSettings(testMode, pipeType, NewDefaultPipeSettings())
+}
+
+// NewPipeHandlerWithSettings - Get a new instance of the PipeHandler type, using the given location and permissions for the pipe
+func NewPipeHandlerWithSettings(testMode bool, pipeType PipeTypeT, settings PipeSettings) *PipeHandler {
 	retVal := PipeHandler{}
 	retVal.TestMode = testMode
 	retVal.PipeType = pipeType
+	retVal.Settings = settings
 
 	return &retVal
 }
 
+// NewDefaultPipeSettings - Get the PipeSettings used when nothing else is configured
+func NewDefaultPipeSettings() PipeSettings {
+	return PipeSettings{"", pipePermission, -1, -1}
+}
+
+// NewPipeSettings - Get the PipeSettings for the directory, the octal file mode and the owner and group given as name or ID.
+// Empty values keep the defaults
+func NewPipeSettings(directory string, mode string, owner string, group string) (PipeSettings, error) {
+	settings := NewDefaultPipeSettings()
+	settings.Directory = directory
+
+	if mode != "" {
+		parsedMode, errParse := strconv.ParseUint(mode, 8, 32)
+		if errParse != nil || parsedMode > 0777 {
+			return settings, fmt.Errorf("The pipe mode \"%s\" is not an octal file mode like '0660'", mode)
+		}
+		settings.Mode = os.FileMode(parsedMode)
+	}
+
+	if owner != "" {
+		uid, errLookup := lookupId(owner, func(name string) (string, error) {
+			found, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return found.Uid, nil
+		})
+		if errLookup != nil {
+			return settings, errLookup
+		}
+		settings.Uid = uid
+	}
+
+	if group != "" {
+		gid, errLookup := lookupId(group, func(name string) (string, error) {
+			found, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return found.Gid, nil
+		})
+		if errLookup != nil {
+			return settings, errLookup
+		}
+		settings.Gid = gid
+	}
+
+	return settings, nil
+}
+
+// lookupId - Get the ID of a user or group given as name or ID
+func lookupId(nameOrId string, lookup func(string) (string, error)) (int, error) {
+	id, errConv := strconv.Atoi(nameOrId)
+	if errConv == nil {
+		return id, nil
+	}
+
+	idStr, errLookup := lookup(nameOrId)
+	if errLookup != nil {
+		return -1, errLookup
+	}
+
+	return strconv.Atoi(idStr)
+}
+
 // GetPipeFilePath -  Get the path to the named pipe files for this application
 func (handler *PipeHandler) GetPipeFilePath() string {
 	var dirname string
-	if handler.TestMode {
+	if handler.Settings.Directory != "" {
+		dirname = handler.Settings.Directory
+	} else if handler.TestMode {
 		dirname = testPipePath
 	} else {
 		dirname = pipePath
@@ -79,15 +170,12 @@ func (handler *PipeHandler) WaitForPipeInputBytes() ([]byte, error) {
 	if errGet != nil {
 		return []byte{}, errGet
 	}
-	received, errRead := reader.ReadBytes(endByte)
-	if errRead != nil {
-		if errRead != io.EOF {
-			return []byte{}, errRead
-		}
-		return received, nil
+	received, errRead := readMessage(reader, handler.MaxMessageSize)
+	if errRead != nil && errRead != io.EOF {
+		return []byte{}, errRead
 	}
 
-	return received[0 : len(received)-1], nil
+	return received, nil
 }
 
 // WaitForPipeInputString - Blocking! Wait for input in the pipe and return it as string
@@ -100,6 +188,11 @@ func (handler *PipeHandler) WaitForPipeInputString() (string, error) {
 
 // WritePipeBytes - Write byte data to the pipe
 func (handler *PipeHandler) WritePipeBytes(data []byte) error {
+	return handler.WritePipeString(string(data))
+}
+
+// WritePipeString - Write string data to the pipe, in chunks of MESSAGE_CHUNK_SIZE
+func (handler *PipeHandler) WritePipeString(data string) error {
 	handler.mMutext.Lock()
 	defer handler.mMutext.Unlock()
 
@@ -107,22 +200,8 @@ func (handler *PipeHandler) WritePipeBytes(data []byte) error {
 	if errGet != nil {
 		return errGet
 	}
-	data = append(data, endByte)
-	_, errWrite := writer.Write(data)
-	if errWrite != nil {
-		return errWrite
-	}
-	errFlush := writer.Flush()
-	if errFlush != nil {
-		return errFlush
-	}
 
-	return nil
-}
-
-// WritePipeString - Write string data to the pipe
-func (handler *PipeHandler) WritePipeString(data string) error {
-	return handler.WritePipeBytes([]byte(data))
+	return writeMessage(writer, data)
 }
 
 // FileExists - Check if a file exists. Return false in case the path does not exist or is a directory
@@ -168,14 +247,45 @@ func (handler *PipeHandler) getWriterPipe() (*bufio.Writer, error) {
 		}
 	}
 
-	file, errOpen := os.OpenFile(handler.GetPipeFilePath(), os.O_RDWR|os.O_CREATE, pipePermission)
+	file, errOpen := os.OpenFile(handler.GetPipeFilePath(), os.O_RDWR|os.O_CREATE, handler.Settings.Mode)
 	if errOpen != nil {
 		return nil, errOpen
 	}
 
-	return bufio.NewWriter(file), nil
+	return bufio.NewWriterSize(file, MESSAGE_CHUNK_SIZE), nil
+}
+
+// PreparePipe - Create the pipe when it not exists and set its mode, owner and group
+func (handler *PipeHandler) PreparePipe() error {
+	handler.mMutext.Lock()
+	defer handler.mMutext.Unlock()
+
+	if !handler.PipeExists() {
+		return handler.createPipe()
+	}
+
+	return handler.setPermissions()
 }
 
 func (handler *PipeHandler) createPipe() error {
-	return syscall.Mkfifo(handler.GetPipeFilePath(), pipePermission)
+	errCreate := syscall.Mkfifo(handler.GetPipeFilePath(), uint32(handler.Settings.Mode))
+	if errCreate != nil {
+		return errCreate
+	}
+
+	return handler.setPermissions()
+}
+
+// setPermissions - Set the mode of the pipe, since the mode given to Mkfifo is reduced by the umask, and change the owner and group when configured
+func (handler *PipeHandler) setPermissions() error {
+	errMode := os.Chmod(handler.GetPipeFilePath(), handler.Settings.Mode)
+	if errMode != nil {
+		return errMode
+	}
+
+	if handler.Settings.Uid == -1 && handler.Settings.Gid == -1 {
+		return nil
+	}
+
+	return os.Chown(handler.GetPipeFilePath(), handler.Settings.Uid, handler.Settings.Gid)
 }
@@ -7,6 +7,9 @@ package commonbl
 
 import (
 	"os"
+	"os/user"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -185,3 +188,98 @@ func scheduleStringWriter(t *testing.T) {
 		t.Errorf("Got error \"%s\" but expected none", err)
 	}
 }
+
+func TestNewPipeSettings(t *testing.T) {
+	settings, err := NewPipeSettings("", "", "", "")
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if settings != NewDefaultPipeSettings() {
+		t.Errorf("Got the settings '%v' but expected the default settings", settings)
+	}
+
+	currentUser, errUser := user.Current()
+	if errUser != nil {
+		t.Fatalf("Got error '%s' but expected none", errUser.Error())
+	}
+	currentGroup, errGroup := user.LookupGroupId(currentUser.Gid)
+	if errGroup != nil {
+		t.Fatalf("Got error '%s' but expected none", errGroup.Error())
+	}
+
+	settings, err = NewPipeSettings("/some/dir", "0640", currentUser.Username, currentGroup.Name)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if settings.Directory != "/some/dir" || settings.Mode != 0640 || strconv.Itoa(settings.Uid) != currentUser.Uid || strconv.Itoa(settings.Gid) != currentUser.Gid {
+		t.Errorf("Got the settings '%v', but expected the given values", settings)
+	}
+
+	settings, err = NewPipeSettings("", "", "1234", "5678")
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if settings.Uid != 1234 || settings.Gid != 5678 {
+		t.Errorf("Got the settings '%v', but expected the given IDs", settings)
+	}
+
+	for _, mode := range []string{"rw-rw----", "0999", "07777"} {
+		_, err = NewPipeSettings("", mode, "", "")
+		if err == nil {
+			t.Errorf("Got no error for the mode '%s', but expected one", mode)
+		}
+	}
+
+	_, err = NewPipeSettings("", "", "not_existing_user_name", "")
+	if err == nil {
+		t.Errorf("Got no error for a not existing user, but expected one")
+	}
+
+	_, err = NewPipeSettings("", "", "", "not_existing_group_name")
+	if err == nil {
+		t.Errorf("Got no error for a not existing group, but expected one")
+	}
+}
+
+func TestPreparePipe(t *testing.T) {
+	currentUser, errUser := user.Current()
+	if errUser != nil {
+		t.Fatalf("Got error '%s' but expected none", errUser.Error())
+	}
+	settings, errSettings := NewPipeSettings(t.TempDir(), "0600", currentUser.Uid, currentUser.Gid)
+	if errSettings != nil {
+		t.Fatalf("Got error '%s' but expected none", errSettings.Error())
+	}
+	handler := NewPipeHandlerWithSettings(true, RequestPipe, settings)
+
+	if !strings.HasPrefix(handler.GetPipeFilePath(), settings.Directory) {
+		t.Errorf("The pipe '%s' is not in the directory '%s'", handler.GetPipeFilePath(), settings.Directory)
+	}
+
+	errPrepare := handler.PreparePipe()
+	if errPrepare != nil {
+		t.Fatalf("Got error '%s' but expected none", errPrepare.Error())
+	}
+
+	info, errStat := os.Stat(handler.GetPipeFilePath())
+	if errStat != nil {
+		t.Fatalf("Got error '%s' but expected none", errStat.Error())
+	}
+	if info.Mode()&os.ModeNamedPipe == 0 {
+		t.Errorf("The file '%s' is not a named pipe", handler.GetPipeFilePath())
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("The pipe has the mode '%s' but expected '-rw-------'", info.Mode().Perm())
+	}
+
+	// The mode of an existing pipe is changed as well
+	handler.Settings.Mode = 0640
+	errPrepare = handler.PreparePipe()
+	if errPrepare != nil {
+		t.Fatalf("Got error '%s' but expected none", errPrepare.Error())
+	}
+	info, _ = os.Stat(handler.GetPipeFilePath())
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("The pipe has the mode '%s' but expected '-rw-r-----'", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,86 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The types a metric of a plugin can have
+const (
+	PLUGIN_METRIC_GAUGE   = "gauge"
+	PLUGIN_METRIC_COUNTER = "counter"
+)
+
+// The names of metrics and labels, as prometheus allows them
+var pluginNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// PluginMetric - A metric a plugin of samba_statusd printed
+type PluginMetric struct {
+	Name   string            `json:"name"`
+	Help   string            `json:"help"`
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// PluginResult - The metrics of one run of a plugin, or the error the run failed with
+type PluginResult struct {
+	Plugin          string         `json:"plugin"`
+	DurationSeconds float64        `json:"duration_seconds"`
+	Error           string         `json:"error,omitempty"`
+	Metrics         []PluginMetric `json:"metrics"`
+}
+
+// Implement Stringer Interface for PluginMetric
+func (metric PluginMetric) String() string {
+	return fmt.Sprintf("Name: %s; Type: %s; Labels: %v; Value: %f", metric.Name, metric.Type, metric.Labels, metric.Value)
+}
+
+// validate - Check if the metric can be exported by samba_exporter
+func (metric PluginMetric) validate() error {
+	if !pluginNameRegex.MatchString(metric.Name) {
+		return fmt.Errorf("the name '%s' is not a valid metric name", metric.Name)
+	}
+	if strings.TrimSpace(metric.Help) == "" {
+		return fmt.Errorf("the metric '%s' has no help text", metric.Name)
+	}
+	if metric.Type != PLUGIN_METRIC_GAUGE && metric.Type != PLUGIN_METRIC_COUNTER {
+		return fmt.Errorf("the type '%s' of the metric '%s' is neither '%s' nor '%s'", metric.Type, metric.Name, PLUGIN_METRIC_GAUGE, PLUGIN_METRIC_COUNTER)
+	}
+	for name := range metric.Labels {
+		if !pluginNameRegex.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("the label '%s' of the metric '%s' is not a valid label name", name, metric.Name)
+		}
+	}
+
+	return nil
+}
+
+// ParsePluginOutput - Get the metrics out of the output of a plugin, a JSON array of objects with the keys 'name', 'help',
+// 'type', 'labels' and 'value'. The type is 'gauge' when not given. Fails when one of the metrics is not valid
+func ParsePluginOutput(output []byte) ([]PluginMetric, error) {
+	var metrics []PluginMetric
+	errConv := json.Unmarshal(output, &metrics)
+	if errConv != nil {
+		return nil, fmt.Errorf("the output is not a JSON array of metrics: %s", errConv.Error())
+	}
+
+	for i := range metrics {
+		if metrics[i].Type == "" {
+			metrics[i].Type = PLUGIN_METRIC_GAUGE
+		}
+		errValidate := metrics[i].validate()
+		if errValidate != nil {
+			return nil, errValidate
+		}
+	}
+
+	return metrics, nil
+}
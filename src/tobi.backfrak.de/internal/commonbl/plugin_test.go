@@ -0,0 +1,64 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+)
+
+func TestParsePluginOutput(t *testing.T) {
+	output := `[
+		{"name": "quota_used_bytes", "help": "Bytes used of the quota", "type": "counter", "labels": {"share": "data"}, "value": 1024},
+		{"name": "backup_age_seconds", "help": "Age of the last backup", "value": 3600.5}
+	]`
+
+	metrics, err := ParsePluginOutput([]byte(output))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(metrics) != 2 {
+		t.Fatalf("Got %d metrics but expected 2", len(metrics))
+	}
+
+	if metrics[0].Type != PLUGIN_METRIC_COUNTER || metrics[0].Labels["share"] != "data" || metrics[0].Value != 1024 {
+		t.Errorf("The metric '%s' is not expected", metrics[0])
+	}
+
+	if metrics[1].Type != PLUGIN_METRIC_GAUGE || len(metrics[1].Labels) != 0 || metrics[1].Value != 3600.5 {
+		t.Errorf("The metric '%s' is not expected", metrics[1])
+	}
+}
+
+func TestParsePluginOutputInvalid(t *testing.T) {
+	outputs := []string{
+		`{"name": "no_array", "help": "Not in an array", "value": 1}`,
+		`[{"name": "1_invalid", "help": "Invalid name", "value": 1}]`,
+		`[{"name": "no_help", "value": 1}]`,
+		`[{"name": "histogram", "help": "Unknown type", "type": "histogram", "value": 1}]`,
+		`[{"name": "reserved_label", "help": "Reserved label name", "labels": {"__name__": "x"}, "value": 1}]`,
+		`[{"name": "string_value", "help": "Value is a string", "value": "1"}]`,
+		``,
+	}
+
+	for _, output := range outputs {
+		_, err := ParsePluginOutput([]byte(output))
+		if err == nil {
+			t.Errorf("Expected an error for the output '%s' but got none", output)
+		}
+	}
+}
+
+func TestParsePluginOutputEmpty(t *testing.T) {
+	metrics, err := ParsePluginOutput([]byte("[]"))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(metrics) != 0 {
+		t.Errorf("Got %d metrics but expected none", len(metrics))
+	}
+}
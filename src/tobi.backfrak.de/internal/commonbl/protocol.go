@@ -25,6 +25,34 @@ const LOCK_REQUEST RequestType = "LOCK_REQUEST:"
 // Request the ps data of the smbd PIDs
 const PS_REQUEST RequestType = "PS_REQUEST:"
 
+// Request the protocol version samba_statusd speaks
+const VERSION_REQUEST RequestType = "VERSION_REQUEST:"
+
+// Request the metrics of the plugins samba_statusd runs
+const PLUGIN_REQUEST RequestType = "PLUGIN_REQUEST:"
+
+// Request the resource usage of the cgroups samba runs in
+const CGROUP_REQUEST RequestType = "CGROUP_REQUEST:"
+
+// Request the nodes of the CTDB cluster samba runs in
+const CTDB_NODES_REQUEST RequestType = "CTDB_NODES_REQUEST:"
+
+// Request the shares configured in the smb.conf
+const SHARE_CONFIG_REQUEST RequestType = "SHARE_CONFIG_REQUEST:"
+
+// Request the open, durable and persistent file handles of each share
+const SHARE_HANDLES_REQUEST RequestType = "SHARE_HANDLES_REQUEST:"
+
+// The data of the response to a request of a collector disabled in samba_statusd. The requests answered with a JSON list get an empty list instead
+const DISABLED_RESPONSE_DATA = "SAMBA_STATUSD_DISABLED"
+
+// The data of a response to a request samba_statusd can not answer starts with this prefix, followed by the error message
+const ERROR_RESPONSE_PREFIX = "SAMBA_STATUSD_ERROR:"
+
+// PROTOCOL_VERSION - The version of the request and response format used between samba_exporter and samba_statusd.
+// Increase it whenever a change makes the format incompatible to older versions
+const PROTOCOL_VERSION = 6
+
 // Normal response when no files are locked
 const NO_LOCKED_FILES = "No locked files"
 
@@ -40,14 +68,25 @@ type PsUtilPidData struct {
 	IoCounterWriteBytes       uint64
 	OpenFilesCount            uint64
 	ThreadCount               uint64
+	// All open file descriptors of the process, the OpenFilesCount plus sockets, pipes and others. 0 when samba_statusd is older
+	FileDescriptorCount uint64
+	// The TCP connections of the process in the state ESTABLISHED. 0 when samba_statusd is older
+	EstablishedConnectionCount uint64
+	// The times the process gave up the CPU while waiting, e. g. for I/O, and the times it was forced to give up the CPU
+	// since it started. Many involuntary context switches point to CPU contention. 0 when samba_statusd is older
+	VoluntaryContextSwitches   uint64
+	InvoluntaryContextSwitches uint64
+	// The soft RLIMIT_NOFILE of the process, the most file descriptors it can open. 0 when samba_statusd is older or the limit is unknown or unlimited
+	FileDescriptorLimit uint64
 }
 
 // Implement Stringer Interface for LockData
 func (pidData PsUtilPidData) String() string {
-	return fmt.Sprintf("PID: %d; CPU Usage Percent: %f; VM Usage Bytes: %d; VM Usage Percent: %f; IO Read Count: %d; IO Read Bytes: %d; IO Write Count: %d; IO Write Bytes: %d; Open File Count: %d; Thread Count: %d",
+	return fmt.Sprintf("PID: %d; CPU Usage Percent: %f; VM Usage Bytes: %d; VM Usage Percent: %f; IO Read Count: %d; IO Read Bytes: %d; IO Write Count: %d; IO Write Bytes: %d; Open File Count: %d; Thread Count: %d; File Descriptor Count: %d; Established Connection Count: %d; Voluntary Context Switches: %d; Involuntary Context Switches: %d; File Descriptor Limit: %d",
 		pidData.PID, pidData.CpuUsagePercent, pidData.VirtualMemoryUsageBytes, pidData.VirtualMemoryUsagePercent,
 		pidData.IoCounterReadCount, pidData.IoCounterReadBytes, pidData.IoCounterWriteCount, pidData.IoCounterWriteBytes,
-		pidData.OpenFilesCount, pidData.ThreadCount)
+		pidData.OpenFilesCount, pidData.ThreadCount, pidData.FileDescriptorCount, pidData.EstablishedConnectionCount,
+		pidData.VoluntaryContextSwitches, pidData.InvoluntaryContextSwitches, pidData.FileDescriptorLimit)
 }
 
 // GetIdFromRequest - Get the ID from a request telegram
@@ -67,6 +106,51 @@ func GetIdFromRequest(request string) (int, error) {
 	return id, nil
 }
 
+// GetVersionResponseData - Get the data of the response to a VERSION_REQUEST
+func GetVersionResponseData(programVersion string) string {
+	return fmt.Sprintf("PROTOCOL_VERSION: %d; PROGRAM_VERSION: %s", PROTOCOL_VERSION, programVersion)
+}
+
+// ParseVersionResponseData - Get the protocol version and the program version out of the data of a VERSION_REQUEST response
+func ParseVersionResponseData(data string) (int, string, error) {
+	splitted := strings.SplitN(strings.TrimSpace(data), ";", 2)
+	if len(splitted) != 2 {
+		return 0, "", NewUnexpectedResponseFormatError(data)
+	}
+
+	protocolStr := strings.TrimSpace(splitted[0])
+	if !strings.HasPrefix(protocolStr, "PROTOCOL_VERSION:") {
+		return 0, "", NewUnexpectedResponseFormatError(data)
+	}
+	protocolVersion, errConv := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(protocolStr, "PROTOCOL_VERSION:")))
+	if errConv != nil {
+		return 0, "", NewUnexpectedResponseFormatError(data)
+	}
+
+	programStr := strings.TrimSpace(splitted[1])
+	if !strings.HasPrefix(programStr, "PROGRAM_VERSION:") {
+		return 0, "", NewUnexpectedResponseFormatError(data)
+	}
+
+	return protocolVersion, strings.TrimSpace(strings.TrimPrefix(programStr, "PROGRAM_VERSION:")), nil
+}
+
+// GetErrorResponseData - Get the data of the response to a request samba_statusd can not answer, e. g. since reading the data failed
+func GetErrorResponseData(message string) string {
+	return fmt.Sprintf("%s %s", ERROR_RESPONSE_PREFIX, message)
+}
+
+// ParseErrorResponseData - Get the error message out of the data of a response, when samba_statusd could not answer the request.
+// Returns false, when the data is no error response
+func ParseErrorResponseData(data string) (string, bool) {
+	trimmed := strings.TrimSpace(data)
+	if !strings.HasPrefix(trimmed, ERROR_RESPONSE_PREFIX) {
+		return "", false
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(trimmed, ERROR_RESPONSE_PREFIX)), true
+}
+
 // GetRequest -  Get the request string
 func GetRequest(requestType RequestType, id int) string {
 	return fmt.Sprintf("%s %d", requestType, id)
@@ -113,7 +197,7 @@ func CheckResponseHeader(header string, rType RequestType, id int) bool {
 		return false
 	}
 
-	if !strings.Contains(header, fmt.Sprintf("Response for request %d", id)) {
+	if !strings.HasSuffix(strings.TrimSpace(header), fmt.Sprintf("Response for request %d", id)) {
 		return false
 	}
 
@@ -208,4 +208,56 @@ func TestCheckResponseHeader(t *testing.T) {
 		t.Errorf("CheckResponseHeader is true, but expected false")
 	}
 
+	if CheckResponseHeader(GetResponseHeader(rType, 123), rType, id) == true {
+		t.Errorf("CheckResponseHeader is true for the response to request 123, but expected false")
+	}
+
+}
+
+func TestParseVersionResponseData(t *testing.T) {
+	protocolVersion, programVersion, err := ParseVersionResponseData(GetVersionResponseData("1.2.3"))
+	if err != nil {
+		t.Fatalf("Got error \"%s\" but expected none", err)
+	}
+
+	if protocolVersion != PROTOCOL_VERSION {
+		t.Errorf("Got protocol version %d but expected %d", protocolVersion, PROTOCOL_VERSION)
+	}
+
+	if programVersion != "1.2.3" {
+		t.Errorf("Got program version \"%s\" but expected \"1.2.3\"", programVersion)
+	}
+}
+
+func TestParseErrorResponseData(t *testing.T) {
+	message, isError := ParseErrorResponseData(GetErrorResponseData("permission denied"))
+	if !isError {
+		t.Fatalf("The error response is not detected")
+	}
+
+	if message != "permission denied" {
+		t.Errorf("Got the message \"%s\" but expected \"permission denied\"", message)
+	}
+
+	for _, data := range []string{"", "[]", TestPsResponse(), GetVersionResponseData("1.2.3")} {
+		if _, isError := ParseErrorResponseData(data); isError {
+			t.Errorf("The data \"%s\" is detected as error response", data)
+		}
+	}
+}
+
+func TestParseVersionResponseDataUnValid(t *testing.T) {
+	for _, data := range []string{"", "PROTOCOL_VERSION: 1", "PROTOCOL_VERSION: abc; PROGRAM_VERSION: 1.2.3", "VERSION: 1; PROGRAM_VERSION: 1.2.3", "PROTOCOL_VERSION: 1; VERSION: 1.2.3"} {
+		_, _, err := ParseVersionResponseData(data)
+		if err == nil {
+			t.Errorf("Expected an error for \"%s\" but got none", data)
+		}
+
+		switch err.(type) {
+		case *UnexpectedResponseFormatError:
+			fmt.Println("OK")
+		default:
+			t.Errorf("Expected an UnexpectedResponseFormatError for \"%s\" but got \"%s\"", data, err)
+		}
+	}
 }
@@ -34,12 +34,12 @@ func (queue *StringQueue) Push(value string) {
 // Pull (get and remove) a string from the StringQueue.
 // Returns an error when the Queue is empty
 func (queue *StringQueue) Pull() (string, error) {
+	queue.mMutex.Lock()
+	defer queue.mMutex.Unlock()
 	if queue.mList.Len() <= 0 {
 		return "", NewEmptyStringQueueError()
 	}
 	var valueString string
-	queue.mMutex.Lock()
-	defer queue.mMutex.Unlock()
 
 	e := queue.mList.Front()
 	valueString = e.Value.(string)
@@ -49,5 +49,7 @@ func (queue *StringQueue) Pull() (string, error) {
 
 // Tell if the StringQueue is empty
 func (queue *StringQueue) IsEmpty() bool {
+	queue.mMutex.Lock()
+	defer queue.mMutex.Unlock()
 	return queue.mList.Len() <= 0
 }
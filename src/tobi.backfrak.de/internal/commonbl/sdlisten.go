@@ -0,0 +1,65 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// The first file descriptor systemd passes with socket activation, the ones before are stdin, stdout and stderr
+const SD_LISTEN_FDS_START = 3
+
+// SdListeners - Get listeners for the sockets systemd passed with socket activation, in the order of the .socket unit.
+// Returns nil when the process is not socket activated. The environment is cleaned, so child processes do not take the sockets
+func SdListeners() ([]net.Listener, error) {
+	return sdListeners(SD_LISTEN_FDS_START)
+}
+
+// sdListeners - Get listeners for the sockets passed by systemd, starting with the file descriptor firstFd
+func sdListeners(firstFd int) ([]net.Listener, error) {
+	pidStr := os.Getenv("LISTEN_PID")
+	fdsStr := os.Getenv("LISTEN_FDS")
+	if pidStr == "" || fdsStr == "" {
+		return nil, nil
+	}
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	// The sockets are meant for the process with this PID only
+	pid, errPid := strconv.Atoi(pidStr)
+	if errPid != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, errFds := strconv.Atoi(fdsStr)
+	if errFds != nil || fds < 0 {
+		return nil, fmt.Errorf("The LISTEN_FDS \"%s\" passed by systemd is not a valid number", fdsStr)
+	}
+
+	listeners := make([]net.Listener, 0, fds)
+	for fd := firstFd; fd < firstFd+fds; fd++ {
+		syscall.CloseOnExec(fd)
+		file := os.NewFile(uintptr(fd), fmt.Sprintf("LISTEN_FD_%d", fd))
+		listener, errListen := net.FileListener(file)
+		// The listener works on a duplicate of the file descriptor
+		file.Close()
+		if errListen != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("The socket %d passed by systemd can not be used to listen: %s", fd, errListen.Error())
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
@@ -0,0 +1,88 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestSdListenersNotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+
+	listeners, err := SdListeners()
+	if err != nil || listeners != nil {
+		t.Errorf("Got the listeners '%v' and error '%v', but expected none", listeners, err)
+	}
+
+	// The sockets of an other process
+	t.Setenv("LISTEN_PID", fmt.Sprintf("%d", os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, err = SdListeners()
+	if err != nil || listeners != nil {
+		t.Errorf("Got the listeners '%v' and error '%v', but expected none", listeners, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Errorf("The LISTEN_FDS are still set")
+	}
+}
+
+func TestSdListeners(t *testing.T) {
+	tcpListener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer tcpListener.Close()
+	// A raw duplicate of the socket, like the one systemd passes. It is closed by sdListeners
+	file, errFile := tcpListener.(*net.TCPListener).File()
+	if errFile != nil {
+		t.Fatalf("Got error '%s' but expected none", errFile.Error())
+	}
+	fd, errDup := syscall.Dup(int(file.Fd()))
+	file.Close()
+	if errDup != nil {
+		t.Fatalf("Got error '%s' but expected none", errDup.Error())
+	}
+
+	t.Setenv("LISTEN_PID", fmt.Sprintf("%d", os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "samba_exporter.socket")
+	listeners, err := sdListeners(fd)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(listeners) != 1 {
+		t.Fatalf("Got '%d' listeners but expected '1'", len(listeners))
+	}
+	defer listeners[0].Close()
+	if listeners[0].Addr().String() != tcpListener.Addr().String() {
+		t.Errorf("Got a listener on '%s' but expected '%s'", listeners[0].Addr(), tcpListener.Addr())
+	}
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		if os.Getenv(name) != "" {
+			t.Errorf("The environment variable %s is still set", name)
+		}
+	}
+
+	conn, errDial := net.Dial("tcp", tcpListener.Addr().String())
+	if errDial != nil {
+		t.Fatalf("Got error '%s' but expected none", errDial.Error())
+	}
+	conn.Close()
+}
+
+func TestSdListenersInvalid(t *testing.T) {
+	t.Setenv("LISTEN_PID", fmt.Sprintf("%d", os.Getpid()))
+	t.Setenv("LISTEN_FDS", "many")
+	_, err := SdListeners()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the LISTEN_FDS is no number")
+	}
+}
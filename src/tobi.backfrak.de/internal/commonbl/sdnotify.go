@@ -0,0 +1,107 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Tell systemd the service startup is finished
+const SD_NOTIFY_READY = "READY=1"
+
+// Tell systemd the service is still alive
+const SD_NOTIFY_WATCHDOG = "WATCHDOG=1"
+
+// Tell systemd the service is going to stop
+const SD_NOTIFY_STOPPING = "STOPPING=1"
+
+// SdNotify - Send a state notification to systemd using the socket given in $NOTIFY_SOCKET.
+// Returns false when the process is not started by systemd with notification support, so nothing was sent
+func SdNotify(state string) (bool, error) {
+	socketAddr := &net.UnixAddr{Name: os.Getenv("NOTIFY_SOCKET"), Net: "unixgram"}
+	if socketAddr.Name == "" {
+		return false, nil
+	}
+
+	conn, errDial := net.DialUnix(socketAddr.Net, nil, socketAddr)
+	if errDial != nil {
+		return false, errDial
+	}
+	defer conn.Close()
+
+	if _, errWrite := conn.Write([]byte(state)); errWrite != nil {
+		return false, errWrite
+	}
+
+	return true, nil
+}
+
+// SdWatchdogInterval - Get the watchdog timeout systemd expects this process to send "WATCHDOG=1" in.
+// Returns false when the watchdog is not enabled for this process
+func SdWatchdogInterval() (time.Duration, bool) {
+	usecStr := os.Getenv("WATCHDOG_USEC")
+	if usecStr == "" {
+		return 0, false
+	}
+
+	// In case WATCHDOG_PID is set, the watchdog is only meant for the process with this PID
+	pidStr := os.Getenv("WATCHDOG_PID")
+	if pidStr != "" {
+		pid, errConv := strconv.Atoi(pidStr)
+		if errConv != nil || pid != os.Getpid() {
+			return 0, false
+		}
+	}
+
+	usec, errConv := strconv.ParseInt(usecStr, 10, 64)
+	if errConv != nil || usec <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// SdWatchdogLoop - Blocking! Send "WATCHDOG=1" to systemd every half watchdog interval, as long as isHealthy returns true.
+// When isHealthy returns false no notification is sent, so systemd will detect the hang and restart the service
+func SdWatchdogLoop(interval time.Duration, isHealthy func(timeout time.Duration) bool, logger Logger) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !isHealthy(interval) {
+			logger.WriteErrorMessage(fmt.Sprintf("Service seems to hang for more than %s, skip sending the watchdog notification to systemd", interval))
+			continue
+		}
+
+		if _, errNotify := SdNotify(SD_NOTIFY_WATCHDOG); errNotify != nil {
+			logger.WriteErrorWithAddition(errNotify, "while sending the watchdog notification to systemd")
+		}
+	}
+}
+
+// StartSdNotifications - Send "READY=1" to systemd and start the watchdog loop in case the watchdog is enabled for this process
+func StartSdNotifications(isHealthy func(timeout time.Duration) bool, logger Logger) {
+	sent, errNotify := SdNotify(SD_NOTIFY_READY)
+	if errNotify != nil {
+		logger.WriteErrorWithAddition(errNotify, "while sending the ready notification to systemd")
+		return
+	}
+	if !sent {
+		logger.WriteVerbose("Not started with systemd notification support, no notifications will be sent")
+		return
+	}
+	logger.WriteVerbose("Sent the ready notification to systemd")
+
+	interval, watchdogEnabled := SdWatchdogInterval()
+	if watchdogEnabled {
+		logger.WriteVerbose(fmt.Sprintf("Systemd watchdog is enabled, send notifications every %s", interval/2))
+		go SdWatchdogLoop(interval, isHealthy, logger)
+	}
+}
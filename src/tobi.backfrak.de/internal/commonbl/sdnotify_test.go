@@ -0,0 +1,139 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func getNotifySocket(t *testing.T) *net.UnixConn {
+	socketAddr := &net.UnixAddr{Name: filepath.Join(t.TempDir(), "notify.sock"), Net: "unixgram"}
+	conn, err := net.ListenUnixgram(socketAddr.Net, socketAddr)
+	if err != nil {
+		t.Fatalf("Got error '%s' while creating the notify socket", err.Error())
+	}
+	t.Setenv("NOTIFY_SOCKET", socketAddr.Name)
+
+	return conn
+}
+
+func readNotification(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 128)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Got error '%s' while reading the notify socket", err.Error())
+	}
+
+	return string(buf[:n])
+}
+
+func TestSdNotifyNoSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	sent, err := SdNotify(SD_NOTIFY_READY)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if sent {
+		t.Errorf("SdNotify tells a notification was sent, but there is no socket")
+	}
+}
+
+func TestSdNotify(t *testing.T) {
+	conn := getNotifySocket(t)
+	defer conn.Close()
+
+	sent, err := SdNotify(SD_NOTIFY_READY)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if !sent {
+		t.Errorf("SdNotify tells no notification was sent, but expected one")
+	}
+
+	received := readNotification(t, conn)
+	if received != SD_NOTIFY_READY {
+		t.Errorf("Received '%s' but expected '%s'", received, SD_NOTIFY_READY)
+	}
+}
+
+func TestSdNotifySocketNotExist(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "not-existing.sock"))
+
+	sent, err := SdNotify(SD_NOTIFY_READY)
+	if err == nil {
+		t.Errorf("Got no error but expected one")
+	}
+
+	if sent {
+		t.Errorf("SdNotify tells a notification was sent, but the socket does not exist")
+	}
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	t.Setenv("WATCHDOG_PID", "")
+	_, enabled := SdWatchdogInterval()
+	if enabled {
+		t.Errorf("The watchdog is enabled, but WATCHDOG_USEC is not set")
+	}
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	interval, enabled := SdWatchdogInterval()
+	if !enabled {
+		t.Errorf("The watchdog is not enabled, but WATCHDOG_USEC is set")
+	}
+
+	if interval != 30*time.Second {
+		t.Errorf("The interval '%s' is not the expected '30s'", interval)
+	}
+
+	t.Setenv("WATCHDOG_PID", fmt.Sprint(os.Getpid()))
+	_, enabled = SdWatchdogInterval()
+	if !enabled {
+		t.Errorf("The watchdog is not enabled, but WATCHDOG_PID is the current process")
+	}
+
+	t.Setenv("WATCHDOG_PID", fmt.Sprint(os.Getpid()+1))
+	_, enabled = SdWatchdogInterval()
+	if enabled {
+		t.Errorf("The watchdog is enabled, but WATCHDOG_PID is an other process")
+	}
+
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "abc")
+	_, enabled = SdWatchdogInterval()
+	if enabled {
+		t.Errorf("The watchdog is enabled, but WATCHDOG_USEC is invalid")
+	}
+}
+
+func TestStartSdNotifications(t *testing.T) {
+	conn := getNotifySocket(t)
+	defer conn.Close()
+	t.Setenv("WATCHDOG_USEC", "100000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	StartSdNotifications(func(timeout time.Duration) bool { return true }, NewConsoleLogger(false))
+
+	received := readNotification(t, conn)
+	if received != SD_NOTIFY_READY {
+		t.Errorf("Received '%s' but expected '%s'", received, SD_NOTIFY_READY)
+	}
+
+	received = readNotification(t, conn)
+	if received != SD_NOTIFY_WATCHDOG {
+		t.Errorf("Received '%s' but expected '%s'", received, SD_NOTIFY_WATCHDOG)
+	}
+}
@@ -0,0 +1,32 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ShareConfig - A share configured in the smb.conf, as printed by 'testparm -s'
+type ShareConfig struct {
+	// The name of the share, the section of the smb.conf
+	Name string `json:"name"`
+	// The parameters of the share testparm printed, by their name in lower case, e. g. 'path' or 'read only'.
+	// Parameters with the default value are not printed by testparm
+	Parameters map[string]string `json:"parameters"`
+}
+
+// Implement Stringer Interface for ShareConfig
+func (config ShareConfig) String() string {
+	parameters := []string{}
+	for name, value := range config.Parameters {
+		parameters = append(parameters, fmt.Sprintf("%s = %s", name, value))
+	}
+	sort.Strings(parameters)
+
+	return fmt.Sprintf("Name: %s; Parameters: %s", config.Name, strings.Join(parameters, ", "))
+}
@@ -0,0 +1,30 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestShareConfigJson(t *testing.T) {
+	var data []ShareConfig
+	errConv := json.Unmarshal([]byte(TestShareConfigResponse()), &data)
+	if errConv != nil {
+		t.Fatalf("Got error '%s' but expected none", errConv.Error())
+	}
+
+	if len(data) != 2 || data[1].Name != "data" || data[1].Parameters["path"] != "/srv/data" {
+		t.Errorf("The shares '%v' are not the test data", data)
+	}
+}
+
+func TestShareConfigString(t *testing.T) {
+	str := GetTestShareConfig()[1].String()
+	if str != "Name: data; Parameters: path = /srv/data, read only = No" {
+		t.Errorf("The string '%s' is not expected", str)
+	}
+}
@@ -0,0 +1,25 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import "fmt"
+
+// ShareHandles - The open file handles of a share, as printed by 'smbstatus -L --json'
+type ShareHandles struct {
+	// The path of the share, like the SharePath of the locks table
+	Share string `json:"share"`
+	// All open file handles on the share
+	OpenCount int `json:"open_count"`
+	// The handles a client can reclaim after a short network outage
+	DurableCount int `json:"durable_count"`
+	// The handles a client can reclaim on another node of a cluster, used for SMB3 continuous availability
+	PersistentCount int `json:"persistent_count"`
+}
+
+// Implement Stringer Interface for ShareHandles
+func (handles ShareHandles) String() string {
+	return fmt.Sprintf("Share: %s; Open: %d; Durable: %d; Persistent: %d", handles.Share, handles.OpenCount, handles.DurableCount, handles.PersistentCount)
+}
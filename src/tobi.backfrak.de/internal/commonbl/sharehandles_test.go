@@ -0,0 +1,30 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestShareHandlesJson(t *testing.T) {
+	var data []ShareHandles
+	errConv := json.Unmarshal([]byte(TestShareHandlesResponse()), &data)
+	if errConv != nil {
+		t.Fatalf("Got error '%s' but expected none", errConv.Error())
+	}
+
+	if len(data) != 1 || data[0].Share != "/usr/share/data" || data[0].DurableCount != 2 || data[0].PersistentCount != 1 {
+		t.Errorf("The handles '%v' are not the test data", data)
+	}
+}
+
+func TestShareHandlesString(t *testing.T) {
+	str := GetTestShareHandles()[0].String()
+	if str != "Share: /usr/share/data; Open: 3; Durable: 2; Persistent: 1" {
+		t.Errorf("The string '%s' is not expected", str)
+	}
+}
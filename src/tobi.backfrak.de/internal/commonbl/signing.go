@@ -0,0 +1,185 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The prefix of the line holding the HMAC of a signed message, the time it was signed and its nonce
+const signaturePrefix = "HMAC-SHA256: "
+
+// The most the time a message was signed may differ from the time it is received. Older messages are dropped as replayed,
+// so the clocks of samba_exporter and samba_statusd may differ by less than this
+const maxMessageAge = 5 * time.Minute
+
+// The nonces of the messages received within the maxMessageAge, mapped to the time the message was signed.
+// Shared by all SigningHandlers, so a message captured on one connection can not be replayed on an other one
+var receivedNonces = map[string]time.Time{}
+var receivedNoncesMux sync.Mutex
+
+// Returns the current time, replaced in the tests
+var signingNow = time.Now
+
+// SigningHandler - A MessageHandler that signs the written messages with a shared secret
+// and drops received messages without a valid signature
+type SigningHandler struct {
+	handler MessageHandler
+	secret  []byte
+	logger  Logger
+}
+
+// NewSigningHandler - Get a new SigningHandler, sending and receiving the messages with the given handler
+func NewSigningHandler(handler MessageHandler, secret []byte, logger Logger) *SigningHandler {
+	return &SigningHandler{handler, secret, logger}
+}
+
+// ReadSecretFile - Read the shared secret used to sign the messages from a file. Leading and trailing white spaces are ignored
+func ReadSecretFile(path string) ([]byte, error) {
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return nil, fmt.Errorf("The secret file \"%s\" is empty", path)
+	}
+
+	return []byte(secret), nil
+}
+
+// SignMessage - Get the message with its HMAC-SHA256 signature as first line. The signature covers the message, which holds the
+// request ID, the time it is signed and a random nonce, so the message can not be replayed
+func SignMessage(message string, secret []byte) string {
+	signedAt := signingNow().UnixNano()
+	nonce := getNonce()
+
+	return fmt.Sprintf("%s%s; TIME: %d; NONCE: %s\n%s", signaturePrefix, getSignature(signedAt, nonce, message, secret), signedAt, nonce, message)
+}
+
+// VerifyMessage - Get the message out of a signed message. Returns an InvalidSignatureError, when the signature is missing or wrong,
+// and a ReplayedMessageError, when the message was signed more than the maxMessageAge ago or was received before
+func VerifyMessage(signed string, secret []byte) (string, error) {
+	signatureLine, message, found := strings.Cut(signed, "\n")
+	if !found || !strings.HasPrefix(signatureLine, signaturePrefix) {
+		return "", NewInvalidSignatureError(signed)
+	}
+
+	fields := strings.Split(strings.TrimPrefix(signatureLine, signaturePrefix), ";")
+	if len(fields) != 3 {
+		return "", NewInvalidSignatureError(signed)
+	}
+	signature, errDecode := hex.DecodeString(strings.TrimSpace(fields[0]))
+	if errDecode != nil {
+		return "", NewInvalidSignatureError(signed)
+	}
+	timeStr := strings.TrimSpace(fields[1])
+	nonce := strings.TrimSpace(fields[2])
+	if !strings.HasPrefix(timeStr, "TIME:") || !strings.HasPrefix(nonce, "NONCE:") {
+		return "", NewInvalidSignatureError(signed)
+	}
+	signedAt, errTime := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(timeStr, "TIME:")), 10, 64)
+	nonce = strings.TrimSpace(strings.TrimPrefix(nonce, "NONCE:"))
+	if errTime != nil || nonce == "" {
+		return "", NewInvalidSignatureError(signed)
+	}
+
+	expected, _ := hex.DecodeString(getSignature(signedAt, nonce, message, secret))
+	if !hmac.Equal(signature, expected) {
+		return "", NewInvalidSignatureError(signed)
+	}
+
+	if !checkNonce(nonce, time.Unix(0, signedAt)) {
+		return "", NewReplayedMessageError(message)
+	}
+
+	return message, nil
+}
+
+func getSignature(signedAt int64, nonce string, message string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(fmt.Sprintf("%d\n%s\n%s", signedAt, nonce, message)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// getNonce - Get a random value, so each signed message differs, even when it is sent twice in the same nanosecond
+func getNonce() string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	return hex.EncodeToString(nonce)
+}
+
+// checkNonce - Tells if a message signed at the time with the nonce is received the first time, and not longer than the maxMessageAge ago.
+// The nonce is remembered for the maxMessageAge, the nonces of older messages are forgotten
+func checkNonce(nonce string, signedAt time.Time) bool {
+	now := signingNow()
+	if now.Sub(signedAt) > maxMessageAge || signedAt.Sub(now) > maxMessageAge {
+		return false
+	}
+
+	receivedNoncesMux.Lock()
+	defer receivedNoncesMux.Unlock()
+	for known, knownSignedAt := range receivedNonces {
+		if now.Sub(knownSignedAt) > maxMessageAge {
+			delete(receivedNonces, known)
+		}
+	}
+	if _, found := receivedNonces[nonce]; found {
+		return false
+	}
+	receivedNonces[nonce] = signedAt
+
+	return true
+}
+
+// WaitForPipeInputString - Blocking! Wait for a message with a valid signature and return it without the signature.
+// Messages without a valid signature, outdated and replayed messages are dropped
+func (handler *SigningHandler) WaitForPipeInputString() (string, error) {
+	for {
+		received, errRead := handler.handler.WaitForPipeInputString()
+		if errRead != nil || received == "" {
+			return received, errRead
+		}
+
+		message, errVerify := VerifyMessage(received, handler.secret)
+		switch errVerify.(type) {
+		case nil:
+			return message, nil
+		case *ReplayedMessageError:
+			handler.logger.WriteErrorMessage(fmt.Sprintf("Dropped an outdated or replayed message received on %s", handler.GetPipeFilePath()))
+		default:
+			handler.logger.WriteErrorMessage(fmt.Sprintf("Dropped a message without valid signature received on %s", handler.GetPipeFilePath()))
+		}
+	}
+}
+
+// WritePipeString - Sign the message and write it. Since the receiving handlers trim the messages, the trimmed message is signed
+func (handler *SigningHandler) WritePipeString(data string) error {
+	message := strings.TrimSpace(data)
+	if message == "" {
+		// Empty messages are used to clear the pipe, they are ignored by the receiver anyway
+		return handler.handler.WritePipeString(message)
+	}
+
+	return handler.handler.WritePipeString(SignMessage(message, handler.secret))
+}
+
+// GetPipeFilePath - Get the path of the underlying handler
+func (handler *SigningHandler) GetPipeFilePath() string {
+	return handler.handler.GetPipeFilePath()
+}
@@ -0,0 +1,153 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerifyMessage(t *testing.T) {
+	secret := []byte("my secret")
+	message := GetResponse(GetResponseHeader(LOCK_REQUEST, 3), "some\ndata")
+
+	signed := SignMessage(message, secret)
+	if !strings.HasSuffix(signed, message) {
+		t.Errorf("The signed message '%s' does not contain the message", signed)
+	}
+
+	verified, err := VerifyMessage(signed, secret)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if verified != message {
+		t.Errorf("Got the message '%s' but expected '%s'", verified, message)
+	}
+}
+
+func TestVerifyMessageInvalid(t *testing.T) {
+	secret := []byte("my secret")
+	signed := SignMessage("LOCK_REQUEST: 3", secret)
+	signatureLine, _, _ := strings.Cut(signed, "\n")
+
+	for _, invalid := range []string{
+		"LOCK_REQUEST: 3",
+		SignMessage("LOCK_REQUEST: 3", []byte("other secret")),
+		fmt.Sprintf("%s\n%s", signatureLine, "LOCK_REQUEST: 4"),
+		fmt.Sprintf("%snot hex\n%s", signaturePrefix, "LOCK_REQUEST: 3"),
+	} {
+		_, err := VerifyMessage(invalid, secret)
+		switch err.(type) {
+		case *InvalidSignatureError:
+			fmt.Println("OK")
+		default:
+			t.Errorf("Got error '%v' for '%s', but expected an InvalidSignatureError", err, invalid)
+		}
+	}
+}
+
+func TestVerifyMessageReplayed(t *testing.T) {
+	secret := []byte("my secret")
+	signed := SignMessage("LOCK_REQUEST: 5", secret)
+
+	_, errFirst := VerifyMessage(signed, secret)
+	if errFirst != nil {
+		t.Fatalf("Got error '%s' but expected none", errFirst.Error())
+	}
+	_, errReplayed := VerifyMessage(signed, secret)
+	switch errReplayed.(type) {
+	case *ReplayedMessageError:
+		fmt.Println("OK")
+	default:
+		t.Errorf("Got error '%v' for the replayed message, but expected a ReplayedMessageError", errReplayed)
+	}
+
+	// The same message signed again has an other nonce
+	_, errSignedAgain := VerifyMessage(SignMessage("LOCK_REQUEST: 5", secret), secret)
+	if errSignedAgain != nil {
+		t.Errorf("Got error '%s' but expected none", errSignedAgain.Error())
+	}
+
+	defer func() { signingNow = time.Now }()
+	for _, signedAt := range []time.Time{time.Now().Add(-2 * maxMessageAge), time.Now().Add(2 * maxMessageAge)} {
+		signingNow = func() time.Time { return signedAt }
+		outdated := SignMessage("LOCK_REQUEST: 6", secret)
+		signingNow = time.Now
+
+		_, errOutdated := VerifyMessage(outdated, secret)
+		switch errOutdated.(type) {
+		case *ReplayedMessageError:
+			fmt.Println("OK")
+		default:
+			t.Errorf("Got error '%v' for the message signed at %s, but expected a ReplayedMessageError", errOutdated, signedAt)
+		}
+	}
+}
+
+func TestReadSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+
+	_, errNotExist := ReadSecretFile(path)
+	if errNotExist == nil {
+		t.Errorf("Got no error but expected one, since the file does not exist")
+	}
+
+	os.WriteFile(path, []byte("  \n"), 0600)
+	_, errEmpty := ReadSecretFile(path)
+	if errEmpty == nil {
+		t.Errorf("Got no error but expected one, since the file is empty")
+	}
+
+	os.WriteFile(path, []byte("my secret\n"), 0600)
+	secret, err := ReadSecretFile(path)
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if string(secret) != "my secret" {
+		t.Errorf("Got the secret '%s' but expected 'my secret'", string(secret))
+	}
+}
+
+func TestSigningHandler(t *testing.T) {
+	listener, errListen := ListenTcp("127.0.0.1:0", nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+
+	client := NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+	signingClient := NewSigningHandler(client, []byte("my secret"), NewConsoleLogger(false))
+
+	// An unsigned message and a message signed with another secret are dropped by the server
+	client.WritePipeString("LOCK_REQUEST: 1")
+	NewSigningHandler(client, []byte("other secret"), NewConsoleLogger(false)).WritePipeString("LOCK_REQUEST: 2")
+	signingClient.WritePipeString("LOCK_REQUEST: 3\n\n")
+
+	conn, errAccept := listener.Accept()
+	if errAccept != nil {
+		t.Fatalf("Got error '%s' but expected none", errAccept.Error())
+	}
+	server := NewTcpConnectionHandler(conn)
+	defer server.Close()
+	signingServer := NewSigningHandler(server, []byte("my secret"), NewConsoleLogger(false))
+
+	received, errRead := signingServer.WaitForPipeInputString()
+	if errRead != nil {
+		t.Fatalf("Got error '%s' but expected none", errRead.Error())
+	}
+	if received != "LOCK_REQUEST: 3" {
+		t.Errorf("Received '%s' but expected 'LOCK_REQUEST: 3'", received)
+	}
+
+	if signingServer.GetPipeFilePath() != server.GetPipeFilePath() {
+		t.Errorf("The path '%s' is not the path of the underlying handler '%s'", signingServer.GetPipeFilePath(), server.GetPipeFilePath())
+	}
+}
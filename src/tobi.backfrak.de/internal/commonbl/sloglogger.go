@@ -0,0 +1,59 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// SlogLogger - A Logger writing the messages with a log/slog Logger, so programs embedding the collectors can use their own logging.
+// Verbose messages are written with the debug level, information with the info level and errors with the error level
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger - Get a new instance of the SlogLogger writing with the logger. When the logger is nil, slog.Default() is used
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ret := SlogLogger{logger}
+
+	return &ret
+}
+
+// GetVerbose - Tell if the debug level of the slog Logger is enabled
+func (logger *SlogLogger) GetVerbose() bool {
+	return logger.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// WriteInformation - Write the message with the info level
+func (logger *SlogLogger) WriteInformation(message string) {
+	logger.logger.Info(message)
+}
+
+// WriteVerbose - Write the message with the debug level
+func (logger *SlogLogger) WriteVerbose(message string) {
+	logger.logger.Debug(message)
+}
+
+// WriteErrorMessage - Write the message with the error level, a "Error: " prefix is removed
+func (logger *SlogLogger) WriteErrorMessage(message string) {
+	logger.logger.Error(strings.TrimPrefix(message, "Error: "))
+}
+
+// WriteError - Write the err.Error() output with the error level
+func (logger *SlogLogger) WriteError(err error) {
+	logger.WriteErrorMessage(err.Error())
+}
+
+// WriteErrorWithAddition - Write the 'err.Error() - addition' output with the error level
+func (logger *SlogLogger) WriteErrorWithAddition(err error, addition string) {
+	logger.WriteErrorMessage(fmt.Sprintf("%s - %s", err.Error(), addition))
+}
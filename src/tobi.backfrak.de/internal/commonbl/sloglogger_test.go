@@ -0,0 +1,55 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var output bytes.Buffer
+	var sut Logger = NewSlogLogger(slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	if sut.GetVerbose() {
+		t.Errorf("The logger is verbose, but expected not, since the debug level is not enabled")
+	}
+	sut.WriteVerbose("not written")
+	sut.WriteInformation("Started")
+	sut.WriteErrorMessage("Error: first")
+	sut.WriteErrorWithAddition(fmt.Errorf("second"), "while testing")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	expected := []string{"level=INFO msg=Started", "level=ERROR msg=first", "level=ERROR msg=\"second - while testing\""}
+	if len(lines) != len(expected) {
+		t.Fatalf("Got '%d' lines, but expected '%d': %s", len(lines), len(expected), output.String())
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, expected[i]) {
+			t.Errorf("Got the line '%s', but expected it to end with '%s'", line, expected[i])
+		}
+	}
+}
+
+func TestSlogLoggerVerbose(t *testing.T) {
+	var output bytes.Buffer
+	sut := NewSlogLogger(slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	if !sut.GetVerbose() {
+		t.Errorf("The logger is not verbose, but expected it, since the debug level is enabled")
+	}
+	sut.WriteVerbose("written")
+	if !strings.Contains(output.String(), "level=DEBUG msg=written") {
+		t.Errorf("Got the output '%s', but expected the verbose message with the debug level", output.String())
+	}
+
+	if NewSlogLogger(nil).logger != slog.Default() {
+		t.Errorf("The logger does not use slog.Default(), when no slog Logger is given")
+	}
+}
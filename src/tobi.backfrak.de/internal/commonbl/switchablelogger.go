@@ -0,0 +1,63 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// SwitchableLogger - A Logger whose verbose messages can be switched on and off while the program runs, so problems
+// can be troubleshot without a restart. The wrapped Logger needs to be verbose, it writes all messages passed on
+type SwitchableLogger struct {
+	Logger
+	verbose atomic.Bool
+}
+
+// NewSwitchableLogger - Get a new instance of the SwitchableLogger, writing verbose messages with the logger when verbose is true
+func NewSwitchableLogger(logger Logger, verbose bool) *SwitchableLogger {
+	ret := SwitchableLogger{Logger: logger}
+	ret.verbose.Store(verbose)
+
+	return &ret
+}
+
+// GetVerbose - Tell if logger is verbose or not
+func (logger *SwitchableLogger) GetVerbose() bool {
+	return logger.verbose.Load()
+}
+
+// WriteVerbose - Write a Verbose message, when the verbose messages are currently switched on
+func (logger *SwitchableLogger) WriteVerbose(message string) {
+	if logger.verbose.Load() {
+		logger.Logger.WriteVerbose(message)
+	}
+}
+
+// SetVerbose - Switch the verbose messages on or off
+func (logger *SwitchableLogger) SetVerbose(verbose bool) {
+	logger.verbose.Store(verbose)
+}
+
+// StartVerboseToggle - Switch the verbose messages of the logger on or off each time SIGUSR2 is received
+func StartVerboseToggle(logger *SwitchableLogger) {
+	userSignal := make(chan os.Signal, 1)
+	signal.Notify(userSignal, syscall.SIGUSR2)
+
+	go func() {
+		for range userSignal {
+			verbose := !logger.GetVerbose()
+			logger.SetVerbose(verbose)
+			if verbose {
+				logger.WriteInformation("Switched the verbose messages on due to user signal 2")
+			} else {
+				logger.WriteInformation("Switched the verbose messages off due to user signal 2")
+			}
+		}
+	}()
+}
@@ -0,0 +1,53 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSwitchableLogger(t *testing.T) {
+	recorder := recordingLogger{}
+	sut := NewSwitchableLogger(&recorder, false)
+
+	sut.WriteVerbose("not written")
+	if sut.GetVerbose() || len(recorder.verboses) != 0 {
+		t.Errorf("Got the verbose messages '%v', but expected none", recorder.verboses)
+	}
+
+	sut.SetVerbose(true)
+	sut.WriteVerbose("written")
+	if !sut.GetVerbose() || len(recorder.verboses) != 1 || recorder.verboses[0] != "written" {
+		t.Errorf("Got the verbose messages '%v', but expected the one written after switching on", recorder.verboses)
+	}
+
+	sut.WriteErrorMessage("always written")
+	if len(recorder.errors) != 1 {
+		t.Errorf("Got '%d' errors, but expected the error to be passed on", len(recorder.errors))
+	}
+}
+
+func TestStartVerboseToggle(t *testing.T) {
+	sut := NewSwitchableLogger(NewConsoleLogger(true), false)
+	StartVerboseToggle(sut)
+
+	for _, expected := range []bool{true, false} {
+		errKill := syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+		if errKill != nil {
+			t.Fatalf("Got error '%s' but expected none", errKill.Error())
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for sut.GetVerbose() != expected && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if sut.GetVerbose() != expected {
+			t.Errorf("The logger is verbose: %t, but expected %t after the signal", !expected, expected)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MessageHandler - Interface for the channels samba_exporter and samba_statusd use to send requests and responses
+type MessageHandler interface {
+	// WaitForPipeInputString - Blocking! Wait for the next message and return it as string
+	WaitForPipeInputString() (string, error)
+
+	// WritePipeString - Send the string as message
+	WritePipeString(data string) error
+
+	// GetPipeFilePath - Get the name of the endpoint used, for log messages
+	GetPipeFilePath() string
+}
+
+// TcpHandler - Type to handle a TCP connection for comunication between samba_exporter and samba_statusd.
+// The same handler is used to send requests and to receive responses
+type TcpHandler struct {
+	Address   string
+	TLSConfig *tls.Config
+	conn      net.Conn
+	reader    *bufio.Reader
+	dial      bool
+	connMutex sync.Mutex
+	readMutex sync.Mutex
+	wrtMutex  sync.Mutex
+
+	// MaxMessageSize - The maximum size of a received message in bytes, larger messages are discarded. No limit when 0
+	MaxMessageSize int
+
+	// DialTimeout - The time a client handler waits for the connection to samba_statusd. No timeout when 0
+	DialTimeout time.Duration
+}
+
+// NewTcpClientHandler - Get a new TcpHandler connecting to samba_statusd listening on the address.
+// The connection is established with the first message and again after an error. When tlsConfig is nil, no TLS is used
+func NewTcpClientHandler(address string, tlsConfig *tls.Config) *TcpHandler {
+	retVal := TcpHandler{}
+	retVal.Address = address
+	retVal.TLSConfig = tlsConfig
+	retVal.dial = true
+
+	return &retVal
+}
+
+// NewTcpConnectionHandler - Get a new TcpHandler for a connection accepted by samba_statusd
+func NewTcpConnectionHandler(conn net.Conn) *TcpHandler {
+	retVal := TcpHandler{}
+	retVal.Address = conn.RemoteAddr().String()
+	retVal.conn = conn
+	retVal.reader = bufio.NewReader(conn)
+
+	return &retVal
+}
+
+// ListenTcp - Listen for connections of samba_exporter on the address. When tlsConfig is nil, no TLS is used
+func ListenTcp(address string, tlsConfig *tls.Config) (net.Listener, error) {
+	if tlsConfig != nil {
+		return tls.Listen("tcp", address, tlsConfig)
+	}
+
+	return net.Listen("tcp", address)
+}
+
+// GetPipeFilePath - Get the address of the other end of the connection
+func (handler *TcpHandler) GetPipeFilePath() string {
+	if handler.TLSConfig != nil {
+		return fmt.Sprintf("tls://%s", handler.Address)
+	}
+
+	return fmt.Sprintf("tcp://%s", handler.Address)
+}
+
+// WaitForPipeInputBytes - Blocking! Wait for the next message on the connection and return it as byte array
+// The array will be empty in case of errors
+func (handler *TcpHandler) WaitForPipeInputBytes() ([]byte, error) {
+	_, reader, errGet := handler.getConnection()
+	if errGet != nil {
+		return []byte{}, errGet
+	}
+
+	handler.readMutex.Lock()
+	defer handler.readMutex.Unlock()
+	received, errRead := readMessage(reader, handler.MaxMessageSize)
+	switch errRead.(type) {
+	case nil:
+		return received, nil
+	case *MessageTooLargeError:
+		// The message was read to its end, so the connection can be used for the next message
+		return []byte{}, errRead
+	default:
+		handler.reset()
+		return []byte{}, errRead
+	}
+}
+
+// WaitForPipeInputString - Blocking! Wait for the next message on the connection and return it as string
+// The string will be empty in case of errors
+func (handler *TcpHandler) WaitForPipeInputString() (string, error) {
+	data, err := handler.WaitForPipeInputBytes()
+
+	return strings.TrimSpace(string(data)), err
+}
+
+// WritePipeBytes - Write byte data as message to the connection
+func (handler *TcpHandler) WritePipeBytes(data []byte) error {
+	return handler.WritePipeString(string(data))
+}
+
+// WritePipeString - Write string data as message to the connection, in chunks of MESSAGE_CHUNK_SIZE
+func (handler *TcpHandler) WritePipeString(data string) error {
+	conn, _, errGet := handler.getConnection()
+	if errGet != nil {
+		return errGet
+	}
+
+	handler.wrtMutex.Lock()
+	defer handler.wrtMutex.Unlock()
+	errWrite := writeMessage(bufio.NewWriterSize(conn, MESSAGE_CHUNK_SIZE), data)
+	if errWrite != nil {
+		handler.reset()
+		return errWrite
+	}
+
+	return nil
+}
+
+// Close - Close the connection
+func (handler *TcpHandler) Close() error {
+	handler.connMutex.Lock()
+	defer handler.connMutex.Unlock()
+
+	if handler.conn == nil {
+		return nil
+	}
+	err := handler.conn.Close()
+	handler.conn = nil
+	handler.reader = nil
+
+	return err
+}
+
+// getConnection - Get the current connection, a client handler connects when not connected.
+// The mutex is not held while dialing, so an unreachable samba_statusd does not block Close
+func (handler *TcpHandler) getConnection() (net.Conn, *bufio.Reader, error) {
+	handler.connMutex.Lock()
+	conn, reader := handler.conn, handler.reader
+	handler.connMutex.Unlock()
+
+	if conn != nil {
+		return conn, reader, nil
+	}
+
+	if !handler.dial {
+		return nil, nil, io.EOF
+	}
+
+	conn, errDial := handler.dialConnection()
+	if errDial != nil {
+		return nil, nil, errDial
+	}
+
+	handler.connMutex.Lock()
+	defer handler.connMutex.Unlock()
+	if handler.conn != nil {
+		// An other request connected while dialing, keep the first connection
+		conn.Close()
+		return handler.conn, handler.reader, nil
+	}
+	handler.conn = conn
+	handler.reader = bufio.NewReader(conn)
+
+	return handler.conn, handler.reader, nil
+}
+
+// dialConnection - Connect to the address, fail when the DialTimeout is exceeded
+func (handler *TcpHandler) dialConnection() (net.Conn, error) {
+	dialer := net.Dialer{Timeout: handler.DialTimeout}
+	if handler.TLSConfig != nil {
+		tlsDialer := tls.Dialer{NetDialer: &dialer, Config: handler.TLSConfig}
+		return tlsDialer.Dial("tcp", handler.Address)
+	}
+
+	return dialer.Dial("tcp", handler.Address)
+}
+
+// reset - Close the connection after an error, so a client handler connects again with the next message
+func (handler *TcpHandler) reset() {
+	if handler.dial {
+		handler.Close()
+	}
+}
+
+// GetServerTLSConfig - Get the TLS configuration for samba_statusd listening on TCP.
+// When a clientCAFile is given, only clients with a certificate signed by this CA can connect
+func GetServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, errLoad := tls.LoadX509KeyPair(certFile, keyFile)
+	if errLoad != nil {
+		return nil, errLoad
+	}
+	config := tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if clientCAFile != "" {
+		pool, errPool := readCertPool(clientCAFile)
+		if errPool != nil {
+			return nil, errPool
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return &config, nil
+}
+
+// GetClientTLSConfig - Get the TLS configuration for samba_exporter connecting to samba_statusd.
+// When no caFile is given, the systems CAs are used. The client certificate is only used when certFile is given
+func GetClientTLSConfig(caFile, certFile, keyFile, serverName string) (*tls.Config, error) {
+	config := tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pool, errPool := readCertPool(caFile)
+		if errPool != nil {
+			return nil, errPool
+		}
+		config.RootCAs = pool
+	}
+
+	if certFile != "" {
+		cert, errLoad := tls.LoadX509KeyPair(certFile, keyFile)
+		if errLoad != nil {
+			return nil, errLoad
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return &config, nil
+}
+
+// readCertPool - Read the PEM encoded certificates of the file into a new pool
+func readCertPool(filePath string) (*x509.CertPool, error) {
+	data, errRead := os.ReadFile(filePath)
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("The file '%s' contains no PEM encoded certificate", filePath)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,226 @@
+package commonbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTcpHandlerImplementsMessageHandler(t *testing.T) {
+	var handler MessageHandler = NewTcpClientHandler("127.0.0.1:9923", nil)
+	if handler.GetPipeFilePath() != "tcp://127.0.0.1:9923" {
+		t.Errorf("The handler has the name '%s', but expected 'tcp://127.0.0.1:9923'", handler.GetPipeFilePath())
+	}
+
+	handler = NewPipeHandler(true, RequestPipe)
+	if handler.GetPipeFilePath() != "/dev/shm/samba_exporter.request.pipe" {
+		t.Errorf("The handler has the name '%s', but expected '/dev/shm/samba_exporter.request.pipe'", handler.GetPipeFilePath())
+	}
+}
+
+func TestTcpHandlerPlain(t *testing.T) {
+	listener, errListen := ListenTcp("127.0.0.1:0", nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+	go echoServer(listener)
+
+	client := NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+	sendAndReceive(t, client, "LOCK_REQUEST: 1")
+	sendAndReceive(t, client, "SHARE_REQUEST: 2")
+}
+
+func TestTcpHandlerReconnect(t *testing.T) {
+	listener, errListen := ListenTcp("127.0.0.1:0", nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+	go echoServer(listener)
+
+	client := NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+	sendAndReceive(t, client, "LOCK_REQUEST: 1")
+
+	client.conn.Close()
+	_, errRead := client.WaitForPipeInputString()
+	if errRead == nil {
+		t.Errorf("Got no error but expected one, since the connection is closed")
+	}
+
+	sendAndReceive(t, client, "LOCK_REQUEST: 2")
+}
+
+func TestTcpHandlerNotListening(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := listener.Addr().String()
+	listener.Close()
+
+	client := NewTcpClientHandler(address, nil)
+	err := client.WritePipeString("LOCK_REQUEST: 1")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since no one listens on '%s'", address)
+	}
+}
+
+func TestTcpHandlerDialTimeout(t *testing.T) {
+	// The listener never accepts, so the TLS handshake gets no answer
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+
+	client := NewTcpClientHandler(listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	client.DialTimeout = 200 * time.Millisecond
+	start := time.Now()
+	err := client.WritePipeString("LOCK_REQUEST: 1")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the TLS handshake gets no answer")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("The dial took '%s', but the DialTimeout is '%s'", time.Since(start), client.DialTimeout)
+	}
+}
+
+func TestTcpHandlerTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	serverConfig, errServer := GetServerTLSConfig(certFile, keyFile, certFile)
+	if errServer != nil {
+		t.Fatalf("Got error '%s' but expected none", errServer.Error())
+	}
+	listener, errListen := ListenTcp("127.0.0.1:0", serverConfig)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+	go echoServer(listener)
+
+	clientConfig, errClient := GetClientTLSConfig(certFile, certFile, keyFile, "localhost")
+	if errClient != nil {
+		t.Fatalf("Got error '%s' but expected none", errClient.Error())
+	}
+	client := NewTcpClientHandler(listener.Addr().String(), clientConfig)
+	defer client.Close()
+	if client.GetPipeFilePath() != "tls://"+listener.Addr().String() {
+		t.Errorf("The handler has the name '%s', but expected it to start with 'tls://'", client.GetPipeFilePath())
+	}
+	sendAndReceive(t, client, "PS_REQUEST: 3")
+
+	clientConfig, _ = GetClientTLSConfig(certFile, "", "", "localhost")
+	noCertClient := NewTcpClientHandler(listener.Addr().String(), clientConfig)
+	defer noCertClient.Close()
+	noCertClient.WritePipeString("PS_REQUEST: 4")
+	_, errRead := noCertClient.WaitForPipeInputString()
+	if errRead == nil {
+		t.Errorf("Got no error but expected one, since the client has no certificate")
+	}
+}
+
+func TestGetTLSConfigErrors(t *testing.T) {
+	notExisting := filepath.Join(t.TempDir(), "not_existing.pem")
+	_, err := GetServerTLSConfig(notExisting, notExisting, "")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the certificate does not exist")
+	}
+
+	_, err = GetClientTLSConfig(notExisting, "", "", "")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the CA file does not exist")
+	}
+
+	noCert := filepath.Join(t.TempDir(), "no_cert.pem")
+	os.WriteFile(noCert, []byte("no certificate"), 0644)
+	_, err = GetClientTLSConfig(noCert, "", "", "")
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the CA file contains no certificate")
+	}
+}
+
+func sendAndReceive(t *testing.T, client *TcpHandler, message string) {
+	errWrite := client.WritePipeString(message)
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+	received, errRead := client.WaitForPipeInputString()
+	if errRead != nil {
+		t.Fatalf("Got error '%s' but expected none", errRead.Error())
+	}
+	if received != message {
+		t.Errorf("Received '%s' but expected '%s'", received, message)
+	}
+}
+
+// echoServer - Answer each message on the accepted connections with the same message
+func echoServer(listener net.Listener) {
+	for {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			return
+		}
+		go func() {
+			handler := NewTcpConnectionHandler(conn)
+			defer handler.Close()
+			for {
+				received, errRead := handler.WaitForPipeInputString()
+				if errRead != nil {
+					return
+				}
+				handler.WritePipeString(received)
+			}
+		}()
+	}
+}
+
+// writeTestCertificate - Write a self signed certificate for 'localhost' usable by servers and clients to the temp dir
+func writeTestCertificate(t *testing.T) (string, string) {
+	key, errKey := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if errKey != nil {
+		t.Fatalf("Got error '%s' but expected none", errKey.Error())
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, errCreate := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if errCreate != nil {
+		t.Fatalf("Got error '%s' but expected none", errCreate.Error())
+	}
+	keyDer, errMarshal := x509.MarshalECPrivateKey(key)
+	if errMarshal != nil {
+		t.Fatalf("Got error '%s' but expected none", errMarshal.Error())
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	return certFile, keyFile
+}
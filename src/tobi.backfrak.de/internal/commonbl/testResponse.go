@@ -43,6 +43,71 @@ func TestPsResponseEmpty() string {
 	return string(jsonData)
 }
 
+// TestCgroupResponse - The JSON of the test cgroup data
+func TestCgroupResponse() string {
+
+	jsonData, _ := json.MarshalIndent(GetTestCgroupData(), "", " ")
+
+	return string(jsonData)
+}
+
+// TestCtdbNodesResponse - The JSON of the test CTDB nodes
+func TestCtdbNodesResponse() string {
+
+	jsonData, _ := json.MarshalIndent(GetTestCtdbNodes(), "", " ")
+
+	return string(jsonData)
+}
+
+// GetTestCtdbNodes - Always returns the same CtdbNodes for test propose
+func GetTestCtdbNodes() []CtdbNode {
+	return []CtdbNode{{Node: 0, Address: "192.168.1.10"}, {Node: 1, Address: "192.168.1.11"}}
+}
+
+// TestShareConfigResponse - The JSON of the test share configuration
+func TestShareConfigResponse() string {
+
+	jsonData, _ := json.MarshalIndent(GetTestShareConfig(), "", " ")
+
+	return string(jsonData)
+}
+
+// GetTestShareConfig - Always returns the same ShareConfig for test propose
+func GetTestShareConfig() []ShareConfig {
+	return []ShareConfig{
+		{Name: "IPC$", Parameters: map[string]string{}},
+		{Name: "data", Parameters: map[string]string{"path": "/srv/data", "read only": "No"}},
+	}
+}
+
+// TestShareHandlesResponse - The JSON of the test share handles
+func TestShareHandlesResponse() string {
+
+	jsonData, _ := json.MarshalIndent(GetTestShareHandles(), "", " ")
+
+	return string(jsonData)
+}
+
+// GetTestShareHandles - Always returns the same ShareHandles for test propose
+func GetTestShareHandles() []ShareHandles {
+	return []ShareHandles{{Share: "/usr/share/data", OpenCount: 3, DurableCount: 2, PersistentCount: 1}}
+}
+
+// GetTestCgroupData - Always returns the same CgroupData for test propose
+func GetTestCgroupData() []CgroupData {
+	return []CgroupData{{
+		Path:                "system.slice/smbd.service",
+		CpuUsageSeconds:     1234.5,
+		CpuUserSeconds:      1000.25,
+		CpuSystemSeconds:    234.25,
+		CpuThrottledSeconds: 1.5,
+		MemoryUsageBytes:    104857600,
+		IoReadBytes:         7340032,
+		IoWriteBytes:        3145728,
+		TaskCount:           12,
+	}}
+}
+
 // Always returns the same PsUtilPidData for test propose
 func GetTestPsUtilPidData() []PsUtilPidData {
 	pidData := []PsUtilPidData{}
@@ -57,6 +122,11 @@ func GetTestPsUtilPidData() []PsUtilPidData {
 		6789,
 		1467,
 		8765,
+		1482,
+		3,
+		45678,
+		1234,
+		16384,
 	})
 
 	pidData = append(pidData, PsUtilPidData{
@@ -70,6 +140,11 @@ func GetTestPsUtilPidData() []PsUtilPidData {
 		789543,
 		467123,
 		765853,
+		467140,
+		17,
+		987654,
+		23456,
+		524288,
 	})
 
 	return pidData
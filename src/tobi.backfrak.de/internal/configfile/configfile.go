@@ -0,0 +1,108 @@
+package configfile
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyConfigFile - Read the YAML configuration file and use its settings as values for the flags in the flagSet.
+// The keys of the file are the names of the flags. Nested keys are joined with a '.', so 'web: {listen-address: ":9922"}'
+// sets the flag 'web.listen-address'. Lists are joined to a comma separated value. The entries of a map, whose key is the name of
+// a flag, set this flag as 'key=value' pairs, so 'label: {datacenter: fra1}' sets the flag 'label' to 'datacenter=fra1'.
+// Flags already set, e. g. on the command line, are not changed, so they override the configuration file
+func ApplyConfigFile(filePath string, flagSet *flag.FlagSet) error {
+	content, errRead := os.ReadFile(filePath)
+	if errRead != nil {
+		return errRead
+	}
+
+	settings, errParse := ParseConfig(content)
+	if errParse != nil {
+		return fmt.Errorf("Error: Can not parse the configuration file \"%s\": %s", filePath, errParse.Error())
+	}
+
+	alreadySet := make(map[string]bool)
+	flagSet.Visit(func(f *flag.Flag) { alreadySet[f.Name] = true })
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flagName, value := name, settings[name]
+		if flagSet.Lookup(flagName) == nil {
+			lastDot := strings.LastIndex(name, ".")
+			if lastDot < 0 || flagSet.Lookup(name[:lastDot]) == nil {
+				return NewUnknownSettingError(name, filePath)
+			}
+			flagName, value = name[:lastDot], fmt.Sprintf("%s=%s", name[lastDot+1:], value)
+		}
+		if alreadySet[flagName] {
+			continue
+		}
+		if errSet := flagSet.Set(flagName, value); errSet != nil {
+			return NewInvalidSettingError(flagName, value, errSet)
+		}
+	}
+
+	return nil
+}
+
+// ParseConfig - Parse the YAML content of a configuration file into a map of flag names and the values to set
+func ParseConfig(content []byte) (map[string]string, error) {
+	var values map[string]interface{}
+	if errUnmarshal := yaml.Unmarshal(content, &values); errUnmarshal != nil {
+		return nil, errUnmarshal
+	}
+
+	settings := make(map[string]string)
+	if errFlatten := flatten("", values, settings); errFlatten != nil {
+		return nil, errFlatten
+	}
+
+	return settings, nil
+}
+
+func flatten(prefix string, values map[string]interface{}, settings map[string]string) error {
+	for key, value := range values {
+		name := key
+		if prefix != "" {
+			name = fmt.Sprintf("%s.%s", prefix, key)
+		}
+
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			if errFlatten := flatten(name, typed, settings); errFlatten != nil {
+				return errFlatten
+			}
+		case []interface{}:
+			entries := make([]string, 0, len(typed))
+			for _, entry := range typed {
+				switch entry.(type) {
+				case map[string]interface{}, []interface{}:
+					return fmt.Errorf("The list \"%s\" contains a nested value, only plain values are supported", name)
+				}
+				entries = append(entries, fmt.Sprint(entry))
+			}
+			settings[name] = strings.Join(entries, ",")
+		case nil:
+			settings[name] = ""
+		default:
+			settings[name] = fmt.Sprint(typed)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package configfile
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testParameters struct {
+	ListenAddress  string
+	RequestTimeOut int
+	Verbose        bool
+	Collectors     string
+	Labels         testLabels
+}
+
+type testLabels []string
+
+func (labels *testLabels) String() string { return strings.Join(*labels, ",") }
+
+func (labels *testLabels) Set(value string) error {
+	*labels = append(*labels, value)
+	return nil
+}
+
+func getTestFlagSet(params *testParameters) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.StringVar(&params.ListenAddress, "web.listen-address", ":9922", "")
+	flagSet.IntVar(&params.RequestTimeOut, "request-timeout", 5, "")
+	flagSet.BoolVar(&params.Verbose, "verbose", false, "")
+	flagSet.StringVar(&params.Collectors, "disabled-collectors", "", "")
+	flagSet.Var(&params.Labels, "label", "")
+
+	return flagSet
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	filePath := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Got error '%s' while writing the configuration file", err.Error())
+	}
+
+	return filePath
+}
+
+func TestParseConfig(t *testing.T) {
+	settings, err := ParseConfig([]byte("web:\n  listen-address: \":9000\"\nrequest-timeout: 10\nverbose: true\ndisabled-collectors:\n  - locks\n  - psdata\n"))
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	expected := map[string]string{"web.listen-address": ":9000", "request-timeout": "10", "verbose": "true", "disabled-collectors": "locks,psdata"}
+	if len(settings) != len(expected) {
+		t.Errorf("Got '%d' settings but expected '%d'", len(settings), len(expected))
+	}
+
+	for name, value := range expected {
+		if settings[name] != value {
+			t.Errorf("The setting '%s' is '%s' but expected '%s'", name, settings[name], value)
+		}
+	}
+
+	_, err = ParseConfig([]byte("web: [listen-address: 1"))
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the content is no valid YAML")
+	}
+
+	_, err = ParseConfig([]byte("disabled-collectors:\n  - name: locks\n"))
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the list contains a map")
+	}
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	var params testParameters
+	flagSet := getTestFlagSet(&params)
+	flagSet.Parse([]string{"-request-timeout=3"})
+
+	filePath := writeConfigFile(t, "web:\n  listen-address: \":9000\"\nrequest-timeout: 10\nverbose: true\n")
+	err := ApplyConfigFile(filePath, flagSet)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if params.ListenAddress != ":9000" {
+		t.Errorf("The listen address is '%s' but expected ':9000'", params.ListenAddress)
+	}
+
+	if !params.Verbose {
+		t.Errorf("Verbose is not set, but expected")
+	}
+
+	if params.RequestTimeOut != 3 {
+		t.Errorf("The request timeout is '%d' but expected the value '3' given on the command line", params.RequestTimeOut)
+	}
+}
+
+func TestApplyConfigFileMap(t *testing.T) {
+	var params testParameters
+	flagSet := getTestFlagSet(&params)
+
+	err := ApplyConfigFile(writeConfigFile(t, "label:\n  datacenter: fra1\n  cluster: samba-01\n"), flagSet)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if params.Labels.String() != "cluster=samba-01,datacenter=fra1" {
+		t.Errorf("The labels are '%s' but expected 'cluster=samba-01,datacenter=fra1'", params.Labels.String())
+	}
+
+	var cliParams testParameters
+	flagSet = getTestFlagSet(&cliParams)
+	flagSet.Parse([]string{"-label=rack=r1"})
+	err = ApplyConfigFile(writeConfigFile(t, "label:\n  datacenter: fra1\n"), flagSet)
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	if cliParams.Labels.String() != "rack=r1" {
+		t.Errorf("The labels are '%s' but expected only the label given on the command line", cliParams.Labels.String())
+	}
+}
+
+func TestApplyConfigFileErrors(t *testing.T) {
+	var params testParameters
+
+	err := ApplyConfigFile(filepath.Join(t.TempDir(), "not-existing.yml"), getTestFlagSet(&params))
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the file does not exist")
+	}
+
+	err = ApplyConfigFile(writeConfigFile(t, "unknown-setting: 1\n"), getTestFlagSet(&params))
+	switch err.(type) {
+	case *UnknownSettingError:
+		fmt.Println("OK")
+	default:
+		t.Errorf("Got error of type '%T' but expected '*UnknownSettingError'", err)
+	}
+
+	err = ApplyConfigFile(writeConfigFile(t, "request-timeout: abc\n"), getTestFlagSet(&params))
+	switch err.(type) {
+	case *InvalidSettingError:
+		fmt.Println("OK")
+	default:
+		t.Errorf("Got error of type '%T' but expected '*InvalidSettingError'", err)
+	}
+}
@@ -0,0 +1,44 @@
+package configfile
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import "fmt"
+
+// UnknownSettingError - Error when the configuration file contains a setting, that is not a known parameter
+type UnknownSettingError struct {
+	err string
+	// The name of the unknown setting
+	Setting string
+	// The configuration file containing the setting
+	FilePath string
+}
+
+func (e *UnknownSettingError) Error() string { // Implement the Error Interface for the UnknownSettingError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewUnknownSettingError - Get a new UnknownSettingError struct
+func NewUnknownSettingError(setting string, filePath string) *UnknownSettingError {
+	return &UnknownSettingError{fmt.Sprintf("The setting \"%s\" in the configuration file \"%s\" is unknown", setting, filePath), setting, filePath}
+}
+
+// InvalidSettingError - Error when a setting in the configuration file has a value, that can not be used
+type InvalidSettingError struct {
+	err string
+	// The name of the invalid setting
+	Setting string
+	// The value that can not be used
+	Value string
+}
+
+func (e *InvalidSettingError) Error() string { // Implement the Error Interface for the InvalidSettingError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewInvalidSettingError - Get a new InvalidSettingError struct
+func NewInvalidSettingError(setting string, value string, reason error) *InvalidSettingError {
+	return &InvalidSettingError{fmt.Sprintf("The value \"%s\" of setting \"%s\" can not be used: %s", value, setting, reason.Error()), setting, value}
+}
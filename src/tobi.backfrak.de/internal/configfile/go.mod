@@ -0,0 +1,5 @@
+module tobi.backfrak.de/internal/configfile
+
+go 1.21
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,63 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker - Counts the requests to samba_statusd that timed out in a row, and stops sending requests for a cooldown
+// period once they reach the threshold. So a hanging smbstatus does not fill the pipes with requests nobody waits for
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	timeOuts  int
+	openUntil time.Time
+}
+
+var statusdBreaker = &circuitBreaker{}
+
+// IsCircuitOpen - Tells if no requests are sent to samba_statusd at the moment, since too many requests timed out in a row
+func IsCircuitOpen() bool {
+	return statusdBreaker.getOpenTime() > 0
+}
+
+// getOpenTime - Get the time left until requests are sent again, 0 when the circuit is closed
+func (breaker *circuitBreaker) getOpenTime() time.Duration {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	remaining := time.Until(breaker.openUntil)
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
+}
+
+// addTimeOut - Count a request that timed out. Opens the circuit for the cooldown, when threshold requests timed out in a row.
+// Returns true, when the circuit got opened. After the cooldown one request is sent, when it times out too, the circuit opens again
+func (breaker *circuitBreaker) addTimeOut(threshold int, cooldown time.Duration) bool {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.timeOuts++
+	if breaker.timeOuts < threshold {
+		return false
+	}
+	breaker.openUntil = time.Now().Add(cooldown)
+
+	return true
+}
+
+// reset - Close the circuit after a request got answered
+func (breaker *circuitBreaker) reset() {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.timeOuts = 0
+	breaker.openUntil = time.Time{}
+}
@@ -2,6 +2,7 @@ package pipecomunication
 
 import (
 	"fmt"
+	"time"
 
 	"tobi.backfrak.de/internal/commonbl"
 )
@@ -37,3 +38,62 @@ func (e *SmbStatusUnexpectedResponseError) Error() string { // Implement the Err
 func NewSmbStatusUnexpectedResponseError(response string) *SmbStatusUnexpectedResponseError {
 	return &SmbStatusUnexpectedResponseError{fmt.Sprintf("The response \"%s\" was not exptected", response), response}
 }
+
+// ProtocolVersionMismatchError - Error when samba_statusd speaks an other protocol version than samba_exporter
+type ProtocolVersionMismatchError struct {
+	err string
+	// StatusdProtocolVersion - The protocol version samba_statusd speaks
+	StatusdProtocolVersion int
+	// StatusdProgramVersion - The program version of samba_statusd
+	StatusdProgramVersion string
+}
+
+func (e *ProtocolVersionMismatchError) Error() string { // Implement the Error Interface for the ProtocolVersionMismatchError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewProtocolVersionMismatchError - Get a new ProtocolVersionMismatchError struct
+func NewProtocolVersionMismatchError(statusdProtocolVersion int, statusdProgramVersion string) *ProtocolVersionMismatchError {
+	return &ProtocolVersionMismatchError{fmt.Sprintf("samba_statusd version \"%s\" speaks protocol version %d, but samba_exporter needs protocol version %d. Install samba_exporter and samba_statusd in the same version",
+		statusdProgramVersion, statusdProtocolVersion, commonbl.PROTOCOL_VERSION), statusdProtocolVersion, statusdProgramVersion}
+}
+
+// CircuitOpenError - Error when a request is not sent to samba_statusd, since too many requests timed out in a row
+type CircuitOpenError struct {
+	err string
+	// Request - The request that was not sent
+	Request commonbl.RequestType
+	// Remaining - The time until requests are sent again
+	Remaining time.Duration
+}
+
+func (e *CircuitOpenError) Error() string { // Implement the Error Interface for the CircuitOpenError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewCircuitOpenError - Get a new CircuitOpenError struct
+func NewCircuitOpenError(request commonbl.RequestType, remaining time.Duration) *CircuitOpenError {
+	return &CircuitOpenError{fmt.Sprintf("The \"%s\" was not sent, since too many requests to samba_statusd timed out. Requests are sent again in %s",
+		request, remaining.Round(time.Second)), request, remaining}
+}
+
+// MemoryLimitError - Error when a response of samba_statusd is not parsed, since the memory needed would exceed the memory limit
+type MemoryLimitError struct {
+	err string
+	// Request - The request the response belongs to
+	Request commonbl.RequestType
+	// ResponseSize - The size of the response in bytes
+	ResponseSize int
+	// Limit - The memory limit in bytes
+	Limit int64
+}
+
+func (e *MemoryLimitError) Error() string { // Implement the Error Interface for the MemoryLimitError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewMemoryLimitError - Get a new MemoryLimitError struct
+func NewMemoryLimitError(request commonbl.RequestType, responseSize int, limit int64) *MemoryLimitError {
+	return &MemoryLimitError{fmt.Sprintf("The response to the \"%s\" with %d bytes is not parsed, since this would exceed the memory limit of %d bytes",
+		request, responseSize, limit), request, responseSize, limit}
+}
@@ -8,6 +8,7 @@ package pipecomunication
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"tobi.backfrak.de/internal/commonbl"
 )
@@ -37,3 +38,43 @@ func TestSmbStatusUnexpectedResponseError(t *testing.T) {
 		t.Errorf("The error message of SmbStatusUnexpectedResponseError does not contain the expected request")
 	}
 }
+
+func TestProtocolVersionMismatchError(t *testing.T) {
+	err := NewProtocolVersionMismatchError(commonbl.PROTOCOL_VERSION+1, "1.2.3")
+
+	if err.StatusdProtocolVersion != commonbl.PROTOCOL_VERSION+1 {
+		t.Errorf("The protocol version was %d, but %d was expected", err.StatusdProtocolVersion, commonbl.PROTOCOL_VERSION+1)
+	}
+
+	if err.StatusdProgramVersion != "1.2.3" {
+		t.Errorf("The program version was %s, but 1.2.3 was expected", err.StatusdProgramVersion)
+	}
+
+	if strings.Contains(err.Error(), "1.2.3") == false {
+		t.Errorf("The error message of ProtocolVersionMismatchError does not contain the program version")
+	}
+}
+
+func TestCircuitOpenError(t *testing.T) {
+	err := NewCircuitOpenError(commonbl.SHARE_REQUEST, 42*time.Second)
+
+	if err.Request != commonbl.SHARE_REQUEST || err.Remaining != 42*time.Second {
+		t.Errorf("Got the request '%s' and remaining time '%s', but expected '%s' and '42s'", err.Request, err.Remaining, commonbl.SHARE_REQUEST)
+	}
+
+	if !strings.Contains(err.Error(), string(commonbl.SHARE_REQUEST)) || !strings.Contains(err.Error(), "42s") {
+		t.Errorf("The error message '%s' does not contain the request and remaining time", err.Error())
+	}
+}
+
+func TestMemoryLimitError(t *testing.T) {
+	err := NewMemoryLimitError(commonbl.LOCK_REQUEST, 2048, 1024)
+
+	if err.Request != commonbl.LOCK_REQUEST || err.ResponseSize != 2048 || err.Limit != 1024 {
+		t.Errorf("The MemoryLimitError has the request '%s', size '%d' and limit '%d', but expected '%s', '2048' and '1024'", err.Request, err.ResponseSize, err.Limit, commonbl.LOCK_REQUEST)
+	}
+
+	if !strings.Contains(err.Error(), "1024 bytes") {
+		t.Errorf("The error message of MemoryLimitError does not contain the limit")
+	}
+}
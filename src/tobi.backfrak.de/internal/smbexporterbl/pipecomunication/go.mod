@@ -1,5 +1,7 @@
 module tobi.backfrak.de/internal/smbexporterbl/pipecomunication
 
+go 1.21
+
 require tobi.backfrak.de/internal/commonbl v0.0.0
 replace tobi.backfrak.de/internal/commonbl v0.0.0 => ../../commonbl
 
@@ -10,4 +12,18 @@ require tobi.backfrak.de/internal/smbstatusout v0.0.0
 replace tobi.backfrak.de/internal/smbstatusout v0.0.0 => ../../smbstatusout
 
 require tobi.backfrak.de/internal/testhelper v0.0.0
-replace tobi.backfrak.de/internal/testhelper v0.0.0 => ../../../internal/testhelper
\ No newline at end of file
+replace tobi.backfrak.de/internal/testhelper v0.0.0 => ../../../internal/testhelper
+
+require tobi.backfrak.de/internal/statusdrpc v0.0.0
+replace tobi.backfrak.de/internal/statusdrpc v0.0.0 => ../../statusdrpc
+
+require google.golang.org/grpc v1.62.1
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
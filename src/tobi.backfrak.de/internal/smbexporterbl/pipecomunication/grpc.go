@@ -0,0 +1,271 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/statusdrpc"
+)
+
+// Stream of smbstatus output received from samba_statusd
+type smbstatusOutputStream interface {
+	Recv() (*statusdrpc.SmbstatusOutput, error)
+}
+
+// Stream of typed messages received from samba_statusd, e. g. the ps data of the smbd processes
+type messageStream[M any] interface {
+	Recv() (*M, error)
+}
+
+// NewGrpcClient - Get a client for the gRPC service of samba_statusd on the address. When tlsConfig is nil, no TLS is used.
+// The connection is established with the first request, close the returned connection when the client is no longer needed
+func NewGrpcClient(address string, tlsConfig *tls.Config) (*grpc.ClientConn, statusdrpc.SambaStatusClient, error) {
+	transportCredentials := insecure.NewCredentials()
+	if tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, errDial := grpc.Dial(address, grpc.WithTransportCredentials(transportCredentials))
+	if errDial != nil {
+		return nil, nil, errDial
+	}
+
+	return conn, statusdrpc.NewSambaStatusClient(conn), nil
+}
+
+// GetSambaStatusGrpc - Get all data tables from samba_statusd using the gRPC service. The calls are cancelled with the context
+func GetSambaStatusGrpc(ctx context.Context, client statusdrpc.SambaStatusClient, logger commonbl.Logger, settings RequestSettings) ([]smbstatusreader.LockData, []smbstatusreader.ProcessData, []smbstatusreader.ShareData, []commonbl.PsUtilPidData, error) {
+	// The tables are parsed while the next table is received, like GetSambaStatus does
+	sharesChan := make(chan []smbstatusreader.ShareData, 1)
+	processesChan := make(chan []smbstatusreader.ProcessData, 1)
+	locksChan := make(chan []smbstatusreader.LockData, 1)
+
+	res, errGet := receiveSmbstatusOutputRetry(ctx, client, commonbl.PROCESS_REQUEST, logger, settings)
+	if errGet != nil {
+		return nil, nil, nil, nil, errGet
+	}
+	if errLimit := checkMemoryLimit(commonbl.PROCESS_REQUEST, res, settings.MemoryLimit); errLimit != nil {
+		return nil, nil, nil, nil, errLimit
+	}
+	storeRawResponse(commonbl.PROCESS_REQUEST, res)
+	go goGetProcessData(res, logger, processesChan)
+
+	res, errGet = receiveSmbstatusOutputRetry(ctx, client, commonbl.SHARE_REQUEST, logger, settings)
+	if errGet != nil {
+		return nil, nil, nil, nil, errGet
+	}
+	if errLimit := checkMemoryLimit(commonbl.SHARE_REQUEST, res, settings.MemoryLimit); errLimit != nil {
+		return nil, nil, nil, nil, errLimit
+	}
+	storeRawResponse(commonbl.SHARE_REQUEST, res)
+	go goGetShareData(res, logger, sharesChan)
+
+	res, errGet = receiveSmbstatusOutputRetry(ctx, client, commonbl.LOCK_REQUEST, logger, settings)
+	if errGet != nil {
+		return nil, nil, nil, nil, errGet
+	}
+	if errLimit := checkMemoryLimit(commonbl.LOCK_REQUEST, res, settings.MemoryLimit); errLimit != nil {
+		return nil, nil, nil, nil, errLimit
+	}
+	storeRawResponse(commonbl.LOCK_REQUEST, res)
+	go goGetLockData(res, logger, locksChan)
+
+	var psdata []commonbl.PsUtilPidData
+	errPs := doWithRetry(ctx, commonbl.PS_REQUEST, settings, logger, func() error {
+		var errReceive error
+		psdata, errReceive = receivePsData(ctx, client, logger, time.Second*time.Duration(settings.TimeOut))
+		return errReceive
+	})
+	if errPs != nil {
+		return nil, nil, nil, nil, errPs
+	}
+
+	processes := <-processesChan
+	shares := <-sharesChan
+	locks := <-locksChan
+
+	if len(shares) < 1 {
+		logger.WriteVerbose("Got an empty share table when requesting \"smbstatus -S -n\" from samba_statusd")
+	}
+
+	if len(processes) < 1 {
+		logger.WriteVerbose("Got an empty process table when requesting \"smbstatus -p -n\" from samba_statusd")
+	}
+
+	return locks, processes, shares, psdata, nil
+}
+
+// receiveSmbstatusOutputRetry - Call the gRPC service and join the streamed smbstatus output, retry the call when it times out
+func receiveSmbstatusOutputRetry(ctx context.Context, client statusdrpc.SambaStatusClient, request commonbl.RequestType, logger commonbl.Logger, settings RequestSettings) (string, error) {
+	var output string
+	err := doWithRetry(ctx, request, settings, logger, func() error {
+		var errReceive error
+		output, errReceive = receiveSmbstatusOutput(ctx, client, request, logger, time.Second*time.Duration(settings.TimeOut), settings.MaxResponseSize)
+		return errReceive
+	})
+
+	return output, err
+}
+
+// receiveSmbstatusOutput - Call the gRPC service and join the streamed smbstatus output. When maxSize is greater 0 and the output
+// gets larger, the call is cancelled and a MessageTooLargeError is returned
+func receiveSmbstatusOutput(parent context.Context, client statusdrpc.SambaStatusClient, request commonbl.RequestType, logger commonbl.Logger, timeOut time.Duration, maxSize int) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, timeOut)
+	defer cancel()
+
+	logger.WriteVerbose(fmt.Sprintf("Send \"%s\" request using gRPC", request))
+	stream, errCall := openSmbstatusStream(ctx, client, request)
+	if errCall != nil {
+		return "", convertGrpcError(parent, errCall, request)
+	}
+
+	var messages []*statusdrpc.SmbstatusOutput
+	size := 0
+	for {
+		message, errRecv := stream.Recv()
+		if errRecv == io.EOF {
+			break
+		}
+		if errRecv != nil {
+			return "", convertGrpcError(parent, errRecv, request)
+		}
+		for _, line := range message.GetLines() {
+			size += len(line) + 1
+		}
+		if maxSize > 0 && size > maxSize {
+			// Leaving the function cancels the call, so the rest of the output is not sent
+			return "", commonbl.NewMessageTooLargeError(size, maxSize)
+		}
+		messages = append(messages, message)
+	}
+	logger.WriteVerbose(fmt.Sprintf("Received %d messages for the \"%s\" request using gRPC", len(messages), request))
+
+	return statusdrpc.JoinSmbstatusOutput(messages), nil
+}
+
+// openSmbstatusStream - Call the gRPC service streaming the smbstatus output for the request
+func openSmbstatusStream(ctx context.Context, client statusdrpc.SambaStatusClient, request commonbl.RequestType) (smbstatusOutputStream, error) {
+	switch request {
+	case commonbl.LOCK_REQUEST:
+		return client.GetLocks(ctx, &statusdrpc.StatusRequest{})
+	case commonbl.SHARE_REQUEST:
+		return client.GetShares(ctx, &statusdrpc.StatusRequest{})
+	case commonbl.PROCESS_REQUEST:
+		return client.GetProcesses(ctx, &statusdrpc.StatusRequest{})
+	default:
+		return nil, NewSmbStatusUnexpectedResponseError(string(request))
+	}
+}
+
+// receivePsData - Call the gRPC service and collect the streamed ps data
+func receivePsData(parent context.Context, client statusdrpc.SambaStatusClient, logger commonbl.Logger, timeOut time.Duration) ([]commonbl.PsUtilPidData, error) {
+	open := func(ctx context.Context) (messageStream[statusdrpc.PsData], error) {
+		return client.GetPsData(ctx, &statusdrpc.StatusRequest{})
+	}
+
+	return receiveList(parent, commonbl.PS_REQUEST, logger, timeOut, open, (*statusdrpc.PsData).ToPsUtilPidData)
+}
+
+// GetCgroupsGrpc - Get the resource usage of the cgroups samba runs in using the gRPC service. The calls are cancelled with the context
+func GetCgroupsGrpc(ctx context.Context, client statusdrpc.SambaStatusClient, logger commonbl.Logger, settings RequestSettings) ([]commonbl.CgroupData, error) {
+	open := func(ctx context.Context) (messageStream[statusdrpc.Cgroup], error) {
+		return client.GetCgroups(ctx, &statusdrpc.StatusRequest{})
+	}
+
+	return receiveListRetry(ctx, commonbl.CGROUP_REQUEST, logger, settings, open, (*statusdrpc.Cgroup).ToCgroupData)
+}
+
+// GetCtdbNodesGrpc - Get the nodes of the CTDB cluster samba runs in using the gRPC service. The calls are cancelled with the context
+func GetCtdbNodesGrpc(ctx context.Context, client statusdrpc.SambaStatusClient, logger commonbl.Logger, settings RequestSettings) ([]commonbl.CtdbNode, error) {
+	open := func(ctx context.Context) (messageStream[statusdrpc.CtdbNode], error) {
+		return client.GetCtdbNodes(ctx, &statusdrpc.StatusRequest{})
+	}
+
+	return receiveListRetry(ctx, commonbl.CTDB_NODES_REQUEST, logger, settings, open, (*statusdrpc.CtdbNode).ToCtdbNode)
+}
+
+// GetShareConfigGrpc - Get the shares configured in the smb.conf using the gRPC service. The calls are cancelled with the context
+func GetShareConfigGrpc(ctx context.Context, client statusdrpc.SambaStatusClient, logger commonbl.Logger, settings RequestSettings) ([]commonbl.ShareConfig, error) {
+	open := func(ctx context.Context) (messageStream[statusdrpc.ShareConfig], error) {
+		return client.GetShareConfig(ctx, &statusdrpc.StatusRequest{})
+	}
+
+	return receiveListRetry(ctx, commonbl.SHARE_CONFIG_REQUEST, logger, settings, open, (*statusdrpc.ShareConfig).ToShareConfig)
+}
+
+// GetShareHandlesGrpc - Get the open, durable and persistent file handles of each share using the gRPC service. The calls are cancelled with the context
+func GetShareHandlesGrpc(ctx context.Context, client statusdrpc.SambaStatusClient, logger commonbl.Logger, settings RequestSettings) ([]commonbl.ShareHandles, error) {
+	open := func(ctx context.Context) (messageStream[statusdrpc.ShareHandles], error) {
+		return client.GetShareHandles(ctx, &statusdrpc.StatusRequest{})
+	}
+
+	return receiveListRetry(ctx, commonbl.SHARE_HANDLES_REQUEST, logger, settings, open, (*statusdrpc.ShareHandles).ToShareHandles)
+}
+
+// receiveListRetry - Call the gRPC service and convert the streamed messages, retry the call when it times out
+func receiveListRetry[M any, T any](ctx context.Context, request commonbl.RequestType, logger commonbl.Logger, settings RequestSettings,
+	open func(context.Context) (messageStream[M], error), convert func(*M) T) ([]T, error) {
+	var ret []T
+	err := doWithRetry(ctx, request, settings, logger, func() error {
+		var errReceive error
+		ret, errReceive = receiveList(ctx, request, logger, time.Second*time.Duration(settings.TimeOut), open, convert)
+		return errReceive
+	})
+
+	return ret, err
+}
+
+// receiveList - Call the gRPC service with open and convert the streamed messages
+func receiveList[M any, T any](parent context.Context, request commonbl.RequestType, logger commonbl.Logger, timeOut time.Duration,
+	open func(context.Context) (messageStream[M], error), convert func(*M) T) ([]T, error) {
+	ctx, cancel := context.WithTimeout(parent, timeOut)
+	defer cancel()
+
+	logger.WriteVerbose(fmt.Sprintf("Send \"%s\" request using gRPC", request))
+	stream, errCall := open(ctx)
+	if errCall != nil {
+		return nil, convertGrpcError(parent, errCall, request)
+	}
+
+	ret := []T{}
+	for {
+		message, errRecv := stream.Recv()
+		if errRecv == io.EOF {
+			break
+		}
+		if errRecv != nil {
+			return nil, convertGrpcError(parent, errRecv, request)
+		}
+		ret = append(ret, convert(message))
+	}
+	logger.WriteVerbose(fmt.Sprintf("Received %d messages for the \"%s\" request using gRPC", len(ret), request))
+
+	return ret, nil
+}
+
+// convertGrpcError - Get the error of the parent context when it is done, or a SmbStatusTimeOutError when the deadline of the request is exceeded
+func convertGrpcError(parent context.Context, err error, request commonbl.RequestType) error {
+	if errParent := parent.Err(); errParent != nil {
+		return errParent
+	}
+	if status.Code(err) == codes.DeadlineExceeded {
+		return NewSmbStatusTimeOutError(request)
+	}
+
+	return err
+}
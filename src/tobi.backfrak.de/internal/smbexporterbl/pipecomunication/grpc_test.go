@@ -0,0 +1,272 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/statusdrpc"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// testStatusServer - gRPC service sending the test data, after waiting for delay
+type testStatusServer struct {
+	statusdrpc.UnimplementedSambaStatusServer
+	delay time.Duration
+}
+
+func (server *testStatusServer) GetLocks(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetLocksServer) error {
+	return server.send(commonbl.TestLockResponse, stream)
+}
+
+func (server *testStatusServer) GetShares(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetSharesServer) error {
+	return server.send(commonbl.TestShareResponse, stream)
+}
+
+func (server *testStatusServer) GetProcesses(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetProcessesServer) error {
+	return server.send(commonbl.TestProcessResponse, stream)
+}
+
+func (server *testStatusServer) GetPsData(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetPsDataServer) error {
+	var psData []commonbl.PsUtilPidData
+	errConv := json.Unmarshal([]byte(commonbl.TestPsResponse()), &psData)
+	if errConv != nil {
+		return errConv
+	}
+	for _, data := range psData {
+		errSend := stream.Send(statusdrpc.NewPsData(data))
+		if errSend != nil {
+			return errSend
+		}
+	}
+
+	return nil
+}
+
+func (server *testStatusServer) GetCgroups(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetCgroupsServer) error {
+	return sendTestList(server, commonbl.GetTestCgroupData(), statusdrpc.NewCgroup, stream.Send)
+}
+
+func (server *testStatusServer) GetCtdbNodes(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetCtdbNodesServer) error {
+	return sendTestList(server, commonbl.GetTestCtdbNodes(), statusdrpc.NewCtdbNode, stream.Send)
+}
+
+func (server *testStatusServer) GetShareConfig(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetShareConfigServer) error {
+	return sendTestList(server, commonbl.GetTestShareConfig(), statusdrpc.NewShareConfig, stream.Send)
+}
+
+func (server *testStatusServer) GetShareHandles(request *statusdrpc.StatusRequest, stream statusdrpc.SambaStatus_GetShareHandlesServer) error {
+	return sendTestList(server, commonbl.GetTestShareHandles(), statusdrpc.NewShareHandles, stream.Send)
+}
+
+// sendTestList - Send each entry of the list as message, after waiting for the delay of the server
+func sendTestList[T any, M any](server *testStatusServer, list []T, newMessage func(T) *M, send func(*M) error) error {
+	time.Sleep(server.delay)
+	for _, entry := range list {
+		errSend := send(newMessage(entry))
+		if errSend != nil {
+			return errSend
+		}
+	}
+
+	return nil
+}
+
+func (server *testStatusServer) send(output string, stream grpc.ServerStream) error {
+	time.Sleep(server.delay)
+	for _, message := range statusdrpc.SplitSmbstatusOutput(output) {
+		errSend := stream.SendMsg(message)
+		if errSend != nil {
+			return errSend
+		}
+	}
+
+	return nil
+}
+
+func startTestStatusServer(t *testing.T, delay time.Duration) (*grpc.Server, string) {
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	server := grpc.NewServer()
+	statusdrpc.RegisterSambaStatusServer(server, &testStatusServer{delay: delay})
+	go server.Serve(listener)
+
+	return server, listener.Addr().String()
+}
+
+func TestGetSambaStatusGrpc(t *testing.T) {
+	server, address := startTestStatusServer(t, 0)
+	defer server.Stop()
+
+	conn, client, errNew := NewGrpcClient(address, nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	defer conn.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	locks, processes, shares, psData, err := GetSambaStatusGrpc(context.Background(), client, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(locks) != 1 {
+		t.Errorf("Got '%d' locks but expected '1'", len(locks))
+	}
+
+	if len(processes) != 1 {
+		t.Errorf("Got '%d' processes but expected '1'", len(processes))
+	}
+
+	if len(shares) != 1 {
+		t.Errorf("Got '%d' shares but expected '1'", len(shares))
+	}
+
+	if len(psData) != 2 {
+		t.Errorf("Got '%d' ps data entries but expected '2'", len(psData))
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestGetSambaStatusGrpcMaxResponseSize(t *testing.T) {
+	server, address := startTestStatusServer(t, 0)
+	defer server.Stop()
+
+	conn, client, errNew := NewGrpcClient(address, nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	defer conn.Close()
+
+	settings := NewRequestSettings(2)
+	settings.MaxResponseSize = 10
+	_, _, _, _, err := GetSambaStatusGrpc(context.Background(), client, testhelper.NewTestLogger(true), settings)
+	switch err.(type) {
+	case *commonbl.MessageTooLargeError:
+		fmt.Fprintln(os.Stdout, "OK")
+	default:
+		t.Errorf("Got error '%v', but expected '*commonbl.MessageTooLargeError'", err)
+	}
+}
+
+func TestGetSambaStatusGrpcTimeout(t *testing.T) {
+	server, address := startTestStatusServer(t, 2*time.Second)
+	defer server.Stop()
+
+	conn, client, errNew := NewGrpcClient(address, nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	defer conn.Close()
+
+	_, _, _, _, err := GetSambaStatusGrpc(context.Background(), client, testhelper.NewTestLogger(true), NewRequestSettings(1))
+	if err == nil {
+		t.Fatalf("Exptected an error but got none")
+	}
+
+	switch err.(type) {
+	case *SmbStatusTimeOutError:
+		fmt.Fprintln(os.Stdout, "OK")
+	default:
+		t.Errorf("Got error '%s' type, but expected '*SmbStatusTimeOutError'", err.Error())
+	}
+}
+
+func TestGetSambaStatusGrpcCancelled(t *testing.T) {
+	server, address := startTestStatusServer(t, 2*time.Second)
+	defer server.Stop()
+
+	conn, client, errNew := NewGrpcClient(address, nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, _, _, err := GetSambaStatusGrpc(ctx, client, testhelper.NewTestLogger(true), NewRequestSettings(5))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Got error '%v', but expected '%v'", err, context.DeadlineExceeded)
+	}
+
+	if time.Since(start) > time.Second {
+		t.Errorf("The cancelled request took '%s', but expected it to stop with the context", time.Since(start))
+	}
+}
+
+func TestGetListsGrpc(t *testing.T) {
+	server, address := startTestStatusServer(t, 0)
+	defer server.Stop()
+
+	conn, client, errNew := NewGrpcClient(address, nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	defer conn.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	settings := NewRequestSettings(2)
+	ctx := context.Background()
+
+	cgroups, errCgroups := GetCgroupsGrpc(ctx, client, logger, settings)
+	if errCgroups != nil || !reflect.DeepEqual(cgroups, commonbl.GetTestCgroupData()) {
+		t.Errorf("Got '%v' cgroups and error '%v', but expected '%v'", cgroups, errCgroups, commonbl.GetTestCgroupData())
+	}
+
+	nodes, errNodes := GetCtdbNodesGrpc(ctx, client, logger, settings)
+	if errNodes != nil || !reflect.DeepEqual(nodes, commonbl.GetTestCtdbNodes()) {
+		t.Errorf("Got '%v' cluster nodes and error '%v', but expected '%v'", nodes, errNodes, commonbl.GetTestCtdbNodes())
+	}
+
+	configs, errConfigs := GetShareConfigGrpc(ctx, client, logger, settings)
+	if errConfigs != nil || !reflect.DeepEqual(configs, commonbl.GetTestShareConfig()) {
+		t.Errorf("Got '%v' share configs and error '%v', but expected '%v'", configs, errConfigs, commonbl.GetTestShareConfig())
+	}
+
+	handles, errHandles := GetShareHandlesGrpc(ctx, client, logger, settings)
+	if errHandles != nil || !reflect.DeepEqual(handles, commonbl.GetTestShareHandles()) {
+		t.Errorf("Got '%v' share handles and error '%v', but expected '%v'", handles, errHandles, commonbl.GetTestShareHandles())
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestGetListGrpcTimeout(t *testing.T) {
+	server, address := startTestStatusServer(t, 2*time.Second)
+	defer server.Stop()
+
+	conn, client, errNew := NewGrpcClient(address, nil)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	defer conn.Close()
+
+	_, err := GetCgroupsGrpc(context.Background(), client, testhelper.NewTestLogger(true), NewRequestSettings(1))
+	switch err.(type) {
+	case *SmbStatusTimeOutError:
+		fmt.Fprintln(os.Stdout, "OK")
+	default:
+		t.Errorf("Got error '%v', but expected '*SmbStatusTimeOutError'", err)
+	}
+}
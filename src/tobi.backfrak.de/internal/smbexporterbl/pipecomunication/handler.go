@@ -6,6 +6,7 @@ package pipecomunication
 // LICENSE file.
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -17,14 +18,21 @@ import (
 var requestCount = 0
 var requestMux sync.Mutex
 var collectMux sync.Mutex
+var droppedResponseCount = 0
+var droppedResponseMux sync.Mutex
 
+// The request handlers samba_statusd already answered the VERSION_REQUEST with a compatible version on
+var versionCheckedHandlers = map[commonbl.MessageHandler]bool{}
+var versionCheckedMux sync.Mutex
+
+// smbResponse - The data of a response from samba_statusd, or the error reading it
 type smbResponse struct {
 	Data  string
 	Error error
 }
 
-// GetSambaStatus - Get the output of all data tables from samba_statusd
-func GetSambaStatus(requestHandler *commonbl.PipeHandler, responseHandler *commonbl.PipeHandler, logger commonbl.Logger, requestTimeOut int) ([]smbstatusreader.LockData, []smbstatusreader.ProcessData, []smbstatusreader.ShareData, []commonbl.PsUtilPidData, error) {
+// GetSambaStatus - Get the output of all data tables from samba_statusd. Waiting for a response is stopped, when the context is done
+func GetSambaStatus(ctx context.Context, requestHandler commonbl.MessageHandler, responseHandler commonbl.MessageHandler, logger commonbl.Logger, settings RequestSettings) ([]smbstatusreader.LockData, []smbstatusreader.ProcessData, []smbstatusreader.ShareData, []commonbl.PsUtilPidData, error) {
 	var processes []smbstatusreader.ProcessData
 	var shares []smbstatusreader.ShareData
 	var locks []smbstatusreader.LockData
@@ -36,28 +44,49 @@ func GetSambaStatus(requestHandler *commonbl.PipeHandler, responseHandler *commo
 	collectMux.Lock()
 	defer collectMux.Unlock()
 
-	res, errGet := getSmbStatusDataTimeOut(requestHandler, responseHandler, commonbl.PROCESS_REQUEST, logger, requestTimeOut)
+	errVersion := checkProtocolVersion(ctx, requestHandler, responseHandler, logger, settings)
+	if errVersion != nil {
+		return nil, nil, nil, nil, errVersion
+	}
+
+	res, errGet := getSmbStatusDataRetry(ctx, requestHandler, responseHandler, commonbl.PROCESS_REQUEST, logger, settings)
 	if errGet != nil {
 		return nil, nil, nil, nil, errGet
 	}
+	if errLimit := checkMemoryLimit(commonbl.PROCESS_REQUEST, res, settings.MemoryLimit); errLimit != nil {
+		return nil, nil, nil, nil, errLimit
+	}
+	storeRawResponse(commonbl.PROCESS_REQUEST, res)
 	go goGetProcessData(res, logger, processesChan)
 
-	res, errGet = getSmbStatusDataTimeOut(requestHandler, responseHandler, commonbl.SHARE_REQUEST, logger, requestTimeOut)
+	res, errGet = getSmbStatusDataRetry(ctx, requestHandler, responseHandler, commonbl.SHARE_REQUEST, logger, settings)
 	if errGet != nil {
 		return nil, nil, nil, nil, errGet
 	}
+	if errLimit := checkMemoryLimit(commonbl.SHARE_REQUEST, res, settings.MemoryLimit); errLimit != nil {
+		return nil, nil, nil, nil, errLimit
+	}
+	storeRawResponse(commonbl.SHARE_REQUEST, res)
 	go goGetShareData(res, logger, sharesChan)
 
-	res, errGet = getSmbStatusDataTimeOut(requestHandler, responseHandler, commonbl.LOCK_REQUEST, logger, requestTimeOut)
+	res, errGet = getSmbStatusDataRetry(ctx, requestHandler, responseHandler, commonbl.LOCK_REQUEST, logger, settings)
 	if errGet != nil {
 		return nil, nil, nil, nil, errGet
 	}
+	if errLimit := checkMemoryLimit(commonbl.LOCK_REQUEST, res, settings.MemoryLimit); errLimit != nil {
+		return nil, nil, nil, nil, errLimit
+	}
+	storeRawResponse(commonbl.LOCK_REQUEST, res)
 	go goGetLockData(res, logger, locksChan)
 
-	res, errGet = getSmbStatusDataTimeOut(requestHandler, responseHandler, commonbl.PS_REQUEST, logger, requestTimeOut)
+	res, errGet = getSmbStatusDataRetry(ctx, requestHandler, responseHandler, commonbl.PS_REQUEST, logger, settings)
 	if errGet != nil {
 		return nil, nil, nil, nil, errGet
 	}
+	if errLimit := checkMemoryLimit(commonbl.PS_REQUEST, res, settings.MemoryLimit); errLimit != nil {
+		return nil, nil, nil, nil, errLimit
+	}
+	storeRawResponse(commonbl.PS_REQUEST, res)
 	go goGetPsData(res, logger, psdataChan)
 
 	processes = <-processesChan
@@ -76,6 +105,79 @@ func GetSambaStatus(requestHandler *commonbl.PipeHandler, responseHandler *commo
 	return locks, processes, shares, psdata, nil
 }
 
+// GetJsonData - Get the entries of the JSON list samba_statusd answers the request with, e. g. the plugin results to the PLUGIN_REQUEST.
+// Waiting for a response is stopped, when the context is done. Other than GetSambaStatus, the function can run several times at once,
+// since the responses are passed to the request waiting for them by their ID
+func GetJsonData[T any](ctx context.Context, requestHandler commonbl.MessageHandler, responseHandler commonbl.MessageHandler, request commonbl.RequestType, logger commonbl.Logger, settings RequestSettings) ([]T, error) {
+	errVersion := checkProtocolVersion(ctx, requestHandler, responseHandler, logger, settings)
+	if errVersion != nil {
+		return nil, errVersion
+	}
+
+	res, errGet := getSmbStatusDataRetry(ctx, requestHandler, responseHandler, request, logger, settings)
+	if errGet != nil {
+		return nil, errGet
+	}
+
+	list := []T{}
+	if !smbstatusreader.ReadJsonList(res, request, &list, logger) {
+		return []T{}, nil
+	}
+
+	return list, nil
+}
+
+// checkProtocolVersion - Ask samba_statusd for the protocol version it speaks, before the first data is requested.
+// This way an incompatible samba_statusd is reported, instead of mis-parsing its responses
+func checkProtocolVersion(ctx context.Context, requestHandler commonbl.MessageHandler, responseHandler commonbl.MessageHandler, logger commonbl.Logger, settings RequestSettings) error {
+	if isVersionChecked(requestHandler) {
+		return nil
+	}
+
+	res, errGet := getSmbStatusDataRetry(ctx, requestHandler, responseHandler, commonbl.VERSION_REQUEST, logger, settings)
+	if errGet != nil {
+		switch errGet.(type) {
+		case *SmbStatusTimeOutError:
+			logger.WriteErrorMessage("samba_statusd did not tell its protocol version. Either it is not running, or it is older than samba_exporter. Install samba_exporter and samba_statusd in the same version")
+		}
+		return errGet
+	}
+
+	protocolVersion, programVersion, errParse := commonbl.ParseVersionResponseData(res)
+	if errParse != nil {
+		return errParse
+	}
+
+	if protocolVersion != commonbl.PROTOCOL_VERSION {
+		return NewProtocolVersionMismatchError(protocolVersion, programVersion)
+	}
+
+	logger.WriteVerbose(fmt.Sprintf("samba_statusd version \"%s\" speaks the protocol version %d", programVersion, protocolVersion))
+	setVersionChecked(requestHandler, true)
+
+	return nil
+}
+
+// isVersionChecked - Tell if samba_statusd already answered the VERSION_REQUEST with a compatible version on the request handler
+func isVersionChecked(requestHandler commonbl.MessageHandler) bool {
+	versionCheckedMux.Lock()
+	defer versionCheckedMux.Unlock()
+
+	return versionCheckedHandlers[requestHandler]
+}
+
+// setVersionChecked - Keep if the protocol version of samba_statusd is checked on the request handler
+func setVersionChecked(requestHandler commonbl.MessageHandler, checked bool) {
+	versionCheckedMux.Lock()
+	defer versionCheckedMux.Unlock()
+
+	if checked {
+		versionCheckedHandlers[requestHandler] = true
+	} else {
+		delete(versionCheckedHandlers, requestHandler)
+	}
+}
+
 func goGetProcessData(res string, logger commonbl.Logger, c chan []smbstatusreader.ProcessData) {
 	processes := smbstatusreader.GetProcessData(res, logger)
 
@@ -100,75 +202,96 @@ func goGetPsData(res string, logger commonbl.Logger, c chan []commonbl.PsUtilPid
 	c <- locks
 }
 
-func getSmbStatusDataTimeOut(requestHandler *commonbl.PipeHandler, responseHandler *commonbl.PipeHandler, request commonbl.RequestType, logger commonbl.Logger, requestTimeOut int) (string, error) {
-	c := make(chan smbResponse, 1)
+// getSmbStatusDataRetry - Get the response data for the request from samba_statusd, retry the request when it times out
+func getSmbStatusDataRetry(ctx context.Context, requestHandler commonbl.MessageHandler, responseHandler commonbl.MessageHandler, request commonbl.RequestType, logger commonbl.Logger, settings RequestSettings) (string, error) {
 	var data string
+	err := doWithRetry(ctx, request, settings, logger, func() error {
+		var errGet error
+		data, errGet = getSmbStatusDataTimeOut(ctx, requestHandler, responseHandler, request, logger, settings.TimeOut)
+		return errGet
+	})
 
-	go goGetSmbStatusData(requestHandler, responseHandler, request, logger, c)
-	select {
-	case res := <-c:
-		if res.Error == nil {
-			data = res.Data
-		} else {
-			return "", res.Error
-		}
-	case <-time.After(time.Second * time.Duration(requestTimeOut)):
-		logger.WriteVerbose("Clear request pipe after request time out")
-		errClear := requestHandler.WritePipeString("")
-		if errClear != nil {
-			panic(errClear)
+	return data, err
+}
+
+// getSmbStatusDataTimeOut - Get the response data for the request from samba_statusd. Stop waiting when the request times out or the context is done
+func getSmbStatusDataTimeOut(ctx context.Context, requestHandler commonbl.MessageHandler, responseHandler commonbl.MessageHandler, request commonbl.RequestType, logger commonbl.Logger, requestTimeOut int) (string, error) {
+	reader := getResponseReader(responseHandler)
+	id := getNextRequestId()
+	responses := reader.wait(request, id, logger)
+	// A response arriving after the request stopped waiting is dropped by the reader
+	defer reader.cancel(id)
+
+	written := make(chan error, 1)
+	go goSendRequest(requestHandler, request, id, logger, written)
+	timeOut := time.After(time.Second * time.Duration(requestTimeOut))
+	for {
+		select {
+		case errWrite := <-written:
+			if errWrite != nil {
+				return "", errWrite
+			}
+			logger.WriteVerbose(fmt.Sprintf("Wait for \"%s\" response with ID %d on pipe", request, id))
+			reader.start()
+			// Stop selecting the channel, it is not written again
+			written = nil
+		case res := <-responses:
+			if res.Error != nil {
+				return "", res.Error
+			}
+			logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" response with ID %d from pipe", request, id))
+			return res.Data, nil
+		case <-timeOut:
+			// samba_statusd might got restarted in an other version, so check the version again with the next request
+			setVersionChecked(requestHandler, false)
+			logger.WriteVerbose("Clear request pipe after request time out")
+			clearRequestPipe(requestHandler, logger)
+			return "", NewSmbStatusTimeOutError(request)
+		case <-ctx.Done():
+			logger.WriteVerbose(fmt.Sprintf("Clear request pipe after the \"%s\" request got cancelled", request))
+			clearRequestPipe(requestHandler, logger)
+			return "", ctx.Err()
 		}
-		return "", NewSmbStatusTimeOutError(request)
 	}
-
-	return data, nil
 }
 
-func goGetSmbStatusData(requestHandler *commonbl.PipeHandler, responseHandler *commonbl.PipeHandler, request commonbl.RequestType, logger commonbl.Logger, c chan smbResponse) {
-	retStr, err := getSmbStatusData(requestHandler, responseHandler, request, logger)
-
-	ret := smbResponse{retStr, err}
-
-	c <- ret
+// clearRequestPipe - Send an empty message, so samba_statusd stops waiting for the rest of the request. Errors are only logged,
+// the next request will fail in case samba_statusd is not reachable
+func clearRequestPipe(requestHandler commonbl.MessageHandler, logger commonbl.Logger) {
+	errClear := requestHandler.WritePipeString("")
+	if errClear != nil {
+		logger.WriteErrorWithAddition(errClear, "while clearing the request pipe")
+	}
 }
 
-func getSmbStatusData(requestHandler *commonbl.PipeHandler, responseHandler *commonbl.PipeHandler, request commonbl.RequestType, logger commonbl.Logger) (string, error) {
-	// Ensure we run only one request per time on the pipes
+// getNextRequestId - Get the ID for the next request, each request gets an own ID
+func getNextRequestId() int {
 	requestMux.Lock()
 	defer requestMux.Unlock()
 	requestCount++
-	requestString := commonbl.GetRequest(request, requestCount)
-
-	logger.WriteVerbose(fmt.Sprintf("Send \"%s\" request with ID %d on pipe", request, requestCount))
 
-	errWrite := requestHandler.WritePipeString(requestString)
-	if errWrite != nil {
-		return "", errWrite
-	}
-
-	logger.WriteVerbose(fmt.Sprintf("Wait for \"%s\" response with ID %d on pipe", request, requestCount))
+	return requestCount
+}
 
-	response := ""
-	var errRead error
+// goSendRequest - Send the request with the ID and write the result to the channel. Writing a named pipe blocks
+// until samba_statusd opens it, so the request is sent in an own go routine, that might outlive the request time out
+func goSendRequest(requestHandler commonbl.MessageHandler, request commonbl.RequestType, id int, logger commonbl.Logger, c chan error) {
+	logger.WriteVerbose(fmt.Sprintf("Send \"%s\" request with ID %d on pipe", request, id))
 
-	// as long as the response pipe is empty, wait for response
-	for response == "" {
-		response, errRead = responseHandler.WaitForPipeInputString()
-		if errRead != nil {
-			return "", errRead
-		}
-	}
+	c <- requestHandler.WritePipeString(commonbl.GetRequest(request, id))
+}
 
-	logger.WriteVerbose(fmt.Sprintf("Handle \"%s\" response with ID %d from pipe", request, requestCount))
+// GetDroppedResponseCount - Get the number of responses from samba_statusd, that got dropped since they did not match the request waiting for a response
+func GetDroppedResponseCount() int {
+	droppedResponseMux.Lock()
+	defer droppedResponseMux.Unlock()
 
-	header, data, errSplit := commonbl.SplitResponse(response)
-	if errSplit != nil {
-		return "", errSplit
-	}
+	return droppedResponseCount
+}
 
-	if !commonbl.CheckResponseHeader(header, request, requestCount) {
-		return "", commonbl.NewReaderError(response, request, requestCount)
-	}
+func addDroppedResponse() {
+	droppedResponseMux.Lock()
+	defer droppedResponseMux.Unlock()
 
-	return data, nil
+	droppedResponseCount++
 }
@@ -1,8 +1,12 @@
 package pipecomunication
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
+	"strings"
 	"testing"
 
 	"tobi.backfrak.de/internal/commonbl"
@@ -14,11 +18,355 @@ import (
 // by a BSD-style license that can be found in the
 // LICENSE file.
 
+// startTestStatusd - Listen on a TCP port and answer the VERSION_REQUEST with the given protocol version and the other requests with the test data.
+// With staleResponses, a response to an other request ID is sent before each response
+func startTestStatusd(t *testing.T, protocolVersion int, staleResponses bool) (net.Listener, *commonbl.TcpHandler) {
+	listener, errListen := commonbl.ListenTcp("127.0.0.1:0", nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+
+	go func() {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			return
+		}
+		handler := commonbl.NewTcpConnectionHandler(conn)
+		defer handler.Close()
+		testData := map[commonbl.RequestType]string{
+			commonbl.VERSION_REQUEST:       fmt.Sprintf("PROTOCOL_VERSION: %d; PROGRAM_VERSION: test", protocolVersion),
+			commonbl.PROCESS_REQUEST:       commonbl.TestProcessResponse,
+			commonbl.SHARE_REQUEST:         commonbl.TestShareResponse,
+			commonbl.LOCK_REQUEST:          commonbl.TestLockResponse,
+			commonbl.PS_REQUEST:            commonbl.TestPsResponse(),
+			commonbl.PLUGIN_REQUEST:        `[{"plugin": "quota", "duration_seconds": 0.1, "metrics": [{"name": "quota_used_bytes", "help": "Bytes used", "type": "gauge", "value": 42}]}]`,
+			commonbl.CGROUP_REQUEST:        commonbl.TestCgroupResponse(),
+			commonbl.CTDB_NODES_REQUEST:    commonbl.TestCtdbNodesResponse(),
+			commonbl.SHARE_CONFIG_REQUEST:  commonbl.TestShareConfigResponse(),
+			commonbl.SHARE_HANDLES_REQUEST: commonbl.TestShareHandlesResponse(),
+		}
+		for {
+			request, errRead := handler.WaitForPipeInputString()
+			if errRead != nil {
+				return
+			}
+			for requestType, data := range testData {
+				if strings.HasPrefix(request, string(requestType)) {
+					id, _ := commonbl.GetIdFromRequest(request)
+					if staleResponses {
+						handler.WritePipeString(commonbl.GetResponse(commonbl.GetResponseHeader(requestType, id+1000), "stale data"))
+					}
+					handler.WritePipeString(commonbl.GetResponse(commonbl.GetResponseHeader(requestType, id), data))
+				}
+			}
+		}
+	}()
+
+	client := commonbl.NewTcpClientHandler(listener.Addr().String(), nil)
+
+	return listener, client
+}
+
+func TestGetSambaStatusVersionCheck(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, false)
+	defer listener.Close()
+	defer client.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	locks, processes, shares, psData, err := GetSambaStatus(context.Background(), client, client, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(locks) != 1 || len(processes) != 1 || len(shares) != 1 || len(psData) != 2 {
+		t.Errorf("Got %d locks, %d processes, %d shares and %d ps data entries, but expected 1, 1, 1 and 2", len(locks), len(processes), len(shares), len(psData))
+	}
+
+	if !isVersionChecked(client) {
+		t.Errorf("The version of the handler is not marked as checked")
+	}
+}
+
+func TestGetLastRawResponses(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, false)
+	defer listener.Close()
+	defer client.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	_, _, _, _, err := GetSambaStatus(context.Background(), client, client, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	responses := GetLastRawResponses()
+	if len(responses) != 4 {
+		t.Fatalf("Got %d raw responses but expected 4", len(responses))
+	}
+	for i, expected := range []commonbl.RequestType{commonbl.LOCK_REQUEST, commonbl.PROCESS_REQUEST, commonbl.PS_REQUEST, commonbl.SHARE_REQUEST} {
+		if responses[i].Request != expected {
+			t.Errorf("Got the raw response of '%s' at %d but expected '%s'", responses[i].Request, i, expected)
+		}
+	}
+	if responses[0].Data != commonbl.TestLockResponse {
+		t.Errorf("Got the raw lock response '%s' but expected the test lock response", responses[0].Data)
+	}
+	if responses[0].Received.IsZero() {
+		t.Errorf("The time the raw lock response was received is not set")
+	}
+}
+
+func TestGetJsonDataPluginResults(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, false)
+	defer listener.Close()
+	defer client.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	results, err := GetJsonData[commonbl.PluginResult](context.Background(), client, client, commonbl.PLUGIN_REQUEST, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(results) != 1 || results[0].Plugin != "quota" || len(results[0].Metrics) != 1 {
+		t.Errorf("The plugin results '%v' are not expected", results)
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestGetJsonDataCgroupData(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, false)
+	defer listener.Close()
+	defer client.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	cgroups, err := GetJsonData[commonbl.CgroupData](context.Background(), client, client, commonbl.CGROUP_REQUEST, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(cgroups) != 1 || cgroups[0] != commonbl.GetTestCgroupData()[0] {
+		t.Errorf("The cgroups '%v' are not expected", cgroups)
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestGetJsonDataCtdbNodes(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, false)
+	defer listener.Close()
+	defer client.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	nodes, err := GetJsonData[commonbl.CtdbNode](context.Background(), client, client, commonbl.CTDB_NODES_REQUEST, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	expected := commonbl.GetTestCtdbNodes()
+	if len(nodes) != len(expected) || nodes[0] != expected[0] || nodes[1] != expected[1] {
+		t.Errorf("The nodes '%v' are not expected", nodes)
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestGetJsonDataShareConfig(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, false)
+	defer listener.Close()
+	defer client.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	shares, err := GetJsonData[commonbl.ShareConfig](context.Background(), client, client, commonbl.SHARE_CONFIG_REQUEST, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(shares) != 2 || shares[1].Name != "data" || shares[1].Parameters["read only"] != "No" {
+		t.Errorf("The shares '%v' are not expected", shares)
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestGetJsonDataShareHandles(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, false)
+	defer listener.Close()
+	defer client.Close()
+
+	logger := testhelper.NewTestLogger(true)
+	handles, err := GetJsonData[commonbl.ShareHandles](context.Background(), client, client, commonbl.SHARE_HANDLES_REQUEST, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(handles) != 1 || handles[0].Share != "/usr/share/data" || handles[0].PersistentCount != 1 {
+		t.Errorf("The handles '%v' are not expected", handles)
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestGetSambaStatusVersionMismatch(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION+1, false)
+	defer listener.Close()
+	defer client.Close()
+
+	_, _, _, _, err := GetSambaStatus(context.Background(), client, client, testhelper.NewTestLogger(true), NewRequestSettings(2))
+	if err == nil {
+		t.Fatalf("Exptected an error but got none")
+	}
+
+	switch err.(type) {
+	case *ProtocolVersionMismatchError:
+		fmt.Fprintln(os.Stdout, "OK")
+	default:
+		t.Errorf("Got error '%s' type, but expected '*ProtocolVersionMismatchError'", err.Error())
+	}
+
+	if isVersionChecked(client) {
+		t.Errorf("The version of the handler is marked as checked")
+	}
+}
+
+func TestGetSambaStatusDropStaleResponses(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, true)
+	defer listener.Close()
+	defer client.Close()
+
+	droppedBefore := GetDroppedResponseCount()
+	logger := testhelper.NewTestLogger(true)
+	locks, processes, shares, psData, err := GetSambaStatus(context.Background(), client, client, logger, NewRequestSettings(2))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(locks) != 1 || len(processes) != 1 || len(shares) != 1 || len(psData) != 2 {
+		t.Errorf("Got %d locks, %d processes, %d shares and %d ps data entries, but expected 1, 1, 1 and 2", len(locks), len(processes), len(shares), len(psData))
+	}
+
+	// One stale response for the VERSION_REQUEST and each of the 4 data requests
+	if GetDroppedResponseCount()-droppedBefore != 5 {
+		t.Errorf("Got '%d' dropped responses but expected '5'", GetDroppedResponseCount()-droppedBefore)
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestGetSambaStatusMemoryLimit(t *testing.T) {
+	listener, client := startTestStatusd(t, commonbl.PROTOCOL_VERSION, false)
+	defer listener.Close()
+	defer client.Close()
+
+	settings := NewRequestSettings(2)
+	settings.MemoryLimit = 1
+	_, _, _, _, err := GetSambaStatus(context.Background(), client, client, testhelper.NewTestLogger(true), settings)
+	switch err.(type) {
+	case *MemoryLimitError:
+		if err.(*MemoryLimitError).Request != commonbl.PROCESS_REQUEST {
+			t.Errorf("Got the request '%s' in the error, but expected the first data request", err.(*MemoryLimitError).Request)
+		}
+	default:
+		t.Errorf("Got error '%v' but expected a MemoryLimitError", err)
+	}
+}
+
+func TestGetSmbStatusDataAfterLostResponse(t *testing.T) {
+	listener, errListen := commonbl.ListenTcp("127.0.0.1:0", nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			return
+		}
+		handler := commonbl.NewTcpConnectionHandler(conn)
+		defer handler.Close()
+		answered := 0
+		for {
+			request, errRead := handler.WaitForPipeInputString()
+			if errRead != nil {
+				return
+			}
+			if request == "" {
+				continue
+			}
+			answered++
+			if answered == 1 {
+				// Lose the response to the first request
+				continue
+			}
+			id, _ := commonbl.GetIdFromRequest(request)
+			handler.WritePipeString(commonbl.GetResponse(commonbl.GetResponseHeader(commonbl.PROCESS_REQUEST, id), commonbl.TestProcessResponse))
+		}
+	}()
+
+	client := commonbl.NewTcpClientHandler(listener.Addr().String(), nil)
+	defer client.Close()
+	logger := testhelper.NewTestLogger(true)
+
+	_, errLost := getSmbStatusDataTimeOut(context.Background(), client, client, commonbl.PROCESS_REQUEST, logger, 1)
+	switch errLost.(type) {
+	case *SmbStatusTimeOutError:
+		fmt.Fprintln(os.Stdout, "OK")
+	default:
+		t.Fatalf("Got error '%v' but expected a SmbStatusTimeOutError", errLost)
+	}
+
+	// The reader still waits for the lost response, the next request must get its response anyway
+	data, errGet := getSmbStatusDataTimeOut(context.Background(), client, client, commonbl.PROCESS_REQUEST, logger, 1)
+	if errGet != nil {
+		t.Fatalf("Got error '%s' but expected none", errGet.Error())
+	}
+	if data != commonbl.TestProcessResponse {
+		t.Errorf("Got the data '%s' but expected the test process response", data)
+	}
+}
+
+// brokenHandler - A commonbl.MessageHandler that fails like a broken pipe
+type brokenHandler struct{}
+
+func (handler *brokenHandler) WaitForPipeInputString() (string, error) {
+	return "", errors.New("broken pipe")
+}
+
+func (handler *brokenHandler) WritePipeString(data string) error {
+	return errors.New("broken pipe")
+}
+
+func (handler *brokenHandler) GetPipeFilePath() string {
+	return "broken"
+}
+
+func TestClearRequestPipeBroken(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	clearRequestPipe(&brokenHandler{}, logger)
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+}
+
+// Keep this test last, the timed out request keeps waiting for its response
 func TestGetSambaStatusTimeout(t *testing.T) {
 	requestHandler := *commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := *commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := *testhelper.NewTestLogger(true)
-	_, _, _, _, err := GetSambaStatus(&requestHandler, &responseHandler, &logger, 2)
+	_, _, _, _, err := GetSambaStatus(context.Background(), &requestHandler, &responseHandler, &logger, NewRequestSettings(2))
 
 	if err == nil {
 		t.Errorf("Exptected an error but got none")
@@ -31,7 +379,11 @@ func TestGetSambaStatusTimeout(t *testing.T) {
 		t.Errorf("Got error '%s' type, but expected '*SmbStatusTimeOutError'", err.Error())
 	}
 
-	if logger.GetOutputCount() != 3 {
-		t.Errorf("The OutputCount '%d' is not the expected '3'", logger.GetOutputCount())
+	if logger.GetOutputCount() != 4 {
+		t.Errorf("The OutputCount '%d' is not the expected '4'", logger.GetOutputCount())
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
 	}
 }
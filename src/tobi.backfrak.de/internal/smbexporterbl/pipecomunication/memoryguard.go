@@ -0,0 +1,64 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"runtime/metrics"
+	"sync"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// PARSE_MEMORY_FACTOR - How many times the size of a response is estimated to be needed, while the response is stored and parsed
+const PARSE_MEMORY_FACTOR = 10
+
+// The metric of the go runtime telling the heap memory still in use after the last garbage collection
+const heapLiveMetric = "/gc/heap/live:bytes"
+
+var memoryLimitAbortCount = 0
+var memoryLimitAbortMux sync.Mutex
+
+// GetMemoryLimitAbortCount - Get the number of collections aborted, since parsing the response would have exceeded the memory limit
+func GetMemoryLimitAbortCount() int {
+	memoryLimitAbortMux.Lock()
+	defer memoryLimitAbortMux.Unlock()
+
+	return memoryLimitAbortCount
+}
+
+func addMemoryLimitAbort() {
+	memoryLimitAbortMux.Lock()
+	defer memoryLimitAbortMux.Unlock()
+
+	memoryLimitAbortCount++
+}
+
+// checkMemoryLimit - Return a MemoryLimitError, when the memory needed to parse the response is estimated to exceed the limit.
+// The heap in use is added to the estimation. No limit is checked, when the limit is 0 or less
+func checkMemoryLimit(request commonbl.RequestType, response string, limit int64) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	estimated := getHeapInUse() + int64(len(response))*PARSE_MEMORY_FACTOR
+	if estimated > limit {
+		addMemoryLimitAbort()
+		return NewMemoryLimitError(request, len(response), limit)
+	}
+
+	return nil
+}
+
+// getHeapInUse - Get the heap memory in use after the last garbage collection in bytes
+func getHeapInUse() int64 {
+	sample := []metrics.Sample{{Name: heapLiveMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return int64(sample[0].Value.Uint64())
+}
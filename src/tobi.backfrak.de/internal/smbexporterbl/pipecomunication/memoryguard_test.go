@@ -0,0 +1,36 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestCheckMemoryLimit(t *testing.T) {
+	response := strings.Repeat("x", 1024)
+
+	if err := checkMemoryLimit(commonbl.LOCK_REQUEST, response, 0); err != nil {
+		t.Errorf("Got error '%s' but expected none, since no limit is set", err.Error())
+	}
+
+	if err := checkMemoryLimit(commonbl.LOCK_REQUEST, response, getHeapInUse()+1024*1024*1024); err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	abortsBefore := GetMemoryLimitAbortCount()
+	err := checkMemoryLimit(commonbl.LOCK_REQUEST, response, 1024)
+	switch err.(type) {
+	case *MemoryLimitError:
+	default:
+		t.Errorf("Got error '%v' but expected a MemoryLimitError", err)
+	}
+	if GetMemoryLimitAbortCount()-abortsBefore != 1 {
+		t.Errorf("Got '%d' aborts but expected '1'", GetMemoryLimitAbortCount()-abortsBefore)
+	}
+}
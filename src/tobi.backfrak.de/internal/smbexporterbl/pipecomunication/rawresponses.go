@@ -0,0 +1,46 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// RawResponse - The data of a response of samba_statusd as received, before it was parsed
+type RawResponse struct {
+	Request  commonbl.RequestType
+	Received time.Time
+	Data     string
+}
+
+var lastRawResponses = map[commonbl.RequestType]RawResponse{}
+var lastRawResponsesMux sync.Mutex
+
+// GetLastRawResponses - Get the last response samba_statusd sent for each request type, sorted by the request type
+func GetLastRawResponses() []RawResponse {
+	lastRawResponsesMux.Lock()
+	defer lastRawResponsesMux.Unlock()
+
+	ret := []RawResponse{}
+	for _, response := range lastRawResponses {
+		ret = append(ret, response)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Request < ret[j].Request })
+
+	return ret
+}
+
+// storeRawResponse - Keep the data as last response of samba_statusd for the request type
+func storeRawResponse(request commonbl.RequestType, data string) {
+	lastRawResponsesMux.Lock()
+	defer lastRawResponsesMux.Unlock()
+
+	lastRawResponses[request] = RawResponse{Request: request, Received: time.Now(), Data: data}
+}
@@ -0,0 +1,142 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"sync"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// waitingRequest - A request sent to samba_statusd, that waits for its response on the channel
+type waitingRequest struct {
+	request commonbl.RequestType
+	c       chan smbResponse
+}
+
+// responseReader - Reads the responses of samba_statusd from a response handler and passes each one to the request waiting for it,
+// found by the request ID. Responses no request waits for, e. g. the late ones of requests that timed out, are dropped.
+// The reader runs as long as requests wait, no lock is held while it waits for a response
+type responseReader struct {
+	handler commonbl.MessageHandler
+	logger  commonbl.Logger
+	mutex   sync.Mutex
+	waiting map[int]waitingRequest
+	reading bool
+}
+
+var responseReaders = map[commonbl.MessageHandler]*responseReader{}
+var responseReadersMux sync.Mutex
+
+// getResponseReader - Get the responseReader of the response handler
+func getResponseReader(handler commonbl.MessageHandler) *responseReader {
+	responseReadersMux.Lock()
+	defer responseReadersMux.Unlock()
+
+	reader, found := responseReaders[handler]
+	if !found {
+		reader = &responseReader{handler: handler, waiting: map[int]waitingRequest{}}
+		responseReaders[handler] = reader
+	}
+
+	return reader
+}
+
+// wait - Wait for the response to the request with the ID, it is sent to the returned channel. Call wait before the request is sent,
+// so the response can not arrive before the request waits for it
+func (reader *responseReader) wait(request commonbl.RequestType, id int, logger commonbl.Logger) <-chan smbResponse {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	c := make(chan smbResponse, 1)
+	reader.waiting[id] = waitingRequest{request, c}
+	reader.logger = logger
+
+	return c
+}
+
+// start - Start reading the responses, when no response is read yet. Call start after the request was sent, like this the
+// handler connects to samba_statusd when writing the request, and not while reading at the same time
+func (reader *responseReader) start() {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	if !reader.reading && len(reader.waiting) > 0 {
+		reader.reading = true
+		go reader.read()
+	}
+}
+
+// cancel - Stop waiting for the response to the request with the ID, e. g. after it timed out. The response is dropped, when it arrives later
+func (reader *responseReader) cancel(id int) {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	delete(reader.waiting, id)
+}
+
+// read - Read the responses until no request waits anymore. When reading fails, the error is passed to all waiting requests
+// and the next request starts reading again
+func (reader *responseReader) read() {
+	for {
+		response, errRead := reader.handler.WaitForPipeInputString()
+		if errRead != nil {
+			reader.fail(errRead)
+			return
+		}
+		if response == "" {
+			continue
+		}
+
+		if !reader.dispatch(response) {
+			return
+		}
+	}
+}
+
+// dispatch - Pass the response to the request waiting for it, or drop it. Returns false, when no request waits anymore and reading stops
+func (reader *responseReader) dispatch(response string) bool {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	header, data, errSplit := commonbl.SplitResponse(response)
+	found := false
+	if errSplit == nil {
+		for id, waiting := range reader.waiting {
+			if commonbl.CheckResponseHeader(header, waiting.request, id) {
+				delete(reader.waiting, id)
+				waiting.c <- smbResponse{data, nil}
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		// The response belongs to no waiting request, e. g. one that timed out before. Drop it, so it can not be attributed to an other request
+		reader.logger.WriteVerbose(fmt.Sprintf("Drop the response \"%s\", since no request waits for it", header))
+		addDroppedResponse()
+	}
+
+	if len(reader.waiting) == 0 {
+		reader.reading = false
+		return false
+	}
+
+	return true
+}
+
+// fail - Pass the error to all waiting requests and stop reading
+func (reader *responseReader) fail(err error) {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	for id, waiting := range reader.waiting {
+		delete(reader.waiting, id)
+		waiting.c <- smbResponse{"", err}
+	}
+	reader.reading = false
+}
@@ -0,0 +1,102 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+var timeOutCount = 0
+var timeOutMux sync.Mutex
+
+// RequestSettings - The timeout of requests to samba_statusd and how requests that timed out are retried
+type RequestSettings struct {
+	// TimeOut - The time to wait for a response in seconds
+	TimeOut int
+	// Retries - How often a request that timed out is sent again. No retries are done when 0
+	Retries int
+	// RetryBackoff - The time to wait before the first retry, it doubles with each further retry
+	RetryBackoff time.Duration
+	// BreakerThreshold - The number of requests that timed out in a row, after which no requests are sent for the BreakerCooldown.
+	// The retries of a request are not counted. The circuit breaker is not used when 0
+	BreakerThreshold int
+	// BreakerCooldown - The time no requests are sent, once the BreakerThreshold is reached
+	BreakerCooldown time.Duration
+	// MemoryLimit - The memory in bytes the exporter should not exceed. A response is not parsed, when the memory needed
+	// is estimated to exceed the limit. No limit is checked when 0
+	MemoryLimit int64
+	// MaxResponseSize - The maximum size of a smbstatus output received with gRPC in bytes, larger outputs are not received to their end.
+	// No limit when 0. The named pipes and TCP connections are limited with the MaxMessageSize of their handlers
+	MaxResponseSize int
+}
+
+// NewRequestSettings - Get a new RequestSettings struct without retries
+func NewRequestSettings(timeOut int) RequestSettings {
+	return RequestSettings{TimeOut: timeOut}
+}
+
+// GetTimeOutCount - Get the number of requests to samba_statusd, that timed out
+func GetTimeOutCount() int {
+	timeOutMux.Lock()
+	defer timeOutMux.Unlock()
+
+	return timeOutCount
+}
+
+func addTimeOut() {
+	timeOutMux.Lock()
+	defer timeOutMux.Unlock()
+
+	timeOutCount++
+}
+
+// doWithRetry - Call the function and call it again after the backoff time, as long as it returns a SmbStatusTimeOutError and retries are left.
+// When the BreakerThreshold is set and the circuit is open, a CircuitOpenError is returned without calling the function.
+// When the context is done, its error is returned without further calls
+func doWithRetry(ctx context.Context, request commonbl.RequestType, settings RequestSettings, logger commonbl.Logger, function func() error) error {
+	if settings.BreakerThreshold > 0 {
+		if remaining := statusdBreaker.getOpenTime(); remaining > 0 {
+			return NewCircuitOpenError(request, remaining)
+		}
+	}
+
+	backoff := settings.RetryBackoff
+	for retry := 1; ; retry++ {
+		if errCtx := ctx.Err(); errCtx != nil {
+			return errCtx
+		}
+		err := function()
+		switch err.(type) {
+		case *SmbStatusTimeOutError:
+			addTimeOut()
+			if retry > settings.Retries {
+				if settings.BreakerThreshold > 0 && statusdBreaker.addTimeOut(settings.BreakerThreshold, settings.BreakerCooldown) {
+					logger.WriteErrorMessage(fmt.Sprintf("%d requests to samba_statusd timed out in a row, send no requests for %s", settings.BreakerThreshold, settings.BreakerCooldown))
+				}
+				return err
+			}
+			logger.WriteVerbose(fmt.Sprintf("The \"%s\" request timed out, retry %d of %d in %s", request, retry, settings.Retries, backoff))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff = backoff * 2
+		case nil:
+			if settings.BreakerThreshold > 0 {
+				statusdBreaker.reset()
+			}
+			return nil
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package pipecomunication
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestDoWithRetry(t *testing.T) {
+	settings := RequestSettings{TimeOut: 1, Retries: 3, RetryBackoff: 10 * time.Millisecond}
+	logger := testhelper.NewTestLogger(true)
+	timeOutsBefore := GetTimeOutCount()
+	calls := 0
+	start := time.Now()
+
+	err := doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, logger, func() error {
+		calls++
+		if calls < 3 {
+			return NewSmbStatusTimeOutError(commonbl.LOCK_REQUEST)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if calls != 3 {
+		t.Errorf("The function was called '%d' times, but expected '3'", calls)
+	}
+
+	if GetTimeOutCount()-timeOutsBefore != 2 {
+		t.Errorf("Got '%d' timeouts but expected '2'", GetTimeOutCount()-timeOutsBefore)
+	}
+
+	// The backoff doubles, so 10ms before the first and 20ms before the second retry
+	if time.Since(start) < 30*time.Millisecond {
+		t.Errorf("The retries took '%s', but expected at least '30ms'", time.Since(start))
+	}
+
+	if logger.GetOutputCount() != 2 {
+		t.Errorf("Got '%d' output messages but expected '2'", logger.GetOutputCount())
+	}
+}
+
+func TestDoWithRetryNoRetriesLeft(t *testing.T) {
+	settings := RequestSettings{TimeOut: 1, Retries: 1, RetryBackoff: time.Millisecond}
+	calls := 0
+
+	err := doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, testhelper.NewTestLogger(true), func() error {
+		calls++
+		return NewSmbStatusTimeOutError(commonbl.LOCK_REQUEST)
+	})
+
+	switch err.(type) {
+	case *SmbStatusTimeOutError:
+		fmt.Println("OK")
+	default:
+		t.Errorf("Got error '%v', but expected a '*SmbStatusTimeOutError'", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("The function was called '%d' times, but expected '2'", calls)
+	}
+}
+
+func TestDoWithRetryOtherError(t *testing.T) {
+	settings := RequestSettings{TimeOut: 1, Retries: 3, RetryBackoff: time.Millisecond}
+	calls := 0
+
+	err := doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, testhelper.NewTestLogger(true), func() error {
+		calls++
+		return NewSmbStatusUnexpectedResponseError("some response")
+	})
+
+	switch err.(type) {
+	case *SmbStatusUnexpectedResponseError:
+		fmt.Println("OK")
+	default:
+		t.Errorf("Got error '%v', but expected a '*SmbStatusUnexpectedResponseError'", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("The function was called '%d' times, but expected '1'", calls)
+	}
+}
+
+func TestDoWithRetryCancelled(t *testing.T) {
+	settings := RequestSettings{TimeOut: 1, Retries: 3, RetryBackoff: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	start := time.Now()
+
+	err := doWithRetry(ctx, commonbl.LOCK_REQUEST, settings, testhelper.NewTestLogger(true), func() error {
+		calls++
+		cancel()
+		return NewSmbStatusTimeOutError(commonbl.LOCK_REQUEST)
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Got error '%v', but expected '%v'", err, context.Canceled)
+	}
+
+	if calls != 1 {
+		t.Errorf("The function was called '%d' times, but expected '1'", calls)
+	}
+
+	if time.Since(start) > time.Second {
+		t.Errorf("The cancelled request took '%s', but expected it to stop waiting for the backoff", time.Since(start))
+	}
+
+	err = doWithRetry(ctx, commonbl.LOCK_REQUEST, settings, testhelper.NewTestLogger(true), func() error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("Got error '%v' and '%d' calls, but expected '%v' and no further call", err, calls, context.Canceled)
+	}
+}
+
+func TestDoWithRetryCircuitBreaker(t *testing.T) {
+	statusdBreaker.reset()
+	defer statusdBreaker.reset()
+	settings := RequestSettings{TimeOut: 1, BreakerThreshold: 2, BreakerCooldown: 50 * time.Millisecond}
+	logger := testhelper.NewTestLogger(true)
+	calls := 0
+	timeOut := func() error {
+		calls++
+		return NewSmbStatusTimeOutError(commonbl.LOCK_REQUEST)
+	}
+
+	doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, logger, timeOut)
+	if IsCircuitOpen() {
+		t.Errorf("The circuit is open after one time out, but expected it after two")
+	}
+	doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, logger, timeOut)
+	if !IsCircuitOpen() {
+		t.Errorf("The circuit is closed after two time outs, but expected it open")
+	}
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("Got '%d' errors but expected '1'", logger.GetErrorCount())
+	}
+
+	err := doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, logger, timeOut)
+	switch err.(type) {
+	case *CircuitOpenError:
+		fmt.Println("OK")
+	default:
+		t.Errorf("Got error '%v', but expected a '*CircuitOpenError'", err)
+	}
+	if calls != 2 {
+		t.Errorf("The function was called '%d' times, but expected '2', since the circuit is open", calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	err = doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, logger, func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if calls != 3 || IsCircuitOpen() {
+		t.Errorf("The function was called '%d' times and the circuit is open: %t, but expected '3' and closed", calls, IsCircuitOpen())
+	}
+}
+
+func TestDoWithRetryCircuitBreakerReopens(t *testing.T) {
+	statusdBreaker.reset()
+	defer statusdBreaker.reset()
+	settings := RequestSettings{TimeOut: 1, BreakerThreshold: 3, BreakerCooldown: 10 * time.Millisecond}
+	logger := testhelper.NewTestLogger(true)
+	timeOut := func() error {
+		return NewSmbStatusTimeOutError(commonbl.LOCK_REQUEST)
+	}
+
+	for i := 0; i < 3; i++ {
+		doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, logger, timeOut)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if IsCircuitOpen() {
+		t.Fatalf("The circuit is open after the cooldown, but expected it closed")
+	}
+
+	// The first request after the cooldown timed out as well, so the circuit opens again at once
+	doWithRetry(context.Background(), commonbl.LOCK_REQUEST, settings, logger, timeOut)
+	if !IsCircuitOpen() {
+		t.Errorf("The circuit is closed, but expected it open again after the request after the cooldown timed out")
+	}
+}
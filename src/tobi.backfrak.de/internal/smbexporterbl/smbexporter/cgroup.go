@@ -0,0 +1,85 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+)
+
+// The prefix of the metrics about the cgroups samba runs in
+const CGROUP_METRIC_PREFIX = "cgroup_"
+
+// The label with the path of the cgroup below the cgroup root
+const CGROUP_LABEL = "cgroup"
+
+// cgroupMetric - A metric exported for each cgroup samba_statusd reports
+type cgroupMetric struct {
+	name      string
+	help      string
+	valueType prometheus.ValueType
+	value     func(data commonbl.CgroupData) float64
+}
+
+var cgroupMetrics = []cgroupMetric{
+	{"cpu_usage_seconds_total", "CPU time used by all processes of the cgroup in seconds", prometheus.CounterValue,
+		func(data commonbl.CgroupData) float64 { return data.CpuUsageSeconds }},
+	{"cpu_user_seconds_total", "CPU time used by all processes of the cgroup in user mode in seconds", prometheus.CounterValue,
+		func(data commonbl.CgroupData) float64 { return data.CpuUserSeconds }},
+	{"cpu_system_seconds_total", "CPU time used by all processes of the cgroup in kernel mode in seconds", prometheus.CounterValue,
+		func(data commonbl.CgroupData) float64 { return data.CpuSystemSeconds }},
+	{"cpu_throttled_seconds_total", "Time the processes of the cgroup were throttled by its CPU limit in seconds", prometheus.CounterValue,
+		func(data commonbl.CgroupData) float64 { return data.CpuThrottledSeconds }},
+	{"memory_usage_bytes", "Memory used by the cgroup in bytes, including the page cache", prometheus.GaugeValue,
+		func(data commonbl.CgroupData) float64 { return float64(data.MemoryUsageBytes) }},
+	{"io_read_bytes_total", "Bytes read from block devices by the cgroup", prometheus.CounterValue,
+		func(data commonbl.CgroupData) float64 { return float64(data.IoReadBytes) }},
+	{"io_write_bytes_total", "Bytes written to block devices by the cgroup", prometheus.CounterValue,
+		func(data commonbl.CgroupData) float64 { return float64(data.IoWriteBytes) }},
+	{"task_count", "Processes and threads running in the cgroup", prometheus.GaugeValue,
+		func(data commonbl.CgroupData) float64 { return float64(data.TaskCount) }},
+}
+
+// setCgroupMetrics - Request the resource usage of the cgroups samba runs in and send it. Like the metrics of the plugins, the metrics are
+// not described when the exporter is registered, since samba_statusd only reports cgroups when samba runs under systemd
+func (smbExporter *SambaExporter) setCgroupMetrics(ctx context.Context, collectors []string, ch chan<- prometheus.Metric) {
+	if !smbExporter.isCollected(CGROUP_METRIC_PREFIX+cgroupMetrics[0].name, collectors) {
+		return
+	}
+
+	cgroups, errGet := requestList(ctx, smbExporter, commonbl.CGROUP_REQUEST, pipecomunication.GetCgroupsGrpc)
+	if errGet != nil {
+		smbExporter.Logger.WriteErrorWithAddition(errGet, "while requesting the cgroups")
+		return
+	}
+
+	smbExporter.sendCgroupMetrics(cgroups, ch)
+}
+
+// sendCgroupMetrics - Send the metrics of each cgroup
+func (smbExporter *SambaExporter) sendCgroupMetrics(cgroups []commonbl.CgroupData, ch chan<- prometheus.Metric) {
+	if _, isConst := smbExporter.ConstLabels[CGROUP_LABEL]; isConst {
+		smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("The constant label '%s' is also a label of the cgroup metrics, the metrics will not be exported", CGROUP_LABEL))
+		return
+	}
+
+	for _, metric := range cgroupMetrics {
+		name := CGROUP_METRIC_PREFIX + metric.name
+		desc := prometheus.NewDesc(prometheus.BuildFQName(EXPORTER_LABEL_PREFIX, "", name), metric.help, []string{CGROUP_LABEL}, smbExporter.ConstLabels)
+		for _, data := range cgroups {
+			constMetric, errMetric := prometheus.NewConstMetric(desc, metric.valueType, metric.value(data), data.Path)
+			if errMetric != nil {
+				smbExporter.Logger.WriteErrorWithAddition(errMetric, fmt.Sprintf("while exporting the metric '%s'", name))
+				continue
+			}
+			ch <- constMetric
+		}
+	}
+}
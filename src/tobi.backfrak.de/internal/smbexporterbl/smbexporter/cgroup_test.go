@@ -0,0 +1,87 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestSendCgroupMetrics(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	cgroups := append(commonbl.GetTestCgroupData(), commonbl.CgroupData{Path: "system.slice/nmbd.service", TaskCount: 1})
+
+	ch := make(chan prometheus.Metric, 100)
+	exporter.sendCgroupMetrics(cgroups, ch)
+	close(ch)
+
+	values := make(map[string]float64)
+	counters := 0
+	for metric := range ch {
+		var written dto.Metric
+		errWrite := metric.Write(&written)
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		name := strings.Split(strings.Split(metric.Desc().String(), "fqName: \"")[1], "\"")[0]
+		if written.GetCounter() != nil {
+			counters++
+			values[name+"{"+written.GetLabel()[0].GetValue()+"}"] = written.GetCounter().GetValue()
+		} else {
+			values[name+"{"+written.GetLabel()[0].GetValue()+"}"] = written.GetGauge().GetValue()
+		}
+	}
+
+	if len(values) != 2*len(cgroupMetrics) || counters != 12 {
+		t.Errorf("Got %d metrics with %d counters, but expected %d with 12 counters", len(values), counters, 2*len(cgroupMetrics))
+	}
+	expected := map[string]float64{
+		"samba_cgroup_cpu_usage_seconds_total{system.slice/smbd.service}": 1234.5,
+		"samba_cgroup_memory_usage_bytes{system.slice/smbd.service}":      104857600,
+		"samba_cgroup_io_write_bytes_total{system.slice/smbd.service}":    3145728,
+		"samba_cgroup_task_count{system.slice/nmbd.service}":              1,
+	}
+	for name, value := range expected {
+		if values[name] != value {
+			t.Errorf("The metric '%s' is '%f', but expected '%f'", name, values[name], value)
+		}
+	}
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestSendCgroupMetricsConstLabel(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.ConstLabels = prometheus.Labels{CGROUP_LABEL: "a"}
+
+	ch := make(chan prometheus.Metric, 100)
+	exporter.sendCgroupMetrics(commonbl.GetTestCgroupData(), ch)
+	if len(ch) != 0 || logger.GetErrorCount() != 1 {
+		t.Errorf("Got %d metrics and %d errors, but expected none and one error, since the cgroup label is a constant label", len(ch), logger.GetErrorCount())
+	}
+}
+
+func TestSetCgroupMetricsDisabled(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	settings := getNewStatisticGenSettings()
+	settings.DisabledCollectors = []string{"cgroup"}
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, settings)
+
+	ch := make(chan prometheus.Metric, 10)
+	exporter.setCgroupMetrics(context.Background(), nil, ch)
+	if len(ch) != 0 || logger.GetErrorCount() != 0 {
+		t.Errorf("Got %d metrics and %d errors, but the cgroup collector is disabled", len(ch), logger.GetErrorCount())
+	}
+}
@@ -0,0 +1,56 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"errors"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// The key of the request for the samba status in the singleflight.Group of the exporter
+const statusFlightKey = "samba_status"
+
+// sambaStatusTables - The data tables of one request to samba_statusd, shared by the scrapes running at the same time
+type sambaStatusTables struct {
+	locks     []smbstatusreader.LockData
+	processes []smbstatusreader.ProcessData
+	shares    []smbstatusreader.ShareData
+	psData    []commonbl.PsUtilPidData
+}
+
+// getSambaStatus - Get all data tables from samba_statusd. Scrapes running at the same time share one request, so all of them
+// get the same snapshot. When the shared request got cancelled with the context of an other scrape, the request is sent again
+func (smbExporter *SambaExporter) getSambaStatus(ctx context.Context) ([]smbstatusreader.LockData, []smbstatusreader.ProcessData, []smbstatusreader.ShareData, []commonbl.PsUtilPidData, error) {
+	resChan := smbExporter.statusFlight.DoChan(statusFlightKey, func() (interface{}, error) {
+		locks, processes, shares, psData, err := smbExporter.requestSambaStatus(ctx)
+
+		return sambaStatusTables{locks, processes, shares, psData}, err
+	})
+
+	select {
+	case res := <-resChan:
+		if res.Shared {
+			smbExporter.Logger.WriteVerbose("The request to samba_statusd was shared with other scrapes")
+		}
+		if isContextError(res.Err) && ctx.Err() == nil {
+			// The scrape that sent the request got cancelled, but this one is still waiting for the samba status
+			return smbExporter.requestSambaStatus(ctx)
+		}
+		tables := res.Val.(sambaStatusTables)
+
+		return tables.locks, tables.processes, tables.shares, tables.psData, res.Err
+	case <-ctx.Done():
+		return nil, nil, nil, nil, ctx.Err()
+	}
+}
+
+// isContextError - Tell if the error is from a context that got cancelled or reached its deadline
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
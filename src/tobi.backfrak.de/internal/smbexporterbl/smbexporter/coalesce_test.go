@@ -0,0 +1,67 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// slowBrokenHandler - Counts the requests written and fails each of them after a delay
+type slowBrokenHandler struct {
+	writes atomic.Int32
+}
+
+func (handler *slowBrokenHandler) WaitForPipeInputString() (string, error) {
+	return "", errors.New("broken pipe")
+}
+
+func (handler *slowBrokenHandler) WritePipeString(data string) error {
+	handler.writes.Add(1)
+	time.Sleep(200 * time.Millisecond)
+	return errors.New("broken pipe")
+}
+
+func (handler *slowBrokenHandler) GetPipeFilePath() string {
+	return "slow"
+}
+
+func TestGetSambaStatusCoalesced(t *testing.T) {
+	handler := &slowBrokenHandler{}
+	exporter := NewSambaExporter(handler, handler, testhelper.NewTestLogger(true), "0.0.0", 5, getNewStatisticGenSettings())
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, _, _, errs[i] = exporter.getSambaStatus(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	if handler.writes.Load() != 1 {
+		t.Errorf("Got '%d' requests to samba_statusd, but expected '1' shared by both scrapes", handler.writes.Load())
+	}
+
+	for _, err := range errs {
+		if err == nil || err.Error() != "broken pipe" {
+			t.Errorf("Got error '%v', but expected the 'broken pipe' of the shared request", err)
+		}
+	}
+
+	_, _, _, _, err := exporter.getSambaStatus(context.Background())
+	if err == nil || handler.writes.Load() != 2 {
+		t.Errorf("Got error '%v' and '%d' requests, but expected a new request once the shared one is done", err, handler.writes.Load())
+	}
+}
@@ -0,0 +1,44 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector - A prometheus collector exporting the metrics of a SambaExporter, for programs registering the samba metrics
+// in their own registry. Other than the SambaExporter, registering the Collector does not request samba_statusd, so it
+// works when samba_statusd is not running yet. The Collector sends no descriptions, so it is an unchecked collector
+type Collector struct {
+	exporter *SambaExporter
+	// The exporter is not made for parallel scrapes, the registry of a program might be gathered from more than one handler
+	mutex sync.Mutex
+}
+
+// NewCollector - Get a Collector for the exporter. The descriptions of the metrics are set from sample smbstatus tables,
+// so the exporter itself must not be registered
+func NewCollector(exporter *SambaExporter) *Collector {
+	exporter.setSampleDescriptions()
+
+	return &Collector{exporter: exporter}
+}
+
+// Describe function for the Prometheus Exporter Interface. Sends no descriptions, since the metrics depend on the
+// response of samba_statusd
+func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
+	return
+}
+
+// Collect function for the Prometheus Exporter Interface
+func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	collector.exporter.collectMetrics(context.Background(), nil, ch)
+}
@@ -0,0 +1,69 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbstatusout"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestCollectorWithoutStatusd(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	conn, client, _ := pipecomunication.NewGrpcClient("127.0.0.1:1", nil)
+	defer conn.Close()
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 1, getNewStatisticGenSettings())
+	exporter.GrpcClient = client
+
+	registry := prometheus.NewRegistry()
+	errRegister := registry.Register(NewCollector(exporter))
+	if errRegister != nil {
+		t.Fatalf("Got error '%s' but expected none", errRegister.Error())
+	}
+
+	families, errGather := registry.Gather()
+	if errGather != nil {
+		t.Fatalf("Got error '%s' but expected none", errGather.Error())
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() == "samba_statusd_up" {
+			found = true
+			if family.GetMetric()[0].GetGauge().GetValue() != 0 {
+				t.Errorf("The samba_statusd_up is not 0, but samba_statusd is not running")
+			}
+		}
+	}
+	if !found {
+		t.Errorf("The metric samba_statusd_up was not collected")
+	}
+}
+
+func TestCollectorSampleDescriptions(t *testing.T) {
+	expectedMetChanels := 120
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	psData := smbstatusreader.GetPsData(commonbl.TestPsResponse(), logger)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	NewCollector(exporter)
+
+	chMet := make(chan prometheus.Metric, expectedMetChanels+10)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
+
+	if len(chMet) != expectedMetChanels {
+		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
+	}
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("The ErrorCount '%d' is not the expected '0'", logger.GetErrorCount())
+	}
+}
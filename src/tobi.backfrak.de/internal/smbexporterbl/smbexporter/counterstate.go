@@ -0,0 +1,129 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CounterState - The values of the counters of the exporter, kept in a state file, so a restart of the exporter does not reset the counters
+type CounterState struct {
+	filePath string
+	mutex    sync.Mutex
+	// The values of the counters stored in the state file, when the exporter started
+	offsets map[string]float64
+	// The values the counters got since the exporter started
+	current map[string]float64
+	// The time the counters were created, when the exporter was started the first time with this state file
+	created time.Time
+}
+
+// counterStateFile - The content of the state file
+type counterStateFile struct {
+	Created  time.Time          `json:"created"`
+	Counters map[string]float64 `json:"counters"`
+}
+
+// NewCounterState - Get the CounterState stored in the file. When the file does not exist, the counters start with 0
+func NewCounterState(filePath string) (*CounterState, error) {
+	state := CounterState{filePath: filePath, offsets: make(map[string]float64), current: make(map[string]float64), created: time.Now()}
+
+	data, errRead := os.ReadFile(filePath)
+	if errors.Is(errRead, os.ErrNotExist) {
+		return &state, nil
+	}
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	var stored counterStateFile
+	errParse := json.Unmarshal(data, &stored)
+	if errParse != nil {
+		return nil, fmt.Errorf("the state file '%s' is not valid: %s", filePath, errParse.Error())
+	}
+	if !stored.Created.IsZero() {
+		state.created = stored.Created
+	}
+	for key, value := range stored.Counters {
+		state.offsets[key] = value
+	}
+
+	return &state, nil
+}
+
+// GetCreated - Get the time the counters were created
+func (state *CounterState) GetCreated() time.Time {
+	return state.created
+}
+
+// getValue - Remember the value the counter got since the exporter started, and get it added to the value stored in the state file
+func (state *CounterState) getValue(name string, labels map[string]string, value float64) float64 {
+	key := getCounterKey(name, labels)
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	state.current[key] = value
+
+	return state.offsets[key] + value
+}
+
+// Save - Write the values of the counters to the state file
+func (state *CounterState) Save() error {
+	state.mutex.Lock()
+	stored := counterStateFile{Created: state.created, Counters: make(map[string]float64)}
+	for key, value := range state.offsets {
+		stored.Counters[key] = value
+	}
+	for key, value := range state.current {
+		stored.Counters[key] = state.offsets[key] + value
+	}
+	state.mutex.Unlock()
+
+	data, errMarshal := json.MarshalIndent(stored, "", "  ")
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	// Write to a temporary file first, so an exporter killed while writing does not leave a broken state file
+	tmpFile, errCreate := os.CreateTemp(filepath.Dir(state.filePath), filepath.Base(state.filePath)+".*")
+	if errCreate != nil {
+		return errCreate
+	}
+	defer os.Remove(tmpFile.Name())
+	_, errWrite := tmpFile.Write(data)
+	errClose := tmpFile.Close()
+	if errWrite != nil {
+		return errWrite
+	}
+	if errClose != nil {
+		return errClose
+	}
+
+	return os.Rename(tmpFile.Name(), state.filePath)
+}
+
+// getCounterKey - Get the key of a counter in the state file, in the format 'name{label1="value1",label2="value2"}'
+func getCounterKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	var pairs []string
+	for key, value := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", key, value))
+	}
+	sort.Strings(pairs)
+
+	return fmt.Sprintf("%s{%s}", name, strings.Join(pairs, ","))
+}
@@ -0,0 +1,90 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCounterState(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "counters.json")
+	state, errNew := NewCounterState(filePath)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+
+	if value := state.getValue("scrape_errors_total", nil, 3); value != 3 {
+		t.Errorf("Got the value '%f' but expected '3'", value)
+	}
+	if value := state.getValue("parser_errors_total", map[string]string{"table": "locks"}, 2); value != 2 {
+		t.Errorf("Got the value '%f' but expected '2'", value)
+	}
+
+	errSave := state.Save()
+	if errSave != nil {
+		t.Fatalf("Got error '%s' but expected none", errSave.Error())
+	}
+
+	restarted, errRestart := NewCounterState(filePath)
+	if errRestart != nil {
+		t.Fatalf("Got error '%s' but expected none", errRestart.Error())
+	}
+
+	if !restarted.GetCreated().Equal(state.GetCreated()) {
+		t.Errorf("The creation time '%s' is not the expected '%s'", restarted.GetCreated(), state.GetCreated())
+	}
+	if value := restarted.getValue("scrape_errors_total", nil, 1); value != 4 {
+		t.Errorf("Got the value '%f' after the restart but expected '4'", value)
+	}
+	if value := restarted.getValue("parser_errors_total", map[string]string{"table": "locks"}, 0); value != 2 {
+		t.Errorf("Got the value '%f' after the restart but expected '2'", value)
+	}
+	if value := restarted.getValue("parser_errors_total", map[string]string{"table": "shares"}, 5); value != 5 {
+		t.Errorf("Got the value '%f' for a new counter but expected '5'", value)
+	}
+
+	// The values of the counters not exported since the restart are kept as well
+	errSave = restarted.Save()
+	if errSave != nil {
+		t.Fatalf("Got error '%s' but expected none", errSave.Error())
+	}
+	restartedTwice, _ := NewCounterState(filePath)
+	if value := restartedTwice.getValue("scrape_errors_total", nil, 0); value != 4 {
+		t.Errorf("Got the value '%f' after the second restart but expected '4'", value)
+	}
+
+	files, _ := os.ReadDir(filepath.Dir(filePath))
+	if len(files) != 1 {
+		t.Errorf("Got '%d' files in the state directory, but expected only the state file", len(files))
+	}
+}
+
+func TestCounterStateInvalidFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "counters.json")
+	os.WriteFile(filePath, []byte("no json"), 0644)
+
+	_, errNew := NewCounterState(filePath)
+	if errNew == nil {
+		t.Errorf("Got no error for an invalid state file, but expected one")
+	}
+}
+
+func TestGetCounterKey(t *testing.T) {
+	keys := map[string]string{
+		getCounterKey("scrape_errors_total", nil):                                          "scrape_errors_total",
+		getCounterKey("parser_errors_total", map[string]string{"table": "locks"}):          "parser_errors_total{table=\"locks\"}",
+		getCounterKey("my_total", map[string]string{"b": "2", "a": "1"}):                   "my_total{a=\"1\",b=\"2\"}",
+		getCounterKey("my_total", map[string]string{"share": "my \"share\"", "user": "1"}): "my_total{share=\"my \\\"share\\\"\",user=\"1\"}",
+	}
+
+	for key, expected := range keys {
+		if key != expected {
+			t.Errorf("Got the key '%s' but expected '%s'", key, expected)
+		}
+	}
+}
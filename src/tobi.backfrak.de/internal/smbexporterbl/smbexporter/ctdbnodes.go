@@ -0,0 +1,110 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+)
+
+// The time the addresses of the cluster nodes are kept, before samba_statusd is asked again
+const clusterNodesRefreshInterval = 5 * time.Minute
+
+// clusterNodeCache - The addresses of the cluster nodes by their number, as listed by samba_statusd
+type clusterNodeCache struct {
+	mutex    sync.Mutex
+	names    map[int]string
+	received time.Time
+}
+
+// newClusterNodeCache - Get a new clusterNodeCache without nodes
+func newClusterNodeCache() *clusterNodeCache {
+	return &clusterNodeCache{names: make(map[int]string)}
+}
+
+// set - Keep the addresses of the nodes
+func (cache *clusterNodeCache) set(nodes []commonbl.CtdbNode) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.names = make(map[int]string)
+	for _, node := range nodes {
+		cache.names[node.Node] = node.Address
+	}
+	cache.received = time.Now()
+}
+
+// get - Get a copy of the kept addresses
+func (cache *clusterNodeCache) get() map[int]string {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	ret := make(map[int]string, len(cache.names))
+	for node, name := range cache.names {
+		ret[node] = name
+	}
+
+	return ret
+}
+
+// isOutdated - Tell if the addresses were never received or are older than the clusterNodesRefreshInterval
+func (cache *clusterNodeCache) isOutdated() bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.received.IsZero() || time.Since(cache.received) > clusterNodesRefreshInterval
+}
+
+// isClusterMode - Tell if the processes were read from smbstatus of a CTDB cluster, where the PIDs have the node in front
+func isClusterMode(processes []smbstatusreader.ProcessData) bool {
+	for _, process := range processes {
+		if process.ClusterNodeId > -1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateClusterNodes - Ask samba_statusd for the addresses of the cluster nodes, when ResolveClusterNodes is set, samba runs
+// in a cluster and the kept addresses are outdated. On errors, the kept addresses are used further
+func (smbExporter *SambaExporter) updateClusterNodes(ctx context.Context, processes []smbstatusreader.ProcessData) {
+	if !smbExporter.ResolveClusterNodes || !isClusterMode(processes) || !smbExporter.clusterNodes.isOutdated() ||
+		!smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(statisticsGenerator.COLLECTOR_CTDB) {
+		return
+	}
+
+	nodes, errGet := requestList(ctx, smbExporter, commonbl.CTDB_NODES_REQUEST, pipecomunication.GetCtdbNodesGrpc)
+	if errGet != nil {
+		smbExporter.Logger.WriteErrorWithAddition(errGet, "while requesting the cluster nodes")
+		return
+	}
+	if len(nodes) == 0 {
+		// samba_statusd could not call ctdb, it logged the reason
+		smbExporter.Logger.WriteVerbose("samba_statusd listed no cluster nodes, export the node numbers")
+		return
+	}
+
+	smbExporter.Logger.WriteVerbose(fmt.Sprintf("Export the cluster nodes %v by their address", nodes))
+	smbExporter.clusterNodes.set(nodes)
+}
+
+// getGeneratorSettings - Get the StatisticsGeneratorSettings with the known addresses of the cluster nodes, when ResolveClusterNodes is set
+func (smbExporter *SambaExporter) getGeneratorSettings() statisticsGenerator.StatisticsGeneratorSettings {
+	settings := smbExporter.StatisticsGeneratorSettings
+	if smbExporter.ResolveClusterNodes {
+		settings.ClusterNodeNames = smbExporter.clusterNodes.get()
+	}
+
+	return settings
+}
@@ -0,0 +1,68 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestClusterNodeCache(t *testing.T) {
+	cache := newClusterNodeCache()
+	if !cache.isOutdated() || len(cache.get()) != 0 {
+		t.Errorf("A new cache is not outdated or not empty")
+	}
+
+	cache.set(commonbl.GetTestCtdbNodes())
+	names := cache.get()
+	if cache.isOutdated() || len(names) != 2 || names[0] != "192.168.1.10" || names[1] != "192.168.1.11" {
+		t.Errorf("Got the nodes '%v', but expected the test nodes", names)
+	}
+
+	// The returned map is a copy
+	names[0] = "changed"
+	if cache.get()[0] != "192.168.1.10" {
+		t.Errorf("The cache was changed by changing the returned nodes")
+	}
+}
+
+func TestUpdateClusterNodes(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	client := startStatusTestStatusd(t, commonbl.TestLockResponse)
+	exporter := NewSambaExporter(client, client, logger, "0.0.0", 5, getNewStatisticGenSettings())
+
+	processes := []smbstatusreader.ProcessData{{PID: 1117, ClusterNodeId: 1}}
+	exporter.updateClusterNodes(context.Background(), processes)
+	if len(exporter.getGeneratorSettings().ClusterNodeNames) != 0 {
+		t.Errorf("The cluster nodes were requested, but ResolveClusterNodes is not set")
+	}
+
+	exporter.ResolveClusterNodes = true
+	exporter.updateClusterNodes(context.Background(), processes)
+	names := exporter.getGeneratorSettings().ClusterNodeNames
+	if len(names) != 2 || names[1] != "192.168.1.11" {
+		t.Errorf("Got the nodes '%v', but expected the test nodes", names)
+	}
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestUpdateClusterNodesNoCluster(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	// Without handlers, any request to samba_statusd would fail
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.ResolveClusterNodes = true
+
+	exporter.updateClusterNodes(context.Background(), []smbstatusreader.ProcessData{{PID: 1117, ClusterNodeId: -1}})
+	if !exporter.clusterNodes.isOutdated() || logger.GetErrorCount() != 0 {
+		t.Errorf("The cluster nodes were requested, but samba does not run in a cluster")
+	}
+}
@@ -0,0 +1,86 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"sync"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// The tables the entries of exited smbd processes are dropped from
+var deadEntryTables = []string{smbstatusreader.LOCK_TABLE, smbstatusreader.SHARE_TABLE, smbstatusreader.PROCESS_TABLE}
+
+var deadEntryCount = make(map[string]int)
+var deadEntryMux sync.Mutex
+
+// GetDeadEntryCount - Get the number of entries of the table dropped, since their smbd process is not running anymore
+func GetDeadEntryCount(table string) int {
+	deadEntryMux.Lock()
+	defer deadEntryMux.Unlock()
+
+	return deadEntryCount[table]
+}
+
+func addDeadEntries(table string, count int) {
+	deadEntryMux.Lock()
+	defer deadEntryMux.Unlock()
+
+	deadEntryCount[table] += count
+}
+
+// dropDeadEntries - Drop the locks, processes and shares of smbd processes, that are not in the process table samba_statusd read from /proc.
+// smbstatus sometimes lists sessions whose smbd already exited. The entries of other cluster nodes are kept, since their processes
+// are not in the process table, and nothing is dropped when the process table is empty
+func (smbExporter *SambaExporter) dropDeadEntries(locks []smbstatusreader.LockData, processes []smbstatusreader.ProcessData, shares []smbstatusreader.ShareData, psData []commonbl.PsUtilPidData) ([]smbstatusreader.LockData, []smbstatusreader.ProcessData, []smbstatusreader.ShareData) {
+	if len(psData) == 0 {
+		smbExporter.Logger.WriteVerbose("Got no smbd processes from samba_statusd, can not tell which entries are dead")
+		return locks, processes, shares
+	}
+
+	running := make(map[int]bool, len(psData))
+	for _, data := range psData {
+		running[int(data.PID)] = true
+	}
+	isDead := func(clusterNodeId int, pid int) bool {
+		return clusterNodeId < 0 && !running[pid]
+	}
+
+	var liveLocks []smbstatusreader.LockData
+	for _, lock := range locks {
+		if isDead(lock.ClusterNodeId, lock.PID) {
+			smbExporter.Logger.WriteVerbose(fmt.Sprintf("Drop the lock on \"%s\" of the exited smbd process %d", lock.SharePath, lock.PID))
+			continue
+		}
+		liveLocks = append(liveLocks, lock)
+	}
+
+	var liveProcesses []smbstatusreader.ProcessData
+	for _, process := range processes {
+		if isDead(process.ClusterNodeId, process.PID) {
+			smbExporter.Logger.WriteVerbose(fmt.Sprintf("Drop the exited smbd process %d", process.PID))
+			continue
+		}
+		liveProcesses = append(liveProcesses, process)
+	}
+
+	var liveShares []smbstatusreader.ShareData
+	for _, share := range shares {
+		if isDead(share.ClusterNodeId, share.PID) {
+			smbExporter.Logger.WriteVerbose(fmt.Sprintf("Drop the share \"%s\" of the exited smbd process %d", share.Service, share.PID))
+			continue
+		}
+		liveShares = append(liveShares, share)
+	}
+
+	addDeadEntries(smbstatusreader.LOCK_TABLE, len(locks)-len(liveLocks))
+	addDeadEntries(smbstatusreader.PROCESS_TABLE, len(processes)-len(liveProcesses))
+	addDeadEntries(smbstatusreader.SHARE_TABLE, len(shares)-len(liveShares))
+
+	return liveLocks, liveProcesses, liveShares
+}
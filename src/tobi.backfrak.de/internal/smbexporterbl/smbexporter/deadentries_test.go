@@ -0,0 +1,69 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbstatusout"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestDropDeadEntries(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	locksBefore := GetDeadEntryCount(smbstatusreader.LOCK_TABLE)
+	sharesBefore := GetDeadEntryCount(smbstatusreader.SHARE_TABLE)
+	processesBefore := GetDeadEntryCount(smbstatusreader.PROCESS_TABLE)
+
+	// Only the smbd processes 1117 and 1119 are still running
+	psData := []commonbl.PsUtilPidData{{PID: 1117}, {PID: 1119}}
+	liveLocks, liveProcesses, liveShares := exporter.dropDeadEntries(locks, processes, shares, psData)
+
+	if len(liveLocks) != 0 {
+		t.Errorf("Got %d locks, but expected 0, since the process 1120 exited", len(liveLocks))
+	}
+	if len(liveProcesses) != 2 || liveProcesses[0].PID != 1117 || liveProcesses[1].PID != 1119 {
+		t.Errorf("Got the processes '%v', but expected the processes 1117 and 1119", liveProcesses)
+	}
+	if len(liveShares) != 3 {
+		t.Errorf("Got %d shares, but expected 3", len(liveShares))
+	}
+
+	if GetDeadEntryCount(smbstatusreader.LOCK_TABLE) != locksBefore+4 {
+		t.Errorf("Got %d dead locks, but expected %d", GetDeadEntryCount(smbstatusreader.LOCK_TABLE), locksBefore+4)
+	}
+	if GetDeadEntryCount(smbstatusreader.PROCESS_TABLE) != processesBefore+2 {
+		t.Errorf("Got %d dead processes, but expected %d", GetDeadEntryCount(smbstatusreader.PROCESS_TABLE), processesBefore+2)
+	}
+	if GetDeadEntryCount(smbstatusreader.SHARE_TABLE) != sharesBefore+1 {
+		t.Errorf("Got %d dead shares, but expected %d", GetDeadEntryCount(smbstatusreader.SHARE_TABLE), sharesBefore+1)
+	}
+}
+
+func TestDropDeadEntriesWithoutProcessTable(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+
+	liveLocks, liveProcesses, _ := exporter.dropDeadEntries(locks, processes, nil, nil)
+	if len(liveLocks) != len(locks) || len(liveProcesses) != len(processes) {
+		t.Errorf("Entries were dropped, but the process table is empty")
+	}
+
+	// The processes of the other cluster nodes are not in the process table
+	cluster := []smbstatusreader.ProcessData{{PID: 1120, ClusterNodeId: 1}, {PID: 1121, ClusterNodeId: -1}}
+	_, liveProcesses, _ = exporter.dropDeadEntries(nil, cluster, nil, []commonbl.PsUtilPidData{{PID: 1117}})
+	if len(liveProcesses) != 1 || liveProcesses[0].ClusterNodeId != 1 {
+		t.Errorf("Got the processes '%v', but expected only the one of the cluster node 1", liveProcesses)
+	}
+}
@@ -6,37 +6,136 @@ package smbexporter
 // LICENSE file.
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
 	"tobi.backfrak.de/internal/commonbl"
 	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
 	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
 	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/statusdrpc"
 )
 
 // The Prefix for labels of this prometheus exporter
 const EXPORTER_LABEL_PREFIX = "samba"
 
+var scrapeErrorCount = 0
+var scrapeErrorMux sync.Mutex
+
+// GetScrapeErrorCount - Get the number of scrapes that could not get the status from samba_statusd
+func GetScrapeErrorCount() int {
+	scrapeErrorMux.Lock()
+	defer scrapeErrorMux.Unlock()
+
+	return scrapeErrorCount
+}
+
+func addScrapeError() {
+	scrapeErrorMux.Lock()
+	defer scrapeErrorMux.Unlock()
+
+	scrapeErrorCount++
+}
+
+var cardinalityLimitedCount = make(map[string]int)
+var cardinalityLimitedMux sync.Mutex
+
+// GetCardinalityLimitedCounts - Get the number of series collapsed by the CardinalityLimit for each metric
+func GetCardinalityLimitedCounts() map[string]int {
+	cardinalityLimitedMux.Lock()
+	defer cardinalityLimitedMux.Unlock()
+
+	ret := make(map[string]int)
+	for name, count := range cardinalityLimitedCount {
+		ret[name] = count
+	}
+
+	return ret
+}
+
+func addCardinalityLimited(collapsed map[string]int) {
+	cardinalityLimitedMux.Lock()
+	defer cardinalityLimitedMux.Unlock()
+
+	for name, count := range collapsed {
+		cardinalityLimitedCount[name] += count
+	}
+}
+
 // SambaExporter - The class that implements the Prometheus Exporter Interface
 type SambaExporter struct {
-	RequestHandler              *commonbl.PipeHandler
-	ResponseHander              *commonbl.PipeHandler
-	Logger                      commonbl.Logger
-	Version                     string
-	RequestTimeOut              int
+	RequestHandler commonbl.MessageHandler
+	ResponseHander commonbl.MessageHandler
+	// When set, the gRPC service of samba_statusd is used instead of the RequestHandler and ResponseHander
+	GrpcClient     statusdrpc.SambaStatusClient
+	Logger         commonbl.Logger
+	Version        string
+	RequestTimeOut int
+	// How often a request to samba_statusd that timed out is sent again
+	RequestRetries int
+	// The time to wait before the first retry of a request, it doubles with each further retry
+	RequestRetryBackoff time.Duration
+	// The number of requests to samba_statusd that timed out in a row, after which no requests are sent for the
+	// RequestBreakerCooldown. The circuit breaker is not used when 0
+	RequestBreakerThreshold int
+	// The time no requests are sent to samba_statusd, once the RequestBreakerThreshold is reached
+	RequestBreakerCooldown      time.Duration
 	StatisticsGeneratorSettings statisticsGenerator.StatisticsGeneratorSettings
+	// Labels with constant values added to every metric. Must be set before the exporter is registered
+	ConstLabels map[string]string
+	// When set, the counters of the exporter itself continue with the values of the state, and the state is saved after each scrape
+	CounterState *CounterState
+	// The maximum number of series of a metric, the others are collapsed into a series with the labels 'other'. 0 for no limit
+	CardinalityLimit int
+	// When greater 0 and samba_statusd can not be reached, the samba status of the last successful request is exported,
+	// as long as it is not older than this. The age is exported as data_stale_seconds
+	StaleDataMaxAge time.Duration
+	// The memory in bytes the exporter should not exceed. A response of samba_statusd is not parsed, when the memory needed
+	// is estimated to exceed the limit, and the collection is aborted. No limit is checked when 0
+	MemoryLimit int64
+	// The maximum size of a smbstatus output received with gRPC in bytes. The RequestHandler and ResponseHander limit
+	// the size of the messages themselves. No limit when 0
+	MaxResponseSize int
+	// When set, the entries of smbd processes that are not in the process table of samba_statusd are dropped.
+	// The number of dropped entries is exported as dead_entries_dropped_total
+	DropDeadEntries bool
+	// When set, the node label of the cluster metrics is the address of the node, as listed by 'ctdb listnodes' on the samba_statusd host
+	ResolveClusterNodes bool
 
 	// Used to ensure that every metric is only added once
 	descriptions map[string]prometheus.Desc
 
 	// Used to ensure that the order of labels is always the same for a given metric
 	metricsLabelList map[string][]string
+
+	// The creation time of the counters of the exporter itself
+	startTime time.Time
+
+	// Counts the sessions connected and disconnected between the scrapes
+	sessions *sessionTracker
+
+	// Sums up the bytes read and written by the smbd processes between the scrapes
+	io *ioTracker
+
+	// The samba status of the last successful request, kept when the StaleDataMaxAge is set
+	last *lastStatus
+
+	// Shares the request to samba_statusd between the scrapes running at the same time
+	statusFlight *singleflight.Group
+
+	// The addresses of the cluster nodes, used when ResolveClusterNodes is set
+	clusterNodes *clusterNodeCache
+
+	// The shares configured in the smb.conf, as listed by samba_statusd
+	shareConfigs *shareConfigCache
 }
 
 // Get a new instance of the SambaExporter
-func NewSambaExporter(requestHandler *commonbl.PipeHandler, responseHander *commonbl.PipeHandler, logger commonbl.Logger, version string, requestTimeOut int, statisticsGeneratorSettings statisticsGenerator.StatisticsGeneratorSettings) *SambaExporter {
+func NewSambaExporter(requestHandler commonbl.MessageHandler, responseHander commonbl.MessageHandler, logger commonbl.Logger, version string, requestTimeOut int, statisticsGeneratorSettings statisticsGenerator.StatisticsGeneratorSettings) *SambaExporter {
 	var ret SambaExporter
 	ret.RequestHandler = requestHandler
 	ret.ResponseHander = responseHander
@@ -46,14 +145,73 @@ func NewSambaExporter(requestHandler *commonbl.PipeHandler, responseHander *comm
 	ret.descriptions = make(map[string]prometheus.Desc)
 	ret.StatisticsGeneratorSettings = statisticsGeneratorSettings
 	ret.metricsLabelList = make(map[string][]string)
+	ret.startTime = time.Now()
+	ret.sessions = newSessionTracker()
+	ret.io = newIoTracker()
+	ret.last = newLastStatus()
+	ret.statusFlight = &singleflight.Group{}
+	ret.clusterNodes = newClusterNodeCache()
+	ret.shareConfigs = &shareConfigCache{}
 
 	return &ret
 }
 
+// getRequestSettings - Get the timeout, retry and circuit breaker settings for requests to samba_statusd
+func (smbExporter *SambaExporter) getRequestSettings() pipecomunication.RequestSettings {
+	return pipecomunication.RequestSettings{
+		TimeOut:          smbExporter.RequestTimeOut,
+		Retries:          smbExporter.RequestRetries,
+		RetryBackoff:     smbExporter.RequestRetryBackoff,
+		BreakerThreshold: smbExporter.RequestBreakerThreshold,
+		BreakerCooldown:  smbExporter.RequestBreakerCooldown,
+		MemoryLimit:      smbExporter.MemoryLimit,
+		MaxResponseSize:  smbExporter.MaxResponseSize,
+	}
+}
+
+// requestSambaStatus - Get all data tables from samba_statusd, using the gRPC service when a GrpcClient is set.
+// Waiting for samba_statusd is stopped, when the context is done
+func (smbExporter *SambaExporter) requestSambaStatus(ctx context.Context) ([]smbstatusreader.LockData, []smbstatusreader.ProcessData, []smbstatusreader.ShareData, []commonbl.PsUtilPidData, error) {
+	var locks []smbstatusreader.LockData
+	var processes []smbstatusreader.ProcessData
+	var shares []smbstatusreader.ShareData
+	var psData []commonbl.PsUtilPidData
+	var errGet error
+	settings := smbExporter.getRequestSettings()
+	if smbExporter.GrpcClient != nil {
+		locks, processes, shares, psData, errGet = pipecomunication.GetSambaStatusGrpc(ctx, smbExporter.GrpcClient, smbExporter.Logger, settings)
+	} else {
+		locks, processes, shares, psData, errGet = pipecomunication.GetSambaStatus(ctx, smbExporter.RequestHandler, smbExporter.ResponseHander, smbExporter.Logger, settings)
+	}
+	if errGet == nil && smbExporter.DropDeadEntries {
+		locks, processes, shares = smbExporter.dropDeadEntries(locks, processes, shares, psData)
+	}
+
+	return locks, processes, shares, psData, errGet
+}
+
+// requestList - Get the list of the request from samba_statusd, using getGrpc when a GrpcClient is set.
+// Waiting for samba_statusd is stopped, when the context is done
+func requestList[T any](ctx context.Context, smbExporter *SambaExporter, request commonbl.RequestType,
+	getGrpc func(context.Context, statusdrpc.SambaStatusClient, commonbl.Logger, pipecomunication.RequestSettings) ([]T, error)) ([]T, error) {
+	settings := smbExporter.getRequestSettings()
+	if smbExporter.GrpcClient != nil {
+		return getGrpc(ctx, smbExporter.GrpcClient, smbExporter.Logger, settings)
+	}
+
+	return pipecomunication.GetJsonData[T](ctx, smbExporter.RequestHandler, smbExporter.ResponseHander, request, smbExporter.Logger, settings)
+}
+
 // Describe function for the Prometheus Exporter Interface
 func (smbExporter *SambaExporter) Describe(ch chan<- *prometheus.Desc) {
 	smbExporter.Logger.WriteVerbose("Request samba_statusd to get prometheus descriptions")
-	locks, processes, shares, psData, errGet := pipecomunication.GetSambaStatus(smbExporter.RequestHandler, smbExporter.ResponseHander, smbExporter.Logger, smbExporter.RequestTimeOut)
+	locks, processes, shares, psData, errGet := smbExporter.getSambaStatus(context.Background())
+	switch errGet.(type) {
+	case *pipecomunication.MemoryLimitError, *commonbl.MessageTooLargeError:
+		// The descriptions do not depend on the content of the tables, so a huge samba status must not stop the exporter
+		smbExporter.Logger.WriteErrorWithAddition(errGet, "setup the descriptions without the samba status")
+		locks, processes, shares, psData, errGet = nil, nil, nil, nil, nil
+	}
 	if errGet != nil {
 		smbExporter.Logger.WriteError(errGet)
 
@@ -67,53 +225,221 @@ func (smbExporter *SambaExporter) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect function for the Prometheus Exporter Interface
 func (smbExporter *SambaExporter) Collect(ch chan<- prometheus.Metric) {
+	smbExporter.collectMetrics(context.Background(), nil, ch)
+}
+
+// collectMetrics - Request the samba status and send the metrics of the given collectors. When collectors is nil, all metrics are send.
+// When the context is done before samba_statusd responded, no metrics are send
+func (smbExporter *SambaExporter) collectMetrics(ctx context.Context, collectors []string, ch chan<- prometheus.Metric) {
 	smbExporter.Logger.WriteVerbose("Request samba_statusd to get prometheus metrics")
 	smbStatusUp := 1
 	smbServerUp := 1
 	start := time.Now()
-	locks, processes, shares, psData, errGet := pipecomunication.GetSambaStatus(smbExporter.RequestHandler, smbExporter.ResponseHander, smbExporter.Logger, smbExporter.RequestTimeOut)
+	locks, processes, shares, psData, errGet := smbExporter.getSambaStatus(ctx)
+	if isContextError(errGet) {
+		// Nobody waits for the metrics of a cancelled scrape, and samba_statusd was not asked to its end
+		smbExporter.Logger.WriteVerbose(fmt.Sprintf("Stop collecting the metrics: %s", errGet.Error()))
+		return
+	}
 	if errGet != nil {
-		smbExporter.Logger.WriteError(errGet)
 		switch errGet.(type) {
-		case *pipecomunication.SmbStatusTimeOutError:
-			smbStatusUp = 0
-			smbServerUp = 0
-		case *pipecomunication.SmbStatusUnexpectedResponseError:
+		case *pipecomunication.CircuitOpenError:
+			// The circuit breaker already logged the time outs that opened it
+			smbExporter.Logger.WriteVerbose(errGet.Error())
+		default:
+			smbExporter.Logger.WriteError(errGet)
+		}
+		addScrapeError()
+		switch errGet.(type) {
+		case *pipecomunication.SmbStatusUnexpectedResponseError, *pipecomunication.ProtocolVersionMismatchError, *pipecomunication.MemoryLimitError,
+			*commonbl.MessageTooLargeError:
 			smbServerUp = 0
 		default:
-			return
+			// A timeout or a broken connection, samba_statusd seems not to be running.
+			// The metrics of the exporter itself are still sent, so this can be told apart from an idle server
+			smbStatusUp = 0
+			smbServerUp = 0
+		}
+	}
+	if errGet == nil && smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(statisticsGenerator.COLLECTOR_SHARES) {
+		smbExporter.sessions.update(smbExporter.StatisticsGeneratorSettings.ShareFilter.FilterShareData(shares))
+	}
+	if errGet == nil && smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(statisticsGenerator.COLLECTOR_PSDATA) {
+		smbExporter.io.update(psData)
+	}
+	staleSeconds := 0.0
+	if smbExporter.StaleDataMaxAge > 0 {
+		if errGet == nil {
+			smbExporter.last.set(locks, processes, shares, psData)
+		} else {
+			var age time.Duration
+			var found bool
+			locks, processes, shares, psData, age, found = smbExporter.last.get(smbExporter.StaleDataMaxAge)
+			staleSeconds = age.Seconds()
+			if found {
+				smbExporter.Logger.WriteVerbose(fmt.Sprintf("Export the samba status of the last successful request %s ago", age.Round(time.Second)))
+			}
 		}
 	}
 	elapsed := time.Since(start)
-	elapsedFloat := float64(elapsed.Milliseconds())
-	smbExporter.setMetricsFromResponse(locks, processes, shares, psData, smbStatusUp, smbServerUp, elapsedFloat, ch)
+	elapsedFloat := float64(elapsed.Microseconds()) / 1000
+	if errGet == nil {
+		smbExporter.updateClusterNodes(ctx, processes)
+	}
+	smbExporter.setMetricsFromResponse(locks, processes, shares, psData, smbStatusUp, smbServerUp, elapsedFloat, collectors, ch)
+	smbExporter.setDataStaleMetric(staleSeconds, ch)
+	smbExporter.setCircuitOpenMetric(ch)
+	if errGet == nil {
+		smbExporter.setOptionalMetrics(ctx, collectors, shares, ch)
+	}
+
+	if smbExporter.CounterState != nil {
+		errSave := smbExporter.CounterState.Save()
+		if errSave != nil {
+			smbExporter.Logger.WriteErrorWithAddition(errSave, "while saving the counter state")
+		}
+	}
+
+	return
+}
 
+// setOptionalMetrics - Request the plugin results, cgroups, configured shares and file handles from samba_statusd at the same time
+// and send their metrics, so a scrape waits only for the slowest of the requests
+func (smbExporter *SambaExporter) setOptionalMetrics(ctx context.Context, collectors []string, shares []smbstatusreader.ShareData, ch chan<- prometheus.Metric) {
+	var wg sync.WaitGroup
+	setters := []func(){
+		func() { smbExporter.setPluginMetrics(ctx, collectors, ch) },
+		func() { smbExporter.setCgroupMetrics(ctx, collectors, ch) },
+		func() { smbExporter.setShareConfigMetrics(ctx, collectors, shares, ch) },
+		func() { smbExporter.setShareHandlesMetrics(ctx, collectors, ch) },
+	}
+	for _, setter := range setters {
+		wg.Add(1)
+		go func(set func()) {
+			defer wg.Done()
+			set()
+		}(setter)
+	}
+	wg.Wait()
+}
+
+// FilteredCollector - A prometheus collector, that exports only the metrics of some collectors of the SambaExporter.
+// Used to handle scrapes with the 'collect[]' query parameter, and scrapes that should stop with the context of the HTTP request
+type FilteredCollector struct {
+	exporter   *SambaExporter
+	Collectors []string
+	ctx        context.Context
+}
+
+// NewFilteredCollector - Get a new FilteredCollector, exporting the metrics of the given collectors, all metrics when collectors is nil.
+// The requests to samba_statusd are cancelled with the context
+func (smbExporter *SambaExporter) NewFilteredCollector(ctx context.Context, collectors []string) (*FilteredCollector, error) {
+	errValidate := statisticsGenerator.ValidateCollectorNames(collectors)
+	if errValidate != nil {
+		return nil, errValidate
+	}
+
+	for _, collector := range collectors {
+		if !smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(collector) {
+			return nil, statisticsGenerator.NewCollectorDisabledError(collector)
+		}
+	}
+
+	return &FilteredCollector{smbExporter, collectors, ctx}, nil
+}
+
+// Describe function for the Prometheus Exporter Interface. Sends no descriptions, since the FilteredCollector uses
+// the descriptions the SambaExporter got when it was registered. So the FilteredCollector is an unchecked collector
+func (filtered *FilteredCollector) Describe(ch chan<- *prometheus.Desc) {
 	return
 }
 
-func (smbExporter *SambaExporter) setMetricsFromResponse(locks []smbstatusreader.LockData, processes []smbstatusreader.ProcessData, shares []smbstatusreader.ShareData, psData []commonbl.PsUtilPidData, smbStatusUp int, smbServerUp int, requestTime float64, ch chan<- prometheus.Metric) {
+// Collect function for the Prometheus Exporter Interface
+func (filtered *FilteredCollector) Collect(ch chan<- prometheus.Metric) {
+	filtered.exporter.collectMetrics(filtered.ctx, filtered.Collectors, ch)
+}
+
+func (smbExporter *SambaExporter) setMetricsFromResponse(locks []smbstatusreader.LockData, processes []smbstatusreader.ProcessData, shares []smbstatusreader.ShareData, psData []commonbl.PsUtilPidData, smbStatusUp int, smbServerUp int, requestTime float64, collectors []string, ch chan<- prometheus.Metric) {
 	smbExporter.Logger.WriteVerbose("Handle samba_statusd response and set prometheus metrics")
 	smbExporter.setGaugeIntMetricNoLabel("server_up", float64(smbServerUp), ch)
 	smbExporter.setGaugeIntMetricNoLabel("satutsd_up", float64(smbStatusUp), ch)
+	smbExporter.setGaugeIntMetricNoLabel("statusd_up", float64(smbStatusUp), ch)
+	smbExporter.setGaugeIntMetricNoLabel("scrape_duration_seconds", requestTime/1000, ch)
+	smbExporter.setCounterMetricNoLabel("scrape_errors_total", float64(GetScrapeErrorCount()), ch)
+	smbExporter.setCounterMetricNoLabel("statusd_dropped_responses_total", float64(pipecomunication.GetDroppedResponseCount()), ch)
+	smbExporter.setCounterMetricNoLabel("statusd_request_timeouts_total", float64(pipecomunication.GetTimeOutCount()), ch)
+	smbExporter.setCounterMetricNoLabel("memory_limit_aborts_total", float64(pipecomunication.GetMemoryLimitAbortCount()), ch)
+	for _, table := range smbstatusreader.GetTableNames() {
+		smbExporter.setCounterMetricWithLabel("parser_errors_total", float64(smbstatusreader.GetParserErrorCount(table)), map[string]string{"table": table}, ch)
+	}
+	for _, table := range deadEntryTables {
+		smbExporter.setCounterMetricWithLabel("dead_entries_dropped_total", float64(GetDeadEntryCount(table)), map[string]string{"table": table}, ch)
+	}
 	smbExporter.setGaugeIntMetricWithLabel("exporter_information", 1, map[string]string{"version": smbExporter.Version}, ch)
 
-	stats := statisticsGenerator.GetSmbStatistics(locks, processes, shares, smbExporter.StatisticsGeneratorSettings)
+	stats := statisticsGenerator.GetSmbStatistics(locks, processes, shares, smbExporter.getGeneratorSettings())
 	if stats == nil {
 		smbExporter.Logger.WriteError(pipecomunication.NewSmbStatusUnexpectedResponseError("Empty response from samba_statusd"))
 		return
 	}
-	stats = append(stats, statisticsGenerator.GetSmbdMetrics(psData, smbExporter.StatisticsGeneratorSettings.DoNotExportPid)...)
+	if smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(statisticsGenerator.COLLECTOR_PSDATA) {
+		stats = append(stats, statisticsGenerator.GetSmbdMetrics(psData, smbExporter.StatisticsGeneratorSettings.DoNotExportPid)...)
+	}
+	if collectors != nil {
+		stats = statisticsGenerator.FilterStatistics(stats, collectors)
+	}
+	if smbExporter.CardinalityLimit > 0 {
+		var collapsed map[string]int
+		stats, collapsed = statisticsGenerator.LimitCardinality(stats, smbExporter.CardinalityLimit)
+		addCardinalityLimited(collapsed)
+	}
 
 	for _, stat := range stats {
+		// The creation time of the counters of the smbd processes is not known
+		valueType := prometheus.GaugeValue
+		if statisticsGenerator.IsCounter(stat.Name) {
+			valueType = prometheus.CounterValue
+		}
 		if stat.Labels == nil {
-			smbExporter.setGaugeIntMetricNoLabel(stat.Name, stat.Value, ch)
+			smbExporter.setMetricNoLabel(stat.Name, valueType, stat.Value, time.Time{}, ch)
 		} else {
-			smbExporter.setGaugeIntMetricWithLabel(stat.Name, stat.Value, stat.Labels, ch)
+			smbExporter.setMetricWithLabel(stat.Name, valueType, stat.Value, stat.Labels, time.Time{}, ch)
 		}
 	}
+	if smbExporter.isCollected("connections_total", collectors) {
+		connects, disconnects := smbExporter.sessions.getCounts()
+		smbExporter.setCounterMetricNoLabel("connections_total", float64(connects), ch)
+		smbExporter.setCounterMetricNoLabel("disconnections_total", float64(disconnects), ch)
+	}
+	if smbExporter.isCollected("smbd_io_read_bytes_total", collectors) {
+		readBytes, writeBytes := smbExporter.io.getTotals()
+		smbExporter.setCounterMetricNoLabel("smbd_io_read_bytes_total", float64(readBytes), ch)
+		smbExporter.setCounterMetricNoLabel("smbd_io_write_bytes_total", float64(writeBytes), ch)
+	}
+	for name, count := range GetCardinalityLimitedCounts() {
+		smbExporter.setCounterMetricWithLabel("exporter_cardinality_limited_total", float64(count), map[string]string{"metric": name}, ch)
+	}
 	smbExporter.setGaugeIntMetricNoLabel("request_time", requestTime, ch)
 }
 
+// isCollected - Tell if the metric is exported by a scrape of the given collectors. When collectors is nil, all enabled collectors are scraped
+func (smbExporter *SambaExporter) isCollected(name string, collectors []string) bool {
+	collector := statisticsGenerator.GetCollectorOfMetric(name)
+	if !smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(collector) {
+		return false
+	}
+	if collectors == nil {
+		return true
+	}
+	for _, scraped := range collectors {
+		if scraped == collector {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (smbExporter *SambaExporter) setDescriptionsFromResponse(locks []smbstatusreader.LockData, processes []smbstatusreader.ProcessData, shares []smbstatusreader.ShareData, psData []commonbl.PsUtilPidData, ch chan<- *prometheus.Desc) {
 	smbExporter.Logger.WriteVerbose("Handle samba_statusd response and set prometheus descriptions")
 	stats := statisticsGenerator.GetSmbStatistics(locks, processes, shares, smbExporter.StatisticsGeneratorSettings)
@@ -124,11 +450,22 @@ func (smbExporter *SambaExporter) setDescriptionsFromResponse(locks []smbstatusr
 		// Exit with panic, since this means there are no descriptions setup for further operation
 		panic(err)
 	}
-	stats = append(stats, statisticsGenerator.GetSmbdMetrics(psData, smbExporter.StatisticsGeneratorSettings.DoNotExportPid)...)
+	if smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(statisticsGenerator.COLLECTOR_PSDATA) {
+		stats = append(stats, statisticsGenerator.GetSmbdMetrics(psData, smbExporter.StatisticsGeneratorSettings.DoNotExportPid)...)
+	}
 
 	smbExporter.setGaugeDescriptionNoLabel("server_up", "1 if the samba server seems to be running", ch)
 	smbExporter.setGaugeDescriptionNoLabel("satutsd_up", "1 if the samba_statusd seems to be running", ch)
+	smbExporter.setGaugeDescriptionNoLabel("statusd_up", "1 if the samba_statusd seems to be running", ch)
+	smbExporter.setGaugeDescriptionNoLabel("scrape_duration_seconds", "Time it took to request the samba status from samba_statusd in seconds", ch)
+	smbExporter.setGaugeDescriptionNoLabel("scrape_errors_total", "Number of scrapes that could not get the samba status from samba_statusd", ch)
+	smbExporter.setGaugeDescriptionNoLabel("statusd_dropped_responses_total", "Number of responses from samba_statusd dropped, since they did not belong to the request waiting for a response", ch)
+	smbExporter.setGaugeDescriptionNoLabel("statusd_request_timeouts_total", "Number of requests to samba_statusd that timed out, including the retried ones", ch)
+	smbExporter.setGaugeDescriptionNoLabel("memory_limit_aborts_total", "Number of scrapes aborted, since parsing the response of samba_statusd would have exceeded the memory limit", ch)
+	smbExporter.setGaugeDescriptionWithLabel("parser_errors_total", "Number of lines or tables of the smbstatus output that could not be parsed", map[string]string{"table": ""}, ch)
+	smbExporter.setGaugeDescriptionWithLabel("dead_entries_dropped_total", "Number of entries of the smbstatus output dropped, since their smbd process is not running anymore", map[string]string{"table": ""}, ch)
 	smbExporter.setGaugeDescriptionWithLabel("exporter_information", "Information of the samba_exporter", map[string]string{"version": smbExporter.Version}, ch)
+	smbExporter.setGaugeDescriptionWithLabel("exporter_cardinality_limited_total", "Number of series collapsed into the series with the labels 'other', since the metric had more series than the cardinality limit", map[string]string{"metric": ""}, ch)
 
 	for _, stat := range stats {
 		if stat.Labels == nil {
@@ -138,21 +475,90 @@ func (smbExporter *SambaExporter) setDescriptionsFromResponse(locks []smbstatusr
 		}
 	}
 
+	if smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(statisticsGenerator.COLLECTOR_SHARES) {
+		smbExporter.setGaugeDescriptionNoLabel("connections_total", "Number of sessions connected to a share since the exporter started, counted between the scrapes", ch)
+		smbExporter.setGaugeDescriptionNoLabel("disconnections_total", "Number of sessions disconnected from a share since the exporter started, counted between the scrapes", ch)
+	}
+	if smbExporter.StatisticsGeneratorSettings.IsCollectorEnabled(statisticsGenerator.COLLECTOR_PSDATA) {
+		smbExporter.setGaugeDescriptionNoLabel("smbd_io_read_bytes_total", "Bytes read from the disk by all 'smbd' processes since the exporter started, including the processes that exited", ch)
+		smbExporter.setGaugeDescriptionNoLabel("smbd_io_write_bytes_total", "Bytes written to the disk by all 'smbd' processes since the exporter started, including the processes that exited", ch)
+	}
 	smbExporter.setGaugeDescriptionNoLabel("request_time", "Time it took to reqest the samba status from samba_statusd [ms]", ch)
+	smbExporter.setDataStaleDescription(ch)
+	smbExporter.setCircuitOpenDescription(ch)
+}
+
+// setCircuitOpenMetric - Send if requests to samba_statusd are stopped by the circuit breaker, when the RequestBreakerThreshold is set
+func (smbExporter *SambaExporter) setCircuitOpenMetric(ch chan<- prometheus.Metric) {
+	if smbExporter.RequestBreakerThreshold <= 0 {
+		return
+	}
+	circuitOpen := 0.0
+	if pipecomunication.IsCircuitOpen() {
+		circuitOpen = 1
+	}
+
+	smbExporter.setGaugeIntMetricNoLabel("statusd_circuit_open", circuitOpen, ch)
+}
+
+// setCircuitOpenDescription - Send the description of the circuit breaker state, when the RequestBreakerThreshold is set
+func (smbExporter *SambaExporter) setCircuitOpenDescription(ch chan<- *prometheus.Desc) {
+	if smbExporter.RequestBreakerThreshold <= 0 {
+		return
+	}
+
+	smbExporter.setGaugeDescriptionNoLabel("statusd_circuit_open", "1 if no requests are sent to samba_statusd, since too many requests timed out in a row", ch)
 }
 
 func (smbExporter *SambaExporter) setGaugeIntMetricNoLabel(name string, value float64, ch chan<- prometheus.Metric) {
+	smbExporter.setMetricNoLabel(name, prometheus.GaugeValue, value, time.Time{}, ch)
+}
+
+// setCounterMetricNoLabel - Send a counter of the exporter itself, created when the exporter started
+func (smbExporter *SambaExporter) setCounterMetricNoLabel(name string, value float64, ch chan<- prometheus.Metric) {
+	value, created := smbExporter.getCounterValue(name, nil, value)
+	smbExporter.setMetricNoLabel(name, prometheus.CounterValue, value, created, ch)
+}
+
+// getCounterValue - Get the value and creation time of a counter of the exporter itself, continuing the CounterState when set
+func (smbExporter *SambaExporter) getCounterValue(name string, labels map[string]string, value float64) (float64, time.Time) {
+	if smbExporter.CounterState == nil {
+		return value, smbExporter.startTime
+	}
+
+	return smbExporter.CounterState.getValue(name, labels, value), smbExporter.CounterState.GetCreated()
+}
+
+func (smbExporter *SambaExporter) setMetricNoLabel(name string, valueType prometheus.ValueType, value float64, created time.Time, ch chan<- prometheus.Metric) {
 	desc, found := smbExporter.descriptions[name]
 	if found == false {
 		smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("No description found for %s", name))
 		return
 	}
 
-	met := prometheus.MustNewConstMetric(&desc, prometheus.GaugeValue, value)
-	ch <- met
+	ch <- newConstMetric(&desc, valueType, value, created)
+}
+
+// newConstMetric - Get a new metric. When created is not zero, it is set as the creation time of a counter
+func newConstMetric(desc *prometheus.Desc, valueType prometheus.ValueType, value float64, created time.Time, labelValues ...string) prometheus.Metric {
+	if valueType == prometheus.CounterValue && !created.IsZero() {
+		return prometheus.MustNewConstMetricWithCreatedTimestamp(desc, valueType, value, created, labelValues...)
+	}
+
+	return prometheus.MustNewConstMetric(desc, valueType, value, labelValues...)
 }
 
 func (smbExporter *SambaExporter) setGaugeIntMetricWithLabel(name string, value float64, labels map[string]string, ch chan<- prometheus.Metric) {
+	smbExporter.setMetricWithLabel(name, prometheus.GaugeValue, value, labels, time.Time{}, ch)
+}
+
+// setCounterMetricWithLabel - Send a counter of the exporter itself, created when the exporter started
+func (smbExporter *SambaExporter) setCounterMetricWithLabel(name string, value float64, labels map[string]string, ch chan<- prometheus.Metric) {
+	value, created := smbExporter.getCounterValue(name, labels, value)
+	smbExporter.setMetricWithLabel(name, prometheus.CounterValue, value, labels, created, ch)
+}
+
+func (smbExporter *SambaExporter) setMetricWithLabel(name string, valueType prometheus.ValueType, value float64, labels map[string]string, created time.Time, ch chan<- prometheus.Metric) {
 	desc, found := smbExporter.descriptions[name]
 	if !found {
 		smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("No description found for metric '%s'", name))
@@ -192,12 +598,11 @@ func (smbExporter *SambaExporter) setGaugeIntMetricWithLabel(name string, value
 		}
 	}
 
-	met := prometheus.MustNewConstMetric(&desc, prometheus.GaugeValue, value, labelValues...)
-	ch <- met
+	ch <- newConstMetric(&desc, valueType, value, created, labelValues...)
 }
 
 func (smbExporter *SambaExporter) setGaugeDescriptionNoLabel(name string, help string, ch chan<- *prometheus.Desc) {
-	desc := prometheus.NewDesc(prometheus.BuildFQName(EXPORTER_LABEL_PREFIX, "", name), help, []string{}, nil)
+	desc := prometheus.NewDesc(prometheus.BuildFQName(EXPORTER_LABEL_PREFIX, "", name), help, []string{}, smbExporter.ConstLabels)
 	smbExporter.descriptions[name] = *desc
 	ch <- desc
 }
@@ -209,11 +614,15 @@ func (smbExporter *SambaExporter) setGaugeDescriptionWithLabel(name string, help
 	if !found {
 		var labelKeys []string
 		for key, _ := range labels {
+			if _, isConst := smbExporter.ConstLabels[key]; isConst {
+				smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("The constant label '%s' is also a label of metric '%s', the metric will not be exported", key, name))
+				return
+			}
 			labelKeys = append(labelKeys, key)
 		}
 
 		smbExporter.metricsLabelList[name] = labelKeys
-		desc := prometheus.NewDesc(prometheus.BuildFQName(EXPORTER_LABEL_PREFIX, "", name), help, labelKeys, nil)
+		desc := prometheus.NewDesc(prometheus.BuildFQName(EXPORTER_LABEL_PREFIX, "", name), help, labelKeys, smbExporter.ConstLabels)
 		smbExporter.descriptions[name] = *desc
 		ch <- desc
 	}
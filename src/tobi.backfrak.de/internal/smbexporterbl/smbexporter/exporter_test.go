@@ -6,12 +6,16 @@ package smbexporter
 // LICENSE file.
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
 	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
 	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
 	"tobi.backfrak.de/internal/smbstatusout"
@@ -28,11 +32,11 @@ func TestNewSambaExporter(t *testing.T) {
 	logger := *testhelper.NewTestLogger(true)
 	exporter := NewSambaExporter(&requestHandler, &responseHandler, &logger, "0.0.0", 5, getNewStatisticGenSettings())
 
-	if exporter.RequestHandler.PipeType != commonbl.RequestPipe {
+	if exporter.RequestHandler.GetPipeFilePath() != requestHandler.GetPipeFilePath() {
 		t.Errorf("The exporter.RequestHandler is not of the expected type")
 	}
 
-	if exporter.ResponseHander.PipeType != commonbl.ResposePipe {
+	if exporter.ResponseHander.GetPipeFilePath() != responseHandler.GetPipeFilePath() {
 		t.Errorf("The exporter.RequestHandler is not of the expected type")
 	}
 
@@ -54,7 +58,7 @@ func TestNewSambaExporter(t *testing.T) {
 }
 
 func TestSetDescriptionsFromResponse(t *testing.T) {
-	expectedChanels := 38
+	expectedChanels := 75
 	requestHandler := *commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := *commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := *testhelper.NewTestLogger(true)
@@ -83,8 +87,8 @@ func TestSetDescriptionsFromResponse(t *testing.T) {
 }
 
 func TestSetMetricsFromResponse(t *testing.T) {
-	expectedDescChanels := 38
-	expectedMetChanels := 65
+	expectedDescChanels := 75
+	expectedMetChanels := 120
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -96,7 +100,7 @@ func TestSetMetricsFromResponse(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
@@ -115,9 +119,112 @@ func TestSetMetricsFromResponse(t *testing.T) {
 	}
 }
 
+func TestSetMetricsFromResponseFiltered(t *testing.T) {
+	expectedDescChanels := 75
+	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	psData := smbstatusreader.GetPsData(commonbl.TestPsResponse(), logger)
+	chDesc := make(chan *prometheus.Desc, expectedDescChanels)
+	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
+
+	chAll := make(chan prometheus.Metric, 200)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chAll)
+	chLocks := make(chan prometheus.Metric, 200)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, []string{statisticsGenerator.COLLECTOR_LOCKS}, chLocks)
+	chLocksPs := make(chan prometheus.Metric, 200)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, []string{statisticsGenerator.COLLECTOR_LOCKS, statisticsGenerator.COLLECTOR_PSDATA}, chLocksPs)
+
+	if len(chLocks) >= len(chLocksPs) || len(chLocksPs) >= len(chAll) {
+		t.Errorf("Got '%d' metrics for locks, '%d' for locks and psdata and '%d' for all collectors, but expected less metrics for less collectors", len(chLocks), len(chLocksPs), len(chAll))
+	}
+
+	for len(chLocks) > 0 {
+		desc := (<-chLocks).Desc().String()
+		if strings.Contains(desc, "\"samba_smbd_") || strings.Contains(desc, "\"samba_share_count\"") {
+			t.Errorf("Got the metric '%s', but it should be filtered out", desc)
+		}
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("The ErrorCount '%d' is not the expected '0'", logger.GetErrorCount())
+	}
+}
+
+func TestNewFilteredCollector(t *testing.T) {
+	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
+
+	_, err := exporter.NewFilteredCollector(context.Background(), []string{statisticsGenerator.COLLECTOR_LOCKS, "unknown"})
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the collector is unknown")
+	}
+
+	filtered, err := exporter.NewFilteredCollector(context.Background(), []string{statisticsGenerator.COLLECTOR_SHARES})
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	chDesc := make(chan *prometheus.Desc, 10)
+	filtered.Describe(chDesc)
+	if len(chDesc) != 0 {
+		t.Errorf("Got '%d' descriptions, but the filtered collector should be unchecked", len(chDesc))
+	}
+
+	exporter.StatisticsGeneratorSettings.DisabledCollectors = []string{statisticsGenerator.COLLECTOR_SHARES}
+	_, err = exporter.NewFilteredCollector(context.Background(), []string{statisticsGenerator.COLLECTOR_LOCKS, statisticsGenerator.COLLECTOR_SHARES})
+	switch err.(type) {
+	case *statisticsGenerator.CollectorDisabledError:
+		fmt.Println("OK")
+	default:
+		t.Errorf("Got error of type '%T' but expected '*statisticsGenerator.CollectorDisabledError'", err)
+	}
+}
+
+func TestSetMetricsFromResponseDisabledPsData(t *testing.T) {
+	expectedDescChanels := 75
+	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	psData := smbstatusreader.GetPsData(commonbl.TestPsResponse(), logger)
+	settings := statisticsGenerator.StatisticsGeneratorSettings{DisabledCollectors: []string{statisticsGenerator.COLLECTOR_PSDATA}}
+	chDesc := make(chan *prometheus.Desc, expectedDescChanels)
+	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, settings)
+	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
+	chMet := make(chan prometheus.Metric, 200)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
+
+	for len(chDesc) > 0 {
+		desc := (<-chDesc).String()
+		if strings.Contains(desc, "\"samba_smbd_") {
+			t.Errorf("Got the description '%s' of a disabled collector", desc)
+		}
+	}
+
+	for len(chMet) > 0 {
+		desc := (<-chMet).Desc().String()
+		if strings.Contains(desc, "\"samba_smbd_") {
+			t.Errorf("Got the metric '%s' of a disabled collector", desc)
+		}
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("The ErrorCount '%d' is not the expected '0'", logger.GetErrorCount())
+	}
+}
+
 func TestSetMetricsFromResponseNameWithSpaces(t *testing.T) {
-	expectedDescChanels := 38
-	expectedMetChanels := 61
+	expectedDescChanels := 75
+	expectedMetChanels := 114
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -129,7 +236,7 @@ func TestSetMetricsFromResponseNameWithSpaces(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
@@ -155,9 +262,9 @@ func TestSetMetricsFromResponseNameWithSpaces(t *testing.T) {
 }
 
 func TestSetMetricsFromResponseNoPid(t *testing.T) {
-	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{false, false, false, true, false}
-	expectedDescChanels := 38
-	expectedMetChanels := 47
+	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{DoNotExportPid: true}
+	expectedDescChanels := 74
+	expectedMetChanels := 88
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -169,7 +276,7 @@ func TestSetMetricsFromResponseNoPid(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, exportSettings)
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
@@ -182,9 +289,9 @@ func TestSetMetricsFromResponseNoPid(t *testing.T) {
 }
 
 func TestSetMetricsFromResponseNoUser(t *testing.T) {
-	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{false, true, false, false, false}
-	expectedDescChanels := 38
-	expectedMetChanels := 57
+	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{DoNotExportUser: true}
+	expectedDescChanels := 75
+	expectedMetChanels := 112
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -196,7 +303,7 @@ func TestSetMetricsFromResponseNoUser(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, exportSettings)
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
@@ -209,9 +316,9 @@ func TestSetMetricsFromResponseNoUser(t *testing.T) {
 }
 
 func TestSetMetricsFromResponseNoShareDetails(t *testing.T) {
-	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{false, false, false, false, true}
-	expectedDescChanels := 38
-	expectedMetChanels := 53
+	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{DoNotExportShareDetails: true}
+	expectedDescChanels := 74
+	expectedMetChanels := 100
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -223,7 +330,7 @@ func TestSetMetricsFromResponseNoShareDetails(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, exportSettings)
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
@@ -236,9 +343,9 @@ func TestSetMetricsFromResponseNoShareDetails(t *testing.T) {
 }
 
 func TestSetMetricsFromResponseNoClient(t *testing.T) {
-	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{true, false, false, false, false}
-	expectedDescChanels := 38
-	expectedMetChanels := 53
+	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{DoNotExportClient: true}
+	expectedDescChanels := 75
+	expectedMetChanels := 108
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -250,7 +357,7 @@ func TestSetMetricsFromResponseNoClient(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, exportSettings)
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
@@ -262,9 +369,9 @@ func TestSetMetricsFromResponseNoClient(t *testing.T) {
 }
 
 func TestSetMetricsFromResponseCluster(t *testing.T) {
-	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{true, false, false, false, false}
-	expectedDescChanels := 42
-	expectedMetChanels := 53
+	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{DoNotExportClient: true}
+	expectedDescChanels := 82
+	expectedMetChanels := 108
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -276,7 +383,7 @@ func TestSetMetricsFromResponseCluster(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, exportSettings)
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
@@ -288,9 +395,9 @@ func TestSetMetricsFromResponseCluster(t *testing.T) {
 }
 
 func TestSetMetricsFromResponseNoShare(t *testing.T) {
-	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{false, false, true, false, false}
-	expectedDescChanels := 38
-	expectedMetChanels := 62
+	exportSettings := statisticsGenerator.StatisticsGeneratorSettings{DoNotExportEncryption: true}
+	expectedDescChanels := 71
+	expectedMetChanels := 110
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -302,7 +409,7 @@ func TestSetMetricsFromResponseNoShare(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, exportSettings)
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric channels, but expected %d", len(chMet), expectedMetChanels)
@@ -314,8 +421,8 @@ func TestSetMetricsFromResponseNoShare(t *testing.T) {
 }
 
 func TestSetMetricsFromEmptyResponse1(t *testing.T) {
-	expectedDescChanels := 38
-	expectedMetChanels := 19
+	expectedDescChanels := 75
+	expectedMetChanels := 51
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -327,7 +434,7 @@ func TestSetMetricsFromEmptyResponse1(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 32, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 32, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric chanels, but expected %d", len(chMet), expectedMetChanels)
@@ -339,8 +446,8 @@ func TestSetMetricsFromEmptyResponse1(t *testing.T) {
 }
 
 func TestSetMetricsFromEmptyResponse2(t *testing.T) {
-	expectedDescChanels := 38
-	expectedMetChanels := 19
+	expectedDescChanels := 75
+	expectedMetChanels := 51
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
 	logger := testhelper.NewTestLogger(true)
@@ -352,7 +459,7 @@ func TestSetMetricsFromEmptyResponse2(t *testing.T) {
 	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
 	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, chDesc)
 	chMet := make(chan prometheus.Metric, expectedMetChanels)
-	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 32, chMet)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 32, nil, chMet)
 
 	if len(chMet) != expectedMetChanels {
 		t.Errorf("Got %d metric chanels, but expected %d", len(chMet), expectedMetChanels)
@@ -432,6 +539,38 @@ func TestSetGaugeDescriptionWithLabel(t *testing.T) {
 	}
 }
 
+func TestSetGaugeDescriptionConstLabels(t *testing.T) {
+	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	logger := testhelper.NewTestLogger(true)
+	ch := make(chan *prometheus.Desc, 3)
+	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.ConstLabels = map[string]string{"datacenter": "fra1"}
+
+	exporter.setGaugeDescriptionNoLabel("my_name", "My help", ch)
+	exporter.setGaugeDescriptionWithLabel("my_labeled_name", "My help", map[string]string{"key1": "value1"}, ch)
+
+	for i := 0; i < 2; i++ {
+		descString := (<-ch).String()
+		if !strings.Contains(descString, "datacenter") || !strings.Contains(descString, "fra1") {
+			t.Errorf("The description '%s' does not contain the constant label", descString)
+		}
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("The ErrorCount '%d' is not the expected '0'", logger.GetErrorCount())
+	}
+
+	exporter.setGaugeDescriptionWithLabel("my_conflicting_name", "My help", map[string]string{"datacenter": "value1"}, ch)
+	if len(ch) != 0 {
+		t.Errorf("Got a description for a metric with a label conflicting with a constant label")
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+}
+
 func TestSetGaugeIntMetricNoLabel(t *testing.T) {
 	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
 	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
@@ -539,3 +678,225 @@ func TestSetGaugeIntMetricWithLabelNoDescription(t *testing.T) {
 		t.Errorf("The error message '%s' is not the expected 'Error: No description found for metric 'my_name''", logger.WrittenErrors[0])
 	}
 }
+
+// brokenHandler - A commonbl.MessageHandler that fails like a broken pipe
+type brokenHandler struct{}
+
+func (handler *brokenHandler) WaitForPipeInputString() (string, error) {
+	return "", errors.New("broken pipe")
+}
+
+func (handler *brokenHandler) WritePipeString(data string) error {
+	return errors.New("broken pipe")
+}
+
+func (handler *brokenHandler) GetPipeFilePath() string {
+	return "broken"
+}
+
+func TestCollectMetricsStatusdNotReachable(t *testing.T) {
+	handler := &brokenHandler{}
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(handler, handler, logger, "0.0.0", 1, getNewStatisticGenSettings())
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	psData := smbstatusreader.GetPsData(commonbl.TestPsResponse(), logger)
+	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, make(chan *prometheus.Desc, 200))
+	errorsBefore := GetScrapeErrorCount()
+
+	chMet := make(chan prometheus.Metric, 200)
+	exporter.collectMetrics(context.Background(), nil, chMet)
+
+	if GetScrapeErrorCount() != errorsBefore+1 {
+		t.Errorf("Got '%d' scrape errors, but expected '%d'", GetScrapeErrorCount(), errorsBefore+1)
+	}
+
+	values := map[string]float64{}
+	for len(chMet) > 0 {
+		metric := <-chMet
+		var data dto.Metric
+		errWrite := metric.Write(&data)
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		desc := metric.Desc().String()
+		name := desc[strings.Index(desc, "\"")+1 : strings.Index(desc, "\", help")]
+		if data.Gauge != nil {
+			values[name] = data.Gauge.GetValue()
+		} else if data.Counter != nil {
+			values[name] = data.Counter.GetValue()
+		}
+	}
+
+	if value, found := values["samba_statusd_up"]; !found || value != 0 {
+		t.Errorf("Got samba_statusd_up '%f' (found: %t), but expected '0'", value, found)
+	}
+
+	if value, found := values["samba_scrape_errors_total"]; !found || value != float64(errorsBefore+1) {
+		t.Errorf("Got samba_scrape_errors_total '%f' (found: %t), but expected '%d'", value, found, errorsBefore+1)
+	}
+
+	if _, found := values["samba_scrape_duration_seconds"]; !found {
+		t.Errorf("Got no samba_scrape_duration_seconds")
+	}
+}
+
+func TestCollectMetricsMemoryLimit(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	client := startStatusTestStatusd(t, commonbl.TestLockResponse)
+	exporter := NewSambaExporter(client, client, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.MemoryLimit = 1
+	abortsBefore := pipecomunication.GetMemoryLimitAbortCount()
+
+	chDesc := make(chan *prometheus.Desc, 100)
+	exporter.Describe(chDesc)
+	if len(chDesc) == 0 {
+		t.Errorf("Got no descriptions, but expected the descriptions without the samba status")
+	}
+
+	chMet := make(chan prometheus.Metric, 200)
+	exporter.collectMetrics(context.Background(), nil, chMet)
+
+	values := map[string]float64{}
+	for len(chMet) > 0 {
+		metric := <-chMet
+		var data dto.Metric
+		errWrite := metric.Write(&data)
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		desc := metric.Desc().String()
+		name := desc[strings.Index(desc, "\"")+1 : strings.Index(desc, "\", help")]
+		if data.Gauge != nil {
+			values[name] = data.Gauge.GetValue()
+		} else if data.Counter != nil {
+			values[name] = data.Counter.GetValue()
+		}
+	}
+
+	if value, found := values["samba_statusd_up"]; !found || value != 1 {
+		t.Errorf("Got samba_statusd_up '%f' (found: %t), but expected '1'", value, found)
+	}
+
+	if value, found := values["samba_server_up"]; !found || value != 0 {
+		t.Errorf("Got samba_server_up '%f' (found: %t), but expected '0'", value, found)
+	}
+
+	if value, found := values["samba_memory_limit_aborts_total"]; !found || value != float64(abortsBefore+2) {
+		t.Errorf("Got samba_memory_limit_aborts_total '%f' (found: %t), but expected '%d'", value, found, abortsBefore+2)
+	}
+}
+
+func TestCollectMetricsCancelled(t *testing.T) {
+	handler := &brokenHandler{}
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(handler, handler, logger, "0.0.0", 1, getNewStatisticGenSettings())
+	errorsBefore := GetScrapeErrorCount()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	chMet := make(chan prometheus.Metric, 200)
+	exporter.collectMetrics(ctx, nil, chMet)
+
+	if len(chMet) != 0 {
+		t.Errorf("Got '%d' metrics, but expected none for a cancelled scrape", len(chMet))
+	}
+
+	if GetScrapeErrorCount() != errorsBefore {
+		t.Errorf("Got '%d' scrape errors, but expected '%d'", GetScrapeErrorCount(), errorsBefore)
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestSetMetricsFromResponseMetricTypes(t *testing.T) {
+	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	psData := smbstatusreader.GetPsData(commonbl.TestPsResponse(), logger)
+	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, make(chan *prometheus.Desc, 200))
+	chMet := make(chan prometheus.Metric, 200)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
+
+	counters := map[string]bool{}
+	for len(chMet) > 0 {
+		metric := <-chMet
+		var data dto.Metric
+		errWrite := metric.Write(&data)
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		desc := metric.Desc().String()
+		name := desc[strings.Index(desc, "\"")+1 : strings.Index(desc, "\", help")]
+		if data.Counter == nil {
+			continue
+		}
+		counters[name] = true
+
+		// The sums over the smbd processes since the exporter started are counters of the exporter itself
+		processCounter := statisticsGenerator.IsCounter(strings.TrimPrefix(name, "samba_"))
+		if processCounter && data.Counter.CreatedTimestamp != nil {
+			t.Errorf("The counter '%s' of a smbd process has a creation time", name)
+		}
+		if !processCounter && data.Counter.CreatedTimestamp.AsTime().Unix() != exporter.startTime.Unix() {
+			t.Errorf("The counter '%s' was not created when the exporter started", name)
+		}
+	}
+
+	for _, name := range []string{"samba_scrape_errors_total", "samba_statusd_dropped_responses_total", "samba_statusd_request_timeouts_total", "samba_memory_limit_aborts_total",
+		"samba_parser_errors_total", "samba_dead_entries_dropped_total", "samba_smbd_io_counter_read_count_total", "samba_smbd_io_counter_write_bytes_total",
+		"samba_smbd_io_read_bytes_total", "samba_smbd_io_write_bytes_total"} {
+		if !counters[name] {
+			t.Errorf("The metric '%s' is not a counter", name)
+		}
+	}
+
+	for _, name := range []string{"samba_server_up", "samba_locked_file_count", "samba_smbd_sum_io_counter_read_count"} {
+		if counters[name] {
+			t.Errorf("The metric '%s' is a counter, but should be a gauge", name)
+		}
+	}
+}
+
+func TestSetMetricsFromResponseCardinalityLimit(t *testing.T) {
+	requestHandler := commonbl.NewPipeHandler(true, commonbl.RequestPipe)
+	responseHandler := commonbl.NewPipeHandler(true, commonbl.ResposePipe)
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	psData := smbstatusreader.GetPsData(commonbl.TestPsResponse(), logger)
+	exporter := NewSambaExporter(requestHandler, responseHandler, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.CardinalityLimit = 1
+	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, make(chan *prometheus.Desc, 200))
+	limitedBefore := GetCardinalityLimitedCounts()["smbd_cpu_usage_percentage"]
+	chMet := make(chan prometheus.Metric, 200)
+	exporter.setMetricsFromResponse(locks, processes, shares, psData, 1, 1, 31, nil, chMet)
+
+	series := map[string]int{}
+	for len(chMet) > 0 {
+		metric := <-chMet
+		desc := metric.Desc().String()
+		name := desc[strings.Index(desc, "\"")+1 : strings.Index(desc, "\", help")]
+		series[name]++
+	}
+
+	for name, count := range series {
+		if count > 2 && name != "samba_parser_errors_total" && name != "samba_dead_entries_dropped_total" && name != "samba_exporter_cardinality_limited_total" {
+			t.Errorf("Got %d series of '%s', but expected at most 2", count, name)
+		}
+	}
+	if series["samba_exporter_cardinality_limited_total"] == 0 {
+		t.Errorf("The samba_exporter_cardinality_limited_total was not exported")
+	}
+	if GetCardinalityLimitedCounts()["smbd_cpu_usage_percentage"] <= limitedBefore {
+		t.Errorf("The collapsed series of 'smbd_cpu_usage_percentage' were not counted")
+	}
+}
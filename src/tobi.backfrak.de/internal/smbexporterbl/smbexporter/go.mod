@@ -24,6 +24,14 @@ replace tobi.backfrak.de/internal/smbstatusout v0.0.0 => ../../smbstatusout
 
 require github.com/prometheus/client_golang v1.19.0
 
+require github.com/prometheus/client_model v0.5.0
+
+require github.com/golang/snappy v0.0.4
+
+require google.golang.org/protobuf v1.34.2
+
+require golang.org/x/sync v0.8.0
+
 require tobi.backfrak.de/internal/testhelper v0.0.0
 
 replace tobi.backfrak.de/internal/testhelper v0.0.0 => ../../../internal/testhelper
@@ -31,9 +39,17 @@ replace tobi.backfrak.de/internal/testhelper v0.0.0 => ../../../internal/testhel
 require (
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
-	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/oschwald/maxminddb-golang v1.12.0 // indirect
 	github.com/prometheus/common v0.48.0 // indirect
 	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
 	golang.org/x/sys v0.16.0 // indirect
-	google.golang.org/protobuf v1.33.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/grpc v1.62.1 // indirect
 )
+
+require tobi.backfrak.de/internal/statusdrpc v0.0.0
+
+replace tobi.backfrak.de/internal/statusdrpc v0.0.0 => ../../statusdrpc
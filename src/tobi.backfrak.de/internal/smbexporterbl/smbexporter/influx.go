@@ -0,0 +1,196 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// InfluxWriter - Writes the metrics of a registry in an interval in the InfluxDB line protocol,
+// to the write API of an InfluxDB or Telegraf listening on HTTP, or appended to a file
+type InfluxWriter struct {
+	target   string
+	interval time.Duration
+	gatherer prometheus.Gatherer
+	logger   commonbl.Logger
+	client   *http.Client
+	// The token sent in the Authorization header to an InfluxDB 2, used when set
+	Token string
+}
+
+// The characters escaped in the measurement, and in the keys and values of the tags and the keys of the fields
+var influxMeasurementEscaper = strings.NewReplacer(",", "\\,", " ", "\\ ")
+var influxKeyEscaper = strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ ")
+
+// NewInfluxWriter - Get an InfluxWriter writing the metrics of the gatherer in the interval. When the target is a http or https URL,
+// e. g. 'http://influxdb:8086/api/v2/write?org=it&bucket=samba', the lines are sent to it, waiting at most timeout for the answer.
+// Otherwise the target is the path of a file the lines are appended to
+func NewInfluxWriter(target string, interval time.Duration, timeout time.Duration, gatherer prometheus.Gatherer, logger commonbl.Logger) (*InfluxWriter, error) {
+	if isHTTPTarget(target) {
+		errCheck := checkPushSettings(target, interval)
+		if errCheck != nil {
+			return nil, errCheck
+		}
+	} else if interval <= 0 {
+		return nil, fmt.Errorf("the push interval '%s' is not positive", interval)
+	}
+
+	return &InfluxWriter{target: target, interval: interval, gatherer: gatherer, logger: logger, client: &http.Client{Timeout: timeout}}, nil
+}
+
+// Run - Write the metrics in the interval. A failed write is logged, the metrics are written again in the next interval
+func (writer *InfluxWriter) Run() {
+	runPushLoop(writer.target, writer.interval, writer.Push, writer.logger)
+}
+
+// Push - Gather the metrics and write them to the target
+func (writer *InfluxWriter) Push() error {
+	families, errGather := writer.gatherer.Gather()
+	if errGather != nil {
+		return errGather
+	}
+	lines := GetInfluxLines(families, time.Now())
+
+	if !isHTTPTarget(writer.target) {
+		file, errOpen := os.OpenFile(writer.target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+		if errOpen != nil {
+			return errOpen
+		}
+		defer file.Close()
+		_, errWrite := file.WriteString(strings.Join(lines, ""))
+
+		return errWrite
+	}
+
+	request, errRequest := http.NewRequest(http.MethodPost, writer.target, bytes.NewReader([]byte(strings.Join(lines, ""))))
+	if errRequest != nil {
+		return errRequest
+	}
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if writer.Token != "" {
+		request.Header.Set("Authorization", "Token "+writer.Token)
+	}
+
+	response, errPost := writer.client.Do(request)
+	if errPost != nil {
+		return errPost
+	}
+	defer response.Body.Close()
+
+	errResponse := checkPushResponse(response)
+	if errResponse != nil {
+		return errResponse
+	}
+	writer.logger.WriteVerbose(fmt.Sprintf("Wrote %d lines to '%s'", len(lines), writer.target))
+
+	return nil
+}
+
+// isHTTPTarget - Tell if the target is a http or https URL, not a file
+func isHTTPTarget(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+// GetInfluxLines - Get a line for each metric of the families, with the labels as tags and the fields of getMetricFields,
+// like Telegraf does for Prometheus metrics. NaN and infinite values can not be written and are dropped
+func GetInfluxLines(families []*dto.MetricFamily, now time.Time) []string {
+	var ret []string
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			timestamp := now
+			if metric.TimestampMs != nil {
+				timestamp = time.UnixMilli(metric.GetTimestampMs())
+			}
+			line := getInfluxLine(family.GetName(), metric.GetLabel(), getMetricFields(family.GetType(), metric), timestamp)
+			if line != "" {
+				ret = append(ret, line)
+			}
+		}
+	}
+
+	return ret
+}
+
+// getMetricFields - Get the fields of a metric. Counters, gauges and untyped metrics get the field 'value'.
+// Histograms and summaries get the fields 'count' and 'sum', and a field for each bucket or quantile
+func getMetricFields(metricType dto.MetricType, metric *dto.Metric) map[string]float64 {
+	fields := make(map[string]float64)
+	switch metricType {
+	case dto.MetricType_COUNTER:
+		fields["value"] = metric.GetCounter().GetValue()
+	case dto.MetricType_GAUGE:
+		fields["value"] = metric.GetGauge().GetValue()
+	case dto.MetricType_UNTYPED:
+		fields["value"] = metric.GetUntyped().GetValue()
+	case dto.MetricType_SUMMARY:
+		summary := metric.GetSummary()
+		fields["count"] = float64(summary.GetSampleCount())
+		fields["sum"] = summary.GetSampleSum()
+		for _, quantile := range summary.GetQuantile() {
+			fields[formatFloat(quantile.GetQuantile())] = quantile.GetValue()
+		}
+	case dto.MetricType_HISTOGRAM:
+		histogram := metric.GetHistogram()
+		fields["count"] = float64(histogram.GetSampleCount())
+		fields["sum"] = histogram.GetSampleSum()
+		for _, bucket := range histogram.GetBucket() {
+			fields[formatFloat(bucket.GetUpperBound())] = float64(bucket.GetCumulativeCount())
+		}
+		fields["+Inf"] = float64(histogram.GetSampleCount())
+	}
+
+	return fields
+}
+
+// getInfluxLine - Get the line of a metric, sorted by the keys of the tags and fields, so the lines are the same in each interval.
+// Returns an empty string, when the metric has no field with a valid value
+func getInfluxLine(measurement string, labels []*dto.LabelPair, fields map[string]float64, timestamp time.Time) string {
+	var fieldKeys []string
+	for key, value := range fields {
+		if !math.IsNaN(value) && !math.IsInf(value, 0) {
+			fieldKeys = append(fieldKeys, key)
+		}
+	}
+	if len(fieldKeys) == 0 {
+		return ""
+	}
+	sort.Strings(fieldKeys)
+
+	var line strings.Builder
+	line.WriteString(influxMeasurementEscaper.Replace(measurement))
+	sortedLabels := append([]*dto.LabelPair{}, labels...)
+	sort.Slice(sortedLabels, func(i, j int) bool { return sortedLabels[i].GetName() < sortedLabels[j].GetName() })
+	for _, label := range sortedLabels {
+		// A tag with an empty value is not valid
+		if label.GetValue() == "" {
+			continue
+		}
+		line.WriteString(fmt.Sprintf(",%s=%s", influxKeyEscaper.Replace(label.GetName()), influxKeyEscaper.Replace(label.GetValue())))
+	}
+
+	for i, key := range fieldKeys {
+		separator := ","
+		if i == 0 {
+			separator = " "
+		}
+		line.WriteString(fmt.Sprintf("%s%s=%s", separator, influxKeyEscaper.Replace(key), strconv.FormatFloat(fields[key], 'g', -1, 64)))
+	}
+	line.WriteString(fmt.Sprintf(" %d\n", timestamp.UnixNano()))
+
+	return line.String()
+}
@@ -0,0 +1,105 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestInfluxWriterPushHTTP(t *testing.T) {
+	body := ""
+	authorization := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	writer, errNew := NewInfluxWriter(server.URL+"/api/v2/write?org=it&bucket=samba", time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	writer.Token = "token"
+
+	errPush := writer.Push()
+	if errPush != nil {
+		t.Fatalf("Got error '%s' but expected none", errPush.Error())
+	}
+
+	if authorization != "Token token" {
+		t.Errorf("The Authorization header '%s' is not expected", authorization)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Got %d lines, but expected 2: %s", len(lines), body)
+	}
+	if !strings.HasPrefix(lines[0], "samba_test_seconds +Inf=2,0.5=1,count=2,sum=2.25 ") {
+		t.Errorf("The line '%s' is not expected", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "samba_test_total,share=data value=3 ") {
+		t.Errorf("The line '%s' is not expected", lines[1])
+	}
+}
+
+func TestInfluxWriterPushFile(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "samba.influx")
+	writer, errNew := NewInfluxWriter(target, time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		errPush := writer.Push()
+		if errPush != nil {
+			t.Fatalf("Got error '%s' but expected none", errPush.Error())
+		}
+	}
+
+	data, errRead := os.ReadFile(target)
+	if errRead != nil {
+		t.Fatalf("Got error '%s' but expected none", errRead.Error())
+	}
+	if strings.Count(string(data), "\n") != 4 {
+		t.Errorf("The lines of both writes are not in the file: %s", string(data))
+	}
+}
+
+func TestGetInfluxLineEscaping(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "samba_test", Help: "A test gauge"}, []string{"share", "user"})
+	gauge.WithLabelValues("my share,1", "").Set(1)
+	registry.MustRegister(gauge)
+	families, _ := registry.Gather()
+
+	lines := GetInfluxLines(families, time.Unix(1, 0))
+	if len(lines) != 1 || lines[0] != "samba_test,share=my\\ share\\,1 value=1 1000000000\n" {
+		t.Errorf("The lines %v are not expected", lines)
+	}
+}
+
+func TestNewInfluxWriterInvalid(t *testing.T) {
+	_, errNew := NewInfluxWriter("http://", time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew == nil {
+		t.Errorf("Got no error for an URL without host, but expected one")
+	}
+
+	_, errNew = NewInfluxWriter("/var/log/samba.influx", 0, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew == nil {
+		t.Errorf("Got no error for the interval 0, but expected one")
+	}
+}
@@ -0,0 +1,108 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"sync"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// ioBytes - The bytes a smbd process read from and wrote to the disk, as given in /proc/<pid>/io
+type ioBytes struct {
+	read  uint64
+	write uint64
+}
+
+// The number of scrapes a smbd process is kept, after it was seen last. So a scrape missing the ps data, e. g. since samba_statusd
+// could not read it, does not make the processes look new, when they are seen again
+const maxMissedIoScrapes = 3
+
+// ioProcess - The I/O counters of a smbd process
+type ioProcess struct {
+	// The bytes when the process was seen first, 0 for processes started after the first scrape
+	start ioBytes
+	// The bytes when the process was seen last
+	last ioBytes
+	// The scrapes since the process was seen last
+	missed int
+}
+
+// ioTracker - Remembers the I/O counters of the smbd processes, to sum up the bytes read and written by all smbd processes
+// since the exporter started. Unlike the smbd_sum_io_counter_* gauges, the sums keep the bytes of processes that exited:
+// The last seen bytes of a process that vanished are kept, and moved to the retired bytes once it was missed by maxMissedIoScrapes scrapes.
+// The bytes a process reads or writes after the last scrape it was seen by are not known, and not part of the sums
+type ioTracker struct {
+	mutex     sync.Mutex
+	processes map[int64]*ioProcess
+	retired   ioBytes
+	// Tells if the processes of the first scrape were seen, they started before the exporter
+	started bool
+}
+
+func newIoTracker() *ioTracker {
+	return &ioTracker{processes: map[int64]*ioProcess{}}
+}
+
+// update - Remember the bytes the processes read and wrote. Processes not seen by the first scrape started since, so all their bytes are counted.
+// The processes seen by the first scrape started before the exporter, only the bytes since are counted
+func (tracker *ioTracker) update(psData []commonbl.PsUtilPidData) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	seen := make(map[int64]bool)
+	for _, data := range psData {
+		bytes := ioBytes{data.IoCounterReadBytes, data.IoCounterWriteBytes}
+		seen[data.PID] = true
+		process, found := tracker.processes[data.PID]
+		if found && (bytes.read < process.last.read || bytes.write < process.last.write) {
+			// The PID was reused by a new process
+			tracker.retire(data.PID, process)
+			found = false
+		}
+		if !found {
+			process = &ioProcess{}
+			if !tracker.started {
+				process.start = bytes
+			}
+			tracker.processes[data.PID] = process
+		}
+		process.last = bytes
+		process.missed = 0
+	}
+	tracker.started = true
+
+	for pid, process := range tracker.processes {
+		if seen[pid] {
+			continue
+		}
+		process.missed++
+		if process.missed > maxMissedIoScrapes {
+			tracker.retire(pid, process)
+		}
+	}
+}
+
+// retire - Add the bytes of the process that exited to the retired bytes and forget it
+func (tracker *ioTracker) retire(pid int64, process *ioProcess) {
+	tracker.retired.read += process.last.read - process.start.read
+	tracker.retired.write += process.last.write - process.start.write
+	delete(tracker.processes, pid)
+}
+
+// getTotals - Get the bytes read and written by all smbd processes since the exporter started
+func (tracker *ioTracker) getTotals() (uint64, uint64) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	read, write := tracker.retired.read, tracker.retired.write
+	for _, process := range tracker.processes {
+		read += process.last.read - process.start.read
+		write += process.last.write - process.start.write
+	}
+
+	return read, write
+}
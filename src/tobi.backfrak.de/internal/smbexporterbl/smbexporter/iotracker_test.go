@@ -0,0 +1,69 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestIoTracker(t *testing.T) {
+	tracker := newIoTracker()
+
+	// The processes of the first scrape started before the exporter
+	tracker.update([]commonbl.PsUtilPidData{{PID: 1117, IoCounterReadBytes: 1000, IoCounterWriteBytes: 100}})
+	if read, write := tracker.getTotals(); read != 0 || write != 0 {
+		t.Errorf("Got '%d' bytes read and '%d' written after the first scrape, but expected none", read, write)
+	}
+
+	tracker.update([]commonbl.PsUtilPidData{{PID: 1117, IoCounterReadBytes: 1500, IoCounterWriteBytes: 150},
+		{PID: 1120, IoCounterReadBytes: 300, IoCounterWriteBytes: 30}})
+	if read, write := tracker.getTotals(); read != 800 || write != 80 {
+		t.Errorf("Got '%d' bytes read and '%d' written, but expected '800' and '80'", read, write)
+	}
+
+	// The bytes of the exited process 1120 are kept, the PID 1117 is used by a new process
+	tracker.update([]commonbl.PsUtilPidData{{PID: 1117, IoCounterReadBytes: 200, IoCounterWriteBytes: 20}})
+	if read, write := tracker.getTotals(); read != 1000 || write != 100 {
+		t.Errorf("Got '%d' bytes read and '%d' written, but expected '1000' and '100'", read, write)
+	}
+}
+
+func TestIoTrackerMissedScrapes(t *testing.T) {
+	tracker := newIoTracker()
+	tracker.update([]commonbl.PsUtilPidData{{PID: 1117, IoCounterReadBytes: 1000, IoCounterWriteBytes: 100}})
+	tracker.update([]commonbl.PsUtilPidData{{PID: 1117, IoCounterReadBytes: 1500, IoCounterWriteBytes: 150},
+		{PID: 1120, IoCounterReadBytes: 300, IoCounterWriteBytes: 30}})
+
+	// A scrape without ps data does not make the processes look new, when they are seen again
+	tracker.update([]commonbl.PsUtilPidData{})
+	if read, write := tracker.getTotals(); read != 800 || write != 80 {
+		t.Errorf("Got '%d' bytes read and '%d' written, but expected '800' and '80'", read, write)
+	}
+	tracker.update([]commonbl.PsUtilPidData{{PID: 1117, IoCounterReadBytes: 1600, IoCounterWriteBytes: 160},
+		{PID: 1120, IoCounterReadBytes: 300, IoCounterWriteBytes: 30}})
+	if read, write := tracker.getTotals(); read != 900 || write != 90 {
+		t.Errorf("Got '%d' bytes read and '%d' written, but expected '900' and '90'", read, write)
+	}
+
+	// The bytes of the processes are retired, once they were missed too often
+	for i := 0; i <= maxMissedIoScrapes; i++ {
+		tracker.update([]commonbl.PsUtilPidData{})
+	}
+	if len(tracker.processes) != 0 {
+		t.Errorf("Got '%d' processes, but expected none", len(tracker.processes))
+	}
+	if read, write := tracker.getTotals(); read != 900 || write != 90 {
+		t.Errorf("Got '%d' bytes read and '%d' written after the processes exited, but expected '900' and '90'", read, write)
+	}
+
+	// A process using a retired PID is new
+	tracker.update([]commonbl.PsUtilPidData{{PID: 1117, IoCounterReadBytes: 50, IoCounterWriteBytes: 5}})
+	if read, write := tracker.getTotals(); read != 950 || write != 95 {
+		t.Errorf("Got '%d' bytes read and '%d' written, but expected '950' and '95'", read, write)
+	}
+}
@@ -0,0 +1,43 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"math"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// JSON_NAME_KEY - The key of the metric name in the objects of GetJSONMetrics
+const JSON_NAME_KEY = "name"
+
+// GetJSONMetrics - Get a flat object for each metric of the families, as the json parser of Telegraf reads them.
+// The object has the metric name in the key 'name', the labels as strings and the fields of getMetricFields as numbers.
+// NaN and infinite values can not be encoded in JSON and are dropped, a metric without any valid value is dropped
+func GetJSONMetrics(families []*dto.MetricFamily) []map[string]interface{} {
+	ret := []map[string]interface{}{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			object := make(map[string]interface{})
+			for key, value := range getMetricFields(family.GetType(), metric) {
+				if !math.IsNaN(value) && !math.IsInf(value, 0) {
+					object[key] = value
+				}
+			}
+			if len(object) == 0 {
+				continue
+			}
+
+			for _, label := range metric.GetLabel() {
+				object[label.GetName()] = label.GetValue()
+			}
+			object[JSON_NAME_KEY] = family.GetName()
+			ret = append(ret, object)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,52 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestGetJSONMetrics(t *testing.T) {
+	families, _ := getTestRegistry().Gather()
+
+	metrics := GetJSONMetrics(families)
+	if len(metrics) != 2 {
+		t.Fatalf("Got %d metrics, but expected 2: %v", len(metrics), metrics)
+	}
+	if metrics[0]["name"] != "samba_test_seconds" || metrics[0]["count"] != float64(2) || metrics[0]["sum"] != 2.25 || metrics[0]["0.5"] != float64(1) {
+		t.Errorf("The histogram %v is not expected", metrics[0])
+	}
+	if metrics[1]["name"] != "samba_test_total" || metrics[1]["share"] != "data" || metrics[1]["value"] != float64(3) {
+		t.Errorf("The counter %v is not expected", metrics[1])
+	}
+
+	_, errMarshal := json.Marshal(metrics)
+	if errMarshal != nil {
+		t.Errorf("Got error '%s' but expected none", errMarshal.Error())
+	}
+}
+
+func TestGetJSONMetricsDropsNaN(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "samba_test", Help: "A test gauge"})
+	gauge.Set(math.NaN())
+	registry.MustRegister(gauge)
+	families, _ := registry.Gather()
+
+	metrics := GetJSONMetrics(families)
+	if len(metrics) != 0 {
+		t.Errorf("Got the metrics %v, but expected none", metrics)
+	}
+
+	_, errMarshal := json.Marshal(metrics)
+	if errMarshal != nil {
+		t.Errorf("Got error '%s' but expected none", errMarshal.Error())
+	}
+}
@@ -0,0 +1,174 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+)
+
+// The smbstatus tables of a small samba cluster. Together with the tables of the test mode of samba_statusd
+// they give every metric the exporter exports. Every label has a value, so no metric is dropped
+const sampleClusterLockTable = `Locked files:
+Pid          Uid        DenyMode   Access      R/W        Oplock           SharePath   Name   Time
+--------------------------------------------------------------------------------------------------
+1:1120       1080       DENY_NONE  0x80        RDONLY     NONE             /srv/data   report.odt   Sun May 16 12:07:02 2021
+2:1121       1081       DENY_WRITE 0x120089    RDWR       LEASE(RWH)       /srv/foto   summer.jpg   Sun May 16 12:09:12 2021`
+
+const sampleClusterShareTable = `Samba version 4.9.5-Debian
+PID     Username     Group        Machine                                   Protocol Version  Encryption           Signing
+----------------------------------------------------------------------------------------------------------------------------------------
+1:1120  1080         117          192.168.1.242 (ipv4:192.168.1.242:42296)  SMB3_11           -                    -
+2:1121  1081         117          192.168.1.243 (ipv4:192.168.1.243:42298)  SMB3_11           -                    -`
+
+const sampleClusterProcessTable = `Samba version 4.9.5-Debian
+PID     Username     Group        Machine                                   Protocol Version  Encryption           Signing
+----------------------------------------------------------------------------------------------------------------------------------------
+1:1120  1080         117          192.168.1.242 (ipv4:192.168.1.242:42296)  SMB3_11           -                    -
+2:1121  1081         117          192.168.1.243 (ipv4:192.168.1.243:42298)  SMB3_11           -                    -`
+
+// sampleTables - The lock, share and process tables the metrics are listed with
+var sampleTables = [][3]string{
+	{commonbl.TestLockResponse, commonbl.TestShareResponse, commonbl.TestProcessResponse},
+	{sampleClusterLockTable, sampleClusterShareTable, sampleClusterProcessTable},
+}
+
+// MetricInfo - The name, type, help text and labels of a metric the exporter exports
+type MetricInfo struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Help   string   `json:"help"`
+	Labels []string `json:"labels"`
+	// The collector the metric belongs to, empty for the metrics about the exporter itself
+	Collector string `json:"collector,omitempty"`
+}
+
+// sampleCollector - A prometheus collector exporting the metrics of a SambaExporter for the sample smbstatus tables
+type sampleCollector struct {
+	exporter  *SambaExporter
+	locks     []smbstatusreader.LockData
+	processes []smbstatusreader.ProcessData
+	shares    []smbstatusreader.ShareData
+	psData    []commonbl.PsUtilPidData
+}
+
+// newSampleCollector - Get a sampleCollector for the exporter and the lock, share and process table
+func newSampleCollector(exporter *SambaExporter, tables [3]string) *sampleCollector {
+	return &sampleCollector{
+		exporter:  exporter,
+		locks:     smbstatusreader.GetLockData(tables[0], exporter.Logger),
+		shares:    smbstatusreader.GetShareData(tables[1], exporter.Logger),
+		processes: smbstatusreader.GetProcessData(tables[2], exporter.Logger),
+		psData:    commonbl.GetTestPsUtilPidData(),
+	}
+}
+
+// Describe function for the Prometheus Exporter Interface
+func (collector *sampleCollector) Describe(ch chan<- *prometheus.Desc) {
+	collector.exporter.setDescriptionsFromResponse(collector.locks, collector.processes, collector.shares, collector.psData, ch)
+}
+
+// Collect function for the Prometheus Exporter Interface
+func (collector *sampleCollector) Collect(ch chan<- prometheus.Metric) {
+	collector.exporter.setMetricsFromResponse(collector.locks, collector.processes, collector.shares, collector.psData, 1, 1, 0, nil, ch)
+	collector.exporter.setDataStaleMetric(0, ch)
+	collector.exporter.setCircuitOpenMetric(ch)
+}
+
+// GetMetricInfos - Get the metrics the exporter exports with its settings, sorted by name. The metrics are taken from sample
+// smbstatus tables instead of samba_statusd, so this works without a running samba server
+func (smbExporter *SambaExporter) GetMetricInfos() ([]MetricInfo, error) {
+	infos := make(map[string]*MetricInfo)
+	for _, tables := range sampleTables {
+		families, errGather := smbExporter.gatherSample(tables)
+		if errGather != nil {
+			return nil, errGather
+		}
+
+		for _, family := range families {
+			info, found := infos[family.GetName()]
+			if !found {
+				info = &MetricInfo{
+					Name:      family.GetName(),
+					Type:      strings.ToLower(family.GetType().String()),
+					Help:      family.GetHelp(),
+					Collector: statisticsGenerator.GetCollectorOfMetric(strings.TrimPrefix(family.GetName(), EXPORTER_LABEL_PREFIX+"_")),
+				}
+				infos[family.GetName()] = info
+			}
+			info.Labels = addLabelNames(info.Labels, family)
+		}
+	}
+
+	ret := []MetricInfo{}
+	for _, info := range infos {
+		ret = append(ret, *info)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+
+	return ret, nil
+}
+
+// gatherSample - Get the metrics a copy of the exporter exports for the lock, share and process table
+func (smbExporter *SambaExporter) gatherSample(tables [3]string) ([]*dto.MetricFamily, error) {
+	sample := *smbExporter
+	sample.descriptions = make(map[string]prometheus.Desc)
+	sample.metricsLabelList = make(map[string][]string)
+	// The sample values must not change the values of the counters kept
+	sample.CounterState = nil
+
+	registry := prometheus.NewRegistry()
+	errRegister := registry.Register(newSampleCollector(&sample, tables))
+	if errRegister != nil {
+		return nil, errRegister
+	}
+
+	return registry.Gather()
+}
+
+// addLabelNames - Add the names of the labels of the metrics in the family to the names, keeping them sorted
+func addLabelNames(names []string, family *dto.MetricFamily) []string {
+	found := make(map[string]bool)
+	for _, name := range names {
+		found[name] = true
+	}
+	ret := append([]string{}, names...)
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if !found[label.GetName()] {
+				found[label.GetName()] = true
+				ret = append(ret, label.GetName())
+			}
+		}
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// setSampleDescriptions - Set the descriptions of all metrics the exporter exports with its settings from the sample
+// smbstatus tables, so metrics can be collected without describing them with the response of samba_statusd
+func (smbExporter *SambaExporter) setSampleDescriptions() {
+	ch := make(chan *prometheus.Desc)
+	done := make(chan bool)
+	go func() {
+		for range ch {
+		}
+		done <- true
+	}()
+
+	for _, tables := range sampleTables {
+		newSampleCollector(smbExporter, tables).Describe(ch)
+	}
+	close(ch)
+	<-done
+}
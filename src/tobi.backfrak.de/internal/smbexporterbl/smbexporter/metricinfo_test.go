@@ -0,0 +1,73 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// getMetricInfoMap - Get the infos of the exporter by the metric name
+func getMetricInfoMap(t *testing.T, exporter *SambaExporter) map[string]MetricInfo {
+	infos, err := exporter.GetMetricInfos()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	ret := make(map[string]MetricInfo)
+	for _, info := range infos {
+		ret[info.Name] = info
+	}
+
+	return ret
+}
+
+func TestGetMetricInfos(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+
+	infos := getMetricInfoMap(t, exporter)
+	expected := []string{"samba_server_up", "samba_statusd_up", "samba_share_count", "samba_client_connected_at", "samba_locks_per_share_count",
+		"samba_process_per_client_count", "samba_smbd_cpu_usage_percentage", "samba_cluster_node_count", "samba_shares_per_node_count"}
+	for _, name := range expected {
+		if _, found := infos[name]; !found {
+			t.Errorf("The metric '%s' is not listed", name)
+		}
+	}
+
+	clientConnected := infos["samba_client_connected_at"]
+	if clientConnected.Type != "gauge" || clientConnected.Collector != statisticsGenerator.COLLECTOR_SHARES || strings.Join(clientConnected.Labels, ",") != "client_host,client_ip" {
+		t.Errorf("The info %v is not expected", clientConnected)
+	}
+	scrapeErrors := infos["samba_scrape_errors_total"]
+	if scrapeErrors.Type != "counter" || scrapeErrors.Collector != "" || len(scrapeErrors.Labels) != 0 || scrapeErrors.Help == "" {
+		t.Errorf("The info %v is not expected", scrapeErrors)
+	}
+	nodeLocks := infos["samba_locks_per_node_count"]
+	if nodeLocks.Collector != statisticsGenerator.COLLECTOR_CTDB || strings.Join(nodeLocks.Labels, ",") != "node" {
+		t.Errorf("The info %v is not expected", nodeLocks)
+	}
+}
+
+func TestGetMetricInfosWithSettings(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	settings := getNewStatisticGenSettings()
+	settings.DoNotExportClient = true
+	settings.DisabledCollectors = []string{statisticsGenerator.COLLECTOR_PSDATA}
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, settings)
+	exporter.ConstLabels = map[string]string{"cluster": "a"}
+
+	infos := getMetricInfoMap(t, exporter)
+	if _, found := infos["samba_smbd_thread_count"]; found {
+		t.Errorf("The metric of the disabled collector psdata is listed")
+	}
+	if labels := strings.Join(infos["samba_lock_created_at"].Labels, ","); labels != "cluster,share,user" {
+		t.Errorf("The labels '%s' are not expected", labels)
+	}
+}
@@ -0,0 +1,295 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// OTLP_SERVICE_NAME - The service.name of the resource the metrics are sent for
+const OTLP_SERVICE_NAME = "samba_exporter"
+
+// The aggregation temporality of the sums and histograms, the exporter only knows the values since it started
+const otlpTemporalityCumulative = 2
+
+// OTLPExporter - Sends the metrics of a registry in an interval to an OpenTelemetry collector,
+// using OTLP over HTTP with the JSON encoding
+type OTLPExporter struct {
+	endpoint string
+	interval time.Duration
+	gatherer prometheus.Gatherer
+	logger   commonbl.Logger
+	client   *http.Client
+	version  string
+	started  time.Time
+	// Headers added to each request, e. g. for the authentication at the collector
+	Headers map[string]string
+}
+
+// The types of the OTLP JSON encoding used by the OTLPExporter, int64 and uint64 values are encoded as strings
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+	Summary     *otlpSummary   `json:"summary,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          float64         `json:"asDouble"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	BucketCounts      []string        `json:"bucketCounts"`
+	ExplicitBounds    []float64       `json:"explicitBounds"`
+}
+
+type otlpSummary struct {
+	DataPoints []otlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type otlpSummaryDataPoint struct {
+	Attributes        []otlpAttribute     `json:"attributes,omitempty"`
+	StartTimeUnixNano string              `json:"startTimeUnixNano"`
+	TimeUnixNano      string              `json:"timeUnixNano"`
+	Count             string              `json:"count"`
+	Sum               float64             `json:"sum"`
+	QuantileValues    []otlpQuantileValue `json:"quantileValues"`
+}
+
+type otlpQuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+// NewOTLPExporter - Get an OTLPExporter sending the metrics of the gatherer to the endpoint in the interval, e. g. to
+// 'http://collector:4318/v1/metrics'. A push waits at most timeout for the answer of the collector
+func NewOTLPExporter(endpoint string, interval time.Duration, timeout time.Duration, gatherer prometheus.Gatherer, version string, logger commonbl.Logger) (*OTLPExporter, error) {
+	errCheck := checkPushSettings(endpoint, interval)
+	if errCheck != nil {
+		return nil, errCheck
+	}
+
+	return &OTLPExporter{endpoint: endpoint, interval: interval, gatherer: gatherer, logger: logger, client: &http.Client{Timeout: timeout},
+		version: version, started: time.Now(), Headers: make(map[string]string)}, nil
+}
+
+// Run - Send the metrics in the interval. A failed push is logged, the metrics are sent again in the next interval
+func (exporter *OTLPExporter) Run() {
+	runPushLoop(exporter.endpoint, exporter.interval, exporter.Push, exporter.logger)
+}
+
+// Push - Gather the metrics and send them to the collector
+func (exporter *OTLPExporter) Push() error {
+	families, errGather := exporter.gatherer.Gather()
+	if errGather != nil {
+		return errGather
+	}
+
+	metrics := getOTLPMetrics(families, exporter.started, time.Now())
+	body, errMarshal := json.Marshal(otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: []otlpAttribute{getOTLPAttribute("service.name", OTLP_SERVICE_NAME), getOTLPAttribute("service.version", exporter.version)}},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: OTLP_SERVICE_NAME, Version: exporter.version}, Metrics: metrics}},
+	}}})
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	request, errRequest := http.NewRequest(http.MethodPost, exporter.endpoint, bytes.NewReader(body))
+	if errRequest != nil {
+		return errRequest
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range exporter.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, errPost := exporter.client.Do(request)
+	if errPost != nil {
+		return errPost
+	}
+	defer response.Body.Close()
+
+	errResponse := checkPushResponse(response)
+	if errResponse != nil {
+		return errResponse
+	}
+	exporter.logger.WriteVerbose(fmt.Sprintf("Sent %d metrics to '%s'", len(metrics), exporter.endpoint))
+
+	return nil
+}
+
+// getOTLPMetrics - Get the OTLP metrics of the metric families. Counters are sent as monotonic sums, starting when the exporter started
+// or when the counter was created. Gauges and untyped metrics are sent as gauges. NaN values can not be encoded in JSON and are dropped
+func getOTLPMetrics(families []*dto.MetricFamily, started time.Time, now time.Time) []otlpMetric {
+	var ret []otlpMetric
+	for _, family := range families {
+		metric := otlpMetric{Name: family.GetName(), Description: family.GetHelp()}
+		for _, promMetric := range family.GetMetric() {
+			attributes := getOTLPAttributes(promMetric.GetLabel())
+			timestamp := getOTLPTime(now)
+			if promMetric.TimestampMs != nil {
+				timestamp = getOTLPTime(time.UnixMilli(promMetric.GetTimestampMs()))
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				start := started
+				if promMetric.GetCounter().GetCreatedTimestamp() != nil {
+					start = promMetric.GetCounter().GetCreatedTimestamp().AsTime()
+				}
+				if metric.Sum == nil {
+					metric.Sum = &otlpSum{AggregationTemporality: otlpTemporalityCumulative, IsMonotonic: true}
+				}
+				value := promMetric.GetCounter().GetValue()
+				if !math.IsNaN(value) {
+					metric.Sum.DataPoints = append(metric.Sum.DataPoints, otlpNumberDataPoint{attributes, getOTLPTime(start), timestamp, value})
+				}
+			case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+				if metric.Gauge == nil {
+					metric.Gauge = &otlpGauge{}
+				}
+				value := promMetric.GetGauge().GetValue()
+				if family.GetType() == dto.MetricType_UNTYPED {
+					value = promMetric.GetUntyped().GetValue()
+				}
+				if !math.IsNaN(value) {
+					metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, otlpNumberDataPoint{Attributes: attributes, TimeUnixNano: timestamp, AsDouble: value})
+				}
+			case dto.MetricType_HISTOGRAM:
+				if metric.Histogram == nil {
+					metric.Histogram = &otlpHistogram{AggregationTemporality: otlpTemporalityCumulative}
+				}
+				metric.Histogram.DataPoints = append(metric.Histogram.DataPoints, getOTLPHistogramDataPoint(promMetric.GetHistogram(), attributes, getOTLPTime(started), timestamp))
+			case dto.MetricType_SUMMARY:
+				if metric.Summary == nil {
+					metric.Summary = &otlpSummary{}
+				}
+				summary := promMetric.GetSummary()
+				dataPoint := otlpSummaryDataPoint{Attributes: attributes, StartTimeUnixNano: getOTLPTime(started), TimeUnixNano: timestamp,
+					Count: strconv.FormatUint(summary.GetSampleCount(), 10), Sum: summary.GetSampleSum(), QuantileValues: []otlpQuantileValue{}}
+				for _, quantile := range summary.GetQuantile() {
+					if !math.IsNaN(quantile.GetValue()) {
+						dataPoint.QuantileValues = append(dataPoint.QuantileValues, otlpQuantileValue{quantile.GetQuantile(), quantile.GetValue()})
+					}
+				}
+				metric.Summary.DataPoints = append(metric.Summary.DataPoints, dataPoint)
+			}
+		}
+
+		if metric.Sum != nil || metric.Gauge != nil || metric.Histogram != nil || metric.Summary != nil {
+			ret = append(ret, metric)
+		}
+	}
+
+	return ret
+}
+
+// getOTLPHistogramDataPoint - Get the data point of the histogram. Prometheus counts the observations of a bucket cumulative,
+// OTLP counts only the observations of the bucket, and the last count is the one of the +Inf bucket
+func getOTLPHistogramDataPoint(histogram *dto.Histogram, attributes []otlpAttribute, start string, timestamp string) otlpHistogramDataPoint {
+	dataPoint := otlpHistogramDataPoint{Attributes: attributes, StartTimeUnixNano: start, TimeUnixNano: timestamp,
+		Count: strconv.FormatUint(histogram.GetSampleCount(), 10), Sum: histogram.GetSampleSum(), BucketCounts: []string{}, ExplicitBounds: []float64{}}
+
+	var previous uint64
+	for _, bucket := range histogram.GetBucket() {
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+		dataPoint.ExplicitBounds = append(dataPoint.ExplicitBounds, bucket.GetUpperBound())
+		dataPoint.BucketCounts = append(dataPoint.BucketCounts, strconv.FormatUint(bucket.GetCumulativeCount()-previous, 10))
+		previous = bucket.GetCumulativeCount()
+	}
+	dataPoint.BucketCounts = append(dataPoint.BucketCounts, strconv.FormatUint(histogram.GetSampleCount()-previous, 10))
+
+	return dataPoint
+}
+
+// getOTLPAttributes - Get the labels of a metric as OTLP attributes
+func getOTLPAttributes(pairs []*dto.LabelPair) []otlpAttribute {
+	var ret []otlpAttribute
+	for _, pair := range pairs {
+		ret = append(ret, getOTLPAttribute(pair.GetName(), pair.GetValue()))
+	}
+
+	return ret
+}
+
+// getOTLPAttribute - Get an attribute with a string value
+func getOTLPAttribute(key string, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAttributeValue{StringValue: value}}
+}
+
+// getOTLPTime - Get the time in nano seconds since the epoch, as string like the OTLP JSON encoding wants it
+func getOTLPTime(timestamp time.Time) string {
+	return strconv.FormatInt(timestamp.UnixNano(), 10)
+}
@@ -0,0 +1,91 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestOTLPExporterPush(t *testing.T) {
+	var received otlpRequest
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		errDecode := json.NewDecoder(r.Body).Decode(&received)
+		if errDecode != nil {
+			t.Errorf("Can not decode the request: %s", errDecode.Error())
+		}
+	}))
+	defer server.Close()
+
+	exporter, errNew := NewOTLPExporter(server.URL+"/v1/metrics", time.Minute, time.Second, getTestRegistry(), "1.2.3", testhelper.NewTestLogger(true))
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	exporter.Headers["X-Api-Key"] = "secret"
+
+	errPush := exporter.Push()
+	if errPush != nil {
+		t.Fatalf("Got error '%s' but expected none", errPush.Error())
+	}
+
+	if header.Get("Content-Type") != "application/json" || header.Get("X-Api-Key") != "secret" {
+		t.Errorf("The headers %v are not expected", header)
+	}
+	if len(received.ResourceMetrics) != 1 || len(received.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("The request %v is not expected", received)
+	}
+	if received.ResourceMetrics[0].ScopeMetrics[0].Scope.Version != "1.2.3" {
+		t.Errorf("The scope %v is not expected", received.ResourceMetrics[0].ScopeMetrics[0].Scope)
+	}
+
+	metrics := make(map[string]otlpMetric)
+	for _, metric := range received.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		metrics[metric.Name] = metric
+	}
+	counter, found := metrics["samba_test_total"]
+	if !found || counter.Sum == nil || !counter.Sum.IsMonotonic || len(counter.Sum.DataPoints) != 1 {
+		t.Fatalf("The counter %v is not expected", counter)
+	}
+	if counter.Sum.DataPoints[0].AsDouble != 3 || counter.Sum.DataPoints[0].Attributes[0].Value.StringValue != "data" {
+		t.Errorf("The data point %v of the counter is not expected", counter.Sum.DataPoints[0])
+	}
+
+	histogram, found := metrics["samba_test_seconds"]
+	if !found || histogram.Histogram == nil || len(histogram.Histogram.DataPoints) != 1 {
+		t.Fatalf("The histogram %v is not expected", histogram)
+	}
+	dataPoint := histogram.Histogram.DataPoints[0]
+	if dataPoint.Count != "2" || len(dataPoint.ExplicitBounds) != 1 || len(dataPoint.BucketCounts) != 2 || dataPoint.BucketCounts[0] != "1" || dataPoint.BucketCounts[1] != "1" {
+		t.Errorf("The data point %v of the histogram is not expected", dataPoint)
+	}
+}
+
+func TestOTLPExporterPushRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	exporter, _ := NewOTLPExporter(server.URL, time.Minute, time.Second, getTestRegistry(), "1.2.3", testhelper.NewTestLogger(true))
+	errPush := exporter.Push()
+	if errPush == nil {
+		t.Errorf("Got no error, but expected one")
+	}
+}
+
+func TestNewOTLPExporterInvalid(t *testing.T) {
+	_, errNew := NewOTLPExporter("collector:4318", time.Minute, time.Second, getTestRegistry(), "1.2.3", testhelper.NewTestLogger(true))
+	if errNew == nil {
+		t.Errorf("Got no error for an endpoint without scheme, but expected one")
+	}
+}
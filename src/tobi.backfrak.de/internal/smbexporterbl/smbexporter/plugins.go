@@ -0,0 +1,142 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+)
+
+// The prefix of the metrics printed by the plugins of samba_statusd
+const PLUGIN_METRIC_PREFIX = "plugin_"
+
+// The label with the name of the plugin a metric was printed by
+const PLUGIN_LABEL = "plugin"
+
+// pluginFamily - The help, type and label names of a metric of the plugins. A metric name can only be used with one of each
+type pluginFamily struct {
+	help      string
+	valueType prometheus.ValueType
+	labelKeys []string
+}
+
+// setPluginMetrics - Request the results of the plugins of samba_statusd and send their metrics. The metrics are not described
+// when the exporter is registered, since they are only known after the plugins ran
+func (smbExporter *SambaExporter) setPluginMetrics(ctx context.Context, collectors []string, ch chan<- prometheus.Metric) {
+	if !smbExporter.isCollected(PLUGIN_METRIC_PREFIX+"up", collectors) {
+		return
+	}
+	if smbExporter.GrpcClient != nil {
+		smbExporter.Logger.WriteVerbose("The plugins of samba_statusd are not requested using gRPC")
+		return
+	}
+
+	results, errGet := pipecomunication.GetJsonData[commonbl.PluginResult](ctx, smbExporter.RequestHandler, smbExporter.ResponseHander, commonbl.PLUGIN_REQUEST, smbExporter.Logger, smbExporter.getRequestSettings())
+	if errGet != nil {
+		smbExporter.Logger.WriteErrorWithAddition(errGet, "while requesting the plugin metrics")
+		return
+	}
+
+	smbExporter.sendPluginMetrics(results, ch)
+}
+
+// sendPluginMetrics - Send the metrics of the plugin results, together with the state and run time of each plugin. Metrics that
+// do not fit the first metric with the same name, or repeat its labels, are dropped
+func (smbExporter *SambaExporter) sendPluginMetrics(results []commonbl.PluginResult, ch chan<- prometheus.Metric) {
+	families := map[string]*pluginFamily{
+		PLUGIN_METRIC_PREFIX + "up":               {"1 if the plugin of samba_statusd printed its metrics without error", prometheus.GaugeValue, []string{PLUGIN_LABEL}},
+		PLUGIN_METRIC_PREFIX + "duration_seconds": {"Time the plugin of samba_statusd ran in seconds", prometheus.GaugeValue, []string{PLUGIN_LABEL}},
+	}
+	sent := make(map[string]bool)
+	for _, result := range results {
+		up := 1.0
+		if result.Error != "" {
+			up = 0
+		}
+		pluginLabels := map[string]string{PLUGIN_LABEL: result.Plugin}
+		smbExporter.sendPluginMetric(PLUGIN_METRIC_PREFIX+"up", families, up, pluginLabels, sent, ch)
+		smbExporter.sendPluginMetric(PLUGIN_METRIC_PREFIX+"duration_seconds", families, result.DurationSeconds, pluginLabels, sent, ch)
+
+		for _, metric := range result.Metrics {
+			name := PLUGIN_METRIC_PREFIX + metric.Name
+			labels := map[string]string{PLUGIN_LABEL: result.Plugin}
+			for key, value := range metric.Labels {
+				if key == PLUGIN_LABEL {
+					smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("The metric '%s' of the plugin '%s' uses the reserved label '%s', the metric will not be exported", metric.Name, result.Plugin, PLUGIN_LABEL))
+					labels = nil
+					break
+				}
+				labels[key] = value
+			}
+			if labels == nil {
+				continue
+			}
+
+			if _, found := families[name]; !found {
+				valueType := prometheus.GaugeValue
+				if metric.Type == commonbl.PLUGIN_METRIC_COUNTER {
+					valueType = prometheus.CounterValue
+				}
+				families[name] = &pluginFamily{metric.Help, valueType, getSortedLabelKeys(labels)}
+			} else if families[name].help != metric.Help {
+				smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("The metric '%s' of the plugin '%s' has an other help text than the metric with the same name before, the metric will not be exported", metric.Name, result.Plugin))
+				continue
+			}
+			smbExporter.sendPluginMetric(name, families, metric.Value, labels, sent, ch)
+		}
+	}
+}
+
+// sendPluginMetric - Send a metric of the plugins with the family of its name, when it fits the family and was not sent before
+func (smbExporter *SambaExporter) sendPluginMetric(name string, families map[string]*pluginFamily, value float64, labels map[string]string, sent map[string]bool, ch chan<- prometheus.Metric) {
+	family := families[name]
+	if strings.Join(getSortedLabelKeys(labels), ",") != strings.Join(family.labelKeys, ",") {
+		smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("The labels of the metric '%s' of the plugin '%s' differ from the metric with the same name before, the metric will not be exported", name, labels[PLUGIN_LABEL]))
+		return
+	}
+	for key := range labels {
+		if _, isConst := smbExporter.ConstLabels[key]; isConst {
+			smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("The constant label '%s' is also a label of metric '%s', the metric will not be exported", key, name))
+			return
+		}
+	}
+
+	labelValues := make([]string, len(family.labelKeys))
+	for i, key := range family.labelKeys {
+		labelValues[i] = labels[key]
+	}
+	id := name + "\xff" + strings.Join(labelValues, "\xff")
+	if sent[id] {
+		smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("The metric '%s' of the plugin '%s' was already printed with the same labels, the metric will not be exported", name, labels[PLUGIN_LABEL]))
+		return
+	}
+	sent[id] = true
+
+	desc := prometheus.NewDesc(prometheus.BuildFQName(EXPORTER_LABEL_PREFIX, "", name), family.help, family.labelKeys, smbExporter.ConstLabels)
+	metric, errMetric := prometheus.NewConstMetric(desc, family.valueType, value, labelValues...)
+	if errMetric != nil {
+		smbExporter.Logger.WriteErrorWithAddition(errMetric, fmt.Sprintf("while exporting the metric '%s'", name))
+		return
+	}
+	ch <- metric
+}
+
+// getSortedLabelKeys - Get the names of the labels sorted
+func getSortedLabelKeys(labels map[string]string) []string {
+	ret := []string{}
+	for key := range labels {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
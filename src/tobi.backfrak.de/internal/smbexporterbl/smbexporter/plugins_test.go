@@ -0,0 +1,132 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// getTestPluginResults - Get plugin results with valid metrics, a failed plugin and metrics that can not be exported
+func getTestPluginResults() []commonbl.PluginResult {
+	return []commonbl.PluginResult{
+		{Plugin: "quota", DurationSeconds: 0.5, Metrics: []commonbl.PluginMetric{
+			{Name: "quota_used_bytes", Help: "Bytes used", Type: commonbl.PLUGIN_METRIC_GAUGE, Labels: map[string]string{"share": "data"}, Value: 42},
+			{Name: "quota_used_bytes", Help: "Bytes used", Type: commonbl.PLUGIN_METRIC_GAUGE, Labels: map[string]string{"share": "foto"}, Value: 7},
+			{Name: "quota_checks_total", Help: "Quota checks", Type: commonbl.PLUGIN_METRIC_COUNTER, Value: 3},
+			// Dropped, the labels are already used
+			{Name: "quota_used_bytes", Help: "Bytes used", Type: commonbl.PLUGIN_METRIC_GAUGE, Labels: map[string]string{"share": "data"}, Value: 1},
+			// Dropped, the label names differ
+			{Name: "quota_used_bytes", Help: "Bytes used", Type: commonbl.PLUGIN_METRIC_GAUGE, Labels: map[string]string{"path": "/srv"}, Value: 1},
+			// Dropped, the plugin label is reserved
+			{Name: "reserved", Help: "Reserved label", Type: commonbl.PLUGIN_METRIC_GAUGE, Labels: map[string]string{PLUGIN_LABEL: "x"}, Value: 1},
+		}},
+		{Plugin: "backup", DurationSeconds: 3, Error: "the plugin did not finish within 3s", Metrics: []commonbl.PluginMetric{}},
+		{Plugin: "other_quota", DurationSeconds: 0.1, Metrics: []commonbl.PluginMetric{
+			// Dropped, the help differs
+			{Name: "quota_checks_total", Help: "Other help", Type: commonbl.PLUGIN_METRIC_COUNTER, Value: 1},
+		}},
+	}
+}
+
+// getSentPluginMetrics - Get the metrics sent for the plugin results by name and the label values joined with ','
+func getSentPluginMetrics(t *testing.T, exporter *SambaExporter, results []commonbl.PluginResult) map[string]*dto.Metric {
+	ch := make(chan prometheus.Metric, 100)
+	exporter.sendPluginMetrics(results, ch)
+	close(ch)
+
+	ret := make(map[string]*dto.Metric)
+	for metric := range ch {
+		var written dto.Metric
+		errWrite := metric.Write(&written)
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		name := strings.Split(strings.Split(metric.Desc().String(), "fqName: \"")[1], "\"")[0]
+		var values []string
+		for _, label := range written.GetLabel() {
+			values = append(values, label.GetValue())
+		}
+		ret[name+"{"+strings.Join(values, ",")+"}"] = &written
+	}
+
+	return ret
+}
+
+func TestSendPluginMetrics(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+
+	metrics := getSentPluginMetrics(t, exporter, getTestPluginResults())
+	expected := map[string]float64{
+		"samba_plugin_up{quota}":                     1,
+		"samba_plugin_up{backup}":                    0,
+		"samba_plugin_up{other_quota}":               1,
+		"samba_plugin_duration_seconds{backup}":      3,
+		"samba_plugin_quota_used_bytes{quota,data}":  42,
+		"samba_plugin_quota_used_bytes{quota,foto}":  7,
+		"samba_plugin_quota_checks_total{quota}":     3,
+		"samba_plugin_duration_seconds{quota}":       0.5,
+		"samba_plugin_duration_seconds{other_quota}": 0.1,
+	}
+	if len(metrics) != len(expected) {
+		t.Errorf("Got %d metrics but expected %d", len(metrics), len(expected))
+	}
+	for name, value := range expected {
+		metric, found := metrics[name]
+		if !found {
+			t.Errorf("The metric '%s' was not sent", name)
+			continue
+		}
+		got := metric.GetGauge().GetValue()
+		if metric.GetCounter() != nil {
+			got = metric.GetCounter().GetValue()
+		}
+		if got != value {
+			t.Errorf("Got %f for the metric '%s' but expected %f", got, name, value)
+		}
+	}
+	if metrics["samba_plugin_quota_checks_total{quota}"].GetCounter() == nil {
+		t.Errorf("The metric 'samba_plugin_quota_checks_total' is not a counter")
+	}
+
+	if logger.GetErrorCount() != 4 {
+		t.Errorf("Got %d errors but expected 4 for the dropped metrics", logger.GetErrorCount())
+	}
+}
+
+func TestSendPluginMetricsConstLabels(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.ConstLabels = map[string]string{"share": "all"}
+
+	metrics := getSentPluginMetrics(t, exporter, getTestPluginResults()[:1])
+	if _, found := metrics["samba_plugin_quota_used_bytes{quota,data}"]; found {
+		t.Errorf("The metric with the constant label as label was sent")
+	}
+	if _, found := metrics["samba_plugin_quota_checks_total{quota,all}"]; !found {
+		t.Errorf("The metric without the constant label was not sent")
+	}
+}
+
+func TestSetPluginMetricsDisabled(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	settings := getNewStatisticGenSettings()
+	settings.DisabledCollectors = []string{"plugins"}
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, settings)
+
+	ch := make(chan prometheus.Metric, 10)
+	exporter.setPluginMetrics(context.Background(), nil, ch)
+	if len(ch) != 0 || logger.GetErrorCount() != 0 {
+		t.Errorf("Got %d metrics and %d errors, but the plugins collector is disabled", len(ch), logger.GetErrorCount())
+	}
+}
@@ -0,0 +1,246 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// REMOTE_WRITE_VERSION - The version of the Prometheus remote write protocol used to push the metrics
+const REMOTE_WRITE_VERSION = "0.1.0"
+
+// RemoteWriter - Pushes the metrics of a registry in an interval to a Prometheus remote write endpoint,
+// e. g. of Mimir, Thanos or VictoriaMetrics, so no Prometheus is needed to scrape the exporter
+type RemoteWriter struct {
+	endpoint string
+	interval time.Duration
+	gatherer prometheus.Gatherer
+	logger   commonbl.Logger
+	client   *http.Client
+	// The user and password for the basic authentication, used when the Username is set
+	Username string
+	Password string
+	// The token sent as bearer token in the Authorization header, used when set
+	BearerToken string
+}
+
+// remoteWriteLabel - A label of a series pushed to the remote write endpoint
+type remoteWriteLabel struct {
+	name  string
+	value string
+}
+
+// remoteWriteSeries - A series with one sample pushed to the remote write endpoint
+type remoteWriteSeries struct {
+	labels    []remoteWriteLabel
+	value     float64
+	timestamp int64
+}
+
+// NewRemoteWriter - Get a RemoteWriter pushing the metrics of the gatherer to the endpoint in the interval.
+// A push waits at most timeout for the answer of the endpoint
+func NewRemoteWriter(endpoint string, interval time.Duration, timeout time.Duration, gatherer prometheus.Gatherer, logger commonbl.Logger) (*RemoteWriter, error) {
+	errCheck := checkPushSettings(endpoint, interval)
+	if errCheck != nil {
+		return nil, errCheck
+	}
+
+	return &RemoteWriter{endpoint: endpoint, interval: interval, gatherer: gatherer, logger: logger, client: &http.Client{Timeout: timeout}}, nil
+}
+
+// Run - Push the metrics in the interval. A failed push is logged, the metrics are pushed again in the next interval
+func (writer *RemoteWriter) Run() {
+	runPushLoop(writer.endpoint, writer.interval, writer.Push, writer.logger)
+}
+
+// Push - Gather the metrics and push them to the remote write endpoint
+func (writer *RemoteWriter) Push() error {
+	families, errGather := writer.gatherer.Gather()
+	if errGather != nil {
+		return errGather
+	}
+
+	series := getRemoteWriteSeries(families, time.Now())
+	request, errRequest := http.NewRequest(http.MethodPost, writer.endpoint, bytes.NewReader(snappy.Encode(nil, encodeWriteRequest(series))))
+	if errRequest != nil {
+		return errRequest
+	}
+	request.Header.Set("Content-Encoding", "snappy")
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	request.Header.Set("X-Prometheus-Remote-Write-Version", REMOTE_WRITE_VERSION)
+	if writer.BearerToken != "" {
+		request.Header.Set("Authorization", "Bearer "+writer.BearerToken)
+	} else if writer.Username != "" {
+		request.SetBasicAuth(writer.Username, writer.Password)
+	}
+
+	response, errPost := writer.client.Do(request)
+	if errPost != nil {
+		return errPost
+	}
+	defer response.Body.Close()
+
+	errResponse := checkPushResponse(response)
+	if errResponse != nil {
+		return errResponse
+	}
+	writer.logger.WriteVerbose(fmt.Sprintf("Pushed %d series to '%s'", len(series), writer.endpoint))
+
+	return nil
+}
+
+// checkPushSettings - Get an error, when the endpoint is no http or https URL, or the interval is not positive
+func checkPushSettings(endpoint string, interval time.Duration) error {
+	endpointURL, errParse := url.Parse(endpoint)
+	if errParse != nil {
+		return fmt.Errorf("the endpoint '%s' is not a valid URL: %s", endpoint, errParse.Error())
+	}
+	if (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") || endpointURL.Host == "" {
+		return fmt.Errorf("the endpoint '%s' is not a http or https URL", endpoint)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("the push interval '%s' is not positive", interval)
+	}
+
+	return nil
+}
+
+// runPushLoop - Call push in the interval. A failed push is logged, the metrics are pushed again in the next interval
+func runPushLoop(endpoint string, interval time.Duration, push func() error, logger commonbl.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		errPush := push()
+		if errPush != nil {
+			logger.WriteErrorWithAddition(errPush, fmt.Sprintf("while pushing the metrics to '%s'", endpoint))
+		}
+	}
+}
+
+// checkPushResponse - Get an error containing the start of the body, when the endpoint did not accept the pushed metrics
+func checkPushResponse(response *http.Response) error {
+	if response.StatusCode >= 200 && response.StatusCode <= 299 {
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+
+	return fmt.Errorf("the endpoint answered '%s': %s", response.Status, strings.TrimSpace(string(message)))
+}
+
+// getRemoteWriteSeries - Get the series of the metric families. Summaries and histograms are split in the series
+// a scrape would give, like '_sum', '_count' and '_bucket'. Metrics without time stamp get the time stamp now
+func getRemoteWriteSeries(families []*dto.MetricFamily, now time.Time) []remoteWriteSeries {
+	var ret []remoteWriteSeries
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			timestamp := now.UnixMilli()
+			if metric.TimestampMs != nil {
+				timestamp = metric.GetTimestampMs()
+			}
+			newSeries := func(name string, value float64, extraLabels ...remoteWriteLabel) remoteWriteSeries {
+				return remoteWriteSeries{labels: getRemoteWriteLabels(name, metric.GetLabel(), extraLabels), value: value, timestamp: timestamp}
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				ret = append(ret, newSeries(name, metric.GetCounter().GetValue()))
+			case dto.MetricType_GAUGE:
+				ret = append(ret, newSeries(name, metric.GetGauge().GetValue()))
+			case dto.MetricType_UNTYPED:
+				ret = append(ret, newSeries(name, metric.GetUntyped().GetValue()))
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				for _, quantile := range summary.GetQuantile() {
+					ret = append(ret, newSeries(name, quantile.GetValue(), remoteWriteLabel{"quantile", formatFloat(quantile.GetQuantile())}))
+				}
+				ret = append(ret, newSeries(name+"_sum", summary.GetSampleSum()))
+				ret = append(ret, newSeries(name+"_count", float64(summary.GetSampleCount())))
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				infSeen := false
+				for _, bucket := range histogram.GetBucket() {
+					infSeen = infSeen || math.IsInf(bucket.GetUpperBound(), 1)
+					ret = append(ret, newSeries(name+"_bucket", float64(bucket.GetCumulativeCount()), remoteWriteLabel{"le", formatFloat(bucket.GetUpperBound())}))
+				}
+				if !infSeen {
+					ret = append(ret, newSeries(name+"_bucket", float64(histogram.GetSampleCount()), remoteWriteLabel{"le", "+Inf"}))
+				}
+				ret = append(ret, newSeries(name+"_sum", histogram.GetSampleSum()))
+				ret = append(ret, newSeries(name+"_count", float64(histogram.GetSampleCount())))
+			}
+		}
+	}
+
+	return ret
+}
+
+// getRemoteWriteLabels - Get the labels of a series with the name, sorted by the label name as the remote write protocol requires
+func getRemoteWriteLabels(name string, pairs []*dto.LabelPair, extraLabels []remoteWriteLabel) []remoteWriteLabel {
+	labels := []remoteWriteLabel{{"__name__", name}}
+	for _, pair := range pairs {
+		labels = append(labels, remoteWriteLabel{pair.GetName(), pair.GetValue()})
+	}
+	labels = append(labels, extraLabels...)
+	sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+	return labels
+}
+
+// formatFloat - Format the value of a 'le' or 'quantile' label like Prometheus does
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// encodeWriteRequest - Get the protobuf encoded WriteRequest of the remote write protocol for the series
+func encodeWriteRequest(series []remoteWriteSeries) []byte {
+	var request []byte
+	for _, oneSeries := range series {
+		var timeSeries []byte
+		for _, label := range oneSeries.labels {
+			var encodedLabel []byte
+			encodedLabel = protowire.AppendTag(encodedLabel, 1, protowire.BytesType)
+			encodedLabel = protowire.AppendString(encodedLabel, label.name)
+			encodedLabel = protowire.AppendTag(encodedLabel, 2, protowire.BytesType)
+			encodedLabel = protowire.AppendString(encodedLabel, label.value)
+			timeSeries = protowire.AppendTag(timeSeries, 1, protowire.BytesType)
+			timeSeries = protowire.AppendBytes(timeSeries, encodedLabel)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(oneSeries.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(oneSeries.timestamp))
+		timeSeries = protowire.AppendTag(timeSeries, 2, protowire.BytesType)
+		timeSeries = protowire.AppendBytes(timeSeries, sample)
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, timeSeries)
+	}
+
+	return request
+}
@@ -0,0 +1,180 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// decodeWriteRequest - Decode the series of a WriteRequest, the labels are joined like 'name=value,name=value'
+func decodeWriteRequest(t *testing.T, data []byte) map[string]float64 {
+	ret := make(map[string]float64)
+	forEachField(t, data, func(_ protowire.Number, timeSeries []byte) {
+		var labels []string
+		var value float64
+		forEachField(t, timeSeries, func(number protowire.Number, field []byte) {
+			if number == 1 {
+				var pair []string
+				forEachField(t, field, func(_ protowire.Number, text []byte) { pair = append(pair, string(text)) })
+				labels = append(labels, strings.Join(pair, "="))
+			} else {
+				bits, length := protowire.ConsumeFixed64(field[1:])
+				if length < 0 {
+					t.Fatalf("Can not decode the value of a sample")
+				}
+				value = math.Float64frombits(bits)
+			}
+		})
+		ret[strings.Join(labels, ",")] = value
+	})
+
+	return ret
+}
+
+// forEachField - Call the function with the content of each length delimited field of the message.
+// Other fields are given with their tag, so the sample can be decoded
+func forEachField(t *testing.T, data []byte, fn func(protowire.Number, []byte)) {
+	for len(data) > 0 {
+		number, wireType, tagLength := protowire.ConsumeTag(data)
+		if tagLength < 0 {
+			t.Fatalf("Can not decode the tag of a field")
+		}
+		if wireType != protowire.BytesType {
+			fn(number, data)
+			return
+		}
+		value, valueLength := protowire.ConsumeBytes(data[tagLength:])
+		if valueLength < 0 {
+			t.Fatalf("Can not decode the field %d", number)
+		}
+		fn(number, value)
+		data = data[tagLength+valueLength:]
+	}
+}
+
+func getTestRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "samba_test_total", Help: "A test counter"}, []string{"share"})
+	counter.WithLabelValues("data").Add(3)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "samba_test_seconds", Help: "A test histogram", Buckets: []float64{0.5}})
+	histogram.Observe(0.25)
+	histogram.Observe(2)
+	registry.MustRegister(counter, histogram)
+
+	return registry
+}
+
+func TestRemoteWriterPush(t *testing.T) {
+	var received map[string]float64
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ := io.ReadAll(r.Body)
+		data, errDecode := snappy.Decode(nil, body)
+		if errDecode != nil {
+			t.Errorf("Can not decode the snappy body: %s", errDecode.Error())
+		}
+		received = decodeWriteRequest(t, data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	writer, errNew := NewRemoteWriter(server.URL+"/api/v1/push", time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	writer.Username = "exporter"
+	writer.Password = "secret"
+
+	errPush := writer.Push()
+	if errPush != nil {
+		t.Fatalf("Got error '%s' but expected none", errPush.Error())
+	}
+
+	if header.Get("Content-Encoding") != "snappy" || header.Get("X-Prometheus-Remote-Write-Version") != REMOTE_WRITE_VERSION {
+		t.Errorf("The headers %v are not expected", header)
+	}
+	if user, password, ok := (&http.Request{Header: header}).BasicAuth(); !ok || user != "exporter" || password != "secret" {
+		t.Errorf("The request has not the expected basic authentication")
+	}
+
+	expected := map[string]float64{
+		"__name__=samba_test_total,share=data":       3,
+		"__name__=samba_test_seconds_bucket,le=0.5":  1,
+		"__name__=samba_test_seconds_bucket,le=+Inf": 2,
+		"__name__=samba_test_seconds_sum":            2.25,
+		"__name__=samba_test_seconds_count":          2,
+	}
+	if len(received) != len(expected) {
+		t.Errorf("Got %d series, but expected %d: %v", len(received), len(expected), received)
+	}
+	for series, value := range expected {
+		if received[series] != value {
+			t.Errorf("Got the value %f for '%s', but expected %f", received[series], series, value)
+		}
+	}
+}
+
+func TestRemoteWriterPushBearerToken(t *testing.T) {
+	authorization := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	writer, _ := NewRemoteWriter(server.URL, time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	writer.BearerToken = "token"
+	errPush := writer.Push()
+	if errPush != nil {
+		t.Fatalf("Got error '%s' but expected none", errPush.Error())
+	}
+
+	if authorization != "Bearer token" {
+		t.Errorf("The Authorization header '%s' is not expected", authorization)
+	}
+}
+
+func TestRemoteWriterPushRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	writer, _ := NewRemoteWriter(server.URL, time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	errPush := writer.Push()
+	if errPush == nil {
+		t.Fatalf("Got no error, but expected one")
+	}
+
+	if !strings.Contains(errPush.Error(), "400") || !strings.Contains(errPush.Error(), "out of order sample") {
+		t.Errorf("The error '%s' is not expected", errPush.Error())
+	}
+}
+
+func TestNewRemoteWriterInvalid(t *testing.T) {
+	for _, endpoint := range []string{"", "mimir:9009", "ftp://mimir/push", "http://%zz"} {
+		_, errNew := NewRemoteWriter(endpoint, time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+		if errNew == nil {
+			t.Errorf("Got no error for the endpoint '%s', but expected one", endpoint)
+		}
+	}
+
+	_, errNew := NewRemoteWriter("http://mimir:9009/api/v1/push", 0, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew == nil {
+		t.Errorf("Got no error for the interval 0, but expected one")
+	}
+}
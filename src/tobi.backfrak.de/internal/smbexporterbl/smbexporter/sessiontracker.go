@@ -0,0 +1,64 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"sync"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// sessionTracker - Remembers the sessions seen by the last scrape, to count the sessions connected and disconnected since
+type sessionTracker struct {
+	mutex       sync.Mutex
+	sessions    map[string]bool
+	connects    int
+	disconnects int
+}
+
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{}
+}
+
+// update - Count the sessions not seen by the last scrape as connected, and the sessions missing now as disconnected.
+// The sessions seen by the first scrape are only remembered, since they connected before the exporter started
+func (tracker *sessionTracker) update(shares []smbstatusreader.ShareData) {
+	current := make(map[string]bool)
+	for _, share := range shares {
+		current[getSessionKey(share)] = true
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if tracker.sessions != nil {
+		for session := range current {
+			if !tracker.sessions[session] {
+				tracker.connects++
+			}
+		}
+		for session := range tracker.sessions {
+			if !current[session] {
+				tracker.disconnects++
+			}
+		}
+	}
+	tracker.sessions = current
+}
+
+// getCounts - Get the number of sessions connected and disconnected since the exporter started
+func (tracker *sessionTracker) getCounts() (int, int) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	return tracker.connects, tracker.disconnects
+}
+
+// getSessionKey - Get the identity of a session, the smbd process serving the share to the machine
+func getSessionKey(share smbstatusreader.ShareData) string {
+	return fmt.Sprintf("%d/%d/%s/%s", share.ClusterNodeId, share.PID, share.Machine, share.Service)
+}
@@ -0,0 +1,40 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+func TestSessionTracker(t *testing.T) {
+	tracker := newSessionTracker()
+	first := smbstatusreader.ShareData{Service: "IPC$", PID: 1120, Machine: "192.168.1.242"}
+	second := smbstatusreader.ShareData{Service: "share", PID: 1120, Machine: "192.168.1.242"}
+	third := smbstatusreader.ShareData{Service: "share", PID: 1121, Machine: "192.168.1.243"}
+
+	// The sessions of the first scrape connected before the exporter started
+	tracker.update([]smbstatusreader.ShareData{first, second})
+	if connects, disconnects := tracker.getCounts(); connects != 0 || disconnects != 0 {
+		t.Errorf("Got '%d' connects and '%d' disconnects after the first scrape, but expected none", connects, disconnects)
+	}
+
+	tracker.update([]smbstatusreader.ShareData{first, third})
+	if connects, disconnects := tracker.getCounts(); connects != 1 || disconnects != 1 {
+		t.Errorf("Got '%d' connects and '%d' disconnects, but expected '1' and '1'", connects, disconnects)
+	}
+
+	tracker.update([]smbstatusreader.ShareData{})
+	if connects, disconnects := tracker.getCounts(); connects != 1 || disconnects != 3 {
+		t.Errorf("Got '%d' connects and '%d' disconnects, but expected '1' and '3'", connects, disconnects)
+	}
+
+	tracker.update([]smbstatusreader.ShareData{first, second})
+	if connects, disconnects := tracker.getCounts(); connects != 3 || disconnects != 3 {
+		t.Errorf("Got '%d' connects and '%d' disconnects, but expected '3' and '3'", connects, disconnects)
+	}
+}
@@ -0,0 +1,124 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+)
+
+// The time the configured shares are kept, before samba_statusd is asked again. Calling testparm on every scrape is not needed,
+// since the smb.conf rarely changes
+const shareConfigRefreshInterval = 5 * time.Minute
+
+// shareConfigCache - The shares configured in the smb.conf, as listed by samba_statusd
+type shareConfigCache struct {
+	mutex    sync.Mutex
+	configs  []commonbl.ShareConfig
+	received time.Time
+}
+
+// set - Keep the configured shares
+func (cache *shareConfigCache) set(configs []commonbl.ShareConfig) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.configs = configs
+	cache.received = time.Now()
+}
+
+// get - Get the kept configured shares
+func (cache *shareConfigCache) get() []commonbl.ShareConfig {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.configs
+}
+
+// isOutdated - Tell if the configured shares were never received or are older than the shareConfigRefreshInterval
+func (cache *shareConfigCache) isOutdated() bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.received.IsZero() || time.Since(cache.received) > shareConfigRefreshInterval
+}
+
+// getShareConfigs - Get the configured shares, ask samba_statusd for them when the kept ones are outdated. On errors, the kept shares are used further
+func (smbExporter *SambaExporter) getShareConfigs(ctx context.Context) []commonbl.ShareConfig {
+	if !smbExporter.shareConfigs.isOutdated() {
+		return smbExporter.shareConfigs.get()
+	}
+
+	configs, errGet := requestList(ctx, smbExporter, commonbl.SHARE_CONFIG_REQUEST, pipecomunication.GetShareConfigGrpc)
+	if errGet != nil {
+		smbExporter.Logger.WriteErrorWithAddition(errGet, "while requesting the configured shares")
+		return smbExporter.shareConfigs.get()
+	}
+	if len(configs) == 0 {
+		// samba_statusd could not call testparm and logged the reason, or no share is configured. Ask again with the next scrape
+		smbExporter.Logger.WriteVerbose("samba_statusd found no configured shares")
+		return smbExporter.shareConfigs.get()
+	}
+
+	smbExporter.shareConfigs.set(configs)
+
+	return configs
+}
+
+// setShareConfigMetrics - Request the shares configured in the smb.conf and send how many of them have connections. Like the metrics of the
+// cgroups, the metrics are not described when the exporter is registered, since samba_statusd needs testparm to read the smb.conf
+func (smbExporter *SambaExporter) setShareConfigMetrics(ctx context.Context, collectors []string, shares []smbstatusreader.ShareData, ch chan<- prometheus.Metric) {
+	if !smbExporter.isCollected("configured_share_count", collectors) {
+		return
+	}
+
+	configs := smbExporter.getShareConfigs(ctx)
+	if len(configs) == 0 {
+		return
+	}
+
+	smbExporter.sendUndescribedStatistics(statisticsGenerator.GetShareConfigStatistics(configs, shares, smbExporter.StatisticsGeneratorSettings), ch)
+}
+
+// sendUndescribedStatistics - Send statistics whose metrics were not described when the exporter was registered
+func (smbExporter *SambaExporter) sendUndescribedStatistics(stats []statisticsGenerator.SmbStatisticsNumeric, ch chan<- prometheus.Metric) {
+	for _, stat := range stats {
+		labelKeys := getSortedLabelKeys(stat.Labels)
+		labelValues := make([]string, len(labelKeys))
+		conflicting := false
+		for i, key := range labelKeys {
+			if _, isConst := smbExporter.ConstLabels[key]; isConst {
+				smbExporter.Logger.WriteErrorMessage(fmt.Sprintf("The constant label '%s' is also a label of metric '%s', the metric will not be exported", key, stat.Name))
+				conflicting = true
+				break
+			}
+			labelValues[i] = stat.Labels[key]
+		}
+		if conflicting {
+			continue
+		}
+
+		valueType := prometheus.GaugeValue
+		if statisticsGenerator.IsCounter(stat.Name) {
+			valueType = prometheus.CounterValue
+		}
+		desc := prometheus.NewDesc(prometheus.BuildFQName(EXPORTER_LABEL_PREFIX, "", stat.Name), stat.Help, labelKeys, smbExporter.ConstLabels)
+		metric, errMetric := prometheus.NewConstMetric(desc, valueType, stat.Value, labelValues...)
+		if errMetric != nil {
+			smbExporter.Logger.WriteErrorWithAddition(errMetric, fmt.Sprintf("while exporting the metric '%s'", stat.Name))
+			continue
+		}
+		ch <- metric
+	}
+}
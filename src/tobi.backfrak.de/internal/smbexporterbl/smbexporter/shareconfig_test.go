@@ -0,0 +1,105 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// getSentGaugeValues - Get the values of the gauges sent to the channel by their name
+func getSentGaugeValues(t *testing.T, ch chan prometheus.Metric) map[string]float64 {
+	values := map[string]float64{}
+	for len(ch) > 0 {
+		metric := <-ch
+		var data dto.Metric
+		errWrite := metric.Write(&data)
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		desc := metric.Desc().String()
+		values[desc[strings.Index(desc, "\"")+1:strings.Index(desc, "\", help")]] = data.GetGauge().GetValue()
+	}
+
+	return values
+}
+
+func TestSetShareConfigMetrics(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	client := startStatusTestStatusd(t, commonbl.TestLockResponse)
+	exporter := NewSambaExporter(client, client, logger, "0.0.0", 5, getNewStatisticGenSettings())
+
+	ch := make(chan prometheus.Metric, 10)
+	exporter.setShareConfigMetrics(context.Background(), nil, []smbstatusreader.ShareData{{Service: "data", PID: 1117}}, ch)
+
+	values := getSentGaugeValues(t, ch)
+	// The test config has the shares IPC$ and data
+	if values["samba_configured_share_count"] != 2 || values["samba_active_share_count"] != 1 || values["samba_unused_share_count"] != 1 {
+		t.Errorf("Got the values '%v', but expected 2 configured, 1 active and 1 unused share", values)
+	}
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestSetShareConfigMetricsDisabled(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	settings := getNewStatisticGenSettings()
+	settings.DisabledCollectors = []string{statisticsGenerator.COLLECTOR_CONFIG}
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, settings)
+
+	ch := make(chan prometheus.Metric, 10)
+	exporter.setShareConfigMetrics(context.Background(), nil, nil, ch)
+	if len(ch) != 0 || logger.GetErrorCount() != 0 {
+		t.Errorf("Got %d metrics and %d errors, but the config collector is disabled", len(ch), logger.GetErrorCount())
+	}
+}
+
+func TestSendUndescribedStatisticsConstLabel(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	exporter.ConstLabels = prometheus.Labels{"share": "a"}
+	stats := []statisticsGenerator.SmbStatisticsNumeric{{Name: "configured_share_count", Value: 3, Help: "Help"},
+		{Name: "share_test_info", Value: 1, Help: "Help", Labels: map[string]string{"share": "data"}}}
+
+	ch := make(chan prometheus.Metric, 10)
+	exporter.sendUndescribedStatistics(stats, ch)
+	if len(ch) != 1 || logger.GetErrorCount() != 1 {
+		t.Errorf("Got %d metrics and %d errors, but expected one metric and one error, since the share label is a constant label", len(ch), logger.GetErrorCount())
+	}
+}
+
+func TestShareConfigCache(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, getNewStatisticGenSettings())
+	if !exporter.shareConfigs.isOutdated() {
+		t.Errorf("The share config cache is not outdated, but nothing was received")
+	}
+
+	exporter.shareConfigs.set(commonbl.GetTestShareConfig())
+	if exporter.shareConfigs.isOutdated() {
+		t.Errorf("The share config cache is outdated, but the shares were just received")
+	}
+	// samba_statusd is not asked, while the kept shares are not outdated
+	configs := exporter.getShareConfigs(context.Background())
+	if len(configs) != len(commonbl.GetTestShareConfig()) || logger.GetErrorCount() != 0 {
+		t.Errorf("Got %d shares and %d errors, but expected the %d kept shares", len(configs), logger.GetErrorCount(), len(commonbl.GetTestShareConfig()))
+	}
+
+	exporter.shareConfigs.received = time.Now().Add(-shareConfigRefreshInterval - time.Second)
+	if !exporter.shareConfigs.isOutdated() {
+		t.Errorf("The share config cache is not outdated, but the shares are older than the refresh interval")
+	}
+}
@@ -0,0 +1,31 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+)
+
+// setShareHandlesMetrics - Request the open, durable and persistent file handles of the shares and send their counts. Like the metrics of the
+// configured shares, the metrics are not described when the exporter is registered, since samba_statusd needs a smbstatus printing JSON
+func (smbExporter *SambaExporter) setShareHandlesMetrics(ctx context.Context, collectors []string, ch chan<- prometheus.Metric) {
+	if !smbExporter.isCollected("durable_handle_count", collectors) {
+		return
+	}
+
+	handles, errGet := requestList(ctx, smbExporter, commonbl.SHARE_HANDLES_REQUEST, pipecomunication.GetShareHandlesGrpc)
+	if errGet != nil {
+		smbExporter.Logger.WriteErrorWithAddition(errGet, "while requesting the file handles of the shares")
+		return
+	}
+
+	smbExporter.sendUndescribedStatistics(statisticsGenerator.GetShareHandlesStatistics(handles, smbExporter.StatisticsGeneratorSettings), ch)
+}
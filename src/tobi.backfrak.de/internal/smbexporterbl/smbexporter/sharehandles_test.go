@@ -0,0 +1,47 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestSetShareHandlesMetrics(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	client := startStatusTestStatusd(t, commonbl.TestLockResponse)
+	exporter := NewSambaExporter(client, client, logger, "0.0.0", 5, getNewStatisticGenSettings())
+
+	ch := make(chan prometheus.Metric, 10)
+	exporter.setShareHandlesMetrics(context.Background(), nil, ch)
+
+	values := getSentGaugeValues(t, ch)
+	// The test handles are 3 open, 2 durable and 1 persistent on the share '/usr/share/data'
+	if len(values) != 5 || values["samba_share_open_handle_count"] != 3 || values["samba_durable_handle_count"] != 2 || values["samba_persistent_handle_count"] != 1 {
+		t.Errorf("Got the values '%v', but expected the test handles", values)
+	}
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("Got '%d' errors but expected none", logger.GetErrorCount())
+	}
+}
+
+func TestSetShareHandlesMetricsDisabled(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	settings := getNewStatisticGenSettings()
+	settings.DisabledCollectors = []string{statisticsGenerator.COLLECTOR_HANDLES}
+	exporter := NewSambaExporter(nil, nil, logger, "0.0.0", 5, settings)
+
+	ch := make(chan prometheus.Metric, 10)
+	exporter.setShareHandlesMetrics(context.Background(), nil, ch)
+	if len(ch) != 0 || logger.GetErrorCount() != 0 {
+		t.Errorf("Got %d metrics and %d errors, but the handles collector is disabled", len(ch), logger.GetErrorCount())
+	}
+}
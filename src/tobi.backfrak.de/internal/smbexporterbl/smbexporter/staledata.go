@@ -0,0 +1,78 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// lastStatus - The tables of the last successful request to samba_statusd, exported when samba_statusd can not be reached
+type lastStatus struct {
+	mutex     sync.Mutex
+	locks     []smbstatusreader.LockData
+	processes []smbstatusreader.ProcessData
+	shares    []smbstatusreader.ShareData
+	psData    []commonbl.PsUtilPidData
+	received  time.Time
+}
+
+// newLastStatus - Get a new lastStatus without tables
+func newLastStatus() *lastStatus {
+	return &lastStatus{}
+}
+
+// set - Keep the tables of a successful request
+func (last *lastStatus) set(locks []smbstatusreader.LockData, processes []smbstatusreader.ProcessData, shares []smbstatusreader.ShareData, psData []commonbl.PsUtilPidData) {
+	last.mutex.Lock()
+	defer last.mutex.Unlock()
+
+	last.locks = locks
+	last.processes = processes
+	last.shares = shares
+	last.psData = psData
+	last.received = time.Now()
+}
+
+// get - Get the kept tables and their age. The bool is false, when no tables are kept or they are older than maxAge
+func (last *lastStatus) get(maxAge time.Duration) ([]smbstatusreader.LockData, []smbstatusreader.ProcessData, []smbstatusreader.ShareData, []commonbl.PsUtilPidData, time.Duration, bool) {
+	last.mutex.Lock()
+	defer last.mutex.Unlock()
+
+	if last.received.IsZero() {
+		return nil, nil, nil, nil, 0, false
+	}
+	age := time.Since(last.received)
+	if age > maxAge {
+		return nil, nil, nil, nil, age, false
+	}
+
+	return last.locks, last.processes, last.shares, last.psData, age, true
+}
+
+// setDataStaleMetric - Send the age of the exported samba status, when the StaleDataMaxAge is set
+func (smbExporter *SambaExporter) setDataStaleMetric(staleSeconds float64, ch chan<- prometheus.Metric) {
+	if smbExporter.StaleDataMaxAge <= 0 {
+		return
+	}
+
+	smbExporter.setGaugeIntMetricNoLabel("data_stale_seconds", staleSeconds, ch)
+}
+
+// setDataStaleDescription - Send the description of the age of the exported samba status, when the StaleDataMaxAge is set
+func (smbExporter *SambaExporter) setDataStaleDescription(ch chan<- *prometheus.Desc) {
+	if smbExporter.StaleDataMaxAge <= 0 {
+		return
+	}
+
+	smbExporter.setGaugeDescriptionNoLabel("data_stale_seconds",
+		"Seconds since the last successful request to samba_statusd, 0 when the current samba status is exported. "+
+			"When greater 0, the samba status of the last successful request is exported", ch)
+}
@@ -0,0 +1,108 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbstatusout"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// collectStaleTestValues - Collect the metrics of the exporter and get the values of the metrics without labels by name
+func collectStaleTestValues(t *testing.T, exporter *SambaExporter) map[string]float64 {
+	chMet := make(chan prometheus.Metric, 1000)
+	exporter.collectMetrics(context.Background(), nil, chMet)
+
+	values := map[string]float64{}
+	for len(chMet) > 0 {
+		metric := <-chMet
+		var data dto.Metric
+		errWrite := metric.Write(&data)
+		if errWrite != nil {
+			t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+		}
+		if len(data.GetLabel()) > 0 {
+			continue
+		}
+		desc := metric.Desc().String()
+		name := desc[strings.Index(desc, "\"")+1 : strings.Index(desc, "\", help")]
+		if data.Gauge != nil {
+			values[name] = data.Gauge.GetValue()
+		} else if data.Counter != nil {
+			values[name] = data.Counter.GetValue()
+		}
+	}
+
+	return values
+}
+
+func TestCollectMetricsStaleData(t *testing.T) {
+	handler := &brokenHandler{}
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(handler, handler, logger, "0.0.0", 1, getNewStatisticGenSettings())
+	exporter.StaleDataMaxAge = time.Minute
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	psData := smbstatusreader.GetPsData(commonbl.TestPsResponse(), logger)
+	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, make(chan *prometheus.Desc, 100))
+
+	values := collectStaleTestValues(t, exporter)
+	if values["samba_locked_file_count"] != 0 || values["samba_data_stale_seconds"] != 0 {
+		t.Errorf("Got %f locked files %f seconds old, but expected none, since no request was successful",
+			values["samba_locked_file_count"], values["samba_data_stale_seconds"])
+	}
+
+	exporter.last.set(locks, processes, shares, psData)
+	exporter.last.received = time.Now().Add(-10 * time.Second)
+	values = collectStaleTestValues(t, exporter)
+	if value, found := values["samba_statusd_up"]; !found || value != 0 {
+		t.Errorf("Got samba_statusd_up '%f' (found: %t), but expected '0'", value, found)
+	}
+	if values["samba_locked_file_count"] != float64(len(locks)) {
+		t.Errorf("Got %f locked files but expected the %d of the last successful request", values["samba_locked_file_count"], len(locks))
+	}
+	if values["samba_data_stale_seconds"] < 10 {
+		t.Errorf("Got samba_data_stale_seconds '%f' but expected at least 10", values["samba_data_stale_seconds"])
+	}
+
+	exporter.last.received = time.Now().Add(-2 * time.Minute)
+	values = collectStaleTestValues(t, exporter)
+	if values["samba_locked_file_count"] != 0 {
+		t.Errorf("Got %f locked files but expected none, since the last successful request is older than the StaleDataMaxAge", values["samba_locked_file_count"])
+	}
+	if values["samba_data_stale_seconds"] < 120 {
+		t.Errorf("Got samba_data_stale_seconds '%f' but expected at least 120", values["samba_data_stale_seconds"])
+	}
+}
+
+func TestCollectMetricsWithoutStaleDataMaxAge(t *testing.T) {
+	handler := &brokenHandler{}
+	logger := testhelper.NewTestLogger(true)
+	exporter := NewSambaExporter(handler, handler, logger, "0.0.0", 1, getNewStatisticGenSettings())
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+	psData := smbstatusreader.GetPsData(commonbl.TestPsResponse(), logger)
+	exporter.setDescriptionsFromResponse(locks, processes, shares, psData, make(chan *prometheus.Desc, 100))
+	exporter.last.set(locks, processes, shares, psData)
+
+	values := collectStaleTestValues(t, exporter)
+	if _, found := values["samba_data_stale_seconds"]; found {
+		t.Errorf("Got samba_data_stale_seconds but expected it is not exported without StaleDataMaxAge")
+	}
+	if values["samba_locked_file_count"] != 0 {
+		t.Errorf("Got %f locked files but expected none without StaleDataMaxAge", values["samba_locked_file_count"])
+	}
+}
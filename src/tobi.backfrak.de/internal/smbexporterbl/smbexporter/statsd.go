@@ -0,0 +1,172 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The formats of the labels sent to the statsd server
+const (
+	// The labels are sent as DogStatsD tags, like '|#share:data'
+	STATSD_TAGS_DOGSTATSD = "dogstatsd"
+	// The labels are not sent, for statsd servers not knowing tags
+	STATSD_TAGS_NONE = "none"
+)
+
+// STATSD_MAX_PACKET_SIZE - The maximum size of the UDP packets, so they are not fragmented on an ethernet
+const STATSD_MAX_PACKET_SIZE = 1432
+
+// StatsdEmitter - Sends the metrics of a registry in an interval as statsd gauges and counters over UDP,
+// e. g. to a Datadog agent or a statsd server
+type StatsdEmitter struct {
+	address   string
+	interval  time.Duration
+	tagFormat string
+	gatherer  prometheus.Gatherer
+	logger    commonbl.Logger
+	conn      net.Conn
+	mutex     sync.Mutex
+	// The values of the counters sent the last time, since statsd counters are increments
+	lastCounters map[string]float64
+}
+
+// NewStatsdEmitter - Get a StatsdEmitter sending the metrics of the gatherer to the UDP address in the interval,
+// with the labels in the tagFormat
+func NewStatsdEmitter(address string, interval time.Duration, tagFormat string, gatherer prometheus.Gatherer, logger commonbl.Logger) (*StatsdEmitter, error) {
+	if tagFormat != STATSD_TAGS_DOGSTATSD && tagFormat != STATSD_TAGS_NONE {
+		return nil, fmt.Errorf("the statsd tag format '%s' is not known, use '%s' or '%s'", tagFormat, STATSD_TAGS_DOGSTATSD, STATSD_TAGS_NONE)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("the push interval '%s' is not positive", interval)
+	}
+	conn, errDial := net.Dial("udp", address)
+	if errDial != nil {
+		return nil, fmt.Errorf("the statsd address '%s' can not be used: %s", address, errDial.Error())
+	}
+
+	return &StatsdEmitter{address: address, interval: interval, tagFormat: tagFormat, gatherer: gatherer, logger: logger, conn: conn,
+		lastCounters: make(map[string]float64)}, nil
+}
+
+// Run - Send the metrics in the interval. A failed send is logged, the metrics are sent again in the next interval
+func (emitter *StatsdEmitter) Run() {
+	runPushLoop(emitter.address, emitter.interval, emitter.Push, emitter.logger)
+}
+
+// Close - Close the UDP socket
+func (emitter *StatsdEmitter) Close() {
+	emitter.conn.Close()
+}
+
+// Push - Gather the metrics and send them to the statsd server, in packets of at most STATSD_MAX_PACKET_SIZE bytes
+func (emitter *StatsdEmitter) Push() error {
+	families, errGather := emitter.gatherer.Gather()
+	if errGather != nil {
+		return errGather
+	}
+
+	lines := emitter.getStatsdLines(families)
+	packet := ""
+	for _, line := range lines {
+		if packet != "" && len(packet)+len(line)+1 > STATSD_MAX_PACKET_SIZE {
+			_, errWrite := emitter.conn.Write([]byte(packet))
+			if errWrite != nil {
+				return errWrite
+			}
+			packet = ""
+		}
+		if packet != "" {
+			packet += "\n"
+		}
+		packet += line
+	}
+	if packet != "" {
+		_, errWrite := emitter.conn.Write([]byte(packet))
+		if errWrite != nil {
+			return errWrite
+		}
+	}
+	emitter.logger.WriteVerbose(fmt.Sprintf("Sent %d statsd metrics to '%s'", len(lines), emitter.address))
+
+	return nil
+}
+
+// getStatsdLines - Get the statsd lines of the metric families. Gauges and untyped metrics are sent as gauges. Counters are sent
+// as counters with the increment since the last push. Of histograms and summaries the '_count' and '_sum' are sent as counters
+func (emitter *StatsdEmitter) getStatsdLines(families []*dto.MetricFamily) []string {
+	emitter.mutex.Lock()
+	defer emitter.mutex.Unlock()
+
+	var ret []string
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			tags := emitter.getStatsdTags(metric.GetLabel())
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				ret = append(ret, getStatsdLine(name, metric.GetGauge().GetValue(), "g", tags))
+			case dto.MetricType_UNTYPED:
+				ret = append(ret, getStatsdLine(name, metric.GetUntyped().GetValue(), "g", tags))
+			case dto.MetricType_COUNTER:
+				ret = append(ret, getStatsdLine(name, emitter.getIncrement(name+tags, metric.GetCounter().GetValue()), "c", tags))
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				ret = append(ret, getStatsdLine(name+"_count", emitter.getIncrement(name+"_count"+tags, float64(histogram.GetSampleCount())), "c", tags))
+				ret = append(ret, getStatsdLine(name+"_sum", emitter.getIncrement(name+"_sum"+tags, histogram.GetSampleSum()), "c", tags))
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				ret = append(ret, getStatsdLine(name+"_count", emitter.getIncrement(name+"_count"+tags, float64(summary.GetSampleCount())), "c", tags))
+				ret = append(ret, getStatsdLine(name+"_sum", emitter.getIncrement(name+"_sum"+tags, summary.GetSampleSum()), "c", tags))
+			}
+		}
+	}
+
+	return ret
+}
+
+// getIncrement - Get the increment of the counter since the last push, and remember the value. After a reset of the counter the value is the increment
+func (emitter *StatsdEmitter) getIncrement(key string, value float64) float64 {
+	last, found := emitter.lastCounters[key]
+	emitter.lastCounters[key] = value
+	if !found || value < last {
+		return value
+	}
+
+	return value - last
+}
+
+// getStatsdTags - Get the tags of the labels in the tag format, sorted by the label name
+func (emitter *StatsdEmitter) getStatsdTags(labels []*dto.LabelPair) string {
+	if emitter.tagFormat == STATSD_TAGS_NONE || len(labels) == 0 {
+		return ""
+	}
+
+	var tags []string
+	for _, label := range labels {
+		// The characters separating the tags and the parts of a line can not be in a tag
+		value := strings.NewReplacer(",", "_", "|", "_", "#", "_").Replace(label.GetValue())
+		tags = append(tags, fmt.Sprintf("%s:%s", label.GetName(), value))
+	}
+	sort.Strings(tags)
+
+	return "|#" + strings.Join(tags, ",")
+}
+
+// getStatsdLine - Get the line of a metric, like 'samba_share_count:2|g|#cluster:a'
+func getStatsdLine(name string, value float64, metricType string, tags string) string {
+	return fmt.Sprintf("%s:%s|%s%s", name, strconv.FormatFloat(value, 'f', -1, 64), metricType, tags)
+}
@@ -0,0 +1,91 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// readStatsdLines - Read the lines of the next packet sent to the listener
+func readStatsdLines(t *testing.T, listener net.PacketConn) []string {
+	buffer := make([]byte, STATSD_MAX_PACKET_SIZE)
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	count, _, errRead := listener.ReadFrom(buffer)
+	if errRead != nil {
+		t.Fatalf("Got error '%s' but expected none", errRead.Error())
+	}
+
+	return strings.Split(string(buffer[:count]), "\n")
+}
+
+func TestStatsdEmitterPush(t *testing.T) {
+	listener, errListen := net.ListenPacket("udp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	defer listener.Close()
+
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "samba_test_total", Help: "A test counter"}, []string{"share"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "samba_test_count", Help: "A test gauge"})
+	registry.MustRegister(counter, gauge)
+	counter.WithLabelValues("data|1").Add(3)
+	gauge.Set(2)
+
+	emitter, errNew := NewStatsdEmitter(listener.LocalAddr().String(), time.Minute, STATSD_TAGS_DOGSTATSD, registry, testhelper.NewTestLogger(true))
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	defer emitter.Close()
+
+	errPush := emitter.Push()
+	if errPush != nil {
+		t.Fatalf("Got error '%s' but expected none", errPush.Error())
+	}
+	lines := readStatsdLines(t, listener)
+	if len(lines) != 2 || lines[0] != "samba_test_count:2|g" || lines[1] != "samba_test_total:3|c|#share:data_1" {
+		t.Errorf("The lines %v are not expected", lines)
+	}
+
+	counter.WithLabelValues("data|1").Add(2)
+	errPush = emitter.Push()
+	if errPush != nil {
+		t.Fatalf("Got error '%s' but expected none", errPush.Error())
+	}
+	lines = readStatsdLines(t, listener)
+	if len(lines) != 2 || lines[1] != "samba_test_total:2|c|#share:data_1" {
+		t.Errorf("The counter is not sent as increment: %v", lines)
+	}
+}
+
+func TestStatsdEmitterWithoutTags(t *testing.T) {
+	emitter := StatsdEmitter{tagFormat: STATSD_TAGS_NONE, lastCounters: make(map[string]float64)}
+	families, _ := getTestRegistry().Gather()
+
+	lines := emitter.getStatsdLines(families)
+	expected := []string{"samba_test_seconds_count:2|c", "samba_test_seconds_sum:2.25|c", "samba_test_total:3|c"}
+	if strings.Join(lines, " ") != strings.Join(expected, " ") {
+		t.Errorf("The lines %v are not the expected %v", lines, expected)
+	}
+}
+
+func TestNewStatsdEmitterInvalid(t *testing.T) {
+	_, errNew := NewStatsdEmitter("localhost:8125", time.Minute, "graphite", getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew == nil {
+		t.Errorf("Got no error for an unknown tag format, but expected one")
+	}
+
+	_, errNew = NewStatsdEmitter("localhost", time.Minute, STATSD_TAGS_DOGSTATSD, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew == nil {
+		t.Errorf("Got no error for an address without port, but expected one")
+	}
+}
@@ -0,0 +1,42 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"time"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// The version of the SambaStatus document. Increased when a field is removed or changes its meaning, not when a field is added
+const STATUS_API_VERSION = 1
+
+// SambaStatus - The entries of the smbstatus tables at a point in time, as served by the status API of samba_exporter
+type SambaStatus struct {
+	ApiVersion int
+	Time       time.Time
+	Locks      []smbstatusreader.LockData
+	Shares     []smbstatusreader.ShareData
+	Processes  []smbstatusreader.ProcessData
+}
+
+// GetSambaStatus - Request the current status from samba_statusd. The locks and shares are filtered with the ShareFilter
+// of the StatisticsGeneratorSettings, tables without entries are empty, not nil. Waiting for samba_statusd is stopped, when the context is done
+func (smbExporter *SambaExporter) GetSambaStatus(ctx context.Context) (SambaStatus, error) {
+	ret := SambaStatus{ApiVersion: STATUS_API_VERSION, Time: time.Now().UTC()}
+	locks, processes, shares, _, errGet := smbExporter.getSambaStatus(ctx)
+	if errGet != nil {
+		return ret, errGet
+	}
+
+	filter := smbExporter.StatisticsGeneratorSettings.ShareFilter
+	ret.Locks = append([]smbstatusreader.LockData{}, filter.FilterLockData(locks)...)
+	ret.Shares = append([]smbstatusreader.ShareData{}, filter.FilterShareData(shares)...)
+	ret.Processes = append([]smbstatusreader.ProcessData{}, processes...)
+
+	return ret, nil
+}
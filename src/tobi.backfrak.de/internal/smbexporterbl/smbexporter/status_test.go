@@ -0,0 +1,107 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// startStatusTestStatusd - Start a samba_statusd on a random local port, answering with the test data. Get the client connected to it
+func startStatusTestStatusd(t *testing.T, lockData string) *commonbl.TcpHandler {
+	listener, errListen := commonbl.ListenTcp("127.0.0.1:0", nil)
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			return
+		}
+		handler := commonbl.NewTcpConnectionHandler(conn)
+		defer handler.Close()
+		testData := map[commonbl.RequestType]string{
+			commonbl.VERSION_REQUEST:       fmt.Sprintf("PROTOCOL_VERSION: %d; PROGRAM_VERSION: test", commonbl.PROTOCOL_VERSION),
+			commonbl.PROCESS_REQUEST:       commonbl.TestProcessResponse,
+			commonbl.SHARE_REQUEST:         commonbl.TestShareResponse,
+			commonbl.LOCK_REQUEST:          lockData,
+			commonbl.PS_REQUEST:            commonbl.TestPsResponse(),
+			commonbl.CTDB_NODES_REQUEST:    commonbl.TestCtdbNodesResponse(),
+			commonbl.SHARE_CONFIG_REQUEST:  commonbl.TestShareConfigResponse(),
+			commonbl.SHARE_HANDLES_REQUEST: commonbl.TestShareHandlesResponse(),
+		}
+		for {
+			request, errRead := handler.WaitForPipeInputString()
+			if errRead != nil {
+				return
+			}
+			for requestType, data := range testData {
+				if strings.HasPrefix(request, string(requestType)) {
+					id, _ := commonbl.GetIdFromRequest(request)
+					handler.WritePipeString(commonbl.GetResponse(commonbl.GetResponseHeader(requestType, id), data))
+				}
+			}
+		}
+	}()
+
+	client := commonbl.NewTcpClientHandler(listener.Addr().String(), nil)
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestGetSambaStatus(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	client := startStatusTestStatusd(t, commonbl.TestLockResponse)
+	exporter := NewSambaExporter(client, client, logger, "0.0.0", 5, getNewStatisticGenSettings())
+
+	status, err := exporter.GetSambaStatus(context.Background())
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if status.ApiVersion != STATUS_API_VERSION || status.Time.IsZero() {
+		t.Errorf("The version %d and time '%s' are not expected", status.ApiVersion, status.Time)
+	}
+	if len(status.Locks) != 1 || len(status.Processes) != 1 || len(status.Shares) == 0 {
+		t.Errorf("Got %d locks, %d processes and %d shares, but expected 1, 1 and some shares", len(status.Locks), len(status.Processes), len(status.Shares))
+	}
+}
+
+func TestGetSambaStatusEmptyAndFiltered(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	client := startStatusTestStatusd(t, "")
+	settings := getNewStatisticGenSettings()
+	filter, errFilter := statisticsGenerator.NewShareFilter("", ".*")
+	if errFilter != nil {
+		t.Fatalf("Got error '%s' but expected none", errFilter.Error())
+	}
+	settings.ShareFilter = filter
+	exporter := NewSambaExporter(client, client, logger, "0.0.0", 5, settings)
+
+	status, err := exporter.GetSambaStatus(context.Background())
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	data, errConv := json.Marshal(status)
+	if errConv != nil {
+		t.Fatalf("Got error '%s' but expected none", errConv.Error())
+	}
+	if !strings.Contains(string(data), `"Locks":[]`) || !strings.Contains(string(data), `"Shares":[]`) {
+		t.Errorf("The status '%s' does not contain empty locks and shares", string(data))
+	}
+	if len(status.Processes) != 1 {
+		t.Errorf("Got %d processes but expected 1, the processes are not filtered by share", len(status.Processes))
+	}
+}
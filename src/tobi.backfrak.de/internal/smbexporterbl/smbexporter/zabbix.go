@@ -0,0 +1,218 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// ZABBIX_DEFAULT_PORT - The port of the trapper of a Zabbix server or proxy, used when the address has no port
+const ZABBIX_DEFAULT_PORT = "10051"
+
+// The header of the packets of the Zabbix protocol, followed by the length of the data
+const zabbixHeader = "ZBXD\x01"
+
+// The largest answer of the Zabbix server that is read
+const zabbixMaxResponseSize = 64 * 1024
+
+// ZabbixSender - Sends the metrics of a registry in an interval to the trapper items of a host in Zabbix,
+// like the zabbix_sender tool does
+type ZabbixSender struct {
+	server   string
+	host     string
+	interval time.Duration
+	timeout  time.Duration
+	gatherer prometheus.Gatherer
+	logger   commonbl.Logger
+}
+
+// zabbixItem - A value of a trapper item sent to Zabbix
+type zabbixItem struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock"`
+}
+
+// zabbixRequest - The data sent to the Zabbix server
+type zabbixRequest struct {
+	Request string       `json:"request"`
+	Data    []zabbixItem `json:"data"`
+	Clock   int64        `json:"clock"`
+}
+
+// zabbixResponse - The answer of the Zabbix server
+type zabbixResponse struct {
+	Response string `json:"response"`
+	Info     string `json:"info"`
+}
+
+// NewZabbixSender - Get a ZabbixSender sending the metrics of the gatherer in the interval to the server, as values of the host in Zabbix.
+// The server is given as 'host:port', without port ZABBIX_DEFAULT_PORT is used. The connection and the answer take at most timeout
+func NewZabbixSender(server string, host string, interval time.Duration, timeout time.Duration, gatherer prometheus.Gatherer, logger commonbl.Logger) (*ZabbixSender, error) {
+	if _, _, errSplit := net.SplitHostPort(server); errSplit != nil {
+		server = net.JoinHostPort(server, ZABBIX_DEFAULT_PORT)
+	}
+	if host == "" {
+		return nil, fmt.Errorf("the name of the host in Zabbix is not given")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("the push interval '%s' is not positive", interval)
+	}
+
+	return &ZabbixSender{server: server, host: host, interval: interval, timeout: timeout, gatherer: gatherer, logger: logger}, nil
+}
+
+// Run - Send the metrics in the interval. A failed send is logged, the metrics are sent again in the next interval
+func (sender *ZabbixSender) Run() {
+	runPushLoop(sender.server, sender.interval, sender.Push, sender.logger)
+}
+
+// Push - Gather the metrics and send them to the Zabbix server. Values of items not known by the server are
+// only logged in verbose mode, since usually not every metric has a trapper item
+func (sender *ZabbixSender) Push() error {
+	families, errGather := sender.gatherer.Gather()
+	if errGather != nil {
+		return errGather
+	}
+	now := time.Now().Unix()
+	data, errMarshal := json.Marshal(zabbixRequest{Request: "sender data", Data: getZabbixItems(families, sender.host, now), Clock: now})
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	conn, errDial := net.DialTimeout("tcp", sender.server, sender.timeout)
+	if errDial != nil {
+		return errDial
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(sender.timeout))
+
+	_, errWrite := conn.Write(getZabbixPacket(data))
+	if errWrite != nil {
+		return errWrite
+	}
+	response, errRead := readZabbixPacket(conn)
+	if errRead != nil {
+		return errRead
+	}
+
+	var answer zabbixResponse
+	errUnmarshal := json.Unmarshal(response, &answer)
+	if errUnmarshal != nil {
+		return fmt.Errorf("the answer of the Zabbix server can not be read: %s", errUnmarshal.Error())
+	}
+	if answer.Response != "success" {
+		return fmt.Errorf("the Zabbix server answered '%s': %s", answer.Response, answer.Info)
+	}
+	sender.logger.WriteVerbose(fmt.Sprintf("Sent the metrics to the Zabbix server '%s': %s", sender.server, answer.Info))
+
+	return nil
+}
+
+// getZabbixPacket - Get the packet of the Zabbix protocol containing the data
+func getZabbixPacket(data []byte) []byte {
+	packet := make([]byte, len(zabbixHeader)+8, len(zabbixHeader)+8+len(data))
+	copy(packet, zabbixHeader)
+	// The length of the data, followed by 4 reserved bytes
+	binary.LittleEndian.PutUint32(packet[len(zabbixHeader):], uint32(len(data)))
+
+	return append(packet, data...)
+}
+
+// readZabbixPacket - Read a packet of the Zabbix protocol and get its data
+func readZabbixPacket(reader io.Reader) ([]byte, error) {
+	header := make([]byte, len(zabbixHeader)+8)
+	_, errHeader := io.ReadFull(reader, header)
+	if errHeader != nil {
+		return nil, errHeader
+	}
+	if string(header[:len(zabbixHeader)]) != zabbixHeader {
+		return nil, fmt.Errorf("the answer is not a packet of the Zabbix protocol")
+	}
+	length := binary.LittleEndian.Uint32(header[len(zabbixHeader):])
+	if length > zabbixMaxResponseSize {
+		return nil, fmt.Errorf("the answer of %d bytes is too large", length)
+	}
+
+	data := make([]byte, length)
+	_, errData := io.ReadFull(reader, data)
+
+	return data, errData
+}
+
+// getZabbixItems - Get the values of the metrics as items of the host. Counters, gauges and untyped metrics are sent with the key of getZabbixKey,
+// of histograms and summaries the '_count' and '_sum' are sent. NaN and infinite values can not be stored by Zabbix and are dropped
+func getZabbixItems(families []*dto.MetricFamily, host string, clock int64) []zabbixItem {
+	ret := []zabbixItem{}
+	add := func(name string, labels []*dto.LabelPair, value float64) {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return
+		}
+		ret = append(ret, zabbixItem{Host: host, Key: getZabbixKey(name, labels), Value: strconv.FormatFloat(value, 'f', -1, 64), Clock: clock})
+	}
+
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, metric.GetLabel(), metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, metric.GetLabel(), metric.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, metric.GetLabel(), metric.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				add(name+"_count", metric.GetLabel(), float64(metric.GetHistogram().GetSampleCount()))
+				add(name+"_sum", metric.GetLabel(), metric.GetHistogram().GetSampleSum())
+			case dto.MetricType_SUMMARY:
+				add(name+"_count", metric.GetLabel(), float64(metric.GetSummary().GetSampleCount()))
+				add(name+"_sum", metric.GetLabel(), metric.GetSummary().GetSampleSum())
+			}
+		}
+	}
+
+	return ret
+}
+
+// getZabbixKey - Get the key of the item of a metric. The values of the labels, sorted by the label names,
+// are the parameters of the key, like 'samba_open_files[data]'. A metric without labels has the metric name as key
+func getZabbixKey(name string, labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	sortedLabels := append([]*dto.LabelPair{}, labels...)
+	sort.Slice(sortedLabels, func(i, j int) bool { return sortedLabels[i].GetName() < sortedLabels[j].GetName() })
+	var parameters []string
+	for _, label := range sortedLabels {
+		parameters = append(parameters, getZabbixKeyParameter(label.GetValue()))
+	}
+
+	return fmt.Sprintf("%s[%s]", name, strings.Join(parameters, ","))
+}
+
+// getZabbixKeyParameter - Get the parameter of a key, quoted when it contains characters with a meaning in the key
+func getZabbixKeyParameter(value string) string {
+	if !strings.ContainsAny(value, ",[]\"") && !strings.HasPrefix(value, " ") {
+		return value
+	}
+
+	return fmt.Sprintf("\"%s\"", strings.ReplaceAll(value, "\"", "\\\""))
+}
@@ -0,0 +1,106 @@
+package smbexporter
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// startTestZabbixServer - Start a Zabbix server answering a single request with the response, the request is sent to the channel
+func startTestZabbixServer(t *testing.T, response string) (string, chan zabbixRequest) {
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("Got error '%s' but expected none", errListen.Error())
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	requests := make(chan zabbixRequest, 1)
+	go func() {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			return
+		}
+		defer conn.Close()
+		data, errRead := readZabbixPacket(conn)
+		if errRead != nil {
+			t.Errorf("Can not read the request: %s", errRead.Error())
+		}
+		var request zabbixRequest
+		json.Unmarshal(data, &request)
+		requests <- request
+		conn.Write(getZabbixPacket([]byte(response)))
+	}()
+
+	return listener.Addr().String(), requests
+}
+
+func TestZabbixSenderPush(t *testing.T) {
+	address, requests := startTestZabbixServer(t, `{"response":"success","info":"processed: 3; failed: 0; total: 3; seconds spent: 0.000055"}`)
+	sender, errNew := NewZabbixSender(address, "fileserver", time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+
+	errPush := sender.Push()
+	if errPush != nil {
+		t.Fatalf("Got error '%s' but expected none", errPush.Error())
+	}
+
+	request := <-requests
+	if request.Request != "sender data" || len(request.Data) != 3 {
+		t.Fatalf("The request %v is not expected", request)
+	}
+	expected := []string{"samba_test_seconds_count=2", "samba_test_seconds_sum=2.25", "samba_test_total[data]=3"}
+	for i, item := range request.Data {
+		if item.Host != "fileserver" || item.Key+"="+item.Value != expected[i] {
+			t.Errorf("The item %v is not the expected '%s'", item, expected[i])
+		}
+	}
+}
+
+func TestZabbixSenderPushFailed(t *testing.T) {
+	address, _ := startTestZabbixServer(t, `{"response":"failed","info":"host not found"}`)
+	sender, _ := NewZabbixSender(address, "fileserver", time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+
+	errPush := sender.Push()
+	if errPush == nil {
+		t.Errorf("Got no error, but expected one")
+	}
+}
+
+func TestGetZabbixKey(t *testing.T) {
+	labels := []*dto.LabelPair{
+		{Name: proto.String("user"), Value: proto.String("tobi")},
+		{Name: proto.String("share"), Value: proto.String("my,share")},
+	}
+
+	key := getZabbixKey("samba_test", labels)
+	if key != "samba_test[\"my,share\",tobi]" {
+		t.Errorf("The key '%s' is not expected", key)
+	}
+}
+
+func TestNewZabbixSender(t *testing.T) {
+	sender, errNew := NewZabbixSender("zabbix", "fileserver", time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	if sender.server != "zabbix:10051" {
+		t.Errorf("The server '%s' is not expected", sender.server)
+	}
+
+	_, errNew = NewZabbixSender("zabbix:10051", "", time.Minute, time.Second, getTestRegistry(), testhelper.NewTestLogger(true))
+	if errNew == nil {
+		t.Errorf("Got no error for a missing host, but expected one")
+	}
+}
@@ -0,0 +1,75 @@
+package smbstatusreader
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The names of the tables parser errors are counted for
+const (
+	LOCK_TABLE    = "locks"
+	SHARE_TABLE   = "shares"
+	PROCESS_TABLE = "processes"
+	PS_TABLE      = "psdata"
+)
+
+var parserErrorCounts = map[string]int{}
+var parserErrorMux sync.Mutex
+var logRawLines atomic.Bool
+
+// GetTableNames - Get the names of the tables parser errors are counted for
+func GetTableNames() []string {
+	return []string{LOCK_TABLE, SHARE_TABLE, PROCESS_TABLE, PS_TABLE}
+}
+
+// GetParserErrorCount - Get the number of lines or tables of the given table, that could not be parsed
+func GetParserErrorCount(table string) int {
+	parserErrorMux.Lock()
+	defer parserErrorMux.Unlock()
+
+	return parserErrorCounts[table]
+}
+
+func addParserError(table string) {
+	parserErrorMux.Lock()
+	defer parserErrorMux.Unlock()
+
+	parserErrorCounts[table]++
+}
+
+// unexpectedTableFormat - Count a table that can not be parsed at all. Logged as verbose message only, since
+// samba prints other texts instead of the table in some cases
+func unexpectedTableFormat(table string, reason string, logger commonbl.Logger) {
+	addParserError(table)
+	logger.WriteVerbose(fmt.Sprintf("The %s table has an unexpected format: %s", table, reason))
+}
+
+// SetLogRawLines - When enabled, the errors about lines that can not be parsed contain the raw line,
+// and each parsed entry is logged as verbose message together with the line it was parsed from
+func SetLogRawLines(enabled bool) {
+	logRawLines.Store(enabled)
+}
+
+// writeLineError - Count and log an error while parsing a line of the table
+func writeLineError(table string, line string, err error, addition string, logger commonbl.Logger) {
+	addParserError(table)
+	if logRawLines.Load() {
+		addition = fmt.Sprintf("%s in the line \"%s\"", addition, line)
+	}
+	logger.WriteErrorWithAddition(err, addition)
+}
+
+// logParsedEntry - Log the entry and the line it was parsed from, when logging the raw lines is enabled
+func logParsedEntry(entry fmt.Stringer, line string, logger commonbl.Logger) {
+	if logRawLines.Load() {
+		logger.WriteVerbose(fmt.Sprintf("Parsed \"%s\" from the line \"%s\"", entry, line))
+	}
+}
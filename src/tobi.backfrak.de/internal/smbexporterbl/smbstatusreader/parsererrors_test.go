@@ -0,0 +1,126 @@
+package smbstatusreader
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/smbstatusout"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestParserErrorCountInvalidLine(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	oldCount := GetParserErrorCount(LOCK_TABLE)
+
+	entryList := GetLockData(smbstatusout.LockDataInvadlidResponse, logger)
+	if len(entryList) != 3 {
+		t.Errorf("Got %d entries, expected 3", len(entryList))
+	}
+
+	if GetParserErrorCount(LOCK_TABLE) != oldCount+1 {
+		t.Errorf("Got %d parser errors for the lock table, expected %d", GetParserErrorCount(LOCK_TABLE), oldCount+1)
+	}
+}
+
+func TestParserErrorCountWrongTable(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	oldLockCount := GetParserErrorCount(LOCK_TABLE)
+	oldShareCount := GetParserErrorCount(SHARE_TABLE)
+	oldProcessCount := GetParserErrorCount(PROCESS_TABLE)
+	oldPsCount := GetParserErrorCount(PS_TABLE)
+
+	GetLockData(smbstatusout.ProcessData4Lines, logger)
+	GetShareData(smbstatusout.LockDataOneLine, logger)
+	GetProcessData(smbstatusout.ShareDataOneLine, logger)
+	GetPsData("no json", logger)
+
+	if GetParserErrorCount(LOCK_TABLE) != oldLockCount+1 {
+		t.Errorf("Got %d parser errors for the lock table, expected %d", GetParserErrorCount(LOCK_TABLE), oldLockCount+1)
+	}
+	if GetParserErrorCount(SHARE_TABLE) != oldShareCount+1 {
+		t.Errorf("Got %d parser errors for the share table, expected %d", GetParserErrorCount(SHARE_TABLE), oldShareCount+1)
+	}
+	if GetParserErrorCount(PROCESS_TABLE) != oldProcessCount+1 {
+		t.Errorf("Got %d parser errors for the process table, expected %d", GetParserErrorCount(PROCESS_TABLE), oldProcessCount+1)
+	}
+	if GetParserErrorCount(PS_TABLE) != oldPsCount+1 {
+		t.Errorf("Got %d parser errors for the ps table, expected %d", GetParserErrorCount(PS_TABLE), oldPsCount+1)
+	}
+}
+
+func TestParserErrorCountShortLines(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	oldLockCount := GetParserErrorCount(LOCK_TABLE)
+	oldShareCount := GetParserErrorCount(SHARE_TABLE)
+	oldProcessCount := GetParserErrorCount(PROCESS_TABLE)
+
+	locks := GetLockData(smbstatusout.LockDataOneLine+"\n1121  1080\n\n", logger)
+	shares := GetShareData(smbstatusout.ShareDataOneLine+"\nIPC$  1120\n\n", logger)
+	processes := GetProcessData(smbstatusout.ProcessDataOneLine+"\n1118  1080\n\n", logger)
+
+	if len(locks) != 1 || len(shares) != 1 || len(processes) != 1 {
+		t.Errorf("Got %d locks, %d shares and %d processes, expected 1 of each", len(locks), len(shares), len(processes))
+	}
+
+	if GetParserErrorCount(LOCK_TABLE) != oldLockCount+1 {
+		t.Errorf("Got %d parser errors for the lock table, expected %d", GetParserErrorCount(LOCK_TABLE), oldLockCount+1)
+	}
+	if GetParserErrorCount(SHARE_TABLE) != oldShareCount+1 {
+		t.Errorf("Got %d parser errors for the share table, expected %d", GetParserErrorCount(SHARE_TABLE), oldShareCount+1)
+	}
+	if GetParserErrorCount(PROCESS_TABLE) != oldProcessCount+1 {
+		t.Errorf("Got %d parser errors for the process table, expected %d", GetParserErrorCount(PROCESS_TABLE), oldProcessCount+1)
+	}
+
+	if logger.GetErrorCount() != 3 {
+		t.Errorf("The ErrorCount '%d' is not the expected '3'", logger.GetErrorCount())
+	}
+}
+
+func TestRawLine(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+
+	locks := GetLockData(smbstatusout.LockDataOneLine, logger)
+	if len(locks) != 1 || !strings.HasPrefix(locks[0].RawLine, "1120         1080       DENY_NONE") {
+		t.Errorf("The lock entry does not contain the expected raw line")
+	}
+
+	shares := GetShareData(smbstatusout.ShareDataOneLine, logger)
+	if len(shares) != 1 || !strings.HasPrefix(shares[0].RawLine, "IPC$         1119") {
+		t.Errorf("The share entry does not contain the expected raw line")
+	}
+
+	processes := GetProcessData(smbstatusout.ProcessDataOneLine, logger)
+	if len(processes) != 1 || !strings.HasPrefix(processes[0].RawLine, "1117") {
+		t.Errorf("The process entry does not contain the expected raw line")
+	}
+}
+
+func TestSetLogRawLines(t *testing.T) {
+	defer SetLogRawLines(false)
+	logger := testhelper.NewTestLogger(true)
+
+	GetLockData(smbstatusout.LockDataOneLine, logger)
+	if logger.GetOutputCount() != 0 {
+		t.Errorf("Got %d output messages but expected 0, since logging the raw lines is disabled", logger.GetOutputCount())
+	}
+
+	SetLogRawLines(true)
+	GetLockData(smbstatusout.LockDataOneLine, logger)
+	if logger.GetOutputCount() != 1 {
+		t.Errorf("Got %d output messages but expected 1", logger.GetOutputCount())
+	}
+
+	GetProcessData(smbstatusout.ProcessDataOneLine+"\n1118  abc  117  192.168.1.242  SMB3_11  -  -\n", logger)
+	if logger.GetErrorCount() != 1 {
+		t.Fatalf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+	if !strings.Contains(logger.WrittenErrors[0], "1118  abc  117") {
+		t.Errorf("The error message '%s' does not contain the raw line", logger.WrittenErrors[0])
+	}
+}
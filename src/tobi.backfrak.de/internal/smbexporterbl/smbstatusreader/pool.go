@@ -0,0 +1,61 @@
+package smbstatusreader
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Slices and buffers that grew above this capacity, e. g. for a very long line, are not put back into the pools
+const maxPooledCapacity = 4096
+
+// The field slices of the lines are reused between the tables and scrapes, so a big smbstatus output does not produce the same garbage again with each scrape
+var fieldSlicePool = sync.Pool{
+	New: func() interface{} {
+		fields := make([]string, 0, 16)
+		return &fields
+	},
+}
+
+// The buffers to join fields are reused, so joining the fields of a line does not allocate more than the resulting string
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getFieldSlice - Get an empty field slice out of the pool
+func getFieldSlice() *[]string {
+	return fieldSlicePool.Get().(*[]string)
+}
+
+// putFieldSlice - Put the field slice back into the pool. The fields are cleared, so the pool does not keep the lines of the last output alive
+func putFieldSlice(fields *[]string) {
+	if cap(*fields) > maxPooledCapacity {
+		return
+	}
+	*fields = (*fields)[:cap(*fields)]
+	for i := range *fields {
+		(*fields)[i] = ""
+	}
+	*fields = (*fields)[:0]
+	fieldSlicePool.Put(fields)
+}
+
+// getBuffer - Get an empty buffer out of the pool
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer - Put the buffer back into the pool
+func putBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledCapacity {
+		return
+	}
+	buffer.Reset()
+	bufferPool.Put(buffer)
+}
@@ -0,0 +1,55 @@
+package smbstatusreader
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPutFieldSlice(t *testing.T) {
+	fields := getFieldSlice()
+	if len(*fields) != 0 {
+		t.Errorf("Got a field slice with length '%d' from the pool, but expected '0'", len(*fields))
+	}
+
+	*fields = appendFields((*fields)[:0], "1120  1080  DENY_NONE", " ")
+	used := *fields
+	putFieldSlice(fields)
+
+	if len(*fields) != 0 {
+		t.Errorf("The field slice has the length '%d' after put, but expected '0'", len(*fields))
+	}
+
+	for i, field := range used {
+		if field != "" {
+			t.Errorf("The field '%d' is '%s' after put, but expected to be cleared", i, field)
+		}
+	}
+}
+
+func TestConcatStrFromArr(t *testing.T) {
+	if concatStrFromArr(nil) != "" {
+		t.Errorf("Got '%s' but expected an empty string", concatStrFromArr(nil))
+	}
+
+	joined := concatStrFromArr([]string{"Sun", "May", "16", "12:07:02", "2021"})
+	if joined != "Sun May 16 12:07:02 2021" {
+		t.Errorf("Got '%s' but expected 'Sun May 16 12:07:02 2021'", joined)
+	}
+
+	// The buffer is reused, so the joined string must not change with the next call
+	concatStrFromArr([]string{strings.Repeat("x", len(joined))})
+	if joined != "Sun May 16 12:07:02 2021" {
+		t.Errorf("The joined string changed to '%s' with the next call", joined)
+	}
+
+	buffer := getBuffer()
+	if buffer.Len() != 0 {
+		t.Errorf("Got a buffer with length '%d' from the pool, but expected '0'", buffer.Len())
+	}
+	putBuffer(buffer)
+}
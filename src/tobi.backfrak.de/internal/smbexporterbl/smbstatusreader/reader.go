@@ -6,6 +6,7 @@ package smbstatusreader
 // LICENSE file.
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -27,6 +28,7 @@ type LockData struct {
 	SharePath     string
 	Name          string
 	Time          time.Time
+	RawLine       string // The line of the smbstatus output the entry was parsed from
 }
 
 // Implement Stringer Interface for LockData
@@ -42,9 +44,13 @@ func (lockData LockData) String() string {
 }
 
 // GetLockData - Get the entries out of the 'smbstatus -L -n' output table multiline string
-// Will return an empty array if the data is in unexpected format
+// Will return an empty array if the data is in unexpected format. Lines that can not be parsed are skipped.
+// Skipped lines and tables are counted, see GetParserErrorCount
 func GetLockData(data string, logger commonbl.Logger) []LockData {
 	var ret []LockData
+	if isDisabledTable(data, LOCK_TABLE, logger) {
+		return ret
+	}
 	if strings.HasPrefix(strings.TrimSpace(data), commonbl.NO_LOCKED_FILES) {
 		return ret
 	}
@@ -54,93 +60,189 @@ func GetLockData(data string, logger commonbl.Logger) []LockData {
 		return ret
 	}
 
-	lines := strings.Split(data, "\n")
-	sepLineIndex := findSeperatorLineIndex(lines)
-
-	if sepLineIndex < 1 {
+	scanner := newLineScanner(data)
+	headerLines, sepFound := scanToSeparatorLine(scanner, 1)
+	if !sepFound || len(headerLines) < 1 {
+		unexpectedTableFormat(LOCK_TABLE, "No separator line after the header found", logger)
 		return ret
 	}
+	header := headerLines[0]
 
-	tableHeaderMatrix := getFieldMatrixFixLength(lines[sepLineIndex-1:sepLineIndex], "  ", 9)
-	if len(tableHeaderMatrix) != 1 {
+	tableHeaderFields := appendFields(nil, header, "  ")
+	if len(tableHeaderFields) != 9 {
+		unexpectedTableFormat(LOCK_TABLE, fmt.Sprintf("The header \"%s\" has not 9 columns", header), logger)
 		return ret
 	}
-	tableHeaderFields := tableHeaderMatrix[0]
 
 	if tableHeaderFields[0] != "Pid" || tableHeaderFields[5] != "Oplock" {
+		unexpectedTableFormat(LOCK_TABLE, fmt.Sprintf("Unknown header \"%s\"", header), logger)
 		return ret
 	}
 
-	i := -1
-	for _, oneLineFields := range getFieldMatrix(lines[sepLineIndex+1:], " ") {
-		i++
+	// The columns up to the SharePath are aligned to the header, the SharePath, Name and Time are separated by spaces only
+	columnOffsets, errOffsets := getColumnOffsets(header, tableHeaderFields[:7])
+	if errOffsets != nil {
+		unexpectedTableFormat(LOCK_TABLE, errOffsets.Error(), logger)
+		return ret
+	}
+
+	columnBuffer := getFieldSlice()
+	defer putFieldSlice(columnBuffer)
+	for scanner.Scan() {
+		line := scanner.Text()
 		var err error
 		var entry LockData
-		fieldLength := len(oneLineFields)
-		if strings.Contains(oneLineFields[0], ":") {
-			pidFields := strings.Split(oneLineFields[0], ":")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry.RawLine = line
+		columns, found := getColumnsByOffset(line, columnOffsets, (*columnBuffer)[:0])
+		*columnBuffer = columns
+		if !found {
+			logger.WriteErrorMessage(fmt.Sprintf("The columns of following LockData line do not match the header: \"%s\"", line))
+			addParserError(LOCK_TABLE)
+			continue
+		}
+		if strings.Contains(columns[0], ":") {
+			pidFields := strings.Split(columns[0], ":")
 			entry.ClusterNodeId, err = strconv.Atoi(pidFields[0])
 			if err != nil {
-				logger.WriteErrorWithAddition(err, "while getting LockData ClusterNodeId")
+				writeLineError(LOCK_TABLE, line, err, "while getting LockData ClusterNodeId", logger)
 				continue
 			}
 			entry.PID, err = strconv.Atoi(pidFields[1])
 			if err != nil {
-				logger.WriteErrorWithAddition(err, "while getting LockData PID (ClusterNodeId)")
+				writeLineError(LOCK_TABLE, line, err, "while getting LockData PID (ClusterNodeId)", logger)
 				continue
 			}
 		} else {
 			entry.ClusterNodeId = -1
-			entry.PID, err = strconv.Atoi(oneLineFields[0])
+			entry.PID, err = strconv.Atoi(columns[0])
 			if err != nil {
-				logger.WriteErrorWithAddition(err, "while getting LockData PID")
+				writeLineError(LOCK_TABLE, line, err, "while getting LockData PID", logger)
 				continue
 			}
 		}
-		entry.UserID, err = strconv.Atoi(oneLineFields[1])
+		entry.UserID, err = strconv.Atoi(columns[1])
 		if err != nil {
-			logger.WriteErrorWithAddition(err, "while getting LockData UserID")
+			writeLineError(LOCK_TABLE, line, err, "while getting LockData UserID", logger)
 			continue
 		}
-		entry.DenyMode = oneLineFields[2]
-		entry.Access = oneLineFields[3]
-		entry.AccessMode = oneLineFields[4]
-		entry.Oplock = oneLineFields[5]
-		entry.SharePath = oneLineFields[6]
-		timeConvSuc := false
-		var connectTime time.Time
-		var lastNameIndex = -1
-		timeConvSuc, connectTime = tryGetTimeStampFromStrArr(oneLineFields[fieldLength-5 : fieldLength])
-		if timeConvSuc {
-			entry.Time = connectTime
-			lastNameIndex = fieldLength - 5
-		} else {
-			timeConvSuc, connectTime = tryGetTimeStampFromStrArr(oneLineFields[fieldLength-6 : fieldLength])
-			if timeConvSuc {
-				entry.Time = connectTime
-				lastNameIndex = fieldLength - 6
-			}
+		entry.DenyMode = columns[2]
+		entry.Access = columns[3]
+		entry.AccessMode = columns[4]
+		entry.Oplock = columns[5]
+
+		pathAndName, lockTime, timeConvSuc := splitTimeStampFromEnd(columns[6])
+		if !timeConvSuc {
+			logger.WriteErrorMessage(fmt.Sprintf("Not able to parse the time stamp in following LockData line: \"%s\"", line))
+			addParserError(LOCK_TABLE)
+			continue
 		}
+		entry.Time = lockTime
 
-		if lastNameIndex == -1 {
-			logger.WriteErrorMessage(fmt.Sprintf("Not able to parse the time stamp in following LockData line: \"%s\"", lines[sepLineIndex+1+i]))
+		sharePath, name, nameFound := splitSharePathAndName(pathAndName)
+		if !nameFound {
+			logger.WriteErrorMessage(fmt.Sprintf("Not able to find the name in following LockData line: \"%s\"", line))
+			addParserError(LOCK_TABLE)
 			continue
 		}
+		entry.SharePath = sharePath
+		entry.Name = name
 
-		if lastNameIndex <= 7 {
-			logger.WriteErrorMessage(fmt.Sprintf("Not able to find the name in following LockData line: \"%s\"", lines[sepLineIndex+1+i]))
-			continue
+		logParsedEntry(entry, entry.RawLine, logger)
+		ret = append(ret, entry)
+	}
+	checkScanError(LOCK_TABLE, scanner, logger)
+
+	return ret
+}
+
+// getColumnOffsets - Get the offsets of the columns with the given names in the header line
+func getColumnOffsets(header string, columnNames []string) ([]int, error) {
+	var offsets []int
+	position := 0
+	for _, name := range columnNames {
+		index := strings.Index(header[position:], name)
+		if index < 0 {
+			return nil, fmt.Errorf("The column \"%s\" was not found in the header \"%s\"", name, header)
 		}
+		offsets = append(offsets, position+index)
+		position = position + index + len(name)
+	}
 
-		name := ""
-		for _, namePart := range oneLineFields[7:lastNameIndex] {
-			name = fmt.Sprintf("%s %s", name, namePart)
+	return offsets, nil
+}
+
+// getColumnsByOffset - Split the line at the offsets of the columns and append them to columns. The last column contains the rest of the line.
+// False is returned, if the line is too short or a column does not start at its offset
+func getColumnsByOffset(line string, offsets []int, columns []string) ([]string, bool) {
+	for i, offset := range offsets {
+		if len(line) <= offset || (offset > 0 && line[offset-1] != ' ') {
+			return columns, false
+		}
+		end := len(line)
+		if i+1 < len(offsets) && offsets[i+1] < end {
+			end = offsets[i+1]
+		}
+		column := strings.TrimSpace(line[offset:end])
+		if column == "" || (i+1 < len(offsets) && strings.Contains(column, " ")) {
+			return columns, false
 		}
-		entry.Name = strings.TrimSpace(name)
+		columns = append(columns, column)
+	}
 
-		ret = append(ret, entry)
+	return columns, true
+}
+
+// splitTimeStampFromEnd - Get the time stamp at the end of the text and the text in front of it
+func splitTimeStampFromEnd(text string) (string, time.Time, bool) {
+	fields := strings.Fields(text)
+	for _, timeFields := range []int{5, 6} {
+		if len(fields) <= timeFields {
+			break
+		}
+		timeConvSuc, timeStamp := tryGetTimeStampFromStrArr(fields[len(fields)-timeFields:])
+		if timeConvSuc {
+			return strings.TrimSpace(text[:getFieldStartIndex(text, len(fields)-timeFields)]), timeStamp, true
+		}
 	}
-	return ret
+
+	return "", time.Now(), false
+}
+
+// splitSharePathAndName - Split the text into the share path and the name. smbstatus separates them by 3 spaces,
+// so a single space may be part of the share path. Names can contain any number of spaces
+func splitSharePathAndName(text string) (string, string, bool) {
+	for _, separator := range []string{"   ", "  ", " "} {
+		index := strings.Index(text, separator)
+		if index > 0 {
+			return text[:index], strings.TrimSpace(text[index:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+// getFieldStartIndex - Get the index of the first character of the space separated field with the given number in the text
+func getFieldStartIndex(text string, fieldNumber int) int {
+	field := -1
+	inField := false
+	for i, char := range text {
+		if char == ' ' || char == '\t' {
+			inField = false
+			continue
+		}
+		if !inField {
+			field++
+			inField = true
+			if field == fieldNumber {
+				return i
+			}
+		}
+	}
+
+	return len(text)
 }
 
 // Type to represent a entry in the 'smbstatus -S -n' output table
@@ -152,6 +254,7 @@ type ShareData struct {
 	ConnectedAt   time.Time
 	Encryption    string
 	Signing       string
+	RawLine       string // The line of the smbstatus output the entry was parsed from
 }
 
 // Implement Stringer Interface for ShareData
@@ -167,34 +270,33 @@ func (shareData ShareData) String() string {
 }
 
 // GetShareData - Get the entries out of the 'smbstatus -S -n' output table multiline string
-// Will return an empty array if the data is in unexpected format
+// Will return an empty array if the data is in unexpected format. Lines that can not be parsed are skipped.
+// Skipped lines and tables are counted, see GetParserErrorCount
 func GetShareData(data string, logger commonbl.Logger) []ShareData {
 	var ret []ShareData
+	if isDisabledTable(data, SHARE_TABLE, logger) {
+		return ret
+	}
 
 	if strings.TrimSpace(data) == "" {
 		logger.WriteInformation("Got an empty string from 'smbstatus -S -n'")
 		return ret
 	}
 
-	lines := strings.Split(data, "\n")
-	sepLineIndex := findSeperatorLineIndex(lines)
-
-	if sepLineIndex < 1 {
+	scanner := newLineScanner(data)
+	headerLines, sepFound := scanToSeparatorLine(scanner, 1)
+	if !sepFound || len(headerLines) < 1 {
+		unexpectedTableFormat(SHARE_TABLE, "No separator line after the header found", logger)
 		return ret
 	}
+	header := headerLines[0]
 
-	// Normal setup gives 6 fields in this line
-	tableHeaderMatrix := getFieldMatrixFixLength(lines[sepLineIndex-1:sepLineIndex], "  ", 6)
-
-	if len(tableHeaderMatrix) != 1 {
-		// Cluster setup gives 7 fields in this line
-		tableHeaderMatrix = getFieldMatrixFixLength(lines[sepLineIndex-1:sepLineIndex], "  ", 7)
-
-		if len(tableHeaderMatrix) != 1 {
-			return ret
-		}
+	// Normal setup gives 6 fields in this line, cluster setup gives 7 fields
+	tableHeaderFields := appendFields(nil, header, "  ")
+	if len(tableHeaderFields) != 6 && len(tableHeaderFields) != 7 {
+		unexpectedTableFormat(SHARE_TABLE, fmt.Sprintf("The header \"%s\" has not 6 or 7 columns", header), logger)
+		return ret
 	}
-	tableHeaderFields := tableHeaderMatrix[0]
 	runningMode := "none"
 	if tableHeaderFields[0] == "Service" && tableHeaderFields[3] == "Connected at" {
 		runningMode = "normal"
@@ -204,24 +306,41 @@ func GetShareData(data string, logger commonbl.Logger) []ShareData {
 		runningMode = "cluster"
 	}
 
+	if runningMode == "none" {
+		unexpectedTableFormat(SHARE_TABLE, fmt.Sprintf("Unknown header \"%s\"", header), logger)
+		return ret
+	}
+
+	fieldBuffer := getFieldSlice()
+	defer putFieldSlice(fieldBuffer)
 	if runningMode == "normal" {
-		i := -1
-		for _, oneLineFields := range getFieldMatrix(lines[sepLineIndex+1:], " ") {
-			i++
+		for scanner.Scan() {
+			line := scanner.Text()
+			oneLineFields := appendFields((*fieldBuffer)[:0], line, " ")
+			*fieldBuffer = oneLineFields
 			lastNameField := -1
 			var err error
 			var entry ShareData
+			entry.RawLine = line
 			fieldLength := len(oneLineFields)
+			if fieldLength == 0 {
+				continue
+			}
+			if fieldLength < 11 {
+				logger.WriteErrorMessage(fmt.Sprintf("Not enough fields in following ShareData line: \"%s\"", line))
+				addParserError(SHARE_TABLE)
+				continue
+			}
 			if strings.Contains(oneLineFields[1], ":") {
 				pidFields := strings.Split(oneLineFields[1], ":")
 				entry.ClusterNodeId, err = strconv.Atoi(pidFields[0])
 				if err != nil {
-					logger.WriteErrorWithAddition(err, "while getting ShareData ClusterNodeId (normal with :)")
+					writeLineError(SHARE_TABLE, line, err, "while getting ShareData ClusterNodeId (normal with :)", logger)
 					continue
 				}
 				entry.PID, err = strconv.Atoi(pidFields[1])
 				if err != nil {
-					logger.WriteErrorWithAddition(err, "while getting ShareData PID (normal with :)")
+					writeLineError(SHARE_TABLE, line, err, "while getting ShareData PID (normal with :)", logger)
 					continue
 				}
 			} else {
@@ -236,7 +355,7 @@ func GetShareData(data string, logger commonbl.Logger) []ShareData {
 						break
 					}
 					if len(oneLineFields)-11 <= lastNameField {
-						logger.WriteErrorWithAddition(err, "while getting ShareData PID (normal without :)")
+						writeLineError(SHARE_TABLE, line, err, "while getting ShareData PID (normal without :)", logger)
 						pidFound = false
 						break
 					}
@@ -264,43 +383,51 @@ func GetShareData(data string, logger commonbl.Logger) []ShareData {
 			}
 
 			if lastTimeIndex == -1 {
-				logger.WriteErrorMessage(fmt.Sprintf("Not able to parse the time stamp in following ShareData line: \"%s\"", lines[sepLineIndex+1+i]))
+				logger.WriteErrorMessage(fmt.Sprintf("Not able to parse the time stamp in following ShareData line: \"%s\"", line))
+				addParserError(SHARE_TABLE)
 				continue
 			}
 			if lastTimeIndex != fieldLength-3 {
-				logger.WriteErrorMessage(fmt.Sprintf("Can not find end of time stamp in following ShareData line: \"%s\"", lines[sepLineIndex+1+i]))
+				logger.WriteErrorMessage(fmt.Sprintf("Can not find end of time stamp in following ShareData line: \"%s\"", line))
+				addParserError(SHARE_TABLE)
 				continue
 			}
 			entry.Encryption = oneLineFields[lastTimeIndex+1]
 			entry.Signing = oneLineFields[lastTimeIndex+2]
 
+			logParsedEntry(entry, entry.RawLine, logger)
 			ret = append(ret, entry)
 		}
 
 	} else if runningMode == "cluster" {
-		i := -1
-		for _, oneLineFields := range getFieldMatrix(lines[sepLineIndex+1:], " ") {
-			i++
+		for scanner.Scan() {
+			line := scanner.Text()
+			oneLineFields := appendFields((*fieldBuffer)[:0], line, " ")
+			*fieldBuffer = oneLineFields
 			var err error
 			var entry ShareData
+			entry.RawLine = line
 			fieldLength := len(oneLineFields)
+			if fieldLength == 0 {
+				continue
+			}
 			if strings.Contains(oneLineFields[0], ":") {
 				pidFields := strings.Split(oneLineFields[0], ":")
 				entry.ClusterNodeId, err = strconv.Atoi(pidFields[0])
 				if err != nil {
-					logger.WriteErrorWithAddition(err, "while getting ShareData ClusterNodeId (cluster - with :)")
+					writeLineError(SHARE_TABLE, line, err, "while getting ShareData ClusterNodeId (cluster - with :)", logger)
 					continue
 				}
 				entry.PID, err = strconv.Atoi(pidFields[1])
 				if err != nil {
-					logger.WriteErrorWithAddition(err, "while getting ShareData PID (cluster - with :)")
+					writeLineError(SHARE_TABLE, line, err, "while getting ShareData PID (cluster - with :)", logger)
 					continue
 				}
 			} else {
 				entry.ClusterNodeId = -1
 				entry.PID, err = strconv.Atoi(oneLineFields[0])
 				if err != nil {
-					logger.WriteErrorWithAddition(err, "while getting ShareData PID (cluster - without :)")
+					writeLineError(SHARE_TABLE, line, err, "while getting ShareData PID (cluster - without :)", logger)
 					continue
 				}
 			}
@@ -314,13 +441,16 @@ func GetShareData(data string, logger commonbl.Logger) []ShareData {
 				entry.Encryption = oneLineFields[5]
 				entry.Signing = oneLineFields[6]
 			} else {
-				logger.WriteErrorMessage(fmt.Sprintf("Can not parse the following ShareData line: \"%s\"", lines[i]))
+				logger.WriteErrorMessage(fmt.Sprintf("Can not parse the following ShareData line: \"%s\"", line))
+				addParserError(SHARE_TABLE)
 				continue
 			}
 
+			logParsedEntry(entry, entry.RawLine, logger)
 			ret = append(ret, entry)
 		}
 	}
+	checkScanError(SHARE_TABLE, scanner, logger)
 
 	return ret
 }
@@ -336,6 +466,7 @@ type ProcessData struct {
 	Encryption      string
 	Signing         string
 	SambaVersion    string
+	RawLine         string // The line of the smbstatus output the entry was parsed from
 }
 
 // Implement Stringer Interface for ProcessData
@@ -351,64 +482,83 @@ func (processData ProcessData) String() string {
 }
 
 // GetProcessData - Get the entries out of the 'smbstatus -p -n' output table multiline string
-// Will return an empty array if the data is in unexpected format
+// Will return an empty array if the data is in unexpected format. Lines that can not be parsed are skipped.
+// Skipped lines and tables are counted, see GetParserErrorCount
 func GetProcessData(data string, logger commonbl.Logger) []ProcessData {
 	var ret []ProcessData
+	if isDisabledTable(data, PROCESS_TABLE, logger) {
+		return ret
+	}
 
 	if strings.TrimSpace(data) == "" {
 		logger.WriteInformation("Got an empty string from 'smbstatus -p -n'")
 		return ret
 	}
 
-	lines := strings.Split(data, "\n")
-	sepLineIndex := findSeperatorLineIndex(lines)
-
-	if sepLineIndex < 2 {
+	scanner := newLineScanner(data)
+	headerLines, sepFound := scanToSeparatorLine(scanner, 2)
+	if !sepFound || len(headerLines) < 2 {
+		unexpectedTableFormat(PROCESS_TABLE, "No separator line after the header found", logger)
 		return ret
 	}
+	header := headerLines[1]
 
 	var sambaVersion string
-	sambaVersionLine := lines[sepLineIndex-2 : sepLineIndex-1][0]
+	sambaVersionLine := headerLines[0]
 	if strings.HasPrefix(sambaVersionLine, "Samba version") {
 		sambaVersion = strings.TrimSpace(strings.Replace(sambaVersionLine, "Samba version", "", 1))
 	} else {
+		unexpectedTableFormat(PROCESS_TABLE, fmt.Sprintf("No samba version found in \"%s\"", sambaVersionLine), logger)
 		return ret
 	}
 
-	tableHeaderMatrix := getFieldMatrixFixLength(lines[sepLineIndex-1:sepLineIndex], "  ", 7)
-	if len(tableHeaderMatrix) != 1 {
+	tableHeaderFields := appendFields(nil, header, "  ")
+	if len(tableHeaderFields) != 7 {
+		unexpectedTableFormat(PROCESS_TABLE, fmt.Sprintf("The header \"%s\" has not 7 columns", header), logger)
 		return ret
 	}
-	tableHeaderFields := tableHeaderMatrix[0]
 
 	if tableHeaderFields[1] != "Username" || tableHeaderFields[4] != "Protocol Version" {
+		unexpectedTableFormat(PROCESS_TABLE, fmt.Sprintf("Unknown header \"%s\"", header), logger)
 		return ret
 	}
 
-	i := -1
-	for _, oneLineFields := range getFieldMatrix(lines[sepLineIndex+1:], " ") {
-		i++
+	fieldBuffer := getFieldSlice()
+	defer putFieldSlice(fieldBuffer)
+	for scanner.Scan() {
+		line := scanner.Text()
+		oneLineFields := appendFields((*fieldBuffer)[:0], line, " ")
+		*fieldBuffer = oneLineFields
 		var err error
 		var entry ProcessData
+		entry.RawLine = line
 		fieldLength := len(oneLineFields)
+		if fieldLength == 0 {
+			continue
+		}
+		if fieldLength < 7 {
+			logger.WriteErrorMessage(fmt.Sprintf("Not enough fields in following ProcessData line: \"%s\"", line))
+			addParserError(PROCESS_TABLE)
+			continue
+		}
 		// In cluster versions samba adds an extra id separated by ':'
 		if strings.Contains(oneLineFields[0], ":") {
 			pidFields := strings.Split(oneLineFields[0], ":")
 			entry.ClusterNodeId, err = strconv.Atoi(pidFields[0])
 			if err != nil {
-				logger.WriteErrorWithAddition(err, "while getting ProcessData ClusterNodeId")
+				writeLineError(PROCESS_TABLE, line, err, "while getting ProcessData ClusterNodeId", logger)
 				continue
 			}
 			entry.PID, err = strconv.Atoi(pidFields[1])
 			if err != nil {
-				logger.WriteErrorWithAddition(err, "while getting ProcessData PID (with :)")
+				writeLineError(PROCESS_TABLE, line, err, "while getting ProcessData PID (with :)", logger)
 				continue
 			}
 		} else {
 			entry.ClusterNodeId = -1
 			entry.PID, err = strconv.Atoi(oneLineFields[0])
 			if err != nil {
-				logger.WriteErrorWithAddition(err, "while getting ProcessData PID (without :)")
+				writeLineError(PROCESS_TABLE, line, err, "while getting ProcessData PID (without :)", logger)
 				continue
 			}
 		}
@@ -418,7 +568,7 @@ func GetProcessData(data string, logger commonbl.Logger) []ProcessData {
 		} else {
 			entry.UserID, err = strconv.Atoi(oneLineFields[1])
 			if err != nil {
-				logger.WriteErrorWithAddition(err, "while getting ProcessData UserID")
+				writeLineError(PROCESS_TABLE, line, err, "while getting ProcessData UserID", logger)
 				continue
 			}
 		}
@@ -428,7 +578,7 @@ func GetProcessData(data string, logger commonbl.Logger) []ProcessData {
 		} else {
 			entry.GroupID, err = strconv.Atoi(oneLineFields[2])
 			if err != nil {
-				logger.WriteErrorWithAddition(err, "while getting ProcessData GroupID")
+				writeLineError(PROCESS_TABLE, line, err, "while getting ProcessData GroupID", logger)
 				continue
 			}
 		}
@@ -443,20 +593,29 @@ func GetProcessData(data string, logger commonbl.Logger) []ProcessData {
 			entry.Encryption = oneLineFields[5]
 			entry.Signing = oneLineFields[6]
 		} else {
-			logger.WriteErrorMessage(fmt.Sprintf("Can not parse the following ProcessData line: \"%s\"", lines[i]))
+			logger.WriteErrorMessage(fmt.Sprintf("Can not parse the following ProcessData line: \"%s\"", line))
+			addParserError(PROCESS_TABLE)
 			continue
 		}
 		entry.SambaVersion = sambaVersion
 
+		logParsedEntry(entry, entry.RawLine, logger)
 		ret = append(ret, entry)
 	}
+	checkScanError(PROCESS_TABLE, scanner, logger)
+
 	return ret
 }
 
 func GetPsData(data string, logger commonbl.Logger) []commonbl.PsUtilPidData {
 	var ret []commonbl.PsUtilPidData
+	if message, isError := commonbl.ParseErrorResponseData(data); isError {
+		logger.WriteErrorMessage(fmt.Sprintf("samba_statusd could not get the ps data: %s", message))
+		return []commonbl.PsUtilPidData{}
+	}
 	errConv := json.Unmarshal([]byte(data), &ret)
 	if errConv != nil {
+		addParserError(PS_TABLE)
 		logger.WriteErrorWithAddition(errConv, "while converting PsData json")
 		return []commonbl.PsUtilPidData{}
 	}
@@ -464,94 +623,117 @@ func GetPsData(data string, logger commonbl.Logger) []commonbl.PsUtilPidData {
 	return ret
 }
 
-func getFieldMatrixFixLength(dataLines []string, separator string, lineFields int) [][]string {
-
-	var fieldMatrix [][]string
-
-	for _, matrixLine := range getFieldMatrix(dataLines, separator) {
-		if len(matrixLine) == lineFields {
-			fieldMatrix = append(fieldMatrix, matrixLine)
-		}
+// isDisabledTable - Tells if samba_statusd answered, that the collector of the table is disabled. The table is empty then, without a parser error
+func isDisabledTable(data string, table string, logger commonbl.Logger) bool {
+	if strings.TrimSpace(data) != commonbl.DISABLED_RESPONSE_DATA {
+		return false
 	}
+	logger.WriteVerbose(fmt.Sprintf("The %s collector is disabled in samba_statusd", table))
 
-	return fieldMatrix
+	return true
 }
 
-func getFieldMatrix(dataLines []string, separator string) [][]string {
+// ReadJsonList - Read the JSON list samba_statusd sent as response to the request into the list, e. g. the cgroups of the CGROUP_REQUEST.
+// Returns false and logs the error, when the data can not be read
+func ReadJsonList(data string, request commonbl.RequestType, list interface{}, logger commonbl.Logger) bool {
+	errConv := json.Unmarshal([]byte(data), list)
+	if errConv != nil {
+		logger.WriteErrorWithAddition(errConv, fmt.Sprintf("while converting the json of the \"%s\" response", strings.TrimSuffix(string(request), ":")))
+		return false
+	}
 
-	var fieldMatrix [][]string
+	return true
+}
 
-	for _, line := range dataLines {
-		fields := strings.Split(line, separator)
-		var matrixLine []string
-		for _, field := range fields {
-			trimmedField := strings.TrimSpace(field)
-			if trimmedField != "" {
-				matrixLine = append(matrixLine, trimmedField)
-			}
+// appendFields - Append the not empty fields of the line separated by the separator to fields. Pass a field slice of
+// the previous line with length 0 to reuse it, so no new slice is allocated for each line
+func appendFields(fields []string, line string, separator string) []string {
+	for line != "" {
+		field, rest, _ := strings.Cut(line, separator)
+		trimmedField := strings.TrimSpace(field)
+		if trimmedField != "" {
+			fields = append(fields, trimmedField)
 		}
-		fieldMatrix = append(fieldMatrix, matrixLine)
+		line = rest
 	}
 
-	return fieldMatrix
+	return fields
 }
 
 func concatStrFromArr(fields []string) string {
-	ret := ""
+	buffer := getBuffer()
+	defer putBuffer(buffer)
 	for i, field := range fields {
-		if i == 0 {
-			ret = field
-		} else {
-			ret = ret + " " + field
+		if i > 0 {
+			buffer.WriteByte(' ')
 		}
+		buffer.WriteString(field)
 	}
 
-	return ret
+	return buffer.String()
 }
 
 func tryGetTimeStampFromStrArr(fields []string) (bool, time.Time) {
-	timeStr := ""
-	var ret time.Time
-	var err error
-	for _, sec := range fields {
-		timeStr = fmt.Sprintf("%s %s", timeStr, sec)
-	}
-	timeStr = strings.TrimSpace(timeStr)
-	ret, err = time.ParseInLocation(time.ANSIC, timeStr, time.Now().Location())
-	if err == nil {
-		return true, ret
-	}
-	ret, err = time.Parse(time.ANSIC, timeStr)
-	if err == nil {
-		return true, ret
-	}
-	ret, err = time.Parse("Mon Jan 02 03:04:05 PM 2006 MST", timeStr)
-	if err == nil {
-		return true, ret
-	}
-	ret, err = time.Parse("Mon Jan 2 03:04:05 PM 2006 MST", timeStr)
-	if err == nil {
-		return true, ret
-	}
-	ret, err = time.Parse("Mon Jan _2 15:04:05 2006 MST", timeStr)
-	if err == nil {
-		return true, ret
+	timeStr := strings.TrimSpace(concatStrFromArr(fields))
+
+	found, ret := parseTimeStamp(timeStr, GetSambaTimezone())
+	if !found {
+		return false, time.Now()
 	}
-	ret, err = time.Parse("Mo Jan _2 15:04:05 2006 MST", timeStr)
-	if err == nil {
-		return true, ret
+
+	return true, ret.UTC()
+}
+
+// parseTimeStamp - Parse the time stamp in the given location. Time stamps without zone are in the location,
+// zone abbreviations are looked up in it
+func parseTimeStamp(timeStr string, location *time.Location) (bool, time.Time) {
+	for _, layout := range []string{time.ANSIC,
+		"Mon Jan 02 03:04:05 PM 2006 MST",
+		"Mon Jan 2 03:04:05 PM 2006 MST",
+		"Mon Jan _2 15:04:05 2006 MST",
+		"Mo Jan _2 15:04:05 2006 MST"} {
+		ret, err := time.ParseInLocation(layout, timeStr, location)
+		if err == nil {
+			return true, ret
+		}
 	}
 
-	return false, time.Now()
+	return tryGetLocalizedTimeStamp(timeStr, location)
 }
 
-func findSeperatorLineIndex(lines []string) int {
+// The maximal length of a line of the smbstatus output, e. g. a lock of a file with a very long path
+const maxLineLength = 1024 * 1024
+
+// newLineScanner - Get a scanner reading the smbstatus output line by line, so the output is not split into lines at once
+func newLineScanner(data string) *bufio.Scanner {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
 
-	for i, line := range lines {
+	return scanner
+}
+
+// scanToSeparatorLine - Read the lines up to the separator line below the table header. Get the headerLines lines in front of the separator line,
+// there are less when the separator line is one of the first lines. False is returned, when no separator line is found
+func scanToSeparatorLine(scanner *bufio.Scanner, headerLines int) ([]string, bool) {
+	lines := make([]string, 0, headerLines)
+	for scanner.Scan() {
+		line := scanner.Text()
 		if strings.HasPrefix(line, "-----------------------------------------") {
-			return i
+			return lines, true
+		}
+		if len(lines) == headerLines {
+			lines = append(lines[:0], lines[1:]...)
 		}
+		lines = append(lines, line)
 	}
 
-	return -1
+	return lines, false
+}
+
+// checkScanError - Count a parser error for the table, when the scanner stopped before the end of the smbstatus output
+func checkScanError(table string, scanner *bufio.Scanner, logger commonbl.Logger) {
+	if errScan := scanner.Err(); errScan != nil {
+		logger.WriteErrorWithAddition(errScan, fmt.Sprintf("while reading the %s table", table))
+		addParserError(table)
+	}
 }
@@ -156,7 +156,7 @@ func TestGetLockDataOneLine(t *testing.T) {
 
 	expectDate, _ := time.ParseInLocation(time.ANSIC, "Sun May 16 12:07:02 2021", time.Now().Location())
 
-	if oneEntry[0].Time != expectDate {
+	if oneEntry[0].Time != expectDate.UTC() {
 		t.Errorf("The Time %s is not the expected Sun May 16 12:07:02 2021", oneEntry[0].Time)
 	}
 
@@ -217,6 +217,82 @@ func TestGetLockDataFileNameWithSpaces(t *testing.T) {
 	}
 }
 
+func TestGetLockDataMultipleSpaces(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	entryList := GetLockData(smbstatusout.LockData2LinesWithMultipleSpaces, logger)
+
+	if len(entryList) != 2 {
+		t.Fatalf("Got %d entries, expected 2", len(entryList))
+	}
+
+	if entryList[0].SharePath != "/srv/shares/my share" {
+		t.Errorf("The SharePath '%s' is not the expected '/srv/shares/my share'", entryList[0].SharePath)
+	}
+
+	if entryList[0].Name != "my  test   file.txt" {
+		t.Errorf("The Name '%s' is not the expected 'my  test   file.txt'", entryList[0].Name)
+	}
+
+	if entryList[1].DenyMode != "DENY_WRITE" || entryList[1].AccessMode != "RDWR" || entryList[1].Oplock != "NONE" {
+		t.Errorf("The DenyMode '%s', AccessMode '%s' or Oplock '%s' is not the expected", entryList[1].DenyMode, entryList[1].AccessMode, entryList[1].Oplock)
+	}
+
+	if entryList[1].Name != "report  2024.ods" {
+		t.Errorf("The Name '%s' is not the expected 'report  2024.ods'", entryList[1].Name)
+	}
+
+	if entryList[1].Time.Format(time.ANSIC) != "Tue Jan  2 14:15:21 2024" {
+		t.Errorf("The time %s is not expected", entryList[1].Time.Format(time.ANSIC))
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("The ErrorCount '%d' is not the expected '0'", logger.GetErrorCount())
+	}
+}
+
+func TestGetLockDataNotAligned(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	entryList := GetLockData(smbstatusout.LockDataNotAligned, logger)
+
+	if len(entryList) != 1 {
+		t.Errorf("Got %d entries, expected 1", len(entryList))
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+}
+
+func TestGetColumnsByOffset(t *testing.T) {
+	offsets, err := getColumnOffsets("Pid   User   Name", []string{"Pid", "User", "Name"})
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err)
+	}
+	if len(offsets) != 3 || offsets[0] != 0 || offsets[1] != 6 || offsets[2] != 13 {
+		t.Errorf("Got the offsets '%v' but expected '[0 6 13]'", offsets)
+	}
+
+	columns, found := getColumnsByOffset("12    1000   my  file.txt", offsets, nil)
+	if !found {
+		t.Fatalf("The columns were not found")
+	}
+	if columns[0] != "12" || columns[1] != "1000" || columns[2] != "my  file.txt" {
+		t.Errorf("Got the columns '%v' but expected '[12 1000 my  file.txt]'", columns)
+	}
+
+	for _, line := range []string{"12    1000", "12 1000   my  file.txt", "12    10 0   name"} {
+		_, found = getColumnsByOffset(line, offsets, nil)
+		if found {
+			t.Errorf("Got columns for the line '%s' but expected none", line)
+		}
+	}
+
+	_, err = getColumnOffsets("Pid   User", []string{"Pid", "User", "Name"})
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the header has no Name column")
+	}
+}
+
 func TestGetLockDataInvalidResponse(t *testing.T) {
 	logger := testhelper.NewTestLogger(true)
 	entryList := GetLockData(smbstatusout.LockDataInvadlidResponse, logger)
@@ -242,6 +318,37 @@ func TestGetLockDataInvalidResponse(t *testing.T) {
 	}
 }
 
+func TestGetLockDataLineTooLong(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	errorsBefore := GetParserErrorCount(LOCK_TABLE)
+	data := smbstatusout.LockData4Lines + "\n" + strings.Repeat("x", maxLineLength+1)
+	entryList := GetLockData(data, logger)
+
+	if len(entryList) != 4 {
+		t.Errorf("Got %d entries, expected 4", len(entryList))
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+
+	if GetParserErrorCount(LOCK_TABLE) != errorsBefore+1 {
+		t.Errorf("The parser error count '%d' is not the expected '%d'", GetParserErrorCount(LOCK_TABLE), errorsBefore+1)
+	}
+}
+
+func BenchmarkGetLockData(b *testing.B) {
+	logger := testhelper.NewTestLogger(false)
+	lines := strings.Split(smbstatusout.LockData4Lines, "\n")
+	data := strings.Join(lines[:3], "\n") + strings.Repeat("\n"+strings.Join(lines[3:], "\n"), 2500)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GetLockData(data, logger)
+	}
+}
+
 func TestGetLockDataCluster(t *testing.T) {
 	logger := testhelper.NewTestLogger(true)
 	entryList := GetLockData(smbstatusout.LockDataCluster, logger)
@@ -749,6 +856,170 @@ func TestGetPsDataTwoPids(t *testing.T) {
 	}
 }
 
+func TestGetDataDisabled(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	parserErrors := GetParserErrorCount(LOCK_TABLE) + GetParserErrorCount(SHARE_TABLE) + GetParserErrorCount(PROCESS_TABLE)
+
+	if locks := GetLockData(commonbl.DISABLED_RESPONSE_DATA, logger); len(locks) != 0 {
+		t.Errorf("Got %d locks for a disabled collector", len(locks))
+	}
+	if shares := GetShareData(commonbl.DISABLED_RESPONSE_DATA+"\n", logger); len(shares) != 0 {
+		t.Errorf("Got %d shares for a disabled collector", len(shares))
+	}
+	if processes := GetProcessData(commonbl.DISABLED_RESPONSE_DATA, logger); len(processes) != 0 {
+		t.Errorf("Got %d processes for a disabled collector", len(processes))
+	}
+
+	if logger.GetErrorCount() != 0 {
+		t.Errorf("The ErrorCount '%d' is not the expected '0'", logger.GetErrorCount())
+	}
+	if GetParserErrorCount(LOCK_TABLE)+GetParserErrorCount(SHARE_TABLE)+GetParserErrorCount(PROCESS_TABLE) != parserErrors {
+		t.Errorf("The responses of disabled collectors are counted as parser errors")
+	}
+}
+
+func TestGetPsDataErrorResponse(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	entryList := GetPsData(commonbl.GetErrorResponseData("permission denied"), logger)
+
+	if len(entryList) != 0 {
+		t.Errorf("Got entries when reading an error response")
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+
+	if logger.WrittenErrors[0] != "Error: samba_statusd could not get the ps data: permission denied" {
+		t.Errorf("The error message '%s' is not the expected 'Error: samba_statusd could not get the ps data: permission denied'", logger.WrittenErrors[0])
+	}
+}
+
+func TestReadJsonListPluginData(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	data := `[{"plugin": "quota", "duration_seconds": 0.2, "metrics": [{"name": "quota_used_bytes", "help": "Bytes used", "type": "gauge", "labels": {"share": "data"}, "value": 42}]},
+		{"plugin": "backup", "duration_seconds": 10, "error": "the plugin did not finish within 10s", "metrics": []}]`
+	var entryList []commonbl.PluginResult
+	if !ReadJsonList(data, commonbl.PLUGIN_REQUEST, &entryList, logger) {
+		t.Fatalf("Could not read the json list")
+	}
+
+	if len(entryList) != 2 {
+		t.Fatalf("Got %d entries but expected 2", len(entryList))
+	}
+
+	if entryList[0].Plugin != "quota" || len(entryList[0].Metrics) != 1 || entryList[0].Metrics[0].Labels["share"] != "data" {
+		t.Errorf("The entry '%v' is not expected", entryList[0])
+	}
+
+	if entryList[1].Error == "" {
+		t.Errorf("The entry '%v' has no error", entryList[1])
+	}
+
+	if ReadJsonList("no json", commonbl.PLUGIN_REQUEST, &entryList, logger) {
+		t.Errorf("Got no error when reading wrong input")
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+}
+
+func TestReadJsonListCgroupData(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	data := `[{"path": "system.slice/smbd.service", "cpu_usage_seconds": 12.5, "memory_usage_bytes": 1024, "io_read_bytes": 512, "task_count": 3}]`
+	var entryList []commonbl.CgroupData
+	if !ReadJsonList(data, commonbl.CGROUP_REQUEST, &entryList, logger) {
+		t.Fatalf("Could not read the json list")
+	}
+
+	if len(entryList) != 1 {
+		t.Fatalf("Got %d entries but expected 1", len(entryList))
+	}
+
+	if entryList[0].Path != "system.slice/smbd.service" || entryList[0].CpuUsageSeconds != 12.5 || entryList[0].MemoryUsageBytes != 1024 ||
+		entryList[0].IoReadBytes != 512 || entryList[0].TaskCount != 3 {
+		t.Errorf("The entry '%v' is not expected", entryList[0])
+	}
+
+	if ReadJsonList("no json", commonbl.CGROUP_REQUEST, &entryList, logger) {
+		t.Errorf("Got no error when reading wrong input")
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+}
+
+func TestReadJsonListCtdbNodes(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	var entryList []commonbl.CtdbNode
+	if !ReadJsonList(`[{"node": 0, "address": "192.168.1.10"}, {"node": 2, "address": "fd00::12"}]`, commonbl.CTDB_NODES_REQUEST, &entryList, logger) {
+		t.Fatalf("Could not read the json list")
+	}
+
+	if len(entryList) != 2 {
+		t.Fatalf("Got %d entries but expected 2", len(entryList))
+	}
+	if entryList[1].Node != 2 || entryList[1].Address != "fd00::12" {
+		t.Errorf("The entry '%v' is not expected", entryList[1])
+	}
+
+	if ReadJsonList("no json", commonbl.CTDB_NODES_REQUEST, &entryList, logger) {
+		t.Errorf("Got no error when reading wrong input")
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+}
+
+func TestReadJsonListShareConfig(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	var entryList []commonbl.ShareConfig
+	if !ReadJsonList(`[{"name": "data", "parameters": {"path": "/srv/data", "guest ok": "Yes"}}]`, commonbl.SHARE_CONFIG_REQUEST, &entryList, logger) {
+		t.Fatalf("Could not read the json list")
+	}
+
+	if len(entryList) != 1 {
+		t.Fatalf("Got %d entries but expected 1", len(entryList))
+	}
+	if entryList[0].Name != "data" || entryList[0].Parameters["guest ok"] != "Yes" {
+		t.Errorf("The entry '%v' is not expected", entryList[0])
+	}
+
+	if ReadJsonList("no json", commonbl.SHARE_CONFIG_REQUEST, &entryList, logger) {
+		t.Errorf("Got no error when reading wrong input")
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+}
+
+func TestReadJsonListShareHandles(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	var entryList []commonbl.ShareHandles
+	if !ReadJsonList(`[{"share": "/srv/data", "open_count": 4, "durable_count": 3, "persistent_count": 1}]`, commonbl.SHARE_HANDLES_REQUEST, &entryList, logger) {
+		t.Fatalf("Could not read the json list")
+	}
+
+	if len(entryList) != 1 {
+		t.Fatalf("Got %d entries but expected 1", len(entryList))
+	}
+	if entryList[0].Share != "/srv/data" || entryList[0].OpenCount != 4 || entryList[0].DurableCount != 3 || entryList[0].PersistentCount != 1 {
+		t.Errorf("The entry '%v' is not expected", entryList[0])
+	}
+
+	if ReadJsonList("no json", commonbl.SHARE_HANDLES_REQUEST, &entryList, logger) {
+		t.Errorf("Got no error when reading wrong input")
+	}
+
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("The ErrorCount '%d' is not the expected '1'", logger.GetErrorCount())
+	}
+}
+
 func TestTryGetTimeStampFromStrArr(t *testing.T) {
 	var suc bool
 	var value time.Time
@@ -0,0 +1,121 @@
+package smbstatusreader
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// The layouts of time stamps printed by smbstatus with a non english locale, after the day and month names are translated
+var localizedTimeLayouts = []string{
+	"Mon _2 Jan 2006 15:04:05 MST",
+	"Mon 02 Jan 2006 15:04:05 MST",
+	"Mon _2 Jan 2006 15:04:05",
+	"Mon 02 Jan 2006 15:04:05",
+	"Mon _2. Jan 2006 15:04:05 MST",
+	"Mon _2. Jan 2006 15:04:05",
+}
+
+// The german and french names of days and months, as printed by smbstatus, with their english abbreviations
+var timeNameTranslations = map[string]string{
+	// German days
+	"mo": "Mon", "di": "Tue", "mi": "Wed", "do": "Thu", "fr": "Fri", "sa": "Sat", "so": "Sun",
+	"montag": "Mon", "dienstag": "Tue", "mittwoch": "Wed", "donnerstag": "Thu", "freitag": "Fri", "samstag": "Sat", "sonntag": "Sun",
+	// German months
+	"jan": "Jan", "feb": "Feb", "mär": "Mar", "apr": "Apr", "mai": "May", "jun": "Jun",
+	"jul": "Jul", "aug": "Aug", "sep": "Sep", "okt": "Oct", "nov": "Nov", "dez": "Dec",
+	"januar": "Jan", "februar": "Feb", "märz": "Mar", "april": "Apr", "juni": "Jun",
+	"juli": "Jul", "august": "Aug", "september": "Sep", "oktober": "Oct", "november": "Nov", "dezember": "Dec",
+	// French days, the abbreviations end with a dot
+	"lun.": "Mon", "mar.": "Tue", "mer.": "Wed", "jeu.": "Thu", "ven.": "Fri", "sam.": "Sat", "dim.": "Sun",
+	"lundi": "Mon", "mardi": "Tue", "mercredi": "Wed", "jeudi": "Thu", "vendredi": "Fri", "samedi": "Sat", "dimanche": "Sun",
+	// French months
+	"janv.": "Jan", "févr.": "Feb", "mars": "Mar", "avr.": "Apr", "avril": "Apr", "juin": "Jun", "juil.": "Jul",
+	"août": "Aug", "sept.": "Sep", "oct.": "Oct", "nov.": "Nov", "déc.": "Dec",
+	"janvier": "Jan", "février": "Feb", "juillet": "Jul", "septembre": "Sep", "octobre": "Oct", "novembre": "Nov", "décembre": "Dec",
+}
+
+var sambaTimezone *time.Location
+var sambaTimezoneMux sync.RWMutex
+
+var additionalTimeLayouts []string
+var additionalTimeLayoutsMux sync.RWMutex
+
+// SetAdditionalTimeLayouts - Set the layouts, in the format of the time package, used for time stamps the exporter can not parse otherwise.
+// The layouts are tried with the time stamp as printed by smbstatus, and with the german and french day and month names translated to english
+func SetAdditionalTimeLayouts(layouts []string) {
+	additionalTimeLayoutsMux.Lock()
+	defer additionalTimeLayoutsMux.Unlock()
+
+	additionalTimeLayouts = append([]string{}, layouts...)
+}
+
+func getAdditionalTimeLayouts() []string {
+	additionalTimeLayoutsMux.RLock()
+	defer additionalTimeLayoutsMux.RUnlock()
+
+	return additionalTimeLayouts
+}
+
+// SetSambaTimezone - Set the timezone of the samba server. Time stamps without zone are read in this timezone,
+// zone abbreviations like 'CET' are looked up in it. When nil, the local timezone is used
+func SetSambaTimezone(location *time.Location) {
+	sambaTimezoneMux.Lock()
+	defer sambaTimezoneMux.Unlock()
+
+	sambaTimezone = location
+}
+
+// GetSambaTimezone - Get the timezone the time stamps of the samba server are read in
+func GetSambaTimezone() *time.Location {
+	sambaTimezoneMux.RLock()
+	defer sambaTimezoneMux.RUnlock()
+
+	if sambaTimezone == nil {
+		return time.Local
+	}
+
+	return sambaTimezone
+}
+
+// translateTimeNames - Replace the german and french day and month names in the time stamp with the english abbreviations.
+// A ',' following a name is kept
+func translateTimeNames(timeStr string) string {
+	var fields []string
+	for _, field := range strings.Fields(timeStr) {
+		name := strings.TrimSuffix(field, ",")
+		translated, found := timeNameTranslations[strings.ToLower(name)]
+		if found {
+			field = translated + strings.TrimPrefix(field, name)
+		}
+		fields = append(fields, field)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// tryGetLocalizedTimeStamp - Try to parse the time stamp with the additional layouts, and with the layouts of non english locales after translating the names
+func tryGetLocalizedTimeStamp(timeStr string, location *time.Location) (bool, time.Time) {
+	additionalLayouts := getAdditionalTimeLayouts()
+	for _, layout := range additionalLayouts {
+		ret, err := time.ParseInLocation(layout, timeStr, location)
+		if err == nil {
+			return true, ret
+		}
+	}
+
+	translated := translateTimeNames(timeStr)
+	for _, layout := range append(additionalLayouts, localizedTimeLayouts...) {
+		ret, err := time.ParseInLocation(layout, translated, location)
+		if err == nil {
+			return true, ret
+		}
+	}
+
+	return false, time.Now()
+}
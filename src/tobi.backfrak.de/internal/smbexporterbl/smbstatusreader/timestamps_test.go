@@ -0,0 +1,153 @@
+package smbstatusreader
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+const shareDataGerman = `
+Service      pid     Machine       Connected at                     Encryption   Signing     
+---------------------------------------------------------------------------------------------
+IPC$         1119    192.168.1.242  So 16 Mai 2021 11:55:36 CEST     -            -           `
+
+const shareDataFrench = `
+Service      pid     Machine       Connected at                     Encryption   Signing     
+---------------------------------------------------------------------------------------------
+IPC$         1119    192.168.1.242  dim. 16 mai 2021 11:55:36 CEST   -            -           `
+
+func TestTranslateTimeNames(t *testing.T) {
+	translations := map[string]string{
+		"So 16 Mai 2021 11:55:36 CEST":        "Sun 16 May 2021 11:55:36 CEST",
+		"Di 02 Mär 2021 08:01:02 CET":         "Tue 02 Mar 2021 08:01:02 CET",
+		"dim. 16 mai 2021 11:55:36 CEST":      "Sun 16 May 2021 11:55:36 CEST",
+		"mar. 14 déc. 2021 11:55:36 CET":      "Tue 14 Dec 2021 11:55:36 CET",
+		"Sun May 16 12:07:02 2021":            "Sun May 16 12:07:02 2021",
+		"Donnerstag 1. Oktober 2020 10:00:00": "Thu 1. Oct 2020 10:00:00",
+		"Sonntag, 16. Mai 2021 11:55:36":      "Sun, 16. May 2021 11:55:36",
+	}
+
+	for timeStr, expected := range translations {
+		translated := translateTimeNames(timeStr)
+		if translated != expected {
+			t.Errorf("Got '%s' for '%s' but expected '%s'", translated, timeStr, expected)
+		}
+	}
+}
+
+func TestTryGetLocalizedTimeStamp(t *testing.T) {
+	for _, timeStr := range []string{"So 16 Mai 2021 11:55:36 CEST", "dim. 16 mai 2021 11:55:36 CEST", "Donnerstag 1. Oktober 2020 10:00:00"} {
+		suc, value := tryGetLocalizedTimeStamp(timeStr, time.Local)
+		if !suc {
+			t.Errorf("Got no time from '%s'", timeStr)
+		}
+		if value.Year() != 2021 && value.Year() != 2020 {
+			t.Errorf("Got the year %d from '%s'", value.Year(), timeStr)
+		}
+	}
+
+	suc, _ := tryGetLocalizedTimeStamp("no time stamp", time.Local)
+	if suc {
+		t.Errorf("Got a time from 'no time stamp'")
+	}
+}
+
+func TestSetAdditionalTimeLayouts(t *testing.T) {
+	defer SetAdditionalTimeLayouts(nil)
+	timeStr := "2021-05-16 11:55:36"
+
+	suc, _ := tryGetLocalizedTimeStamp(timeStr, time.Local)
+	if suc {
+		t.Errorf("Got a time from '%s' without the additional layout", timeStr)
+	}
+
+	SetAdditionalTimeLayouts([]string{"2006-01-02 15:04:05"})
+	suc, value := tryGetLocalizedTimeStamp(timeStr, time.Local)
+	if !suc {
+		t.Fatalf("Got no time from '%s' with the additional layout", timeStr)
+	}
+	if value.Format(time.ANSIC) != "Sun May 16 11:55:36 2021" {
+		t.Errorf("The time %s is not expected", value.Format(time.ANSIC))
+	}
+
+	SetAdditionalTimeLayouts([]string{"Mon, _2. Jan 2006 15:04:05"})
+	suc, _ = tryGetLocalizedTimeStamp("Sonntag, 16. Mai 2021 11:55:36", time.Local)
+	if !suc {
+		t.Errorf("Got no time from 'Sonntag, 16. Mai 2021 11:55:36' with the additional layout")
+	}
+
+	SetAdditionalTimeLayouts([]string{"Mon 2 January 2006 15:04"})
+	suc, _ = tryGetLocalizedTimeStamp("Sonntag 16 Mai 2021 11:55", time.Local)
+	if !suc {
+		t.Errorf("Got no time from the translated time stamp with the additional layout")
+	}
+}
+
+func TestSetSambaTimezone(t *testing.T) {
+	defer SetSambaTimezone(nil)
+	if GetSambaTimezone() != time.Local {
+		t.Errorf("The timezone '%s' is not the local timezone", GetSambaTimezone())
+	}
+
+	newYork, errLoad := time.LoadLocation("America/New_York")
+	if errLoad != nil {
+		t.Skipf("The timezone database is not available: %s", errLoad.Error())
+	}
+	SetSambaTimezone(newYork)
+
+	suc, value := tryGetTimeStampFromStrArr([]string{"Sun", "May", "16", "12:07:02", "2021"})
+	if !suc {
+		t.Fatalf("Got no time from 'Sun May 16 12:07:02 2021'")
+	}
+	if value.Location() != time.UTC {
+		t.Errorf("The time %s is not in UTC", value)
+	}
+	if value.Format(time.ANSIC) != "Sun May 16 16:07:02 2021" {
+		t.Errorf("The time %s is not the expected 'Sun May 16 16:07:02 2021'", value.Format(time.ANSIC))
+	}
+
+	suc, value = tryGetTimeStampFromStrArr([]string{"Sun", "May", "16", "12:07:02", "PM", "2021", "EDT"})
+	if !suc {
+		t.Fatalf("Got no time from 'Sun May 16 12:07:02 PM 2021 EDT'")
+	}
+	if value.Format(time.ANSIC) != "Sun May 16 16:07:02 2021" {
+		t.Errorf("The time %s is not the expected 'Sun May 16 16:07:02 2021'", value.Format(time.ANSIC))
+	}
+
+	suc, value = tryGetTimeStampFromStrArr([]string{"So", "16", "Mai", "2021", "12:07:02"})
+	if !suc {
+		t.Fatalf("Got no time from 'So 16 Mai 2021 12:07:02'")
+	}
+	if value.Format(time.ANSIC) != "Sun May 16 16:07:02 2021" {
+		t.Errorf("The time %s is not the expected 'Sun May 16 16:07:02 2021'", value.Format(time.ANSIC))
+	}
+}
+
+func TestGetShareDataLocalized(t *testing.T) {
+	for _, data := range []string{shareDataGerman, shareDataFrench} {
+		logger := testhelper.NewTestLogger(true)
+		shares := GetShareData(data, logger)
+
+		if len(shares) != 1 {
+			t.Fatalf("Got %d entries, expected 1", len(shares))
+		}
+
+		if shares[0].ConnectedAt.Day() != 16 || shares[0].ConnectedAt.Month() != time.May {
+			t.Errorf("The time %s is not expected", shares[0].ConnectedAt.Format(time.ANSIC))
+		}
+
+		if shares[0].Encryption != "-" || shares[0].Signing != "-" {
+			t.Errorf("Got the Encryption '%s' and Signing '%s' but expected '-'", shares[0].Encryption, shares[0].Signing)
+		}
+
+		if logger.GetErrorCount() != 0 {
+			t.Errorf("The ErrorCount '%d' is not the expected '0'", logger.GetErrorCount())
+		}
+	}
+}
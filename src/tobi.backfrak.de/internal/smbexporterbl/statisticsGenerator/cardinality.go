@@ -0,0 +1,90 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// CARDINALITY_OTHER_LABEL - The value of all labels of the series a metric with too many series is collapsed to
+const CARDINALITY_OTHER_LABEL = "other"
+
+// LimitCardinality - Get the statistics with at most limit series for each metric. The series with the highest values are kept,
+// the others are collapsed into one series with all labels set to CARDINALITY_OTHER_LABEL. Also returns the number of
+// series collapsed for each metric. A limit less than 1 does not limit the statistics
+func LimitCardinality(stats []SmbStatisticsNumeric, limit int) ([]SmbStatisticsNumeric, map[string]int) {
+	collapsed := make(map[string]int)
+	if limit < 1 {
+		return stats, collapsed
+	}
+
+	var names []string
+	seriesOfMetric := make(map[string][]SmbStatisticsNumeric)
+	for _, stat := range stats {
+		if _, found := seriesOfMetric[stat.Name]; !found {
+			names = append(names, stat.Name)
+		}
+		seriesOfMetric[stat.Name] = append(seriesOfMetric[stat.Name], stat)
+	}
+
+	ret := []SmbStatisticsNumeric{}
+	for _, name := range names {
+		series := seriesOfMetric[name]
+		if len(series) <= limit || series[0].Labels == nil {
+			ret = append(ret, series...)
+			continue
+		}
+
+		sort.SliceStable(series, func(i, j int) bool {
+			if series[i].Value != series[j].Value {
+				return series[i].Value > series[j].Value
+			}
+			return getLabelsKey(series[i].Labels) < getLabelsKey(series[j].Labels)
+		})
+		ret = append(ret, series[:limit]...)
+		ret = append(ret, collapseSeries(series[limit:]))
+		collapsed[name] = len(series) - limit
+	}
+
+	return ret, collapsed
+}
+
+// collapseSeries - Get one series for all given series of a metric. Time stamps get the earliest value,
+// durations the longest, and all other values are summed up
+func collapseSeries(series []SmbStatisticsNumeric) SmbStatisticsNumeric {
+	first := series[0]
+	labels := make(map[string]string)
+	for key := range first.Labels {
+		labels[key] = CARDINALITY_OTHER_LABEL
+	}
+
+	value := first.Value
+	for _, stat := range series[1:] {
+		switch {
+		case strings.HasSuffix(first.Name, "_at"):
+			value = math.Min(value, stat.Value)
+		case strings.HasSuffix(first.Name, "_seconds"):
+			value = math.Max(value, stat.Value)
+		default:
+			value += stat.Value
+		}
+	}
+
+	return SmbStatisticsNumeric{first.Name, value, first.Help, labels}
+}
+
+// getLabelsKey - Get a string containing all labels, to sort series with the same value
+func getLabelsKey(labels map[string]string) string {
+	var pairs []string
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
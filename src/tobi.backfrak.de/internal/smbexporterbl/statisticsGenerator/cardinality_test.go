@@ -0,0 +1,51 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+)
+
+func TestLimitCardinality(t *testing.T) {
+	stats := []SmbStatisticsNumeric{
+		{"share_count", 4, "Number of shares", nil},
+		{"locks_per_share_count", 1, "Number of locks on share", map[string]string{"share": "/srv/a"}},
+		{"locks_per_share_count", 5, "Number of locks on share", map[string]string{"share": "/srv/b"}},
+		{"locks_per_share_count", 2, "Number of locks on share", map[string]string{"share": "/srv/c"}},
+		{"locks_per_share_count", 2, "Number of locks on share", map[string]string{"share": "/srv/d"}},
+		{"client_connected_at", 300, "Unix time stamp a client connected", map[string]string{"client": "a"}},
+		{"client_connected_at", 100, "Unix time stamp a client connected", map[string]string{"client": "b"}},
+		{"client_connected_at", 200, "Unix time stamp a client connected", map[string]string{"client": "c"}},
+	}
+
+	unlimited, collapsed := LimitCardinality(stats, 0)
+	if len(unlimited) != len(stats) || len(collapsed) != 0 {
+		t.Errorf("The statistics were limited without a limit")
+	}
+
+	ret, collapsed := LimitCardinality(stats, 2)
+	if len(ret) != 7 {
+		t.Fatalf("Got %d statistics, but expected 7", len(ret))
+	}
+	if collapsed["locks_per_share_count"] != 2 || collapsed["client_connected_at"] != 1 || len(collapsed) != 2 {
+		t.Errorf("The collapsed series %v are not the expected", collapsed)
+	}
+
+	expected := []SmbStatisticsNumeric{
+		{"share_count", 4, "", nil},
+		{"locks_per_share_count", 5, "", map[string]string{"share": "/srv/b"}},
+		{"locks_per_share_count", 2, "", map[string]string{"share": "/srv/c"}},
+		{"locks_per_share_count", 3, "", map[string]string{"share": CARDINALITY_OTHER_LABEL}},
+		{"client_connected_at", 300, "", map[string]string{"client": "a"}},
+		{"client_connected_at", 200, "", map[string]string{"client": "c"}},
+		{"client_connected_at", 100, "", map[string]string{"client": CARDINALITY_OTHER_LABEL}},
+	}
+	for i, stat := range ret {
+		if stat.Name != expected[i].Name || stat.Value != expected[i].Value || getLabelsKey(stat.Labels) != getLabelsKey(expected[i].Labels) {
+			t.Errorf("Got '%s' %f %v at %d, but expected '%s' %f %v", stat.Name, stat.Value, stat.Labels, i, expected[i].Name, expected[i].Value, expected[i].Labels)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"net"
+	"strings"
+)
+
+// CLIENT_IP_UNKNOWN - The value of the client_ip label, when smbstatus prints no address for a client
+const CLIENT_IP_UNKNOWN = "-"
+
+// clientAddress - The IP address and the host name of a client, as exported in the labels client_ip and client_host
+type clientAddress struct {
+	IP   string
+	Host string
+}
+
+// parseMachine - Get the address of the machine of a client printed by smbstatus, like '192.168.1.242',
+// 'workstation (ipv4:192.168.1.242:42296)' or '192.168.1.242 (ipv4:192.168.1.242:42296)'.
+// The host is the name printed before the address, smbstatus prints the address there, when it does not know the name.
+// The port of the connection is dropped, so all connections of a client get the same labels
+func parseMachine(machine string) clientAddress {
+	name := strings.TrimSpace(machine)
+	socketAddress := ""
+	start := strings.Index(name, "(")
+	if start > -1 && strings.HasSuffix(name, ")") {
+		socketAddress = name[start+1 : len(name)-1]
+		name = strings.TrimSpace(name[:start])
+	}
+
+	if name == "" {
+		return clientAddress{}
+	}
+
+	address := clientAddress{IP: CLIENT_IP_UNKNOWN, Host: name}
+	if net.ParseIP(name) != nil {
+		address.IP = name
+	}
+	ip := getSocketAddressIP(socketAddress)
+	if ip != "" {
+		address.IP = ip
+	}
+
+	return address
+}
+
+// getSocketAddressIP - Get the IP of a socket address like 'ipv4:192.168.1.242:42296' or 'ipv6:fe80::1:445'.
+// Returns an empty string, when the address can not be parsed
+func getSocketAddressIP(socketAddress string) string {
+	for _, prefix := range []string{"ipv4:", "ipv6:"} {
+		if !strings.HasPrefix(socketAddress, prefix) {
+			continue
+		}
+		ip := strings.TrimPrefix(socketAddress, prefix)
+		end := strings.LastIndex(ip, ":")
+		if end > -1 {
+			ip = ip[:end]
+		}
+		ip = strings.Trim(ip, "[]")
+		if net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+
+	return ""
+}
+
+// getLabels - Get the labels client_ip and client_host of the client
+func (address clientAddress) getLabels() map[string]string {
+	return map[string]string{"client_ip": address.IP, "client_host": address.Host}
+}
@@ -0,0 +1,57 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+func TestParseMachine(t *testing.T) {
+	tests := map[string]clientAddress{
+		"192.168.1.242": {IP: "192.168.1.242", Host: "192.168.1.242"},
+		"192.168.1.242 (ipv4:192.168.1.242:42296)": {IP: "192.168.1.242", Host: "192.168.1.242"},
+		"workstation (ipv4:192.168.1.242:445)":     {IP: "192.168.1.242", Host: "workstation"},
+		"workstation (ipv6:fe80::1:445)":           {IP: "fe80::1", Host: "workstation"},
+		"fe80::1 (ipv6:[fe80::1]:445)":             {IP: "fe80::1", Host: "fe80::1"},
+		"workstation":                              {IP: CLIENT_IP_UNKNOWN, Host: "workstation"},
+		"workstation (unknown)":                    {IP: CLIENT_IP_UNKNOWN, Host: "workstation"},
+		"":                                         {},
+	}
+
+	for machine, expected := range tests {
+		address := parseMachine(machine)
+		if address != expected {
+			t.Errorf("Got %v for '%s', but expected %v", address, machine, expected)
+		}
+	}
+}
+
+func TestGetSmbStatisticsClientAddress(t *testing.T) {
+	settings := getNewStatisticGenSettings()
+	processes := []smbstatusreader.ProcessData{
+		{PID: 1117, UserID: 1080, Machine: "workstation (ipv4:192.168.1.242:42296)"},
+		{PID: 1118, UserID: 1080, Machine: "workstation (ipv4:192.168.1.242:42297)"},
+	}
+
+	found := false
+	for _, metric := range GetSmbStatistics(nil, processes, nil, settings) {
+		if metric.Name != "process_per_client_count" {
+			continue
+		}
+		found = true
+		if metric.Labels["client_ip"] != "192.168.1.242" || metric.Labels["client_host"] != "workstation" || metric.Value != 2 {
+			t.Errorf("Got %f processes for the labels %v, but expected 2 for 'workstation' with '192.168.1.242'", metric.Value, metric.Labels)
+		}
+		if _, foundClient := metric.Labels["client"]; foundClient {
+			t.Errorf("The combined label 'client' is still exported")
+		}
+	}
+	if !found {
+		t.Errorf("The process_per_client_count was not found")
+	}
+}
@@ -0,0 +1,91 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The time to wait for the answer of a reverse DNS lookup, so a slow DNS server does not block the scrape
+const CLIENT_LOOKUP_TIMEOUT = time.Second
+
+// ClientResolver - Resolves the addresses of the clients to their host names with reverse DNS lookups.
+// The results, also the failed lookups, are cached for the TTL, so not every scrape sends requests to the DNS server
+type ClientResolver struct {
+	ttl    time.Duration
+	mutex  sync.Mutex
+	cache  map[string]resolvedClient
+	lookup func(ctx context.Context, address string) ([]string, error)
+}
+
+// resolvedClient - The host name of a client address, cached till expires
+type resolvedClient struct {
+	name    string
+	expires time.Time
+}
+
+// NewClientResolver - Get a ClientResolver caching the host names for the given TTL
+func NewClientResolver(ttl time.Duration) *ClientResolver {
+	return &ClientResolver{ttl: ttl, cache: make(map[string]resolvedClient), lookup: net.DefaultResolver.LookupAddr}
+}
+
+// Resolve - Get the host name of the machine of a client, like '192.168.1.242' or '192.168.1.242 (ipv4:192.168.1.242:42296)'.
+// When the machine is no IP address, or the lookup fails, the machine is returned unchanged. A nil ClientResolver does not resolve
+func (resolver *ClientResolver) Resolve(machine string) string {
+	if resolver == nil {
+		return machine
+	}
+	fields := strings.Fields(machine)
+	if len(fields) == 0 || net.ParseIP(fields[0]) == nil {
+		return machine
+	}
+	address := fields[0]
+
+	resolver.mutex.Lock()
+	cached, found := resolver.cache[address]
+	resolver.mutex.Unlock()
+	if found && time.Now().Before(cached.expires) {
+		return getResolvedName(cached, machine)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CLIENT_LOOKUP_TIMEOUT)
+	defer cancel()
+	entry := resolvedClient{expires: time.Now().Add(resolver.ttl)}
+	names, errLookup := resolver.lookup(ctx, address)
+	if errLookup == nil && len(names) > 0 {
+		entry.name = strings.TrimSuffix(names[0], ".")
+	}
+
+	resolver.mutex.Lock()
+	resolver.cache[address] = entry
+	resolver.removeExpired()
+	resolver.mutex.Unlock()
+
+	return getResolvedName(entry, machine)
+}
+
+// getResolvedName - Get the cached name, or the machine when the lookup failed
+func getResolvedName(entry resolvedClient, machine string) string {
+	if entry.name == "" {
+		return machine
+	}
+
+	return entry.name
+}
+
+// removeExpired - Remove the expired entries, so the cache does not grow with the addresses of clients long gone. Call with the mutex locked
+func (resolver *ClientResolver) removeExpired() {
+	now := time.Now()
+	for address, entry := range resolver.cache {
+		if now.After(entry.expires) {
+			delete(resolver.cache, address)
+		}
+	}
+}
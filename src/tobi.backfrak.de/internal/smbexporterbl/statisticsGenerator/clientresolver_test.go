@@ -0,0 +1,91 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+func getTestClientResolver(ttl time.Duration, lookups *int) *ClientResolver {
+	resolver := NewClientResolver(ttl)
+	resolver.lookup = func(ctx context.Context, address string) ([]string, error) {
+		*lookups++
+		if address == "192.168.1.242" {
+			return []string{"workstation.example.com."}, nil
+		}
+		return nil, fmt.Errorf("no name for %s", address)
+	}
+
+	return resolver
+}
+
+func TestClientResolverResolve(t *testing.T) {
+	lookups := 0
+	resolver := getTestClientResolver(time.Minute, &lookups)
+
+	machines := map[string]string{
+		"192.168.1.242": "workstation.example.com",
+		"192.168.1.242 (ipv4:192.168.1.242:42296)": "workstation.example.com",
+		"192.168.1.243": "192.168.1.243",
+		"workstation":   "workstation",
+		"":              "",
+	}
+	for machine, expected := range machines {
+		if name := resolver.Resolve(machine); name != expected {
+			t.Errorf("Got the name '%s' for '%s', but expected '%s'", name, machine, expected)
+		}
+	}
+
+	if lookups != 2 {
+		t.Errorf("Got %d lookups, but expected 2, since the results are cached", lookups)
+	}
+
+	var nilResolver *ClientResolver
+	if nilResolver.Resolve("192.168.1.242") != "192.168.1.242" {
+		t.Errorf("The nil ClientResolver resolved the address")
+	}
+}
+
+func TestClientResolverExpires(t *testing.T) {
+	lookups := 0
+	resolver := getTestClientResolver(time.Millisecond, &lookups)
+
+	resolver.Resolve("192.168.1.242")
+	time.Sleep(5 * time.Millisecond)
+	resolver.Resolve("192.168.1.242")
+
+	if lookups != 2 {
+		t.Errorf("Got %d lookups, but expected 2, since the cached result expired", lookups)
+	}
+}
+
+func TestGetSmbStatisticsClientResolver(t *testing.T) {
+	lookups := 0
+	settings := getNewStatisticGenSettings()
+	settings.ClientResolver = getTestClientResolver(time.Minute, &lookups)
+	processes := []smbstatusreader.ProcessData{
+		{PID: 1117, UserID: 1080, Machine: "192.168.1.242 (ipv4:192.168.1.242:42296)"},
+		{PID: 1118, UserID: 1080, Machine: "192.168.1.242 (ipv4:192.168.1.242:42297)"},
+	}
+
+	found := false
+	for _, metric := range GetSmbStatistics(nil, processes, nil, settings) {
+		if metric.Name == "process_per_client_count" {
+			found = true
+			if metric.Labels["client_host"] != "workstation.example.com" || metric.Value != 2 {
+				t.Errorf("Got %f processes for the client host '%s', but expected 2 for 'workstation.example.com'", metric.Value, metric.Labels["client_host"])
+			}
+		}
+	}
+	if !found {
+		t.Errorf("The process_per_client_count was not found")
+	}
+}
@@ -0,0 +1,149 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import "strings"
+
+// The names of the collectors, the metrics of the samba_exporter are grouped in
+const (
+	COLLECTOR_LOCKS     = "locks"
+	COLLECTOR_SHARES    = "shares"
+	COLLECTOR_PROCESSES = "processes"
+	COLLECTOR_PSDATA    = "psdata"
+	COLLECTOR_CTDB      = "ctdb"
+	COLLECTOR_PLUGINS   = "plugins"
+	COLLECTOR_CGROUP    = "cgroup"
+	COLLECTOR_CONFIG    = "config"
+	COLLECTOR_HANDLES   = "handles"
+)
+
+// The collector of each metric generated out of the smbstatus tables
+var metricCollectors = map[string]string{
+	"locked_file_count":                                COLLECTOR_LOCKS,
+	"locks_per_share_count":                            COLLECTOR_LOCKS,
+	"open_files":                                       COLLECTOR_LOCKS,
+	"locked_file_info":                                 COLLECTOR_LOCKS,
+	"lock_created_at":                                  COLLECTOR_LOCKS,
+	"lock_created_since_seconds":                       COLLECTOR_LOCKS,
+	"share_count":                                      COLLECTOR_SHARES,
+	"share_connections":                                COLLECTOR_SHARES,
+	"client_count":                                     COLLECTOR_SHARES,
+	"client_connected_at":                              COLLECTOR_SHARES,
+	"client_connected_since_seconds":                   COLLECTOR_SHARES,
+	"connections_total":                                COLLECTOR_SHARES,
+	"disconnections_total":                             COLLECTOR_SHARES,
+	"oldest_connection_age_seconds":                    COLLECTOR_SHARES,
+	"newest_connection_age_seconds":                    COLLECTOR_SHARES,
+	"individual_user_count":                            COLLECTOR_PROCESSES,
+	"pid_count":                                        COLLECTOR_PROCESSES,
+	"server_information":                               COLLECTOR_PROCESSES,
+	"version_info":                                     COLLECTOR_PROCESSES,
+	"protocol_version_count":                           COLLECTOR_PROCESSES,
+	"signing_method_count":                             COLLECTOR_PROCESSES,
+	"encryption_method_count":                          COLLECTOR_PROCESSES,
+	"encrypted_session_count":                          COLLECTOR_PROCESSES,
+	"partially_encrypted_session_count":                COLLECTOR_PROCESSES,
+	"unencrypted_session_count":                        COLLECTOR_PROCESSES,
+	"process_per_client_count":                         COLLECTOR_PROCESSES,
+	"unique_client_count":                              COLLECTOR_PROCESSES,
+	"unique_user_count":                                COLLECTOR_PROCESSES,
+	"session_last_connect_or_lock_age_seconds":         COLLECTOR_PROCESSES,
+	"longest_session_last_connect_or_lock_age_seconds": COLLECTOR_PROCESSES,
+	"average_session_last_connect_or_lock_age_seconds": COLLECTOR_PROCESSES,
+	"cluster_node_count":                               COLLECTOR_CTDB,
+	"cluster_connection_count":                         COLLECTOR_CTDB,
+	"cluster_deduplicated_connection_count":            COLLECTOR_CTDB,
+	"pids_per_node_count":                              COLLECTOR_CTDB,
+	"locks_per_node_count":                             COLLECTOR_CTDB,
+	"processes_per_node_count":                         COLLECTOR_CTDB,
+	"shares_per_node_count":                            COLLECTOR_CTDB,
+	"clients_per_node_count":                           COLLECTOR_CTDB,
+	"configured_share_count":                           COLLECTOR_CONFIG,
+	"active_share_count":                               COLLECTOR_CONFIG,
+	"unused_share_count":                               COLLECTOR_CONFIG,
+	"share_config_info":                                COLLECTOR_CONFIG,
+	"share_max_connections_utilization":                COLLECTOR_CONFIG,
+	"durable_handle_count":                             COLLECTOR_HANDLES,
+	"persistent_handle_count":                          COLLECTOR_HANDLES,
+	"share_open_handle_count":                          COLLECTOR_HANDLES,
+	"share_durable_handle_count":                       COLLECTOR_HANDLES,
+	"share_persistent_handle_count":                    COLLECTOR_HANDLES,
+}
+
+// GetCollectorNames - Get the names of all collectors
+func GetCollectorNames() []string {
+	return []string{COLLECTOR_LOCKS, COLLECTOR_SHARES, COLLECTOR_PROCESSES, COLLECTOR_PSDATA, COLLECTOR_CTDB, COLLECTOR_PLUGINS, COLLECTOR_CGROUP, COLLECTOR_CONFIG, COLLECTOR_HANDLES}
+}
+
+// GetCollectorHelp - Get a short description of the metrics, the collector with the given name exports
+func GetCollectorHelp(name string) string {
+	switch name {
+	case COLLECTOR_LOCKS:
+		return "the locked files"
+	case COLLECTOR_SHARES:
+		return "the shares and the clients using them"
+	case COLLECTOR_PROCESSES:
+		return "the smbd processes serving the clients"
+	case COLLECTOR_PSDATA:
+		return "the resource usage of the smbd processes"
+	case COLLECTOR_CTDB:
+		return "the nodes of a samba cluster"
+	case COLLECTOR_PLUGINS:
+		return "the plugins of samba_statusd"
+	case COLLECTOR_CGROUP:
+		return "the resource usage of the cgroups samba runs in"
+	case COLLECTOR_CONFIG:
+		return "the shares configured in the smb.conf"
+	case COLLECTOR_HANDLES:
+		return "the durable and persistent file handles"
+	default:
+		return ""
+	}
+}
+
+// IsCollectorEnabled - Tell if the metrics of the collector with the given name are exported
+func (settings StatisticsGeneratorSettings) IsCollectorEnabled(name string) bool {
+	return !strArrContains(settings.DisabledCollectors, name)
+}
+
+// GetCollectorOfMetric - Get the name of the collector the metric with the given name belongs to.
+// Returns "" for metrics not belonging to a collector, like the metrics about the exporter itself
+func GetCollectorOfMetric(name string) string {
+	if strings.HasPrefix(name, "smbd_") {
+		return COLLECTOR_PSDATA
+	}
+	if strings.HasPrefix(name, "plugin_") {
+		return COLLECTOR_PLUGINS
+	}
+	if strings.HasPrefix(name, "cgroup_") {
+		return COLLECTOR_CGROUP
+	}
+
+	return metricCollectors[name]
+}
+
+// ValidateCollectorNames - Check if all given names are known collectors
+func ValidateCollectorNames(names []string) error {
+	for _, name := range names {
+		if !strArrContains(GetCollectorNames(), name) {
+			return NewUnknownCollectorError(name)
+		}
+	}
+
+	return nil
+}
+
+// FilterStatistics - Get only the statistics belonging to one of the given collectors
+func FilterStatistics(stats []SmbStatisticsNumeric, collectors []string) []SmbStatisticsNumeric {
+	var ret []SmbStatisticsNumeric
+	for _, stat := range stats {
+		if strArrContains(collectors, GetCollectorOfMetric(stat.Name)) {
+			ret = append(ret, stat)
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,112 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbstatusout"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func getAllTestStatistics() []SmbStatisticsNumeric {
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockDataCluster, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareDataCluster, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessDataCluster, logger)
+
+	ret := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
+	ret = append(ret, GetSmbStatistics(nil, nil, nil, getNewStatisticGenSettings())...)
+	ret = append(ret, GetSmbdMetrics(commonbl.GetTestPsUtilPidData(), false)...)
+	ret = append(ret, GetShareConfigStatistics(commonbl.GetTestShareConfig(), shares, getNewStatisticGenSettings())...)
+	ret = append(ret, GetShareHandlesStatistics(commonbl.GetTestShareHandles(), getNewStatisticGenSettings())...)
+
+	return ret
+}
+
+func TestGetCollectorOfMetric(t *testing.T) {
+	for _, stat := range getAllTestStatistics() {
+		if GetCollectorOfMetric(stat.Name) == "" {
+			t.Errorf("The metric '%s' does not belong to a collector", stat.Name)
+		}
+	}
+
+	if GetCollectorOfMetric("smbd_thread_count") != COLLECTOR_PSDATA {
+		t.Errorf("The metric 'smbd_thread_count' does not belong to the '%s' collector", COLLECTOR_PSDATA)
+	}
+
+	if GetCollectorOfMetric("plugin_up") != COLLECTOR_PLUGINS {
+		t.Errorf("The metric 'plugin_up' does not belong to the '%s' collector", COLLECTOR_PLUGINS)
+	}
+
+	if GetCollectorOfMetric("cgroup_memory_usage_bytes") != COLLECTOR_CGROUP {
+		t.Errorf("The metric 'cgroup_memory_usage_bytes' does not belong to the '%s' collector", COLLECTOR_CGROUP)
+	}
+
+	if GetCollectorOfMetric("server_up") != "" {
+		t.Errorf("The metric 'server_up' belongs to a collector, but should not")
+	}
+}
+
+func TestValidateCollectorNames(t *testing.T) {
+	err := ValidateCollectorNames(GetCollectorNames())
+	if err != nil {
+		t.Errorf("Got error '%s' but expected none", err.Error())
+	}
+
+	err = ValidateCollectorNames([]string{COLLECTOR_LOCKS, "unknown"})
+	switch err.(type) {
+	case *UnknownCollectorError:
+		fmt.Println("OK")
+	default:
+		t.Errorf("Got error of type '%T' but expected '*UnknownCollectorError'", err)
+	}
+}
+
+func TestFilterStatistics(t *testing.T) {
+	stats := getAllTestStatistics()
+
+	all := FilterStatistics(stats, GetCollectorNames())
+	if len(all) != len(stats) {
+		t.Errorf("Got '%d' statistics when filtering for all collectors, but expected '%d'", len(all), len(stats))
+	}
+
+	locks := FilterStatistics(stats, []string{COLLECTOR_LOCKS})
+	if len(locks) == 0 {
+		t.Errorf("Got no statistics for the '%s' collector", COLLECTOR_LOCKS)
+	}
+
+	for _, stat := range locks {
+		if GetCollectorOfMetric(stat.Name) != COLLECTOR_LOCKS {
+			t.Errorf("The metric '%s' is not filtered out", stat.Name)
+		}
+	}
+
+	if len(FilterStatistics(stats, nil)) != 0 {
+		t.Errorf("Got statistics when filtering for no collector")
+	}
+}
+
+func TestIsCollectorEnabled(t *testing.T) {
+	settings := StatisticsGeneratorSettings{DisabledCollectors: []string{COLLECTOR_CTDB}}
+
+	if settings.IsCollectorEnabled(COLLECTOR_CTDB) {
+		t.Errorf("The collector '%s' is enabled, but should not", COLLECTOR_CTDB)
+	}
+
+	if !settings.IsCollectorEnabled(COLLECTOR_LOCKS) {
+		t.Errorf("The collector '%s' is not enabled, but should", COLLECTOR_LOCKS)
+	}
+
+	for _, collector := range GetCollectorNames() {
+		if GetCollectorHelp(collector) == "" {
+			t.Errorf("The collector '%s' has no help", collector)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnknownCollectorError - Error when a collector name is not known
+type UnknownCollectorError struct {
+	err string
+	// The unknown collector name
+	Collector string
+}
+
+func (e *UnknownCollectorError) Error() string { // Implement the Error Interface for the UnknownCollectorError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewUnknownCollectorError - Get a new UnknownCollectorError struct
+func NewUnknownCollectorError(collector string) *UnknownCollectorError {
+	return &UnknownCollectorError{fmt.Sprintf("The collector \"%s\" is unknown. Possible values: %s", collector, strings.Join(GetCollectorNames(), ", ")), collector}
+}
+
+// CollectorDisabledError - Error when the metrics of a disabled collector are requested
+type CollectorDisabledError struct {
+	err string
+	// The disabled collector name
+	Collector string
+}
+
+func (e *CollectorDisabledError) Error() string { // Implement the Error Interface for the CollectorDisabledError struct
+	return fmt.Sprintf("Error: %s", e.err)
+}
+
+// NewCollectorDisabledError - Get a new CollectorDisabledError struct
+func NewCollectorDisabledError(collector string) *CollectorDisabledError {
+	return &CollectorDisabledError{fmt.Sprintf("The collector \"%s\" is disabled", collector), collector}
+}
@@ -6,6 +6,7 @@ package statisticsGenerator
 // LICENSE file.
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -24,7 +25,7 @@ func TestGetSmbStatisticsNoLockData(t *testing.T) {
 
 	ret := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
 
-	if len(ret) != 15 {
+	if len(ret) != 28 {
 		t.Errorf("The number of return values %d was not expected", len(ret))
 	}
 
@@ -45,7 +46,7 @@ func TestGetSmbStatisticsClusterData(t *testing.T) {
 
 	ret := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
 
-	if len(ret) != 33 {
+	if len(ret) != 57 {
 		t.Errorf("The number of return values %d was not expected", len(ret))
 	}
 
@@ -61,11 +62,96 @@ func TestGetSmbStatisticsClusterData(t *testing.T) {
 		t.Errorf("The cluster_node_count does not match as expected")
 	}
 
+	// Each node gets all per node counts, also the ones it has no entries for
+	perNode := map[string]float64{}
+	for _, stat := range ret {
+		if strings.HasSuffix(stat.Name, "_per_node_count") {
+			perNode[stat.Name+"/"+stat.Labels["node"]] = stat.Value
+		}
+	}
+	expected := map[string]float64{"pids_per_node_count/1": 3, "locks_per_node_count/1": 6, "processes_per_node_count/1": 4, "clients_per_node_count/1": 2,
+		"processes_per_node_count/2": 0, "clients_per_node_count/2": 0, "pids_per_node_count/3": 2, "locks_per_node_count/3": 1,
+		"processes_per_node_count/3": 3, "shares_per_node_count/3": 0, "clients_per_node_count/3": 2}
+	if len(perNode) != 15 {
+		t.Errorf("Got %d per node counts, but expected 15", len(perNode))
+	}
+	for key, value := range expected {
+		if found, ok := perNode[key]; !ok || found != value {
+			t.Errorf("The per node count '%s' is '%f', but expected '%f'", key, found, value)
+		}
+	}
+
 	if logger.GetErrorCount() != 0 {
 		t.Errorf("The ErrorCount '%d' is not the expected '0'", logger.GetErrorCount())
 	}
 }
 
+func TestGetSmbStatisticsClusterConnections(t *testing.T) {
+	shares := []smbstatusreader.ShareData{
+		{Service: "data", PID: 1117, ClusterNodeId: 0, Machine: "10.63.0.11 (ipv4:10.63.0.11:50370)"},
+		{Service: "data", PID: 2117, ClusterNodeId: 1, Machine: "10.63.0.11 (ipv4:10.63.0.11:50371)"},
+		{Service: "home", PID: 2117, ClusterNodeId: 1, Machine: "10.63.0.11 (ipv4:10.63.0.11:50371)"},
+		{Service: "data", PID: 2118, ClusterNodeId: 1, Machine: "10.63.0.12 (ipv4:10.63.0.12:50372)"},
+	}
+
+	counts := map[string]float64{}
+	for _, stat := range GetSmbStatistics(nil, nil, shares, getNewStatisticGenSettings()) {
+		if stat.Name == "cluster_connection_count" || stat.Name == "cluster_deduplicated_connection_count" {
+			counts[stat.Name] = stat.Value
+		}
+	}
+
+	if counts["cluster_connection_count"] != 4 {
+		t.Errorf("The cluster_connection_count %f is not the expected 4", counts["cluster_connection_count"])
+	}
+	// The client 10.63.0.11 is connected to the share data on both nodes
+	if counts["cluster_deduplicated_connection_count"] != 3 {
+		t.Errorf("The cluster_deduplicated_connection_count %f is not the expected 3", counts["cluster_deduplicated_connection_count"])
+	}
+}
+
+func TestGetSmbStatisticsClusterNodeNames(t *testing.T) {
+	processes := []smbstatusreader.ProcessData{{PID: 1117, ClusterNodeId: 0}, {PID: 2117, ClusterNodeId: 1}}
+	settings := getNewStatisticGenSettings()
+	// The address of node 1 is not known
+	settings.ClusterNodeNames = map[int]string{0: "192.168.1.10"}
+
+	nodes := []string{}
+	for _, stat := range GetSmbStatistics(nil, processes, nil, settings) {
+		if stat.Name == "processes_per_node_count" {
+			nodes = append(nodes, stat.Labels["node"])
+		}
+	}
+
+	if len(nodes) != 2 || nodes[0] != "192.168.1.10" || nodes[1] != "1" {
+		t.Errorf("Got the node labels '%v', but expected '192.168.1.10' and '1'", nodes)
+	}
+}
+
+func TestGetSmbStatisticsDisabledCollectors(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockDataCluster, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareDataCluster, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessDataCluster, logger)
+
+	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{DisabledCollectors: []string{COLLECTOR_CTDB, COLLECTOR_LOCKS}})
+	for _, stat := range ret {
+		collector := GetCollectorOfMetric(stat.Name)
+		if collector == COLLECTOR_CTDB || collector == COLLECTOR_LOCKS {
+			t.Errorf("Got the metric '%s' of the disabled collector '%s'", stat.Name, collector)
+		}
+	}
+
+	if ret[0].Name != "individual_user_count" || ret[1].Name != "share_count" {
+		t.Errorf("The metrics of the enabled collectors are not in the expected order")
+	}
+
+	ret = GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{DisabledCollectors: GetCollectorNames()})
+	if ret == nil || len(ret) != 0 {
+		t.Errorf("Got '%d' metrics with all collectors disabled, but expected an empty list", len(ret))
+	}
+}
+
 func getNewStatisticGenSettings() StatisticsGeneratorSettings {
 	return StatisticsGeneratorSettings{}
 }
@@ -78,7 +164,7 @@ func TestGetSmbStatisticsEmptyData(t *testing.T) {
 
 	ret := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
 
-	if len(ret) != 15 {
+	if len(ret) != 28 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -109,9 +195,9 @@ func TestGetSmbStatisticsEmptyData(t *testing.T) {
 		t.Errorf("The SambaVersion \"%s\" is not expected", value)
 	}
 
-	value, found = ret[12].Labels["client"]
+	value, found = ret[15].Labels["client_ip"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if value != "" {
@@ -131,7 +217,7 @@ func TestGetSmbStatisticsEmptyRespomse(t *testing.T) {
 
 	ret := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
 
-	if len(ret) != 15 {
+	if len(ret) != 28 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -162,9 +248,9 @@ func TestGetSmbStatisticsEmptyRespomse(t *testing.T) {
 		t.Errorf("The SambaVersion \"%s\" is not expected", value)
 	}
 
-	value, found = ret[12].Labels["client"]
+	value, found = ret[15].Labels["client_ip"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if value != "" {
@@ -183,7 +269,7 @@ func TestGetSmbStatisticsEmptyResponseLabels(t *testing.T) {
 	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData0Lines, logger)
 
 	ret := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
-	if len(ret) != 15 {
+	if len(ret) != 28 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -208,7 +294,7 @@ func TestGetSmbStatistics(t *testing.T) {
 
 	ret := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
 
-	if len(ret) != 33 {
+	if len(ret) != 55 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -308,56 +394,68 @@ func TestGetSmbStatistics(t *testing.T) {
 		t.Errorf("The encryption \"%s\" is not expected", value)
 	}
 
-	if ret[23].Name != "client_connected_at" {
-		t.Errorf("The name %s is not expected", ret[23].Name)
+	if ret[13].Name != "encrypted_session_count" || ret[13].Value != 0 {
+		t.Errorf("The encrypted_session_count '%v' is not expected", ret[13])
+	}
+
+	if ret[14].Name != "partially_encrypted_session_count" || ret[14].Value != 0 {
+		t.Errorf("The partially_encrypted_session_count '%v' is not expected", ret[14])
+	}
+
+	if ret[15].Name != "unencrypted_session_count" || ret[15].Value != 4 {
+		t.Errorf("The unencrypted_session_count '%v' is not expected", ret[15])
 	}
 
-	value, found = ret[23].Labels["client"]
+	if ret[26].Name != "client_connected_at" {
+		t.Errorf("The name %s is not expected", ret[26].Name)
+	}
+
+	value, found = ret[26].Labels["client_ip"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if !strings.HasPrefix(value, "192.168.1.") {
 		t.Errorf("The value %s is not expected", value)
 	}
 
-	if ret[31].Name != "lock_created_at" {
-		t.Errorf("The name %s is not expected", ret[23].Name)
+	if ret[34].Name != "lock_created_at" {
+		t.Errorf("The name %s is not expected", ret[26].Name)
 	}
 
-	value, found = ret[31].Labels["user"]
+	value, found = ret[34].Labels["user"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if !strings.HasPrefix(value, "1080") {
 		t.Errorf("The value %s is not expected", value)
 	}
 
-	if ret[32].Name != "lock_created_since_seconds" {
-		t.Errorf("The name %s is not expected", ret[32].Name)
+	if ret[35].Name != "lock_created_since_seconds" {
+		t.Errorf("The name %s is not expected", ret[35].Name)
 	}
 
-	value, found = ret[32].Labels["user"]
+	value, found = ret[35].Labels["user"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if value != "1080" {
 		t.Errorf("The value %s is not expected", value)
 	}
 
-	value, found = ret[32].Labels["share"]
+	value, found = ret[35].Labels["share"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if !strings.HasPrefix(value, "/usr/share") {
 		t.Errorf("The value %s is not expected", value)
 	}
 
-	if ret[32].Value <= 0 {
-		t.Errorf("The 'lock_created_since_seconds' is '%f', it's expected grater then '0'", ret[32].Value)
+	if ret[35].Value <= 0 {
+		t.Errorf("The 'lock_created_since_seconds' is '%f', it's expected grater then '0'", ret[35].Value)
 	}
 
 	if logger.GetErrorCount() != 0 {
@@ -371,9 +469,9 @@ func TestGetSmbStatisticsNotExportEncryption(t *testing.T) {
 	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
 	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
 
-	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{false, false, true, false, false})
+	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{DoNotExportEncryption: true})
 
-	if len(ret) != 30 {
+	if len(ret) != 45 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -381,9 +479,9 @@ func TestGetSmbStatisticsNotExportEncryption(t *testing.T) {
 		t.Errorf("The name %s is not expected", ret[20].Name)
 	}
 
-	value, found := ret[20].Labels["client"]
+	value, found := ret[20].Labels["client_ip"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if !strings.HasPrefix(value, "192.168.1.") {
@@ -401,9 +499,9 @@ func TestGetSmbStatisticsNotExportClient(t *testing.T) {
 	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
 	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
 
-	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{true, false, false, false, false})
+	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{DoNotExportClient: true})
 
-	if len(ret) != 21 {
+	if len(ret) != 43 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -422,9 +520,9 @@ func TestGetSmbStatisticsNotExportUser(t *testing.T) {
 	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
 	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
 
-	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{false, true, false, false, false})
+	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{DoNotExportUser: true})
 
-	if len(ret) != 25 {
+	if len(ret) != 47 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -432,9 +530,9 @@ func TestGetSmbStatisticsNotExportUser(t *testing.T) {
 		t.Errorf("The name %s is not expected", ret[12].Name)
 	}
 
-	value, found := ret[24].Labels["client"]
+	value, found := ret[24].Labels["client_ip"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if !strings.HasPrefix(value, "192.168.1.") {
@@ -452,9 +550,9 @@ func TestGetSmbStatisticsNotExportShare(t *testing.T) {
 	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
 	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
 
-	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{false, false, false, false, true})
+	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{DoNotExportShareDetails: true})
 
-	if len(ret) != 21 {
+	if len(ret) != 35 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -462,9 +560,9 @@ func TestGetSmbStatisticsNotExportShare(t *testing.T) {
 		t.Errorf("The name %s is not expected", ret[8].Name)
 	}
 
-	value, found := ret[20].Labels["client"]
+	value, found := ret[20].Labels["client_ip"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if !strings.HasPrefix(value, "192.168.1.") {
@@ -482,9 +580,9 @@ func TestGetSmbStatisticsNotExportShareAndUser(t *testing.T) {
 	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
 	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
 
-	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{false, true, false, false, true})
+	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{DoNotExportUser: true, DoNotExportShareDetails: true})
 
-	if len(ret) != 21 {
+	if len(ret) != 35 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -492,9 +590,9 @@ func TestGetSmbStatisticsNotExportShareAndUser(t *testing.T) {
 		t.Errorf("The name %s is not expected", ret[8].Name)
 	}
 
-	value, found := ret[20].Labels["client"]
+	value, found := ret[20].Labels["client_ip"]
 	if !found {
-		t.Errorf("No label with key \"client\" found")
+		t.Errorf("No label with key \"client_ip\" found")
 	}
 
 	if !strings.HasPrefix(value, "192.168.1.") {
@@ -512,9 +610,9 @@ func TestGetSmbStatisticsAllNotExportFlags(t *testing.T) {
 	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
 	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
 
-	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{true, true, true, true, true})
+	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{DoNotExportClient: true, DoNotExportUser: true, DoNotExportEncryption: true, DoNotExportPid: true, DoNotExportShareDetails: true})
 
-	if len(ret) != 6 {
+	if len(ret) != 13 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -533,9 +631,9 @@ func TestGetSmbStatisticsNameWithSpaces(t *testing.T) {
 	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4LinesWithSpacesInName, logger)
 	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
 
-	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{false, false, false, false, false})
+	ret := GetSmbStatistics(locks, processes, shares, StatisticsGeneratorSettings{})
 
-	if len(ret) != 29 {
+	if len(ret) != 49 {
 		t.Errorf("The number of resturn values %d was not expected", len(ret))
 	}
 
@@ -556,6 +654,16 @@ func TestGetSmbStatisticsNameWithSpaces(t *testing.T) {
 	}
 }
 
+func TestGetEncryptionState(t *testing.T) {
+	states := map[string]string{"-": ENCRYPTION_STATE_UNENCRYPTED, "": ENCRYPTION_STATE_UNENCRYPTED, "AES-128-GCM": ENCRYPTION_STATE_ENCRYPTED,
+		"AES-256-CCM": ENCRYPTION_STATE_ENCRYPTED, "partial(AES-128-GCM)": ENCRYPTION_STATE_PARTIAL}
+	for encryption, expected := range states {
+		if state := getEncryptionState(encryption); state != expected {
+			t.Errorf("Got the state '%s' for the encryption '%s', but expected '%s'", state, encryption, expected)
+		}
+	}
+}
+
 func TestStringArrContains(t *testing.T) {
 	arr := []string{"a", "b", "c"}
 
@@ -600,3 +708,227 @@ func TestLockArrContains(t *testing.T) {
 		t.Errorf("lockArrContainsEntry returns true but should false")
 	}
 }
+
+func TestGetSmbStatisticsVersionInfo(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+
+	ret := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
+
+	var versionInfo SmbStatisticsNumeric
+	for _, metric := range ret {
+		if metric.Name == "version_info" {
+			versionInfo = metric
+		}
+	}
+	if versionInfo.Name != "version_info" {
+		t.Fatalf("The version_info was not found")
+	}
+
+	if versionInfo.Value != 1 {
+		t.Errorf("The Value %f is not expected", versionInfo.Value)
+	}
+
+	if versionInfo.Labels["version"] != processes[0].SambaVersion || versionInfo.Labels["version"] == "" {
+		t.Errorf("The version \"%s\" is not the expected \"%s\"", versionInfo.Labels["version"], processes[0].SambaVersion)
+	}
+
+	settings := getNewStatisticGenSettings()
+	settings.DisabledCollectors = []string{COLLECTOR_PROCESSES}
+	for _, metric := range GetSmbStatistics(locks, processes, shares, settings) {
+		if metric.Name == "version_info" {
+			t.Errorf("Got the version_info with the processes collector disabled")
+		}
+	}
+}
+
+func TestGetSmbStatisticsUniqueClientsAndUsers(t *testing.T) {
+	processes := []smbstatusreader.ProcessData{
+		{PID: 1117, UserID: 1080, Machine: "192.168.1.242 (ipv4:192.168.1.242:42296)"},
+		{PID: 1118, UserID: 1080, Machine: "192.168.1.242 (ipv4:192.168.1.242:42297)"},
+		{PID: 1119, UserID: 1081, Machine: "192.168.1.242 (ipv4:192.168.1.242:42298)"},
+		{PID: 1120, UserID: 1080, Machine: "192.168.1.243 (ipv4:192.168.1.243:42296)"},
+	}
+
+	ret := GetSmbStatistics(nil, processes, nil, getNewStatisticGenSettings())
+
+	found := 0
+	for _, metric := range ret {
+		if metric.Name == "unique_client_count" {
+			found++
+			if metric.Value != 2 {
+				t.Errorf("The unique_client_count %f is not the expected 2", metric.Value)
+			}
+		}
+		if metric.Name == "unique_user_count" {
+			found++
+			if metric.Value != 2 {
+				t.Errorf("The unique_user_count %f is not the expected 2", metric.Value)
+			}
+		}
+	}
+
+	if found != 2 {
+		t.Errorf("Found %d of the unique count metrics, but expected 2", found)
+	}
+}
+
+func TestGetSmbStatisticsShareConnections(t *testing.T) {
+	processes := []smbstatusreader.ProcessData{
+		{PID: 1117, ClusterNodeId: -1, ProtocolVersion: "SMB3_11"},
+		{PID: 1118, ClusterNodeId: -1, ProtocolVersion: "SMB2_02"},
+		{PID: 1119, ClusterNodeId: -1, ProtocolVersion: "SMB3_11"},
+	}
+	shares := []smbstatusreader.ShareData{
+		{Service: "IPC$", PID: 1117, ClusterNodeId: -1},
+		{Service: "share", PID: 1117, ClusterNodeId: -1},
+		{Service: "share", PID: 1118, ClusterNodeId: -1},
+		{Service: "share", PID: 1119, ClusterNodeId: -1},
+		{Service: "share", PID: 1120, ClusterNodeId: -1},
+	}
+
+	connections := map[string]float64{}
+	for _, metric := range GetSmbStatistics(nil, processes, shares, getNewStatisticGenSettings()) {
+		if metric.Name == "share_connections" {
+			connections[metric.Labels["share"]+"/"+metric.Labels["protocol_version"]] = metric.Value
+		}
+	}
+
+	// The session of the process 1120 is not in the processes table
+	expected := map[string]float64{"IPC$/SMB3_11": 1, "share/SMB3_11": 2, "share/SMB2_02": 1, "share/-": 1}
+	if len(connections) != len(expected) {
+		t.Errorf("Got the connections '%v', but expected '%v'", connections, expected)
+	}
+	for key, value := range expected {
+		if connections[key] != value {
+			t.Errorf("Got %f connections for '%s', but expected %f", connections[key], key, value)
+		}
+	}
+
+	for _, settings := range []StatisticsGeneratorSettings{{DoNotExportShareDetails: true}, {DoNotExportEncryption: true}} {
+		for _, metric := range GetSmbStatistics(nil, processes, shares, settings) {
+			if metric.Name == "share_connections" {
+				t.Errorf("The share_connections are exported with the settings '%v'", settings)
+			}
+		}
+	}
+}
+
+func TestGetSmbStatisticsConnectionAge(t *testing.T) {
+	now := time.Now()
+	shares := []smbstatusreader.ShareData{
+		{Service: "IPC$", PID: 1117, Machine: "192.168.1.242", ConnectedAt: now.Add(-2 * time.Hour)},
+		{Service: "share", PID: 1117, Machine: "192.168.1.242", ConnectedAt: now.Add(-10 * time.Minute)},
+		{Service: "share", PID: 1118, Machine: "192.168.1.243", ConnectedAt: now.Add(-1 * time.Hour)},
+	}
+
+	ages := map[string]float64{}
+	for _, metric := range GetSmbStatistics(nil, nil, shares, getNewStatisticGenSettings()) {
+		if metric.Name == "oldest_connection_age_seconds" || metric.Name == "newest_connection_age_seconds" {
+			ages[metric.Name] = metric.Value
+		}
+	}
+
+	if ages["oldest_connection_age_seconds"] < 7200 || ages["oldest_connection_age_seconds"] > 7260 {
+		t.Errorf("The oldest_connection_age_seconds %f is not the expected 7200", ages["oldest_connection_age_seconds"])
+	}
+	if ages["newest_connection_age_seconds"] < 600 || ages["newest_connection_age_seconds"] > 660 {
+		t.Errorf("The newest_connection_age_seconds %f is not the expected 600", ages["newest_connection_age_seconds"])
+	}
+
+	for _, metric := range GetSmbStatistics(nil, nil, nil, getNewStatisticGenSettings()) {
+		if (metric.Name == "oldest_connection_age_seconds" || metric.Name == "newest_connection_age_seconds") && metric.Value != 0 {
+			t.Errorf("The %s %f is not the expected 0 without connections", metric.Name, metric.Value)
+		}
+	}
+}
+
+func TestGetSmbStatisticsOpenFiles(t *testing.T) {
+	locks := []smbstatusreader.LockData{
+		{PID: 1117, UserID: 1080, SharePath: "/usr/share/data", Name: "Documents/Test.txt"},
+		{PID: 1118, UserID: 1081, SharePath: "/usr/share/data", Name: "Documents/Test.txt"},
+		{PID: 1118, UserID: 1081, SharePath: "/usr/share/data", Name: "Documents/Other.txt"},
+		{PID: 1119, UserID: 1080, SharePath: "/usr/share/home", Name: "Test.txt"},
+	}
+
+	openFiles := map[string]float64{}
+	for _, metric := range GetSmbStatistics(locks, nil, nil, getNewStatisticGenSettings()) {
+		if metric.Name == "open_files" {
+			openFiles[metric.Labels["share"]] = metric.Value
+		}
+	}
+
+	if len(openFiles) != 2 {
+		t.Errorf("Got open_files for %d shares, but expected 2", len(openFiles))
+	}
+	if openFiles["/usr/share/data"] != 2 {
+		t.Errorf("The open_files %f of '/usr/share/data' is not the expected 2", openFiles["/usr/share/data"])
+	}
+	if openFiles["/usr/share/home"] != 1 {
+		t.Errorf("The open_files %f of '/usr/share/home' is not the expected 1", openFiles["/usr/share/home"])
+	}
+
+	settings := getNewStatisticGenSettings()
+	settings.DoNotExportShareDetails = true
+	for _, metric := range GetSmbStatistics(locks, nil, nil, settings) {
+		if metric.Name == "open_files" {
+			t.Errorf("Got the open_files with DoNotExportShareDetails set")
+		}
+	}
+}
+
+func TestGetSmbStatisticsLockedFileInfo(t *testing.T) {
+	locks := []smbstatusreader.LockData{
+		{PID: 1117, UserID: 1080, SharePath: "/usr/share/data", Name: "Documents/Test.txt"},
+		{PID: 1118, UserID: 1081, SharePath: "/usr/share/data", Name: "Documents/Test.txt"},
+		{PID: 1119, UserID: 1081, SharePath: "/usr/share/data", Name: "Documents/Test.txt"},
+		{PID: 1118, UserID: 1081, SharePath: "/usr/share/data", Name: "Documents/Other.txt"},
+		{PID: 1119, UserID: 1080, SharePath: "/usr/share/home", Name: "Test.txt"},
+		{PID: 1117, UserID: 1080, SharePath: "/usr/share/home", Name: "Test.txt"},
+		{PID: 1117, UserID: 1080, SharePath: "/usr/share/home", Name: "."},
+	}
+
+	for _, metric := range GetSmbStatistics(locks, nil, nil, getNewStatisticGenSettings()) {
+		if metric.Name == "locked_file_info" {
+			t.Errorf("Got the locked_file_info without LockedFilesTopN set")
+		}
+	}
+
+	settings := getNewStatisticGenSettings()
+	settings.LockedFilesTopN = 2
+	var files []SmbStatisticsNumeric
+	for _, metric := range GetSmbStatistics(locks, nil, nil, settings) {
+		if metric.Name == "locked_file_info" {
+			files = append(files, metric)
+		}
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("Got %d locked_file_info, but expected 2", len(files))
+	}
+	if files[0].Labels["path"] != "/usr/share/data/Documents/Test.txt" || files[0].Value != 3 {
+		t.Errorf("The most locked file '%s' with %f locks is not the expected", files[0].Labels["path"], files[0].Value)
+	}
+	if files[1].Labels["path"] != "/usr/share/home/Test.txt" || files[1].Value != 2 {
+		t.Errorf("The second most locked file '%s' with %f locks is not the expected", files[1].Labels["path"], files[1].Value)
+	}
+}
+
+func TestGetMostLockedFiles(t *testing.T) {
+	locksPerFile := map[string]int{"/usr/share/data/b.txt": 1, "/usr/share/data/a.txt": 1, "/usr/share/data/c.txt": 4}
+
+	files := getMostLockedFiles(locksPerFile, 5)
+	if len(files) != 3 || files[0] != "/usr/share/data/c.txt" || files[1] != "/usr/share/data/a.txt" || files[2] != "/usr/share/data/b.txt" {
+		t.Errorf("The files %v are not the expected", files)
+	}
+
+	manyFiles := map[string]int{}
+	for i := 0; i < 2*MAX_LOCKED_FILES_TOP_N; i++ {
+		manyFiles[fmt.Sprintf("/usr/share/data/%d.txt", i)] = i
+	}
+	if len(getMostLockedFiles(manyFiles, 1000)) != MAX_LOCKED_FILES_TOP_N {
+		t.Errorf("The number of files is not limited to %d", MAX_LOCKED_FILES_TOP_N)
+	}
+}
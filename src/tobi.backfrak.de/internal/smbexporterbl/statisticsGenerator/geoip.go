@@ -0,0 +1,82 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GEOIP_UNKNOWN - The value of the country and asn labels, when the address of a client is not found in the databases
+const GEOIP_UNKNOWN = "-"
+
+// GeoIPDatabases - MaxMind DB files used to get the country and the autonomous system of the clients,
+// e. g. a GeoLite2-Country and a GeoLite2-ASN database
+type GeoIPDatabases struct {
+	readers []*maxminddb.Reader
+}
+
+// geoIPRecord - The fields of the country and ASN databases used for the labels
+type geoIPRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	AutonomousSystemNumber uint `maxminddb:"autonomous_system_number"`
+}
+
+// OpenGeoIPDatabases - Open the MaxMind DB files with the given paths
+func OpenGeoIPDatabases(paths []string) (*GeoIPDatabases, error) {
+	databases := GeoIPDatabases{}
+	for _, path := range paths {
+		reader, errOpen := maxminddb.Open(path)
+		if errOpen != nil {
+			databases.Close()
+			return nil, fmt.Errorf("the GeoIP database '%s' can not be opened: %s", path, errOpen.Error())
+		}
+		databases.readers = append(databases.readers, reader)
+	}
+
+	return &databases, nil
+}
+
+// Close - Close the database files
+func (databases *GeoIPDatabases) Close() {
+	for _, reader := range databases.readers {
+		reader.Close()
+	}
+}
+
+// Lookup - Get the ISO code of the country and the number of the autonomous system of the machine of a client,
+// like '192.168.1.242' or '192.168.1.242 (ipv4:192.168.1.242:42296)'. Values not found in the databases are GEOIP_UNKNOWN
+func (databases *GeoIPDatabases) Lookup(machine string) (string, string) {
+	country, asn := GEOIP_UNKNOWN, GEOIP_UNKNOWN
+	fields := strings.Fields(machine)
+	if len(fields) == 0 {
+		return country, asn
+	}
+	ip := net.ParseIP(fields[0])
+	if ip == nil {
+		return country, asn
+	}
+
+	for _, reader := range databases.readers {
+		var record geoIPRecord
+		if reader.Lookup(ip, &record) != nil {
+			continue
+		}
+		if country == GEOIP_UNKNOWN && record.Country.ISOCode != "" {
+			country = record.Country.ISOCode
+		}
+		if asn == GEOIP_UNKNOWN && record.AutonomousSystemNumber != 0 {
+			asn = fmt.Sprint(record.AutonomousSystemNumber)
+		}
+	}
+
+	return country, asn
+}
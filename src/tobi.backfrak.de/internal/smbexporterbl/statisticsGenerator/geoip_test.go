@@ -0,0 +1,127 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// writeTestGeoIPDatabase - Write a MaxMind DB file, where all IPv4 addresses of 0.0.0.0/1 have the given record, and the others none
+func writeTestGeoIPDatabase(t *testing.T, record []byte) string {
+	// The search tree has one node with 24 bit records, the left one points to the data section, the right one means not found
+	const nodeCount = 1
+	dataRecord := nodeCount + 16
+	data := []byte{byte(dataRecord >> 16), byte(dataRecord >> 8), byte(dataRecord), 0, 0, nodeCount}
+	data = append(data, make([]byte, 16)...)
+	data = append(data, record...)
+
+	data = append(data, []byte("\xAB\xCD\xEFMaxMind.com")...)
+	data = append(data, mmdbMap(
+		mmdbString("node_count"), mmdbUint32(nodeCount),
+		mmdbString("record_size"), mmdbUint16(24),
+		mmdbString("ip_version"), mmdbUint16(4),
+		mmdbString("database_type"), mmdbString("Test"),
+		mmdbString("binary_format_major_version"), mmdbUint16(2),
+		mmdbString("binary_format_minor_version"), mmdbUint16(0),
+	)...)
+
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	errWrite := os.WriteFile(path, data, 0644)
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+
+	return path
+}
+
+func mmdbString(value string) []byte {
+	return append([]byte{2<<5 | byte(len(value))}, value...)
+}
+
+func mmdbUint16(value uint16) []byte {
+	return []byte{5<<5 | 2, byte(value >> 8), byte(value)}
+}
+
+func mmdbUint32(value uint32) []byte {
+	return []byte{6<<5 | 4, byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
+}
+
+func mmdbMap(pairs ...[]byte) []byte {
+	ret := []byte{7<<5 | byte(len(pairs)/2)}
+	for _, pair := range pairs {
+		ret = append(ret, pair...)
+	}
+
+	return ret
+}
+
+func TestGeoIPDatabasesLookup(t *testing.T) {
+	countryDB := writeTestGeoIPDatabase(t, mmdbMap(mmdbString("country"), mmdbMap(mmdbString("iso_code"), mmdbString("DE"))))
+	asnDB := writeTestGeoIPDatabase(t, mmdbMap(mmdbString("autonomous_system_number"), mmdbUint32(3320)))
+
+	databases, errOpen := OpenGeoIPDatabases([]string{countryDB, asnDB})
+	if errOpen != nil {
+		t.Fatalf("Got error '%s' but expected none", errOpen.Error())
+	}
+	defer databases.Close()
+
+	machines := map[string][]string{
+		"80.1.2.3":                       {"DE", "3320"},
+		"80.1.2.3 (ipv4:80.1.2.3:42296)": {"DE", "3320"},
+		"192.168.1.242":                  {GEOIP_UNKNOWN, GEOIP_UNKNOWN},
+		"workstation":                    {GEOIP_UNKNOWN, GEOIP_UNKNOWN},
+		"":                               {GEOIP_UNKNOWN, GEOIP_UNKNOWN},
+	}
+	for machine, expected := range machines {
+		country, asn := databases.Lookup(machine)
+		if country != expected[0] || asn != expected[1] {
+			t.Errorf("Got the country '%s' and asn '%s' for '%s', but expected '%s' and '%s'", country, asn, machine, expected[0], expected[1])
+		}
+	}
+}
+
+func TestOpenGeoIPDatabasesNotExisting(t *testing.T) {
+	_, errOpen := OpenGeoIPDatabases([]string{filepath.Join(t.TempDir(), "not-existing.mmdb")})
+	if errOpen == nil {
+		t.Errorf("Got no error for a not existing database, but expected one")
+	}
+}
+
+func TestGetSmbStatisticsGeoIP(t *testing.T) {
+	databases, errOpen := OpenGeoIPDatabases([]string{writeTestGeoIPDatabase(t, mmdbMap(mmdbString("country"), mmdbMap(mmdbString("iso_code"), mmdbString("DE"))))})
+	if errOpen != nil {
+		t.Fatalf("Got error '%s' but expected none", errOpen.Error())
+	}
+	defer databases.Close()
+	settings := getNewStatisticGenSettings()
+	settings.GeoIP = databases
+	shares := []smbstatusreader.ShareData{
+		{Service: "data", PID: 1117, Machine: "80.1.2.3"},
+		{Service: "data", PID: 1118, Machine: "192.168.1.242"},
+	}
+
+	found := 0
+	for _, metric := range GetSmbStatistics(nil, nil, shares, settings) {
+		if metric.Name != "client_connected_at" && metric.Name != "client_connected_since_seconds" {
+			continue
+		}
+		found++
+		expectedCountry := GEOIP_UNKNOWN
+		if metric.Labels["client_ip"] == "80.1.2.3" {
+			expectedCountry = "DE"
+		}
+		if metric.Labels["country"] != expectedCountry || metric.Labels["asn"] != GEOIP_UNKNOWN {
+			t.Errorf("Got the labels %v for '%s', but expected the country '%s'", metric.Labels, metric.Name, expectedCountry)
+		}
+	}
+	if found != 4 {
+		t.Errorf("Found %d client connection metrics, but expected 4", found)
+	}
+}
@@ -7,7 +7,10 @@ package statisticsGenerator
 
 import (
 	"fmt"
+	"path"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
@@ -27,6 +30,40 @@ type StatisticsGeneratorSettings struct {
 	DoNotExportEncryption   bool
 	DoNotExportPid          bool
 	DoNotExportShareDetails bool
+	// The names of the collectors, whose metrics are not exported
+	DisabledCollectors []string
+	// The number of the most locked files exported with their path, 0 to not export them. Limited to MAX_LOCKED_FILES_TOP_N
+	LockedFilesTopN int
+	// One of the PRIVACY_MODE_* constants. With PRIVACY_MODE_OMIT, the generator acts like DoNotExportClient, DoNotExportUser and DoNotExportShareDetails are set
+	PrivacyMode string
+	// The key used to hash the labels with PRIVACY_MODE_HASH, when empty the labels are hashed without a key
+	PrivacyHashKey []byte
+	// Selects the shares whose locks and connections are used, nil to use all shares
+	ShareFilter *ShareFilter
+	// Resolves the addresses of the clients to their host names, nil to export the addresses
+	ClientResolver *ClientResolver
+	// When set, the client connection metrics get the labels country and asn of the client address
+	GeoIP *GeoIPDatabases
+	// The addresses of the cluster nodes by their number, exported in the node label instead of the number. nil to export the numbers
+	ClusterNodeNames map[int]string
+}
+
+// MAX_LOCKED_FILES_TOP_N - The maximum number of files exported in locked_file_info, since each file is a time series
+const MAX_LOCKED_FILES_TOP_N = 25
+
+// PROTOCOL_VERSION_UNKNOWN - The value of the protocol_version label of a connection to a share, when the session of its smbd process is not known
+const PROTOCOL_VERSION_UNKNOWN = "-"
+
+// shareProtocol - A share and the protocol version of the sessions connected to it, as exported in the labels of share_connections
+type shareProtocol struct {
+	Share           string
+	ProtocolVersion string
+}
+
+// clientShare - A client connected to a share, to find the connections of a client to the same share over several cluster nodes
+type clientShare struct {
+	Client clientAddress
+	Share  string
 }
 
 type lockCreationEntry struct {
@@ -37,25 +74,46 @@ type lockCreationEntry struct {
 
 // GetSmbStatistics - Get the statistic data for prometheus out of the response data arrays
 func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstatusreader.ProcessData, shareData []smbstatusreader.ShareData, settings StatisticsGeneratorSettings) []SmbStatisticsNumeric {
-	var ret []SmbStatisticsNumeric
+	ret := []SmbStatisticsNumeric{}
+
+	if settings.PrivacyMode == PRIVACY_MODE_OMIT {
+		settings.DoNotExportClient = true
+		settings.DoNotExportUser = true
+		settings.DoNotExportShareDetails = true
+	}
+
+	lockData = settings.ShareFilter.FilterLockData(lockData)
+	shareData = settings.ShareFilter.FilterShareData(shareData)
 
 	var users []int
 	var pids []int
 	var shares []string
 	var clients []string
+	var sessionUsers []int
+	var sessionClients []string
+	var oldestConnection time.Time
+	var newestConnection time.Time
 	var sambaVersion string
 	var cluserNodeIds []int
 	var lockCreationEntries []lockCreationEntry
 	locksPerShare := make(map[string]int, 0)
-	processPerClient := make(map[string]int, 0)
+	openFilesPerShare := make(map[string][]string, 0)
+	locksPerFile := make(map[string]int, 0)
+	processPerClient := make(map[clientAddress]int, 0)
 	protocolVersionCount := make(map[string]int, 0)
 	signingMethodCount := make(map[string]int, 0)
 	encryptionMethodCount := make(map[string]int, 0)
-	clientConnectionTime := make(map[string]int64, 0)
+	encryptionStateCount := make(map[string]int, 0)
+	sessionProtocolVersion := make(map[sessionKey]string, 0)
+	connectionsPerShareProtocol := make(map[shareProtocol]int, 0)
+	clientConnectionTime := make(map[clientAddress]int64, 0)
+	clientLocation := make(map[clientAddress][]string, 0)
 	pidsPerNode := make(map[int][]int, 0)
 	locksPerNode := make(map[int]int)
 	processPerNode := make(map[int]int)
 	sharesPerNode := make(map[int]int)
+	clientsPerNode := make(map[int][]string)
+	clientShares := make(map[clientShare]bool)
 
 	for _, lock := range lockData {
 		if !intArrContains(users, lock.UserID) {
@@ -71,19 +129,11 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 		}
 
 		if lock.ClusterNodeId > -1 {
-			pidsOfNode, foundPids := pidsPerNode[lock.ClusterNodeId]
-			if !foundPids {
-				if !intArrContains(pidsOfNode, lock.PID) {
-					pidsPerNode[lock.ClusterNodeId] = append(pidsPerNode[lock.ClusterNodeId], lock.PID)
-				}
+			if !intArrContains(pidsPerNode[lock.ClusterNodeId], lock.PID) {
+				pidsPerNode[lock.ClusterNodeId] = append(pidsPerNode[lock.ClusterNodeId], lock.PID)
 			}
 
-			locksCount, foundLocks := locksPerNode[lock.ClusterNodeId]
-			if foundLocks {
-				locksPerNode[lock.ClusterNodeId] = locksCount + 1
-			} else {
-				locksPerNode[lock.ClusterNodeId] = 1
-			}
+			locksPerNode[lock.ClusterNodeId]++
 		}
 
 		locksOfShare, found := locksPerShare[lock.SharePath]
@@ -93,6 +143,13 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 			locksPerShare[lock.SharePath] = locksOfShare + 1
 		}
 
+		locksPerFile[path.Join(lock.SharePath, lock.Name)]++
+
+		// A file opened by several processes has a lock for each of them
+		if !strArrContains(openFilesPerShare[lock.SharePath], lock.Name) {
+			openFilesPerShare[lock.SharePath] = append(openFilesPerShare[lock.SharePath], lock.Name)
+		}
+
 		newEntry := lockCreationEntry{lock.UserID, lock.Time, lock.SharePath}
 		if !lockArrContainsEntry(lockCreationEntries, newEntry) {
 			lockCreationEntries = append(lockCreationEntries, newEntry)
@@ -104,6 +161,16 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 			users = append(users, process.UserID)
 		}
 
+		if !intArrContains(sessionUsers, process.UserID) {
+			sessionUsers = append(sessionUsers, process.UserID)
+		}
+
+		// The machine of a session contains the port of the connection, e. g. '10.63.0.36 (ipv4:10.63.0.36:53407)'
+		client := strings.Fields(process.Machine)
+		if len(client) > 0 && !strArrContains(sessionClients, client[0]) {
+			sessionClients = append(sessionClients, client[0])
+		}
+
 		if !intArrContains(pids, process.PID) {
 			pids = append(pids, process.PID)
 		}
@@ -113,26 +180,25 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 		}
 
 		if process.ClusterNodeId > -1 {
-			pidsOfNode, found := pidsPerNode[process.ClusterNodeId]
-			if !found {
-				if !intArrContains(pidsOfNode, process.PID) {
-					pidsPerNode[process.ClusterNodeId] = append(pidsPerNode[process.ClusterNodeId], process.PID)
-				}
+			if !intArrContains(pidsPerNode[process.ClusterNodeId], process.PID) {
+				pidsPerNode[process.ClusterNodeId] = append(pidsPerNode[process.ClusterNodeId], process.PID)
 			}
 
-			processCount, processFound := processPerNode[process.ClusterNodeId]
-			if processFound {
-				processPerNode[process.ClusterNodeId] = processCount + 1
-			} else {
-				processPerNode[process.ClusterNodeId] = 1
+			processPerNode[process.ClusterNodeId]++
+
+			if len(client) > 0 && !strArrContains(clientsPerNode[process.ClusterNodeId], client[0]) {
+				clientsPerNode[process.ClusterNodeId] = append(clientsPerNode[process.ClusterNodeId], client[0])
 			}
 		}
 
-		processOnShare, foundC := processPerClient[process.Machine]
-		if !foundC {
-			processPerClient[process.Machine] = 1
-		} else {
-			processPerClient[process.Machine] = processOnShare + 1
+		if !settings.DoNotExportClient {
+			client := settings.getClientAddress(process.Machine)
+			processOnShare, foundC := processPerClient[client]
+			if !foundC {
+				processPerClient[client] = 1
+			} else {
+				processPerClient[client] = processOnShare + 1
+			}
 		}
 
 		versionCount, foundV := protocolVersionCount[process.ProtocolVersion]
@@ -155,6 +221,8 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 		} else {
 			encryptionMethodCount[process.Encryption] = encryptionCount + 1
 		}
+		encryptionStateCount[getEncryptionState(process.Encryption)]++
+		sessionProtocolVersion[sessionKey{process.ClusterNodeId, process.PID}] = process.ProtocolVersion
 	}
 
 	for _, share := range shareData {
@@ -167,32 +235,50 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 		}
 
 		if share.ClusterNodeId > -1 {
-			pidsOfNode, found := pidsPerNode[share.ClusterNodeId]
-			if !found {
-				if !intArrContains(pidsOfNode, share.PID) {
-					pidsPerNode[share.ClusterNodeId] = append(pidsPerNode[share.ClusterNodeId], share.PID)
-				}
+			if !intArrContains(pidsPerNode[share.ClusterNodeId], share.PID) {
+				pidsPerNode[share.ClusterNodeId] = append(pidsPerNode[share.ClusterNodeId], share.PID)
 			}
 
-			sharesCount, foundShares := sharesPerNode[share.ClusterNodeId]
-			if foundShares {
-				sharesPerNode[share.ClusterNodeId] = sharesCount + 1
-			} else {
-				sharesPerNode[share.ClusterNodeId] = 1
-			}
+			sharesPerNode[share.ClusterNodeId]++
 		}
 
 		if !strArrContains(shares, share.Service) {
 			shares = append(shares, share.Service)
 		}
 
+		// The protocol version is only known for the session, joined to the connection by the smbd process
+		protocolVersion, foundP := sessionProtocolVersion[sessionKey{share.ClusterNodeId, share.PID}]
+		if !foundP || protocolVersion == "" {
+			protocolVersion = PROTOCOL_VERSION_UNKNOWN
+		}
+		connectionsPerShareProtocol[shareProtocol{share.Service, protocolVersion}]++
+
+		// The port is dropped with the address, so the connections of a client over several nodes are the same
+		clientShares[clientShare{parseMachine(share.Machine), share.Service}] = true
+
 		if !strArrContains(clients, share.Machine) {
 			clients = append(clients, share.Machine)
 		}
 
-		_, foundC := clientConnectionTime[share.Machine]
-		if !foundC {
-			clientConnectionTime[share.Machine] = share.ConnectedAt.Unix()
+		if !settings.DoNotExportClient {
+			client := settings.getClientAddress(share.Machine)
+			_, foundC := clientConnectionTime[client]
+			if !foundC {
+				clientConnectionTime[client] = share.ConnectedAt.Unix()
+				if settings.GeoIP != nil {
+					country, asn := settings.GeoIP.Lookup(client.IP)
+					clientLocation[client] = []string{country, asn}
+				}
+			}
+		}
+
+		if !share.ConnectedAt.IsZero() {
+			if oldestConnection.IsZero() || share.ConnectedAt.Before(oldestConnection) {
+				oldestConnection = share.ConnectedAt
+			}
+			if newestConnection.IsZero() || share.ConnectedAt.After(newestConnection) {
+				newestConnection = share.ConnectedAt
+			}
 		}
 	}
 
@@ -209,36 +295,51 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 	}
 
 	// TODO: Generate more metrics
-	ret = append(ret, SmbStatisticsNumeric{"individual_user_count", float64(len(users)), "The number of users connected to this samba server", nil})
-	ret = append(ret, SmbStatisticsNumeric{"locked_file_count", float64(len(lockData)), "Number of files locked by the samba server", nil})
-	ret = append(ret, SmbStatisticsNumeric{"share_count", float64(len(shares)), "Number of shares servered by the samba server", nil})
-	ret = append(ret, SmbStatisticsNumeric{"client_count", float64(len(clients)), "Number of clients using the samba server", nil})
-
-	if clusterMode {
-		ret = append(ret, SmbStatisticsNumeric{"cluster_node_count", float64(len(cluserNodeIds)), "Number of cluster nodes running the samba cluster", nil})
-		for node, pids := range pidsPerNode {
-			ret = append(ret, SmbStatisticsNumeric{"pids_per_node_count", float64(len(pids)), "Number of PIDs per cluster node", map[string]string{"node": fmt.Sprint(node)}})
-		}
+	if settings.IsCollectorEnabled(COLLECTOR_PROCESSES) {
+		ret = append(ret, SmbStatisticsNumeric{"individual_user_count", float64(len(users)), "The number of users connected to this samba server", nil})
+	}
 
-		for node, locks := range locksPerNode {
-			ret = append(ret, SmbStatisticsNumeric{"locks_per_node_count", float64(locks), "Number of Locks per cluster node", map[string]string{"node": fmt.Sprint(node)}})
-		}
+	if settings.IsCollectorEnabled(COLLECTOR_LOCKS) {
+		ret = append(ret, SmbStatisticsNumeric{"locked_file_count", float64(len(lockData)), "Number of files locked by the samba server", nil})
+	}
 
-		for node, processes := range processPerNode {
-			ret = append(ret, SmbStatisticsNumeric{"processes_per_node_count", float64(processes), "Number of Locks per cluster node", map[string]string{"node": fmt.Sprint(node)}})
-		}
+	if settings.IsCollectorEnabled(COLLECTOR_SHARES) {
+		ret = append(ret, SmbStatisticsNumeric{"share_count", float64(len(shares)), "Number of shares servered by the samba server", nil})
+		ret = append(ret, SmbStatisticsNumeric{"client_count", float64(len(clients)), "Number of clients using the samba server", nil})
+	}
 
-		for node, shares := range sharesPerNode {
-			ret = append(ret, SmbStatisticsNumeric{"shares_per_node_count", float64(shares), "Number of Shares per cluster node", map[string]string{"node": fmt.Sprint(node)}})
+	if clusterMode {
+		if settings.IsCollectorEnabled(COLLECTOR_CTDB) {
+			ret = append(ret, SmbStatisticsNumeric{"cluster_node_count", float64(len(cluserNodeIds)), "Number of cluster nodes running the samba cluster", nil})
+			ret = append(ret, SmbStatisticsNumeric{"cluster_connection_count", float64(len(shareData)), "Number of connections to a share on all cluster nodes", nil})
+			ret = append(ret, SmbStatisticsNumeric{"cluster_deduplicated_connection_count", float64(len(clientShares)),
+				"Number of connections to a share on all cluster nodes, counting the connections of a client to the same share on several nodes once", nil})
+			// Each node seen in one of the tables gets all counts, so a node without connections shows up with 0
+			nodes := []int{}
+			for _, node := range cluserNodeIds {
+				if node > -1 {
+					nodes = append(nodes, node)
+				}
+			}
+			sort.Ints(nodes)
+
+			for _, node := range nodes {
+				ret = append(ret, SmbStatisticsNumeric{"pids_per_node_count", float64(len(pidsPerNode[node])), "Number of PIDs per cluster node", settings.getNodeLabels(node)})
+				ret = append(ret, SmbStatisticsNumeric{"locks_per_node_count", float64(locksPerNode[node]), "Number of Locks per cluster node", settings.getNodeLabels(node)})
+				ret = append(ret, SmbStatisticsNumeric{"processes_per_node_count", float64(processPerNode[node]), "Number of processes per cluster node", settings.getNodeLabels(node)})
+				ret = append(ret, SmbStatisticsNumeric{"shares_per_node_count", float64(sharesPerNode[node]), "Number of Shares per cluster node", settings.getNodeLabels(node)})
+				ret = append(ret, SmbStatisticsNumeric{"clients_per_node_count", float64(len(clientsPerNode[node])), "Number of different machines with a session on the cluster node", settings.getNodeLabels(node)})
+			}
 		}
-
-	} else {
+	} else if settings.IsCollectorEnabled(COLLECTOR_PROCESSES) {
 		ret = append(ret, SmbStatisticsNumeric{"pid_count", float64(len(pids)), "Number of processes running by the samba server", nil})
 	}
 
-	ret = append(ret, SmbStatisticsNumeric{"server_information", 1, "Version of the samba server", map[string]string{"version": sambaVersion}})
+	if settings.IsCollectorEnabled(COLLECTOR_PROCESSES) {
+		ret = append(ret, SmbStatisticsNumeric{"server_information", 1, "Version of the samba server", map[string]string{"version": sambaVersion}})
+	}
 
-	if !settings.DoNotExportShareDetails {
+	if !settings.DoNotExportShareDetails && settings.IsCollectorEnabled(COLLECTOR_LOCKS) {
 		if len(locksPerShare) > 0 {
 			for share, locks := range locksPerShare {
 				ret = append(ret, SmbStatisticsNumeric{"locks_per_share_count", float64(locks), "Number of locks on share", map[string]string{"share": share}})
@@ -249,7 +350,7 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 		}
 	}
 
-	if !settings.DoNotExportEncryption {
+	if !settings.DoNotExportEncryption && settings.IsCollectorEnabled(COLLECTOR_PROCESSES) {
 		if len(protocolVersionCount) > 0 {
 			for version, count := range protocolVersionCount {
 				ret = append(ret, SmbStatisticsNumeric{"protocol_version_count", float64(count), "Number of processes on the server using the protocol", map[string]string{"protocol_version": version}})
@@ -273,32 +374,54 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 		} else {
 			ret = append(ret, SmbStatisticsNumeric{"encryption_method_count", float64(0), "Number of processes on the server using the encryption", map[string]string{"encryption": ""}})
 		}
+
+		ret = append(ret, SmbStatisticsNumeric{"encrypted_session_count", float64(encryptionStateCount[ENCRYPTION_STATE_ENCRYPTED]),
+			"Number of sessions encrypting all connections to the shares", nil})
+		ret = append(ret, SmbStatisticsNumeric{"partially_encrypted_session_count", float64(encryptionStateCount[ENCRYPTION_STATE_PARTIAL]),
+			"Number of sessions encrypting only the connections to the shares requiring encryption", nil})
+		ret = append(ret, SmbStatisticsNumeric{"unencrypted_session_count", float64(encryptionStateCount[ENCRYPTION_STATE_UNENCRYPTED]),
+			"Number of sessions not encrypting any connection", nil})
 	}
 
-	if !settings.DoNotExportClient {
+	if !settings.DoNotExportClient && settings.IsCollectorEnabled(COLLECTOR_PROCESSES) {
 		if len(processPerClient) > 0 {
 			for client, count := range processPerClient {
-				ret = append(ret, SmbStatisticsNumeric{"process_per_client_count", float64(count), "Number of processes on the server used by one client", map[string]string{"client": client}})
+				ret = append(ret, SmbStatisticsNumeric{"process_per_client_count", float64(count), "Number of processes on the server used by one client", client.getLabels()})
 			}
 		} else {
-			ret = append(ret, SmbStatisticsNumeric{"process_per_client_count", float64(0), "Number of processes on the server used by one client", map[string]string{"client": ""}})
+			ret = append(ret, SmbStatisticsNumeric{"process_per_client_count", float64(0), "Number of processes on the server used by one client", clientAddress{}.getLabels()})
 		}
+	}
 
+	if !(settings.DoNotExportShareDetails || settings.DoNotExportEncryption) && settings.IsCollectorEnabled(COLLECTOR_SHARES) {
+		if len(connectionsPerShareProtocol) > 0 {
+			for connection, count := range connectionsPerShareProtocol {
+				ret = append(ret, SmbStatisticsNumeric{"share_connections", float64(count), "Number of connections to a share by sessions using the protocol version",
+					map[string]string{"share": connection.Share, "protocol_version": connection.ProtocolVersion}})
+			}
+		} else {
+			// Add this value even if no connections found, so prometheus description will be created
+			ret = append(ret, SmbStatisticsNumeric{"share_connections", float64(0), "Number of connections to a share by sessions using the protocol version",
+				map[string]string{"share": "", "protocol_version": ""}})
+		}
+	}
+
+	if !settings.DoNotExportClient && settings.IsCollectorEnabled(COLLECTOR_SHARES) {
 		if len(clientConnectionTime) > 0 {
 			for client, connectTime := range clientConnectionTime {
-				ret = append(ret, SmbStatisticsNumeric{"client_connected_at", float64(connectTime), "Unix time stamp a client connected", map[string]string{"client": client}})
+				ret = append(ret, SmbStatisticsNumeric{"client_connected_at", float64(connectTime), "Unix time stamp a client connected", settings.getClientLabels(client, clientLocation[client])})
 				now := time.Now()
 				connected_since := now.Sub(time.Unix(connectTime, 0))
-				ret = append(ret, SmbStatisticsNumeric{"client_connected_since_seconds", connected_since.Seconds(), "Seconds since a client connected", map[string]string{"client": client}})
+				ret = append(ret, SmbStatisticsNumeric{"client_connected_since_seconds", connected_since.Seconds(), "Seconds since a client connected", settings.getClientLabels(client, clientLocation[client])})
 			}
 		} else {
 			// Add this values even if no locks found, so prometheus description will be created
-			ret = append(ret, SmbStatisticsNumeric{"client_connected_at", float64(0), "Unix time stamp a client connected", map[string]string{"client": ""}})
-			ret = append(ret, SmbStatisticsNumeric{"client_connected_since_seconds", float64(0), "Seconds since a client connected", map[string]string{"client": ""}})
+			ret = append(ret, SmbStatisticsNumeric{"client_connected_at", float64(0), "Unix time stamp a client connected", settings.getClientLabels(clientAddress{}, nil)})
+			ret = append(ret, SmbStatisticsNumeric{"client_connected_since_seconds", float64(0), "Seconds since a client connected", settings.getClientLabels(clientAddress{}, nil)})
 		}
 	}
 
-	if !(settings.DoNotExportUser || settings.DoNotExportShareDetails) {
+	if !(settings.DoNotExportUser || settings.DoNotExportShareDetails) && settings.IsCollectorEnabled(COLLECTOR_LOCKS) {
 		if len(lockCreationEntries) > 0 {
 			for _, lockEntry := range lockCreationEntries {
 				ret = append(ret, SmbStatisticsNumeric{"lock_created_at", float64(lockEntry.CreationTime.Unix()),
@@ -315,9 +438,140 @@ func GetSmbStatistics(lockData []smbstatusreader.LockData, processData []smbstat
 		}
 	}
 
+	if settings.IsCollectorEnabled(COLLECTOR_PROCESSES) {
+		// The version in the usual *_info style, to join it with other metrics
+		ret = append(ret, SmbStatisticsNumeric{"version_info", 1, "Version of the samba server, always 1", map[string]string{"version": sambaVersion}})
+		ret = append(ret, SmbStatisticsNumeric{"unique_client_count", float64(len(sessionClients)), "Number of different machines with a session on the samba server", nil})
+		ret = append(ret, SmbStatisticsNumeric{"unique_user_count", float64(len(sessionUsers)), "Number of different users with a session on the samba server", nil})
+		ret = append(ret, getSessionLastConnectOrLockAgeStatistics(lockData, processData, shareData, settings, time.Now())...)
+	}
+
+	if !settings.DoNotExportShareDetails && settings.IsCollectorEnabled(COLLECTOR_LOCKS) {
+		if len(openFilesPerShare) > 0 {
+			for share, files := range openFilesPerShare {
+				ret = append(ret, SmbStatisticsNumeric{"open_files", float64(len(files)), "Number of different files open on share", map[string]string{"share": share}})
+			}
+		} else {
+			// Add this value even if no locks found, so prometheus description will be created
+			ret = append(ret, SmbStatisticsNumeric{"open_files", float64(0), "Number of different files open on share", map[string]string{"share": ""}})
+		}
+	}
+
+	if settings.LockedFilesTopN > 0 && !settings.DoNotExportShareDetails && settings.IsCollectorEnabled(COLLECTOR_LOCKS) {
+		files := getMostLockedFiles(locksPerFile, settings.LockedFilesTopN)
+		if len(files) > 0 {
+			for _, file := range files {
+				ret = append(ret, SmbStatisticsNumeric{"locked_file_info", float64(locksPerFile[file]), "Number of locks on one of the most locked files", map[string]string{"path": file}})
+			}
+		} else {
+			// Add this value even if no locks found, so prometheus description will be created
+			ret = append(ret, SmbStatisticsNumeric{"locked_file_info", float64(0), "Number of locks on one of the most locked files", map[string]string{"path": ""}})
+		}
+	}
+
+	if settings.IsCollectorEnabled(COLLECTOR_SHARES) {
+		// Without connections, both ages are 0
+		oldestAge := float64(0)
+		newestAge := float64(0)
+		if !oldestConnection.IsZero() {
+			now := time.Now()
+			oldestAge = now.Sub(oldestConnection).Seconds()
+			newestAge = now.Sub(newestConnection).Seconds()
+		}
+		ret = append(ret, SmbStatisticsNumeric{"oldest_connection_age_seconds", oldestAge, "Seconds since the oldest active connection to a share was established", nil})
+		ret = append(ret, SmbStatisticsNumeric{"newest_connection_age_seconds", newestAge, "Seconds since the newest active connection to a share was established", nil})
+	}
+
+	if settings.PrivacyMode == PRIVACY_MODE_HASH {
+		hashPersonalLabels(ret, settings.PrivacyHashKey)
+	}
+
 	return ret
 }
 
+// The encryption states of a session, see getEncryptionState
+const (
+	ENCRYPTION_STATE_ENCRYPTED   = "encrypted"
+	ENCRYPTION_STATE_PARTIAL     = "partial"
+	ENCRYPTION_STATE_UNENCRYPTED = "unencrypted"
+)
+
+// getEncryptionState - Get the encryption state out of the encryption smbstatus prints for a session: '-' when the session is not encrypted,
+// the cipher like 'AES-128-GCM' when all is encrypted, or 'partial(AES-128-GCM)' when only the connections to some shares are encrypted
+func getEncryptionState(encryption string) string {
+	encryption = strings.TrimSpace(encryption)
+	if encryption == "" || encryption == "-" {
+		return ENCRYPTION_STATE_UNENCRYPTED
+	}
+	if strings.HasPrefix(encryption, "partial(") {
+		return ENCRYPTION_STATE_PARTIAL
+	}
+
+	return ENCRYPTION_STATE_ENCRYPTED
+}
+
+// getNodeLabels - Get the labels of a per node metric. The node is given by its address, when it is in the ClusterNodeNames
+func (settings StatisticsGeneratorSettings) getNodeLabels(node int) map[string]string {
+	if name, found := settings.ClusterNodeNames[node]; found && name != "" {
+		return map[string]string{"node": name}
+	}
+
+	return map[string]string{"node": fmt.Sprint(node)}
+}
+
+// getClientAddress - Get the address and the host name of the machine of a client. With a ClientResolver, the host name is looked up
+func (settings StatisticsGeneratorSettings) getClientAddress(machine string) clientAddress {
+	address := parseMachine(machine)
+	if settings.ClientResolver != nil && address.IP != "" {
+		name := settings.ClientResolver.Resolve(address.IP)
+		if name != address.IP {
+			address.Host = name
+		}
+	}
+
+	return address
+}
+
+// getClientLabels - Get the labels of a client connection metric. With GeoIP, the location contains the country and the asn of the client
+func (settings StatisticsGeneratorSettings) getClientLabels(client clientAddress, location []string) map[string]string {
+	labels := client.getLabels()
+	if settings.GeoIP == nil {
+		return labels
+	}
+
+	labels["country"], labels["asn"] = "", ""
+	if len(location) == 2 {
+		labels["country"], labels["asn"] = location[0], location[1]
+	}
+
+	return labels
+}
+
+// getMostLockedFiles - Get the paths of the count files with the most locks. Files with the same number of locks are sorted by path,
+// so the same files are exported by each scrape
+func getMostLockedFiles(locksPerFile map[string]int, count int) []string {
+	if count > MAX_LOCKED_FILES_TOP_N {
+		count = MAX_LOCKED_FILES_TOP_N
+	}
+
+	files := make([]string, 0, len(locksPerFile))
+	for file := range locksPerFile {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if locksPerFile[files[i]] != locksPerFile[files[j]] {
+			return locksPerFile[files[i]] > locksPerFile[files[j]]
+		}
+		return files[i] < files[j]
+	})
+
+	if len(files) > count {
+		return files[:count]
+	}
+
+	return files
+}
+
 func intArrContains(arr []int, value int) bool {
 	for _, field := range arr {
 		if field == value {
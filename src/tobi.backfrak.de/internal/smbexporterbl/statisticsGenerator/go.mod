@@ -1,6 +1,9 @@
 
 module tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator
 
+require github.com/oschwald/maxminddb-golang v1.12.0
+require golang.org/x/sys v0.10.0 // indirect
+
 require tobi.backfrak.de/internal/smbexporterbl/smbstatusreader v0.0.0
 replace tobi.backfrak.de/internal/smbexporterbl/smbstatusreader v0.0.0 => ../smbstatusreader
 
@@ -0,0 +1,22 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+// The metrics holding cumulative values, that only increase as long as the labels stay the same
+var counterMetrics = map[string]bool{
+	"smbd_io_counter_read_count_total":            true,
+	"smbd_io_counter_write_count_total":           true,
+	"smbd_io_counter_read_bytes_total":            true,
+	"smbd_io_counter_write_bytes_total":           true,
+	"smbd_voluntary_context_switch_count_total":   true,
+	"smbd_involuntary_context_switch_count_total": true,
+}
+
+// IsCounter - Tell if the metric with the given name is a counter. All other metrics are gauges, holding a state.
+// The sums over all smbd processes are gauges as well, since they decrease when a process ends
+func IsCounter(name string) bool {
+	return counterMetrics[name]
+}
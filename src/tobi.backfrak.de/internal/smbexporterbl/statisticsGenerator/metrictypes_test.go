@@ -0,0 +1,34 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestIsCounter(t *testing.T) {
+	counters := 0
+	for _, metric := range GetSmbdMetrics([]commonbl.PsUtilPidData{{PID: 12, IoCounterReadCount: 3}}, false) {
+		if IsCounter(metric.Name) {
+			counters++
+			if metric.Labels["pid"] == "" {
+				t.Errorf("The counter '%s' has no pid label", metric.Name)
+			}
+		}
+	}
+
+	if counters != 6 {
+		t.Errorf("Got '%d' counters, but expected '6'", counters)
+	}
+
+	for _, name := range []string{"smbd_sum_io_counter_read_count", "smbd_sum_involuntary_context_switch_count", "locked_file_count", "client_connected_since_seconds"} {
+		if IsCounter(name) {
+			t.Errorf("The metric '%s' is a counter, but should be a gauge", name)
+		}
+	}
+}
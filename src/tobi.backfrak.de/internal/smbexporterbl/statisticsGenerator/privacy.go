@@ -0,0 +1,73 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// The privacy modes of the exporter
+const (
+	// The labels identifying persons are exported as they are
+	PRIVACY_MODE_NONE = ""
+	// The values of the labels identifying persons are replaced by a hash
+	PRIVACY_MODE_HASH = "hash"
+	// The metrics with labels identifying persons are not exported
+	PRIVACY_MODE_OMIT = "omit"
+)
+
+// The labels whose values can identify a person, like a user ID, the machine of a client or a path containing a user name
+var personalLabels = []string{"user", "client_ip", "client_host", "share", "path"}
+
+// GetPrivacyModes - Get the names of all privacy modes
+func GetPrivacyModes() []string {
+	return []string{PRIVACY_MODE_HASH, PRIVACY_MODE_OMIT}
+}
+
+// CheckPrivacyMode - Get an error, when the mode is not a known privacy mode
+func CheckPrivacyMode(mode string) error {
+	if mode == PRIVACY_MODE_NONE || strArrContains(GetPrivacyModes(), mode) {
+		return nil
+	}
+
+	return fmt.Errorf("the privacy mode '%s' is not known, use one of %v", mode, GetPrivacyModes())
+}
+
+// hashPersonalLabels - Replace the values of the labels identifying persons by their hash.
+// The same value always gets the same hash, so the metrics can still be grouped by the label
+func hashPersonalLabels(stats []SmbStatisticsNumeric, key []byte) {
+	for _, stat := range stats {
+		for _, label := range personalLabels {
+			value, found := stat.Labels[label]
+			if found {
+				stat.Labels[label] = hashLabelValue(value, key)
+			}
+		}
+	}
+}
+
+// hashLabelValue - Get the hash of a label value. With a key, a HMAC is used, so the values can not be guessed by hashing
+// all possible values, e. g. all user IDs. An empty value is kept, it does not identify anyone
+func hashLabelValue(value string, key []byte) string {
+	if value == "" {
+		return value
+	}
+
+	var sum []byte
+	if len(key) > 0 {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		sum = mac.Sum(nil)
+	} else {
+		hash := sha256.Sum256([]byte(value))
+		sum = hash[:]
+	}
+
+	return hex.EncodeToString(sum[:8])
+}
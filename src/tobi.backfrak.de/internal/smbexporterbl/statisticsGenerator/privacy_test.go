@@ -0,0 +1,91 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+	"tobi.backfrak.de/internal/smbstatusout"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+func TestCheckPrivacyMode(t *testing.T) {
+	for _, mode := range []string{PRIVACY_MODE_NONE, PRIVACY_MODE_HASH, PRIVACY_MODE_OMIT} {
+		if err := CheckPrivacyMode(mode); err != nil {
+			t.Errorf("Got error '%s' for the mode '%s' but expected none", err.Error(), mode)
+		}
+	}
+
+	if err := CheckPrivacyMode("anonymize"); err == nil {
+		t.Errorf("Got no error for an unknown mode, but expected one")
+	}
+}
+
+func TestHashLabelValue(t *testing.T) {
+	hash := hashLabelValue("1080", nil)
+	if hash == "1080" || len(hash) != 16 {
+		t.Errorf("The hash '%s' is not the expected", hash)
+	}
+	if hashLabelValue("1080", nil) != hash {
+		t.Errorf("The same value got different hashes")
+	}
+	if hashLabelValue("1081", nil) == hash {
+		t.Errorf("Different values got the same hash")
+	}
+	if hashLabelValue("1080", []byte("secret")) == hash {
+		t.Errorf("The hash with a key is the same as without")
+	}
+	if hashLabelValue("", nil) != "" {
+		t.Errorf("An empty value was hashed")
+	}
+}
+
+func TestGetSmbStatisticsPrivacyMode(t *testing.T) {
+	logger := testhelper.NewTestLogger(true)
+	locks := smbstatusreader.GetLockData(smbstatusout.LockData4Lines, logger)
+	shares := smbstatusreader.GetShareData(smbstatusout.ShareData4Lines, logger)
+	processes := smbstatusreader.GetProcessData(smbstatusout.ProcessData4Lines, logger)
+
+	plain := GetSmbStatistics(locks, processes, shares, getNewStatisticGenSettings())
+
+	settings := getNewStatisticGenSettings()
+	settings.PrivacyMode = PRIVACY_MODE_HASH
+	hashed := GetSmbStatistics(locks, processes, shares, settings)
+	if len(hashed) != len(plain) {
+		t.Fatalf("Got %d metrics in hash mode, but expected %d", len(hashed), len(plain))
+	}
+	plainValues := map[string]bool{}
+	for _, metric := range plain {
+		for _, label := range personalLabels {
+			if value := metric.Labels[label]; value != "" {
+				plainValues[value] = true
+			}
+		}
+	}
+	if len(plainValues) == 0 {
+		t.Fatalf("The test data has no personal labels")
+	}
+	for _, metric := range hashed {
+		for _, label := range personalLabels {
+			if plainValues[metric.Labels[label]] {
+				t.Errorf("The label '%s' of '%s' was not hashed", label, metric.Name)
+			}
+		}
+		if metric.Name == "version_info" && metric.Labels["version"] == hashLabelValue(processes[0].SambaVersion, nil) {
+			t.Errorf("The label 'version' of '%s' was hashed", metric.Name)
+		}
+	}
+
+	settings.PrivacyMode = PRIVACY_MODE_OMIT
+	for _, metric := range GetSmbStatistics(locks, processes, shares, settings) {
+		for _, label := range personalLabels {
+			if _, found := metric.Labels[label]; found {
+				t.Errorf("Got the label '%s' of '%s' in omit mode", label, metric.Name)
+			}
+		}
+	}
+}
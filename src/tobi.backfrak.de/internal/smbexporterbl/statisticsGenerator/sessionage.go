@@ -0,0 +1,121 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// sessionKey - Identifies the smbd process of a session, also in cluster mode where the PIDs of the nodes can be the same
+type sessionKey struct {
+	ClusterNodeId int
+	PID           int
+}
+
+// getLabel - Get the value of the pid label of the session, like smbstatus prints it, e. g. '1117' or '1:1117' in cluster mode
+func (key sessionKey) getLabel() string {
+	if key.ClusterNodeId > -1 {
+		return fmt.Sprintf("%d:%d", key.ClusterNodeId, key.PID)
+	}
+
+	return strconv.Itoa(key.PID)
+}
+
+// getSessionLastActivity - Get the time of the last activity smbstatus tells about for each session: The newest connection
+// to a share or the newest lock of its smbd process. smbstatus does not print when a client used the session the last time,
+// so the age of a session holding an old lock or connection grows, even when the client still reads and writes.
+// Sessions without a connection to a share and without locks are not in the map
+func getSessionLastActivity(lockData []smbstatusreader.LockData, shareData []smbstatusreader.ShareData) map[sessionKey]time.Time {
+	ret := make(map[sessionKey]time.Time)
+	setNewer := func(key sessionKey, activity time.Time) {
+		if activity.IsZero() {
+			return
+		}
+		last, found := ret[key]
+		if !found || activity.After(last) {
+			ret[key] = activity
+		}
+	}
+
+	for _, share := range shareData {
+		setNewer(sessionKey{share.ClusterNodeId, share.PID}, share.ConnectedAt)
+	}
+	for _, lock := range lockData {
+		setNewer(sessionKey{lock.ClusterNodeId, lock.PID}, lock.Time)
+	}
+
+	return ret
+}
+
+// getSessionLastConnectOrLockAgeStatistics - Get the seconds since each session connected to a share or locked a file the last time,
+// and the longest and average of these ages. This is no idle time, smbstatus does not tell it.
+// The sessions are the entries of the process table, the ones without connection and lock are skipped
+func getSessionLastConnectOrLockAgeStatistics(lockData []smbstatusreader.LockData, processData []smbstatusreader.ProcessData, shareData []smbstatusreader.ShareData,
+	settings StatisticsGeneratorSettings, now time.Time) []SmbStatisticsNumeric {
+	ret := []SmbStatisticsNumeric{}
+	lastActivity := getSessionLastActivity(lockData, shareData)
+	help := "Seconds since the session connected to a share or locked a file the last time"
+
+	ageSum := float64(0)
+	ageMax := float64(0)
+	ageCount := 0
+	for _, process := range processData {
+		key := sessionKey{process.ClusterNodeId, process.PID}
+		activity, found := lastActivity[key]
+		if !found {
+			continue
+		}
+		// Delete the session, so a process listed twice is counted once
+		delete(lastActivity, key)
+
+		age := now.Sub(activity).Seconds()
+		if age < 0 {
+			// The clocks of the cluster nodes differ
+			age = 0
+		}
+		ageSum += age
+		ageCount++
+		if age > ageMax {
+			ageMax = age
+		}
+
+		if !settings.DoNotExportPid {
+			ret = append(ret, SmbStatisticsNumeric{"session_last_connect_or_lock_age_seconds", age, help, settings.getSessionLabels(key.getLabel(), process.Machine)})
+		}
+	}
+
+	if ageCount == 0 && !settings.DoNotExportPid {
+		// Add this value even if no session found, so prometheus description will be created
+		ret = append(ret, SmbStatisticsNumeric{"session_last_connect_or_lock_age_seconds", float64(0), help, settings.getSessionLabels("", "")})
+	}
+
+	ageAverage := float64(0)
+	if ageCount > 0 {
+		ageAverage = ageSum / float64(ageCount)
+	}
+	ret = append(ret, SmbStatisticsNumeric{"longest_session_last_connect_or_lock_age_seconds", ageMax, "Most seconds since a session connected to a share or locked a file the last time", nil})
+	ret = append(ret, SmbStatisticsNumeric{"average_session_last_connect_or_lock_age_seconds", ageAverage, "Average seconds since the sessions connected to a share or locked a file the last time", nil})
+
+	return ret
+}
+
+// getSessionLabels - Get the labels of the session_last_connect_or_lock_age_seconds metric, the pid and, when exported, the client of the session
+func (settings StatisticsGeneratorSettings) getSessionLabels(pid string, machine string) map[string]string {
+	labels := map[string]string{"pid": pid}
+	if settings.DoNotExportClient {
+		return labels
+	}
+
+	for name, value := range settings.getClientAddress(machine).getLabels() {
+		labels[name] = value
+	}
+
+	return labels
+}
@@ -0,0 +1,87 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+func TestGetSessionLastConnectOrLockAgeStatistics(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	processes := []smbstatusreader.ProcessData{{PID: 1117, ClusterNodeId: -1, Machine: "192.168.1.242 (ipv4:192.168.1.242:42296)"},
+		{PID: 1119, ClusterNodeId: -1, Machine: "192.168.1.243"}, {PID: 1120, ClusterNodeId: -1, Machine: "192.168.1.244"}}
+	shares := []smbstatusreader.ShareData{{PID: 1117, ClusterNodeId: -1, ConnectedAt: now.Add(-time.Hour)},
+		{PID: 1119, ClusterNodeId: -1, ConnectedAt: now.Add(-3 * time.Hour)}}
+	// The lock of 1117 is newer than its connection
+	locks := []smbstatusreader.LockData{{PID: 1117, ClusterNodeId: -1, Time: now.Add(-10 * time.Minute)}}
+
+	ret := getSessionLastConnectOrLockAgeStatistics(locks, processes, shares, getNewStatisticGenSettings(), now)
+
+	// The process 1120 has no activity and is skipped
+	if len(ret) != 4 {
+		t.Fatalf("Got %d values but expected 4", len(ret))
+	}
+	if ret[0].Name != "session_last_connect_or_lock_age_seconds" || ret[0].Value != 600 || ret[0].Labels["pid"] != "1117" || ret[0].Labels["client_ip"] != "192.168.1.242" {
+		t.Errorf("The session_last_connect_or_lock_age_seconds '%v' of the process 1117 is not expected", ret[0])
+	}
+	if ret[1].Value != 10800 || ret[1].Labels["pid"] != "1119" {
+		t.Errorf("The session_last_connect_or_lock_age_seconds '%v' of the process 1119 is not expected", ret[1])
+	}
+	if ret[2].Name != "longest_session_last_connect_or_lock_age_seconds" || ret[2].Value != 10800 {
+		t.Errorf("The longest_session_last_connect_or_lock_age_seconds '%v' is not expected", ret[2])
+	}
+	if ret[3].Name != "average_session_last_connect_or_lock_age_seconds" || ret[3].Value != 5700 {
+		t.Errorf("The average_session_last_connect_or_lock_age_seconds '%v' is not expected", ret[3])
+	}
+}
+
+func TestGetSessionLastConnectOrLockAgeStatisticsNoSessions(t *testing.T) {
+	settings := getNewStatisticGenSettings()
+	ret := getSessionLastConnectOrLockAgeStatistics(nil, nil, nil, settings, time.Now())
+
+	if len(ret) != 3 {
+		t.Fatalf("Got %d values but expected 3", len(ret))
+	}
+	if ret[0].Name != "session_last_connect_or_lock_age_seconds" || ret[0].Value != 0 || ret[0].Labels["pid"] != "" || len(ret[0].Labels) != 3 {
+		t.Errorf("The session_last_connect_or_lock_age_seconds '%v' is not expected", ret[0])
+	}
+	if ret[1].Value != 0 || ret[2].Value != 0 {
+		t.Errorf("The aggregated ages '%v' are not 0", ret[1:])
+	}
+
+	settings.DoNotExportPid = true
+	settings.DoNotExportClient = true
+	ret = getSessionLastConnectOrLockAgeStatistics(nil, nil, nil, settings, time.Now())
+	if len(ret) != 2 || ret[0].Name != "longest_session_last_connect_or_lock_age_seconds" {
+		t.Errorf("Got the values '%v', but expected only the aggregated ages", ret)
+	}
+}
+
+func TestGetSessionLastConnectOrLockAgeStatisticsCluster(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	settings := getNewStatisticGenSettings()
+	settings.DoNotExportClient = true
+	// The nodes 0 and 1 run a process with the same PID
+	processes := []smbstatusreader.ProcessData{{PID: 1117, ClusterNodeId: 0}, {PID: 1117, ClusterNodeId: 1}}
+	shares := []smbstatusreader.ShareData{{PID: 1117, ClusterNodeId: 0, ConnectedAt: now.Add(-time.Minute)},
+		{PID: 1117, ClusterNodeId: 1, ConnectedAt: now.Add(time.Minute)}}
+
+	ret := getSessionLastConnectOrLockAgeStatistics(nil, processes, shares, settings, now)
+
+	if len(ret) != 4 {
+		t.Fatalf("Got %d values but expected 4", len(ret))
+	}
+	if ret[0].Labels["pid"] != "0:1117" || ret[0].Value != 60 || len(ret[0].Labels) != 1 {
+		t.Errorf("The session_last_connect_or_lock_age_seconds '%v' of the node 0 is not expected", ret[0])
+	}
+	// The clock of node 1 is ahead
+	if ret[1].Labels["pid"] != "1:1117" || ret[1].Value != 0 {
+		t.Errorf("The session_last_connect_or_lock_age_seconds '%v' of the node 1 is not expected", ret[1])
+	}
+}
@@ -0,0 +1,103 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"strconv"
+	"strings"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// The smb.conf parameters exported as labels of share_config_info, by their label, with the value samba uses when the parameter is not set.
+// testparm does not print parameters with the default value
+var shareConfigInfoParameters = []struct {
+	label        string
+	parameter    string
+	defaultValue string
+}{
+	{"read_only", "read only", "Yes"},
+	{"guest_ok", "guest ok", "No"},
+	{"vfs_objects", "vfs objects", ""},
+	{"max_connections", "max connections", "0"},
+}
+
+// GetShareConfigStatistics - Get the number of shares configured in the smb.conf, the number of them with connections and the number of them
+// without. The share names are compared case insensitive, like samba does. Connections to shares not in the smb.conf, like the home directories
+// of the [homes] section or IPC$, are not counted. The shares not included by the ShareFilter are skipped. Unless the share details are not exported,
+// share_config_info tells some parameters of each share and share_max_connections_utilization the use of the shares with a 'max connections' limit
+func GetShareConfigStatistics(configs []commonbl.ShareConfig, shareData []smbstatusreader.ShareData, settings StatisticsGeneratorSettings) []SmbStatisticsNumeric {
+	connections := make(map[string]int)
+	for _, share := range settings.ShareFilter.FilterShareData(shareData) {
+		connections[strings.ToLower(share.Service)]++
+	}
+
+	configured := make(map[string]bool)
+	included := []commonbl.ShareConfig{}
+	active := 0
+	for _, config := range configs {
+		name := strings.ToLower(config.Name)
+		if configured[name] || !settings.ShareFilter.IsIncluded(config.Name) {
+			continue
+		}
+		configured[name] = true
+		included = append(included, config)
+		if connections[name] > 0 {
+			active++
+		}
+	}
+
+	var ret []SmbStatisticsNumeric
+	ret = append(ret, SmbStatisticsNumeric{"configured_share_count", float64(len(configured)), "Number of shares configured in the smb.conf", nil})
+	ret = append(ret, SmbStatisticsNumeric{"active_share_count", float64(active), "Number of shares configured in the smb.conf with at least one connection", nil})
+	ret = append(ret, SmbStatisticsNumeric{"unused_share_count", float64(len(configured) - active), "Number of shares configured in the smb.conf without connections", nil})
+	if !settings.DoNotExportShareDetails && settings.PrivacyMode != PRIVACY_MODE_OMIT {
+		ret = append(ret, getShareConfigInfo(included)...)
+		ret = append(ret, getMaxConnectionsUtilization(included, connections)...)
+	}
+
+	if settings.PrivacyMode == PRIVACY_MODE_HASH {
+		hashPersonalLabels(ret, settings.PrivacyHashKey)
+	}
+
+	return ret
+}
+
+// getShareConfigInfo - Get the share_config_info of each share, with the value 1 and the parameters in the labels
+func getShareConfigInfo(configs []commonbl.ShareConfig) []SmbStatisticsNumeric {
+	ret := []SmbStatisticsNumeric{}
+	for _, config := range configs {
+		labels := map[string]string{"share": config.Name}
+		for _, info := range shareConfigInfoParameters {
+			value, found := config.Parameters[info.parameter]
+			if !found {
+				value = info.defaultValue
+			}
+			labels[info.label] = value
+		}
+		ret = append(ret, SmbStatisticsNumeric{"share_config_info", 1, "Parameters of a share configured in the smb.conf, in the labels", labels})
+	}
+
+	return ret
+}
+
+// getMaxConnectionsUtilization - Get the connections to each share with a 'max connections' limit, as fraction of the limit.
+// Samba refuses further connections at 1. Shares without a limit are skipped
+func getMaxConnectionsUtilization(configs []commonbl.ShareConfig, connections map[string]int) []SmbStatisticsNumeric {
+	ret := []SmbStatisticsNumeric{}
+	for _, config := range configs {
+		maxConnections, errConv := strconv.Atoi(config.Parameters["max connections"])
+		if errConv != nil || maxConnections <= 0 {
+			continue
+		}
+		utilization := float64(connections[strings.ToLower(config.Name)]) / float64(maxConnections)
+		ret = append(ret, SmbStatisticsNumeric{"share_max_connections_utilization", utilization,
+			"Connections to the share as fraction of its 'max connections' limit, no further connections are accepted at 1", map[string]string{"share": config.Name}})
+	}
+
+	return ret
+}
@@ -0,0 +1,109 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+func getTestShareConfigs() []commonbl.ShareConfig {
+	return []commonbl.ShareConfig{{Name: "data"}, {Name: "Backup"}, {Name: "public"}, {Name: "homes"}}
+}
+
+func TestGetShareConfigStatistics(t *testing.T) {
+	shares := []smbstatusreader.ShareData{{Service: "data", PID: 1117}, {Service: "data", PID: 1118}, {Service: "backup", PID: 1117},
+		{Service: "IPC$", PID: 1117}, {Service: "tobi", PID: 1119}}
+
+	ret := GetShareConfigStatistics(getTestShareConfigs(), shares, getNewStatisticGenSettings())
+
+	if len(ret) != 7 {
+		t.Fatalf("Got %d values but expected 7", len(ret))
+	}
+	if ret[0].Name != "configured_share_count" || ret[0].Value != 4 {
+		t.Errorf("The configured_share_count '%v' is not expected", ret[0])
+	}
+	// IPC$ and the home directory 'tobi' are not in the smb.conf
+	if ret[1].Name != "active_share_count" || ret[1].Value != 2 {
+		t.Errorf("The active_share_count '%v' is not expected", ret[1])
+	}
+	if ret[2].Name != "unused_share_count" || ret[2].Value != 2 {
+		t.Errorf("The unused_share_count '%v' is not expected", ret[2])
+	}
+}
+
+func TestGetShareConfigStatisticsFiltered(t *testing.T) {
+	settings := getNewStatisticGenSettings()
+	filter, errFilter := NewShareFilter("", "^(homes|public)$")
+	if errFilter != nil {
+		t.Fatalf("Got error '%s' but expected none", errFilter.Error())
+	}
+	settings.ShareFilter = filter
+
+	ret := GetShareConfigStatistics(getTestShareConfigs(), []smbstatusreader.ShareData{{Service: "public", PID: 1117}}, settings)
+
+	if ret[0].Value != 2 || ret[1].Value != 0 || ret[2].Value != 2 {
+		t.Errorf("Got the counts '%v', but expected 2 configured and 2 unused shares", ret)
+	}
+	if len(ret) != 5 || ret[3].Labels["share"] != "data" || ret[4].Labels["share"] != "Backup" {
+		t.Errorf("Got the values '%v', but expected the share_config_info of the shares 'data' and 'Backup'", ret)
+	}
+}
+
+func TestGetShareConfigStatisticsInfo(t *testing.T) {
+	configs := []commonbl.ShareConfig{{Name: "data", Parameters: map[string]string{"read only": "No", "vfs objects": "recycle full_audit", "max connections": "10"}},
+		{Name: "public", Parameters: map[string]string{"guest ok": "Yes"}}}
+
+	ret := GetShareConfigStatistics(configs, nil, getNewStatisticGenSettings())
+
+	// The share 'data' has the max_connections_utilization in addition
+	if len(ret) != 6 {
+		t.Fatalf("Got %d values but expected 6", len(ret))
+	}
+	expected := map[string]string{"share": "data", "read_only": "No", "guest_ok": "No", "vfs_objects": "recycle full_audit", "max_connections": "10"}
+	if ret[3].Name != "share_config_info" || ret[3].Value != 1 || fmt.Sprint(ret[3].Labels) != fmt.Sprint(expected) {
+		t.Errorf("The share_config_info '%v' of the share 'data' is not expected", ret[3])
+	}
+	// The parameters testparm did not print have the default value
+	expected = map[string]string{"share": "public", "read_only": "Yes", "guest_ok": "Yes", "vfs_objects": "", "max_connections": "0"}
+	if fmt.Sprint(ret[4].Labels) != fmt.Sprint(expected) {
+		t.Errorf("The share_config_info '%v' of the share 'public' is not expected", ret[4])
+	}
+
+	settings := getNewStatisticGenSettings()
+	settings.DoNotExportShareDetails = true
+	if ret := GetShareConfigStatistics(configs, nil, settings); len(ret) != 3 {
+		t.Errorf("Got the values '%v', but expected no share_config_info, since the share details are not exported", ret)
+	}
+
+	settings = getNewStatisticGenSettings()
+	settings.PrivacyMode = PRIVACY_MODE_HASH
+	ret = GetShareConfigStatistics(configs, nil, settings)
+	if ret[3].Labels["share"] == "data" || ret[3].Labels["read_only"] != "No" {
+		t.Errorf("The share_config_info '%v' has not only the share hashed", ret[3])
+	}
+}
+
+func TestGetShareConfigStatisticsMaxConnections(t *testing.T) {
+	configs := []commonbl.ShareConfig{{Name: "Data", Parameters: map[string]string{"max connections": "4"}},
+		{Name: "public", Parameters: map[string]string{"max connections": "0"}}, {Name: "backup", Parameters: map[string]string{"max connections": "2"}}}
+	shares := []smbstatusreader.ShareData{{Service: "data", PID: 1117}, {Service: "data", PID: 1118}, {Service: "data", PID: 1119}, {Service: "public", PID: 1117}}
+
+	utilization := map[string]float64{}
+	for _, stat := range GetShareConfigStatistics(configs, shares, getNewStatisticGenSettings()) {
+		if stat.Name == "share_max_connections_utilization" {
+			utilization[stat.Labels["share"]] = stat.Value
+		}
+	}
+
+	// The share 'public' has no limit
+	if len(utilization) != 2 || utilization["Data"] != 0.75 || utilization["backup"] != 0 {
+		t.Errorf("Got the utilization '%v', but expected 0.75 for 'Data' and 0 for 'backup'", utilization)
+	}
+}
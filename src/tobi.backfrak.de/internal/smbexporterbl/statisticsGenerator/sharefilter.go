@@ -0,0 +1,99 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"regexp"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+// ShareFilter - Selects the shares whose entries are used to generate the metrics. The regular expressions must match the whole
+// name of a share in the shares table, or the whole path of a share in the locks table, since smbstatus does not print the name there
+type ShareFilter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// NewShareFilter - Get a ShareFilter for the regular expressions. An empty include expression includes all shares,
+// an empty exclude expression excludes none. Returns nil, when both are empty
+func NewShareFilter(include string, exclude string) (*ShareFilter, error) {
+	if include == "" && exclude == "" {
+		return nil, nil
+	}
+
+	filter := ShareFilter{}
+	var errCompile error
+	if include != "" {
+		filter.include, errCompile = compileShareExpression(include)
+		if errCompile != nil {
+			return nil, errCompile
+		}
+	}
+	if exclude != "" {
+		filter.exclude, errCompile = compileShareExpression(exclude)
+		if errCompile != nil {
+			return nil, errCompile
+		}
+	}
+
+	return &filter, nil
+}
+
+// IsIncluded - Tell if the entries of the share with the given name or path are used. A nil ShareFilter includes all shares
+func (filter *ShareFilter) IsIncluded(share string) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.include != nil && !filter.include.MatchString(share) {
+		return false
+	}
+
+	return filter.exclude == nil || !filter.exclude.MatchString(share)
+}
+
+// FilterShareData - Get the entries of the shares table belonging to an included share
+func (filter *ShareFilter) FilterShareData(shares []smbstatusreader.ShareData) []smbstatusreader.ShareData {
+	if filter == nil {
+		return shares
+	}
+
+	ret := []smbstatusreader.ShareData{}
+	for _, share := range shares {
+		if filter.IsIncluded(share.Service) {
+			ret = append(ret, share)
+		}
+	}
+
+	return ret
+}
+
+// FilterLockData - Get the entries of the locks table belonging to an included share
+func (filter *ShareFilter) FilterLockData(locks []smbstatusreader.LockData) []smbstatusreader.LockData {
+	if filter == nil {
+		return locks
+	}
+
+	ret := []smbstatusreader.LockData{}
+	for _, lock := range locks {
+		if filter.IsIncluded(lock.SharePath) {
+			ret = append(ret, lock)
+		}
+	}
+
+	return ret
+}
+
+// compileShareExpression - Compile the expression, so it has to match the whole name
+func compileShareExpression(expression string) (*regexp.Regexp, error) {
+	ret, errCompile := regexp.Compile(fmt.Sprintf("^(?:%s)$", expression))
+	if errCompile != nil {
+		return nil, fmt.Errorf("the share filter '%s' is not a valid regular expression: %s", expression, errCompile.Error())
+	}
+
+	return ret, nil
+}
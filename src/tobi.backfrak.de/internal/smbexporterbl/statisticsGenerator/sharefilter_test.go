@@ -0,0 +1,72 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"tobi.backfrak.de/internal/smbexporterbl/smbstatusreader"
+)
+
+func TestNewShareFilter(t *testing.T) {
+	filter, errNew := NewShareFilter("", "")
+	if errNew != nil || filter != nil {
+		t.Errorf("Got a filter or an error without expressions, but expected none")
+	}
+	if !filter.IsIncluded("IPC$") {
+		t.Errorf("The nil filter does not include all shares")
+	}
+
+	_, errNew = NewShareFilter("", "IPC$(")
+	if errNew == nil {
+		t.Errorf("Got no error for an invalid expression, but expected one")
+	}
+}
+
+func TestShareFilterIsIncluded(t *testing.T) {
+	filter, errNew := NewShareFilter("", `IPC\$|print\$`)
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+	for share, expected := range map[string]bool{"IPC$": false, "print$": false, "share": true, "myIPC$": true} {
+		if filter.IsIncluded(share) != expected {
+			t.Errorf("The share '%s' is not included as expected", share)
+		}
+	}
+
+	filter, _ = NewShareFilter("/srv/.*|data", "/srv/private")
+	for share, expected := range map[string]bool{"/srv/public": true, "/srv/private": false, "data": true, "/usr/share/data": false} {
+		if filter.IsIncluded(share) != expected {
+			t.Errorf("The share '%s' is not included as expected", share)
+		}
+	}
+}
+
+func TestGetSmbStatisticsShareFilter(t *testing.T) {
+	locks := []smbstatusreader.LockData{
+		{PID: 1117, UserID: 1080, SharePath: "/usr/share/data", Name: "Test.txt"},
+		{PID: 1118, UserID: 1080, SharePath: "/var/spool/print", Name: "Job.txt"},
+	}
+	shares := []smbstatusreader.ShareData{
+		{Service: "IPC$", PID: 1117, Machine: "192.168.1.242"},
+		{Service: "data", PID: 1117, Machine: "192.168.1.242"},
+		{Service: "print$", PID: 1118, Machine: "192.168.1.243"},
+	}
+
+	settings := getNewStatisticGenSettings()
+	settings.ShareFilter, _ = NewShareFilter("", `IPC\$|print\$|/var/spool/print`)
+	for _, metric := range GetSmbStatistics(locks, nil, shares, settings) {
+		if metric.Name == "share_count" && metric.Value != 1 {
+			t.Errorf("The share_count %f is not the expected 1", metric.Value)
+		}
+		if metric.Name == "client_count" && metric.Value != 1 {
+			t.Errorf("The client_count %f is not the expected 1", metric.Value)
+		}
+		if metric.Name == "locked_file_count" && metric.Value != 1 {
+			t.Errorf("The locked_file_count %f is not the expected 1", metric.Value)
+		}
+	}
+}
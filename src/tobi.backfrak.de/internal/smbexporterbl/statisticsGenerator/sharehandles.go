@@ -0,0 +1,44 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// GetShareHandlesStatistics - Get the number of durable and persistent file handles of all shares, and unless the share details are not exported,
+// the open, durable and persistent handles of each share. The shares not included by the ShareFilter are skipped
+func GetShareHandlesStatistics(handles []commonbl.ShareHandles, settings StatisticsGeneratorSettings) []SmbStatisticsNumeric {
+	exportShares := !settings.DoNotExportShareDetails && settings.PrivacyMode != PRIVACY_MODE_OMIT
+	durableSum := 0
+	persistentSum := 0
+	var ret []SmbStatisticsNumeric
+	for _, share := range handles {
+		if !settings.ShareFilter.IsIncluded(share.Share) {
+			continue
+		}
+		durableSum += share.DurableCount
+		persistentSum += share.PersistentCount
+
+		if exportShares {
+			labels := map[string]string{"share": share.Share}
+			ret = append(ret, SmbStatisticsNumeric{"share_open_handle_count", float64(share.OpenCount), "Number of open file handles on the share", labels})
+			ret = append(ret, SmbStatisticsNumeric{"share_durable_handle_count", float64(share.DurableCount),
+				"Number of durable file handles on the share, a client can reclaim after a short network outage", labels})
+			ret = append(ret, SmbStatisticsNumeric{"share_persistent_handle_count", float64(share.PersistentCount),
+				"Number of persistent file handles on the share, a client can reclaim on another cluster node", labels})
+		}
+	}
+
+	ret = append(ret, SmbStatisticsNumeric{"durable_handle_count", float64(durableSum), "Number of durable file handles on all shares", nil})
+	ret = append(ret, SmbStatisticsNumeric{"persistent_handle_count", float64(persistentSum), "Number of persistent file handles on all shares", nil})
+
+	if settings.PrivacyMode == PRIVACY_MODE_HASH {
+		hashPersonalLabels(ret, settings.PrivacyHashKey)
+	}
+
+	return ret
+}
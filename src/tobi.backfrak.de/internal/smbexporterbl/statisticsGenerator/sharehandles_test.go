@@ -0,0 +1,68 @@
+package statisticsGenerator
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func getTestShareHandles() []commonbl.ShareHandles {
+	return []commonbl.ShareHandles{{Share: "/srv/data", OpenCount: 4, DurableCount: 3, PersistentCount: 2},
+		{Share: "/srv/public", OpenCount: 2, DurableCount: 1}}
+}
+
+func TestGetShareHandlesStatistics(t *testing.T) {
+	ret := GetShareHandlesStatistics(getTestShareHandles(), getNewStatisticGenSettings())
+
+	if len(ret) != 8 {
+		t.Fatalf("Got %d values but expected 8", len(ret))
+	}
+	if ret[0].Name != "share_open_handle_count" || ret[0].Value != 4 || ret[0].Labels["share"] != "/srv/data" {
+		t.Errorf("The share_open_handle_count '%v' is not expected", ret[0])
+	}
+	if ret[1].Name != "share_durable_handle_count" || ret[1].Value != 3 || ret[2].Name != "share_persistent_handle_count" || ret[2].Value != 2 {
+		t.Errorf("The handles '%v' of the share '/srv/data' are not expected", ret[1:3])
+	}
+	if ret[6].Name != "durable_handle_count" || ret[6].Value != 4 {
+		t.Errorf("The durable_handle_count '%v' is not expected", ret[6])
+	}
+	if ret[7].Name != "persistent_handle_count" || ret[7].Value != 2 {
+		t.Errorf("The persistent_handle_count '%v' is not expected", ret[7])
+	}
+}
+
+func TestGetShareHandlesStatisticsNoShareDetails(t *testing.T) {
+	settings := getNewStatisticGenSettings()
+	settings.DoNotExportShareDetails = true
+	ret := GetShareHandlesStatistics(getTestShareHandles(), settings)
+
+	if len(ret) != 2 || ret[0].Name != "durable_handle_count" || ret[0].Value != 4 {
+		t.Errorf("Got the values '%v', but expected only the handles of all shares", ret)
+	}
+
+	settings = getNewStatisticGenSettings()
+	settings.PrivacyMode = PRIVACY_MODE_HASH
+	ret = GetShareHandlesStatistics(getTestShareHandles(), settings)
+	if len(ret) != 8 || ret[0].Labels["share"] == "/srv/data" || ret[0].Labels["share"] == "" {
+		t.Errorf("The share label of '%v' is not hashed", ret[0])
+	}
+}
+
+func TestGetShareHandlesStatisticsFiltered(t *testing.T) {
+	settings := getNewStatisticGenSettings()
+	filter, errFilter := NewShareFilter("", "/srv/public")
+	if errFilter != nil {
+		t.Fatalf("Got error '%s' but expected none", errFilter.Error())
+	}
+	settings.ShareFilter = filter
+
+	ret := GetShareHandlesStatistics(getTestShareHandles(), settings)
+	if len(ret) != 5 || ret[3].Value != 3 || ret[4].Value != 2 {
+		t.Errorf("Got the values '%v', but expected only the handles of the share '/srv/data'", ret)
+	}
+}
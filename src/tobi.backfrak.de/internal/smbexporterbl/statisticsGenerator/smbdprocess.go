@@ -31,6 +31,12 @@ func GetSmbdMetrics(pidDataList []commonbl.PsUtilPidData, notExportPid bool) []S
 		writeBytesSum := uint64(0)
 		openFilesCountSum := uint64(0)
 		threadCountSum := uint64(0)
+		fileDescriptorCountSum := uint64(0)
+		establishedConnectionCountSum := uint64(0)
+		voluntaryContextSwitchesSum := uint64(0)
+		involuntaryContextSwitchesSum := uint64(0)
+		fileDescriptorLimitSum := uint64(0)
+		fileDescriptorUtilizationMax := float64(0)
 		for _, pidData := range pidDataList {
 
 			cpuPercentageSum += pidData.CpuUsagePercent
@@ -42,6 +48,18 @@ func GetSmbdMetrics(pidDataList []commonbl.PsUtilPidData, notExportPid bool) []S
 			writeBytesSum += pidData.IoCounterWriteBytes
 			openFilesCountSum += pidData.OpenFilesCount
 			threadCountSum += pidData.ThreadCount
+			fileDescriptorCountSum += pidData.FileDescriptorCount
+			establishedConnectionCountSum += pidData.EstablishedConnectionCount
+			voluntaryContextSwitchesSum += pidData.VoluntaryContextSwitches
+			involuntaryContextSwitchesSum += pidData.InvoluntaryContextSwitches
+			fileDescriptorLimitSum += pidData.FileDescriptorLimit
+			// Each process has its own limit, so the process closest to it tells when descriptors run out
+			if pidData.FileDescriptorLimit > 0 {
+				utilization := float64(pidData.FileDescriptorCount) / float64(pidData.FileDescriptorLimit)
+				if utilization > fileDescriptorUtilizationMax {
+					fileDescriptorUtilizationMax = utilization
+				}
+			}
 
 			if !notExportPid {
 				// Metrics with PID label
@@ -54,16 +72,16 @@ func GetSmbdMetrics(pidDataList []commonbl.PsUtilPidData, notExportPid bool) []S
 				ret = append(ret, SmbStatisticsNumeric{"smbd_virtual_memory_usage_percent",
 					pidData.VirtualMemoryUsagePercent, fmt.Sprintf("Virtual memory usage of the '%s' process with pid in percent", smbd_image_name),
 					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
-				ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_read_count",
+				ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_read_count_total",
 					float64(pidData.IoCounterReadCount), fmt.Sprintf("IO counter read count of the process '%s'", smbd_image_name),
 					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
-				ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_write_count",
+				ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_write_count_total",
 					float64(pidData.IoCounterWriteCount), fmt.Sprintf("IO counter write count of the process '%s'", smbd_image_name),
 					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
-				ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_read_bytes",
+				ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_read_bytes_total",
 					float64(pidData.IoCounterReadBytes), fmt.Sprintf("IO counter reads of the process '%s' in byte", smbd_image_name),
 					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
-				ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_write_bytes",
+				ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_write_bytes_total",
 					float64(pidData.IoCounterWriteBytes), fmt.Sprintf("IO counter writes of the process '%s' in byte", smbd_image_name),
 					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
 				ret = append(ret, SmbStatisticsNumeric{"smbd_open_file_count",
@@ -72,6 +90,21 @@ func GetSmbdMetrics(pidDataList []commonbl.PsUtilPidData, notExportPid bool) []S
 				ret = append(ret, SmbStatisticsNumeric{"smbd_thread_count",
 					float64(pidData.ThreadCount), fmt.Sprintf("Threads used by process '%s'", smbd_image_name),
 					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
+				ret = append(ret, SmbStatisticsNumeric{"smbd_file_descriptor_count",
+					float64(pidData.FileDescriptorCount), fmt.Sprintf("Open file descriptors, including sockets and pipes, of the process '%s'", smbd_image_name),
+					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
+				ret = append(ret, SmbStatisticsNumeric{"smbd_established_connection_count",
+					float64(pidData.EstablishedConnectionCount), fmt.Sprintf("Established TCP connections of the process '%s'", smbd_image_name),
+					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
+				ret = append(ret, SmbStatisticsNumeric{"smbd_voluntary_context_switch_count_total",
+					float64(pidData.VoluntaryContextSwitches), fmt.Sprintf("Voluntary context switches of the process '%s', e. g. while waiting for I/O", smbd_image_name),
+					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
+				ret = append(ret, SmbStatisticsNumeric{"smbd_involuntary_context_switch_count_total",
+					float64(pidData.InvoluntaryContextSwitches), fmt.Sprintf("Involuntary context switches of the process '%s', when it had to give up the CPU", smbd_image_name),
+					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
+				ret = append(ret, SmbStatisticsNumeric{"smbd_file_descriptor_limit",
+					float64(pidData.FileDescriptorLimit), fmt.Sprintf("File descriptors the process '%s' can open, its RLIMIT_NOFILE", smbd_image_name),
+					map[string]string{"pid": strconv.Itoa(int(pidData.PID))}})
 			}
 		}
 
@@ -94,6 +127,18 @@ func GetSmbdMetrics(pidDataList []commonbl.PsUtilPidData, notExportPid bool) []S
 			float64(openFilesCountSum), fmt.Sprintf("Open file handles of all '%s' processes", smbd_image_name), nil})
 		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_thread_count",
 			float64(threadCountSum), fmt.Sprintf("Threads used by all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_file_descriptor_count",
+			float64(fileDescriptorCountSum), fmt.Sprintf("Open file descriptors, including sockets and pipes, of all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_established_connection_count",
+			float64(establishedConnectionCountSum), fmt.Sprintf("Established TCP connections of all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_voluntary_context_switch_count",
+			float64(voluntaryContextSwitchesSum), fmt.Sprintf("Voluntary context switches of all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_involuntary_context_switch_count",
+			float64(involuntaryContextSwitchesSum), fmt.Sprintf("Involuntary context switches of all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_file_descriptor_limit",
+			float64(fileDescriptorLimitSum), fmt.Sprintf("File descriptors all '%s' processes can open, the sum of their RLIMIT_NOFILE", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_max_file_descriptor_utilization",
+			fileDescriptorUtilizationMax, fmt.Sprintf("Highest fraction of its RLIMIT_NOFILE a '%s' process has open as file descriptors", smbd_image_name), nil})
 
 	} else {
 		// Give back empty metrics, when smbd is not running
@@ -108,16 +153,16 @@ func GetSmbdMetrics(pidDataList []commonbl.PsUtilPidData, notExportPid bool) []S
 			ret = append(ret, SmbStatisticsNumeric{"smbd_virtual_memory_usage_percent",
 				0, fmt.Sprintf("Virtual memory usage of the '%s' process with pid in percent", smbd_image_name),
 				map[string]string{"pid": ""}})
-			ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_read_count",
+			ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_read_count_total",
 				0, fmt.Sprintf("IO counter read count of the process '%s'", smbd_image_name),
 				map[string]string{"pid": ""}})
-			ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_write_count",
+			ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_write_count_total",
 				0, fmt.Sprintf("IO counter write count of the process '%s'", smbd_image_name),
 				map[string]string{"pid": ""}})
-			ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_read_bytes",
+			ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_read_bytes_total",
 				0, fmt.Sprintf("IO counter reads of the process '%s' in byte", smbd_image_name),
 				map[string]string{"pid": ""}})
-			ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_write_bytes",
+			ret = append(ret, SmbStatisticsNumeric{"smbd_io_counter_write_bytes_total",
 				0, fmt.Sprintf("IO counter writes of the process '%s' in byte", smbd_image_name),
 				map[string]string{"pid": ""}})
 			ret = append(ret, SmbStatisticsNumeric{"smbd_open_file_count",
@@ -126,6 +171,21 @@ func GetSmbdMetrics(pidDataList []commonbl.PsUtilPidData, notExportPid bool) []S
 			ret = append(ret, SmbStatisticsNumeric{"smbd_thread_count",
 				0, fmt.Sprintf("Threads used by process '%s'", smbd_image_name),
 				map[string]string{"pid": ""}})
+			ret = append(ret, SmbStatisticsNumeric{"smbd_file_descriptor_count",
+				0, fmt.Sprintf("Open file descriptors, including sockets and pipes, of the process '%s'", smbd_image_name),
+				map[string]string{"pid": ""}})
+			ret = append(ret, SmbStatisticsNumeric{"smbd_established_connection_count",
+				0, fmt.Sprintf("Established TCP connections of the process '%s'", smbd_image_name),
+				map[string]string{"pid": ""}})
+			ret = append(ret, SmbStatisticsNumeric{"smbd_voluntary_context_switch_count_total",
+				0, fmt.Sprintf("Voluntary context switches of the process '%s', e. g. while waiting for I/O", smbd_image_name),
+				map[string]string{"pid": ""}})
+			ret = append(ret, SmbStatisticsNumeric{"smbd_involuntary_context_switch_count_total",
+				0, fmt.Sprintf("Involuntary context switches of the process '%s', when it had to give up the CPU", smbd_image_name),
+				map[string]string{"pid": ""}})
+			ret = append(ret, SmbStatisticsNumeric{"smbd_file_descriptor_limit",
+				0, fmt.Sprintf("File descriptors the process '%s' can open, its RLIMIT_NOFILE", smbd_image_name),
+				map[string]string{"pid": ""}})
 		}
 
 		// Metrics without labels (sum metrics)
@@ -147,6 +207,18 @@ func GetSmbdMetrics(pidDataList []commonbl.PsUtilPidData, notExportPid bool) []S
 			0, fmt.Sprintf("Open file handles of all '%s' processes", smbd_image_name), nil})
 		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_thread_count",
 			0, fmt.Sprintf("Threads used by all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_file_descriptor_count",
+			0, fmt.Sprintf("Open file descriptors, including sockets and pipes, of all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_established_connection_count",
+			0, fmt.Sprintf("Established TCP connections of all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_voluntary_context_switch_count",
+			0, fmt.Sprintf("Voluntary context switches of all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_involuntary_context_switch_count",
+			0, fmt.Sprintf("Involuntary context switches of all '%s' processes", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_sum_file_descriptor_limit",
+			0, fmt.Sprintf("File descriptors all '%s' processes can open, the sum of their RLIMIT_NOFILE", smbd_image_name), nil})
+		ret = append(ret, SmbStatisticsNumeric{"smbd_max_file_descriptor_utilization",
+			0, fmt.Sprintf("Highest fraction of its RLIMIT_NOFILE a '%s' process has open as file descriptors", smbd_image_name), nil})
 	}
 
 	return ret
@@ -15,8 +15,8 @@ func TestGetSmbdMetricsNotRunningProcess(t *testing.T) {
 
 	metrics := GetSmbdMetrics([]commonbl.PsUtilPidData{}, false)
 
-	if len(metrics) != 19 {
-		t.Errorf("Got %d lines but expected %d", len(metrics), 7)
+	if len(metrics) != 30 {
+		t.Errorf("Got %d lines but expected %d", len(metrics), 30)
 	}
 
 	if metrics[0].Name != "smbd_unique_process_id_count" {
@@ -51,32 +51,32 @@ func TestGetSmbdMetricsNotRunningProcess(t *testing.T) {
 		t.Errorf("Can not find a metric named 'smbd_sum_cpu_usage_percentage'")
 	}
 
-	if metricArrContainsItemWithName(metrics, "smbd_io_counter_read_count") == false {
-		t.Errorf("Can not find a metric named 'smbd_io_counter_read_count'")
+	if metricArrContainsItemWithName(metrics, "smbd_io_counter_read_count_total") == false {
+		t.Errorf("Can not find a metric named 'smbd_io_counter_read_count_total'")
 	}
 
 	if metricArrContainsItemWithName(metrics, "smbd_sum_io_counter_read_count") == false {
 		t.Errorf("Can not find a metric named 'smbd_sum_io_counter_read_count'")
 	}
 
-	if metricArrContainsItemWithName(metrics, "smbd_io_counter_write_count") == false {
-		t.Errorf("Can not find a metric named 'smbd_io_counter_write_count'")
+	if metricArrContainsItemWithName(metrics, "smbd_io_counter_write_count_total") == false {
+		t.Errorf("Can not find a metric named 'smbd_io_counter_write_count_total'")
 	}
 
 	if metricArrContainsItemWithName(metrics, "smbd_sum_io_counter_write_count") == false {
 		t.Errorf("Can not find a metric named 'smbd_sum_io_counter_write_count'")
 	}
 
-	if metricArrContainsItemWithName(metrics, "smbd_io_counter_read_bytes") == false {
-		t.Errorf("Can not find a metric named 'smbd_io_counter_read_bytes'")
+	if metricArrContainsItemWithName(metrics, "smbd_io_counter_read_bytes_total") == false {
+		t.Errorf("Can not find a metric named 'smbd_io_counter_read_bytes_total'")
 	}
 
 	if metricArrContainsItemWithName(metrics, "smbd_sum_io_counter_read_bytes") == false {
 		t.Errorf("Can not find a metric named 'smbd_sum_io_counter_read_bytes'")
 	}
 
-	if metricArrContainsItemWithName(metrics, "smbd_io_counter_write_bytes") == false {
-		t.Errorf("Can not find a metric named 'smbd_io_counter_write_bytes'")
+	if metricArrContainsItemWithName(metrics, "smbd_io_counter_write_bytes_total") == false {
+		t.Errorf("Can not find a metric named 'smbd_io_counter_write_bytes_total'")
 	}
 
 	if metricArrContainsItemWithName(metrics, "smbd_sum_io_counter_write_bytes") == false {
@@ -98,6 +98,30 @@ func TestGetSmbdMetricsNotRunningProcess(t *testing.T) {
 	if metricArrContainsItemWithName(metrics, "smbd_sum_thread_count") == false {
 		t.Errorf("Can not find a metric named 'smbd_sum_thread_count'")
 	}
+
+	if metricArrContainsItemWithName(metrics, "smbd_file_descriptor_count") == false {
+		t.Errorf("Can not find a metric named 'smbd_file_descriptor_count'")
+	}
+
+	if metricArrContainsItemWithName(metrics, "smbd_sum_file_descriptor_count") == false {
+		t.Errorf("Can not find a metric named 'smbd_sum_file_descriptor_count'")
+	}
+
+	if metricArrContainsItemWithName(metrics, "smbd_established_connection_count") == false {
+		t.Errorf("Can not find a metric named 'smbd_established_connection_count'")
+	}
+
+	if metricArrContainsItemWithName(metrics, "smbd_sum_established_connection_count") == false {
+		t.Errorf("Can not find a metric named 'smbd_sum_established_connection_count'")
+	}
+
+	for _, name := range []string{"smbd_voluntary_context_switch_count_total", "smbd_involuntary_context_switch_count_total",
+		"smbd_sum_voluntary_context_switch_count", "smbd_sum_involuntary_context_switch_count",
+		"smbd_file_descriptor_limit", "smbd_sum_file_descriptor_limit", "smbd_max_file_descriptor_utilization"} {
+		if metricArrContainsItemWithName(metrics, name) == false {
+			t.Errorf("Can not find a metric named '%s'", name)
+		}
+	}
 }
 
 func TestGetSmbdMetricsRunningProcessNoPids(t *testing.T) {
@@ -117,7 +141,7 @@ func TestGetSmbdMetricsRunningProcessNoPids(t *testing.T) {
 		t.Errorf("Found '%f' processes, but at two expected", metrics[0].Value)
 	}
 
-	expectedMetricCount := 10
+	expectedMetricCount := 16
 	if len(metrics) != expectedMetricCount {
 		t.Errorf("Got '%d' metrics but expected '%d'", len(metrics), expectedMetricCount)
 	}
@@ -156,8 +180,9 @@ func TestGetSmbdMetricsRunningProcess(t *testing.T) {
 		t.Errorf("Found '%f' processes, but at two expected", metrics[0].Value)
 	}
 
-	numUnqueMetrics := 9
-	numSumMetrics := numUnqueMetrics
+	numUnqueMetrics := 14
+	// The smbd_max_file_descriptor_utilization has no metric per process
+	numSumMetrics := numUnqueMetrics + 1
 	expectedMetricCount := 1 + (int(metrics[0].Value) * numUnqueMetrics) + numSumMetrics
 	if len(metrics) != expectedMetricCount {
 		t.Errorf("Got '%d' metrics but expected '%d'", len(metrics), expectedMetricCount)
@@ -217,83 +242,83 @@ func TestGetSmbdMetricsRunningProcess(t *testing.T) {
 		t.Errorf("The metrics 'smbd_virtual_memory_usage_percent' sum is not equal 'smbd_sum_virtual_memory_usage_percent'")
 	}
 
-	if metricArrContainsItemWithName(metrics, "smbd_io_counter_read_count") == false {
-		t.Errorf("Can not find a metric named 'smbd_io_counter_read_count'")
+	if metricArrContainsItemWithName(metrics, "smbd_io_counter_read_count_total") == false {
+		t.Errorf("Can not find a metric named 'smbd_io_counter_read_count_total'")
 	}
 
 	if metricArrContainsItemWithName(metrics, "smbd_sum_io_counter_read_count") == false {
 		t.Errorf("Can not find a metric named 'smbd_sum_io_counter_read_count'")
 	}
 
-	if metricArrCountItemWithName(metrics, "smbd_io_counter_read_count") != int(metrics[0].Value) {
-		t.Errorf("The metric 'smbd_io_counter_read_count' is not exported as often as expected")
+	if metricArrCountItemWithName(metrics, "smbd_io_counter_read_count_total") != int(metrics[0].Value) {
+		t.Errorf("The metric 'smbd_io_counter_read_count_total' is not exported as often as expected")
 	}
 
-	if metricArrSumItemWithName(metrics, "smbd_io_counter_read_count") !=
+	if metricArrSumItemWithName(metrics, "smbd_io_counter_read_count_total") !=
 		metricArrGetValueithName(metrics, "smbd_sum_io_counter_read_count") {
 
-		t.Errorf("The metrics 'smbd_io_counter_read_count' (%f) sum is not equal 'smbd_sum_io_counter_read_count' (%f)",
-			metricArrSumItemWithName(metrics, "smbd_io_counter_read_count"),
+		t.Errorf("The metrics 'smbd_io_counter_read_count_total' (%f) sum is not equal 'smbd_sum_io_counter_read_count' (%f)",
+			metricArrSumItemWithName(metrics, "smbd_io_counter_read_count_total"),
 			metricArrGetValueithName(metrics, "smbd_sum_io_counter_read_count"))
 	}
 
-	if metricArrContainsItemWithName(metrics, "smbd_io_counter_write_count") == false {
-		t.Errorf("Can not find a metric named 'smbd_io_counter_write_count'")
+	if metricArrContainsItemWithName(metrics, "smbd_io_counter_write_count_total") == false {
+		t.Errorf("Can not find a metric named 'smbd_io_counter_write_count_total'")
 	}
 
 	if metricArrContainsItemWithName(metrics, "smbd_sum_io_counter_write_count") == false {
 		t.Errorf("Can not find a metric named 'smbd_sum_io_counter_write_count'")
 	}
 
-	if metricArrCountItemWithName(metrics, "smbd_io_counter_write_count") != int(metrics[0].Value) {
-		t.Errorf("The metric 'smbd_io_counter_write_count' is not exported as often as expected")
+	if metricArrCountItemWithName(metrics, "smbd_io_counter_write_count_total") != int(metrics[0].Value) {
+		t.Errorf("The metric 'smbd_io_counter_write_count_total' is not exported as often as expected")
 	}
 
-	if metricArrSumItemWithName(metrics, "smbd_io_counter_write_count") !=
+	if metricArrSumItemWithName(metrics, "smbd_io_counter_write_count_total") !=
 		metricArrGetValueithName(metrics, "smbd_sum_io_counter_write_count") {
 
-		t.Errorf("The metrics 'smbd_io_counter_write_count' (%f) sum is not equal 'smbd_sum_io_counter_write_count' (%f)",
-			metricArrSumItemWithName(metrics, "smbd_io_counter_write_count"),
+		t.Errorf("The metrics 'smbd_io_counter_write_count_total' (%f) sum is not equal 'smbd_sum_io_counter_write_count' (%f)",
+			metricArrSumItemWithName(metrics, "smbd_io_counter_write_count_total"),
 			metricArrGetValueithName(metrics, "smbd_sum_io_counter_write_count"))
 	}
 
-	if metricArrContainsItemWithName(metrics, "smbd_io_counter_read_bytes") == false {
-		t.Errorf("Can not find a metric named 'smbd_io_counter_read_bytes'")
+	if metricArrContainsItemWithName(metrics, "smbd_io_counter_read_bytes_total") == false {
+		t.Errorf("Can not find a metric named 'smbd_io_counter_read_bytes_total'")
 	}
 
 	if metricArrContainsItemWithName(metrics, "smbd_sum_io_counter_read_bytes") == false {
 		t.Errorf("Can not find a metric named 'smbd_sum_io_counter_read_bytes'")
 	}
 
-	if metricArrCountItemWithName(metrics, "smbd_io_counter_read_bytes") != int(metrics[0].Value) {
-		t.Errorf("The metric 'smbd_io_counter_read_bytes' is not exported as often as expected")
+	if metricArrCountItemWithName(metrics, "smbd_io_counter_read_bytes_total") != int(metrics[0].Value) {
+		t.Errorf("The metric 'smbd_io_counter_read_bytes_total' is not exported as often as expected")
 	}
 
-	if metricArrSumItemWithName(metrics, "smbd_io_counter_read_bytes") !=
+	if metricArrSumItemWithName(metrics, "smbd_io_counter_read_bytes_total") !=
 		metricArrGetValueithName(metrics, "smbd_sum_io_counter_read_bytes") {
 
-		t.Errorf("The metrics 'smbd_io_counter_read_bytes' (%f) sum is not equal 'smbd_sum_io_counter_read_bytes' (%f)",
-			metricArrSumItemWithName(metrics, "smbd_io_counter_read_bytes"),
+		t.Errorf("The metrics 'smbd_io_counter_read_bytes_total' (%f) sum is not equal 'smbd_sum_io_counter_read_bytes' (%f)",
+			metricArrSumItemWithName(metrics, "smbd_io_counter_read_bytes_total"),
 			metricArrGetValueithName(metrics, "smbd_sum_io_counter_read_bytes"))
 	}
 
-	if metricArrContainsItemWithName(metrics, "smbd_io_counter_write_bytes") == false {
-		t.Errorf("Can not find a metric named 'smbd_io_counter_write_bytes'")
+	if metricArrContainsItemWithName(metrics, "smbd_io_counter_write_bytes_total") == false {
+		t.Errorf("Can not find a metric named 'smbd_io_counter_write_bytes_total'")
 	}
 
 	if metricArrContainsItemWithName(metrics, "smbd_sum_io_counter_write_bytes") == false {
 		t.Errorf("Can not find a metric named 'smbd_sum_io_counter_write_bytes'")
 	}
 
-	if metricArrCountItemWithName(metrics, "smbd_io_counter_write_bytes") != int(metrics[0].Value) {
-		t.Errorf("The metric 'smbd_io_counter_write_bytes' is not exported as often as expected")
+	if metricArrCountItemWithName(metrics, "smbd_io_counter_write_bytes_total") != int(metrics[0].Value) {
+		t.Errorf("The metric 'smbd_io_counter_write_bytes_total' is not exported as often as expected")
 	}
 
-	if metricArrSumItemWithName(metrics, "smbd_io_counter_write_bytes") !=
+	if metricArrSumItemWithName(metrics, "smbd_io_counter_write_bytes_total") !=
 		metricArrGetValueithName(metrics, "smbd_sum_io_counter_write_bytes") {
 
-		t.Errorf("The metrics 'smbd_io_counter_write_bytes' (%f) sum is not equal 'smbd_sum_io_counter_write_bytes' (%f)",
-			metricArrSumItemWithName(metrics, "smbd_io_counter_write_bytes"),
+		t.Errorf("The metrics 'smbd_io_counter_write_bytes_total' (%f) sum is not equal 'smbd_sum_io_counter_write_bytes' (%f)",
+			metricArrSumItemWithName(metrics, "smbd_io_counter_write_bytes_total"),
 			metricArrGetValueithName(metrics, "smbd_sum_io_counter_write_bytes"))
 	}
 
@@ -337,6 +362,53 @@ func TestGetSmbdMetricsRunningProcess(t *testing.T) {
 			metricArrGetValueithName(metrics, "smbd_sum_thread_count"))
 	}
 
+	if metricArrCountItemWithName(metrics, "smbd_file_descriptor_count") != int(metrics[0].Value) {
+		t.Errorf("The metric 'smbd_file_descriptor_count' is not exported as often as expected")
+	}
+
+	if metricArrGetValueithName(metrics, "smbd_sum_file_descriptor_count") != 1482+467140 {
+		t.Errorf("The metric 'smbd_sum_file_descriptor_count' is '%f' but expected '%d'",
+			metricArrGetValueithName(metrics, "smbd_sum_file_descriptor_count"), 1482+467140)
+	}
+
+	if metricArrCountItemWithName(metrics, "smbd_established_connection_count") != int(metrics[0].Value) {
+		t.Errorf("The metric 'smbd_established_connection_count' is not exported as often as expected")
+	}
+
+	if metricArrGetValueithName(metrics, "smbd_sum_established_connection_count") != 3+17 {
+		t.Errorf("The metric 'smbd_sum_established_connection_count' is '%f' but expected '%d'",
+			metricArrGetValueithName(metrics, "smbd_sum_established_connection_count"), 3+17)
+	}
+
+	if metricArrCountItemWithName(metrics, "smbd_voluntary_context_switch_count_total") != int(metrics[0].Value) {
+		t.Errorf("The metric 'smbd_voluntary_context_switch_count_total' is not exported as often as expected")
+	}
+
+	if metricArrGetValueithName(metrics, "smbd_sum_voluntary_context_switch_count") != 45678+987654 {
+		t.Errorf("The metric 'smbd_sum_voluntary_context_switch_count' is '%f' but expected '%d'",
+			metricArrGetValueithName(metrics, "smbd_sum_voluntary_context_switch_count"), 45678+987654)
+	}
+
+	if metricArrGetValueithName(metrics, "smbd_sum_involuntary_context_switch_count") != 1234+23456 {
+		t.Errorf("The metric 'smbd_sum_involuntary_context_switch_count' is '%f' but expected '%d'",
+			metricArrGetValueithName(metrics, "smbd_sum_involuntary_context_switch_count"), 1234+23456)
+	}
+
+	if metricArrCountItemWithName(metrics, "smbd_file_descriptor_limit") != int(metrics[0].Value) {
+		t.Errorf("The metric 'smbd_file_descriptor_limit' is not exported as often as expected")
+	}
+
+	if metricArrGetValueithName(metrics, "smbd_sum_file_descriptor_limit") != 16384+524288 {
+		t.Errorf("The metric 'smbd_sum_file_descriptor_limit' is '%f' but expected '%d'",
+			metricArrGetValueithName(metrics, "smbd_sum_file_descriptor_limit"), 16384+524288)
+	}
+
+	// The second process is closer to its limit
+	if metricArrGetValueithName(metrics, "smbd_max_file_descriptor_utilization") != float64(467140)/524288 {
+		t.Errorf("The metric 'smbd_max_file_descriptor_utilization' is '%f' but expected '%f'",
+			metricArrGetValueithName(metrics, "smbd_max_file_descriptor_utilization"), float64(467140)/524288)
+	}
+
 }
 
 func metricArrContainsItemWithName(arr []SmbStatisticsNumeric, name string) bool {
@@ -381,3 +453,14 @@ func metricArrGetValueithName(arr []SmbStatisticsNumeric, name string) float64 {
 
 	return ret
 }
+
+func TestGetSmbdMetricsUnknownFileDescriptorLimit(t *testing.T) {
+	// The samba_statusd is older or the processes are unlimited
+	pidData := []commonbl.PsUtilPidData{{PID: 1234, FileDescriptorCount: 100}, {PID: 4234, FileDescriptorCount: 50, FileDescriptorLimit: 1000}}
+	metrics := GetSmbdMetrics(pidData, true)
+
+	if metricArrGetValueithName(metrics, "smbd_max_file_descriptor_utilization") != 0.05 {
+		t.Errorf("The metric 'smbd_max_file_descriptor_utilization' is '%f' but expected '0.05'",
+			metricArrGetValueithName(metrics, "smbd_max_file_descriptor_utilization"))
+	}
+}
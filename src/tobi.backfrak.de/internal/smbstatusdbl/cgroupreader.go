@@ -0,0 +1,239 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The mount point of the cgroup v2 hierarchy
+const CGROUP_ROOT = "/sys/fs/cgroup"
+
+// The directory the cgroups of the processes are read from
+const PROC_ROOT = "/proc"
+
+// CgroupReader - Reads the resource usage of cgroups out of the files of the cgroup v2 hierarchy. This works for a samba
+// run by systemd, where each service gets an own cgroup, even when the data of the single smbd processes is not collected
+type CgroupReader struct {
+	// The paths of the cgroups below the CgroupRoot. When empty, the cgroups the ProcessToRequest runs in are read
+	Paths            []string
+	ProcessToRequest string
+	CgroupRoot       string
+	ProcRoot         string
+	// Gets the PIDs of the ProcessToRequest
+	pidList func() ([]int32, error)
+}
+
+// NewCgroupReader - Get a new CgroupReader for the given cgroup paths, or the cgroups of the processToRequest when no paths are given
+func NewCgroupReader(paths []string, processToRequest string) *CgroupReader {
+	ret := CgroupReader{Paths: paths, ProcessToRequest: processToRequest, CgroupRoot: CGROUP_ROOT, ProcRoot: PROC_ROOT}
+	ret.pidList = func() ([]int32, error) {
+		generator, errNew := NewPsDataGenerator(processToRequest)
+		if errNew != nil {
+			return nil, errNew
+		}
+
+		return generator.getPidList()
+	}
+
+	return &ret
+}
+
+// GetCgroupData - Get the resource usage of the cgroups. In case no cgroup is given and the ProcessToRequest is not running, an empty list is returned
+func (reader *CgroupReader) GetCgroupData() ([]commonbl.CgroupData, error) {
+	paths := reader.Paths
+	if len(paths) == 0 {
+		var errFind error
+		paths, errFind = reader.findProcessCgroups()
+		if errFind != nil {
+			return nil, errFind
+		}
+	}
+
+	ret := []commonbl.CgroupData{}
+	for _, path := range paths {
+		data, errRead := reader.readCgroup(path)
+		if errRead != nil {
+			return nil, errRead
+		}
+		ret = append(ret, data)
+	}
+
+	return ret, nil
+}
+
+// findProcessCgroups - Get the paths of the cgroup v2 hierarchy the ProcessToRequest runs in. Processes in the root cgroup are skipped,
+// since its usage is the one of the whole system
+func (reader *CgroupReader) findProcessCgroups() ([]string, error) {
+	pids, errPids := reader.pidList()
+	if errPids != nil || len(pids) == 0 {
+		return []string{}, nil
+	}
+
+	ret := []string{}
+	found := make(map[string]bool)
+	for _, pid := range pids {
+		content, errRead := os.ReadFile(filepath.Join(reader.ProcRoot, strconv.Itoa(int(pid)), "cgroup"))
+		if errRead != nil {
+			if os.IsNotExist(errRead) {
+				// The process ended since the PIDs were listed
+				continue
+			}
+			return nil, errRead
+		}
+
+		path, isV2 := getCgroupV2Path(string(content))
+		if !isV2 {
+			return nil, fmt.Errorf("the process %d does not run in a cgroup v2 hierarchy", pid)
+		}
+		if path == "" || found[path] {
+			continue
+		}
+		found[path] = true
+		ret = append(ret, path)
+	}
+
+	return ret, nil
+}
+
+// getCgroupV2Path - Get the path of the cgroup v2 hierarchy out of the content of /proc/<pid>/cgroup, the line '0::<path>'
+func getCgroupV2Path(content string) (string, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "0::") {
+			return strings.Trim(strings.TrimPrefix(line, "0::"), "/ "), true
+		}
+	}
+
+	return "", false
+}
+
+// readCgroup - Read the usage of the cgroup out of its cpu.stat, memory.current, io.stat and pids.current
+func (reader *CgroupReader) readCgroup(path string) (commonbl.CgroupData, error) {
+	ret := commonbl.CgroupData{Path: path}
+	directory := filepath.Join(reader.CgroupRoot, path)
+	info, errStat := os.Stat(directory)
+	if errStat != nil {
+		return ret, errStat
+	}
+	if !info.IsDir() {
+		return ret, fmt.Errorf("the cgroup '%s' is not a directory", directory)
+	}
+
+	cpuStat, errCpu := readCgroupKeyValues(directory, "cpu.stat")
+	if errCpu != nil {
+		return ret, errCpu
+	}
+	ret.CpuUsageSeconds = float64(cpuStat["usage_usec"]) / 1000000
+	ret.CpuUserSeconds = float64(cpuStat["user_usec"]) / 1000000
+	ret.CpuSystemSeconds = float64(cpuStat["system_usec"]) / 1000000
+	ret.CpuThrottledSeconds = float64(cpuStat["throttled_usec"]) / 1000000
+
+	var errValue error
+	ret.MemoryUsageBytes, errValue = readCgroupValue(directory, "memory.current")
+	if errValue != nil {
+		return ret, errValue
+	}
+	ret.TaskCount, errValue = readCgroupValue(directory, "pids.current")
+	if errValue != nil {
+		return ret, errValue
+	}
+
+	ret.IoReadBytes, ret.IoWriteBytes, errValue = readCgroupIoStat(directory)
+	if errValue != nil {
+		return ret, errValue
+	}
+
+	return ret, nil
+}
+
+// readCgroupFile - Read a file of the cgroup. A missing file, of a controller not enabled for the cgroup, gives an empty content
+func readCgroupFile(directory string, name string) (string, error) {
+	content, errRead := os.ReadFile(filepath.Join(directory, name))
+	if errRead != nil {
+		if os.IsNotExist(errRead) {
+			return "", nil
+		}
+		return "", errRead
+	}
+
+	return string(content), nil
+}
+
+// readCgroupValue - Read a file of the cgroup holding a single number
+func readCgroupValue(directory string, name string) (uint64, error) {
+	content, errRead := readCgroupFile(directory, name)
+	if errRead != nil || strings.TrimSpace(content) == "" {
+		return 0, errRead
+	}
+
+	value, errConv := strconv.ParseUint(strings.TrimSpace(content), 10, 64)
+	if errConv != nil {
+		return 0, fmt.Errorf("the content of '%s' is not a number: %s", filepath.Join(directory, name), errConv)
+	}
+
+	return value, nil
+}
+
+// readCgroupKeyValues - Read a file of the cgroup with a key and a number in each line, like cpu.stat
+func readCgroupKeyValues(directory string, name string) (map[string]uint64, error) {
+	content, errRead := readCgroupFile(directory, name)
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	ret := make(map[string]uint64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		value, errConv := strconv.ParseUint(fields[1], 10, 64)
+		if errConv != nil {
+			return nil, fmt.Errorf("the value of '%s' in '%s' is not a number: %s", fields[0], filepath.Join(directory, name), errConv)
+		}
+		ret[fields[0]] = value
+	}
+
+	return ret, nil
+}
+
+// readCgroupIoStat - Read the bytes read and written by the cgroup out of the io.stat, summed up over all devices.
+// Each line of the file looks like '8:0 rbytes=1024 wbytes=512 rios=2 wios=1 dbytes=0 dios=0'
+func readCgroupIoStat(directory string) (uint64, uint64, error) {
+	content, errRead := readCgroupFile(directory, "io.stat")
+	if errRead != nil {
+		return 0, 0, errRead
+	}
+
+	readBytes := uint64(0)
+	writeBytes := uint64(0)
+	for _, line := range strings.Split(content, "\n") {
+		for _, field := range strings.Fields(line) {
+			keyValue := strings.SplitN(field, "=", 2)
+			if len(keyValue) != 2 || (keyValue[0] != "rbytes" && keyValue[0] != "wbytes") {
+				continue
+			}
+			key := keyValue[0]
+			bytes, errConv := strconv.ParseUint(keyValue[1], 10, 64)
+			if errConv != nil {
+				return 0, 0, fmt.Errorf("the value of '%s' in '%s' is not a number: %s", key, filepath.Join(directory, "io.stat"), errConv)
+			}
+			if key == "rbytes" {
+				readBytes += bytes
+			} else {
+				writeBytes += bytes
+			}
+		}
+	}
+
+	return readBytes, writeBytes, nil
+}
@@ -0,0 +1,122 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile - Write the file with the given content, creating its directory
+func writeTestFile(t *testing.T, path string, content string) {
+	os.MkdirAll(filepath.Dir(path), 0755)
+	errWrite := os.WriteFile(path, []byte(content), 0644)
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+}
+
+// newTestCgroupReader - Get a CgroupReader working on a cgroup and proc directory in a temporary directory
+func newTestCgroupReader(t *testing.T, paths []string, pids []int32) *CgroupReader {
+	root := t.TempDir()
+	reader := NewCgroupReader(paths, "smbd")
+	reader.CgroupRoot = filepath.Join(root, "cgroup")
+	reader.ProcRoot = filepath.Join(root, "proc")
+	reader.pidList = func() ([]int32, error) { return pids, nil }
+
+	directory := filepath.Join(reader.CgroupRoot, "system.slice", "smbd.service")
+	writeTestFile(t, filepath.Join(directory, "cpu.stat"), "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\nnr_periods 0\nnr_throttled 0\nthrottled_usec 250000\n")
+	writeTestFile(t, filepath.Join(directory, "memory.current"), "104857600\n")
+	writeTestFile(t, filepath.Join(directory, "pids.current"), "7\n")
+	writeTestFile(t, filepath.Join(directory, "io.stat"), "8:0 rbytes=1024 wbytes=512 rios=2 wios=1 dbytes=0 dios=0\n8:16 rbytes=2048 wbytes=256 rios=1 wios=1 dbytes=0 dios=0\n")
+	os.MkdirAll(filepath.Join(reader.CgroupRoot, "system.slice", "nmbd.service"), 0755)
+
+	return reader
+}
+
+func TestGetCgroupData(t *testing.T) {
+	reader := newTestCgroupReader(t, []string{"system.slice/smbd.service"}, nil)
+
+	data, err := reader.GetCgroupData()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(data) != 1 {
+		t.Fatalf("Got %d cgroups but expected 1", len(data))
+	}
+
+	if data[0].Path != "system.slice/smbd.service" || data[0].CpuUsageSeconds != 2.5 || data[0].CpuUserSeconds != 2 || data[0].CpuSystemSeconds != 0.5 || data[0].CpuThrottledSeconds != 0.25 {
+		t.Errorf("The cpu usage of '%v' is not expected", data[0])
+	}
+	if data[0].MemoryUsageBytes != 104857600 || data[0].TaskCount != 7 {
+		t.Errorf("The memory usage or task count of '%v' is not expected", data[0])
+	}
+	if data[0].IoReadBytes != 3072 || data[0].IoWriteBytes != 768 {
+		t.Errorf("The io of '%v' is not expected", data[0])
+	}
+}
+
+func TestGetCgroupDataMissingControllers(t *testing.T) {
+	reader := newTestCgroupReader(t, []string{"system.slice/nmbd.service"}, nil)
+
+	data, err := reader.GetCgroupData()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(data) != 1 || data[0].Path != "system.slice/nmbd.service" || data[0].CpuUsageSeconds != 0 || data[0].MemoryUsageBytes != 0 {
+		t.Errorf("The cgroup data '%v' is not expected", data)
+	}
+
+	reader.Paths = []string{"system.slice/not-existing.service"}
+	_, err = reader.GetCgroupData()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the cgroup does not exist")
+	}
+}
+
+func TestGetCgroupDataOfProcess(t *testing.T) {
+	reader := newTestCgroupReader(t, nil, []int32{1117, 1119, 1120})
+	writeTestFile(t, filepath.Join(reader.ProcRoot, "1117", "cgroup"), "0::/system.slice/smbd.service\n")
+	writeTestFile(t, filepath.Join(reader.ProcRoot, "1119", "cgroup"), "0::/system.slice/smbd.service\n")
+
+	data, err := reader.GetCgroupData()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(data) != 1 || data[0].Path != "system.slice/smbd.service" || data[0].TaskCount != 7 {
+		t.Errorf("The cgroup data '%v' is not expected", data)
+	}
+
+	writeTestFile(t, filepath.Join(reader.ProcRoot, "1120", "cgroup"), "12:memory:/system.slice/smbd.service\n")
+	_, err = reader.GetCgroupData()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since the process 1120 does not run in a cgroup v2 hierarchy")
+	}
+
+	reader.pidList = func() ([]int32, error) { return nil, nil }
+	data, err = reader.GetCgroupData()
+	if err != nil || len(data) != 0 {
+		t.Errorf("Got the cgroups '%v' and the error '%v', but expected none, since smbd is not running", data, err)
+	}
+}
+
+func TestGetCgroupV2Path(t *testing.T) {
+	path, isV2 := getCgroupV2Path("0::/system.slice/smbd.service\n")
+	if !isV2 || path != "system.slice/smbd.service" {
+		t.Errorf("Got the path '%s', but expected 'system.slice/smbd.service'", path)
+	}
+
+	path, isV2 = getCgroupV2Path("0::/\n")
+	if !isV2 || path != "" {
+		t.Errorf("Got the path '%s', but expected the root cgroup", path)
+	}
+
+	_, isV2 = getCgroupV2Path("12:pids:/system.slice/smbd.service\n1:name=systemd:/system.slice/smbd.service\n")
+	if isV2 {
+		t.Errorf("Found a cgroup v2 path in a cgroup v1 hierarchy")
+	}
+}
@@ -0,0 +1,93 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The maximum time 'ctdb listnodes' may run, before it is killed
+const CTDB_LISTNODES_TIMEOUT = 3 * time.Second
+
+// CtdbNodeLister - Lists the nodes of the CTDB cluster samba runs in, by calling 'ctdb -X listnodes'
+type CtdbNodeLister struct {
+	// The path of the ctdb executable
+	CtdbPath string
+	// The maximum time ctdb may run
+	Timeout time.Duration
+}
+
+// NewCtdbNodeLister - Get a new CtdbNodeLister calling the given ctdb executable
+func NewCtdbNodeLister(ctdbPath string) *CtdbNodeLister {
+	return &CtdbNodeLister{CtdbPath: ctdbPath, Timeout: CTDB_LISTNODES_TIMEOUT}
+}
+
+// GetCtdbNodes - Get the nodes of the cluster with their addresses
+func (lister *CtdbNodeLister) GetCtdbNodes() ([]commonbl.CtdbNode, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lister.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, lister.CtdbPath, "-X", "listnodes")
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	errRun := command.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("\"%s -X listnodes\" did not finish within %s", lister.CtdbPath, lister.Timeout)
+	}
+	if errRun != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("\"%s -X listnodes\" failed: %s: %s", lister.CtdbPath, errRun.Error(), message)
+		}
+		return nil, fmt.Errorf("\"%s -X listnodes\" failed: %s", lister.CtdbPath, errRun.Error())
+	}
+
+	return parseCtdbListnodes(stdout.String()), nil
+}
+
+// parseCtdbListnodes - Parse the output of 'ctdb -X listnodes', a header line '|Node|IP|' followed by a line like '|0|192.168.1.10|'
+// for each node. Deleted nodes are not listed. The output of 'ctdb listnodes' without -X, an address per line, is read as well,
+// numbering the nodes in the order of the lines
+func parseCtdbListnodes(output string) []commonbl.CtdbNode {
+	ret := []commonbl.CtdbNode{}
+	index := 0
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "|") {
+			// A commented address in the nodes file is a deleted node, that keeps its number
+			if !strings.HasPrefix(line, "#") {
+				ret = append(ret, commonbl.CtdbNode{Node: index, Address: line})
+			}
+			index++
+			continue
+		}
+
+		fields := strings.Split(strings.Trim(line, "|"), "|")
+		if len(fields) < 2 {
+			continue
+		}
+		node, errConv := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if errConv != nil {
+			// The header line
+			continue
+		}
+		ret = append(ret, commonbl.CtdbNode{Node: node, Address: strings.TrimSpace(fields[1])})
+	}
+
+	return ret
+}
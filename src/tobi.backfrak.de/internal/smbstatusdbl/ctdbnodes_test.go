@@ -0,0 +1,65 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"path/filepath"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestParseCtdbListnodes(t *testing.T) {
+	nodes := parseCtdbListnodes("|Node|IP|\n|0|192.168.1.10|\n|2|192.168.1.12|\n")
+
+	expected := []commonbl.CtdbNode{{Node: 0, Address: "192.168.1.10"}, {Node: 2, Address: "192.168.1.12"}}
+	if len(nodes) != len(expected) || nodes[0] != expected[0] || nodes[1] != expected[1] {
+		t.Errorf("Got the nodes '%v', but expected '%v'", nodes, expected)
+	}
+}
+
+func TestParseCtdbListnodesPlain(t *testing.T) {
+	nodes := parseCtdbListnodes("192.168.1.10\n#192.168.1.11\n 192.168.1.12 \n\n")
+
+	expected := []commonbl.CtdbNode{{Node: 0, Address: "192.168.1.10"}, {Node: 2, Address: "192.168.1.12"}}
+	if len(nodes) != len(expected) || nodes[0] != expected[0] || nodes[1] != expected[1] {
+		t.Errorf("Got the nodes '%v', but expected '%v'", nodes, expected)
+	}
+
+	if nodes := parseCtdbListnodes(""); len(nodes) != 0 {
+		t.Errorf("Got the nodes '%v', but expected none", nodes)
+	}
+}
+
+func TestGetCtdbNodes(t *testing.T) {
+	directory := t.TempDir()
+	writeTestPlugin(t, directory, "ctdb", "echo '|Node|IP|'; echo '|0|10.0.0.1|'", 0755)
+	ctdb := filepath.Join(directory, "ctdb")
+
+	nodes, err := NewCtdbNodeLister(ctdb).GetCtdbNodes()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(nodes) != 1 || nodes[0].Node != 0 || nodes[0].Address != "10.0.0.1" {
+		t.Errorf("Got the nodes '%v', but expected node 0 with the address '10.0.0.1'", nodes)
+	}
+}
+
+func TestGetCtdbNodesFails(t *testing.T) {
+	directory := t.TempDir()
+	writeTestPlugin(t, directory, "ctdb", "echo 'connect failed' >&2; exit 1", 0755)
+	ctdb := filepath.Join(directory, "ctdb")
+
+	_, err := NewCtdbNodeLister(ctdb).GetCtdbNodes()
+	if err == nil {
+		t.Fatalf("Got no error but expected one")
+	}
+
+	_, err = NewCtdbNodeLister(filepath.Join(t.TempDir(), "notExisting")).GetCtdbNodes()
+	if err == nil {
+		t.Errorf("Got no error but expected one, since ctdb does not exist")
+	}
+}
@@ -0,0 +1,336 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// DEMO_UPDATE_INTERVAL - The time between two changes of the demo data. Requests within this time get the same data
+const DEMO_UPDATE_INTERVAL = 15 * time.Second
+
+// DEMO_MIN_SESSIONS - The number of sessions in the demo data outside of the office hours
+const DEMO_MIN_SESSIONS = 2
+
+// DEMO_MAX_SESSIONS - The number of sessions in the demo data at the peak of a working day
+const DEMO_MAX_SESSIONS = 24
+
+// The most locks a session of the demo data holds
+const demoMaxLocksPerSession = 8
+
+// The samba version printed in the process table of the demo data
+const demoSambaVersion = "4.15.13-Ubuntu"
+
+// The group all users of the demo data belong to
+const demoGroupId = 100
+
+// The pid of the smbd process that starts the smbd processes of the sessions
+const demoParentPid = 1012
+
+const demoProcessTableHeader = `
+Samba version %s
+PID     Username     Group        Machine                                   Protocol Version  Encryption           Signing
+----------------------------------------------------------------------------------------------------------------------------------------`
+
+const demoShareTableHeader = `
+Service      pid     Machine       Connected at                     Encryption   Signing
+---------------------------------------------------------------------------------------------`
+
+const demoLockTableHeader = `
+Locked files:
+Pid          User(ID)   DenyMode   Access      R/W        Oplock           SharePath   Name   Time
+--------------------------------------------------------------------------------------------------`
+
+// demoShare - A share of the demo data and the files that are locked on it
+type demoShare struct {
+	name  string
+	path  string
+	files []string
+}
+
+// demoLockMode - The way a file of the demo data is opened
+type demoLockMode struct {
+	denyMode   string
+	access     string
+	accessMode string
+	oplock     string
+}
+
+// demoConnection - The encryption and signing of a connection in the demo data
+type demoConnection struct {
+	protocol   string
+	encryption string
+	signing    string
+}
+
+var demoShares = []demoShare{
+	{"data", "/srv/samba/data", []string{"Budget 2024.xlsx", "contracts/offer-1041.pdf", "contracts/offer-1042.pdf", "team meeting.docx", "inventory.ods"}},
+	{"projects", "/srv/samba/projects", []string{"website/index.html", "website/style.css", "app/main.go", "app/README.md", "design/logo.svg", "design/flyer.indd"}},
+	{"scans", "/srv/samba/scans", []string{"scan_0001.pdf", "scan_0002.pdf", "scan_0003.pdf"}},
+	{"media", "/srv/samba/media", []string{"photos/summer party.jpg", "photos/team.jpg", "videos/training.mp4"}},
+	{"public", "/srv/samba/public", []string{"canteen menu.pdf", "phone list.xlsx", "templates/letter.dotx", "templates/invoice.ott"}},
+}
+
+var demoUserIds = []int{1000, 1001, 1002, 1003, 1004, 1005, 1006, 1007, 1008, 1009, 1010, 1011}
+
+var demoLockModes = []demoLockMode{
+	{"DENY_NONE", "0x80", "RDONLY", "NONE"},
+	{"DENY_NONE", "0x120089", "RDONLY", "LEASE(RWH)"},
+	{"DENY_WRITE", "0x120089", "RDONLY", "LEASE(RWH)"},
+	{"DENY_NONE", "0x12019f", "RDWR", "LEASE(RWH)"},
+	{"DENY_ALL", "0x12019f", "RDWR", "EXCLUSIVE+BATCH"},
+}
+
+var demoConnections = []demoConnection{
+	{"SMB3_11", "-", "partial(AES-128-CMAC)"},
+	{"SMB3_11", "-", "partial(AES-128-GMAC)"},
+	{"SMB3_11", "AES-128-GCM", "AES-128-GMAC"},
+	{"SMB3_02", "-", "partial(HMAC-SHA256)"},
+	{"SMB2_10", "-", "-"},
+}
+
+// demoLock - A file locked by a session of the demo data
+type demoLock struct {
+	share     demoShare
+	name      string
+	mode      demoLockMode
+	createdAt time.Time
+}
+
+// demoSession - A client connected to the samba server of the demo data, served by an own smbd process
+type demoSession struct {
+	pid         int
+	userId      int
+	ip          string
+	port        int
+	connection  demoConnection
+	connectedAt time.Time
+	shares      []demoShare
+	locks       []demoLock
+	psData      commonbl.PsUtilPidData
+}
+
+// DemoDataGenerator - Generates the output of smbstatus and the resource usage of the smbd processes for a samba server
+// that does not exist. The sessions follow the office hours, clients connect and disconnect and files are locked and unlocked
+type DemoDataGenerator struct {
+	mutex      sync.Mutex
+	random     *rand.Rand
+	sessions   []*demoSession
+	nextPid    int
+	lastUpdate time.Time
+	parentPs   commonbl.PsUtilPidData
+	now        func() time.Time
+}
+
+// NewDemoDataGenerator - Get a new DemoDataGenerator. Generators with the same seed generate the same data at the same time
+func NewDemoDataGenerator(seed int64) *DemoDataGenerator {
+	generator := DemoDataGenerator{
+		random:   rand.New(rand.NewSource(seed)),
+		nextPid:  demoParentPid + 100,
+		now:      time.Now,
+		parentPs: commonbl.PsUtilPidData{PID: demoParentPid, VirtualMemoryUsageBytes: 96 * 1024 * 1024, VirtualMemoryUsagePercent: 0.6, ThreadCount: 1},
+	}
+
+	return &generator
+}
+
+// GetProcessTable - Get the demo data in the format of 'smbstatus -p -n'
+func (generator *DemoDataGenerator) GetProcessTable() string {
+	generator.mutex.Lock()
+	defer generator.mutex.Unlock()
+	generator.update()
+
+	lines := []string{fmt.Sprintf(demoProcessTableHeader, demoSambaVersion)}
+	for _, session := range generator.sessions {
+		machine := fmt.Sprintf("%s (ipv4:%s:%d)", session.ip, session.ip, session.port)
+		lines = append(lines, fmt.Sprintf("%-7d %-12d %-12d %-41s %-17s %-20s %-20s", session.pid, session.userId, demoGroupId, machine,
+			session.connection.protocol, session.connection.encryption, session.connection.signing))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// GetShareTable - Get the demo data in the format of 'smbstatus -S -n'
+func (generator *DemoDataGenerator) GetShareTable() string {
+	generator.mutex.Lock()
+	defer generator.mutex.Unlock()
+	generator.update()
+
+	lines := []string{demoShareTableHeader}
+	for _, session := range generator.sessions {
+		for _, share := range session.shares {
+			lines = append(lines, fmt.Sprintf("%-12s %-7d %-14s %s %-12s %-12s", share.name, session.pid, session.ip,
+				session.connectedAt.Local().Format("Mon Jan 02 03:04:05 PM 2006 MST"), session.connection.encryption, session.connection.signing))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// GetLockTable - Get the demo data in the format of 'smbstatus -L -n'
+func (generator *DemoDataGenerator) GetLockTable() string {
+	generator.mutex.Lock()
+	defer generator.mutex.Unlock()
+	generator.update()
+
+	lines := []string{demoLockTableHeader}
+	for _, session := range generator.sessions {
+		for _, lock := range session.locks {
+			lines = append(lines, fmt.Sprintf("%-12d %-10d %-10s %-11s %-10s %-16s %s   %s   %s", session.pid, session.userId, lock.mode.denyMode, lock.mode.access,
+				lock.mode.accessMode, lock.mode.oplock, lock.share.path, lock.name, lock.createdAt.Local().Format(time.ANSIC)))
+		}
+	}
+	if len(lines) == 1 {
+		return fmt.Sprintf("\n%s\n", commonbl.NO_LOCKED_FILES)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// GetPsUtilPidData - Get the resource usage of the smbd processes of the demo data
+func (generator *DemoDataGenerator) GetPsUtilPidData() ([]commonbl.PsUtilPidData, error) {
+	generator.mutex.Lock()
+	defer generator.mutex.Unlock()
+	generator.update()
+
+	ret := []commonbl.PsUtilPidData{generator.parentPs}
+	for _, session := range generator.sessions {
+		ret = append(ret, session.psData)
+	}
+
+	return ret, nil
+}
+
+// update - Change the demo data, when the last change is DEMO_UPDATE_INTERVAL ago
+func (generator *DemoDataGenerator) update() {
+	now := generator.now()
+	if !generator.lastUpdate.IsZero() && now.Sub(generator.lastUpdate) < DEMO_UPDATE_INTERVAL {
+		return
+	}
+	firstUpdate := generator.lastUpdate.IsZero()
+	generator.lastUpdate = now
+
+	target := getDemoSessionTarget(now)
+	var sessions []*demoSession
+	for _, session := range generator.sessions {
+		// Sessions end more likely when there are more sessions than usual at this time
+		leaveChance := 0.03
+		if len(generator.sessions) > target {
+			leaveChance = 0.2
+		}
+		if generator.random.Float64() >= leaveChance {
+			sessions = append(sessions, session)
+		}
+	}
+	generator.sessions = sessions
+
+	for len(generator.sessions) < target {
+		generator.sessions = append(generator.sessions, generator.newSession(now, firstUpdate))
+		if !firstUpdate && generator.random.Float64() < 0.5 {
+			break
+		}
+	}
+
+	for _, session := range generator.sessions {
+		generator.updateLocks(session, now)
+		generator.updatePsData(session)
+	}
+	generator.parentPs.CpuUsagePercent = 0.1 + generator.random.Float64()*0.2
+}
+
+// newSession - Get a new session, connected at now. The sessions of the first update are connected up to an hour before
+func (generator *DemoDataGenerator) newSession(now time.Time, firstUpdate bool) *demoSession {
+	connectedAt := now
+	if firstUpdate {
+		connectedAt = now.Add(-time.Duration(generator.random.Int63n(int64(time.Hour))))
+	}
+	session := demoSession{
+		pid:         generator.nextPid,
+		userId:      demoUserIds[generator.random.Intn(len(demoUserIds))],
+		ip:          fmt.Sprintf("192.168.1.%d", 20+generator.random.Intn(200)),
+		port:        49152 + generator.random.Intn(16384),
+		connection:  demoConnections[generator.random.Intn(len(demoConnections))],
+		connectedAt: connectedAt.Truncate(time.Second),
+	}
+	generator.nextPid += 1 + generator.random.Intn(20)
+
+	// Windows clients connect to IPC$ first, then to the shares they use
+	session.shares = append(session.shares, demoShare{name: "IPC$", path: "/tmp"})
+	for _, index := range generator.random.Perm(len(demoShares))[:1+generator.random.Intn(2)] {
+		session.shares = append(session.shares, demoShares[index])
+	}
+	session.psData = commonbl.PsUtilPidData{
+		PID:                       int64(session.pid),
+		VirtualMemoryUsageBytes:   uint64(40+generator.random.Intn(40)) * 1024 * 1024,
+		VirtualMemoryUsagePercent: 0.3 + generator.random.Float64()*0.4,
+		ThreadCount:               1,
+	}
+
+	return &session
+}
+
+// updateLocks - Unlock some of the files of the session and lock others on the shares the session uses
+func (generator *DemoDataGenerator) updateLocks(session *demoSession, now time.Time) {
+	var locks []demoLock
+	for _, lock := range session.locks {
+		if generator.random.Float64() >= 0.25 {
+			locks = append(locks, lock)
+		}
+	}
+	session.locks = locks
+
+	for i := generator.random.Intn(3); i > 0 && len(session.locks) < demoMaxLocksPerSession; i-- {
+		// The shares without IPC$, where no files are locked
+		share := session.shares[1+generator.random.Intn(len(session.shares)-1)]
+		session.locks = append(session.locks, demoLock{
+			share:     share,
+			name:      share.files[generator.random.Intn(len(share.files))],
+			mode:      demoLockModes[generator.random.Intn(len(demoLockModes))],
+			createdAt: now.Truncate(time.Second),
+		})
+	}
+}
+
+// updatePsData - Change the resource usage of the smbd process of the session, depending on the number of locked files
+func (generator *DemoDataGenerator) updatePsData(session *demoSession) {
+	activity := uint64(len(session.locks))
+	session.psData.CpuUsagePercent = float64(activity)*0.4 + generator.random.Float64()
+	session.psData.IoCounterReadCount += activity*uint64(generator.random.Intn(200)) + 5
+	session.psData.IoCounterReadBytes += activity*uint64(generator.random.Intn(4*1024*1024)) + 4096
+	session.psData.IoCounterWriteCount += activity * uint64(generator.random.Intn(50))
+	session.psData.IoCounterWriteBytes += activity * uint64(generator.random.Intn(1024*1024))
+	session.psData.OpenFilesCount = activity + 12
+	session.psData.FileDescriptorCount = session.psData.OpenFilesCount + 9
+	session.psData.FileDescriptorLimit = 16384
+	// Each demo session is one client connected to its own smbd
+	session.psData.EstablishedConnectionCount = 1
+	session.psData.ThreadCount = 1 + activity/3
+	session.psData.VoluntaryContextSwitches += activity*uint64(generator.random.Intn(300)) + 20
+	session.psData.InvoluntaryContextSwitches += activity * uint64(generator.random.Intn(10))
+}
+
+// getDemoSessionTarget - Get the number of sessions the demo data has at the time. The sessions rise in the morning
+// and fall in the evening of working days, between DEMO_MIN_SESSIONS and DEMO_MAX_SESSIONS
+func getDemoSessionTarget(now time.Time) int {
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		return DEMO_MIN_SESSIONS
+	}
+
+	hour := float64(now.Hour()) + float64(now.Minute())/60
+	activity := math.Sin(math.Pi * (hour - 7) / 12)
+	if activity < 0 {
+		activity = 0
+	}
+
+	return DEMO_MIN_SESSIONS + int(math.Round(activity*(DEMO_MAX_SESSIONS-DEMO_MIN_SESSIONS)))
+}
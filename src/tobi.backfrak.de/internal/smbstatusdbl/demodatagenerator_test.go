@@ -0,0 +1,122 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// getTestDemoDataGenerator - Get a DemoDataGenerator with the time given by the returned pointer
+func getTestDemoDataGenerator(seed int64, now time.Time) (*DemoDataGenerator, *time.Time) {
+	generator := NewDemoDataGenerator(seed)
+	current := now
+	generator.now = func() time.Time { return current }
+
+	return generator, &current
+}
+
+func TestDemoDataGeneratorTables(t *testing.T) {
+	// A Wednesday noon, the peak of the sessions
+	generator, _ := getTestDemoDataGenerator(42, time.Date(2024, 5, 15, 13, 0, 0, 0, time.Local))
+
+	processLines := strings.Split(strings.TrimSpace(generator.GetProcessTable()), "\n")
+	if processLines[0] != fmt.Sprintf("Samba version %s", demoSambaVersion) {
+		t.Errorf("The first line '%s' of the process table is not expected", processLines[0])
+	}
+	if len(processLines)-3 != DEMO_MAX_SESSIONS {
+		t.Errorf("Got %d sessions but expected %d", len(processLines)-3, DEMO_MAX_SESSIONS)
+	}
+
+	pids := make(map[string]bool)
+	for _, line := range processLines[3:] {
+		pids[strings.Fields(line)[0]] = true
+	}
+	for _, line := range strings.Split(strings.TrimSpace(generator.GetShareTable()), "\n")[2:] {
+		if !pids[strings.Fields(line)[1]] {
+			t.Errorf("The share line '%s' has no process", line)
+		}
+	}
+	lockLines := strings.Split(strings.TrimSpace(generator.GetLockTable()), "\n")
+	if lockLines[0] != "Locked files:" {
+		t.Fatalf("The first line '%s' of the lock table is not expected", lockLines[0])
+	}
+	for _, line := range lockLines[3:] {
+		if !pids[strings.Fields(line)[0]] {
+			t.Errorf("The lock line '%s' has no process", line)
+		}
+	}
+
+	psData, _ := generator.GetPsUtilPidData()
+	if len(psData) != DEMO_MAX_SESSIONS+1 || psData[0].PID != demoParentPid {
+		t.Errorf("Got %d processes starting with %d, but expected %d starting with %d", len(psData), psData[0].PID, DEMO_MAX_SESSIONS+1, demoParentPid)
+	}
+}
+
+func TestDemoDataGeneratorUpdate(t *testing.T) {
+	generator, now := getTestDemoDataGenerator(7, time.Date(2024, 5, 15, 13, 0, 0, 0, time.Local))
+
+	first := generator.GetLockTable()
+	*now = now.Add(DEMO_UPDATE_INTERVAL / 2)
+	if generator.GetLockTable() != first {
+		t.Errorf("The demo data changed within the DEMO_UPDATE_INTERVAL")
+	}
+
+	changed := false
+	for i := 0; i < 10 && !changed; i++ {
+		*now = now.Add(DEMO_UPDATE_INTERVAL)
+		changed = generator.GetLockTable() != first
+	}
+	if !changed {
+		t.Errorf("The demo data did not change after the DEMO_UPDATE_INTERVAL")
+	}
+}
+
+func TestDemoDataGeneratorSameSeed(t *testing.T) {
+	now := time.Date(2024, 5, 15, 9, 30, 0, 0, time.Local)
+	generator1, _ := getTestDemoDataGenerator(3, now)
+	generator2, _ := getTestDemoDataGenerator(3, now)
+
+	if generator1.GetShareTable() != generator2.GetShareTable() {
+		t.Errorf("The generators with the same seed generated different data")
+	}
+}
+
+func TestDemoDataGeneratorNoLocks(t *testing.T) {
+	generator, _ := getTestDemoDataGenerator(1, time.Date(2024, 5, 18, 3, 0, 0, 0, time.Local))
+	generator.GetProcessTable()
+	for _, session := range generator.sessions {
+		session.locks = nil
+	}
+
+	if strings.TrimSpace(generator.GetLockTable()) != commonbl.NO_LOCKED_FILES {
+		t.Errorf("The lock table '%s' is not expected", generator.GetLockTable())
+	}
+}
+
+func TestGetDemoSessionTarget(t *testing.T) {
+	data := map[time.Time]int{
+		time.Date(2024, 5, 15, 13, 0, 0, 0, time.UTC): DEMO_MAX_SESSIONS,
+		time.Date(2024, 5, 15, 3, 0, 0, 0, time.UTC):  DEMO_MIN_SESSIONS,
+		time.Date(2024, 5, 15, 22, 0, 0, 0, time.UTC): DEMO_MIN_SESSIONS,
+		time.Date(2024, 5, 18, 13, 0, 0, 0, time.UTC): DEMO_MIN_SESSIONS,
+	}
+
+	for now, expected := range data {
+		target := getDemoSessionTarget(now)
+		if target != expected {
+			t.Errorf("Got %d sessions at %s but expected %d", target, now, expected)
+		}
+	}
+	morning := getDemoSessionTarget(time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC))
+	if morning <= DEMO_MIN_SESSIONS || morning >= DEMO_MAX_SESSIONS {
+		t.Errorf("Got %d sessions in the morning", morning)
+	}
+}
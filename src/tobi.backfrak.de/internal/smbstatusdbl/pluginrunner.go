@@ -0,0 +1,121 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The time a plugin gets to close its output after it was killed, before its output is read anyway
+const pluginWaitDelay = time.Second
+
+// PluginRunner - Runs the executable files of a directory as plugins. Each plugin prints a JSON array of metrics
+// to stdout, as described by commonbl.ParsePluginOutput
+type PluginRunner struct {
+	Directory string
+	// The maximum time a plugin may run, before it is killed
+	Timeout time.Duration
+}
+
+// NewPluginRunner - Get a new PluginRunner for the plugins in the directory
+func NewPluginRunner(directory string, timeout time.Duration) *PluginRunner {
+	return &PluginRunner{Directory: directory, Timeout: timeout}
+}
+
+// GetPlugins - Get the paths of the plugins, the executable files in the Directory sorted by name. Hidden files are ignored
+func (runner *PluginRunner) GetPlugins() ([]string, error) {
+	entries, errRead := os.ReadDir(runner.Directory)
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	var ret []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(runner.Directory, entry.Name())
+		info, errStat := os.Stat(path)
+		if errStat != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		ret = append(ret, path)
+	}
+	sort.Strings(ret)
+
+	return ret, nil
+}
+
+// RunPlugins - Run all plugins in parallel and get their results in the order of GetPlugins. A plugin that fails,
+// times out or prints invalid metrics has the error in its result
+func (runner *PluginRunner) RunPlugins() ([]commonbl.PluginResult, error) {
+	plugins, errGet := runner.GetPlugins()
+	if errGet != nil {
+		return nil, errGet
+	}
+
+	ret := make([]commonbl.PluginResult, len(plugins))
+	var wg sync.WaitGroup
+	for i, plugin := range plugins {
+		wg.Add(1)
+		go func(i int, plugin string) {
+			defer wg.Done()
+			ret[i] = runner.runPlugin(plugin)
+		}(i, plugin)
+	}
+	wg.Wait()
+
+	return ret, nil
+}
+
+// runPlugin - Run the plugin and parse its output
+func (runner *PluginRunner) runPlugin(path string) commonbl.PluginResult {
+	ret := commonbl.PluginResult{Plugin: filepath.Base(path), Metrics: []commonbl.PluginMetric{}}
+	ctx, cancel := context.WithTimeout(context.Background(), runner.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, path)
+	command.Dir = runner.Directory
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	command.WaitDelay = pluginWaitDelay
+
+	start := time.Now()
+	errRun := command.Run()
+	ret.DurationSeconds = time.Since(start).Seconds()
+	if ctx.Err() == context.DeadlineExceeded {
+		ret.Error = fmt.Sprintf("the plugin did not finish within %s", runner.Timeout)
+		return ret
+	}
+	if errRun != nil {
+		ret.Error = fmt.Sprintf("the plugin failed: %s", errRun.Error())
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			ret.Error = fmt.Sprintf("%s: %s", ret.Error, message)
+		}
+		return ret
+	}
+
+	metrics, errParse := commonbl.ParsePluginOutput(stdout.Bytes())
+	if errParse != nil {
+		ret.Error = errParse.Error()
+		return ret
+	}
+	ret.Metrics = metrics
+
+	return ret
+}
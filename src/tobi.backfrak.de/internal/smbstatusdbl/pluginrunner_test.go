@@ -0,0 +1,79 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestPlugin - Write a shell script with the given body as plugin into the directory
+func writeTestPlugin(t *testing.T, directory string, name string, body string, mode os.FileMode) {
+	errWrite := os.WriteFile(filepath.Join(directory, name), []byte("#!/bin/sh\n"+body+"\n"), mode)
+	if errWrite != nil {
+		t.Fatalf("Got error '%s' but expected none", errWrite.Error())
+	}
+}
+
+func TestGetPlugins(t *testing.T) {
+	directory := t.TempDir()
+	writeTestPlugin(t, directory, "b_plugin", "echo '[]'", 0755)
+	writeTestPlugin(t, directory, "a_plugin", "echo '[]'", 0755)
+	writeTestPlugin(t, directory, "not_executable", "echo '[]'", 0644)
+	writeTestPlugin(t, directory, ".hidden", "echo '[]'", 0755)
+	os.Mkdir(filepath.Join(directory, "subdirectory"), 0755)
+
+	plugins, err := NewPluginRunner(directory, time.Second).GetPlugins()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(plugins) != 2 || filepath.Base(plugins[0]) != "a_plugin" || filepath.Base(plugins[1]) != "b_plugin" {
+		t.Errorf("Got the plugins '%s' but expected 'a_plugin' and 'b_plugin'", strings.Join(plugins, ", "))
+	}
+
+	_, err = NewPluginRunner(filepath.Join(directory, "not_existing"), time.Second).GetPlugins()
+	if err == nil {
+		t.Errorf("Expected an error for a not existing directory but got none")
+	}
+}
+
+func TestRunPlugins(t *testing.T) {
+	directory := t.TempDir()
+	writeTestPlugin(t, directory, "1_quota", `echo '[{"name": "quota_used_bytes", "help": "Bytes used", "labels": {"share": "data"}, "value": 42}]'`, 0755)
+	writeTestPlugin(t, directory, "2_failing", "echo 'no quota' >&2; exit 3", 0755)
+	writeTestPlugin(t, directory, "3_invalid", "echo 'not json'", 0755)
+	writeTestPlugin(t, directory, "4_slow", "sleep 5; echo '[]'", 0755)
+
+	start := time.Now()
+	results, err := NewPluginRunner(directory, 500*time.Millisecond).RunPlugins()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Running the plugins took %s, the slow plugin was not killed", time.Since(start))
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("Got %d results but expected 4", len(results))
+	}
+
+	if results[0].Plugin != "1_quota" || results[0].Error != "" || len(results[0].Metrics) != 1 || results[0].Metrics[0].Value != 42 {
+		t.Errorf("The result '%v' of the quota plugin is not expected", results[0])
+	}
+	if !strings.Contains(results[1].Error, "no quota") || len(results[1].Metrics) != 0 {
+		t.Errorf("The error '%s' of the failing plugin is not expected", results[1].Error)
+	}
+	if !strings.Contains(results[2].Error, "JSON") {
+		t.Errorf("The error '%s' of the invalid plugin is not expected", results[2].Error)
+	}
+	if !strings.Contains(results[3].Error, "did not finish") {
+		t.Errorf("The error '%s' of the slow plugin is not expected", results[3].Error)
+	}
+}
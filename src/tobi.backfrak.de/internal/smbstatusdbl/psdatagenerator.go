@@ -6,9 +6,13 @@ package smbstatusdbl
 // LICENSE file.
 
 import (
+	"fmt"
+	"math"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/shirou/gopsutil/v3/process"
 
@@ -18,12 +22,23 @@ import (
 // Class to get commonbl.PsUtilPidData for a Process
 type PsDataGenerator struct {
 	ProcessToRequest string
-	pgrepPath        string
+	// Logs the values that can not be read, e. g. since samba_statusd runs without the privileges of root. Nothing is logged, when nil
+	Logger    commonbl.Logger
+	pgrepPath string
+	mMutex    sync.Mutex
+	// The values that could not be read before, their errors are logged verbose only
+	unreadable map[string]bool
+}
+
+// readError - The error reading the value of a process
+type readError struct {
+	value string
+	err   error
 }
 
 // Get a new instance of PsDataGenerator
 func NewPsDataGenerator(processToRequest string) (*PsDataGenerator, error) {
-	ret := PsDataGenerator{}
+	ret := PsDataGenerator{unreadable: map[string]bool{}}
 	var errLookPath error
 	ret.ProcessToRequest = processToRequest
 	ret.pgrepPath, errLookPath = exec.LookPath("pgrep")
@@ -36,7 +51,8 @@ func NewPsDataGenerator(processToRequest string) (*PsDataGenerator, error) {
 
 // Get the commonbl.PsUtilPidData data of the ProcessToRequest.
 // - In case this process is not running an empty list is returned
-// - In case an error, other then not finding the process, occurs during gathering data it is returned
+// - A process exiting while its data is gathered is skipped
+// - A value that can not be read is logged and left 0, the other values of the process are still returned
 func (generator *PsDataGenerator) GetPsUtilPidData() ([]commonbl.PsUtilPidData, error) {
 	ret := []commonbl.PsUtilPidData{}
 
@@ -46,48 +62,16 @@ func (generator *PsDataGenerator) GetPsUtilPidData() ([]commonbl.PsUtilPidData,
 	}
 
 	for _, pid := range pidList {
-
 		proc, errProc := process.NewProcess(pid)
 		if errProc != nil {
-			return nil, errProc
-		}
-
-		cpuPercent, errPer := proc.CPUPercent()
-		if errPer != nil {
-			return nil, errPer
-		}
-		vmBytes, errVmBytes := proc.MemoryInfo()
-		if errVmBytes != nil {
-			return nil, errVmBytes
-		}
-		vmPercent, errVmPercent := proc.MemoryPercent()
-		if errVmPercent != nil {
-			return nil, errVmPercent
-		}
-		ioCounters, errIoCounters := proc.IOCounters()
-		if errIoCounters != nil {
-			return nil, errIoCounters
-		}
-		openFileStats, errOpenFileStats := proc.OpenFiles()
-		if errOpenFileStats != nil {
-			return nil, errOpenFileStats
-		}
-		threadStats, errThreadStats := proc.Threads()
-		if errThreadStats != nil {
-			return nil, errThreadStats
+			generator.writeVerbose(fmt.Sprintf("Skip the process %d, since it exited: %s", pid, errProc.Error()))
+			continue
 		}
 
-		entry := commonbl.PsUtilPidData{
-			int64(pid),
-			cpuPercent,
-			vmBytes.VMS,
-			float64(vmPercent),
-			ioCounters.ReadCount,
-			ioCounters.ReadBytes,
-			ioCounters.WriteCount,
-			ioCounters.WriteBytes,
-			uint64(len(openFileStats)),
-			uint64(len(threadStats)),
+		entry, running := generator.getProcessData(proc)
+		if !running {
+			generator.writeVerbose(fmt.Sprintf("Skip the process %d, since it exited", pid))
+			continue
 		}
 
 		ret = append(ret, entry)
@@ -96,6 +80,114 @@ func (generator *PsDataGenerator) GetPsUtilPidData() ([]commonbl.PsUtilPidData,
 	return ret, nil
 }
 
+// getProcessData - Get the commonbl.PsUtilPidData of the process. The values that can not be read are logged and left 0.
+// Returns false, when the process exited meanwhile
+func (generator *PsDataGenerator) getProcessData(proc *process.Process) (commonbl.PsUtilPidData, bool) {
+	entry := commonbl.PsUtilPidData{PID: int64(proc.Pid)}
+	failed := []readError{}
+
+	cpuPercent, errPer := proc.CPUPercent()
+	if errPer != nil {
+		failed = append(failed, readError{"CPU usage", errPer})
+	}
+	entry.CpuUsagePercent = cpuPercent
+	vmBytes, errVmBytes := proc.MemoryInfo()
+	if errVmBytes != nil {
+		failed = append(failed, readError{"virtual memory usage", errVmBytes})
+	} else {
+		entry.VirtualMemoryUsageBytes = vmBytes.VMS
+	}
+	vmPercent, errVmPercent := proc.MemoryPercent()
+	if errVmPercent != nil {
+		failed = append(failed, readError{"virtual memory usage percent", errVmPercent})
+	}
+	entry.VirtualMemoryUsagePercent = float64(vmPercent)
+	ioCounters, errIoCounters := proc.IOCounters()
+	if errIoCounters != nil {
+		failed = append(failed, readError{"I/O counters", errIoCounters})
+	} else {
+		entry.IoCounterReadCount = ioCounters.ReadCount
+		entry.IoCounterReadBytes = ioCounters.ReadBytes
+		entry.IoCounterWriteCount = ioCounters.WriteCount
+		entry.IoCounterWriteBytes = ioCounters.WriteBytes
+	}
+	openFileStats, errOpenFileStats := proc.OpenFiles()
+	if errOpenFileStats != nil {
+		failed = append(failed, readError{"open files", errOpenFileStats})
+	}
+	entry.OpenFilesCount = uint64(len(openFileStats))
+	threadStats, errThreadStats := proc.Threads()
+	if errThreadStats != nil {
+		failed = append(failed, readError{"threads", errThreadStats})
+	}
+	entry.ThreadCount = uint64(len(threadStats))
+	fileDescriptors, errFileDescriptors := proc.NumFDs()
+	if errFileDescriptors != nil {
+		failed = append(failed, readError{"file descriptors", errFileDescriptors})
+	}
+	entry.FileDescriptorCount = uint64(fileDescriptors)
+	connectionStats, errConnectionStats := proc.Connections()
+	if errConnectionStats != nil {
+		failed = append(failed, readError{"connections", errConnectionStats})
+	}
+	// More established connections than sessions of the process point to half-open or leaked connections
+	for _, stat := range connectionStats {
+		if stat.Type == syscall.SOCK_STREAM && stat.Status == "ESTABLISHED" {
+			entry.EstablishedConnectionCount++
+		}
+	}
+	contextSwitches, errContextSwitches := proc.NumCtxSwitches()
+	if errContextSwitches != nil {
+		failed = append(failed, readError{"context switches", errContextSwitches})
+	} else {
+		entry.VoluntaryContextSwitches = uint64(contextSwitches.Voluntary)
+		entry.InvoluntaryContextSwitches = uint64(contextSwitches.Involuntary)
+	}
+	limits, errLimits := proc.Rlimit()
+	if errLimits != nil {
+		failed = append(failed, readError{"file descriptor limit", errLimits})
+	}
+	entry.FileDescriptorLimit = getFileDescriptorLimit(limits)
+
+	if len(failed) == 0 {
+		return entry, true
+	}
+	// Reading fails as well, when the process exits meanwhile
+	if running, _ := proc.IsRunning(); !running {
+		return entry, false
+	}
+	for _, read := range failed {
+		generator.logUnreadable(proc.Pid, read)
+	}
+
+	return entry, true
+}
+
+// logUnreadable - Log the value of the process that can not be read. Since this repeats on each request, e. g. when samba_statusd
+// runs without the privileges of root, the error is logged the first time a value can not be read, and later on verbose only
+func (generator *PsDataGenerator) logUnreadable(pid int32, read readError) {
+	if generator.Logger == nil {
+		return
+	}
+	message := fmt.Sprintf("Can not read the %s of the process %d, the value is reported as 0: %s", read.value, pid, read.err.Error())
+
+	generator.mMutex.Lock()
+	defer generator.mMutex.Unlock()
+	if generator.unreadable[read.value] {
+		generator.Logger.WriteVerbose(message)
+		return
+	}
+	generator.unreadable[read.value] = true
+	generator.Logger.WriteErrorMessage(message)
+}
+
+// writeVerbose - Write the message verbose, when there is a Logger
+func (generator *PsDataGenerator) writeVerbose(message string) {
+	if generator.Logger != nil {
+		generator.Logger.WriteVerbose(message)
+	}
+}
+
 func (generator *PsDataGenerator) getPidList() ([]int32, error) {
 	var pidList []int32
 
@@ -120,3 +212,14 @@ func (generator *PsDataGenerator) getPidList() ([]int32, error) {
 
 	return pidList, nil
 }
+
+// getFileDescriptorLimit - Get the soft RLIMIT_NOFILE out of the limits of a process, 0 when it is not found or unlimited
+func getFileDescriptorLimit(limits []process.RlimitStat) uint64 {
+	for _, limit := range limits {
+		if limit.Resource == process.RLIMIT_NOFILE && limit.Soft != math.MaxUint64 {
+			return limit.Soft
+		}
+	}
+
+	return 0
+}
@@ -1,6 +1,11 @@
 package smbstatusdbl
 
-import "testing"
+import (
+	"math"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
 
 // Copyright 2021 by tobi@backfrak.de. All
 // rights reserved. Use of this source code is governed
@@ -70,19 +75,34 @@ func TestPsDataGeneratorRunningProcess(t *testing.T) {
 		t.Errorf("Expected not an empty list")
 	}
 
-	// Disable this tests, since the latest ubuntu kernel seems to prevent normal users
-	// from accessing /proc/<PID>/io:
-	// pidData, errData := sut.GetPsUtilPidData()
-	// if errData != nil {
-	// 	t.Errorf("Error when getting a pid data: %s", errData.Error())
-	// }
+	// The values a normal user can not read, like /proc/<PID>/io on the latest ubuntu kernel, are left 0
+	pidData, errData := sut.GetPsUtilPidData()
+	if errData != nil {
+		t.Errorf("Error when getting a pid data: %s", errData.Error())
+	}
+
+	if len(pidData) == 0 {
+		t.Errorf("Expected not an empty list")
+	}
+
+	// Processes exiting meanwhile are skipped
+	if len(pidData) > len(pidList) {
+		t.Errorf("Got '%d' data entries but '%d' pids", len(pidData), len(pidList))
+	}
+}
 
-	// if len(pidData) == 0 {
-	// 	t.Errorf("Expected not an empty list")
-	// }
+func TestGetFileDescriptorLimit(t *testing.T) {
+	limits := []process.RlimitStat{{Resource: process.RLIMIT_CPU, Soft: 10, Hard: 20}, {Resource: process.RLIMIT_NOFILE, Soft: 16384, Hard: 524288}}
+	if limit := getFileDescriptorLimit(limits); limit != 16384 {
+		t.Errorf("Got the limit %d but expected 16384", limit)
+	}
 
-	// if len(pidData) != len(pidList) {
-	// 	t.Errorf("Got '%d' data entries but '%d' pids", len(pidData), len(pidList))
-	// }
+	limits[1].Soft = math.MaxUint64
+	if limit := getFileDescriptorLimit(limits); limit != 0 {
+		t.Errorf("Got the limit %d for an unlimited process but expected 0", limit)
+	}
 
+	if limit := getFileDescriptorLimit(limits[:1]); limit != 0 {
+		t.Errorf("Got the limit %d without RLIMIT_NOFILE but expected 0", limit)
+	}
 }
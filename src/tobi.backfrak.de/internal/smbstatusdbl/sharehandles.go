@@ -0,0 +1,65 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The arguments smbstatus is called with to get the open file handles as JSON. Needs samba 4.16 or newer
+var SMBSTATUS_HANDLES_ARGUMENTS = []string{"-L", "-n", "--json"}
+
+// smbstatusOpenFilesJson - The part of the 'smbstatus -L --json' output about the open files
+type smbstatusOpenFilesJson struct {
+	OpenFiles map[string]struct {
+		ServicePath string `json:"service_path"`
+		Opens       map[string]struct {
+			// Printed by newer smbstatus versions only, false otherwise
+			Durable    bool `json:"durable"`
+			Persistent bool `json:"persistent"`
+		} `json:"opens"`
+	} `json:"open_files"`
+}
+
+// ParseSmbstatusHandles - Count the open, durable and persistent file handles per share in the output of 'smbstatus -L --json'.
+// The shares are identified by their path and sorted by it
+func ParseSmbstatusHandles(data []byte) ([]commonbl.ShareHandles, error) {
+	var output smbstatusOpenFilesJson
+	errConv := json.Unmarshal(data, &output)
+	if errConv != nil {
+		return nil, fmt.Errorf("Can not parse the JSON printed by smbstatus: %s", errConv.Error())
+	}
+
+	handlesPerShare := make(map[string]*commonbl.ShareHandles)
+	for _, file := range output.OpenFiles {
+		handles, found := handlesPerShare[file.ServicePath]
+		if !found {
+			handles = &commonbl.ShareHandles{Share: file.ServicePath}
+			handlesPerShare[file.ServicePath] = handles
+		}
+		for _, open := range file.Opens {
+			handles.OpenCount++
+			if open.Durable {
+				handles.DurableCount++
+			}
+			if open.Persistent {
+				handles.PersistentCount++
+			}
+		}
+	}
+
+	ret := []commonbl.ShareHandles{}
+	for _, handles := range handlesPerShare {
+		ret = append(ret, *handles)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Share < ret[j].Share })
+
+	return ret, nil
+}
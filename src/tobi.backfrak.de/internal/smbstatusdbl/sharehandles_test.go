@@ -0,0 +1,75 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import "testing"
+
+const testSmbstatusHandlesJson = `{
+  "timestamp": "2024-03-01T12:00:00.000000+0100",
+  "version": "4.20.1",
+  "smb_conf": "/etc/samba/smb.conf",
+  "open_files": {
+    "/srv/data/report.odt": {
+      "service_path": "/srv/data",
+      "filename": "report.odt",
+      "num_pending_deletes": 0,
+      "opens": {
+        "1117/12": {"uid": 1080, "share_file_id": "12", "durable": true, "persistent": true},
+        "1119/3": {"uid": 1081, "share_file_id": "3", "durable": true, "persistent": false}
+      }
+    },
+    "/srv/data/notes.txt": {
+      "service_path": "/srv/data",
+      "filename": "notes.txt",
+      "opens": {
+        "1117/13": {"uid": 1080, "share_file_id": "13", "durable": false}
+      }
+    },
+    "/srv/archive/2023.zip": {
+      "service_path": "/srv/archive",
+      "filename": "2023.zip",
+      "opens": {
+        "1120/1": {"uid": 1082, "share_file_id": "1"}
+      }
+    }
+  }
+}`
+
+func TestParseSmbstatusHandles(t *testing.T) {
+	handles, err := ParseSmbstatusHandles([]byte(testSmbstatusHandlesJson))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+
+	if len(handles) != 2 {
+		t.Fatalf("Got %d shares but expected 2", len(handles))
+	}
+	// The older smbstatus of the archive does not print the durable and persistent fields
+	if handles[0].Share != "/srv/archive" || handles[0].OpenCount != 1 || handles[0].DurableCount != 0 || handles[0].PersistentCount != 0 {
+		t.Errorf("The handles '%v' are not expected", handles[0])
+	}
+	if handles[1].Share != "/srv/data" || handles[1].OpenCount != 3 || handles[1].DurableCount != 2 || handles[1].PersistentCount != 1 {
+		t.Errorf("The handles '%v' are not expected", handles[1])
+	}
+}
+
+func TestParseSmbstatusHandlesNoOpenFiles(t *testing.T) {
+	handles, err := ParseSmbstatusHandles([]byte(`{"timestamp": "2024-03-01T12:00:00.000000+0100", "version": "4.20.1", "open_files": {}}`))
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(handles) != 0 {
+		t.Errorf("Got the handles '%v' but expected none", handles)
+	}
+}
+
+func TestParseSmbstatusHandlesNoJson(t *testing.T) {
+	// Older smbstatus versions do not know --json and print the locks table
+	_, err := ParseSmbstatusHandles([]byte("No locked files"))
+	if err == nil {
+		t.Errorf("Got no error for output that is no JSON")
+	}
+}
@@ -0,0 +1,92 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The maximum time 'testparm -s' may run, before it is killed
+const TESTPARM_TIMEOUT = 5 * time.Second
+
+// The section of the smb.conf with the global parameters, that is no share
+const TESTPARM_GLOBAL_SECTION = "global"
+
+// TestparmReader - Reads the shares configured in the smb.conf, by calling 'testparm -s'
+type TestparmReader struct {
+	// The path of the testparm executable
+	TestparmPath string
+	// The maximum time testparm may run
+	Timeout time.Duration
+}
+
+// NewTestparmReader - Get a new TestparmReader calling the given testparm executable
+func NewTestparmReader(testparmPath string) *TestparmReader {
+	return &TestparmReader{TestparmPath: testparmPath, Timeout: TESTPARM_TIMEOUT}
+}
+
+// GetShareConfig - Get the shares configured in the smb.conf with their parameters
+func (reader *TestparmReader) GetShareConfig() ([]commonbl.ShareConfig, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reader.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, reader.TestparmPath, "-s")
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	errRun := command.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("\"%s -s\" did not finish within %s", reader.TestparmPath, reader.Timeout)
+	}
+	if errRun != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("\"%s -s\" failed: %s: %s", reader.TestparmPath, errRun.Error(), message)
+		}
+		return nil, fmt.Errorf("\"%s -s\" failed: %s", reader.TestparmPath, errRun.Error())
+	}
+
+	return parseTestparmOutput(stdout.String()), nil
+}
+
+// parseTestparmOutput - Parse the smb.conf printed by 'testparm -s', a section like '[data]' for each share followed by its
+// parameters like 'path = /srv/data'. The [global] section is no share and skipped. The messages testparm prints to stderr are not parsed
+func parseTestparmOutput(output string) []commonbl.ShareConfig {
+	ret := []commonbl.ShareConfig{}
+	var current *commonbl.ShareConfig
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
+			current = nil
+			if strings.EqualFold(name, TESTPARM_GLOBAL_SECTION) || name == "" {
+				continue
+			}
+			ret = append(ret, commonbl.ShareConfig{Name: name, Parameters: map[string]string{}})
+			current = &ret[len(ret)-1]
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if current == nil || !found {
+			continue
+		}
+		current.Parameters[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+
+	return ret
+}
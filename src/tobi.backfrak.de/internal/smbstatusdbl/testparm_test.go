@@ -0,0 +1,70 @@
+package smbstatusdbl
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const testTestparmOutput = `# Global parameters
+[global]
+	server role = standalone server
+	workgroup = WORKGROUP
+
+[homes]
+	browseable = No
+	comment = Home Directories
+
+[data]
+	path = /srv/data
+	Read Only = No
+	vfs objects = recycle
+
+[ ]
+	path = /srv/invalid
+`
+
+func TestParseTestparmOutput(t *testing.T) {
+	shares := parseTestparmOutput(testTestparmOutput)
+
+	if len(shares) != 2 {
+		t.Fatalf("Got %d shares but expected 2", len(shares))
+	}
+	if shares[0].Name != "homes" || len(shares[0].Parameters) != 2 || shares[0].Parameters["browseable"] != "No" {
+		t.Errorf("The share '%v' is not expected", shares[0])
+	}
+	if shares[1].Name != "data" || shares[1].Parameters["read only"] != "No" || shares[1].Parameters["vfs objects"] != "recycle" {
+		t.Errorf("The share '%v' is not expected", shares[1])
+	}
+
+	if shares := parseTestparmOutput(""); len(shares) != 0 {
+		t.Errorf("Got the shares '%v', but expected none", shares)
+	}
+}
+
+func TestGetShareConfig(t *testing.T) {
+	directory := t.TempDir()
+	writeTestPlugin(t, directory, "testparm", "echo 'Load smb config files from /etc/samba/smb.conf' >&2; printf '[global]\\n\\tworkgroup = WORKGROUP\\n\\n[data]\\n\\tpath = /srv/data\\n'", 0755)
+
+	shares, err := NewTestparmReader(filepath.Join(directory, "testparm")).GetShareConfig()
+	if err != nil {
+		t.Fatalf("Got error '%s' but expected none", err.Error())
+	}
+	if len(shares) != 1 || shares[0].Name != "data" || shares[0].Parameters["path"] != "/srv/data" {
+		t.Errorf("Got the shares '%v', but expected the share 'data'", shares)
+	}
+}
+
+func TestGetShareConfigFails(t *testing.T) {
+	directory := t.TempDir()
+	writeTestPlugin(t, directory, "testparm", "echo 'Error loading services.' >&2; exit 1", 0755)
+
+	_, err := NewTestparmReader(filepath.Join(directory, "testparm")).GetShareConfig()
+	if err == nil {
+		t.Errorf("Got no error but expected one")
+	}
+}
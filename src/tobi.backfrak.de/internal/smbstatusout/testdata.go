@@ -57,6 +57,18 @@ Pid          User(ID)   DenyMode   Access      R/W        Oplock           Share
 --------------------------------------------------------------------------------------------------
 3336         1000       DENY_NONE  0x120089    RDONLY     LEASE(RWH)       /srv/shares/simple   my test file.txt   Tue Jan  2 14:13:18 2024`
 
+const LockData2LinesWithMultipleSpaces = `Locked files:
+Pid          User(ID)   DenyMode   Access      R/W        Oplock           SharePath   Name   Time
+--------------------------------------------------------------------------------------------------
+3336         1000       DENY_NONE  0x120089    RDONLY     LEASE(RWH)       /srv/shares/my share   my  test   file.txt   Tue Jan  2 14:13:18 2024
+3337         1000       DENY_WRITE 0x120089    RDWR       NONE             /srv/shares/simple   report  2024.ods   Tue Jan  2 14:15:21 2024`
+
+const LockDataNotAligned = `Locked files:
+Pid          User(ID)   DenyMode   Access      R/W        Oplock           SharePath   Name   Time
+--------------------------------------------------------------------------------------------------
+3336         1000       DENY_NONE  0x120089    RDONLY     LEASE(RWH)       /srv/shares/simple   file.txt   Tue Jan  2 14:13:18 2024
+3337 1000 DENY_WRITE 0x120089 RDWR NONE /srv/shares/simple   report.ods   Tue Jan  2 14:15:21 2024`
+
 const ShareDataOneLine = `
 Service      pid     Machine       Connected at                      Encryption   Signing     
 ---------------------------------------------------------------------------------------------
@@ -0,0 +1,161 @@
+package statusdrpc
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"strings"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+// The maximal number of lines sent in one SmbstatusOutput message, so large outputs are streamed in chunks
+const MaxLinesPerMessage = 500
+
+// SplitSmbstatusOutput - Split the output of smbstatus into messages with MaxLinesPerMessage lines at most
+func SplitSmbstatusOutput(output string) []*SmbstatusOutput {
+	var ret []*SmbstatusOutput
+	if output == "" {
+		return ret
+	}
+
+	lines := strings.Split(output, "\n")
+	for start := 0; start < len(lines); start += MaxLinesPerMessage {
+		end := start + MaxLinesPerMessage
+		if end > len(lines) {
+			end = len(lines)
+		}
+		ret = append(ret, &SmbstatusOutput{Lines: lines[start:end]})
+	}
+
+	return ret
+}
+
+// JoinSmbstatusOutput - Join the received messages to the output of smbstatus
+func JoinSmbstatusOutput(messages []*SmbstatusOutput) string {
+	var lines []string
+	for _, message := range messages {
+		lines = append(lines, message.GetLines()...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// NewPsData - Get the message for the PsUtilPidData of a process
+func NewPsData(data commonbl.PsUtilPidData) *PsData {
+	return &PsData{
+		Pid:                        data.PID,
+		CpuUsagePercent:            data.CpuUsagePercent,
+		VirtualMemoryUsageBytes:    data.VirtualMemoryUsageBytes,
+		VirtualMemoryUsagePercent:  data.VirtualMemoryUsagePercent,
+		IoCounterReadCount:         data.IoCounterReadCount,
+		IoCounterReadBytes:         data.IoCounterReadBytes,
+		IoCounterWriteCount:        data.IoCounterWriteCount,
+		IoCounterWriteBytes:        data.IoCounterWriteBytes,
+		OpenFilesCount:             data.OpenFilesCount,
+		ThreadCount:                data.ThreadCount,
+		FileDescriptorCount:        data.FileDescriptorCount,
+		EstablishedConnectionCount: data.EstablishedConnectionCount,
+		VoluntaryContextSwitches:   data.VoluntaryContextSwitches,
+		InvoluntaryContextSwitches: data.InvoluntaryContextSwitches,
+		FileDescriptorLimit:        data.FileDescriptorLimit,
+	}
+}
+
+// ToPsUtilPidData - Get the PsUtilPidData out of the message
+func (x *PsData) ToPsUtilPidData() commonbl.PsUtilPidData {
+	return commonbl.PsUtilPidData{
+		PID:                        x.GetPid(),
+		CpuUsagePercent:            x.GetCpuUsagePercent(),
+		VirtualMemoryUsageBytes:    x.GetVirtualMemoryUsageBytes(),
+		VirtualMemoryUsagePercent:  x.GetVirtualMemoryUsagePercent(),
+		IoCounterReadCount:         x.GetIoCounterReadCount(),
+		IoCounterReadBytes:         x.GetIoCounterReadBytes(),
+		IoCounterWriteCount:        x.GetIoCounterWriteCount(),
+		IoCounterWriteBytes:        x.GetIoCounterWriteBytes(),
+		OpenFilesCount:             x.GetOpenFilesCount(),
+		ThreadCount:                x.GetThreadCount(),
+		FileDescriptorCount:        x.GetFileDescriptorCount(),
+		EstablishedConnectionCount: x.GetEstablishedConnectionCount(),
+		VoluntaryContextSwitches:   x.GetVoluntaryContextSwitches(),
+		InvoluntaryContextSwitches: x.GetInvoluntaryContextSwitches(),
+		FileDescriptorLimit:        x.GetFileDescriptorLimit(),
+	}
+}
+
+// NewCgroup - Get the message for the CgroupData of a cgroup
+func NewCgroup(data commonbl.CgroupData) *Cgroup {
+	return &Cgroup{
+		Path:                data.Path,
+		CpuUsageSeconds:     data.CpuUsageSeconds,
+		CpuUserSeconds:      data.CpuUserSeconds,
+		CpuSystemSeconds:    data.CpuSystemSeconds,
+		CpuThrottledSeconds: data.CpuThrottledSeconds,
+		MemoryUsageBytes:    data.MemoryUsageBytes,
+		IoReadBytes:         data.IoReadBytes,
+		IoWriteBytes:        data.IoWriteBytes,
+		TaskCount:           data.TaskCount,
+	}
+}
+
+// ToCgroupData - Get the CgroupData out of the message
+func (x *Cgroup) ToCgroupData() commonbl.CgroupData {
+	return commonbl.CgroupData{
+		Path:                x.GetPath(),
+		CpuUsageSeconds:     x.GetCpuUsageSeconds(),
+		CpuUserSeconds:      x.GetCpuUserSeconds(),
+		CpuSystemSeconds:    x.GetCpuSystemSeconds(),
+		CpuThrottledSeconds: x.GetCpuThrottledSeconds(),
+		MemoryUsageBytes:    x.GetMemoryUsageBytes(),
+		IoReadBytes:         x.GetIoReadBytes(),
+		IoWriteBytes:        x.GetIoWriteBytes(),
+		TaskCount:           x.GetTaskCount(),
+	}
+}
+
+// NewCtdbNode - Get the message for a node of the CTDB cluster
+func NewCtdbNode(node commonbl.CtdbNode) *CtdbNode {
+	return &CtdbNode{Node: int64(node.Node), Address: node.Address}
+}
+
+// ToCtdbNode - Get the CtdbNode out of the message
+func (x *CtdbNode) ToCtdbNode() commonbl.CtdbNode {
+	return commonbl.CtdbNode{Node: int(x.GetNode()), Address: x.GetAddress()}
+}
+
+// NewShareConfig - Get the message for a share configured in the smb.conf
+func NewShareConfig(config commonbl.ShareConfig) *ShareConfig {
+	return &ShareConfig{Name: config.Name, Parameters: config.Parameters}
+}
+
+// ToShareConfig - Get the ShareConfig out of the message. The parameters are never nil, like the ones read from JSON
+func (x *ShareConfig) ToShareConfig() commonbl.ShareConfig {
+	parameters := make(map[string]string, len(x.GetParameters()))
+	for name, value := range x.GetParameters() {
+		parameters[name] = value
+	}
+
+	return commonbl.ShareConfig{Name: x.GetName(), Parameters: parameters}
+}
+
+// NewShareHandles - Get the message for the file handles of a share
+func NewShareHandles(handles commonbl.ShareHandles) *ShareHandles {
+	return &ShareHandles{
+		Share:           handles.Share,
+		OpenCount:       int64(handles.OpenCount),
+		DurableCount:    int64(handles.DurableCount),
+		PersistentCount: int64(handles.PersistentCount),
+	}
+}
+
+// ToShareHandles - Get the ShareHandles out of the message
+func (x *ShareHandles) ToShareHandles() commonbl.ShareHandles {
+	return commonbl.ShareHandles{
+		Share:           x.GetShare(),
+		OpenCount:       int(x.GetOpenCount()),
+		DurableCount:    int(x.GetDurableCount()),
+		PersistentCount: int(x.GetPersistentCount()),
+	}
+}
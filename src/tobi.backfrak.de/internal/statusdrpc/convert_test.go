@@ -0,0 +1,88 @@
+package statusdrpc
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"tobi.backfrak.de/internal/commonbl"
+)
+
+func TestSplitAndJoinSmbstatusOutput(t *testing.T) {
+	if len(SplitSmbstatusOutput("")) != 0 {
+		t.Errorf("Got messages for an empty output")
+	}
+
+	messages := SplitSmbstatusOutput(commonbl.TestLockResponse)
+	if len(messages) != 1 {
+		t.Errorf("Got '%d' messages but expected '1'", len(messages))
+	}
+	if JoinSmbstatusOutput(messages) != commonbl.TestLockResponse {
+		t.Errorf("The joined output is not the original output")
+	}
+
+	var lines []string
+	for i := 0; i < MaxLinesPerMessage*2+1; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	output := strings.Join(lines, "\n")
+	messages = SplitSmbstatusOutput(output)
+	if len(messages) != 3 {
+		t.Errorf("Got '%d' messages but expected '3'", len(messages))
+	}
+	if JoinSmbstatusOutput(messages) != output {
+		t.Errorf("The joined output is not the original output")
+	}
+}
+
+func TestPsDataConversion(t *testing.T) {
+	data := commonbl.PsUtilPidData{PID: 1234, CpuUsagePercent: 0.5, VirtualMemoryUsageBytes: 2048, VirtualMemoryUsagePercent: 1.5,
+		IoCounterReadCount: 1, IoCounterReadBytes: 2, IoCounterWriteCount: 3, IoCounterWriteBytes: 4, OpenFilesCount: 5, ThreadCount: 6, FileDescriptorCount: 7,
+		EstablishedConnectionCount: 8, VoluntaryContextSwitches: 9, InvoluntaryContextSwitches: 10, FileDescriptorLimit: 11}
+
+	converted := NewPsData(data).ToPsUtilPidData()
+	if converted != data {
+		t.Errorf("Got '%s' after the conversion but expected '%s'", converted.String(), data.String())
+	}
+}
+
+func TestCgroupConversion(t *testing.T) {
+	data := commonbl.GetTestCgroupData()[0]
+
+	converted := NewCgroup(data).ToCgroupData()
+	if converted != data {
+		t.Errorf("Got '%s' after the conversion but expected '%s'", converted.String(), data.String())
+	}
+}
+
+func TestCtdbNodeConversion(t *testing.T) {
+	node := commonbl.GetTestCtdbNodes()[1]
+
+	converted := NewCtdbNode(node).ToCtdbNode()
+	if converted != node {
+		t.Errorf("Got '%s' after the conversion but expected '%s'", converted.String(), node.String())
+	}
+}
+
+func TestShareConfigConversion(t *testing.T) {
+	for _, config := range commonbl.GetTestShareConfig() {
+		converted := NewShareConfig(config).ToShareConfig()
+		if converted.String() != config.String() || converted.Parameters == nil {
+			t.Errorf("Got '%s' after the conversion but expected '%s'", converted.String(), config.String())
+		}
+	}
+}
+
+func TestShareHandlesConversion(t *testing.T) {
+	handles := commonbl.GetTestShareHandles()[0]
+
+	converted := NewShareHandles(handles).ToShareHandles()
+	if converted != handles {
+		t.Errorf("Got '%s' after the conversion but expected '%s'", converted.String(), handles.String())
+	}
+}
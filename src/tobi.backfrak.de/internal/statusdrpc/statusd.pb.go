@@ -0,0 +1,877 @@
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+// The gRPC service samba_statusd offers to samba_exporter.
+// Regenerate the go code after changes with:
+//   protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative statusd.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v25.3.0
+// source: statusd.proto
+
+package statusdrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// StatusRequest - A request for samba_statusd
+type StatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statusd_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_statusd_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_statusd_proto_rawDescGZIP(), []int{0}
+}
+
+// SmbstatusOutput - A chunk of the lines smbstatus printed
+type SmbstatusOutput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Lines []string `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
+}
+
+func (x *SmbstatusOutput) Reset() {
+	*x = SmbstatusOutput{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statusd_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SmbstatusOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SmbstatusOutput) ProtoMessage() {}
+
+func (x *SmbstatusOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_statusd_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SmbstatusOutput.ProtoReflect.Descriptor instead.
+func (*SmbstatusOutput) Descriptor() ([]byte, []int) {
+	return file_statusd_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SmbstatusOutput) GetLines() []string {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+// PsData - The resource usage of one smbd process
+type PsData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pid                        int64   `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	CpuUsagePercent            float64 `protobuf:"fixed64,2,opt,name=cpu_usage_percent,json=cpuUsagePercent,proto3" json:"cpu_usage_percent,omitempty"`
+	VirtualMemoryUsageBytes    uint64  `protobuf:"varint,3,opt,name=virtual_memory_usage_bytes,json=virtualMemoryUsageBytes,proto3" json:"virtual_memory_usage_bytes,omitempty"`
+	VirtualMemoryUsagePercent  float64 `protobuf:"fixed64,4,opt,name=virtual_memory_usage_percent,json=virtualMemoryUsagePercent,proto3" json:"virtual_memory_usage_percent,omitempty"`
+	IoCounterReadCount         uint64  `protobuf:"varint,5,opt,name=io_counter_read_count,json=ioCounterReadCount,proto3" json:"io_counter_read_count,omitempty"`
+	IoCounterReadBytes         uint64  `protobuf:"varint,6,opt,name=io_counter_read_bytes,json=ioCounterReadBytes,proto3" json:"io_counter_read_bytes,omitempty"`
+	IoCounterWriteCount        uint64  `protobuf:"varint,7,opt,name=io_counter_write_count,json=ioCounterWriteCount,proto3" json:"io_counter_write_count,omitempty"`
+	IoCounterWriteBytes        uint64  `protobuf:"varint,8,opt,name=io_counter_write_bytes,json=ioCounterWriteBytes,proto3" json:"io_counter_write_bytes,omitempty"`
+	OpenFilesCount             uint64  `protobuf:"varint,9,opt,name=open_files_count,json=openFilesCount,proto3" json:"open_files_count,omitempty"`
+	ThreadCount                uint64  `protobuf:"varint,10,opt,name=thread_count,json=threadCount,proto3" json:"thread_count,omitempty"`
+	FileDescriptorCount        uint64  `protobuf:"varint,11,opt,name=file_descriptor_count,json=fileDescriptorCount,proto3" json:"file_descriptor_count,omitempty"`
+	EstablishedConnectionCount uint64  `protobuf:"varint,12,opt,name=established_connection_count,json=establishedConnectionCount,proto3" json:"established_connection_count,omitempty"`
+	VoluntaryContextSwitches   uint64  `protobuf:"varint,13,opt,name=voluntary_context_switches,json=voluntaryContextSwitches,proto3" json:"voluntary_context_switches,omitempty"`
+	InvoluntaryContextSwitches uint64  `protobuf:"varint,14,opt,name=involuntary_context_switches,json=involuntaryContextSwitches,proto3" json:"involuntary_context_switches,omitempty"`
+	FileDescriptorLimit        uint64  `protobuf:"varint,15,opt,name=file_descriptor_limit,json=fileDescriptorLimit,proto3" json:"file_descriptor_limit,omitempty"`
+}
+
+func (x *PsData) Reset() {
+	*x = PsData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statusd_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PsData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PsData) ProtoMessage() {}
+
+func (x *PsData) ProtoReflect() protoreflect.Message {
+	mi := &file_statusd_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PsData.ProtoReflect.Descriptor instead.
+func (*PsData) Descriptor() ([]byte, []int) {
+	return file_statusd_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PsData) GetPid() int64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *PsData) GetCpuUsagePercent() float64 {
+	if x != nil {
+		return x.CpuUsagePercent
+	}
+	return 0
+}
+
+func (x *PsData) GetVirtualMemoryUsageBytes() uint64 {
+	if x != nil {
+		return x.VirtualMemoryUsageBytes
+	}
+	return 0
+}
+
+func (x *PsData) GetVirtualMemoryUsagePercent() float64 {
+	if x != nil {
+		return x.VirtualMemoryUsagePercent
+	}
+	return 0
+}
+
+func (x *PsData) GetIoCounterReadCount() uint64 {
+	if x != nil {
+		return x.IoCounterReadCount
+	}
+	return 0
+}
+
+func (x *PsData) GetIoCounterReadBytes() uint64 {
+	if x != nil {
+		return x.IoCounterReadBytes
+	}
+	return 0
+}
+
+func (x *PsData) GetIoCounterWriteCount() uint64 {
+	if x != nil {
+		return x.IoCounterWriteCount
+	}
+	return 0
+}
+
+func (x *PsData) GetIoCounterWriteBytes() uint64 {
+	if x != nil {
+		return x.IoCounterWriteBytes
+	}
+	return 0
+}
+
+func (x *PsData) GetOpenFilesCount() uint64 {
+	if x != nil {
+		return x.OpenFilesCount
+	}
+	return 0
+}
+
+func (x *PsData) GetThreadCount() uint64 {
+	if x != nil {
+		return x.ThreadCount
+	}
+	return 0
+}
+
+func (x *PsData) GetFileDescriptorCount() uint64 {
+	if x != nil {
+		return x.FileDescriptorCount
+	}
+	return 0
+}
+
+func (x *PsData) GetEstablishedConnectionCount() uint64 {
+	if x != nil {
+		return x.EstablishedConnectionCount
+	}
+	return 0
+}
+
+func (x *PsData) GetVoluntaryContextSwitches() uint64 {
+	if x != nil {
+		return x.VoluntaryContextSwitches
+	}
+	return 0
+}
+
+func (x *PsData) GetInvoluntaryContextSwitches() uint64 {
+	if x != nil {
+		return x.InvoluntaryContextSwitches
+	}
+	return 0
+}
+
+func (x *PsData) GetFileDescriptorLimit() uint64 {
+	if x != nil {
+		return x.FileDescriptorLimit
+	}
+	return 0
+}
+
+// Cgroup - The resource usage of one cgroup samba runs in
+type Cgroup struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path                string  `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	CpuUsageSeconds     float64 `protobuf:"fixed64,2,opt,name=cpu_usage_seconds,json=cpuUsageSeconds,proto3" json:"cpu_usage_seconds,omitempty"`
+	CpuUserSeconds      float64 `protobuf:"fixed64,3,opt,name=cpu_user_seconds,json=cpuUserSeconds,proto3" json:"cpu_user_seconds,omitempty"`
+	CpuSystemSeconds    float64 `protobuf:"fixed64,4,opt,name=cpu_system_seconds,json=cpuSystemSeconds,proto3" json:"cpu_system_seconds,omitempty"`
+	CpuThrottledSeconds float64 `protobuf:"fixed64,5,opt,name=cpu_throttled_seconds,json=cpuThrottledSeconds,proto3" json:"cpu_throttled_seconds,omitempty"`
+	MemoryUsageBytes    uint64  `protobuf:"varint,6,opt,name=memory_usage_bytes,json=memoryUsageBytes,proto3" json:"memory_usage_bytes,omitempty"`
+	IoReadBytes         uint64  `protobuf:"varint,7,opt,name=io_read_bytes,json=ioReadBytes,proto3" json:"io_read_bytes,omitempty"`
+	IoWriteBytes        uint64  `protobuf:"varint,8,opt,name=io_write_bytes,json=ioWriteBytes,proto3" json:"io_write_bytes,omitempty"`
+	TaskCount           uint64  `protobuf:"varint,9,opt,name=task_count,json=taskCount,proto3" json:"task_count,omitempty"`
+}
+
+func (x *Cgroup) Reset() {
+	*x = Cgroup{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statusd_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Cgroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cgroup) ProtoMessage() {}
+
+func (x *Cgroup) ProtoReflect() protoreflect.Message {
+	mi := &file_statusd_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cgroup.ProtoReflect.Descriptor instead.
+func (*Cgroup) Descriptor() ([]byte, []int) {
+	return file_statusd_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Cgroup) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Cgroup) GetCpuUsageSeconds() float64 {
+	if x != nil {
+		return x.CpuUsageSeconds
+	}
+	return 0
+}
+
+func (x *Cgroup) GetCpuUserSeconds() float64 {
+	if x != nil {
+		return x.CpuUserSeconds
+	}
+	return 0
+}
+
+func (x *Cgroup) GetCpuSystemSeconds() float64 {
+	if x != nil {
+		return x.CpuSystemSeconds
+	}
+	return 0
+}
+
+func (x *Cgroup) GetCpuThrottledSeconds() float64 {
+	if x != nil {
+		return x.CpuThrottledSeconds
+	}
+	return 0
+}
+
+func (x *Cgroup) GetMemoryUsageBytes() uint64 {
+	if x != nil {
+		return x.MemoryUsageBytes
+	}
+	return 0
+}
+
+func (x *Cgroup) GetIoReadBytes() uint64 {
+	if x != nil {
+		return x.IoReadBytes
+	}
+	return 0
+}
+
+func (x *Cgroup) GetIoWriteBytes() uint64 {
+	if x != nil {
+		return x.IoWriteBytes
+	}
+	return 0
+}
+
+func (x *Cgroup) GetTaskCount() uint64 {
+	if x != nil {
+		return x.TaskCount
+	}
+	return 0
+}
+
+// CtdbNode - A node of the CTDB cluster
+type CtdbNode struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Node    int64  `protobuf:"varint,1,opt,name=node,proto3" json:"node,omitempty"`
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (x *CtdbNode) Reset() {
+	*x = CtdbNode{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statusd_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CtdbNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CtdbNode) ProtoMessage() {}
+
+func (x *CtdbNode) ProtoReflect() protoreflect.Message {
+	mi := &file_statusd_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CtdbNode.ProtoReflect.Descriptor instead.
+func (*CtdbNode) Descriptor() ([]byte, []int) {
+	return file_statusd_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CtdbNode) GetNode() int64 {
+	if x != nil {
+		return x.Node
+	}
+	return 0
+}
+
+func (x *CtdbNode) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+// ShareConfig - A share configured in the smb.conf, with the parameters testparm printed
+type ShareConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name       string            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Parameters map[string]string `protobuf:"bytes,2,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *ShareConfig) Reset() {
+	*x = ShareConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statusd_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShareConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareConfig) ProtoMessage() {}
+
+func (x *ShareConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_statusd_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareConfig.ProtoReflect.Descriptor instead.
+func (*ShareConfig) Descriptor() ([]byte, []int) {
+	return file_statusd_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ShareConfig) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ShareConfig) GetParameters() map[string]string {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+// ShareHandles - The number of open, durable and persistent file handles on a share
+type ShareHandles struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Share           string `protobuf:"bytes,1,opt,name=share,proto3" json:"share,omitempty"`
+	OpenCount       int64  `protobuf:"varint,2,opt,name=open_count,json=openCount,proto3" json:"open_count,omitempty"`
+	DurableCount    int64  `protobuf:"varint,3,opt,name=durable_count,json=durableCount,proto3" json:"durable_count,omitempty"`
+	PersistentCount int64  `protobuf:"varint,4,opt,name=persistent_count,json=persistentCount,proto3" json:"persistent_count,omitempty"`
+}
+
+func (x *ShareHandles) Reset() {
+	*x = ShareHandles{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statusd_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShareHandles) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareHandles) ProtoMessage() {}
+
+func (x *ShareHandles) ProtoReflect() protoreflect.Message {
+	mi := &file_statusd_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareHandles.ProtoReflect.Descriptor instead.
+func (*ShareHandles) Descriptor() ([]byte, []int) {
+	return file_statusd_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ShareHandles) GetShare() string {
+	if x != nil {
+		return x.Share
+	}
+	return ""
+}
+
+func (x *ShareHandles) GetOpenCount() int64 {
+	if x != nil {
+		return x.OpenCount
+	}
+	return 0
+}
+
+func (x *ShareHandles) GetDurableCount() int64 {
+	if x != nil {
+		return x.DurableCount
+	}
+	return 0
+}
+
+func (x *ShareHandles) GetPersistentCount() int64 {
+	if x != nil {
+		return x.PersistentCount
+	}
+	return 0
+}
+
+var File_statusd_proto protoreflect.FileDescriptor
+
+var file_statusd_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x22, 0x0f, 0x0a, 0x0d, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x27, 0x0a, 0x0f,
+	0x53, 0x6d, 0x62, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12,
+	0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05,
+	0x6c, 0x69, 0x6e, 0x65, 0x73, 0x22, 0x8b, 0x06, 0x0a, 0x06, 0x50, 0x73, 0x44, 0x61, 0x74, 0x61,
+	0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x70,
+	0x69, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x63, 0x70, 0x75, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f,
+	0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x63,
+	0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x12, 0x3b,
+	0x0a, 0x1a, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79,
+	0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x17, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4d, 0x65, 0x6d, 0x6f, 0x72,
+	0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x3f, 0x0a, 0x1c, 0x76,
+	0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x5f, 0x75, 0x73,
+	0x61, 0x67, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x19, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79,
+	0x55, 0x73, 0x61, 0x67, 0x65, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x12, 0x31, 0x0a, 0x15,
+	0x69, 0x6f, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x5f, 0x72, 0x65, 0x61, 0x64, 0x5f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12, 0x69, 0x6f, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x52, 0x65, 0x61, 0x64, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12,
+	0x31, 0x0a, 0x15, 0x69, 0x6f, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x5f, 0x72, 0x65,
+	0x61, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12,
+	0x69, 0x6f, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x52, 0x65, 0x61, 0x64, 0x42, 0x79, 0x74,
+	0x65, 0x73, 0x12, 0x33, 0x0a, 0x16, 0x69, 0x6f, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72,
+	0x5f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x13, 0x69, 0x6f, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x65, 0x72, 0x57, 0x72, 0x69,
+	0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x33, 0x0a, 0x16, 0x69, 0x6f, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x65, 0x72, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x13, 0x69, 0x6f, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x65, 0x72, 0x57, 0x72, 0x69, 0x74, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x10,
+	0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x6f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65,
+	0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x68, 0x72, 0x65, 0x61, 0x64,
+	0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x74, 0x68,
+	0x72, 0x65, 0x61, 0x64, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x32, 0x0a, 0x15, 0x66, 0x69, 0x6c,
+	0x65, 0x5f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x5f, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x13, 0x66, 0x69, 0x6c, 0x65, 0x44, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x40, 0x0a,
+	0x1c, 0x65, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x1a, 0x65, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x64,
+	0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12,
+	0x3c, 0x0a, 0x1a, 0x76, 0x6f, 0x6c, 0x75, 0x6e, 0x74, 0x61, 0x72, 0x79, 0x5f, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x78, 0x74, 0x5f, 0x73, 0x77, 0x69, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x18, 0x76, 0x6f, 0x6c, 0x75, 0x6e, 0x74, 0x61, 0x72, 0x79, 0x43, 0x6f,
+	0x6e, 0x74, 0x65, 0x78, 0x74, 0x53, 0x77, 0x69, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12, 0x40, 0x0a,
+	0x1c, 0x69, 0x6e, 0x76, 0x6f, 0x6c, 0x75, 0x6e, 0x74, 0x61, 0x72, 0x79, 0x5f, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x78, 0x74, 0x5f, 0x73, 0x77, 0x69, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x0e, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x1a, 0x69, 0x6e, 0x76, 0x6f, 0x6c, 0x75, 0x6e, 0x74, 0x61, 0x72, 0x79,
+	0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x53, 0x77, 0x69, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12,
+	0x32, 0x0a, 0x15, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x6f, 0x72, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x04, 0x52, 0x13,
+	0x66, 0x69, 0x6c, 0x65, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x4c, 0x69,
+	0x6d, 0x69, 0x74, 0x22, 0xeb, 0x02, 0x0a, 0x06, 0x43, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x12,
+	0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61,
+	0x74, 0x68, 0x12, 0x2a, 0x0a, 0x11, 0x63, 0x70, 0x75, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f,
+	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x63,
+	0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x28,
+	0x0a, 0x10, 0x63, 0x70, 0x75, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0e, 0x63, 0x70, 0x75, 0x55, 0x73, 0x65,
+	0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x2c, 0x0a, 0x12, 0x63, 0x70, 0x75, 0x5f,
+	0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x10, 0x63, 0x70, 0x75, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x32, 0x0a, 0x15, 0x63, 0x70, 0x75, 0x5f, 0x74, 0x68,
+	0x72, 0x6f, 0x74, 0x74, 0x6c, 0x65, 0x64, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x13, 0x63, 0x70, 0x75, 0x54, 0x68, 0x72, 0x6f, 0x74, 0x74,
+	0x6c, 0x65, 0x64, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x2c, 0x0a, 0x12, 0x6d, 0x65,
+	0x6d, 0x6f, 0x72, 0x79, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x55, 0x73,
+	0x61, 0x67, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x69, 0x6f, 0x5f, 0x72,
+	0x65, 0x61, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0b, 0x69, 0x6f, 0x52, 0x65, 0x61, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x24, 0x0a, 0x0e,
+	0x69, 0x6f, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x69, 0x6f, 0x57, 0x72, 0x69, 0x74, 0x65, 0x42, 0x79, 0x74,
+	0x65, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x74, 0x61, 0x73, 0x6b, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x22, 0x38, 0x0a, 0x08, 0x43, 0x74, 0x64, 0x62, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6e, 0x6f, 0x64,
+	0x65, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0xa9, 0x01, 0x0a, 0x0b,
+	0x53, 0x68, 0x61, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x47, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63,
+	0x2e, 0x53, 0x68, 0x61, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x50, 0x61, 0x72,
+	0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x70, 0x61,
+	0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x1a, 0x3d, 0x0a, 0x0f, 0x50, 0x61, 0x72, 0x61,
+	0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x93, 0x01, 0x0a, 0x0c, 0x53, 0x68, 0x61, 0x72,
+	0x65, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x68, 0x61, 0x72,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x68, 0x61, 0x72, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x6f, 0x70, 0x65, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x23, 0x0a,
+	0x0d, 0x64, 0x75, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x64, 0x75, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x65, 0x72, 0x73, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x74,
+	0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x70, 0x65,
+	0x72, 0x73, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x32, 0xb6, 0x04,
+	0x0a, 0x0b, 0x53, 0x61, 0x6d, 0x62, 0x61, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x44, 0x0a,
+	0x08, 0x47, 0x65, 0x74, 0x4c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x19, 0x2e, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70,
+	0x63, 0x2e, 0x53, 0x6d, 0x62, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x4f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x30, 0x01, 0x12, 0x45, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x53, 0x68, 0x61, 0x72, 0x65, 0x73,
+	0x12, 0x19, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x6d, 0x62, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x30, 0x01, 0x12, 0x48, 0x0a, 0x0c, 0x47, 0x65,
+	0x74, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x19, 0x2e, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72,
+	0x70, 0x63, 0x2e, 0x53, 0x6d, 0x62, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x4f, 0x75, 0x74, 0x70,
+	0x75, 0x74, 0x30, 0x01, 0x12, 0x3c, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x50, 0x73, 0x44, 0x61, 0x74,
+	0x61, 0x12, 0x19, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x73, 0x44, 0x61, 0x74, 0x61,
+	0x30, 0x01, 0x12, 0x3d, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x43, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x73,
+	0x12, 0x19, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x30,
+	0x01, 0x12, 0x41, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x43, 0x74, 0x64, 0x62, 0x4e, 0x6f, 0x64, 0x65,
+	0x73, 0x12, 0x19, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x74, 0x64, 0x62, 0x4e, 0x6f,
+	0x64, 0x65, 0x30, 0x01, 0x12, 0x46, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x53, 0x68, 0x61, 0x72, 0x65,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x19, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64,
+	0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x17, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53,
+	0x68, 0x61, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x30, 0x01, 0x12, 0x48, 0x0a, 0x0f,
+	0x47, 0x65, 0x74, 0x53, 0x68, 0x61, 0x72, 0x65, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x12,
+	0x19, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x68, 0x61, 0x72, 0x65, 0x48, 0x61, 0x6e,
+	0x64, 0x6c, 0x65, 0x73, 0x30, 0x01, 0x42, 0x26, 0x5a, 0x24, 0x74, 0x6f, 0x62, 0x69, 0x2e, 0x62,
+	0x61, 0x63, 0x6b, 0x66, 0x72, 0x61, 0x6b, 0x2e, 0x64, 0x65, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x6e, 0x61, 0x6c, 0x2f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x64, 0x72, 0x70, 0x63, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_statusd_proto_rawDescOnce sync.Once
+	file_statusd_proto_rawDescData = file_statusd_proto_rawDesc
+)
+
+func file_statusd_proto_rawDescGZIP() []byte {
+	file_statusd_proto_rawDescOnce.Do(func() {
+		file_statusd_proto_rawDescData = protoimpl.X.CompressGZIP(file_statusd_proto_rawDescData)
+	})
+	return file_statusd_proto_rawDescData
+}
+
+var file_statusd_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_statusd_proto_goTypes = []any{
+	(*StatusRequest)(nil),   // 0: statusdrpc.StatusRequest
+	(*SmbstatusOutput)(nil), // 1: statusdrpc.SmbstatusOutput
+	(*PsData)(nil),          // 2: statusdrpc.PsData
+	(*Cgroup)(nil),          // 3: statusdrpc.Cgroup
+	(*CtdbNode)(nil),        // 4: statusdrpc.CtdbNode
+	(*ShareConfig)(nil),     // 5: statusdrpc.ShareConfig
+	(*ShareHandles)(nil),    // 6: statusdrpc.ShareHandles
+	nil,                     // 7: statusdrpc.ShareConfig.ParametersEntry
+}
+var file_statusd_proto_depIdxs = []int32{
+	7, // 0: statusdrpc.ShareConfig.parameters:type_name -> statusdrpc.ShareConfig.ParametersEntry
+	0, // 1: statusdrpc.SambaStatus.GetLocks:input_type -> statusdrpc.StatusRequest
+	0, // 2: statusdrpc.SambaStatus.GetShares:input_type -> statusdrpc.StatusRequest
+	0, // 3: statusdrpc.SambaStatus.GetProcesses:input_type -> statusdrpc.StatusRequest
+	0, // 4: statusdrpc.SambaStatus.GetPsData:input_type -> statusdrpc.StatusRequest
+	0, // 5: statusdrpc.SambaStatus.GetCgroups:input_type -> statusdrpc.StatusRequest
+	0, // 6: statusdrpc.SambaStatus.GetCtdbNodes:input_type -> statusdrpc.StatusRequest
+	0, // 7: statusdrpc.SambaStatus.GetShareConfig:input_type -> statusdrpc.StatusRequest
+	0, // 8: statusdrpc.SambaStatus.GetShareHandles:input_type -> statusdrpc.StatusRequest
+	1, // 9: statusdrpc.SambaStatus.GetLocks:output_type -> statusdrpc.SmbstatusOutput
+	1, // 10: statusdrpc.SambaStatus.GetShares:output_type -> statusdrpc.SmbstatusOutput
+	1, // 11: statusdrpc.SambaStatus.GetProcesses:output_type -> statusdrpc.SmbstatusOutput
+	2, // 12: statusdrpc.SambaStatus.GetPsData:output_type -> statusdrpc.PsData
+	3, // 13: statusdrpc.SambaStatus.GetCgroups:output_type -> statusdrpc.Cgroup
+	4, // 14: statusdrpc.SambaStatus.GetCtdbNodes:output_type -> statusdrpc.CtdbNode
+	5, // 15: statusdrpc.SambaStatus.GetShareConfig:output_type -> statusdrpc.ShareConfig
+	6, // 16: statusdrpc.SambaStatus.GetShareHandles:output_type -> statusdrpc.ShareHandles
+	9, // [9:17] is the sub-list for method output_type
+	1, // [1:9] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_statusd_proto_init() }
+func file_statusd_proto_init() {
+	if File_statusd_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_statusd_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*StatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_statusd_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*SmbstatusOutput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_statusd_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*PsData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_statusd_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Cgroup); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_statusd_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*CtdbNode); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_statusd_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ShareConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_statusd_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ShareHandles); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_statusd_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_statusd_proto_goTypes,
+		DependencyIndexes: file_statusd_proto_depIdxs,
+		MessageInfos:      file_statusd_proto_msgTypes,
+	}.Build()
+	File_statusd_proto = out.File
+	file_statusd_proto_rawDesc = nil
+	file_statusd_proto_goTypes = nil
+	file_statusd_proto_depIdxs = nil
+}
@@ -0,0 +1,609 @@
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+// The gRPC service samba_statusd offers to samba_exporter.
+// Regenerate the go code after changes with:
+//   protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative statusd.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v25.3.0
+// source: statusd.proto
+
+package statusdrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	SambaStatus_GetLocks_FullMethodName        = "/statusdrpc.SambaStatus/GetLocks"
+	SambaStatus_GetShares_FullMethodName       = "/statusdrpc.SambaStatus/GetShares"
+	SambaStatus_GetProcesses_FullMethodName    = "/statusdrpc.SambaStatus/GetProcesses"
+	SambaStatus_GetPsData_FullMethodName       = "/statusdrpc.SambaStatus/GetPsData"
+	SambaStatus_GetCgroups_FullMethodName      = "/statusdrpc.SambaStatus/GetCgroups"
+	SambaStatus_GetCtdbNodes_FullMethodName    = "/statusdrpc.SambaStatus/GetCtdbNodes"
+	SambaStatus_GetShareConfig_FullMethodName  = "/statusdrpc.SambaStatus/GetShareConfig"
+	SambaStatus_GetShareHandles_FullMethodName = "/statusdrpc.SambaStatus/GetShareHandles"
+)
+
+// SambaStatusClient is the client API for SambaStatus service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SambaStatusClient interface {
+	// GetLocks - The output of 'smbstatus -L -n'
+	GetLocks(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetLocksClient, error)
+	// GetShares - The output of 'smbstatus -S -n'
+	GetShares(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetSharesClient, error)
+	// GetProcesses - The output of 'smbstatus -p -n'
+	GetProcesses(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetProcessesClient, error)
+	// GetPsData - The resource usage of the smbd processes
+	GetPsData(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetPsDataClient, error)
+	// GetCgroups - The resource usage of the cgroups samba runs in
+	GetCgroups(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetCgroupsClient, error)
+	// GetCtdbNodes - The nodes of the CTDB cluster samba runs in
+	GetCtdbNodes(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetCtdbNodesClient, error)
+	// GetShareConfig - The shares configured in the smb.conf
+	GetShareConfig(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetShareConfigClient, error)
+	// GetShareHandles - The open, durable and persistent file handles of each share
+	GetShareHandles(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetShareHandlesClient, error)
+}
+
+type sambaStatusClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSambaStatusClient(cc grpc.ClientConnInterface) SambaStatusClient {
+	return &sambaStatusClient{cc}
+}
+
+func (c *sambaStatusClient) GetLocks(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetLocksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SambaStatus_ServiceDesc.Streams[0], SambaStatus_GetLocks_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sambaStatusGetLocksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SambaStatus_GetLocksClient interface {
+	Recv() (*SmbstatusOutput, error)
+	grpc.ClientStream
+}
+
+type sambaStatusGetLocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *sambaStatusGetLocksClient) Recv() (*SmbstatusOutput, error) {
+	m := new(SmbstatusOutput)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sambaStatusClient) GetShares(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetSharesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SambaStatus_ServiceDesc.Streams[1], SambaStatus_GetShares_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sambaStatusGetSharesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SambaStatus_GetSharesClient interface {
+	Recv() (*SmbstatusOutput, error)
+	grpc.ClientStream
+}
+
+type sambaStatusGetSharesClient struct {
+	grpc.ClientStream
+}
+
+func (x *sambaStatusGetSharesClient) Recv() (*SmbstatusOutput, error) {
+	m := new(SmbstatusOutput)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sambaStatusClient) GetProcesses(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetProcessesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SambaStatus_ServiceDesc.Streams[2], SambaStatus_GetProcesses_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sambaStatusGetProcessesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SambaStatus_GetProcessesClient interface {
+	Recv() (*SmbstatusOutput, error)
+	grpc.ClientStream
+}
+
+type sambaStatusGetProcessesClient struct {
+	grpc.ClientStream
+}
+
+func (x *sambaStatusGetProcessesClient) Recv() (*SmbstatusOutput, error) {
+	m := new(SmbstatusOutput)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sambaStatusClient) GetPsData(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetPsDataClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SambaStatus_ServiceDesc.Streams[3], SambaStatus_GetPsData_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sambaStatusGetPsDataClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SambaStatus_GetPsDataClient interface {
+	Recv() (*PsData, error)
+	grpc.ClientStream
+}
+
+type sambaStatusGetPsDataClient struct {
+	grpc.ClientStream
+}
+
+func (x *sambaStatusGetPsDataClient) Recv() (*PsData, error) {
+	m := new(PsData)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sambaStatusClient) GetCgroups(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetCgroupsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SambaStatus_ServiceDesc.Streams[4], SambaStatus_GetCgroups_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sambaStatusGetCgroupsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SambaStatus_GetCgroupsClient interface {
+	Recv() (*Cgroup, error)
+	grpc.ClientStream
+}
+
+type sambaStatusGetCgroupsClient struct {
+	grpc.ClientStream
+}
+
+func (x *sambaStatusGetCgroupsClient) Recv() (*Cgroup, error) {
+	m := new(Cgroup)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sambaStatusClient) GetCtdbNodes(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetCtdbNodesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SambaStatus_ServiceDesc.Streams[5], SambaStatus_GetCtdbNodes_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sambaStatusGetCtdbNodesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SambaStatus_GetCtdbNodesClient interface {
+	Recv() (*CtdbNode, error)
+	grpc.ClientStream
+}
+
+type sambaStatusGetCtdbNodesClient struct {
+	grpc.ClientStream
+}
+
+func (x *sambaStatusGetCtdbNodesClient) Recv() (*CtdbNode, error) {
+	m := new(CtdbNode)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sambaStatusClient) GetShareConfig(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetShareConfigClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SambaStatus_ServiceDesc.Streams[6], SambaStatus_GetShareConfig_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sambaStatusGetShareConfigClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SambaStatus_GetShareConfigClient interface {
+	Recv() (*ShareConfig, error)
+	grpc.ClientStream
+}
+
+type sambaStatusGetShareConfigClient struct {
+	grpc.ClientStream
+}
+
+func (x *sambaStatusGetShareConfigClient) Recv() (*ShareConfig, error) {
+	m := new(ShareConfig)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sambaStatusClient) GetShareHandles(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (SambaStatus_GetShareHandlesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SambaStatus_ServiceDesc.Streams[7], SambaStatus_GetShareHandles_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sambaStatusGetShareHandlesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SambaStatus_GetShareHandlesClient interface {
+	Recv() (*ShareHandles, error)
+	grpc.ClientStream
+}
+
+type sambaStatusGetShareHandlesClient struct {
+	grpc.ClientStream
+}
+
+func (x *sambaStatusGetShareHandlesClient) Recv() (*ShareHandles, error) {
+	m := new(ShareHandles)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SambaStatusServer is the server API for SambaStatus service.
+// All implementations must embed UnimplementedSambaStatusServer
+// for forward compatibility
+type SambaStatusServer interface {
+	// GetLocks - The output of 'smbstatus -L -n'
+	GetLocks(*StatusRequest, SambaStatus_GetLocksServer) error
+	// GetShares - The output of 'smbstatus -S -n'
+	GetShares(*StatusRequest, SambaStatus_GetSharesServer) error
+	// GetProcesses - The output of 'smbstatus -p -n'
+	GetProcesses(*StatusRequest, SambaStatus_GetProcessesServer) error
+	// GetPsData - The resource usage of the smbd processes
+	GetPsData(*StatusRequest, SambaStatus_GetPsDataServer) error
+	// GetCgroups - The resource usage of the cgroups samba runs in
+	GetCgroups(*StatusRequest, SambaStatus_GetCgroupsServer) error
+	// GetCtdbNodes - The nodes of the CTDB cluster samba runs in
+	GetCtdbNodes(*StatusRequest, SambaStatus_GetCtdbNodesServer) error
+	// GetShareConfig - The shares configured in the smb.conf
+	GetShareConfig(*StatusRequest, SambaStatus_GetShareConfigServer) error
+	// GetShareHandles - The open, durable and persistent file handles of each share
+	GetShareHandles(*StatusRequest, SambaStatus_GetShareHandlesServer) error
+	mustEmbedUnimplementedSambaStatusServer()
+}
+
+// UnimplementedSambaStatusServer must be embedded to have forward compatible implementations.
+type UnimplementedSambaStatusServer struct {
+}
+
+func (UnimplementedSambaStatusServer) GetLocks(*StatusRequest, SambaStatus_GetLocksServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetLocks not implemented")
+}
+func (UnimplementedSambaStatusServer) GetShares(*StatusRequest, SambaStatus_GetSharesServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetShares not implemented")
+}
+func (UnimplementedSambaStatusServer) GetProcesses(*StatusRequest, SambaStatus_GetProcessesServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetProcesses not implemented")
+}
+func (UnimplementedSambaStatusServer) GetPsData(*StatusRequest, SambaStatus_GetPsDataServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetPsData not implemented")
+}
+func (UnimplementedSambaStatusServer) GetCgroups(*StatusRequest, SambaStatus_GetCgroupsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetCgroups not implemented")
+}
+func (UnimplementedSambaStatusServer) GetCtdbNodes(*StatusRequest, SambaStatus_GetCtdbNodesServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetCtdbNodes not implemented")
+}
+func (UnimplementedSambaStatusServer) GetShareConfig(*StatusRequest, SambaStatus_GetShareConfigServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetShareConfig not implemented")
+}
+func (UnimplementedSambaStatusServer) GetShareHandles(*StatusRequest, SambaStatus_GetShareHandlesServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetShareHandles not implemented")
+}
+func (UnimplementedSambaStatusServer) mustEmbedUnimplementedSambaStatusServer() {}
+
+// UnsafeSambaStatusServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SambaStatusServer will
+// result in compilation errors.
+type UnsafeSambaStatusServer interface {
+	mustEmbedUnimplementedSambaStatusServer()
+}
+
+func RegisterSambaStatusServer(s grpc.ServiceRegistrar, srv SambaStatusServer) {
+	s.RegisterService(&SambaStatus_ServiceDesc, srv)
+}
+
+func _SambaStatus_GetLocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SambaStatusServer).GetLocks(m, &sambaStatusGetLocksServer{stream})
+}
+
+type SambaStatus_GetLocksServer interface {
+	Send(*SmbstatusOutput) error
+	grpc.ServerStream
+}
+
+type sambaStatusGetLocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *sambaStatusGetLocksServer) Send(m *SmbstatusOutput) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SambaStatus_GetShares_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SambaStatusServer).GetShares(m, &sambaStatusGetSharesServer{stream})
+}
+
+type SambaStatus_GetSharesServer interface {
+	Send(*SmbstatusOutput) error
+	grpc.ServerStream
+}
+
+type sambaStatusGetSharesServer struct {
+	grpc.ServerStream
+}
+
+func (x *sambaStatusGetSharesServer) Send(m *SmbstatusOutput) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SambaStatus_GetProcesses_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SambaStatusServer).GetProcesses(m, &sambaStatusGetProcessesServer{stream})
+}
+
+type SambaStatus_GetProcessesServer interface {
+	Send(*SmbstatusOutput) error
+	grpc.ServerStream
+}
+
+type sambaStatusGetProcessesServer struct {
+	grpc.ServerStream
+}
+
+func (x *sambaStatusGetProcessesServer) Send(m *SmbstatusOutput) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SambaStatus_GetPsData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SambaStatusServer).GetPsData(m, &sambaStatusGetPsDataServer{stream})
+}
+
+type SambaStatus_GetPsDataServer interface {
+	Send(*PsData) error
+	grpc.ServerStream
+}
+
+type sambaStatusGetPsDataServer struct {
+	grpc.ServerStream
+}
+
+func (x *sambaStatusGetPsDataServer) Send(m *PsData) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SambaStatus_GetCgroups_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SambaStatusServer).GetCgroups(m, &sambaStatusGetCgroupsServer{stream})
+}
+
+type SambaStatus_GetCgroupsServer interface {
+	Send(*Cgroup) error
+	grpc.ServerStream
+}
+
+type sambaStatusGetCgroupsServer struct {
+	grpc.ServerStream
+}
+
+func (x *sambaStatusGetCgroupsServer) Send(m *Cgroup) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SambaStatus_GetCtdbNodes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SambaStatusServer).GetCtdbNodes(m, &sambaStatusGetCtdbNodesServer{stream})
+}
+
+type SambaStatus_GetCtdbNodesServer interface {
+	Send(*CtdbNode) error
+	grpc.ServerStream
+}
+
+type sambaStatusGetCtdbNodesServer struct {
+	grpc.ServerStream
+}
+
+func (x *sambaStatusGetCtdbNodesServer) Send(m *CtdbNode) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SambaStatus_GetShareConfig_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SambaStatusServer).GetShareConfig(m, &sambaStatusGetShareConfigServer{stream})
+}
+
+type SambaStatus_GetShareConfigServer interface {
+	Send(*ShareConfig) error
+	grpc.ServerStream
+}
+
+type sambaStatusGetShareConfigServer struct {
+	grpc.ServerStream
+}
+
+func (x *sambaStatusGetShareConfigServer) Send(m *ShareConfig) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SambaStatus_GetShareHandles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SambaStatusServer).GetShareHandles(m, &sambaStatusGetShareHandlesServer{stream})
+}
+
+type SambaStatus_GetShareHandlesServer interface {
+	Send(*ShareHandles) error
+	grpc.ServerStream
+}
+
+type sambaStatusGetShareHandlesServer struct {
+	grpc.ServerStream
+}
+
+func (x *sambaStatusGetShareHandlesServer) Send(m *ShareHandles) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// SambaStatus_ServiceDesc is the grpc.ServiceDesc for SambaStatus service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SambaStatus_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "statusdrpc.SambaStatus",
+	HandlerType: (*SambaStatusServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetLocks",
+			Handler:       _SambaStatus_GetLocks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetShares",
+			Handler:       _SambaStatus_GetShares_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetProcesses",
+			Handler:       _SambaStatus_GetProcesses_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetPsData",
+			Handler:       _SambaStatus_GetPsData_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetCgroups",
+			Handler:       _SambaStatus_GetCgroups_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetCtdbNodes",
+			Handler:       _SambaStatus_GetCtdbNodes_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetShareConfig",
+			Handler:       _SambaStatus_GetShareConfig_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetShareHandles",
+			Handler:       _SambaStatus_GetShareHandles_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "statusd.proto",
+}
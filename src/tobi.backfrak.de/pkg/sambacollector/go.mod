@@ -0,0 +1,54 @@
+module tobi.backfrak.de/pkg/sambacollector
+
+go 1.21
+
+require tobi.backfrak.de/internal/commonbl v0.0.0
+
+replace tobi.backfrak.de/internal/commonbl v0.0.0 => ../../internal/commonbl
+
+require tobi.backfrak.de/internal/smbexporterbl/pipecomunication v0.0.0
+
+replace tobi.backfrak.de/internal/smbexporterbl/pipecomunication v0.0.0 => ../../internal/smbexporterbl/pipecomunication
+
+require tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator v0.0.0
+
+replace tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator v0.0.0 => ../../internal/smbexporterbl/statisticsGenerator
+
+require tobi.backfrak.de/internal/smbexporterbl/smbstatusreader v0.0.0
+
+replace tobi.backfrak.de/internal/smbexporterbl/smbstatusreader v0.0.0 => ../../internal/smbexporterbl/smbstatusreader
+
+require tobi.backfrak.de/internal/smbexporterbl/smbexporter v0.0.0
+
+replace tobi.backfrak.de/internal/smbexporterbl/smbexporter v0.0.0 => ../../internal/smbexporterbl/smbexporter
+
+replace tobi.backfrak.de/internal/smbstatusout v0.0.0 => ../../internal/smbstatusout
+
+require tobi.backfrak.de/internal/testhelper v0.0.0
+
+replace tobi.backfrak.de/internal/testhelper v0.0.0 => ../../internal/testhelper
+
+require tobi.backfrak.de/internal/statusdrpc v0.0.0
+
+replace tobi.backfrak.de/internal/statusdrpc v0.0.0 => ../../internal/statusdrpc
+
+require github.com/prometheus/client_golang v1.19.0
+
+require google.golang.org/grpc v1.62.1
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/golang/snappy v0.0.4 // indirect
+	github.com/oschwald/maxminddb-golang v1.12.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
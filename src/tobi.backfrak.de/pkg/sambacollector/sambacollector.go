@@ -0,0 +1,137 @@
+// Package sambacollector - Collect the metrics of samba_exporter in other Go programs. The Collector requests the samba
+// status from samba_statusd like samba_exporter does, and can be registered in the prometheus registry of the program.
+package sambacollector
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"tobi.backfrak.de/internal/commonbl"
+	"tobi.backfrak.de/internal/smbexporterbl/pipecomunication"
+	"tobi.backfrak.de/internal/smbexporterbl/smbexporter"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/statusdrpc"
+)
+
+// DEFAULT_REQUEST_TIMEOUT - The seconds to wait for a response of samba_statusd, when the Options give no RequestTimeout
+const DEFAULT_REQUEST_TIMEOUT = 5
+
+// Settings - Which metrics are exported and which labels they have, like with the parameters of samba_exporter
+type Settings = statisticsGenerator.StatisticsGeneratorSettings
+
+// Logger - Writes the messages of the Collector. Implement it to use the logging of the program, or use NewSlogLogger
+type Logger = commonbl.Logger
+
+// Options - How the Collector connects to samba_statusd and which metrics it exports
+type Options struct {
+	// The address of a samba_statusd started with -tcp.listen-address or -grpc.listen-address. When empty, the named pipes are used
+	StatusdAddress string
+	// Use the gRPC service of samba_statusd on the StatusdAddress
+	Grpc bool
+	// The TLS configuration for the connection to the StatusdAddress, nil for an unencrypted connection
+	TLSConfig *tls.Config
+	// The directory of the named pipes. When empty, the default of samba_statusd is used
+	PipeDirectory string
+	// The seconds to wait for a response of samba_statusd. When 0, DEFAULT_REQUEST_TIMEOUT is used
+	RequestTimeout int
+	// How often a request that timed out is sent again
+	RequestRetries int
+	// The time to wait before the first retry of a request, it doubles with each further retry
+	RequestRetryBackoff time.Duration
+	// The version in the samba_exporter_information metric. When empty, the metric is not exported
+	Version string
+	// The settings of the metrics. The zero value exports all metrics
+	Settings Settings
+	// Labels with constant values added to every metric
+	ConstLabels map[string]string
+	// The logger for errors and verbose messages, e. g. of NewSlogLogger. When nil, the errors are written to stderr
+	Logger Logger
+	// The memory in bytes the program should not exceed. A scrape is aborted, when parsing the response of samba_statusd
+	// is estimated to exceed it. When 0, no limit is checked
+	MemoryLimit int64
+	// The maximum size of a response of samba_statusd in bytes, larger responses are discarded. When 0, the responses are not limited
+	MaxResponseSize int
+	// When set, the entries of smbd processes that are not running anymore are dropped
+	DropDeadEntries bool
+}
+
+// NewSlogLogger - Get a Logger writing the messages of the Collector with the log/slog Logger. Verbose messages are written with the
+// debug level. When the logger is nil, slog.Default() is used
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return commonbl.NewSlogLogger(logger)
+}
+
+// Collector - A prometheus collector exporting the samba metrics. Registering it does not request samba_statusd,
+// when samba_statusd is not reachable the error is logged and the samba_statusd_up metric is 0
+type Collector struct {
+	*smbexporter.Collector
+	grpcConn *grpc.ClientConn
+}
+
+// New - Get a Collector requesting samba_statusd as given in the options. Close it when it is not used any longer
+func New(options Options) (*Collector, error) {
+	logger := options.Logger
+	if logger == nil {
+		logger = commonbl.NewConsoleLogger(false)
+	}
+	timeout := options.RequestTimeout
+	if timeout <= 0 {
+		timeout = DEFAULT_REQUEST_TIMEOUT
+	}
+
+	var requestHandler, responseHandler commonbl.MessageHandler
+	var grpcConn *grpc.ClientConn
+	var grpcClient statusdrpc.SambaStatusClient
+	if options.Grpc {
+		if options.StatusdAddress == "" {
+			return nil, fmt.Errorf("the gRPC service of samba_statusd needs the StatusdAddress")
+		}
+		conn, client, errGrpc := pipecomunication.NewGrpcClient(options.StatusdAddress, options.TLSConfig)
+		if errGrpc != nil {
+			return nil, errGrpc
+		}
+		grpcConn = conn
+		grpcClient = client
+	} else if options.StatusdAddress != "" {
+		handler := commonbl.NewTcpClientHandler(options.StatusdAddress, options.TLSConfig)
+		handler.MaxMessageSize = options.MaxResponseSize
+		handler.DialTimeout = time.Duration(timeout) * time.Second
+		requestHandler = handler
+		responseHandler = handler
+	} else {
+		pipeSettings := commonbl.NewDefaultPipeSettings()
+		pipeSettings.Directory = options.PipeDirectory
+		requestHandler = commonbl.NewPipeHandlerWithSettings(false, commonbl.RequestPipe, pipeSettings)
+		pipeHandler := commonbl.NewPipeHandlerWithSettings(false, commonbl.ResposePipe, pipeSettings)
+		pipeHandler.MaxMessageSize = options.MaxResponseSize
+		responseHandler = pipeHandler
+	}
+
+	exporter := smbexporter.NewSambaExporter(requestHandler, responseHandler, logger, options.Version, timeout, options.Settings)
+	exporter.GrpcClient = grpcClient
+	exporter.RequestRetries = options.RequestRetries
+	exporter.RequestRetryBackoff = options.RequestRetryBackoff
+	exporter.ConstLabels = options.ConstLabels
+	exporter.MemoryLimit = options.MemoryLimit
+	exporter.MaxResponseSize = options.MaxResponseSize
+	exporter.DropDeadEntries = options.DropDeadEntries
+
+	return &Collector{Collector: smbexporter.NewCollector(exporter), grpcConn: grpcConn}, nil
+}
+
+// Close - Close the connection to the gRPC service of samba_statusd
+func (collector *Collector) Close() error {
+	if collector.grpcConn == nil {
+		return nil
+	}
+
+	return collector.grpcConn.Close()
+}
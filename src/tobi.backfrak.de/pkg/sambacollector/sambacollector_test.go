@@ -0,0 +1,94 @@
+package sambacollector
+
+// Copyright 2024 by tobi@backfrak.de. All
+// rights reserved. Use of this source code is governed
+// by a BSD-style license that can be found in the
+// LICENSE file.
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"tobi.backfrak.de/internal/smbexporterbl/statisticsGenerator"
+	"tobi.backfrak.de/internal/testhelper"
+)
+
+// getMetricValue - Gather the registry and get the value of the gauge with the name, false when it was not gathered
+func getMetricValue(t *testing.T, registry *prometheus.Registry, name string) (float64, bool) {
+	families, errGather := registry.Gather()
+	if errGather != nil {
+		t.Fatalf("Got error '%s' but expected none", errGather.Error())
+	}
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue(), true
+		}
+	}
+
+	return 0, false
+}
+
+func TestNewWithoutStatusd(t *testing.T) {
+	for _, grpc := range []bool{false, true} {
+		collector, errNew := New(Options{StatusdAddress: "127.0.0.1:1", Grpc: grpc, RequestTimeout: 1, Logger: testhelper.NewTestLogger(true)})
+		if errNew != nil {
+			t.Fatalf("Got error '%s' but expected none", errNew.Error())
+		}
+		defer collector.Close()
+
+		registry := prometheus.NewRegistry()
+		errRegister := registry.Register(collector)
+		if errRegister != nil {
+			t.Fatalf("Got error '%s' but expected none", errRegister.Error())
+		}
+
+		value, found := getMetricValue(t, registry, "samba_statusd_up")
+		if !found || value != 0 {
+			t.Errorf("Got samba_statusd_up %f, but expected 0 with gRPC %t", value, grpc)
+		}
+	}
+}
+
+func TestNewWithSettings(t *testing.T) {
+	settings := Settings{DisabledCollectors: []string{statisticsGenerator.COLLECTOR_PSDATA}}
+	collector, errNew := New(Options{StatusdAddress: "127.0.0.1:1", RequestTimeout: 1, Version: "1.0.0", Settings: settings,
+		ConstLabels: map[string]string{"cluster": "a"}, Logger: testhelper.NewTestLogger(true)})
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, _ := registry.Gather()
+	for _, family := range families {
+		if family.GetName() == "samba_exporter_information" && family.GetMetric()[0].GetLabel()[0].GetValue() != "a" {
+			t.Errorf("The metric %v has not the constant label", family)
+		}
+	}
+}
+
+func TestNewGrpcWithoutAddress(t *testing.T) {
+	_, errNew := New(Options{Grpc: true})
+	if errNew == nil {
+		t.Errorf("Got no error, but expected one")
+	}
+}
+
+func TestNewWithSlogLogger(t *testing.T) {
+	var output bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&output, nil)))
+	collector, errNew := New(Options{StatusdAddress: "127.0.0.1:1", RequestTimeout: 1, Logger: logger})
+	if errNew != nil {
+		t.Fatalf("Got error '%s' but expected none", errNew.Error())
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	registry.Gather()
+	if !strings.Contains(output.String(), "level=ERROR") {
+		t.Errorf("Got the output '%s', but expected the error about the unreachable samba_statusd", output.String())
+	}
+}
@@ -222,6 +222,7 @@ assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_client_count 1\
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_share_count 2\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_individual_user_count 1\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_server_information\"" 0
+assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_version_info\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_pid_count 1\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_smbd_sum_virtual_memory_usage_percent\"" 0
 
@@ -309,8 +310,8 @@ curl http://127.0.0.1:9922/metrics > "$tmp_dir/samba_exporter.curl.metrics.4.log
 samba_exporter_no_pid_curl_lines=$(wc -l $tmp_dir/samba_exporter.curl.metrics.4.log| awk '{print $1}' )
 echo "$tmp_dir/samba_exporter.curl.metrics.1.log has $samba_exporter_normal_curl_lines lines"
 echo "$tmp_dir/samba_exporter.curl.metrics.4.log has $samba_exporter_no_client_curl_lines lines"
-assert_raises "cat $tmp_dir/samba_exporter.curl.metrics.1.log | grep \"smbd_io_counter_write_count\"" 0
-assert_raises "cat $tmp_dir/samba_exporter.curl.metrics.4.log | grep \"smbd_io_counter_write_count\"" 1
+assert_raises "cat $tmp_dir/samba_exporter.curl.metrics.1.log | grep \"smbd_io_counter_write_count_total\"" 0
+assert_raises "cat $tmp_dir/samba_exporter.curl.metrics.4.log | grep \"smbd_io_counter_write_count_total\"" 1
 
 
 assert_raises " [ $samba_exporter_normal_curl_lines == $samba_exporter_no_client_curl_lines ] " 1
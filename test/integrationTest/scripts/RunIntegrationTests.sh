@@ -157,8 +157,8 @@ assert_raises "$samba_exporter -test-mode -verbose -test-pipe | grep \"samba_cli
 assert_raises "$samba_exporter -test-mode -verbose -test-pipe -not-expose-client-data | grep \"samba_client_connected_since_seconds\"" 1
 assert_raises "$samba_exporter -test-mode -verbose -test-pipe | grep \"samba_protocol_version_count\"" 0
 assert_raises "$samba_exporter -test-mode -verbose -test-pipe -not-expose-encryption-data | grep \"samba_protocol_version_count\"" 1
-assert_raises "$samba_exporter -test-mode -verbose -test-pipe | grep \"smbd_io_counter_write_count\"" 0
-assert_raises "$samba_exporter -test-mode -verbose -test-pipe -not-expose-pid-data | grep \"smbd_io_counter_write_count\"" 1
+assert_raises "$samba_exporter -test-mode -verbose -test-pipe | grep \"smbd_io_counter_write_count_total\"" 0
+assert_raises "$samba_exporter -test-mode -verbose -test-pipe -not-expose-pid-data | grep \"smbd_io_counter_write_count_total\"" 1
 assert_raises "$samba_exporter -test-mode -verbose -test-pipe -not-expose-share-details | grep \"samba_lock_created_since_seconds\"" 1
 assert_raises "$samba_exporter -test-mode -verbose -test-pipe -not-expose-share-details | grep \"locks_per_share_count\"" 1
 
@@ -259,11 +259,15 @@ echo "Test Web Interface"
 echo "# ###################################################################"
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_server_up 1\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_satutsd_up 1\"" 0
+assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_statusd_up 1\"" 0
+assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_scrape_errors_total 0\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"/usr/share/data\"" 0
 assert_raises "curl http://127.0.0.1:9922 | grep \"<p><a href='/metrics'>Metrics</a></p>\"" 0
 assert_raises "curl http://127.0.0.1:9922 | grep \"<head><title>Samba Exporter</title></head>\"" 0 
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_exporter_information\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_server_information\"" 0
+assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_version_info\"" 0
+assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_unique_user_count\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"samba_process_per_client_count\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"SMB3_11\"" 0
 assert_raises "curl http://127.0.0.1:9922/metrics | grep \"4.11.6-Ubuntu\"" 0